@@ -11,6 +11,7 @@ import (
 	"time"
 
 	"apple-price/internal/model"
+	"apple-price/internal/store"
 
 	_ "github.com/mattn/go-sqlite3"
 )
@@ -22,6 +23,7 @@ func main() {
 	dryRun := flag.Bool("dry-run", false, "Show what would be done without making changes")
 	force := flag.Bool("force", false, "Force overwrite existing SQLite database")
 	versionFlag := flag.Bool("version", false, "Show version information")
+	createToken := flag.String("create-token", "", "Create an admin API token with the given name and exit")
 	flag.Parse()
 
 	if *versionFlag {
@@ -29,6 +31,11 @@ func main() {
 		return
 	}
 
+	if *createToken != "" {
+		runCreateToken(*dataDir, *createToken)
+		return
+	}
+
 	fmt.Printf("=== ApplePrice 数据迁移工具 v%s ===\n\n", version)
 
 	// Verify data directory exists
@@ -180,6 +187,24 @@ func main() {
 }
 
 // createSQLiteDB creates the database and runs migrations
+// runCreateToken creates an admin API token in the SQLite database and prints it once
+func runCreateToken(dataDir, name string) {
+	s, err := store.NewSQLite(dataDir)
+	if err != nil {
+		fmt.Printf("错误: 无法打开 SQLite 数据库: %v\n", err)
+		os.Exit(1)
+	}
+	defer s.Close()
+
+	token, err := s.CreateAPIToken(name)
+	if err != nil {
+		fmt.Printf("错误: 无法创建令牌: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("已创建管理令牌 %q (仅显示一次，请妥善保存):\n%s\n", name, token)
+}
+
 func createSQLiteDB(dbPath string) (*sql.DB, error) {
 	// Ensure directory exists
 	if err := os.MkdirAll(filepath.Dir(dbPath), 0755); err != nil {
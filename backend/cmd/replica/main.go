@@ -0,0 +1,236 @@
+// Command replica runs a read-only mirror of another ApplePrice instance: it pulls
+// catalog changes from the upstream's GET /api/replicate into a local SQLite
+// database and serves the regular API from it, without scraping Apple itself.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"apple-price/internal/api"
+	"apple-price/internal/currency"
+	"apple-price/internal/imagecache"
+	"apple-price/internal/lifecycle"
+	"apple-price/internal/logging"
+	"apple-price/internal/model"
+	"apple-price/internal/scraper"
+	"apple-price/internal/store"
+
+	"github.com/gin-gonic/gin"
+)
+
+const version = "1.0.0"
+
+func main() {
+	upstream := flag.String("upstream", os.Getenv("REPLICA_UPSTREAM"), "Base URL of the instance to mirror, e.g. https://apple-price.example.com")
+	dataDir := flag.String("dir", "./data", "Data directory for the local SQLite database")
+	port := flag.String("port", "8080", "Port to serve the read-only API on")
+	interval := flag.Duration("interval", 5*time.Minute, "How often to pull changes from upstream")
+	imageCacheMB := flag.Int("image-cache-mb", imagecache.DefaultMaxBytes>>20, "Size limit of the product image cache in MB (0 = redirect to Apple's images)")
+	versionFlag := flag.Bool("version", false, "Show version information")
+	flag.Parse()
+
+	if *versionFlag {
+		fmt.Printf("replica version %s\n", version)
+		return
+	}
+
+	logging.Setup(os.Getenv("LOG_LEVEL"), os.Getenv("LOG_FORMAT"))
+
+	base, err := url.Parse(strings.TrimRight(*upstream, "/"))
+	if err != nil || (base.Scheme != "http" && base.Scheme != "https") || base.Host == "" {
+		fmt.Println("错误: 请通过 -upstream 或 REPLICA_UPSTREAM 指定上游实例地址 (http:// 或 https://)")
+		os.Exit(1)
+	}
+
+	st, err := store.NewSQLite(*dataDir)
+	if err != nil {
+		fmt.Printf("错误: 无法打开 SQLite 数据库: %v\n", err)
+		os.Exit(1)
+	}
+
+	guard := store.NewStorageGuard(*dataDir, store.DefaultMinFreeBytes, nil)
+	guard.Start(time.Minute)
+
+	r := &replicator{
+		upstream: base.String(),
+		store:    st,
+		guard:    guard,
+		client:   &http.Client{Timeout: 2 * time.Minute},
+		stopCh:   make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	go r.run(*interval)
+
+	// Ready once the database answers and the first pull has landed; on
+	// SIGTERM/SIGINT drain requests, stop pulling and checkpoint the WAL
+	life := lifecycle.New(lifecycle.DefaultShutdownTimeout)
+	life.AddCheck("store", st.Ping)
+	life.AddCheck("replication", r.ready)
+	life.OnShutdown("replication", r.stop)
+	life.OnShutdown("storage guard", func(ctx context.Context) error { guard.Stop(); return nil })
+	life.OnShutdown("checkpoint", func(ctx context.Context) error { return st.Save() })
+	life.OnShutdown("store", func(ctx context.Context) error { return st.Close() })
+
+	engine := gin.New()
+	engine.Use(gin.Recovery())
+	// No dispatcher or scheduler: a mirror neither notifies nor scrapes, and the
+	// admin API stays disabled without a token. Reads go through the in-memory
+	// catalog cache, which reloads after each applied batch. Exchange rates come
+	// from the default provider.
+	rates := currency.NewConverter(currency.NewHTTPProvider(currency.DefaultProviderURL), 12*time.Hour)
+	api.SetupRoutes(engine, api.RouteDeps{
+		Store:     store.NewCachedStore(st),
+		Storage:   &replicaStorage{guard: guard, upstream: base.String()},
+		Currency:  rates,
+		Readiness: life,
+		Images:    openImageCache(*dataDir, *imageCacheMB),
+	})
+
+	slog.Info("Replica serving read-only API", "upstream", base.String(), "port", *port, "interval", *interval)
+	if err := life.Run(&http.Server{Addr: ":" + *port, Handler: engine}); err != nil {
+		os.Exit(1)
+	}
+}
+
+// openImageCache opens the product image cache under dataDir, nil when disabled
+// or unavailable. Images are downloaded from Apple directly, the upstream
+// doesn't serve them.
+func openImageCache(dataDir string, maxMB int) api.ImageCache {
+	if maxMB <= 0 {
+		return nil
+	}
+	userAgent := os.Getenv("SCRAPER_USER_AGENT")
+	if userAgent == "" {
+		userAgent = "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36"
+	}
+	images, err := imagecache.New(filepath.Join(dataDir, "images"), int64(maxMB)<<20, imagecache.DefaultTTL, scraper.NewClient(userAgent))
+	if err != nil {
+		slog.Warn("Image cache disabled", "error", err)
+		return nil
+	}
+	return images
+}
+
+// replicator pulls catalog changes from the upstream instance into the local store
+type replicator struct {
+	upstream string
+	store    *store.SQLiteStore
+	guard    *store.StorageGuard
+	client   *http.Client
+	since    int64 // until of the last applied batch; 0 resyncs everything
+	synced   atomic.Bool
+	stopCh   chan struct{}
+	stopOnce sync.Once
+	done     chan struct{} // closed when run returns
+}
+
+// run syncs immediately and then every interval. The cursor lives in memory, so a
+// restart starts with a full resync, which replaces rather than duplicates data.
+func (r *replicator) run(interval time.Duration) {
+	defer close(r.done)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := r.sync(); err != nil {
+			slog.Warn("Replication pull failed", "upstream", r.upstream, "since", r.since, "error", err)
+		}
+		select {
+		case <-ticker.C:
+		case <-r.stopCh:
+			return
+		}
+	}
+}
+
+// stop ends run and waits for the pull in progress, if any, or for ctx to be done
+func (r *replicator) stop(ctx context.Context) error {
+	r.stopOnce.Do(func() { close(r.stopCh) })
+
+	select {
+	case <-r.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// ready returns why the mirror has nothing to serve yet: no pull has succeeded
+func (r *replicator) ready() error {
+	if !r.synced.Load() {
+		return errors.New("no changes pulled from upstream yet")
+	}
+	return nil
+}
+
+// sync fetches and applies one batch of changes
+func (r *replicator) sync() error {
+	if r.guard.IsReadOnly() {
+		return fmt.Errorf("local storage is not writable: %s", r.guard.Status().Reason)
+	}
+
+	resp, err := r.client.Get(r.upstream + "/api/replicate?since=" + strconv.FormatInt(r.since, 10))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("upstream returned status %d", resp.StatusCode)
+	}
+
+	var batch model.ReplicationBatch
+	if err := json.NewDecoder(resp.Body).Decode(&batch); err != nil {
+		return fmt.Errorf("failed to decode batch: %w", err)
+	}
+	if batch.Until < r.since {
+		return fmt.Errorf("upstream clock went backwards (until %d < since %d)", batch.Until, r.since)
+	}
+
+	if err := r.store.ApplyReplication(&batch); err != nil {
+		return err
+	}
+	r.since = batch.Until
+	r.synced.Store(true)
+
+	slog.Info("Replicated changes from upstream",
+		"products", len(batch.Products), "price_history", len(batch.PriceHistory),
+		"events", len(batch.Events), "listed", len(batch.ProductIDs), "until", batch.Until)
+	return nil
+}
+
+// replicaStorage keeps the API read-only: everything written locally comes from upstream
+type replicaStorage struct {
+	guard    *store.StorageGuard
+	upstream string
+}
+
+// IsReadOnly always refuses writes
+func (s *replicaStorage) IsReadOnly() bool {
+	return true
+}
+
+// Status reports the data directory status, read-only because this is a mirror
+func (s *replicaStorage) Status() model.StorageStatus {
+	status := s.guard.Status()
+	if !status.ReadOnly {
+		status.ReadOnly = true
+		status.Reason = "mirror of " + s.upstream
+	}
+	return status
+}
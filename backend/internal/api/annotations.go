@@ -0,0 +1,124 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"apple-price/internal/model"
+
+	"github.com/gin-gonic/gin"
+)
+
+// annotationDateLayout is the format of PriceAnnotation.Date
+const annotationDateLayout = "2006-01-02"
+
+// CreateAnnotation adds a dated note to price charts
+// POST /api/admin/annotations
+func (h *Handlers) CreateAnnotation(c *gin.Context) {
+	var req struct {
+		Date        string `json:"date" binding:"required"` // YYYY-MM-DD
+		Label       string `json:"label" binding:"required"`
+		Description string `json:"description"`
+		Category    string `json:"category"`
+		Region      string `json:"region"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if _, err := time.Parse(annotationDateLayout, req.Date); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "date must be YYYY-MM-DD"})
+		return
+	}
+	label := strings.TrimSpace(req.Label)
+	if label == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "label is required"})
+		return
+	}
+
+	annotation := &model.PriceAnnotation{
+		ID:          generateID(),
+		Date:        req.Date,
+		Label:       label,
+		Description: strings.TrimSpace(req.Description),
+		Category:    req.Category,
+		Region:      req.Region,
+		CreatedAt:   time.Now(),
+	}
+
+	if err := h.store.AddAnnotation(annotation); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create annotation"})
+		return
+	}
+
+	if err := h.store.Save(); err != nil {
+		requestLogger(c).Error("Failed to save data", "error", err)
+	}
+
+	c.JSON(http.StatusCreated, annotation)
+}
+
+// DeleteAnnotation removes a price chart annotation
+// DELETE /api/admin/annotations/:id
+func (h *Handlers) DeleteAnnotation(c *gin.Context) {
+	id := c.Param("id")
+	if err := h.store.DeleteAnnotation(id); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "annotation not found"})
+		return
+	}
+
+	if err := h.store.Save(); err != nil {
+		requestLogger(c).Error("Failed to save data", "error", err)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "annotation deleted"})
+}
+
+// GetAnnotations lists annotations, optionally limited to a date range and category/region
+// GET /api/annotations?from=2025-01-01&to=2025-12-31&category=Mac&region=cn
+func (h *Handlers) GetAnnotations(c *gin.Context) {
+	from, to := c.Query("from"), c.Query("to")
+	for _, d := range []string{from, to} {
+		if d == "" {
+			continue
+		}
+		if _, err := time.Parse(annotationDateLayout, d); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "from/to must be YYYY-MM-DD"})
+			return
+		}
+	}
+
+	// Catalog-wide annotations (no category/region) match every filter
+	category, region := c.Query("category"), c.Query("region")
+	annotations := []model.PriceAnnotation{}
+	for _, a := range h.store.GetAnnotations(from, to) {
+		if (category == "" || a.Category == "" || a.Category == category) &&
+			(region == "" || a.Region == "" || a.Region == region) {
+			annotations = append(annotations, a)
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"count":       len(annotations),
+		"annotations": annotations,
+	})
+}
+
+// annotationsFor returns the annotations relevant to a product within the span of its history
+func (h *Handlers) annotationsFor(product *model.Product, history []model.PriceHistory) []model.PriceAnnotation {
+	annotations := []model.PriceAnnotation{}
+	if len(history) == 0 {
+		return annotations
+	}
+
+	from := history[0].Timestamp.Format(annotationDateLayout)
+	to := time.Now().Format(annotationDateLayout)
+	for _, a := range h.store.GetAnnotations(from, to) {
+		if a.AppliesTo(product) {
+			annotations = append(annotations, a)
+		}
+	}
+	return annotations
+}
@@ -0,0 +1,86 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ArchiveProductsByRegion hides all products of a region from listings instead of
+// deleting them, so their price history stays available for analysis
+// POST /api/admin/products/region/:region/archive
+func (h *Handlers) ArchiveProductsByRegion(c *gin.Context) {
+	region := strings.ToLower(c.Param("region"))
+
+	count, err := h.store.ArchiveProductsByRegion(region)
+	if err != nil {
+		requestLogger(c).Error("Failed to archive products", "region", region, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to archive products"})
+		return
+	}
+
+	if err := h.store.Save(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save data"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": fmt.Sprintf("Archived %d products from region %s", count, region),
+		"count":   count,
+	})
+}
+
+// GetArchivedProducts lists archived products, optionally of one region
+// GET /api/admin/products/archived[?region=hk]
+func (h *Handlers) GetArchivedProducts(c *gin.Context) {
+	products := h.store.GetArchivedProducts(strings.ToLower(c.Query("region")))
+	c.JSON(http.StatusOK, gin.H{
+		"count":    len(products),
+		"products": products,
+	})
+}
+
+// RestoreProducts brings archived products back into listings: those listed in
+// ids, or every archived product of region
+// POST /api/admin/products/restore
+func (h *Handlers) RestoreProducts(c *gin.Context) {
+	var req struct {
+		IDs    []string `json:"ids"`
+		Region string   `json:"region"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	region := strings.ToLower(strings.TrimSpace(req.Region))
+	if len(req.IDs) == 0 && region == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ids or region is required"})
+		return
+	}
+
+	ids := req.IDs
+	if len(ids) == 0 {
+		for _, p := range h.store.GetArchivedProducts(region) {
+			ids = append(ids, p.ID)
+		}
+	}
+
+	count, err := h.store.RestoreProducts(ids)
+	if err != nil {
+		requestLogger(c).Error("Failed to restore products", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to restore products"})
+		return
+	}
+
+	if err := h.store.Save(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save data"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": fmt.Sprintf("Restored %d products", count),
+		"count":   count,
+	})
+}
@@ -0,0 +1,65 @@
+package api
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TokenValidator validates admin API tokens stored outside the environment
+// (e.g. the api_tokens table in SQLite)
+type TokenValidator interface {
+	ValidateAPIToken(token string) bool
+}
+
+// AdminAuth returns a middleware that requires a valid admin token.
+// Tokens are read from "Authorization: Bearer <token>" or the X-Admin-Token header
+// and checked against adminToken (ADMIN_TOKEN) first, then against validator.
+// When neither source is configured, admin routes are refused rather than left open.
+func AdminAuth(adminToken string, validator TokenValidator) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if adminToken == "" && validator == nil {
+			abortAuth(c, http.StatusForbidden, "admin_disabled", "admin API is disabled: set ADMIN_TOKEN or create an API token")
+			return
+		}
+
+		token := extractToken(c)
+		if token == "" {
+			c.Header("WWW-Authenticate", `Bearer realm="admin"`)
+			abortAuth(c, http.StatusUnauthorized, "missing_token", "admin token is required")
+			return
+		}
+
+		if adminToken != "" && subtle.ConstantTimeCompare([]byte(token), []byte(adminToken)) == 1 {
+			c.Next()
+			return
+		}
+
+		if validator != nil && validator.ValidateAPIToken(token) {
+			c.Next()
+			return
+		}
+
+		abortAuth(c, http.StatusForbidden, "invalid_token", "admin token is invalid or revoked")
+	}
+}
+
+// extractToken reads the admin token from request headers
+func extractToken(c *gin.Context) string {
+	if auth := c.GetHeader("Authorization"); auth != "" {
+		if len(auth) > 7 && strings.EqualFold(auth[:7], "Bearer ") {
+			return strings.TrimSpace(auth[7:])
+		}
+	}
+	return strings.TrimSpace(c.GetHeader("X-Admin-Token"))
+}
+
+// abortAuth stops the request with a structured auth error
+func abortAuth(c *gin.Context, status int, code, message string) {
+	c.AbortWithStatusJSON(status, gin.H{
+		"error": message,
+		"code":  code,
+	})
+}
@@ -0,0 +1,48 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+
+	"apple-price/internal/model"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ValidateBarkKey checks a Bark Key against the Bark server with a silent push.
+// Rejected keys still answer 200 with valid=false and Bark's reason; 502 means
+// the Bark server could not be asked.
+// POST /api/bark/validate
+func (h *Handlers) ValidateBarkKey(c *gin.Context) {
+	var req struct {
+		BarkKey    string `json:"bark_key" binding:"required"`
+		BarkServer string `json:"bark_server"` // Self-hosted Bark server (empty = default)
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if h.dispatcher == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "notification service not available"})
+		return
+	}
+
+	barkServer, err := model.NormalizeBarkServer(req.BarkServer)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	barkKey := strings.TrimSpace(req.BarkKey)
+	result, err := h.dispatcher.ValidateBarkKey(barkKey, barkServer)
+	if err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"error": localize(c, "bark_unreachable"), "detail": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"bark_key":   maskBarkKey(barkKey),
+		"validation": result,
+	})
+}
@@ -0,0 +1,143 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxCachedResponses bounds the response cache; product list URLs vary by query
+const maxCachedResponses = 256
+
+// cachedResponse is a serialized product response and its validators
+type cachedResponse struct {
+	version      uint64 // store ProductsVersion the body was built from
+	body         []byte
+	etag         string
+	lastModified time.Time
+}
+
+// responseCache keeps serialized product responses until the catalog changes,
+// so polling clients get 304s or cached bytes instead of a fresh serialization
+type responseCache struct {
+	mu      sync.Mutex
+	entries map[string]*cachedResponse // request key -> response
+}
+
+func newResponseCache() *responseCache {
+	return &responseCache{entries: make(map[string]*cachedResponse)}
+}
+
+// get returns the entry for key if it was built from the given catalog version
+func (rc *responseCache) get(key string, version uint64) *cachedResponse {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	entry, ok := rc.entries[key]
+	if !ok || entry.version != version {
+		return nil
+	}
+	return entry
+}
+
+// put stores an entry. Last-Modified always moves forward for a key, even when
+// the catalog changes twice within a second.
+func (rc *responseCache) put(key string, entry *cachedResponse) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	if old, ok := rc.entries[key]; ok && !entry.lastModified.After(old.lastModified) {
+		entry.lastModified = old.lastModified.Add(time.Second)
+	}
+
+	if _, ok := rc.entries[key]; !ok && len(rc.entries) >= maxCachedResponses {
+		// Drop entries of older catalog versions first, everything if that isn't enough
+		for k, e := range rc.entries {
+			if e.version != entry.version {
+				delete(rc.entries, k)
+			}
+		}
+		if len(rc.entries) >= maxCachedResponses {
+			rc.entries = make(map[string]*cachedResponse)
+		}
+	}
+	rc.entries[key] = entry
+}
+
+// serveProductResponse answers a product read from the response cache, honouring
+// If-None-Match and If-Modified-Since. build produces the status and body on a
+// miss; only 200 responses are cached. The ETag hashes the key, the last scrape
+// time, the number of products and the catalog version.
+func (h *Handlers) serveProductResponse(c *gin.Context, key string, build func() (status int, body any, count int)) {
+	version := h.store.ProductsVersion()
+
+	entry := h.cache.get(key, version)
+	if entry == nil {
+		status, body, count := build()
+		if status != http.StatusOK {
+			c.JSON(status, body)
+			return
+		}
+
+		data, err := json.Marshal(body)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		var scraped time.Time
+		if st, ok := h.store.(ScrapeTimeReader); ok {
+			scraped = st.GetLastScrapeTime()
+		}
+		hash := fnv.New64a()
+		fmt.Fprintf(hash, "%s|%d|%d|%d", key, scraped.UnixNano(), count, version)
+
+		entry = &cachedResponse{
+			version:      version,
+			body:         data,
+			etag:         fmt.Sprintf(`"%x"`, hash.Sum64()),
+			lastModified: time.Now().UTC().Truncate(time.Second),
+		}
+		h.cache.put(key, entry)
+	}
+
+	c.Header("Cache-Control", "no-cache")
+	c.Header("ETag", entry.etag)
+	c.Header("Last-Modified", entry.lastModified.Format(http.TimeFormat))
+
+	if notModified(c.Request, entry) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+	c.Data(http.StatusOK, "application/json; charset=utf-8", entry.body)
+}
+
+// notModified evaluates the conditional request headers; If-None-Match wins over
+// If-Modified-Since when both are sent
+func notModified(r *http.Request, entry *cachedResponse) bool {
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		return etagMatches(inm, entry.etag)
+	}
+	if ims := r.Header.Get("If-Modified-Since"); ims != "" {
+		t, err := http.ParseTime(ims)
+		return err == nil && !entry.lastModified.After(t)
+	}
+	return false
+}
+
+// etagMatches reports whether an If-None-Match header lists etag (weak comparison)
+func etagMatches(header, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || candidate == etag || candidate == "W/"+etag {
+			return true
+		}
+	}
+	return false
+}
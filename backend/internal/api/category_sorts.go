@@ -0,0 +1,73 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+
+	"apple-price/internal/model"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetCategoryDefaultSorts lists the default product sort configured per category
+// GET /api/admin/category-sorts
+func (h *Handlers) GetCategoryDefaultSorts(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"defaults": h.store.GetCategoryDefaultSorts(),
+		"options":  model.DefaultSortOptions(),
+	})
+}
+
+// SetCategoryDefaultSort sets the sort GET /api/products uses for a category when
+// the client doesn't pass one, e.g. newest first for fast-moving Mac restocks
+// PUT /api/admin/category-sorts/:category
+func (h *Handlers) SetCategoryDefaultSort(c *gin.Context) {
+	category := strings.TrimSpace(c.Param("category"))
+
+	var req struct {
+		Sort string `json:"sort" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !model.ValidDefaultSort(req.Sort) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "invalid sort",
+			"options": model.DefaultSortOptions(),
+		})
+		return
+	}
+
+	if err := h.store.SetCategoryDefaultSort(category, req.Sort); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save default sort"})
+		return
+	}
+
+	if err := h.store.Save(); err != nil {
+		requestLogger(c).Error("Failed to save data", "error", err)
+	}
+
+	requestLogger(c).Info("Category default sort set", "category", category, "sort", req.Sort)
+
+	c.JSON(http.StatusOK, gin.H{"category": category, "sort": req.Sort})
+}
+
+// DeleteCategoryDefaultSort reverts a category to the built-in default (score)
+// DELETE /api/admin/category-sorts/:category
+func (h *Handlers) DeleteCategoryDefaultSort(c *gin.Context) {
+	category := c.Param("category")
+
+	if err := h.store.SetCategoryDefaultSort(category, ""); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to remove default sort"})
+		return
+	}
+
+	if err := h.store.Save(); err != nil {
+		requestLogger(c).Error("Failed to save data", "error", err)
+	}
+
+	requestLogger(c).Info("Category default sort removed", "category", category)
+
+	c.JSON(http.StatusOK, gin.H{"message": "default sort removed", "category": category})
+}
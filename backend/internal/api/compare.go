@@ -0,0 +1,193 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"apple-price/internal/model"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxCompareProducts limits how many products can be compared side by side
+const maxCompareProducts = 4
+
+// ComparisonEntry is one column of the comparison table, with specs already parsed
+type ComparisonEntry struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Category    string `json:"category"`
+	Region      string `json:"region"`
+	Model       string `json:"model"`
+	Chip        string `json:"chip"`
+	Memory      string `json:"memory"`
+	Storage     string `json:"storage"`
+	ScreenSize  string `json:"screen_size"`
+	Color       string `json:"color"`
+	StockStatus string `json:"stock_status"`
+	Grade       string `json:"grade,omitempty"`
+	Warranty    int    `json:"warranty_months,omitempty"`
+	Battery     int    `json:"battery_health,omitempty"`
+	ImageURL    string `json:"image_url"`
+	ProductURL  string `json:"product_url"`
+
+	Price         float64 `json:"price"`
+	OriginalPrice float64 `json:"original_price"`
+	Savings       float64 `json:"savings"`         // original_price - price
+	SavingsPct    float64 `json:"savings_percent"` // savings as % of original price
+	ValueScore    float64 `json:"value_score"`
+	LowestPrice   float64 `json:"lowest_price"`
+	PriceTrend    string  `json:"price_trend"`
+}
+
+// ComparisonResponse is the side-by-side comparison of several products
+type ComparisonResponse struct {
+	Products    []ComparisonEntry `json:"products"`
+	Differences []string          `json:"differences"` // fields whose values differ between products
+	Best        map[string]string `json:"best"`        // lowest_price, best_value, biggest_savings -> product ID
+}
+
+// CompareProducts returns a normalized comparison for GET /api/products/compare?ids=a,b,c
+func (h *Handlers) CompareProducts(c *gin.Context) {
+	var ids []string
+	seen := make(map[string]bool)
+	for _, id := range strings.Split(c.Query("ids"), ",") {
+		id = strings.TrimSpace(id)
+		if id == "" || seen[id] {
+			continue
+		}
+		seen[id] = true
+		ids = append(ids, id)
+	}
+
+	if len(ids) < 2 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "at least 2 product ids are required"})
+		return
+	}
+	if len(ids) > maxCompareProducts {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "too many products to compare", "max": maxCompareProducts})
+		return
+	}
+
+	var entries []ComparisonEntry
+	var missing []string
+	for _, id := range ids {
+		p, ok := h.store.GetProduct(id)
+		if !ok {
+			missing = append(missing, id)
+			continue
+		}
+		entries = append(entries, newComparisonEntry(p))
+	}
+
+	if len(missing) > 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "product not found", "missing": missing})
+		return
+	}
+
+	c.JSON(http.StatusOK, ComparisonResponse{
+		Products:    entries,
+		Differences: comparisonDifferences(entries),
+		Best:        comparisonBest(entries),
+	})
+}
+
+// newComparisonEntry flattens a product and its SpecsDetail into a comparison column
+func newComparisonEntry(p *model.Product) ComparisonEntry {
+	var specs model.ParsedSpecs
+	if p.SpecsDetail != "" {
+		_ = json.Unmarshal([]byte(p.SpecsDetail), &specs)
+	}
+
+	entry := ComparisonEntry{
+		ID:            p.ID,
+		Name:          p.Name,
+		Category:      p.Category,
+		Region:        p.Region,
+		Model:         model.ModelFromName(p.Name, p.Category),
+		Chip:          specs.Chip,
+		Memory:        specs.Memory,
+		Storage:       specs.Storage,
+		ScreenSize:    specs.ScreenSize,
+		Color:         specs.Color,
+		StockStatus:   p.StockStatus,
+		Grade:         p.Grade,
+		Warranty:      p.WarrantyMonths,
+		Battery:       p.BatteryHealth,
+		ImageURL:      p.ImageURL,
+		ProductURL:    p.ProductURL,
+		Price:         p.Price,
+		OriginalPrice: p.OriginalPrice,
+		ValueScore:    p.ValueScore,
+		LowestPrice:   p.LowestPrice,
+		PriceTrend:    p.PriceTrend,
+	}
+
+	if p.OriginalPrice > p.Price {
+		entry.Savings = p.OriginalPrice - p.Price
+		entry.SavingsPct = entry.Savings / p.OriginalPrice * 100
+	}
+
+	return entry
+}
+
+// comparisonDifferences lists the spec and price fields that are not identical across entries
+func comparisonDifferences(entries []ComparisonEntry) []string {
+	fields := []struct {
+		name  string
+		value func(e ComparisonEntry) any
+	}{
+		{"model", func(e ComparisonEntry) any { return e.Model }},
+		{"chip", func(e ComparisonEntry) any { return e.Chip }},
+		{"memory", func(e ComparisonEntry) any { return e.Memory }},
+		{"storage", func(e ComparisonEntry) any { return e.Storage }},
+		{"screen_size", func(e ComparisonEntry) any { return e.ScreenSize }},
+		{"color", func(e ComparisonEntry) any { return e.Color }},
+		{"price", func(e ComparisonEntry) any { return e.Price }},
+		{"savings", func(e ComparisonEntry) any { return e.Savings }},
+		{"value_score", func(e ComparisonEntry) any { return e.ValueScore }},
+		{"price_trend", func(e ComparisonEntry) any { return e.PriceTrend }},
+		{"stock_status", func(e ComparisonEntry) any { return e.StockStatus }},
+		{"grade", func(e ComparisonEntry) any { return e.Grade }},
+		{"warranty_months", func(e ComparisonEntry) any { return e.Warranty }},
+		{"battery_health", func(e ComparisonEntry) any { return e.Battery }},
+	}
+
+	differences := []string{}
+	for _, f := range fields {
+		first := f.value(entries[0])
+		for _, e := range entries[1:] {
+			if f.value(e) != first {
+				differences = append(differences, f.name)
+				break
+			}
+		}
+	}
+	return differences
+}
+
+// comparisonBest picks the winning product ID for the headline criteria
+func comparisonBest(entries []ComparisonEntry) map[string]string {
+	best := make(map[string]string)
+
+	lowest, value, savings := entries[0], entries[0], entries[0]
+	for _, e := range entries[1:] {
+		if e.Price < lowest.Price {
+			lowest = e
+		}
+		if e.ValueScore > value.ValueScore {
+			value = e
+		}
+		if e.Savings > savings.Savings {
+			savings = e
+		}
+	}
+
+	best["lowest_price"] = lowest.ID
+	best["best_value"] = value.ID
+	if savings.Savings > 0 {
+		best["biggest_savings"] = savings.ID
+	}
+	return best
+}
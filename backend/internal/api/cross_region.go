@@ -0,0 +1,170 @@
+package api
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"sort"
+	"strings"
+
+	"apple-price/internal/model"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CurrencyConverter provides exchange rates for cross-region price comparison
+type CurrencyConverter interface {
+	Rates(ctx context.Context) (*model.ExchangeRates, error)
+}
+
+// GetProductCrossRegion shows what the product's model (same spec combination)
+// costs in every region, converted to one currency: the product region's by
+// default, or ?currency=USD
+// GET /api/products/:id/cross-region
+func (h *Handlers) GetProductCrossRegion(c *gin.Context) {
+	product, ok := h.store.GetProduct(c.Param("id"))
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "product not found"})
+		return
+	}
+
+	currencies := make(map[string]string) // region -> currency
+	for _, r := range h.store.GetRegions() {
+		currencies[r.Code] = r.Currency
+	}
+
+	currency := strings.ToUpper(c.Query("currency"))
+	if currency == "" {
+		currency = currencies[product.Region]
+	}
+	if !currencyPattern.MatchString(currency) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "currency must be a 3-letter ISO code"})
+		return
+	}
+
+	comparison := &model.CrossRegionComparison{
+		ProductID: product.ID,
+		Combo:     model.ParseSpecCombo(product.Name + " " + product.Specs),
+		Currency:  currency,
+		Regions:   []model.CrossRegionPrice{},
+	}
+
+	if h.currency != nil {
+		rates, err := h.currency.Rates(c.Request.Context())
+		if err != nil {
+			requestLogger(c).Warn("Failed to get exchange rates", "error", err)
+			comparison.RatesError = err.Error()
+		}
+		comparison.Rates = rates
+	} else {
+		comparison.RatesError = "currency conversion is not configured"
+	}
+
+	convert := func(price float64, from string) float64 {
+		if from == currency {
+			return price
+		}
+		if comparison.Rates == nil {
+			return 0
+		}
+		converted, ok := comparison.Rates.Convert(price, from, currency)
+		if !ok {
+			return 0
+		}
+		return math.Round(converted*100) / 100
+	}
+
+	// Without a model the combo would match everything: compare the product alone
+	listings := map[string]*model.Product{product.Region: product}
+	if comparison.Combo.Model != "" {
+		for region := range currencies {
+			if region == product.Region {
+				continue
+			}
+			if p := cheapestMatch(h.store.GetProductsByRegion(region), comparison.Combo); p != nil {
+				listings[region] = p
+			}
+		}
+	}
+
+	for region, p := range listings {
+		comparison.Regions = append(comparison.Regions, model.CrossRegionPrice{
+			Region:         region,
+			Currency:       currencies[region],
+			ProductID:      p.ID,
+			Name:           p.Name,
+			Price:          p.Price,
+			ConvertedPrice: convert(p.Price, currencies[region]),
+			StockStatus:    p.StockStatus,
+			ProductURL:     p.ProductURL,
+		})
+	}
+
+	own := convert(product.Price, currencies[product.Region])
+	for i := range comparison.Regions {
+		entry := &comparison.Regions[i]
+		if own > 0 && entry.ConvertedPrice > 0 && entry.ProductID != product.ID {
+			entry.DifferencePercent = math.Round((entry.ConvertedPrice-own)/own*1000) / 10
+		}
+	}
+
+	// Cheapest first; regions without a rate go last
+	sort.Slice(comparison.Regions, func(i, j int) bool {
+		a, b := comparison.Regions[i], comparison.Regions[j]
+		if (a.ConvertedPrice > 0) != (b.ConvertedPrice > 0) {
+			return a.ConvertedPrice > 0
+		}
+		if a.ConvertedPrice != b.ConvertedPrice {
+			return a.ConvertedPrice < b.ConvertedPrice
+		}
+		return a.Region < b.Region
+	})
+	if len(comparison.Regions) > 1 && comparison.Regions[0].ConvertedPrice > 0 {
+		comparison.CheapestRegion = comparison.Regions[0].Region
+	}
+
+	c.JSON(http.StatusOK, comparison)
+}
+
+// GetProductEquivalents returns the product's SKU listed in other regions, matched
+// by Apple part number rather than specs
+// GET /api/products/:id/equivalents
+func (h *Handlers) GetProductEquivalents(c *gin.Context) {
+	product, ok := h.store.GetProduct(c.Param("id"))
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "product not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, &model.ProductEquivalents{
+		ProductID:      product.ID,
+		PartNumber:     product.PartNumber,
+		BasePartNumber: model.PartNumberBase(product.PartNumber),
+		Equivalents:    model.MatchEquivalents(product, h.store.GetAllProducts()),
+	})
+}
+
+// cheapestMatch returns the cheapest product matching combo, preferring ones in stock
+func cheapestMatch(products []*model.Product, combo model.SpecCombo) *model.Product {
+	var best *model.Product
+	for _, p := range products {
+		if !combo.Matches(p) {
+			continue
+		}
+		if best == nil {
+			best = p
+			continue
+		}
+		inStock, bestInStock := p.StockStatus != "sold_out", best.StockStatus != "sold_out"
+		if inStock != bestInStock {
+			if inStock {
+				best = p
+			}
+			continue
+		}
+		if p.Price < best.Price {
+			best = p
+		}
+	}
+	return best
+}
@@ -0,0 +1,158 @@
+package api
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+
+	"apple-price/internal/model"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// defaultDealsLimit and maxDealsLimit bound the number of deals returned
+	defaultDealsLimit = 20
+	maxDealsLimit     = 100
+	// dealFullDiscount is the discount (% off the new price) that earns full discount marks
+	dealFullDiscount = 30.0
+	// dealNeutralLow scores products whose price never moved, so there is no historical low to compare with
+	dealNeutralLow = 50.0
+)
+
+// DealWeights blends the deal score components; they are normalized to sum to 1
+type DealWeights struct {
+	Value    float64 `json:"value"`    // value_score
+	Low      float64 `json:"low"`      // closeness to the historical low
+	Discount float64 `json:"discount"` // discount off the new price
+}
+
+// defaultDealWeights favour value, then the historical low, then the discount
+var defaultDealWeights = DealWeights{Value: 0.5, Low: 0.3, Discount: 0.2}
+
+// Deal is a ranked offer with the reason it made the list
+type Deal struct {
+	Product *model.Product `json:"product"`
+	Score   float64        `json:"score"` // 0-100
+	Reason  string         `json:"reason"`
+
+	// Component scores, 0-100 each
+	Value    float64 `json:"value"`
+	Low      float64 `json:"low"`
+	Discount float64 `json:"discount"`
+}
+
+// GetDeals ranks the products in stock by a blend of value score, distance from the
+// historical low and discount
+// GET /api/deals?category=&region=&max_price=&limit=&w_value=&w_low=&w_discount=
+func (h *Handlers) GetDeals(c *gin.Context) {
+	weights := defaultDealWeights
+	params := []struct {
+		name   string
+		weight *float64
+	}{{"w_value", &weights.Value}, {"w_low", &weights.Low}, {"w_discount", &weights.Discount}}
+	for _, param := range params {
+		v := c.Query(param.name)
+		if v == "" {
+			continue
+		}
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil || f < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": param.name + " must be a non-negative number"})
+			return
+		}
+		*param.weight = f
+	}
+	total := weights.Value + weights.Low + weights.Discount
+	if total == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "at least one weight must be positive"})
+		return
+	}
+	weights = DealWeights{Value: weights.Value / total, Low: weights.Low / total, Discount: weights.Discount / total}
+
+	req := RecommendationRequest{Category: c.Query("category")}
+	if v := c.Query("max_price"); v != "" {
+		maxPrice, err := strconv.ParseFloat(v, 64)
+		if err != nil || maxPrice < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "max_price must be a non-negative number"})
+			return
+		}
+		req.BudgetMax = &maxPrice
+	}
+
+	limit := defaultDealsLimit
+	if v := c.Query("limit"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			limit = min(n, maxDealsLimit)
+		}
+	}
+
+	region := c.Query("region")
+	deals := make([]*Deal, 0)
+	for _, p := range h.filterCandidates(h.store.GetAllProducts(), req) {
+		if p.StockStatus == "sold_out" || (region != "" && p.Region != region) {
+			continue
+		}
+		deals = append(deals, h.scoreDeal(p, weights))
+	}
+
+	sort.SliceStable(deals, func(i, j int) bool {
+		if deals[i].Score != deals[j].Score {
+			return deals[i].Score > deals[j].Score
+		}
+		return deals[i].Product.Price < deals[j].Product.Price
+	})
+	if len(deals) > limit {
+		deals = deals[:limit]
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"count":   len(deals),
+		"weights": weights,
+		"deals":   deals,
+	})
+}
+
+// scoreDeal computes a product's deal components and blended score
+func (h *Handlers) scoreDeal(p *model.Product, weights DealWeights) *Deal {
+	deal := &Deal{
+		Product:  p,
+		Value:    math.Max(0, math.Min(p.ValueScore, 100)),
+		Low:      dealNeutralLow,
+		Discount: math.Round(math.Max(0, math.Min(p.Discount/dealFullDiscount*100, 100))*10) / 10,
+	}
+
+	position, hasRange := pricePosition(h.store.GetPriceHistory(p.ID), p.Price)
+	if hasRange {
+		deal.Low = math.Round((1-math.Max(0, math.Min(position, 1)))*1000) / 10
+	}
+
+	score := deal.Value*weights.Value + deal.Low*weights.Low + deal.Discount*weights.Discount
+	deal.Score = math.Round(score*10) / 10
+	deal.Reason = dealReason(p, deal, weights, hasRange && position <= 0)
+	return deal
+}
+
+// dealReason explains a deal by its largest weighted component
+func dealReason(p *model.Product, deal *Deal, weights DealWeights, atLow bool) string {
+	savings := int(p.OriginalPrice - p.Price)
+
+	value := deal.Value * weights.Value
+	low := deal.Low * weights.Low
+	discount := deal.Discount * weights.Discount
+
+	switch {
+	case atLow && low >= value && low >= discount:
+		return "历史最低价"
+	case low >= value && low >= discount && deal.Low >= 80:
+		return "当前价格接近历史低位"
+	case value >= discount && p.ValueScore > 0:
+		return fmt.Sprintf("性价比评分%.0f分", p.ValueScore)
+	case savings > 0:
+		return fmt.Sprintf("比新机省¥%d（%.0f%%）", savings, p.Discount)
+	default:
+		return "官方翻新，享受1年保修"
+	}
+}
@@ -0,0 +1,35 @@
+package api
+
+import "apple-price/internal/model"
+
+// deliveryStatus returns model.DeliveryStatusBroken when pushes to barkKey were
+// paused after failing too often, or "" when the key is healthy
+func (h *Handlers) deliveryStatus(barkKey string) string {
+	if barkKey != "" && h.store.GetDeliveryHealth(barkKey).Broken() {
+		return model.DeliveryStatusBroken
+	}
+	return ""
+}
+
+// subscriptionResponses copies subscriptions for a response, flagging those
+// whose Bark Key no longer accepts pushes
+func (h *Handlers) subscriptionResponses(subs []*model.Subscription) []*model.Subscription {
+	responses := make([]*model.Subscription, len(subs))
+	for i, sub := range subs {
+		response := *sub
+		response.DeliveryStatus = h.deliveryStatus(sub.BarkKey)
+		responses[i] = &response
+	}
+	return responses
+}
+
+// newArrivalSubscriptionResponses is subscriptionResponses for new arrival subscriptions
+func (h *Handlers) newArrivalSubscriptionResponses(subs []*model.NewArrivalSubscription) []*model.NewArrivalSubscription {
+	responses := make([]*model.NewArrivalSubscription, len(subs))
+	for i, sub := range subs {
+		response := *sub
+		response.DeliveryStatus = h.deliveryStatus(sub.BarkKey)
+		responses[i] = &response
+	}
+	return responses
+}
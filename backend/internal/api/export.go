@@ -0,0 +1,263 @@
+package api
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"apple-price/internal/model"
+
+	"github.com/gin-gonic/gin"
+)
+
+// exportFlushEvery is how many rows are written between flushes to the client
+const exportFlushEvery = 500
+
+// exportWriter streams rows in one of the supported export formats
+type exportWriter struct {
+	format string
+	w      gin.ResponseWriter
+	csv    *csv.Writer
+	json   *json.Encoder
+	rows   int
+}
+
+// newExportWriter validates the format query parameter, writes the response headers
+// and returns a writer for the rows. Returns nil after answering 400 for a bad format.
+func newExportWriter(c *gin.Context, dataset string, header []string) *exportWriter {
+	format := c.DefaultQuery("format", "json")
+	switch format {
+	case "csv", "json", "ndjson":
+		return startExport(c, format, dataset, header)
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format must be csv, json or ndjson"})
+		return nil
+	}
+}
+
+// startExport writes the response headers for a download in format and returns a writer for the rows
+func startExport(c *gin.Context, format, dataset string, header []string) *exportWriter {
+	var contentType string
+	switch format {
+	case "csv":
+		contentType = "text/csv; charset=utf-8"
+	case "json":
+		contentType = "application/json; charset=utf-8"
+	case "ndjson":
+		contentType = "application/x-ndjson"
+	}
+
+	filename := fmt.Sprintf("apple-price-%s-%s.%s", dataset, time.Now().Format("20060102"), format)
+	c.Header("Content-Type", contentType)
+	c.Header("Content-Disposition", `attachment; filename="`+filename+`"`)
+	c.Status(http.StatusOK)
+
+	ew := &exportWriter{format: format, w: c.Writer}
+	switch format {
+	case "csv":
+		// BOM so Excel opens Chinese product names correctly
+		_, _ = io.WriteString(c.Writer, "\uFEFF")
+		ew.csv = csv.NewWriter(c.Writer)
+		_ = ew.csv.Write(header)
+	case "json":
+		_, _ = io.WriteString(c.Writer, "[")
+		ew.json = json.NewEncoder(c.Writer)
+	case "ndjson":
+		ew.json = json.NewEncoder(c.Writer)
+	}
+	return ew
+}
+
+// write emits one row: record for JSON formats, fields for CSV
+func (ew *exportWriter) write(record any, fields []string) error {
+	var err error
+	switch ew.format {
+	case "csv":
+		err = ew.csv.Write(fields)
+	case "json":
+		if ew.rows > 0 {
+			if _, err = io.WriteString(ew.w, ","); err != nil {
+				return err
+			}
+		}
+		err = ew.json.Encode(record)
+	case "ndjson":
+		err = ew.json.Encode(record)
+	}
+	if err != nil {
+		return err
+	}
+
+	ew.rows++
+	if ew.rows%exportFlushEvery == 0 {
+		ew.flush()
+	}
+	return nil
+}
+
+// close terminates the document and flushes what is left
+func (ew *exportWriter) close() {
+	if ew.format == "json" {
+		_, _ = io.WriteString(ew.w, "]\n")
+	}
+	ew.flush()
+}
+
+func (ew *exportWriter) flush() {
+	if ew.csv != nil {
+		ew.csv.Flush()
+	}
+	ew.w.Flush()
+}
+
+// exportProducts returns the products matching the category/region query filters
+func (h *Handlers) exportProducts(c *gin.Context) []*model.Product {
+	category := c.Query("category")
+	region := c.Query("region")
+
+	var products []*model.Product
+	for _, p := range h.store.GetAllProducts() {
+		if (category == "" || p.Category == category) && (region == "" || p.Region == region) {
+			products = append(products, p)
+		}
+	}
+	return products
+}
+
+// ExportProducts streams every product as CSV, JSON or NDJSON
+// GET /api/export/products?format=csv|json|ndjson&category=&region=
+func (h *Handlers) ExportProducts(c *gin.Context) {
+	products := h.exportProducts(c)
+
+	ew := newExportWriter(c, "products", []string{
+		"id", "name", "category", "region", "price", "original_price", "discount",
+		"stock_status", "value_score", "lowest_price", "highest_price", "price_trend",
+		"volatility", "drop_streak", "stability", "grade", "warranty_months", "battery_health", "product_url", "image_url",
+		"created_at", "updated_at",
+	})
+	if ew == nil {
+		return
+	}
+	defer ew.close()
+
+	for _, p := range products {
+		err := ew.write(p, []string{
+			p.ID, p.Name, p.Category, p.Region,
+			formatExportFloat(p.Price), formatExportFloat(p.OriginalPrice), formatExportFloat(p.Discount),
+			p.StockStatus, formatExportFloat(p.ValueScore),
+			formatExportFloat(p.LowestPrice), formatExportFloat(p.HighestPrice), p.PriceTrend,
+			formatExportFloat(p.Volatility), strconv.Itoa(p.DropStreak), p.Stability,
+			p.Grade, strconv.Itoa(p.WarrantyMonths), strconv.Itoa(p.BatteryHealth),
+			p.ProductURL, p.ImageURL,
+			p.CreatedAt.Format(time.RFC3339), p.UpdatedAt.Format(time.RFC3339),
+		})
+		if err != nil {
+			// Client went away; nothing more can be sent
+			requestLogger(c).Warn("Product export aborted", "rows", ew.rows, "error", err)
+			return
+		}
+	}
+}
+
+// ExportHistory streams the price history of every product (or one product) as CSV, JSON or NDJSON
+// GET /api/export/history?format=csv|json|ndjson&product_id=&category=&region=&since=YYYY-MM-DD
+func (h *Handlers) ExportHistory(c *gin.Context) {
+	var since time.Time
+	if s := c.Query("since"); s != "" {
+		t, err := time.ParseInLocation(time.DateOnly, s, time.Local)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "since must be YYYY-MM-DD"})
+			return
+		}
+		since = t
+	}
+
+	var products []*model.Product
+	if id := c.Query("product_id"); id != "" {
+		p, ok := h.store.GetProduct(id)
+		if !ok {
+			c.JSON(http.StatusNotFound, gin.H{"error": "product not found"})
+			return
+		}
+		products = []*model.Product{p}
+	} else {
+		products = h.exportProducts(c)
+	}
+
+	ew := newExportWriter(c, "history", []string{"product_id", "price", "discount", "timestamp"})
+	if ew == nil {
+		return
+	}
+	defer ew.close()
+
+	// History is loaded one product at a time so memory stays flat on large datasets
+	for _, p := range products {
+		for _, entry := range h.store.GetPriceHistory(p.ID) {
+			if entry.Timestamp.Before(since) {
+				continue
+			}
+			err := ew.write(entry, []string{
+				entry.ProductID, formatExportFloat(entry.Price), formatExportFloat(entry.Discount),
+				entry.Timestamp.Format(time.RFC3339),
+			})
+			if err != nil {
+				requestLogger(c).Warn("History export aborted", "rows", ew.rows, "error", err)
+				return
+			}
+		}
+	}
+}
+
+// notificationExportPage is how many notification history entries are loaded at a time
+const notificationExportPage = 500
+
+// ExportNotificationHistory streams the full delivery log of a Bark Key as CSV
+// GET /api/notification-history/export.csv?bark_key=
+func (h *Handlers) ExportNotificationHistory(c *gin.Context) {
+	barkKey, ok := h.requestBarkKey(c)
+	if !ok {
+		return
+	}
+	if barkKey == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "bark_key is required"})
+		return
+	}
+
+	ew := startExport(c, "csv", "notifications", []string{
+		"created_at", "notification_type", "status", "error_message",
+		"product_id", "product_name", "product_category", "product_price",
+		"title", "body", "subscription_id", "read_at",
+	})
+	defer ew.close()
+
+	for offset := 0; ; offset += notificationExportPage {
+		history, total := h.store.GetNotificationHistory("", barkKey, notificationExportPage, offset)
+		for _, n := range history {
+			readAt := ""
+			if n.ReadAt != nil {
+				readAt = n.ReadAt.Format(time.RFC3339)
+			}
+			err := ew.write(n, []string{
+				n.CreatedAt.Format(time.RFC3339), n.NotificationType, n.Status, n.ErrorMessage,
+				n.ProductID, n.ProductName, n.ProductCategory, formatExportFloat(n.ProductPrice),
+				n.Title, n.Body, n.SubscriptionID, readAt,
+			})
+			if err != nil {
+				requestLogger(c).Warn("Notification history export aborted", "rows", ew.rows, "error", err)
+				return
+			}
+		}
+		if len(history) < notificationExportPage || offset+len(history) >= total {
+			return
+		}
+	}
+}
+
+// formatExportFloat renders a number without trailing zeros for CSV cells
+func formatExportFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
@@ -0,0 +1,247 @@
+package api
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"time"
+
+	"apple-price/internal/model"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// forecastShortWindow and forecastLongWindow are the moving average lengths (in price points)
+	forecastShortWindow = 3
+	forecastLongWindow  = 10
+
+	// forecastMinPoints is the history length below which no trend is inferred
+	forecastMinPoints = 3
+
+	// forecastTrendThreshold is the relative gap between the two averages that counts as a trend
+	forecastTrendThreshold = 0.01
+)
+
+// ProductForecast is the predicted price direction and buy/wait advice for one product
+type ProductForecast struct {
+	ProductID      string     `json:"product_id"`
+	CurrentPrice   float64    `json:"current_price"`
+	MovingAverage  float64    `json:"moving_average"`  // long-window average of recorded prices
+	Direction      string     `json:"direction"`       // falling, rising, stable
+	PredictedPrice float64    `json:"predicted_price"` // expected price after the next change
+	Confidence     float64    `json:"confidence"`      // 0-1
+	Recommendation string     `json:"recommendation"`  // buy, wait
+	Reasons        []string   `json:"reasons"`
+	ValueScore     float64    `json:"value_score"`
+	SampleSize     int        `json:"sample_size"`
+	NextDropAt     *time.Time `json:"next_drop_at,omitempty"`    // projected from past price drop intervals
+	NextRestockAt  *time.Time `json:"next_restock_at,omitempty"` // projected from past sold_out -> available cycles
+}
+
+// GetProductForecast returns a price prediction and buy/wait recommendation
+// GET /api/products/:id/forecast
+func (h *Handlers) GetProductForecast(c *gin.Context) {
+	id := c.Param("id")
+	if id == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "product ID is required"})
+		return
+	}
+
+	product, ok := h.store.GetProduct(id)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "product not found"})
+		return
+	}
+
+	forecast := forecastProduct(product, h.store.GetPriceHistory(id), h.store.GetProductEvents(id), time.Now())
+
+	c.JSON(http.StatusOK, forecast)
+}
+
+// forecastProduct fits short/long moving averages to the price history, projects the
+// next price drop and restock from past intervals, and combines them with the value score
+func forecastProduct(product *model.Product, history []model.PriceHistory, events []model.ProductEvent, now time.Time) *ProductForecast {
+	f := &ProductForecast{
+		ProductID:      product.ID,
+		CurrentPrice:   product.Price,
+		MovingAverage:  product.Price,
+		Direction:      "stable",
+		PredictedPrice: product.Price,
+		ValueScore:     product.ValueScore,
+		SampleSize:     len(history),
+		Reasons:        []string{},
+	}
+
+	if len(history) >= forecastMinPoints {
+		short := movingAverage(history, forecastShortWindow)
+		long := movingAverage(history, forecastLongWindow)
+		f.MovingAverage = long
+
+		if long > 0 {
+			gap := (short - long) / long
+			switch {
+			case gap < -forecastTrendThreshold:
+				f.Direction = "falling"
+			case gap > forecastTrendThreshold:
+				f.Direction = "rising"
+			}
+			// Extrapolate one step along the trend, never past the recorded range
+			f.PredictedPrice = clampPrice(product.Price+(short-long), history)
+		}
+
+		// Confidence grows with sample size and shrinks with volatility
+		samples := math.Min(float64(len(history))/float64(forecastLongWindow*2), 1)
+		f.Confidence = samples * (1 - math.Min(priceVolatility(history), 0.5)*2)
+	}
+
+	if next, ok := projectNext(priceDropTimes(history)); ok && next.After(now) {
+		f.NextDropAt = &next
+	}
+	if next, ok := projectNext(restockTimes(events)); ok && next.After(now) {
+		f.NextRestockAt = &next
+	}
+
+	f.Recommendation, f.Reasons = forecastRecommendation(product, history, f, now)
+	f.Confidence = math.Round(f.Confidence*100) / 100
+	f.MovingAverage = math.Round(f.MovingAverage*100) / 100
+	f.PredictedPrice = math.Round(f.PredictedPrice*100) / 100
+
+	return f
+}
+
+// forecastRecommendation turns the forecast into buy/wait advice with reasons
+func forecastRecommendation(product *model.Product, history []model.PriceHistory, f *ProductForecast, now time.Time) (string, []string) {
+	var reasons []string
+
+	if product.StockStatus == "sold_out" {
+		if f.NextRestockAt != nil {
+			reasons = append(reasons, fmt.Sprintf("当前售罄，预计 %s 前后补货", f.NextRestockAt.Format("01-02")))
+		} else {
+			reasons = append(reasons, "当前售罄，建议订阅补货通知")
+		}
+		return "wait", reasons
+	}
+
+	lowest := product.Price
+	for _, h := range history {
+		if h.Price < lowest {
+			lowest = h.Price
+		}
+	}
+	atLow := product.Price <= lowest*1.01
+
+	waitScore := 0.0
+	if f.Direction == "falling" {
+		waitScore += f.Confidence
+		reasons = append(reasons, fmt.Sprintf("价格处于下行趋势，预计降至 ¥%.0f", f.PredictedPrice))
+	}
+	if f.NextDropAt != nil && f.NextDropAt.Sub(now) < 14*24*time.Hour {
+		waitScore += 0.3
+		reasons = append(reasons, fmt.Sprintf("按历史降价周期，%s 前后可能再降", f.NextDropAt.Format("01-02")))
+	}
+	if atLow {
+		waitScore -= 0.5
+		reasons = append(reasons, "当前价格处于历史低位")
+	}
+	if f.Direction == "rising" {
+		waitScore -= 0.3
+		reasons = append(reasons, "价格正在上涨，越等越贵")
+	}
+	if product.ValueScore >= 70 {
+		waitScore -= 0.3
+		reasons = append(reasons, fmt.Sprintf("性价比评分%.0f分，值得入手", product.ValueScore))
+	}
+	if product.StockStatus == "limited" {
+		waitScore -= 0.2
+		reasons = append(reasons, "库存紧张，可能很快售罄")
+	}
+
+	if waitScore > 0.2 {
+		return "wait", reasons
+	}
+	if len(reasons) == 0 {
+		reasons = append(reasons, "价格平稳，近期无明显降价信号")
+	}
+	return "buy", reasons
+}
+
+// movingAverage averages the last window prices
+func movingAverage(history []model.PriceHistory, window int) float64 {
+	if window > len(history) {
+		window = len(history)
+	}
+	if window == 0 {
+		return 0
+	}
+	sum := 0.0
+	for _, h := range history[len(history)-window:] {
+		sum += h.Price
+	}
+	return sum / float64(window)
+}
+
+// priceVolatility is the coefficient of variation of the recorded prices
+func priceVolatility(history []model.PriceHistory) float64 {
+	mean := movingAverage(history, len(history))
+	if mean == 0 {
+		return 0
+	}
+	variance := 0.0
+	for _, h := range history {
+		variance += (h.Price - mean) * (h.Price - mean)
+	}
+	return math.Sqrt(variance/float64(len(history))) / mean
+}
+
+// clampPrice keeps a predicted price within the historical min/max
+func clampPrice(price float64, history []model.PriceHistory) float64 {
+	lo, hi := history[0].Price, history[0].Price
+	for _, h := range history {
+		lo = math.Min(lo, h.Price)
+		hi = math.Max(hi, h.Price)
+	}
+	return math.Max(lo, math.Min(hi, price))
+}
+
+// priceDropTimes returns the timestamps at which the price went down
+func priceDropTimes(history []model.PriceHistory) []time.Time {
+	var times []time.Time
+	for i := 1; i < len(history); i++ {
+		if history[i].Price < history[i-1].Price {
+			times = append(times, history[i].Timestamp)
+		}
+	}
+	return times
+}
+
+// restockTimes returns the timestamps at which a sold out product became available again
+func restockTimes(events []model.ProductEvent) []time.Time {
+	var times []time.Time
+	soldOut := false
+	for _, e := range events {
+		switch e.EventType {
+		case "sold_out":
+			soldOut = true
+		case "available", "limited":
+			if soldOut {
+				times = append(times, e.CreatedAt)
+			}
+			soldOut = false
+		}
+	}
+	return times
+}
+
+// projectNext projects the next occurrence from the average interval between past ones
+func projectNext(times []time.Time) (time.Time, bool) {
+	if len(times) < 2 {
+		return time.Time{}, false
+	}
+	span := times[len(times)-1].Sub(times[0])
+	interval := span / time.Duration(len(times)-1)
+	if interval <= 0 {
+		return time.Time{}, false
+	}
+	return times[len(times)-1].Add(interval), true
+}
@@ -0,0 +1,679 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+
+	"apple-price/internal/model"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// maxGraphQLQueryBytes bounds the query document
+	maxGraphQLQueryBytes = 32 << 10
+	// maxGraphQLDepth bounds selection nesting, so a query can't fan out
+	// product -> subscriptions -> product -> … without end
+	maxGraphQLDepth = 8
+	// defaultGraphQLHistoryLimit and maxGraphQLHistoryLimit match GET /api/products/:id/history
+	defaultGraphQLHistoryLimit = 50
+	maxGraphQLHistoryLimit     = 1000
+)
+
+// GraphQLRequest is a GraphQL-over-HTTP request
+type GraphQLRequest struct {
+	Query         string         `json:"query"`
+	Variables     map[string]any `json:"variables,omitempty"`
+	OperationName string         `json:"operationName,omitempty"`
+}
+
+// GraphQLError is an entry of the response's errors list
+type GraphQLError struct {
+	Message string `json:"message"`
+	Path    []any  `json:"path,omitempty"`
+}
+
+// gqlObject is a selected object; it encodes its fields in selection order
+type gqlObject []gqlEntry
+
+type gqlEntry struct {
+	key   string
+	value any
+}
+
+// MarshalJSON encodes the fields in selection order, as GraphQL requires
+func (o gqlObject) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, entry := range o {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, _ := json.Marshal(entry.key)
+		value, err := json.Marshal(entry.value)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// gqlArgs are a field's arguments with variables substituted
+type gqlArgs map[string]any
+
+// str returns a string argument, "" when omitted
+func (a gqlArgs) str(name string) (string, error) {
+	switch v := a[name].(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	default:
+		return "", fmt.Errorf("argument %q must be a string", name)
+	}
+}
+
+// int returns an integer argument, def when omitted. Literals arrive as int64,
+// JSON variables as float64.
+func (a gqlArgs) int(name string, def int) (int, error) {
+	switch v := a[name].(type) {
+	case nil:
+		return def, nil
+	case int64:
+		return int(v), nil
+	case float64:
+		if v == float64(int(v)) {
+			return int(v), nil
+		}
+	}
+	return 0, fmt.Errorf("argument %q must be an integer", name)
+}
+
+// gqlField is a field computed by a resolver rather than read from the model's JSON
+type gqlField struct {
+	typ  string   // object type of the result, "" for scalars and plain JSON
+	args []string // accepted arguments
+	// resolve computes the value; barkKey is the caller's, "" if none
+	resolve func(h *Handlers, barkKey string, parent any, args gqlArgs) (any, error)
+}
+
+// gqlType is an object type: the JSON fields of its model plus computed fields
+type gqlType struct {
+	model  reflect.Type // nil for Query
+	fields map[string]gqlField
+	redact func(fields map[string]any) // hides sensitive JSON fields, may be nil
+}
+
+// gqlSchema is the schema served at /api/graphql. Plain fields use the names of
+// the REST JSON responses; nested objects without a type (stats.scraper_status,
+// price_stats.drops, …) can be selected into but take no arguments.
+var gqlSchema = map[string]*gqlType{
+	"Query": {
+		fields: map[string]gqlField{
+			"products": {
+				typ:  "Product",
+				args: []string{"category", "region", "stock_status", "stability", "sort", "order", "limit", "offset"},
+				resolve: func(h *Handlers, _ string, _ any, args gqlArgs) (any, error) {
+					return h.graphQLProducts(args)
+				},
+			},
+			"product": {
+				typ:  "Product",
+				args: []string{"id"},
+				resolve: func(h *Handlers, _ string, _ any, args gqlArgs) (any, error) {
+					id, err := args.str("id")
+					if err != nil || id == "" {
+						return nil, fmt.Errorf("argument \"id\" is required")
+					}
+					product, ok := h.store.GetProduct(id)
+					if !ok {
+						return nil, nil
+					}
+					return h.productWithVelocity(product), nil
+				},
+			},
+			"categories": {
+				resolve: func(h *Handlers, _ string, _ any, _ gqlArgs) (any, error) {
+					return h.store.GetCategories(), nil
+				},
+			},
+			"stats": {
+				typ: "Stats",
+				resolve: func(h *Handlers, _ string, _ any, _ gqlArgs) (any, error) {
+					return h.store.GetStats(), nil
+				},
+			},
+			"subscriptions": {
+				typ:  "Subscription",
+				args: []string{"product_id"},
+				resolve: func(h *Handlers, barkKey string, _ any, args gqlArgs) (any, error) {
+					productID, err := args.str("product_id")
+					if err != nil {
+						return nil, err
+					}
+					return h.graphQLSubscriptions(barkKey, productID), nil
+				},
+			},
+		},
+	},
+	"Product": {
+		model: reflect.TypeOf(model.Product{}),
+		fields: map[string]gqlField{
+			"history": {
+				typ:  "PriceHistory",
+				args: []string{"limit"},
+				resolve: func(h *Handlers, _ string, parent any, args gqlArgs) (any, error) {
+					limit, err := args.int("limit", defaultGraphQLHistoryLimit)
+					if err != nil {
+						return nil, err
+					}
+					limit = max(1, min(limit, maxGraphQLHistoryLimit))
+					history := h.store.GetPriceHistory(parent.(*model.Product).ID)
+					if len(history) > limit {
+						history = history[len(history)-limit:]
+					}
+					return history, nil
+				},
+			},
+			"price_stats": {
+				typ: "PriceStats",
+				resolve: func(h *Handlers, _ string, parent any, _ gqlArgs) (any, error) {
+					stats, ok := h.store.GetPriceStats(parent.(*model.Product).ID)
+					if !ok {
+						return nil, nil
+					}
+					return stats, nil
+				},
+			},
+			"events": {
+				typ: "ProductEvent",
+				resolve: func(h *Handlers, _ string, parent any, _ gqlArgs) (any, error) {
+					return h.store.GetProductEvents(parent.(*model.Product).ID), nil
+				},
+			},
+			"subscriptions": {
+				typ: "Subscription",
+				resolve: func(h *Handlers, barkKey string, parent any, _ gqlArgs) (any, error) {
+					return h.graphQLSubscriptions(barkKey, parent.(*model.Product).ID), nil
+				},
+			},
+		},
+	},
+	"PriceHistory": {
+		model: reflect.TypeOf(model.PriceHistory{}),
+		fields: map[string]gqlField{
+			"product": {
+				typ: "Product",
+				resolve: func(h *Handlers, _ string, parent any, _ gqlArgs) (any, error) {
+					return h.graphQLProduct(parent.(model.PriceHistory).ProductID), nil
+				},
+			},
+		},
+	},
+	"Subscription": {
+		model: reflect.TypeOf(model.Subscription{}),
+		fields: map[string]gqlField{
+			"product": {
+				typ: "Product",
+				resolve: func(h *Handlers, _ string, parent any, _ gqlArgs) (any, error) {
+					return h.graphQLProduct(parent.(*model.Subscription).ProductID), nil
+				},
+			},
+		},
+		redact: func(fields map[string]any) {
+			if key, ok := fields["bark_key"].(string); ok {
+				fields["bark_key"] = maskBarkKey(key)
+			}
+		},
+	},
+	"PriceStats":   {model: reflect.TypeOf(model.PriceStats{})},
+	"ProductEvent": {model: reflect.TypeOf(model.ProductEvent{})},
+	"Stats":        {model: reflect.TypeOf(model.Stats{})},
+}
+
+// GraphQL executes a read-only GraphQL query over products, price history,
+// subscriptions and stats, e.g.
+//
+//	{ products(category: "Mac", limit: 5) { id name price history(limit: 10) { price timestamp } subscriptions { id target_price } } }
+//
+// Like GET /api/subscriptions, subscriptions are only those of the caller's Bark
+// Key (API key or bark_key); without one they are empty. Bark Keys are masked as
+// in the REST responses.
+// GET /api/graphql?query=&variables=&operationName=
+// POST /api/graphql {"query": "...", "variables": {...}, "operationName": "..."}
+func (h *Handlers) GraphQL(c *gin.Context) {
+	var req GraphQLRequest
+	if c.Request.Method == http.MethodGet {
+		req.Query = c.Query("query")
+		req.OperationName = c.Query("operationName")
+		if v := c.Query("variables"); v != "" {
+			if err := json.Unmarshal([]byte(v), &req.Variables); err != nil {
+				graphQLRequestError(c, "variables must be a JSON object")
+				return
+			}
+		}
+	} else if err := c.ShouldBindJSON(&req); err != nil {
+		graphQLRequestError(c, "invalid request: "+err.Error())
+		return
+	}
+
+	if strings.TrimSpace(req.Query) == "" {
+		graphQLRequestError(c, "query is required")
+		return
+	}
+	if len(req.Query) > maxGraphQLQueryBytes {
+		graphQLRequestError(c, fmt.Sprintf("query exceeds %d bytes", maxGraphQLQueryBytes))
+		return
+	}
+
+	op, err := parseGraphQL(req.Query, req.OperationName)
+	if err != nil {
+		graphQLRequestError(c, err.Error())
+		return
+	}
+	if depth := selectionDepth(op.selection); depth > maxGraphQLDepth {
+		graphQLRequestError(c, fmt.Sprintf("query depth %d exceeds the maximum of %d", depth, maxGraphQLDepth))
+		return
+	}
+
+	vars, err := operationVariables(op, req.Variables)
+	if err != nil {
+		graphQLRequestError(c, err.Error())
+		return
+	}
+
+	barkKey, ok := h.requestBarkKey(c)
+	if !ok {
+		return
+	}
+
+	e := &gqlExecutor{h: h, barkKey: barkKey, vars: vars}
+	data := e.selectObject("Query", nil, op.selection, nil)
+
+	resp := gin.H{"data": data}
+	if len(e.errors) > 0 {
+		resp["errors"] = e.errors
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// graphQLRequestError rejects a request that can't be executed at all
+func graphQLRequestError(c *gin.Context, message string) {
+	c.JSON(http.StatusBadRequest, gin.H{"errors": []GraphQLError{{Message: message}}})
+}
+
+// graphQLProducts resolves Query.products like GET /api/products: unfiltered
+// lists are limited to defaultProductsLimit unless a limit is given
+func (h *Handlers) graphQLProducts(args gqlArgs) (any, error) {
+	var q productQuery
+	for name, dst := range map[string]*string{
+		"category": &q.Category, "region": &q.Region, "stock_status": &q.StockStatus,
+		"stability": &q.Stability, "sort": &q.Sort, "order": &q.Order,
+	} {
+		v, err := args.str(name)
+		if err != nil {
+			return nil, err
+		}
+		*dst = v
+	}
+
+	defaultLimit := 0
+	if !q.filtered() {
+		defaultLimit = defaultProductsLimit
+	}
+	limit, err := args.int("limit", defaultLimit)
+	if err != nil {
+		return nil, err
+	}
+	offset, err := args.int("offset", 0)
+	if err != nil {
+		return nil, err
+	}
+
+	products := h.queryProducts(q)
+	products = products[min(max(offset, 0), len(products)):]
+	if limit > 0 && len(products) > limit {
+		products = products[:limit]
+	}
+	h.applyInventoryVelocity(products)
+	return products, nil
+}
+
+// graphQLProduct looks up a nested product, nil if it no longer exists
+func (h *Handlers) graphQLProduct(id string) *model.Product {
+	product, ok := h.store.GetProduct(id)
+	if !ok {
+		return nil
+	}
+	return h.productWithVelocity(product)
+}
+
+// graphQLSubscriptions returns the caller's price subscriptions, optionally of one
+// product; none without a Bark Key
+func (h *Handlers) graphQLSubscriptions(barkKey, productID string) []*model.Subscription {
+	subs := make([]*model.Subscription, 0)
+	if barkKey == "" {
+		return subs
+	}
+	for _, sub := range h.subscriptionResponses(h.store.GetSubscriptionsByBarkKey(barkKey)) {
+		if productID == "" || sub.ProductID == productID {
+			subs = append(subs, sub)
+		}
+	}
+	return subs
+}
+
+// operationVariables resolves the declared variables from the request's values
+// and the declared defaults
+func operationVariables(op *gqlOperation, values map[string]any) (map[string]any, error) {
+	vars := make(map[string]any, len(op.variables))
+	for _, v := range op.variables {
+		value, ok := values[v.name]
+		if !ok && v.hasDefault {
+			value, ok = v.defaultVal, true
+		}
+		if v.nonNull && (!ok || value == nil) {
+			return nil, fmt.Errorf("variable $%s is required", v.name)
+		}
+		vars[v.name] = value
+	}
+	return vars, nil
+}
+
+// selectionDepth returns how deeply a selection set nests
+func selectionDepth(sels []*gqlSelection) int {
+	depth := 0
+	for _, sel := range sels {
+		depth = max(depth, selectionDepth(sel.selection))
+	}
+	if len(sels) == 0 {
+		return 0
+	}
+	return depth + 1
+}
+
+// gqlExecutor executes one operation, collecting field errors
+type gqlExecutor struct {
+	h       *Handlers
+	barkKey string // caller's Bark Key, scopes subscriptions
+	vars    map[string]any
+	errors  []GraphQLError
+}
+
+// fail records a field error; the field resolves to null
+func (e *gqlExecutor) fail(path []any, err error) {
+	e.errors = append(e.errors, GraphQLError{Message: err.Error(), Path: path})
+}
+
+// fieldPath extends path without sharing its backing array
+func fieldPath(path []any, key any) []any {
+	return append(path[:len(path):len(path)], key)
+}
+
+// selectObject resolves a selection set against a value of the named type
+func (e *gqlExecutor) selectObject(typeName string, parent any, sels []*gqlSelection, path []any) gqlObject {
+	t := gqlSchema[typeName]
+	out := gqlObject{}
+	var plain map[string]any // the parent's JSON fields, decoded on first use
+
+	for _, sel := range sels {
+		include, err := e.included(sel)
+		if err != nil {
+			e.fail(fieldPath(path, sel.alias), err)
+			continue
+		}
+		if !include {
+			continue
+		}
+		p := fieldPath(path, sel.alias)
+
+		if sel.name == "__typename" {
+			out = append(out, gqlEntry{sel.alias, typeName})
+			continue
+		}
+
+		if f, ok := t.fields[sel.name]; ok {
+			var value any
+			args, err := e.arguments(sel, f.args)
+			if err == nil {
+				value, err = f.resolve(e.h, e.barkKey, parent, args)
+			}
+			if err != nil {
+				e.fail(p, err)
+				out = append(out, gqlEntry{sel.alias, nil})
+				continue
+			}
+			out = append(out, gqlEntry{sel.alias, e.complete(f.typ, value, sel, p)})
+			continue
+		}
+
+		if t.model == nil || !gqlModelFields(t.model)[sel.name] {
+			e.fail(p, fmt.Errorf("cannot query field %q on type %s", sel.name, typeName))
+			out = append(out, gqlEntry{sel.alias, nil})
+			continue
+		}
+		if len(sel.args) > 0 {
+			e.fail(p, fmt.Errorf("field %q takes no arguments", sel.name))
+			out = append(out, gqlEntry{sel.alias, nil})
+			continue
+		}
+		if plain == nil {
+			plain = toJSONMap(parent)
+			if t.redact != nil {
+				t.redact(plain)
+			}
+		}
+		out = append(out, gqlEntry{sel.alias, e.selectPlain(plain[sel.name], sel.selection, p)})
+	}
+	return out
+}
+
+// complete shapes a resolved value: lists element by element, objects through
+// their selection set, scalars as they are
+func (e *gqlExecutor) complete(typeName string, value any, sel *gqlSelection, path []any) any {
+	if typeName == "" {
+		if sel.selection != nil {
+			return e.selectPlain(toJSONValue(value), sel.selection, path)
+		}
+		return value
+	}
+
+	v := reflect.ValueOf(value)
+	if !v.IsValid() || (v.Kind() == reflect.Pointer && v.IsNil()) {
+		return nil
+	}
+	if sel.selection == nil {
+		e.fail(path, fmt.Errorf("field %q of type %s must have a selection of subfields", sel.name, typeName))
+		return nil
+	}
+	if v.Kind() == reflect.Slice {
+		list := make([]any, 0, v.Len())
+		for i := 0; i < v.Len(); i++ {
+			list = append(list, e.complete(typeName, v.Index(i).Interface(), sel, fieldPath(path, i)))
+		}
+		return list
+	}
+	return e.selectObject(typeName, value, sel.selection, path)
+}
+
+// selectPlain applies a selection set to decoded JSON; without one the value is
+// returned whole
+func (e *gqlExecutor) selectPlain(value any, sels []*gqlSelection, path []any) any {
+	if sels == nil {
+		return value
+	}
+	switch v := value.(type) {
+	case []any:
+		list := make([]any, 0, len(v))
+		for i, item := range v {
+			list = append(list, e.selectPlain(item, sels, fieldPath(path, i)))
+		}
+		return list
+	case map[string]any:
+		out := gqlObject{}
+		for _, sel := range sels {
+			include, err := e.included(sel)
+			if err != nil {
+				e.fail(fieldPath(path, sel.alias), err)
+				continue
+			}
+			if include {
+				out = append(out, gqlEntry{sel.alias, e.selectPlain(v[sel.name], sel.selection, fieldPath(path, sel.alias))})
+			}
+		}
+		return out
+	default:
+		return value
+	}
+}
+
+// arguments substitutes variables into a field's arguments and rejects unknown ones
+func (e *gqlExecutor) arguments(sel *gqlSelection, accepted []string) (gqlArgs, error) {
+	args := make(gqlArgs, len(sel.args))
+	for name, raw := range sel.args {
+		known := false
+		for _, a := range accepted {
+			known = known || a == name
+		}
+		if !known {
+			return nil, fmt.Errorf("unknown argument %q on field %q", name, sel.name)
+		}
+		value, err := e.value(raw)
+		if err != nil {
+			return nil, err
+		}
+		args[name] = value
+	}
+	return args, nil
+}
+
+// value substitutes variables in an argument value; enum literals become strings
+func (e *gqlExecutor) value(raw any) (any, error) {
+	switch v := raw.(type) {
+	case gqlVarRef:
+		value, ok := e.vars[string(v)]
+		if !ok {
+			return nil, fmt.Errorf("variable $%s is not declared", v)
+		}
+		return value, nil
+	case gqlEnum:
+		return string(v), nil
+	case []any:
+		list := make([]any, len(v))
+		for i, item := range v {
+			value, err := e.value(item)
+			if err != nil {
+				return nil, err
+			}
+			list[i] = value
+		}
+		return list, nil
+	case map[string]any:
+		obj := make(map[string]any, len(v))
+		for k, item := range v {
+			value, err := e.value(item)
+			if err != nil {
+				return nil, err
+			}
+			obj[k] = value
+		}
+		return obj, nil
+	default:
+		return raw, nil
+	}
+}
+
+// included evaluates the @include(if:) and @skip(if:) directives of a field
+func (e *gqlExecutor) included(sel *gqlSelection) (bool, error) {
+	include := true
+	for _, d := range sel.directives {
+		if d.name != "include" && d.name != "skip" {
+			return false, fmt.Errorf("unknown directive @%s", d.name)
+		}
+		value, err := e.value(d.args["if"])
+		if err != nil {
+			return false, err
+		}
+		cond, ok := value.(bool)
+		if !ok {
+			return false, fmt.Errorf("@%s requires a boolean \"if\" argument", d.name)
+		}
+		if (d.name == "include") != cond {
+			include = false
+		}
+	}
+	return include, nil
+}
+
+// toJSONValue decodes a value's JSON encoding into maps, slices and scalars
+func toJSONValue(value any) any {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil
+	}
+	var decoded any
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return nil
+	}
+	return decoded
+}
+
+// toJSONMap decodes an object's JSON encoding; fields left out by omitempty are absent
+func toJSONMap(value any) map[string]any {
+	fields, _ := toJSONValue(value).(map[string]any)
+	if fields == nil {
+		fields = map[string]any{}
+	}
+	return fields
+}
+
+var gqlModelFieldsCache sync.Map // reflect.Type -> map[string]bool
+
+// gqlModelFields returns the JSON field names of a model struct, including
+// those of embedded structs
+func gqlModelFields(t reflect.Type) map[string]bool {
+	if cached, ok := gqlModelFieldsCache.Load(t); ok {
+		return cached.(map[string]bool)
+	}
+
+	fields := make(map[string]bool)
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+		if field.Anonymous && name == "" {
+			ft := field.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				for embedded := range gqlModelFields(ft) {
+					fields[embedded] = true
+				}
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		fields[name] = true
+	}
+
+	gqlModelFieldsCache.Store(t, fields)
+	return fields
+}
@@ -0,0 +1,485 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// This file parses the subset of GraphQL served by /api/graphql: query
+// operations with variables, aliases, arguments, nested selections and the
+// @include/@skip directives. Fragments, mutations and subscriptions are rejected.
+
+// gqlTokenKind classifies a lexer token
+type gqlTokenKind int
+
+const (
+	gqlEOF gqlTokenKind = iota
+	gqlPunct
+	gqlName
+	gqlInt
+	gqlFloat
+	gqlString
+)
+
+type gqlToken struct {
+	kind  gqlTokenKind
+	value string
+	pos   int
+}
+
+// gqlOperation is a parsed query operation
+type gqlOperation struct {
+	name      string
+	variables []gqlVariable
+	selection []*gqlSelection
+}
+
+// gqlVariable is a declared operation variable
+type gqlVariable struct {
+	name       string
+	nonNull    bool
+	defaultVal any // parsed literal, nil if none
+	hasDefault bool
+}
+
+// gqlSelection is one field of a selection set
+type gqlSelection struct {
+	alias      string // response key, the field name unless aliased
+	name       string
+	args       map[string]any // values are literals or gqlVarRef
+	directives []gqlDirective
+	selection  []*gqlSelection // nil for leaf fields
+}
+
+type gqlDirective struct {
+	name string
+	args map[string]any
+}
+
+// gqlVarRef is a $variable used as an argument value
+type gqlVarRef string
+
+// gqlEnum is an enum literal (an unquoted name other than true/false/null)
+type gqlEnum string
+
+type gqlParser struct {
+	src    string
+	tokens []gqlToken
+	pos    int
+}
+
+// parseGraphQL parses a document and returns the operation to execute:
+// the one named operationName, or the only operation in the document
+func parseGraphQL(src, operationName string) (*gqlOperation, error) {
+	tokens, err := lexGraphQL(src)
+	if err != nil {
+		return nil, err
+	}
+	p := &gqlParser{src: src, tokens: tokens}
+
+	var ops []*gqlOperation
+	for p.peek().kind != gqlEOF {
+		op, err := p.parseOperation()
+		if err != nil {
+			return nil, err
+		}
+		ops = append(ops, op)
+	}
+
+	switch {
+	case len(ops) == 0:
+		return nil, fmt.Errorf("document contains no operation")
+	case operationName != "":
+		for _, op := range ops {
+			if op.name == operationName {
+				return op, nil
+			}
+		}
+		return nil, fmt.Errorf("unknown operation %q", operationName)
+	case len(ops) > 1:
+		return nil, fmt.Errorf("operationName is required when the document contains several operations")
+	default:
+		return ops[0], nil
+	}
+}
+
+func (p *gqlParser) peek() gqlToken {
+	return p.tokens[p.pos]
+}
+
+func (p *gqlParser) next() gqlToken {
+	t := p.tokens[p.pos]
+	if t.kind != gqlEOF {
+		p.pos++
+	}
+	return t
+}
+
+// isPunct reports whether the next token is the punctuator s
+func (p *gqlParser) isPunct(s string) bool {
+	t := p.peek()
+	return t.kind == gqlPunct && t.value == s
+}
+
+func (p *gqlParser) expectPunct(s string) error {
+	if !p.isPunct(s) {
+		return p.errorf("expected %q", s)
+	}
+	p.next()
+	return nil
+}
+
+func (p *gqlParser) expectName() (string, error) {
+	t := p.peek()
+	if t.kind != gqlName {
+		return "", p.errorf("expected a name")
+	}
+	p.next()
+	return t.value, nil
+}
+
+// errorf reports a syntax error at the next token, with its line and column
+func (p *gqlParser) errorf(format string, args ...any) error {
+	t := p.peek()
+	line := strings.Count(p.src[:t.pos], "\n") + 1
+	col := t.pos - strings.LastIndex(p.src[:t.pos], "\n")
+	found := t.value
+	if t.kind == gqlEOF {
+		found = "end of document"
+	}
+	return fmt.Errorf("syntax error at %d:%d (found %q): %s", line, col, found, fmt.Sprintf(format, args...))
+}
+
+func (p *gqlParser) parseOperation() (*gqlOperation, error) {
+	op := &gqlOperation{}
+	if p.isPunct("{") {
+		sel, err := p.parseSelectionSet()
+		op.selection = sel
+		return op, err
+	}
+
+	keyword, err := p.expectName()
+	if err != nil {
+		return nil, err
+	}
+	switch keyword {
+	case "query":
+	case "mutation", "subscription":
+		return nil, fmt.Errorf("%s operations are not supported, only queries", keyword)
+	case "fragment":
+		return nil, fmt.Errorf("fragments are not supported")
+	default:
+		return nil, fmt.Errorf("unexpected %q, expected an operation", keyword)
+	}
+
+	if p.peek().kind == gqlName {
+		op.name = p.next().value
+	}
+	if p.isPunct("(") {
+		if op.variables, err = p.parseVariableDefinitions(); err != nil {
+			return nil, err
+		}
+	}
+	if p.isPunct("@") {
+		return nil, fmt.Errorf("directives on operations are not supported")
+	}
+	op.selection, err = p.parseSelectionSet()
+	return op, err
+}
+
+func (p *gqlParser) parseVariableDefinitions() ([]gqlVariable, error) {
+	p.next() // (
+	var vars []gqlVariable
+	for !p.isPunct(")") {
+		if err := p.expectPunct("$"); err != nil {
+			return nil, err
+		}
+		name, err := p.expectName()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expectPunct(":"); err != nil {
+			return nil, err
+		}
+		nonNull, err := p.parseType()
+		if err != nil {
+			return nil, err
+		}
+		v := gqlVariable{name: name, nonNull: nonNull}
+		if p.isPunct("=") {
+			p.next()
+			if v.defaultVal, err = p.parseValue(true); err != nil {
+				return nil, err
+			}
+			v.hasDefault = true
+		}
+		vars = append(vars, v)
+	}
+	p.next() // )
+	return vars, nil
+}
+
+// parseType skips a type reference (Int, [String!]!, …) and reports whether
+// the outer type is non-null. Types are not checked beyond that.
+func (p *gqlParser) parseType() (bool, error) {
+	if p.isPunct("[") {
+		p.next()
+		if _, err := p.parseType(); err != nil {
+			return false, err
+		}
+		if err := p.expectPunct("]"); err != nil {
+			return false, err
+		}
+	} else if _, err := p.expectName(); err != nil {
+		return false, err
+	}
+	if p.isPunct("!") {
+		p.next()
+		return true, nil
+	}
+	return false, nil
+}
+
+func (p *gqlParser) parseSelectionSet() ([]*gqlSelection, error) {
+	if err := p.expectPunct("{"); err != nil {
+		return nil, err
+	}
+	var sels []*gqlSelection
+	for !p.isPunct("}") {
+		if p.isPunct("...") {
+			return nil, fmt.Errorf("fragments are not supported")
+		}
+		sel, err := p.parseField()
+		if err != nil {
+			return nil, err
+		}
+		sels = append(sels, sel)
+	}
+	p.next() // }
+	if len(sels) == 0 {
+		return nil, p.errorf("empty selection set")
+	}
+	return sels, nil
+}
+
+func (p *gqlParser) parseField() (*gqlSelection, error) {
+	name, err := p.expectName()
+	if err != nil {
+		return nil, err
+	}
+	sel := &gqlSelection{alias: name, name: name}
+	if p.isPunct(":") {
+		p.next()
+		if sel.name, err = p.expectName(); err != nil {
+			return nil, err
+		}
+	}
+	if p.isPunct("(") {
+		if sel.args, err = p.parseArguments(); err != nil {
+			return nil, err
+		}
+	}
+	for p.isPunct("@") {
+		p.next()
+		d := gqlDirective{}
+		if d.name, err = p.expectName(); err != nil {
+			return nil, err
+		}
+		if p.isPunct("(") {
+			if d.args, err = p.parseArguments(); err != nil {
+				return nil, err
+			}
+		}
+		sel.directives = append(sel.directives, d)
+	}
+	if p.isPunct("{") {
+		if sel.selection, err = p.parseSelectionSet(); err != nil {
+			return nil, err
+		}
+	}
+	return sel, nil
+}
+
+func (p *gqlParser) parseArguments() (map[string]any, error) {
+	p.next() // (
+	args := make(map[string]any)
+	for !p.isPunct(")") {
+		name, err := p.expectName()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expectPunct(":"); err != nil {
+			return nil, err
+		}
+		if args[name], err = p.parseValue(false); err != nil {
+			return nil, err
+		}
+	}
+	p.next() // )
+	return args, nil
+}
+
+// parseValue parses a value literal; constant values (variable defaults) may
+// not reference variables
+func (p *gqlParser) parseValue(constant bool) (any, error) {
+	t := p.peek()
+	switch t.kind {
+	case gqlInt:
+		p.next()
+		n, err := strconv.ParseInt(t.value, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid integer %s", t.value)
+		}
+		return n, nil
+	case gqlFloat:
+		p.next()
+		f, err := strconv.ParseFloat(t.value, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %s", t.value)
+		}
+		return f, nil
+	case gqlString:
+		p.next()
+		return t.value, nil
+	case gqlName:
+		p.next()
+		switch t.value {
+		case "true":
+			return true, nil
+		case "false":
+			return false, nil
+		case "null":
+			return nil, nil
+		}
+		return gqlEnum(t.value), nil
+	case gqlPunct:
+		switch t.value {
+		case "$":
+			if constant {
+				return nil, p.errorf("variables are not allowed here")
+			}
+			p.next()
+			name, err := p.expectName()
+			return gqlVarRef(name), err
+		case "[":
+			p.next()
+			list := []any{}
+			for !p.isPunct("]") {
+				v, err := p.parseValue(constant)
+				if err != nil {
+					return nil, err
+				}
+				list = append(list, v)
+			}
+			p.next()
+			return list, nil
+		case "{":
+			p.next()
+			obj := map[string]any{}
+			for !p.isPunct("}") {
+				name, err := p.expectName()
+				if err != nil {
+					return nil, err
+				}
+				if err := p.expectPunct(":"); err != nil {
+					return nil, err
+				}
+				if obj[name], err = p.parseValue(constant); err != nil {
+					return nil, err
+				}
+			}
+			p.next()
+			return obj, nil
+		}
+	}
+	return nil, p.errorf("expected a value")
+}
+
+// lexGraphQL splits a document into tokens, dropping whitespace, commas and comments
+func lexGraphQL(src string) ([]gqlToken, error) {
+	var tokens []gqlToken
+	i := 0
+	for i < len(src) {
+		ch := src[i]
+		switch {
+		case ch == ' ' || ch == '\t' || ch == '\n' || ch == '\r' || ch == ',':
+			i++
+		case ch == '#':
+			for i < len(src) && src[i] != '\n' {
+				i++
+			}
+		case strings.HasPrefix(src[i:], "..."):
+			tokens = append(tokens, gqlToken{kind: gqlPunct, value: "...", pos: i})
+			i += 3
+		case strings.IndexByte("{}()[]:!$=@", ch) >= 0:
+			tokens = append(tokens, gqlToken{kind: gqlPunct, value: string(ch), pos: i})
+			i++
+		case ch == '_' || isASCIILetter(ch):
+			start := i
+			for i < len(src) && (src[i] == '_' || isASCIILetter(src[i]) || isASCIIDigit(src[i])) {
+				i++
+			}
+			tokens = append(tokens, gqlToken{kind: gqlName, value: src[start:i], pos: start})
+		case ch == '-' || isASCIIDigit(ch):
+			start := i
+			kind := gqlInt
+			i++
+			for i < len(src) && isASCIIDigit(src[i]) {
+				i++
+			}
+			if i < len(src) && src[i] == '.' {
+				kind = gqlFloat
+				i++
+				for i < len(src) && isASCIIDigit(src[i]) {
+					i++
+				}
+			}
+			if i < len(src) && (src[i] == 'e' || src[i] == 'E') {
+				kind = gqlFloat
+				i++
+				if i < len(src) && (src[i] == '+' || src[i] == '-') {
+					i++
+				}
+				for i < len(src) && isASCIIDigit(src[i]) {
+					i++
+				}
+			}
+			tokens = append(tokens, gqlToken{kind: kind, value: src[start:i], pos: start})
+		case ch == '"':
+			if strings.HasPrefix(src[i:], `"""`) {
+				return nil, fmt.Errorf("block strings are not supported (at offset %d)", i)
+			}
+			start := i
+			i++
+			for i < len(src) && src[i] != '"' && src[i] != '\n' {
+				if src[i] == '\\' {
+					i++
+				}
+				i++
+			}
+			if i >= len(src) || src[i] != '"' {
+				return nil, fmt.Errorf("unterminated string at offset %d", start)
+			}
+			i++
+			// GraphQL string escapes are those of JSON
+			var value string
+			if err := json.Unmarshal([]byte(src[start:i]), &value); err != nil {
+				return nil, fmt.Errorf("invalid string at offset %d", start)
+			}
+			tokens = append(tokens, gqlToken{kind: gqlString, value: value, pos: start})
+		default:
+			return nil, fmt.Errorf("unexpected character %q at offset %d", ch, i)
+		}
+	}
+	return append(tokens, gqlToken{kind: gqlEOF, pos: len(src)}), nil
+}
+
+func isASCIILetter(ch byte) bool {
+	return ch >= 'a' && ch <= 'z' || ch >= 'A' && ch <= 'Z'
+}
+
+func isASCIIDigit(ch byte) bool {
+	return ch >= '0' && ch <= '9'
+}
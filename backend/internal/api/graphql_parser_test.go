@@ -0,0 +1,279 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestParseGraphQLMalformed(t *testing.T) {
+	tests := []struct {
+		name      string
+		query     string
+		operation string
+		wantErr   string
+	}{
+		{"empty document", "  # only a comment\n", "", "no operation"},
+		{"unclosed selection set", "{ products { id }", "", "syntax error"},
+		{"empty selection set", "{ }", "", "empty selection set"},
+		{"missing value", "{ products(limit: ) { id } }", "", "expected a value"},
+		{"missing colon", `{ product(id "1") { id } }`, "", `expected ":"`},
+		{"unclosed arguments", `{ product(id: "1" { id } }`, "", "syntax error"},
+		{"unterminated string", `{ products(category: "Mac) { id } }`, "", "unterminated string"},
+		{"newline in string", "{ products(category: \"Mac\n\") { id } }", "", "unterminated string"},
+		{"invalid escape", `{ products(category: "\q") { id } }`, "", "invalid string"},
+		{"block string", `{ products(category: """Mac""") { id } }`, "", "block strings are not supported"},
+		{"unexpected character", "{ products ~ }", "", "unexpected character"},
+		{"integer overflow", "{ products(limit: 99999999999999999999) { id } }", "", "invalid integer"},
+		{"mutation", "mutation { deleteProduct(id: 1) }", "", "mutation operations are not supported"},
+		{"subscription", "subscription { products { id } }", "", "subscription operations are not supported"},
+		{"fragment definition", "fragment F on Product { id }", "", "fragments are not supported"},
+		{"fragment spread", "{ products { ...F } }", "", "fragments are not supported"},
+		{"inline fragment", "{ products { ... on Product { id } } }", "", "fragments are not supported"},
+		{"unknown keyword", "select { id }", "", `unexpected "select"`},
+		{"operation directive", "query Q @cached { stats }", "", "directives on operations are not supported"},
+		{"variable without type", "query ($x: ) { stats }", "", "syntax error"},
+		{"variable in default", "query ($x: Int = $y) { stats }", "", "variables are not allowed here"},
+		{"several unnamed operations", "{ stats } { categories }", "", "operationName is required"},
+		{"unknown operation name", "query A { stats } query B { categories }", "C", `unknown operation "C"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			op, err := parseGraphQL(tt.query, tt.operation)
+			if err == nil {
+				t.Fatalf("parseGraphQL(%q) = %+v, want error containing %q", tt.query, op, tt.wantErr)
+			}
+			if !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("parseGraphQL(%q) error = %q, want it to contain %q", tt.query, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParseGraphQLSyntaxErrorPosition(t *testing.T) {
+	_, err := parseGraphQL("{\n  products(limit: ) { id }\n}", "")
+	if err == nil || !strings.Contains(err.Error(), "at 2:19") {
+		t.Errorf("error = %v, want the position 2:19 of the closing parenthesis", err)
+	}
+}
+
+func TestParseGraphQLArgumentTypes(t *testing.T) {
+	op, err := parseGraphQL(`
+		# Every kind of value literal
+		query Catalog($cat: String = "Mac", $n: Int!) {
+			p: products(
+				category: $cat, limit: 5, neg: -2, ratio: 1.5, big: 1e3,
+				flag: true, off: false, none: null, sort: PRICE,
+				ids: ["a", "b"], where: {min: 10, tag: $cat}, text: "\"quoted\" é"
+			) @include(if: $flag) @skip(if: false) {
+				id
+			}
+		}`, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if op.name != "Catalog" {
+		t.Errorf("name = %q, want Catalog", op.name)
+	}
+	wantVars := []gqlVariable{
+		{name: "cat", defaultVal: "Mac", hasDefault: true},
+		{name: "n", nonNull: true},
+	}
+	if !reflect.DeepEqual(op.variables, wantVars) {
+		t.Errorf("variables = %+v, want %+v", op.variables, wantVars)
+	}
+
+	if len(op.selection) != 1 {
+		t.Fatalf("selection has %d fields, want 1", len(op.selection))
+	}
+	sel := op.selection[0]
+	if sel.alias != "p" || sel.name != "products" {
+		t.Errorf("alias, name = %q, %q, want p, products", sel.alias, sel.name)
+	}
+
+	tests := []struct {
+		arg  string
+		want any
+	}{
+		{"category", gqlVarRef("cat")},
+		{"limit", int64(5)},
+		{"neg", int64(-2)},
+		{"ratio", 1.5},
+		{"big", 1000.0},
+		{"flag", true},
+		{"off", false},
+		{"none", nil},
+		{"sort", gqlEnum("PRICE")},
+		{"ids", []any{"a", "b"}},
+		{"where", map[string]any{"min": int64(10), "tag": gqlVarRef("cat")}},
+		{"text", `"quoted" é`},
+	}
+	for _, tt := range tests {
+		got, ok := sel.args[tt.arg]
+		if !ok {
+			t.Errorf("argument %s missing", tt.arg)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("argument %s = %#v, want %#v", tt.arg, got, tt.want)
+		}
+	}
+
+	wantDirectives := []gqlDirective{
+		{name: "include", args: map[string]any{"if": gqlVarRef("flag")}},
+		{name: "skip", args: map[string]any{"if": false}},
+	}
+	if !reflect.DeepEqual(sel.directives, wantDirectives) {
+		t.Errorf("directives = %+v, want %+v", sel.directives, wantDirectives)
+	}
+}
+
+func TestParseGraphQLOperationName(t *testing.T) {
+	doc := "query A { stats } query B { categories }"
+	for name, field := range map[string]string{"A": "stats", "B": "categories"} {
+		op, err := parseGraphQL(doc, name)
+		if err != nil {
+			t.Fatalf("operation %s: %v", name, err)
+		}
+		if op.selection[0].name != field {
+			t.Errorf("operation %s selects %s, want %s", name, op.selection[0].name, field)
+		}
+	}
+}
+
+func TestGQLArgs(t *testing.T) {
+	args := gqlArgs{"s": "Mac", "lit": int64(3), "json": 4.0, "frac": 4.5, "list": []any{"a"}}
+
+	tests := []struct {
+		name    string
+		get     func() (any, error)
+		want    any
+		wantErr bool
+	}{
+		{"string", func() (any, error) { return args.str("s") }, "Mac", false},
+		{"omitted string", func() (any, error) { return args.str("missing") }, "", false},
+		{"integer as string", func() (any, error) { return args.str("lit") }, "", true},
+		{"integer literal", func() (any, error) { return args.int("lit", 0) }, 3, false},
+		{"integer from JSON variables", func() (any, error) { return args.int("json", 0) }, 4, false},
+		{"omitted integer", func() (any, error) { return args.int("missing", 20) }, 20, false},
+		{"fractional integer", func() (any, error) { return args.int("frac", 0) }, 0, true},
+		{"string as integer", func() (any, error) { return args.int("s", 0) }, 0, true},
+		{"list as integer", func() (any, error) { return args.int("list", 0) }, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.get()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, want error %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("got %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOperationVariables(t *testing.T) {
+	op, err := parseGraphQL(`query ($cat: String = "Mac", $limit: Int!, $region: String) { stats }`, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	vars, err := operationVariables(op, map[string]any{"limit": 5.0})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]any{"cat": "Mac", "limit": 5.0, "region": nil}
+	if !reflect.DeepEqual(vars, want) {
+		t.Errorf("variables = %v, want %v", vars, want)
+	}
+
+	for _, values := range []map[string]any{nil, {"limit": nil}} {
+		if _, err := operationVariables(op, values); err == nil || !strings.Contains(err.Error(), "$limit is required") {
+			t.Errorf("operationVariables(%v) error = %v, want $limit is required", values, err)
+		}
+	}
+}
+
+func TestSelectionDepth(t *testing.T) {
+	tests := []struct {
+		query string
+		want  int
+	}{
+		{"{ stats }", 1},
+		{"{ stats categories }", 1},
+		{"{ products { id } }", 2},
+		{"{ products { id history { price } } stats }", 3},
+		{"{ a { b { c { d { e } } } } }", 5},
+	}
+
+	for _, tt := range tests {
+		op, err := parseGraphQL(tt.query, "")
+		if err != nil {
+			t.Fatalf("parseGraphQL(%q): %v", tt.query, err)
+		}
+		if got := selectionDepth(op.selection); got != tt.want {
+			t.Errorf("selectionDepth(%q) = %d, want %d", tt.query, got, tt.want)
+		}
+	}
+}
+
+// nestedQuery returns a query whose selection nests depth levels deep
+func nestedQuery(depth int) string {
+	return strings.Repeat("{ a ", depth-1) + "{ a }" + strings.Repeat(" }", depth-1)
+}
+
+func TestGraphQLRejectsDeepAndMalformedQueries(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/api/graphql", (&Handlers{}).GraphQL)
+
+	tests := []struct {
+		name    string
+		query   string
+		wantErr string
+	}{
+		{"too deep", nestedQuery(maxGraphQLDepth + 1), "exceeds the maximum of"},
+		{"too long", "{ " + strings.Repeat("stats ", maxGraphQLQueryBytes/6+1) + "}", "query exceeds"},
+		{"malformed", "{ products(", "syntax error"},
+		{"empty", "   ", "query is required"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, _ := json.Marshal(GraphQLRequest{Query: tt.query})
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/graphql", strings.NewReader(string(body))))
+
+			if w.Code != http.StatusBadRequest {
+				t.Fatalf("status = %d, want 400", w.Code)
+			}
+			var resp struct {
+				Errors []GraphQLError `json:"errors"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatal(err)
+			}
+			if len(resp.Errors) != 1 || !strings.Contains(resp.Errors[0].Message, tt.wantErr) {
+				t.Errorf("errors = %+v, want one containing %q", resp.Errors, tt.wantErr)
+			}
+		})
+	}
+
+	// The deepest allowed query gets past the depth check
+	op, err := parseGraphQL(nestedQuery(maxGraphQLDepth), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if depth := selectionDepth(op.selection); depth != maxGraphQLDepth {
+		t.Errorf("nestedQuery(%d) has depth %d", maxGraphQLDepth, depth)
+	}
+}
@@ -1,7 +1,8 @@
 package api
 
 import (
-	"encoding/json"
+	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"sort"
@@ -10,42 +11,120 @@ import (
 	"time"
 
 	"apple-price/internal/model"
+	"apple-price/internal/notify"
 
 	"github.com/gin-gonic/gin"
 )
 
-// StoreInterface defines the store interface needed by handlers
-type StoreInterface interface {
+// ProductStore is the catalogue part of the store used by handlers
+type ProductStore interface {
 	GetAllProducts() []*model.Product
 	GetProduct(id string) (*model.Product, bool)
 	GetProductsByCategory(category string) []*model.Product
 	GetProductsByRegion(region string) []*model.Product
+	FindProducts(filter model.ProductFilter) []*model.Product
+	GetFilterOptions(category string) model.FilterOptions
+	EditProduct(id string, fields map[string]string, pin bool) (*model.Product, error)
+	GetProductOverride(id string) (*model.ProductOverride, bool)
+	ProductsVersion() uint64
 	GetPriceHistory(productID string) []model.PriceHistory
+	GetPricesAt(t time.Time) map[string]float64
+	GetPriceStats(productID string) (*model.PriceStats, bool)
+	GetScoreBreakdown(productID string) (*model.ScoreBreakdown, bool)
+	GetRetailerPrices(productID string) []model.RetailerPrice
+	GetProductEvents(productID string) []model.ProductEvent
+	GetPriceHistoryBetween(since, until time.Time) []model.PriceHistory
+	GetProductEventsBetween(since, until time.Time) []model.ProductEvent
+	AddAnnotation(annotation *model.PriceAnnotation) error
+	DeleteAnnotation(id string) error
+	GetAnnotations(from, to string) []model.PriceAnnotation
+	GetInventoryVelocity() model.InventoryVelocityIndex
 	GetCategories() []string
+	GetCategoryDefaultSorts() map[string]string
+	SetCategoryDefaultSort(category, sortBy string) error
+	GetRegions() []*model.Region
+	GetRegion(code string) (*model.Region, bool)
+	UpsertRegion(region *model.Region) error
+	DeleteRegion(code string) error
+}
+
+// SubscriptionStore is the subscription part of the store used by handlers
+type SubscriptionStore interface {
 	AddSubscription(sub *model.Subscription) error
 	RemoveSubscription(id string) error
+	UpdateSubscription(sub *model.Subscription) error
+	SetSubscriptionPaused(id string, paused bool) error
+	GetSubscription(id string) (*model.Subscription, bool)
 	GetSubscriptionsByProduct(productID string) []*model.Subscription
+	GetSubscriptionsByBarkKey(barkKey string) []*model.Subscription
 	GetAllSubscriptions() []*model.Subscription
-	GetStats() *model.Stats
-	DeleteProductsByRegion(region string) (int, error)
-	Save() error
 	AddNewArrivalSubscription(sub *model.NewArrivalSubscription) error
 	RemoveNewArrivalSubscription(id string) error
+	GetDeletedNewArrivalSubscription(id string) (*model.NewArrivalSubscription, bool)
+	RestoreNewArrivalSubscription(id string) (*model.NewArrivalSubscription, error)
 	GetAllNewArrivalSubscriptions() []*model.NewArrivalSubscription
 	GetNewArrivalSubscriptionsByBarkKey(barkKey string) []*model.NewArrivalSubscription
 	GetNewArrivalSubscription(id string) (*model.NewArrivalSubscription, bool)
 
-	// Notification history operations
-	AddNotificationHistory(history *model.NotificationHistory) error
-	GetNotificationHistory(subscriptionID string, barkKey string, limit, offset int) ([]*model.NotificationHistory, int)
-	MarkNotificationAsRead(id string) error
-	GetUnreadNotificationCount() int
-
 	// Subscription management operations
 	UpdateNewArrivalSubscription(sub *model.NewArrivalSubscription) error
 	PauseSubscription(id string) error
 	ResumeSubscription(id string) error
 	IncrementNotificationCount(id string) error
+	MigrateBarkKey(oldKey, newKey string) (*model.KeyMigrationResult, error)
+	GetPreferences(barkKey string) *model.UserPreferences
+	SetPreferences(prefs *model.UserPreferences) error
+	SetAllPaused(barkKey string, paused bool) (int, error)
+	GetDeliveryHealth(barkKey string) *model.DeliveryHealth
+
+	// User operations
+	AddUser(user *model.User) error
+	UpdateUser(user *model.User) error
+	GetUserByBarkKey(barkKey string) (*model.User, bool)
+	GetUserByAPIKeyHash(hash string) (*model.User, bool)
+
+	// Watchlist operations
+	AddWatchlist(watchlist *model.Watchlist) error
+	GetWatchlists(clientToken string) []*model.Watchlist
+	GetWatchlist(id string) (*model.Watchlist, bool)
+	DeleteWatchlist(id string) error
+	AddSavedSearch(search *model.SavedSearch) error
+	GetSavedSearch(slug string) (*model.SavedSearch, bool)
+}
+
+// NotificationStore is the notification history part of the store used by handlers
+type NotificationStore interface {
+	AddNotificationHistory(history *model.NotificationHistory) error
+	GetNotificationHistory(subscriptionID string, barkKey string, limit, offset int) ([]*model.NotificationHistory, int)
+	MarkNotificationAsRead(id string) error
+	GetUnreadNotificationCount() int
+}
+
+// StoreInterface defines the store interface needed by handlers
+type StoreInterface interface {
+	ProductStore
+	SubscriptionStore
+	NotificationStore
+
+	GetStats() *model.Stats
+	GetStatsTimeline(category, region string, since time.Time) []model.DailyCategoryStats
+	GetStatsHistory(region string, since time.Time) []model.StatsSnapshot
+	GetPriceIndexTimeline(region string, since time.Time) []model.DailyPriceIndex
+	PreviewRegionDeletion(region string) *model.RegionDeletion
+	DeleteProductsByRegion(region string) (*model.RegionDeletion, error)
+	PreviewStaleDeletion(before time.Time) *model.RegionDeletion
+	DeleteStaleProducts(before time.Time) (*model.RegionDeletion, error)
+	UndoRegionDeletion(id string) (*model.RegionDeletion, error)
+	GetRegionDeletions() []*model.RegionDeletion
+	ArchiveProductsByRegion(region string) (int, error)
+	RestoreProducts(ids []string) (int, error)
+	GetArchivedProducts(region string) []*model.Product
+	GetScraperStatus() *model.ScraperStatus
+	GetRegionScraperStatuses() []*model.ScraperStatus
+	GetLastScoreRecompute() *model.ScoreRecompute
+	GetScrapeRuns(limit int) []*model.ScrapeRun
+	GetScrapeRun(id string) (*model.ScrapeRun, bool)
+	Save() error
 }
 
 // Handlers contains all API handlers
@@ -53,17 +132,37 @@ type Handlers struct {
 	store      StoreInterface
 	dispatcher PriceChangeNotifier
 	scheduler  SchedulerInterface
+	storage    StorageChecker
+	usage      UsageTracker
+	currency   CurrencyConverter
+	readiness  ReadinessChecker
+	images     ImageCache
+	cache      *responseCache
+}
+
+// ReadinessChecker reports whether the store, scheduler and the rest of the
+// service are ready for traffic
+type ReadinessChecker interface {
+	Readiness() model.Readiness
 }
 
 // PriceChangeNotifier interface for handlers
 type PriceChangeNotifier interface {
 	NotifyPriceChange(product *model.Product, oldPrice, newPrice float64, subscriptions []*model.Subscription) error
+	SendTestNotification(barkKey string) error
+	SendSubscriptionTest(sub *model.Subscription, product *model.Product) (*model.NotificationHistory, error)
+	ValidateBarkKey(barkKey, barkServer string) (*notify.KeyValidation, error)
 }
 
 // SchedulerInterface defines the scheduler interface for handlers
 type SchedulerInterface interface {
-	ScrapeNow() error
+	StartScrape(queue bool, scope model.ScrapeScope) (id string, queued bool, err error)
+	DryRunScrape(ctx context.Context) (*model.ScrapeDryRun, error)
+	ActiveRuns() []*model.ScrapeRun
 	GetScrapeStatus() any
+	CancelScrape() bool
+	SubscribeProgress() (<-chan model.ScrapeProgress, func())
+	RequeueDetail(productID string) error
 }
 
 // NewHandlers creates a new handlers instance
@@ -72,28 +171,199 @@ func NewHandlers(store StoreInterface, dispatcher PriceChangeNotifier, scheduler
 		store:      store,
 		dispatcher: dispatcher,
 		scheduler:  scheduler,
+		cache:      newResponseCache(),
 	}
 }
 
-// HealthCheck returns the health status
+// HealthCheck returns the health status.
+// Read-only storage reports "degraded" but stays 200: reads are still served
+// and restarting the container won't free disk space.
 func (h *Handlers) HealthCheck(c *gin.Context) {
-	c.JSON(http.StatusOK, gin.H{
+	resp := gin.H{
 		"status":    "ok",
 		"timestamp": time.Now().Unix(),
-	})
+	}
+
+	if h.storage != nil {
+		storage := h.storage.Status()
+		resp["storage"] = storage
+		if storage.ReadOnly {
+			resp["status"] = "degraded"
+		}
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// ReadinessCheck reports whether the service is ready for traffic: 200 when every
+// check passes, 503 while starting up, shutting down or when a check fails
+func (h *Handlers) ReadinessCheck(c *gin.Context) {
+	readiness := model.Readiness{Ready: true, Checks: map[string]string{}}
+	if h.readiness != nil {
+		readiness = h.readiness.Readiness()
+	}
+
+	status := http.StatusOK
+	if !readiness.Ready {
+		status = http.StatusServiceUnavailable
+	}
+	c.JSON(status, readiness)
 }
 
-// GetProducts returns all products with optional filters
+// defaultProductsLimit caps product lists requested without any filter or limit,
+// so an accidental poll of the whole catalog stays small
+const defaultProductsLimit = 100
+
+// GetProducts returns all products with optional filters.
+// Unfiltered requests are limited to defaultProductsLimit unless all=true or an
+// explicit limit is given; total reports how many products matched.
+// Responses are cached until the catalog changes and support ETag/If-Modified-Since.
 func (h *Handlers) GetProducts(c *gin.Context) {
-	// Get filters
-	category := c.Query("category")
-	region := c.Query("region")
-	sortBy := c.Query("sort") // price, discount, score, created
-	order := c.Query("order") // asc, desc
+	// The category's configured default sort is part of the key, so changing it
+	// doesn't leave clients on a cached order
+	specs, err := parseSpecFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	key := "products?" + c.Request.URL.Query().Encode() + "|default_sort=" + h.defaultSort(c.Query("category"))
+	h.serveProductResponse(c, key, func() (int, any, int) {
+		products := h.listProducts(c, specs)
+		total := len(products)
+
+		limit := productsLimit(c)
+		if limit > 0 && len(products) > limit {
+			products = products[:limit]
+		}
+		h.applyInventoryVelocity(products)
+
+		return http.StatusOK, gin.H{
+			"count":     len(products),
+			"total":     total,
+			"truncated": len(products) < total,
+			"products":  products,
+		}, len(products)
+	})
+}
+
+// productsLimit returns how many products a list request may return, 0 for all:
+// an explicit limit wins, all=true or any filter lifts the default quota
+func productsLimit(c *gin.Context) int {
+	if v := c.Query("limit"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			return n
+		}
+	}
+	if c.Query("all") == "true" {
+		return 0
+	}
+	for _, filter := range []string{"category", "region", "stock_status", "stability", "chip", "memory", "storage", "model", "screen_size", "color", "min_price", "max_price"} {
+		if c.Query(filter) != "" {
+			return 0
+		}
+	}
+	return defaultProductsLimit
+}
+
+// defaultSort returns the default sort configured for a category, "" if none
+func (h *Handlers) defaultSort(category string) string {
+	if category == "" {
+		return ""
+	}
+	return h.store.GetCategoryDefaultSorts()[category]
+}
+
+// productQuery holds the product list filters and sorting
+type productQuery struct {
+	Category    string
+	Region      string
+	Sort        string // price, discount, score, created, savings
+	Order       string // asc, desc
+	StockStatus string
+	Stability   string
+	Specs       model.ProductFilter // spec and price filters; category and region come from above
+}
+
+// filtered reports whether any filter narrows the list
+func (q productQuery) filtered() bool {
+	return q.Category != "" || q.Region != "" || q.StockStatus != "" || q.Stability != "" || q.Specs.HasSpecs()
+}
+
+// listProducts applies the GetProducts filters and sorting
+func (h *Handlers) listProducts(c *gin.Context, specs model.ProductFilter) []*model.Product {
+	return h.queryProducts(productQuery{
+		Category:    c.Query("category"),
+		Region:      c.Query("region"),
+		Sort:        c.Query("sort"),
+		Order:       c.Query("order"),
+		StockStatus: c.Query("stock_status"),
+		Stability:   c.Query("stability"),
+		Specs:       specs,
+	})
+}
+
+// parseSpecFilter reads the spec filters of a product list request: chip, memory,
+// storage, model, screen_size and color take comma-separated values as listed by
+// /api/filter-options, min_price and max_price bound the price
+func parseSpecFilter(c *gin.Context) (model.ProductFilter, error) {
+	filter := model.ProductFilter{
+		Chips:       splitList(c.Query("chip")),
+		Memories:    splitList(c.Query("memory")),
+		Storages:    splitList(c.Query("storage")),
+		Models:      splitList(c.Query("model")),
+		ScreenSizes: splitList(c.Query("screen_size")),
+		Colors:      splitList(c.Query("color")),
+	}
+	for param, dst := range map[string]*float64{"min_price": &filter.MinPrice, "max_price": &filter.MaxPrice} {
+		v := c.Query(param)
+		if v == "" {
+			continue
+		}
+		price, err := strconv.ParseFloat(v, 64)
+		if err != nil || price < 0 {
+			return filter, fmt.Errorf("invalid %s: %q", param, v)
+		}
+		*dst = price
+	}
+	if filter.MaxPrice > 0 && filter.MinPrice > filter.MaxPrice {
+		return filter, fmt.Errorf("min_price must not exceed max_price")
+	}
+	return filter, nil
+}
+
+// splitList splits a comma-separated query value, dropping empty entries
+func splitList(value string) []string {
+	var values []string
+	for _, v := range strings.Split(value, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+// queryProducts returns the products matching q, sorted
+func (h *Handlers) queryProducts(q productQuery) []*model.Product {
+	category, region := q.Category, q.Region
+	sortBy, order := q.Sort, q.Order
+
+	// Without an explicit sort, use the category's configured default (descending)
+	if sortBy == "" {
+		sortBy = h.defaultSort(category)
+		if sortBy != "" && order == "" {
+			order = "desc"
+		}
+	}
 
 	// Get products
 	var products []*model.Product
-	if category != "" && region != "" {
+	if q.Specs.HasSpecs() {
+		// Spec filters run in the store, over its extracted spec columns
+		filter := q.Specs
+		filter.Category, filter.Region = category, region
+		products = h.store.FindProducts(filter)
+	} else if category != "" && region != "" {
 		// Filter by both
 		allProducts := h.store.GetAllProducts()
 		for _, p := range allProducts {
@@ -113,7 +383,7 @@ func (h *Handlers) GetProducts(c *gin.Context) {
 	products = sortProducts(products, sortBy, order)
 
 	// Filter by stock status if requested
-	if stockStatus := c.Query("stock_status"); stockStatus != "" {
+	if stockStatus := q.StockStatus; stockStatus != "" {
 		filtered := make([]*model.Product, 0)
 		for _, p := range products {
 			if p.StockStatus == stockStatus {
@@ -123,13 +393,18 @@ func (h *Handlers) GetProducts(c *gin.Context) {
 		products = filtered
 	}
 
-	c.Header("Cache-Control", "no-cache, no-store, must-revalidate")
-	c.Header("Pragma", "no-cache")
-	c.Header("Expires", "0")
-	c.JSON(http.StatusOK, gin.H{
-		"count":    len(products),
-		"products": products,
-	})
+	// Filter by price stability if requested
+	if stability := q.Stability; stability != "" {
+		filtered := make([]*model.Product, 0)
+		for _, p := range products {
+			if p.Stability == stability {
+				filtered = append(filtered, p)
+			}
+		}
+		products = filtered
+	}
+
+	return products
 }
 
 // GetProduct returns a single product by ID
@@ -140,13 +415,70 @@ func (h *Handlers) GetProduct(c *gin.Context) {
 		return
 	}
 
-	product, ok := h.store.GetProduct(id)
+	h.serveProductResponse(c, "product/"+id, func() (int, any, int) {
+		product, ok := h.store.GetProduct(id)
+		if !ok {
+			return http.StatusNotFound, gin.H{"error": "product not found"}, 0
+		}
+
+		// Velocity and retailer prices go on a copy
+		detail := h.productWithVelocity(product)
+		detail.RetailerPrices = h.store.GetRetailerPrices(id)
+		return http.StatusOK, detail, 1
+	})
+}
+
+// applyInventoryVelocity fills in the "usually gone within X hours" indicator.
+// Store products may be shared with other requests, so each is replaced by a copy.
+func (h *Handlers) applyInventoryVelocity(products []*model.Product) {
+	if len(products) == 0 {
+		return
+	}
+
+	velocity := h.store.GetInventoryVelocity()
+	for i, p := range products {
+		products[i] = withSellOutHours(p, velocity)
+	}
+}
+
+// productWithVelocity is applyInventoryVelocity for a single product
+func (h *Handlers) productWithVelocity(p *model.Product) *model.Product {
+	return withSellOutHours(p, h.store.GetInventoryVelocity())
+}
+
+// withSellOutHours returns a copy of p with its sell-out indicator filled in
+func withSellOutHours(p *model.Product, velocity model.InventoryVelocityIndex) *model.Product {
+	product := *p
+	if v := velocity.Lookup(p); v != nil {
+		product.SellOutHours = v.MedianHours
+	}
+	return &product
+}
+
+// GetProductStats returns min/max/average/median price, drops, the longest run at
+// the current price, time since the last change and where the current price ranks
+// GET /api/products/:id/stats
+func (h *Handlers) GetProductStats(c *gin.Context) {
+	stats, ok := h.store.GetPriceStats(c.Param("id"))
 	if !ok {
 		c.JSON(http.StatusNotFound, gin.H{"error": "product not found"})
 		return
 	}
 
-	c.JSON(http.StatusOK, product)
+	c.JSON(http.StatusOK, stats)
+}
+
+// GetProductScoreBreakdown returns the points each component adds to a product's
+// value score and why
+// GET /api/products/:id/score-breakdown
+func (h *Handlers) GetProductScoreBreakdown(c *gin.Context) {
+	breakdown, ok := h.store.GetScoreBreakdown(c.Param("id"))
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "product not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, breakdown)
 }
 
 // GetProductHistory returns price history for a product
@@ -157,7 +489,7 @@ func (h *Handlers) GetProductHistory(c *gin.Context) {
 		return
 	}
 
-	_, ok := h.store.GetProduct(id)
+	product, ok := h.store.GetProduct(id)
 	if !ok {
 		c.JSON(http.StatusNotFound, gin.H{"error": "product not found"})
 		return
@@ -183,19 +515,49 @@ func (h *Handlers) GetProductHistory(c *gin.Context) {
 		history = history[len(history)-limit:]
 	}
 
+	c.JSON(http.StatusOK, gin.H{
+		"product_id":  id,
+		"count":       len(history),
+		"history":     history,
+		"annotations": h.annotationsFor(product, history),
+	})
+}
+
+// GetProductEvents returns the availability timeline for a product (listed, sold out, restocked)
+func (h *Handlers) GetProductEvents(c *gin.Context) {
+	id := c.Param("id")
+	if id == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "product ID is required"})
+		return
+	}
+
+	if _, ok := h.store.GetProduct(id); !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "product not found"})
+		return
+	}
+
+	events := h.store.GetProductEvents(id)
+
 	c.JSON(http.StatusOK, gin.H{
 		"product_id": id,
-		"count":      len(history),
-		"history":    history,
+		"count":      len(events),
+		"events":     events,
 	})
 }
 
 // CreateSubscription creates a new subscription
 func (h *Handlers) CreateSubscription(c *gin.Context) {
 	var req struct {
-		ProductID   string  `json:"product_id" binding:"required"`
-		BarkKey     string  `json:"bark_key" binding:"required"`
-		TargetPrice float64 `json:"target_price"` // Optional target price for alert
+		ProductID       string  `json:"product_id"`
+		Spec            string  `json:"spec"` // Spec combo to watch instead of a product, e.g. "MacBook Pro 14 M4 Pro 48GB"
+		BarkKey         string  `json:"bark_key"`     // Defaults to the API key user's
+		TargetPrice     float64 `json:"target_price"` // Optional target price for alert
+		QuietHoursStart string  `json:"quiet_hours_start"`
+		QuietHoursEnd   string  `json:"quiet_hours_end"`
+		Timezone        string  `json:"timezone"`
+		LowStockAlert   bool    `json:"low_stock_alert"` // Also warn when the product is likely to sell out soon
+		BarkServer      string  `json:"bark_server"`     // Self-hosted Bark server (empty = default)
+		Language        string  `json:"language"`        // Message language (empty = owner's preference)
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -203,6 +565,59 @@ func (h *Handlers) CreateSubscription(c *gin.Context) {
 		return
 	}
 
+	if req.BarkKey == "" {
+		user, ok := h.currentUser(c)
+		if !ok {
+			return
+		}
+		if user != nil {
+			req.BarkKey = user.BarkKey
+		}
+	}
+	if req.BarkKey == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "bark_key is required"})
+		return
+	}
+	if !h.ownBarkKey(c, req.BarkKey) {
+		return
+	}
+
+	if err := model.ValidateQuietHours(req.QuietHoursStart, req.QuietHoursEnd, req.Timezone); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := model.ValidateLanguage(req.Language); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	barkServer, err := model.NormalizeBarkServer(req.BarkServer)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Without a product ID, watch a spec combo that may not be in the catalog yet:
+	// it becomes a new arrival subscription capped at the target price
+	if req.ProductID == "" {
+		if strings.TrimSpace(req.Spec) == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "product_id or spec is required"})
+			return
+		}
+		h.addNewArrivalSubscription(c, &model.NewArrivalSubscription{
+			Name:            strings.TrimSpace(req.Spec),
+			Spec:            req.Spec,
+			MaxPrice:        req.TargetPrice,
+			BarkKey:         req.BarkKey,
+			QuietHoursStart: req.QuietHoursStart,
+			QuietHoursEnd:   req.QuietHoursEnd,
+			Timezone:        req.Timezone,
+			BarkServer:      barkServer,
+			Language:        req.Language,
+		})
+		return
+	}
+
 	// Validate product exists
 	_, ok := h.store.GetProduct(req.ProductID)
 	if !ok {
@@ -212,11 +627,17 @@ func (h *Handlers) CreateSubscription(c *gin.Context) {
 
 	// Create subscription
 	sub := &model.Subscription{
-		ID:          generateID(),
-		ProductID:   req.ProductID,
-		BarkKey:     req.BarkKey,
-		TargetPrice: req.TargetPrice,
-		CreatedAt:   time.Now(),
+		ID:              generateID(),
+		ProductID:       req.ProductID,
+		BarkKey:         req.BarkKey,
+		TargetPrice:     req.TargetPrice,
+		QuietHoursStart: req.QuietHoursStart,
+		QuietHoursEnd:   req.QuietHoursEnd,
+		Timezone:        req.Timezone,
+		LowStockAlert:   req.LowStockAlert,
+		BarkServer:      barkServer,
+		Language:        model.NormalizeLanguage(req.Language),
+		CreatedAt:       time.Now(),
 	}
 
 	if err := h.store.AddSubscription(sub); err != nil {
@@ -226,8 +647,8 @@ func (h *Handlers) CreateSubscription(c *gin.Context) {
 
 	// Save to disk
 	if err := h.store.Save(); err != nil {
-		// Log error but don't fail the request
-		// The subscription is in memory
+		// Don't fail the request: the subscription is in memory
+		requestLogger(c).Error("Failed to save data", "error", err)
 	}
 
 	c.JSON(http.StatusCreated, sub)
@@ -241,6 +662,15 @@ func (h *Handlers) DeleteSubscription(c *gin.Context) {
 		return
 	}
 
+	sub, ok := h.store.GetSubscription(id)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "subscription not found"})
+		return
+	}
+	if !h.ownBarkKey(c, sub.BarkKey) {
+		return
+	}
+
 	if err := h.store.RemoveSubscription(id); err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "subscription not found"})
 		return
@@ -249,161 +679,239 @@ func (h *Handlers) DeleteSubscription(c *gin.Context) {
 	// Save to disk
 	if err := h.store.Save(); err != nil {
 		// Log error but don't fail the request
+		requestLogger(c).Error("Failed to save data", "error", err)
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "subscription deleted"})
 }
 
-// GetSubscriptions returns all subscriptions for a product
-func (h *Handlers) GetSubscriptions(c *gin.Context) {
-	productID := c.Query("product_id")
+// UpdateSubscription changes a price subscription's target price, quiet hours,
+// low stock alert, Bark server, language and paused state. Omitted fields go back
+// to their defaults; the product and Bark Key cannot be changed.
+// PUT /api/subscriptions/:id
+func (h *Handlers) UpdateSubscription(c *gin.Context) {
+	existing, ok := h.store.GetSubscription(c.Param("id"))
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "subscription not found"})
+		return
+	}
+	if !h.ownBarkKey(c, existing.BarkKey) {
+		return
+	}
 
-	if productID != "" {
-		subs := h.store.GetSubscriptionsByProduct(productID)
-		c.JSON(http.StatusOK, gin.H{
-			"count":         len(subs),
-			"subscriptions": subs,
-		})
-	} else {
-		subs := h.store.GetAllSubscriptions()
-		c.JSON(http.StatusOK, gin.H{
-			"count":         len(subs),
-			"subscriptions": subs,
-		})
+	var req struct {
+		TargetPrice     float64 `json:"target_price"`
+		QuietHoursStart string  `json:"quiet_hours_start"`
+		QuietHoursEnd   string  `json:"quiet_hours_end"`
+		Timezone        string  `json:"timezone"`
+		LowStockAlert   bool    `json:"low_stock_alert"`
+		BarkServer      string  `json:"bark_server"`
+		Language        string  `json:"language"`
+		Paused          *bool   `json:"paused"` // Unchanged when omitted
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if req.TargetPrice < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "target_price must not be negative"})
+		return
+	}
+	if err := model.ValidateQuietHours(req.QuietHoursStart, req.QuietHoursEnd, req.Timezone); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := model.ValidateLanguage(req.Language); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
+	barkServer, err := model.NormalizeBarkServer(req.BarkServer)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	sub := *existing
+	sub.TargetPrice = req.TargetPrice
+	sub.QuietHoursStart = req.QuietHoursStart
+	sub.QuietHoursEnd = req.QuietHoursEnd
+	sub.Timezone = req.Timezone
+	sub.LowStockAlert = req.LowStockAlert
+	sub.BarkServer = barkServer
+	sub.Language = model.NormalizeLanguage(req.Language)
+	if req.Paused != nil {
+		sub.Paused = *req.Paused
+	}
+	sub.UpdatedAt = time.Now()
+
+	if err := h.store.UpdateSubscription(&sub); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update subscription"})
+		return
+	}
+
+	if err := h.store.Save(); err != nil {
+		// Log error but don't fail
+		requestLogger(c).Error("Failed to save data", "error", err)
+	}
+
+	// Return with masked Bark Key
+	response := h.subscriptionResponses([]*model.Subscription{&sub})[0]
+	response.BarkKey = maskBarkKey(response.BarkKey)
+	c.JSON(http.StatusOK, response)
 }
 
-// GetCategories returns all product categories
-func (h *Handlers) GetCategories(c *gin.Context) {
-	categories := h.store.GetCategories()
+// PausePriceSubscription stops a price subscription's notifications until it is resumed
+// PATCH /api/subscriptions/:id/pause
+func (h *Handlers) PausePriceSubscription(c *gin.Context) {
+	h.setSubscriptionPaused(c, true)
+}
 
-	c.JSON(http.StatusOK, gin.H{
-		"categories": categories,
-	})
+// ResumePriceSubscription resumes a paused price subscription
+// PATCH /api/subscriptions/:id/resume
+func (h *Handlers) ResumePriceSubscription(c *gin.Context) {
+	h.setSubscriptionPaused(c, false)
 }
 
-// GetFilterOptions returns dynamic filter options based on current products
-func (h *Handlers) GetFilterOptions(c *gin.Context) {
-	category := c.Query("category")
+// setSubscriptionPaused pauses or resumes the price subscription in the path
+func (h *Handlers) setSubscriptionPaused(c *gin.Context, paused bool) {
+	sub, ok := h.store.GetSubscription(c.Param("id"))
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "subscription not found"})
+		return
+	}
+	if !h.ownBarkKey(c, sub.BarkKey) {
+		return
+	}
 
-	// Get products based on category filter
-	var products []*model.Product
-	if category != "" && category != "全部" {
-		products = h.store.GetProductsByCategory(category)
-	} else {
-		products = h.store.GetAllProducts()
+	if err := h.store.SetSubscriptionPaused(sub.ID, paused); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update subscription"})
+		return
 	}
 
-	options := extractFilterOptions(products)
-	c.JSON(http.StatusOK, options)
-}
-
-// FilterOptions represents available filter options
-type FilterOptions struct {
-	Chips       []string `json:"chips"`
-	Storages    []string `json:"storages"`
-	Memories    []string `json:"memories"`
-	ScreenSizes []string `json:"screen_sizes"`
-	Colors      []string `json:"colors"`
-	Models      []string `json:"models"`
-}
-
-func extractFilterOptions(products []*model.Product) FilterOptions {
-	chips := make(map[string]bool)
-	storages := make(map[string]bool)
-	memories := make(map[string]bool)
-	screenSizes := make(map[string]bool)
-	colors := make(map[string]bool)
-	models := make(map[string]bool)
-
-	for _, p := range products {
-		// Parse specs_detail JSON
-		if p.SpecsDetail != "" {
-			var specs model.ParsedSpecs
-			if err := json.Unmarshal([]byte(p.SpecsDetail), &specs); err == nil {
-				if specs.Chip != "" {
-					chips[specs.Chip] = true
-				}
-				if specs.Storage != "" {
-					storages[specs.Storage] = true
-				}
-				if specs.Memory != "" {
-					memories[specs.Memory] = true
-				}
-				if specs.ScreenSize != "" {
-					screenSizes[specs.ScreenSize] = true
-				}
-				if specs.Color != "" {
-					colors[specs.Color] = true
-				}
-			}
-		}
+	if err := h.store.Save(); err != nil {
+		// Log error but don't fail
+		requestLogger(c).Error("Failed to save data", "error", err)
+	}
+
+	message := "subscription resumed"
+	if paused {
+		message = "subscription paused"
+	}
+	c.JSON(http.StatusOK, gin.H{"message": message})
+}
+
+// TestSubscription sends a sample price alert through the subscription's channel and
+// reports the delivery, so users can verify their Bark Key before a real price change
+// POST /api/subscriptions/:id/test
+func (h *Handlers) TestSubscription(c *gin.Context) {
+	sub, ok := h.store.GetSubscription(c.Param("id"))
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "subscription not found"})
+		return
+	}
+	if !h.ownBarkKey(c, sub.BarkKey) {
+		return
+	}
+
+	if h.dispatcher == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "notification service not available"})
+		return
+	}
+
+	// The product may have left the catalog since subscribing; sample data still works
+	product, ok := h.store.GetProduct(sub.ProductID)
+	if !ok {
+		product = &model.Product{ID: sub.ProductID, Name: localize(c, "sample_product"), Price: 9999}
+	}
+
+	delivery, err := h.dispatcher.SendSubscriptionTest(sub, product)
 
-		// Extract model from name
-		model := extractModelFromName(p.Name, p.Category)
-		if model != "" {
-			models[model] = true
+	if delivery != nil {
+		if err := h.store.Save(); err != nil {
+			requestLogger(c).Error("Failed to save data", "error", err)
 		}
 	}
 
-	return FilterOptions{
-		Chips:       sortByChipVersion(mapKeys(chips)),
-		Storages:    sortByCapacity(mapKeys(storages)),
-		Memories:    sortByCapacity(mapKeys(memories)),
-		ScreenSizes: sortByScreenSize(mapKeys(screenSizes)),
-		Colors:      sortColors(mapKeys(colors)),
-		Models:      sortModels(mapKeys(models)),
+	if err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{
+			"error":    localize(c, "test_failed", err),
+			"delivery": delivery,
+		})
+		return
 	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":  "test notification sent",
+		"delivery": delivery,
+	})
 }
 
-func mapKeys(m map[string]bool) []string {
-	keys := make([]string, 0, len(m))
-	for k := range m {
-		keys = append(keys, k)
+// GetSubscriptions returns the price subscriptions of the authenticated user or
+// the bark_key query parameter, optionally only those for ?product_id=. Without
+// a Bark Key the list is empty: subscriptions are not listed across users.
+// GET /api/subscriptions
+func (h *Handlers) GetSubscriptions(c *gin.Context) {
+	barkKey, ok := h.requestBarkKey(c)
+	if !ok {
+		return
 	}
-	return keys
-}
 
-func extractModelFromName(name, category string) string {
-	nameLower := strings.ToLower(name)
-	switch category {
-	case "Mac":
-		switch {
-		case strings.Contains(nameLower, "macbook air"):
-			return "MacBook Air"
-		case strings.Contains(nameLower, "macbook pro"):
-			return "MacBook Pro"
-		case strings.Contains(nameLower, "mac mini"):
-			return "Mac mini"
-		case strings.Contains(nameLower, "mac studio"):
-			return "Mac Studio"
-		case strings.Contains(nameLower, "imac"):
-			return "iMac"
-		case strings.Contains(nameLower, "mac pro"):
-			return "Mac Pro"
-		}
-	case "iPad":
-		switch {
-		case strings.Contains(nameLower, "ipad pro"):
-			return "iPad Pro"
-		case strings.Contains(nameLower, "ipad air"):
-			return "iPad Air"
-		case strings.Contains(nameLower, "ipad mini"):
-			return "iPad mini"
-		case strings.Contains(nameLower, "ipad"):
-			return "iPad"
-		}
-	case "Watch":
-		switch {
-		case strings.Contains(nameLower, "ultra"):
-			return "Apple Watch Ultra"
-		case strings.Contains(nameLower, "series"):
-			return "Apple Watch Series"
-		case strings.Contains(nameLower, "se"):
-			return "Apple Watch SE"
+	if barkKey == "" {
+		c.JSON(http.StatusOK, gin.H{
+			"count":         0,
+			"subscriptions": []*model.Subscription{},
+		})
+		return
+	}
+
+	productID := c.Query("product_id")
+	subs := make([]*model.Subscription, 0)
+	for _, sub := range h.subscriptionResponses(h.store.GetSubscriptionsByBarkKey(barkKey)) {
+		if productID != "" && sub.ProductID != productID {
+			continue
 		}
+		// Mask Bark Key in response for privacy
+		sub.BarkKey = maskBarkKey(sub.BarkKey)
+		subs = append(subs, sub)
 	}
-	return ""
+
+	c.JSON(http.StatusOK, gin.H{
+		"count":         len(subs),
+		"subscriptions": subs,
+	})
+}
+
+// GetCategories returns all product categories
+func (h *Handlers) GetCategories(c *gin.Context) {
+	categories := h.store.GetCategories()
+
+	c.JSON(http.StatusOK, gin.H{
+		"categories": categories,
+	})
+}
+
+// GetFilterOptions returns dynamic filter options based on current products
+func (h *Handlers) GetFilterOptions(c *gin.Context) {
+	category := c.Query("category")
+
+	// Options only change with the catalog, so they share the product response cache
+	h.serveProductResponse(c, "filter-options?"+category, func() (int, any, int) {
+		if category == "全部" {
+			category = ""
+		}
+		// Distinct values come from the store's spec columns, sorting stays here
+		opts := h.store.GetFilterOptions(category)
+		opts.Chips = sortByChipVersion(opts.Chips)
+		opts.Storages = sortByCapacity(opts.Storages)
+		opts.Memories = sortByCapacity(opts.Memories)
+		opts.ScreenSizes = sortByScreenSize(opts.ScreenSizes)
+		opts.Colors = sortColors(opts.Colors)
+		opts.Models = sortModels(opts.Models)
+
+		count := len(opts.Chips) + len(opts.Storages) + len(opts.Memories) + len(opts.ScreenSizes) + len(opts.Colors) + len(opts.Models)
+		return http.StatusOK, opts, count
+	})
 }
 
 func sortByChipVersion(chips []string) []string {
@@ -507,21 +1015,230 @@ func (h *Handlers) GetStats(c *gin.Context) {
 	c.JSON(http.StatusOK, stats)
 }
 
-// TriggerScrape triggers a manual scrape
+// maxTimelineDays caps the window accepted by GetStatsTimeline
+const maxTimelineDays = 730
+
+// GetStatsTimeline returns daily category/region aggregates for charting trends
+// GET /api/stats/timeline?category=Mac&region=us&days=30
+func (h *Handlers) GetStatsTimeline(c *gin.Context) {
+	days := 30
+	if v := c.Query("days"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "days must be a positive integer"})
+			return
+		}
+		if n > maxTimelineDays {
+			n = maxTimelineDays
+		}
+		days = n
+	}
+
+	category := c.Query("category")
+	region := c.Query("region")
+	since := time.Now().AddDate(0, 0, -(days - 1))
+
+	points := h.store.GetStatsTimeline(category, region, since)
+
+	c.JSON(http.StatusOK, gin.H{
+		"category": category,
+		"region":   region,
+		"days":     days,
+		"timeline": points,
+	})
+}
+
+// GetStatsHistory returns the daily catalog snapshots of one region, or of the
+// whole catalog without region, e.g. for charting the size of the cn catalog
+// GET /api/stats/history?region=cn&days=90
+func (h *Handlers) GetStatsHistory(c *gin.Context) {
+	days := 90
+	if v := c.Query("days"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "days must be a positive integer"})
+			return
+		}
+		days = min(n, maxTimelineDays)
+	}
+
+	region := c.Query("region")
+	since := time.Now().AddDate(0, 0, -(days - 1))
+
+	c.JSON(http.StatusOK, gin.H{
+		"region":  region,
+		"days":    days,
+		"history": h.store.GetStatsHistory(region, since),
+	})
+}
+
+// TriggerScrape starts a manual scrape run and returns its ID. While another run
+// is in flight it answers 409 with that run's ID, or with queue=true, queues the
+// run to start after it. category and region limit the run to one category page
+// or one region, e.g. to pick up a Mac restock without waiting for a full cycle.
+// POST /api/admin/scrape?category=Mac&region=cn
 func (h *Handlers) TriggerScrape(c *gin.Context) {
-	// Trigger scrape through scheduler
-	if h.scheduler != nil {
-		go func() {
-			_ = h.scheduler.ScrapeNow()
-		}()
-		c.JSON(http.StatusAccepted, gin.H{
-			"message": "scrape triggered",
+	if h.scheduler == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"error": "scheduler not available",
 		})
-	} else {
+		return
+	}
+
+	var scope model.ScrapeScope
+	if raw := c.Query("category"); raw != "" {
+		category, ok := model.NormalizeRegionCategory(raw)
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "unknown category: " + raw})
+			return
+		}
+		scope.Category = category
+	}
+	if raw := c.Query("region"); raw != "" {
+		code := strings.ToLower(strings.TrimSpace(raw))
+		region, ok := h.store.GetRegion(code)
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "unknown region: " + raw})
+			return
+		}
+		if !region.Enabled {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "region is disabled: " + code})
+			return
+		}
+		scope.Region = code
+	}
+
+	id, queued, err := h.scheduler.StartScrape(c.Query("queue") == "true", scope)
+	if errors.Is(err, model.ErrScrapeRunning) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error(), "run_id": id})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+		return
+	}
+
+	message := "scrape triggered"
+	if queued {
+		message = "scrape queued"
+	}
+	c.JSON(http.StatusAccepted, gin.H{
+		"message": message,
+		"run_id":  id,
+		"queued":  queued,
+		"scope":   scope,
+	})
+}
+
+// DryRunScrape scrapes without writing anything and returns how the result
+// differs from the stored catalog: new listings, price changes and products that
+// would be marked sold out. The request waits for the scrape to finish.
+// POST /api/admin/scrape/dry-run
+func (h *Handlers) DryRunScrape(c *gin.Context) {
+	if h.scheduler == nil {
 		c.JSON(http.StatusServiceUnavailable, gin.H{
 			"error": "scheduler not available",
 		})
+		return
+	}
+
+	report, err := h.scheduler.DryRunScrape(c.Request.Context())
+	if errors.Is(err, model.ErrScrapeRunning) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// GetScrapeRun returns one recorded scrape run: per category counts and catalog
+// changes (new, changed, removed), and each category page fetched with its
+// duration or error
+// GET /api/admin/scrape/runs/:id
+func (h *Handlers) GetScrapeRun(c *gin.Context) {
+	run, ok := h.store.GetScrapeRun(c.Param("id"))
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "scrape run not found"})
+		return
+	}
+	c.JSON(http.StatusOK, run)
+}
+
+// defaultScrapeRunsLimit and maxScrapeRunsLimit bound the number of scrape runs listed
+const (
+	defaultScrapeRunsLimit = 20
+	maxScrapeRunsLimit     = 500
+)
+
+// GetScrapeRuns lists the run in flight and the queued one (active), and the
+// latest recorded runs with their outcome and per category counts
+// GET /api/admin/scrape/runs?limit=20
+func (h *Handlers) GetScrapeRuns(c *gin.Context) {
+	limit := defaultScrapeRunsLimit
+	if v := c.Query("limit"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			limit = min(n, maxScrapeRunsLimit)
+		}
+	}
+
+	active := []*model.ScrapeRun{}
+	if h.scheduler != nil {
+		active = h.scheduler.ActiveRuns()
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"active": active,
+		"runs":   h.store.GetScrapeRuns(limit),
+	})
+}
+
+// CancelScrape aborts the scrape in flight; nothing of it is written
+// POST /api/admin/scrape/cancel
+func (h *Handlers) CancelScrape(c *gin.Context) {
+	if h.scheduler == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "scheduler not available"})
+		return
+	}
+	if !h.scheduler.CancelScrape() {
+		c.JSON(http.StatusConflict, gin.H{"error": "no scrape is running"})
+		return
+	}
+	c.JSON(http.StatusAccepted, gin.H{"message": "scrape cancelled"})
+}
+
+// RequeueDetails fetches a product's detail page again ahead of the rest of the
+// detail queue, e.g. after Apple corrected its description
+// POST /api/admin/details/requeue/:id
+func (h *Handlers) RequeueDetails(c *gin.Context) {
+	if h.scheduler == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "scheduler not available"})
+		return
+	}
+
+	id := c.Param("id")
+	product, ok := h.store.GetProduct(id)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "product not found"})
+		return
+	}
+	if product.ProductURL == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "product has no detail page"})
+		return
+	}
+
+	err := h.scheduler.RequeueDetail(id)
+	if errors.Is(err, model.ErrDetailsUnavailable) {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+		return
 	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusAccepted, gin.H{"message": "details requeued", "product_id": id})
 }
 
 // GetDetailStatus returns the detail scraper status
@@ -536,7 +1253,8 @@ func (h *Handlers) GetDetailStatus(c *gin.Context) {
 	}
 }
 
-// DeleteProductsByRegion deletes all products from a specific region
+// DeleteProductsByRegion archives all products from a specific region, undoable
+// for a grace period
 func (h *Handlers) DeleteProductsByRegion(c *gin.Context) {
 	region := c.Param("region")
 	if region == "" {
@@ -544,7 +1262,97 @@ func (h *Handlers) DeleteProductsByRegion(c *gin.Context) {
 		return
 	}
 
-	count, err := h.store.DeleteProductsByRegion(region)
+	// Dry run: report what would be removed
+	if c.Query("dry_run") == "true" {
+		c.JSON(http.StatusOK, gin.H{
+			"dry_run":  true,
+			"deletion": h.store.PreviewRegionDeletion(region),
+		})
+		return
+	}
+
+	deletion, err := h.store.DeleteProductsByRegion(region)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete products"})
+		return
+	}
+
+	if err := h.store.Save(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save data"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":  fmt.Sprintf("Archived %d products from region %s", deletion.Products, region),
+		"count":    deletion.Products,
+		"deletion": deletion,
+	})
+}
+
+// minStaleAge keeps DeleteStaleProducts from wiping products a recent scrape may still update
+const minStaleAge = 24 * time.Hour
+
+// staleCandidate is a product listed by a stale deletion dry run
+type staleCandidate struct {
+	ID          string    `json:"id"`
+	Name        string    `json:"name"`
+	Category    string    `json:"category"`
+	Region      string    `json:"region"`
+	StockStatus string    `json:"stock_status"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// parseAge parses an age such as "90d", "36h" or "1h30m"
+func parseAge(s string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil {
+			return 0, fmt.Errorf("invalid age %q", s)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	return time.ParseDuration(s)
+}
+
+// DeleteStaleProducts archives products not updated by a scrape within older_than,
+// e.g. listings Apple dropped long ago. Undoable like a region deletion.
+// DELETE /api/admin/products/stale?older_than=90d[&dry_run=true]
+func (h *Handlers) DeleteStaleProducts(c *gin.Context) {
+	age, err := parseAge(c.Query("older_than"))
+	if err != nil || age < minStaleAge {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "older_than must be a duration of at least 1d, e.g. 90d"})
+		return
+	}
+	before := time.Now().Add(-age)
+
+	// Dry run: report what would be removed, with the candidate products
+	if c.Query("dry_run") == "true" {
+		candidates := []staleCandidate{}
+		for _, p := range h.store.GetAllProducts() {
+			if p.UpdatedAt.Before(before) {
+				candidates = append(candidates, staleCandidate{
+					ID:          p.ID,
+					Name:        p.Name,
+					Category:    p.Category,
+					Region:      p.Region,
+					StockStatus: p.StockStatus,
+					UpdatedAt:   p.UpdatedAt,
+				})
+			}
+		}
+		sort.Slice(candidates, func(i, j int) bool {
+			return candidates[i].UpdatedAt.Before(candidates[j].UpdatedAt)
+		})
+
+		c.JSON(http.StatusOK, gin.H{
+			"dry_run":    true,
+			"deletion":   h.store.PreviewStaleDeletion(before),
+			"candidates": candidates,
+		})
+		return
+	}
+
+	deletion, err := h.store.DeleteStaleProducts(before)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete products"})
 		return
@@ -556,8 +1364,68 @@ func (h *Handlers) DeleteProductsByRegion(c *gin.Context) {
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"message": fmt.Sprintf("Deleted %d products from region %s", count, region),
-		"count":   count,
+		"message":  fmt.Sprintf("Archived %d products not seen since %s", deletion.Products, before.Format(time.DateOnly)),
+		"count":    deletion.Products,
+		"deletion": deletion,
+	})
+}
+
+// GetScraperStatus returns the overall status of the last scrape and that of each
+// region, so one region's failure doesn't hide the health of the others, along
+// with the last value score recomputation. ?region= narrows the regions to one.
+func (h *Handlers) GetScraperStatus(c *gin.Context) {
+	regions := h.store.GetRegionScraperStatuses()
+	if region := strings.ToLower(c.Query("region")); region != "" {
+		var filtered []*model.ScraperStatus
+		for _, status := range regions {
+			if status.Region == region {
+				filtered = append(filtered, status)
+			}
+		}
+		if len(filtered) == 0 {
+			c.JSON(http.StatusNotFound, gin.H{"error": "no scrape recorded for region " + region})
+			return
+		}
+		regions = filtered
+	}
+	if regions == nil {
+		regions = []*model.ScraperStatus{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":          h.store.GetScraperStatus(),
+		"regions":         regions,
+		"score_recompute": h.store.GetLastScoreRecompute(),
+	})
+}
+
+// GetRegionDeletions lists region deletions that can still be undone
+func (h *Handlers) GetRegionDeletions(c *gin.Context) {
+	deletions := h.store.GetRegionDeletions()
+	c.JSON(http.StatusOK, gin.H{
+		"count":     len(deletions),
+		"deletions": deletions,
+	})
+}
+
+// UndoRegionDeletion restores a region deletion within its grace period
+func (h *Handlers) UndoRegionDeletion(c *gin.Context) {
+	id := c.Param("id")
+
+	deletion, err := h.store.UndoRegionDeletion(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.store.Save(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save data"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":  fmt.Sprintf("Restored %d products to region %s", deletion.Products, deletion.Region),
+		"deletion": deletion,
 	})
 }
 
@@ -581,6 +1449,8 @@ func sortProducts(products []*model.Product, sortBy, order string) []*model.Prod
 		sortByScore(sorted, order == "desc")
 	case "created":
 		sortByCreated(sorted, order == "desc")
+	case "savings":
+		sortBySavings(sorted, order == "desc")
 	default:
 		// Default: sort by score descending
 		sortByScore(sorted, true)
@@ -628,6 +1498,19 @@ func sortByScore(products []*model.Product, desc bool) {
 	}
 }
 
+// sortBySavings sorts products by the amount saved off the new price
+func sortBySavings(products []*model.Product, desc bool) {
+	if desc {
+		sort.Slice(products, func(i, j int) bool {
+			return products[i].OriginalPrice-products[i].Price > products[j].OriginalPrice-products[j].Price
+		})
+	} else {
+		sort.Slice(products, func(i, j int) bool {
+			return products[i].OriginalPrice-products[i].Price < products[j].OriginalPrice-products[j].Price
+		})
+	}
+}
+
 // sortByCreated sorts products by creation time
 func sortByCreated(products []*model.Product, desc bool) {
 	if desc {
@@ -654,15 +1537,74 @@ func (h *Handlers) CreateNewArrivalSubscription(c *gin.Context) {
 		return
 	}
 
+	h.addNewArrivalSubscription(c, &req)
+}
+
+// addNewArrivalSubscription validates a new arrival subscription, stores it and
+// answers 201 with the Bark Key masked
+func (h *Handlers) addNewArrivalSubscription(c *gin.Context, req *model.NewArrivalSubscription) {
 	// Validate
 	if req.Name == "" {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "name is required"})
 		return
 	}
 
-	// Bark Key is required for each subscription
+	// Bark Key is required for each subscription; API key users get their own
+	if req.BarkKey == "" {
+		user, ok := h.currentUser(c)
+		if !ok {
+			return
+		}
+		if user != nil {
+			req.BarkKey = user.BarkKey
+		}
+	}
 	if req.BarkKey == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Bark Key 是必填项"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": localize(c, "bark_key_required")})
+		return
+	}
+	if !h.ownBarkKey(c, req.BarkKey) {
+		return
+	}
+
+	if err := model.ValidateQuietHours(req.QuietHoursStart, req.QuietHoursEnd, req.Timezone); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !model.ValidFrequency(req.Frequency) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "frequency must be instant, hourly or daily"})
+		return
+	}
+	if req.Frequency == "" {
+		// The owner's preferred digest frequency, instant if none
+		req.Frequency = h.store.GetPreferences(req.BarkKey).DigestFrequency
+	}
+	if req.Frequency == "" {
+		req.Frequency = model.FrequencyInstant
+	}
+
+	if !model.ValidStability(req.Stability) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "stability must be stable or volatile"})
+		return
+	}
+
+	barkServer, err := model.NormalizeBarkServer(req.BarkServer)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	req.BarkServer = barkServer
+
+	if err := model.ValidateLanguage(req.Language); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	req.Language = model.NormalizeLanguage(req.Language)
+
+	req.Spec = strings.TrimSpace(req.Spec)
+	if req.Spec != "" && model.ParseSpecCombo(req.Spec).IsEmpty() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "spec must name a model, screen size, chip, memory or storage"})
 		return
 	}
 
@@ -673,17 +1615,18 @@ func (h *Handlers) CreateNewArrivalSubscription(c *gin.Context) {
 		req.Enabled = true
 	}
 
-	if err := h.store.AddNewArrivalSubscription(&req); err != nil {
+	if err := h.store.AddNewArrivalSubscription(req); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save subscription"})
 		return
 	}
 
 	if err := h.store.Save(); err != nil {
 		// Log error but don't fail
+		requestLogger(c).Error("Failed to save data", "error", err)
 	}
 
 	// Return subscription with masked Bark Key
-	response := req
+	response := *req
 	response.BarkKey = maskBarkKey(response.BarkKey)
 	c.JSON(http.StatusCreated, response)
 }
@@ -696,6 +1639,15 @@ func (h *Handlers) DeleteNewArrivalSubscription(c *gin.Context) {
 		return
 	}
 
+	sub, found := h.store.GetNewArrivalSubscription(id)
+	if !found {
+		c.JSON(http.StatusNotFound, gin.H{"error": "subscription not found"})
+		return
+	}
+	if !h.ownBarkKey(c, sub.BarkKey) {
+		return
+	}
+
 	if err := h.store.RemoveNewArrivalSubscription(id); err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "subscription not found"})
 		return
@@ -703,14 +1655,54 @@ func (h *Handlers) DeleteNewArrivalSubscription(c *gin.Context) {
 
 	if err := h.store.Save(); err != nil {
 		// Log error but don't fail
+		requestLogger(c).Error("Failed to save data", "error", err)
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "subscription deleted"})
+	c.JSON(http.StatusOK, gin.H{
+		"message":          "subscription deleted",
+		"restorable_until": time.Now().Add(model.SubscriptionRestoreWindow),
+	})
+}
+
+// RestoreNewArrivalSubscription brings back a deleted new arrival subscription
+// within model.SubscriptionRestoreWindow of its deletion
+// POST /api/new-arrival-subscriptions/:id/restore
+func (h *Handlers) RestoreNewArrivalSubscription(c *gin.Context) {
+	id := c.Param("id")
+
+	sub, found := h.store.GetDeletedNewArrivalSubscription(id)
+	if !found {
+		c.JSON(http.StatusNotFound, gin.H{"error": "deleted subscription not found"})
+		return
+	}
+	if !h.ownBarkKey(c, sub.BarkKey) {
+		return
+	}
+	if time.Since(sub.DeletedAt) > model.SubscriptionRestoreWindow {
+		c.JSON(http.StatusGone, gin.H{"error": "restore window has passed"})
+		return
+	}
+
+	restored, err := h.store.RestoreNewArrivalSubscription(id)
+	if err != nil {
+		requestLogger(c).Error("Failed to restore subscription", "subscription_id", id, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to restore subscription"})
+		return
+	}
+
+	if err := h.store.Save(); err != nil {
+		requestLogger(c).Error("Failed to save data", "error", err)
+	}
+
+	c.JSON(http.StatusOK, h.newArrivalSubscriptionResponses([]*model.NewArrivalSubscription{restored})[0])
 }
 
 // GetNewArrivalSubscriptions returns new arrival subscriptions for a specific Bark Key
 func (h *Handlers) GetNewArrivalSubscriptions(c *gin.Context) {
-	barkKey := c.Query("bark_key")
+	barkKey, ok := h.requestBarkKey(c)
+	if !ok {
+		return
+	}
 
 	if barkKey == "" {
 		// No Bark Key provided, return empty list
@@ -721,7 +1713,7 @@ func (h *Handlers) GetNewArrivalSubscriptions(c *gin.Context) {
 		return
 	}
 
-	subs := h.store.GetNewArrivalSubscriptionsByBarkKey(barkKey)
+	subs := h.newArrivalSubscriptionResponses(h.store.GetNewArrivalSubscriptionsByBarkKey(barkKey))
 
 	// Mask Bark Key in response for privacy
 	for _, sub := range subs {
@@ -747,15 +1739,21 @@ func (h *Handlers) GetNewArrivalSubscription(c *gin.Context) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "subscription not found"})
 		return
 	}
+	if !h.ownBarkKey(c, sub.BarkKey) {
+		return
+	}
 
-	c.JSON(http.StatusOK, sub)
+	c.JSON(http.StatusOK, h.newArrivalSubscriptionResponses([]*model.NewArrivalSubscription{sub})[0])
 }
 
 // GetNotificationHistory returns notification history with pagination
 func (h *Handlers) GetNotificationHistory(c *gin.Context) {
 	// Get query parameters
 	subscriptionID := c.Query("subscription_id")
-	barkKey := c.Query("bark_key") // Filter by Bark Key for user isolation
+	barkKey, ok := h.requestBarkKey(c) // Filter by Bark Key for user isolation
+	if !ok {
+		return
+	}
 	limitStr := c.DefaultQuery("limit", "50")
 	offsetStr := c.DefaultQuery("offset", "0")
 
@@ -810,6 +1808,7 @@ func (h *Handlers) MarkNotificationAsRead(c *gin.Context) {
 
 	if err := h.store.Save(); err != nil {
 		// Log error but don't fail
+		requestLogger(c).Error("Failed to save data", "error", err)
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "marked as read"})
@@ -837,6 +1836,9 @@ func (h *Handlers) UpdateNewArrivalSubscription(c *gin.Context) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "subscription not found"})
 		return
 	}
+	if !h.ownBarkKey(c, existing.BarkKey) {
+		return
+	}
 
 	var req model.NewArrivalSubscription
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -844,6 +1846,43 @@ func (h *Handlers) UpdateNewArrivalSubscription(c *gin.Context) {
 		return
 	}
 
+	if err := model.ValidateQuietHours(req.QuietHoursStart, req.QuietHoursEnd, req.Timezone); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !model.ValidFrequency(req.Frequency) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "frequency must be instant, hourly or daily"})
+		return
+	}
+	if req.Frequency == "" {
+		req.Frequency = existing.Frequency
+	}
+
+	if !model.ValidStability(req.Stability) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "stability must be stable or volatile"})
+		return
+	}
+
+	barkServer, err := model.NormalizeBarkServer(req.BarkServer)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	req.BarkServer = barkServer
+
+	if err := model.ValidateLanguage(req.Language); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	req.Language = model.NormalizeLanguage(req.Language)
+
+	req.Spec = strings.TrimSpace(req.Spec)
+	if req.Spec != "" && model.ParseSpecCombo(req.Spec).IsEmpty() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "spec must name a model, screen size, chip, memory or storage"})
+		return
+	}
+
 	// Preserve ID, Bark Key and timestamps
 	req.ID = id
 	req.BarkKey = existing.BarkKey // Preserve original Bark Key
@@ -856,6 +1895,7 @@ func (h *Handlers) UpdateNewArrivalSubscription(c *gin.Context) {
 
 	if err := h.store.Save(); err != nil {
 		// Log error but don't fail
+		requestLogger(c).Error("Failed to save data", "error", err)
 	}
 
 	// Return with masked Bark Key
@@ -873,11 +1913,14 @@ func (h *Handlers) PauseSubscription(c *gin.Context) {
 	}
 
 	// Check if subscription exists
-	_, found := h.store.GetNewArrivalSubscription(id)
+	sub, found := h.store.GetNewArrivalSubscription(id)
 	if !found {
 		c.JSON(http.StatusNotFound, gin.H{"error": "subscription not found"})
 		return
 	}
+	if !h.ownBarkKey(c, sub.BarkKey) {
+		return
+	}
 
 	if err := h.store.PauseSubscription(id); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to pause subscription"})
@@ -886,6 +1929,7 @@ func (h *Handlers) PauseSubscription(c *gin.Context) {
 
 	if err := h.store.Save(); err != nil {
 		// Log error but don't fail
+		requestLogger(c).Error("Failed to save data", "error", err)
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "subscription paused"})
@@ -900,11 +1944,14 @@ func (h *Handlers) ResumeSubscription(c *gin.Context) {
 	}
 
 	// Check if subscription exists
-	_, found := h.store.GetNewArrivalSubscription(id)
+	sub, found := h.store.GetNewArrivalSubscription(id)
 	if !found {
 		c.JSON(http.StatusNotFound, gin.H{"error": "subscription not found"})
 		return
 	}
+	if !h.ownBarkKey(c, sub.BarkKey) {
+		return
+	}
 
 	if err := h.store.ResumeSubscription(id); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to resume subscription"})
@@ -913,11 +1960,132 @@ func (h *Handlers) ResumeSubscription(c *gin.Context) {
 
 	if err := h.store.Save(); err != nil {
 		// Log error but don't fail
+		requestLogger(c).Error("Failed to save data", "error", err)
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "subscription resumed"})
 }
 
+// PauseAll pauses every subscription for a Bark Key at once (e.g. while traveling)
+func (h *Handlers) PauseAll(c *gin.Context) {
+	h.setAllPaused(c, true)
+}
+
+// ResumeAll turns the kill switch off; subscriptions paused on their own stay paused
+func (h *Handlers) ResumeAll(c *gin.Context) {
+	h.setAllPaused(c, false)
+}
+
+// setAllPaused toggles the kill switch for the API key user or the Bark Key in the query string
+func (h *Handlers) setAllPaused(c *gin.Context, paused bool) {
+	barkKey, ok := h.requestBarkKey(c)
+	if !ok {
+		return
+	}
+	if barkKey == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "bark_key is required"})
+		return
+	}
+
+	updated, err := h.store.SetAllPaused(barkKey, paused)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update subscriptions"})
+		return
+	}
+
+	if err := h.store.Save(); err != nil {
+		// Log error but don't fail
+		requestLogger(c).Error("Failed to save data", "error", err)
+	}
+
+	message := "all subscriptions resumed"
+	if paused {
+		message = "all subscriptions paused"
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":    message,
+		"updated":    updated,
+		"paused_all": paused,
+	})
+}
+
+// GetPreferences returns the preferences for a Bark Key
+func (h *Handlers) GetPreferences(c *gin.Context) {
+	barkKey, ok := h.requestBarkKey(c)
+	if !ok {
+		return
+	}
+	if barkKey == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "bark_key is required"})
+		return
+	}
+
+	c.JSON(http.StatusOK, h.store.GetPreferences(barkKey))
+}
+
+// MigrateBarkKey re-associates all subscriptions and history from an old Bark Key to a new one.
+// Both keys must accept a test push before any records are moved.
+func (h *Handlers) MigrateBarkKey(c *gin.Context) {
+	var req struct {
+		OldBarkKey string `json:"old_bark_key" binding:"required"`
+		NewBarkKey string `json:"new_bark_key" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if req.OldBarkKey == req.NewBarkKey {
+		c.JSON(http.StatusBadRequest, gin.H{"error": localize(c, "bark_key_same")})
+		return
+	}
+
+	if !h.ownBarkKey(c, req.OldBarkKey) {
+		return
+	}
+	if owner, ok := h.store.GetUserByBarkKey(req.NewBarkKey); ok && owner.Registered() {
+		if old, ok := h.store.GetUserByBarkKey(req.OldBarkKey); !ok || old.ID != owner.ID {
+			c.JSON(http.StatusConflict, gin.H{"error": localize(c, "bark_key_taken")})
+			return
+		}
+	}
+
+	if h.dispatcher == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "notification service not available"})
+		return
+	}
+
+	// Verify both keys with a test push before touching any records
+	if err := h.dispatcher.SendTestNotification(req.OldBarkKey); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": localize(c, "old_bark_key_failed", err)})
+		return
+	}
+	if err := h.dispatcher.SendTestNotification(req.NewBarkKey); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": localize(c, "new_bark_key_failed", err)})
+		return
+	}
+
+	result, err := h.store.MigrateBarkKey(req.OldBarkKey, req.NewBarkKey)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to migrate bark key"})
+		return
+	}
+
+	if err := h.store.Save(); err != nil {
+		// Log error but don't fail
+		requestLogger(c).Error("Failed to save data", "error", err)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":      "bark key migrated",
+		"old_bark_key": maskBarkKey(req.OldBarkKey),
+		"new_bark_key": maskBarkKey(req.NewBarkKey),
+		"migrated":     result,
+	})
+}
+
 // maskBarkKey masks a Bark Key for display (shows first 4 and last 4 chars)
 func maskBarkKey(key string) string {
 	if key == "" {
@@ -0,0 +1,111 @@
+package api
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"apple-price/internal/model"
+
+	"github.com/gin-gonic/gin"
+)
+
+// messages holds user-facing API messages by key, then language. Missing
+// languages fall back to zh.
+var messages = map[string]map[string]string{
+	"bark_unreachable": {
+		model.LanguageZH:   "无法连接 Bark 服务器，请稍后重试",
+		model.LanguageZHHK: "無法連接 Bark 伺服器，請稍後重試",
+		model.LanguageEN:   "Cannot reach the Bark server, please try again later",
+	},
+	"bark_key_invalid": {
+		model.LanguageZH:   "Bark Key 无效: %s",
+		model.LanguageZHHK: "Bark Key 無效: %s",
+		model.LanguageEN:   "Invalid Bark Key: %s",
+	},
+	"bark_key_required": {
+		model.LanguageZH:   "Bark Key 是必填项",
+		model.LanguageZHHK: "Bark Key 為必填項",
+		model.LanguageEN:   "Bark Key is required",
+	},
+	"bark_key_same": {
+		model.LanguageZH:   "新旧 Bark Key 不能相同",
+		model.LanguageZHHK: "新舊 Bark Key 不能相同",
+		model.LanguageEN:   "The old and new Bark Keys must differ",
+	},
+	"bark_key_taken": {
+		model.LanguageZH:   "新 Bark Key 已属于其他注册用户",
+		model.LanguageZHHK: "新 Bark Key 已屬於其他註冊用戶",
+		model.LanguageEN:   "The new Bark Key belongs to another registered user",
+	},
+	"old_bark_key_failed": {
+		model.LanguageZH:   "旧 Bark Key 验证失败: %v",
+		model.LanguageZHHK: "舊 Bark Key 驗證失敗: %v",
+		model.LanguageEN:   "Old Bark Key check failed: %v",
+	},
+	"new_bark_key_failed": {
+		model.LanguageZH:   "新 Bark Key 验证失败: %v",
+		model.LanguageZHHK: "新 Bark Key 驗證失敗: %v",
+		model.LanguageEN:   "New Bark Key check failed: %v",
+	},
+	"test_failed": {
+		model.LanguageZH:   "测试通知发送失败: %v",
+		model.LanguageZHHK: "測試通知發送失敗: %v",
+		model.LanguageEN:   "Test notification failed: %v",
+	},
+	"sample_product": {
+		model.LanguageZH:   "示例产品",
+		model.LanguageZHHK: "示例產品",
+		model.LanguageEN:   "Sample product",
+	},
+}
+
+// requestLanguage returns the supported language the client prefers most in its
+// Accept-Language header, zh when it names none
+func requestLanguage(c *gin.Context) string {
+	type choice struct {
+		language string
+		q        float64
+	}
+
+	var choices []choice
+	for _, part := range strings.Split(c.GetHeader("Accept-Language"), ",") {
+		tag, params, _ := strings.Cut(part, ";")
+		language := model.NormalizeLanguage(tag)
+		if language == "" {
+			continue
+		}
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if parsed, err := strconv.ParseFloat(v, 64); err == nil {
+				q = parsed
+			}
+		}
+		if q > 0 {
+			choices = append(choices, choice{language, q})
+		}
+	}
+	if len(choices) == 0 {
+		return model.LanguageZH
+	}
+
+	sort.SliceStable(choices, func(i, j int) bool { return choices[i].q > choices[j].q })
+	return choices[0].language
+}
+
+// localize returns the message key in the request's language, formatted with args
+func localize(c *gin.Context, key string, args ...any) string {
+	texts, ok := messages[key]
+	if !ok {
+		return key
+	}
+	text, ok := texts[requestLanguage(c)]
+	if !ok {
+		text = texts[model.LanguageZH]
+	}
+	if len(args) > 0 {
+		return fmt.Sprintf(text, args...)
+	}
+	return text
+}
@@ -0,0 +1,69 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"apple-price/internal/imagecache"
+
+	"github.com/gin-gonic/gin"
+)
+
+// imageMaxAge is how long browsers may keep a served product image
+const imageMaxAge = "public, max-age=86400"
+
+// ImageCache serves product images downloaded once to disk
+type ImageCache interface {
+	Get(ctx context.Context, productID, url string, width int) (*imagecache.Image, error)
+}
+
+// GetProductImage serves a product's image from the image cache, scaled down to
+// w pixels wide (160, 320 or 640) for thumbnails. When Apple's URL stops
+// working the last downloaded copy is served. Without an image cache it
+// redirects to Apple.
+// GET /api/images/:productID?w=320
+func (h *Handlers) GetProductImage(c *gin.Context) {
+	product, ok := h.store.GetProduct(c.Param("productID"))
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "product not found"})
+		return
+	}
+
+	width := 0
+	if raw := c.Query("w"); raw != "" {
+		w, err := strconv.Atoi(raw)
+		if err != nil || !imagecache.ValidWidth(w) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "w must be one of 160, 320 or 640"})
+			return
+		}
+		width = w
+	}
+
+	if h.images == nil {
+		if product.ImageURL == "" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "product has no image"})
+			return
+		}
+		c.Redirect(http.StatusFound, product.ImageURL)
+		return
+	}
+
+	img, err := h.images.Get(c.Request.Context(), product.ID, product.ImageURL, width)
+	if errors.Is(err, imagecache.ErrNoImage) {
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Header("Cache-Control", imageMaxAge)
+	c.Header("Last-Modified", img.FetchedAt.UTC().Format(http.TimeFormat))
+	if img.Stale {
+		c.Header("X-Image-Stale", "true")
+	}
+	c.Data(http.StatusOK, img.ContentType, img.Data)
+}
@@ -0,0 +1,62 @@
+package api
+
+import (
+	"log/slog"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const loggerKey = "logger"
+
+// RequestLogger attaches a request-scoped logger (request_id, method, path) to the
+// context and logs each request on completion. Incoming X-Request-ID headers are reused.
+func RequestLogger() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+
+		requestID := c.GetHeader("X-Request-ID")
+		if requestID == "" {
+			requestID = generateID()
+		}
+		c.Header("X-Request-ID", requestID)
+
+		logger := slog.Default().With(
+			"request_id", requestID,
+			"method", c.Request.Method,
+			"path", c.Request.URL.Path,
+		)
+		c.Set(loggerKey, logger)
+
+		c.Next()
+
+		status := c.Writer.Status()
+		attrs := []any{
+			"status", status,
+			"duration", time.Since(start),
+			"client_ip", c.ClientIP(),
+		}
+		if len(c.Errors) > 0 {
+			attrs = append(attrs, "errors", c.Errors.String())
+		}
+
+		switch {
+		case status >= 500:
+			logger.Error("Request failed", attrs...)
+		case status >= 400:
+			logger.Warn("Request rejected", attrs...)
+		default:
+			logger.Info("Request completed", attrs...)
+		}
+	}
+}
+
+// requestLogger returns the request-scoped logger, falling back to the default logger
+func requestLogger(c *gin.Context) *slog.Logger {
+	if v, ok := c.Get(loggerKey); ok {
+		if logger, ok := v.(*slog.Logger); ok {
+			return logger
+		}
+	}
+	return slog.Default()
+}
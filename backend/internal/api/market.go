@@ -0,0 +1,178 @@
+package api
+
+import (
+	"math"
+	"net/http"
+	"sort"
+	"time"
+
+	"apple-price/internal/model"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// marketWindow is what counts as recent for new arrivals and price drops
+	marketWindow = 7 * 24 * time.Hour
+	// marketTopDrops is how many price drops are listed per category and per model
+	marketTopDrops = 5
+)
+
+// MarketStats aggregates a group of listings
+type MarketStats struct {
+	Count         int               `json:"count"`
+	Available     int               `json:"available"` // not sold out
+	AvgDiscount   float64           `json:"avg_discount"`
+	AvgValueScore float64           `json:"avg_value_score"`
+	NewArrivals   int               `json:"new_arrivals"` // listed within the last 7 days
+	BiggestDrops  []MarketPriceDrop `json:"biggest_drops"`
+}
+
+// MarketPriceDrop is a listing now cheaper than its highest price in the last 7 days
+type MarketPriceDrop struct {
+	ProductID     string  `json:"product_id"`
+	Name          string  `json:"name"`
+	Model         string  `json:"model"`
+	PreviousPrice float64 `json:"previous_price"`
+	Price         float64 `json:"price"`
+	Drop          float64 `json:"drop"`
+	DropPercent   float64 `json:"drop_percent"`
+}
+
+// MarketModel is the overview of one model line (MacBook Air, iPad Pro, …)
+type MarketModel struct {
+	Model string `json:"model"`
+	MarketStats
+}
+
+// MarketCategory is the overview of one category with its model lines, largest first
+type MarketCategory struct {
+	Category string `json:"category"`
+	MarketStats
+	Models []MarketModel `json:"models"`
+}
+
+// marketGroup accumulates the listings of a category or model
+type marketGroup struct {
+	products []*model.Product
+	drops    []MarketPriceDrop
+}
+
+// stats summarizes the group's listings, keeping the largest drops
+func (g *marketGroup) stats(since time.Time) MarketStats {
+	s := MarketStats{Count: len(g.products), BiggestDrops: []MarketPriceDrop{}}
+	var discount, score float64
+	for _, p := range g.products {
+		if p.StockStatus != "sold_out" {
+			s.Available++
+		}
+		if !p.CreatedAt.Before(since) {
+			s.NewArrivals++
+		}
+		discount += p.Discount
+		score += p.ValueScore
+	}
+	if s.Count > 0 {
+		s.AvgDiscount = math.Round(discount/float64(s.Count)*10) / 10
+		s.AvgValueScore = math.Round(score/float64(s.Count)*10) / 10
+	}
+
+	drops := append([]MarketPriceDrop(nil), g.drops...)
+	sort.Slice(drops, func(i, j int) bool { return drops[i].DropPercent > drops[j].DropPercent })
+	if len(drops) > marketTopDrops {
+		drops = drops[:marketTopDrops]
+	}
+	s.BiggestDrops = append(s.BiggestDrops, drops...)
+	return s
+}
+
+// GetMarketOverview summarizes the catalog per category and model for a dashboard
+// GET /api/market/overview?region=
+func (h *Handlers) GetMarketOverview(c *gin.Context) {
+	region := c.Query("region")
+	now := time.Now()
+	since := now.Add(-marketWindow)
+
+	categories := make(map[string]*marketGroup)
+	models := make(map[string]map[string]*marketGroup)
+
+	for _, p := range h.store.GetAllProducts() {
+		if region != "" && p.Region != region {
+			continue
+		}
+
+		modelName := model.ModelFromName(p.Name, p.Category)
+		if modelName == "" {
+			modelName = p.Category
+		}
+
+		cat, ok := categories[p.Category]
+		if !ok {
+			cat = &marketGroup{}
+			categories[p.Category] = cat
+			models[p.Category] = make(map[string]*marketGroup)
+		}
+		line, ok := models[p.Category][modelName]
+		if !ok {
+			line = &marketGroup{}
+			models[p.Category][modelName] = line
+		}
+		cat.products = append(cat.products, p)
+		line.products = append(line.products, p)
+
+		if p.StockStatus == "sold_out" {
+			continue
+		}
+		if drop, ok := recentPriceDrop(p, h.store.GetPriceHistory(p.ID), since); ok {
+			drop.Model = modelName
+			cat.drops = append(cat.drops, drop)
+			line.drops = append(line.drops, drop)
+		}
+	}
+
+	overview := make([]MarketCategory, 0, len(categories))
+	for name, g := range categories {
+		mc := MarketCategory{Category: name, MarketStats: g.stats(since), Models: []MarketModel{}}
+		for modelName, line := range models[name] {
+			mc.Models = append(mc.Models, MarketModel{Model: modelName, MarketStats: line.stats(since)})
+		}
+		sort.Slice(mc.Models, func(i, j int) bool {
+			if mc.Models[i].Count != mc.Models[j].Count {
+				return mc.Models[i].Count > mc.Models[j].Count
+			}
+			return mc.Models[i].Model < mc.Models[j].Model
+		})
+		overview = append(overview, mc)
+	}
+	sort.Slice(overview, func(i, j int) bool { return overview[i].Category < overview[j].Category })
+
+	c.JSON(http.StatusOK, gin.H{
+		"region":       region,
+		"window_days":  int(marketWindow.Hours() / 24),
+		"generated_at": now,
+		"categories":   overview,
+	})
+}
+
+// recentPriceDrop compares a product's price with the highest price recorded since
+func recentPriceDrop(p *model.Product, history []model.PriceHistory, since time.Time) (MarketPriceDrop, bool) {
+	var highest float64
+	for _, h := range history {
+		if !h.Timestamp.Before(since) && h.Price > highest {
+			highest = h.Price
+		}
+	}
+	if highest <= p.Price || highest == 0 {
+		return MarketPriceDrop{}, false
+	}
+
+	drop := highest - p.Price
+	return MarketPriceDrop{
+		ProductID:     p.ID,
+		Name:          p.Name,
+		PreviousPrice: highest,
+		Price:         p.Price,
+		Drop:          drop,
+		DropPercent:   math.Round(drop/highest*1000) / 10,
+	}, true
+}
@@ -0,0 +1,172 @@
+package api
+
+import (
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"apple-price/internal/model"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ModelPricePoint is one day of a model's price index, over the listings of the
+// model that were live and not sold out at the end of that day
+type ModelPricePoint struct {
+	Date     string  `json:"date"` // YYYY-MM-DD, server local time
+	Listings int     `json:"listings"`
+	AvgPrice float64 `json:"avg_price"`
+	MinPrice float64 `json:"min_price"`
+}
+
+// GetModelPriceIndex aggregates the listings matching a model such as
+// "MacBook Pro 14 M3 Pro" into a daily average and minimum price, rebuilt from
+// price history and stock events. Prices are only comparable within a region,
+// so region is required when the matching listings span several.
+// GET /api/models/:model/price-index?region=cn&days=90
+func (h *Handlers) GetModelPriceIndex(c *gin.Context) {
+	name := c.Param("model")
+	combo := model.ParseSpecCombo(name)
+	if combo.Model == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "unrecognized model, e.g. MacBook Pro 14 M3 Pro"})
+		return
+	}
+
+	days := 90
+	if v := c.Query("days"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "days must be a positive integer"})
+			return
+		}
+		days = min(n, maxTimelineDays)
+	}
+
+	region := c.Query("region")
+	var products []*model.Product
+	if region != "" {
+		products = h.store.GetProductsByRegion(region)
+	} else {
+		products = h.store.GetAllProducts()
+	}
+
+	var matched []*model.Product
+	regions := make(map[string]bool)
+	for _, p := range products {
+		if combo.Matches(p) {
+			matched = append(matched, p)
+			regions[p.Region] = true
+		}
+	}
+	if len(matched) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "no listings match the model", "model": combo})
+		return
+	}
+	if len(regions) > 1 {
+		codes := make([]string, 0, len(regions))
+		for code := range regions {
+			codes = append(codes, code)
+		}
+		sort.Strings(codes)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "listings span several regions, pass region", "regions": codes})
+		return
+	}
+	if region == "" {
+		region = matched[0].Region
+	}
+
+	now := time.Now()
+	points := h.modelPriceIndex(matched, now, days)
+	change := 0.0
+	if len(points) > 1 && points[0].AvgPrice > 0 {
+		change = math.Round((points[len(points)-1].AvgPrice/points[0].AvgPrice-1)*1000) / 10
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"model":          combo,
+		"region":         region,
+		"days":           days,
+		"products":       len(matched),
+		"change_percent": change, // average price of the last day against the first
+		"index":          points,
+	})
+}
+
+// modelPriceIndex computes the daily points of the last days up to now, leaving
+// out days without a live listing
+func (h *Handlers) modelPriceIndex(products []*model.Product, now time.Time, days int) []ModelPricePoint {
+	type listing struct {
+		product *model.Product
+		history []model.PriceHistory
+		events  []model.ProductEvent
+	}
+	listings := make([]listing, len(products))
+	for i, p := range products {
+		listings[i] = listing{p, h.store.GetPriceHistory(p.ID), h.store.GetProductEvents(p.ID)}
+	}
+
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	points := []ModelPricePoint{}
+	for d := days - 1; d >= 0; d-- {
+		day := today.AddDate(0, 0, -d)
+		end := day.AddDate(0, 0, 1)
+		if end.After(now) {
+			end = now
+		}
+
+		point := ModelPricePoint{Date: day.Format("2006-01-02")}
+		var sum float64
+		for _, l := range listings {
+			if l.product.CreatedAt.After(end) || stockStatusAt(l.product, l.events, end) == model.EventSoldOut {
+				continue
+			}
+			price := priceAt(l.product, l.history, end)
+			if price <= 0 {
+				continue
+			}
+			point.Listings++
+			sum += price
+			if point.MinPrice == 0 || price < point.MinPrice {
+				point.MinPrice = price
+			}
+		}
+		if point.Listings == 0 {
+			continue
+		}
+		point.AvgPrice = math.Round(sum/float64(point.Listings)*100) / 100
+		points = append(points, point)
+	}
+	return points
+}
+
+// priceAt returns a product's price at t. History entries hold the price a
+// product had until the change recorded at their timestamp.
+func priceAt(p *model.Product, history []model.PriceHistory, t time.Time) float64 {
+	for _, entry := range history {
+		if entry.Timestamp.After(t) {
+			return entry.Price
+		}
+	}
+	return p.Price
+}
+
+// stockStatusAt returns a product's stock status at t from its stock events,
+// which are ordered by time. Without any events only the current status is known.
+func stockStatusAt(p *model.Product, events []model.ProductEvent, t time.Time) string {
+	if len(events) == 0 {
+		return p.StockStatus
+	}
+	status := model.EventAvailable
+	for _, e := range events {
+		if e.CreatedAt.After(t) {
+			break
+		}
+		status = e.EventType
+	}
+	if status == model.EventListed {
+		return model.EventAvailable
+	}
+	return status
+}
@@ -0,0 +1,237 @@
+package api
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"apple-price/internal/model"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// maxPollWait is how long GET /api/poll holds a request without events
+	maxPollWait = 30 * time.Second
+	// pollCheckInterval is how often a held poll looks for catalog changes
+	pollCheckInterval = time.Second
+	// maxPollEvents caps the events of one poll; the rest come with the next
+	maxPollEvents = 100
+)
+
+// pollFilter narrows the events a poll returns; empty fields match everything
+type pollFilter struct {
+	category   string
+	region     string
+	types      map[string]bool
+	productIDs map[string]bool
+	spec       model.SpecCombo
+	maxPrice   float64
+}
+
+// splitSet turns a comma-separated query value into a set
+func splitSet(v string) map[string]bool {
+	set := make(map[string]bool)
+	for _, s := range strings.Split(v, ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			set[s] = true
+		}
+	}
+	return set
+}
+
+// matchesProduct reports whether a product's events pass the product filters
+func (f *pollFilter) matchesProduct(p *model.Product) bool {
+	if f.category != "" && p.Category != f.category {
+		return false
+	}
+	if f.region != "" && p.Region != f.region {
+		return false
+	}
+	if len(f.productIDs) > 0 && !f.productIDs[p.ID] {
+		return false
+	}
+	return f.spec.IsEmpty() || f.spec.Matches(p)
+}
+
+// matchesEvent reports whether an event passes the type and price filters
+func (f *pollFilter) matchesEvent(e model.PollEvent) bool {
+	if len(f.types) > 0 && !f.types[e.Type] {
+		return false
+	}
+	return f.maxPrice <= 0 || e.Price <= f.maxPrice
+}
+
+// pollEvents collects the events in the batch's (since, until] window, oldest first
+func (h *Handlers) pollEvents(f *pollFilter, batch *model.PollBatch) []model.PollEvent {
+	inWindow := func(t time.Time) bool {
+		return t.Unix() > batch.Since && t.Unix() <= batch.Until
+	}
+
+	events := []model.PollEvent{}
+	for _, p := range h.store.GetAllProducts() {
+		// History and events are only written together with a product update
+		if !inWindow(p.UpdatedAt) || !f.matchesProduct(p) {
+			continue
+		}
+		event := func(eventType string, price float64, at time.Time) model.PollEvent {
+			return model.PollEvent{
+				Type:        eventType,
+				ProductID:   p.ID,
+				ProductName: p.Name,
+				Category:    p.Category,
+				Region:      p.Region,
+				ImageURL:    p.ImageURL,
+				Price:       price,
+				CreatedAt:   at,
+			}
+		}
+
+		for _, e := range h.store.GetProductEvents(p.ID) {
+			if ev := event(e.EventType, e.Price, e.CreatedAt); inWindow(e.CreatedAt) && f.matchesEvent(ev) {
+				events = append(events, ev)
+			}
+		}
+
+		// A price change records the old price at the time of the change; the new
+		// one is in the next entry, or the product itself for the latest change
+		history := h.store.GetPriceHistory(p.ID)
+		for i, entry := range history {
+			newPrice := p.Price
+			if i+1 < len(history) {
+				newPrice = history[i+1].Price
+			}
+			if !inWindow(entry.Timestamp) || newPrice == entry.Price {
+				continue
+			}
+			eventType := model.EventPriceDrop
+			if newPrice > entry.Price {
+				eventType = model.EventPriceRise
+			}
+			ev := event(eventType, newPrice, entry.Timestamp)
+			ev.OldPrice = entry.Price
+			if f.matchesEvent(ev) {
+				events = append(events, ev)
+			}
+		}
+	}
+
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].CreatedAt.Before(events[j].CreatedAt)
+	})
+	if len(events) > maxPollEvents {
+		// End the window before the second the cap falls in; the next poll picks up
+		// the rest. A single second holding more than the cap is delivered whole.
+		cut := events[maxPollEvents].CreatedAt.Unix()
+		if cut == events[0].CreatedAt.Unix() {
+			cut++
+		}
+		n := sort.Search(len(events), func(i int) bool { return events[i].CreatedAt.Unix() >= cut })
+		events = events[:n]
+		batch.Until = cut - 1
+	}
+	return events
+}
+
+// Poll long-polls for catalog changes, for browsers that show desktop notifications
+// but cannot use WebSocket or SSE. Without since it returns a cursor immediately;
+// otherwise it holds the request up to wait seconds (max 30) until events arrive.
+// GET /api/poll?since=<unix seconds>&category=&region=&types=listed,price_drop&product_ids=&spec=&max_price=&wait=
+func (h *Handlers) Poll(c *gin.Context) {
+	filter := &pollFilter{
+		category:   c.Query("category"),
+		region:     c.Query("region"),
+		types:      splitSet(c.Query("types")),
+		productIDs: splitSet(c.Query("product_ids")),
+		spec:       model.ParseSpecCombo(c.Query("spec")),
+	}
+	if v := c.Query("max_price"); v != "" {
+		price, err := strconv.ParseFloat(v, 64)
+		if err != nil || price < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "max_price must be a non-negative number"})
+			return
+		}
+		filter.maxPrice = price
+	}
+
+	wait := maxPollWait
+	if v := c.Query("wait"); v != "" {
+		seconds, err := strconv.Atoi(v)
+		if err != nil || seconds < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "wait must be a non-negative number of seconds"})
+			return
+		}
+		if d := time.Duration(seconds) * time.Second; d < wait {
+			wait = d
+		}
+	}
+
+	// First poll: hand out a cursor, not the whole history
+	s := c.Query("since")
+	if s == "" {
+		until := time.Now().Add(-replicationLag).Unix()
+		c.JSON(http.StatusOK, model.PollBatch{Since: until, Until: until, Events: []model.PollEvent{}})
+		return
+	}
+	since, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || since < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "since must be a Unix timestamp in seconds"})
+		return
+	}
+
+	collect := func() *model.PollBatch {
+		batch := &model.PollBatch{Since: since, Until: time.Now().Add(-replicationLag).Unix()}
+		if batch.Until < since {
+			batch.Until = since
+		}
+		batch.Events = h.pollEvents(filter, batch)
+		return batch
+	}
+
+	batch := collect()
+	if len(batch.Events) > 0 || wait == 0 {
+		c.JSON(http.StatusOK, batch)
+		return
+	}
+
+	// Hold the request, re-checking once the catalog changed and rows stamped in
+	// that second fell out of the replication lag
+	deadline := time.NewTimer(wait)
+	defer deadline.Stop()
+	ticker := time.NewTicker(pollCheckInterval)
+	defer ticker.Stop()
+
+	version := h.store.ProductsVersion()
+	var changedAt, lastChange time.Time // first unchecked and latest catalog change
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case <-deadline.C:
+			c.JSON(http.StatusOK, collect())
+			return
+		case now := <-ticker.C:
+			if v := h.store.ProductsVersion(); v != version {
+				version = v
+				lastChange = now
+				if changedAt.IsZero() {
+					changedAt = now
+				}
+			}
+			if changedAt.IsZero() || now.Sub(changedAt) < replicationLag {
+				continue
+			}
+			if batch := collect(); len(batch.Events) > 0 {
+				c.JSON(http.StatusOK, batch)
+				return
+			}
+			// Changes still inside the lag were not covered by this check
+			changedAt = time.Time{}
+			if now.Sub(lastChange) < replicationLag {
+				changedAt = lastChange
+			}
+		}
+	}
+}
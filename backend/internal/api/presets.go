@@ -0,0 +1,59 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+
+	"apple-price/internal/model"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetSubscriptionPresets lists the curated new arrival filter presets
+// GET /api/subscription-presets
+func (h *Handlers) GetSubscriptionPresets(c *gin.Context) {
+	presets := model.SubscriptionPresets()
+	c.JSON(http.StatusOK, gin.H{
+		"count":   len(presets),
+		"presets": presets,
+	})
+}
+
+// CreateSubscriptionFromPreset creates a new arrival subscription with a preset's
+// filters under the caller's Bark Key. Delivery settings come from the request.
+// POST /api/subscription-presets/:id/subscribe
+func (h *Handlers) CreateSubscriptionFromPreset(c *gin.Context) {
+	preset, ok := model.FindSubscriptionPreset(c.Param("id"))
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "preset not found"})
+		return
+	}
+
+	var req struct {
+		BarkKey         string `json:"bark_key"`
+		Name            string `json:"name"` // optional, defaults to the preset name
+		BarkServer      string `json:"bark_server"`
+		QuietHoursStart string `json:"quiet_hours_start"`
+		QuietHoursEnd   string `json:"quiet_hours_end"`
+		Timezone        string `json:"timezone"`
+		Frequency       string `json:"frequency"`
+		Language        string `json:"language"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	sub := preset.NewSubscription(req.BarkKey)
+	if name := strings.TrimSpace(req.Name); name != "" {
+		sub.Name = name
+	}
+	sub.BarkServer = req.BarkServer
+	sub.QuietHoursStart = req.QuietHoursStart
+	sub.QuietHoursEnd = req.QuietHoursEnd
+	sub.Timezone = req.Timezone
+	sub.Frequency = req.Frequency
+	sub.Language = req.Language
+
+	h.addNewArrivalSubscription(c, sub)
+}
@@ -0,0 +1,99 @@
+package api
+
+import (
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"apple-price/internal/match"
+	"apple-price/internal/model"
+
+	"github.com/gin-gonic/gin"
+)
+
+// priceDropWindows are the look-back windows the price drop leaderboard supports
+var priceDropWindows = map[string]time.Duration{
+	"24h": 24 * time.Hour,
+	"7d":  7 * 24 * time.Hour,
+}
+
+// PriceDrop is a product that got cheaper within the window
+type PriceDrop struct {
+	Product       *model.Product `json:"product"`
+	PreviousPrice float64        `json:"previous_price"` // price at the start of the window
+	Drop          float64        `json:"drop"`           // previous minus current price
+	DropPercent   float64        `json:"drop_percent"`   // drop as % of the previous price
+}
+
+// GetPriceDrops ranks the products whose price fell within the window, by the
+// amount or by the percentage of the drop, comparing the price at the start of
+// the window (from price history) with the current one
+// GET /api/price-drops?window=24h|7d&sort=amount|percent&category=Mac&region=cn&limit=20
+func (h *Handlers) GetPriceDrops(c *gin.Context) {
+	window := c.DefaultQuery("window", "24h")
+	duration, ok := priceDropWindows[window]
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "window must be 24h or 7d"})
+		return
+	}
+
+	sortBy := c.DefaultQuery("sort", "amount")
+	if sortBy != "amount" && sortBy != "percent" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "sort must be amount or percent"})
+		return
+	}
+
+	limit := defaultDealsLimit
+	if v := c.Query("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be a positive integer"})
+			return
+		}
+		limit = min(n, maxDealsLimit)
+	}
+
+	criteria := match.Criteria{Categories: c.QueryArray("category"), Regions: c.QueryArray("region")}
+	since := time.Now().Add(-duration)
+	previous := h.store.GetPricesAt(since)
+
+	drops := make([]*PriceDrop, 0)
+	for _, p := range h.store.GetAllProducts() {
+		was, ok := previous[p.ID]
+		if !ok || was <= p.Price || p.Price <= 0 || !criteria.Matches(p) {
+			continue
+		}
+		drop := was - p.Price
+		drops = append(drops, &PriceDrop{
+			Product:       p,
+			PreviousPrice: was,
+			Drop:          math.Round(drop*100) / 100,
+			DropPercent:   math.Round(drop/was*1000) / 10,
+		})
+	}
+
+	sort.SliceStable(drops, func(i, j int) bool {
+		a, b := drops[i], drops[j]
+		if sortBy == "percent" && a.DropPercent != b.DropPercent {
+			return a.DropPercent > b.DropPercent
+		}
+		if a.Drop != b.Drop {
+			return a.Drop > b.Drop
+		}
+		return a.Product.ID < b.Product.ID
+	})
+	total := len(drops)
+	if len(drops) > limit {
+		drops = drops[:limit]
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"window": window,
+		"since":  since,
+		"sort":   sortBy,
+		"total":  total,
+		"drops":  drops,
+	})
+}
@@ -0,0 +1,24 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"apple-price/internal/model"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetPriceIndex returns the "ApplePrice index": today's price per GB of storage for
+// each spec tier (product line, chip and memory) against its trailing 90 day
+// average, and their product-weighted average. 100 means prices are as usual,
+// below 100 that the catalog is cheaper than usual.
+// GET /api/index?region=cn
+func (h *Handlers) GetPriceIndex(c *gin.Context) {
+	region := strings.ToLower(c.Query("region"))
+	since := time.Now().AddDate(0, 0, -(model.PriceIndexWindowDays - 1))
+
+	index := model.BuildPriceIndex(h.store.GetPriceIndexTimeline(region, since), model.PriceIndexWindowDays)
+	c.JSON(http.StatusOK, index)
+}
@@ -0,0 +1,118 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"sort"
+	"strings"
+
+	"apple-price/internal/model"
+
+	"github.com/gin-gonic/gin"
+)
+
+// EditProduct corrects scraper mistakes such as a wrong category or a broken
+// image URL. Only model.EditableProductFields may be set. With "override": true
+// the corrected fields are pinned and reapplied over every later scrape; without
+// it the next scrape may overwrite them again, and any earlier pin on them is
+// released.
+// PATCH /api/admin/products/:id {"category": "Mac", "override": true}
+func (h *Handlers) EditProduct(c *gin.Context) {
+	id := c.Param("id")
+
+	var req map[string]json.RawMessage
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	pin := false
+	fields := make(map[string]string)
+	for name, raw := range req {
+		if name == "override" {
+			if err := json.Unmarshal(raw, &pin); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "override must be a boolean"})
+				return
+			}
+			continue
+		}
+		if !slices.Contains(model.EditableProductFields, name) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":    fmt.Sprintf("field %s is not editable", name),
+				"editable": model.EditableProductFields,
+			})
+			return
+		}
+		var value string
+		if err := json.Unmarshal(raw, &value); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("%s must be a string", name)})
+			return
+		}
+		value = strings.TrimSpace(value)
+		if err := validateProductField(name, value); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		fields[name] = value
+	}
+	if len(fields) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":    "no fields to edit",
+			"editable": model.EditableProductFields,
+		})
+		return
+	}
+
+	if _, ok := h.store.GetProduct(id); !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "product not found"})
+		return
+	}
+
+	product, err := h.store.EditProduct(id, fields, pin)
+	if err != nil {
+		requestLogger(c).Error("Failed to edit product", "product_id", id, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to edit product"})
+		return
+	}
+
+	if err := h.store.Save(); err != nil {
+		requestLogger(c).Error("Failed to save data", "error", err)
+	}
+
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	requestLogger(c).Info("Product edited", "product_id", id, "fields", names, "override", pin)
+
+	pinned := map[string]string{}
+	if override, ok := h.store.GetProductOverride(id); ok {
+		pinned = override.Fields
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"product": product,
+		"pinned":  pinned, // fields later scrapes won't overwrite
+	})
+}
+
+// validateProductField checks a value an admin wants to set on a product
+func validateProductField(name, value string) error {
+	switch name {
+	case "name", "category":
+		if value == "" {
+			return fmt.Errorf("%s must not be empty", name)
+		}
+	case "image_url", "product_url":
+		if value == "" {
+			return nil
+		}
+		if u, err := url.Parse(value); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("%s must be an http(s) URL", name)
+		}
+	}
+	return nil
+}
@@ -1,6 +1,7 @@
 package api
 
 import (
+	"apple-price/internal/match"
 	"apple-price/internal/model"
 	"errors"
 	"fmt"
@@ -45,6 +46,10 @@ var categoryMapping = map[string][]string{
 	"iPad Air":      {"iPad", "Air"},
 	"iPad":          {"iPad"},
 	"Watch":         {"Watch"},
+	"AirPods":       {"AirPods"},
+	"HomePod":       {"HomePod"},
+	"Apple TV":      {"Apple TV"},
+	"Display":       {"Display"},
 	"Accessory":     {"Accessory"},
 }
 
@@ -152,6 +157,17 @@ func abs(x float64) float64 {
 func (h *Handlers) filterCandidates(products []*model.Product, req RecommendationRequest) []*model.Product {
 	var candidates []*model.Product
 
+	var criteria match.Criteria
+	if req.Chip != "" {
+		criteria.Chips = []string{req.Chip}
+	}
+	if req.StorageMin != nil {
+		criteria.MinStorageGB = *req.StorageMin
+	}
+	if req.StorageMax != nil {
+		criteria.MaxStorageGB = *req.StorageMax
+	}
+
 	for _, p := range products {
 		// 预算筛选 - 严格匹配，不放宽
 		if req.BudgetMin != nil && p.Price < *req.BudgetMin {
@@ -166,20 +182,8 @@ func (h *Handlers) filterCandidates(products []*model.Product, req Recommendatio
 			continue
 		}
 
-		// 芯片筛选
-		if req.Chip != "" {
-			chip := extractChip(p.Name)
-			if chip != req.Chip {
-				continue
-			}
-		}
-
-		// 存储筛选
-		storage := extractStorage(p.Name)
-		if req.StorageMin != nil && storage < *req.StorageMin {
-			continue
-		}
-		if req.StorageMax != nil && storage > *req.StorageMax {
+		// 芯片、存储筛选 - 与商品筛选、上新订阅共用同一套规格匹配
+		if !criteria.Matches(p) {
 			continue
 		}
 
@@ -289,27 +293,10 @@ func (h *Handlers) calculateRecommendationScore(product *model.Product, req Reco
 	}
 
 	// 4. 价格位置 (0-15分)
-	history := h.store.GetPriceHistory(product.ID)
-	if len(history) > 1 {
-		minPrice := history[0].Price
-		maxPrice := history[0].Price
-		for _, h := range history {
-			if h.Price < minPrice {
-				minPrice = h.Price
-			}
-			if h.Price > maxPrice {
-				maxPrice = h.Price
-			}
-		}
-
-		if maxPrice > minPrice {
-			position := (product.Price - minPrice) / (maxPrice - minPrice)
-			if position <= 0.2 {
-				score += 15
-				if len(reasons) < 3 {
-					reasons = append(reasons, "当前价格接近历史低位，是好时机")
-				}
-			}
+	if position, ok := pricePosition(h.store.GetPriceHistory(product.ID), product.Price); ok && position <= 0.2 {
+		score += 15
+		if len(reasons) < 3 {
+			reasons = append(reasons, "当前价格接近历史低位，是好时机")
 		}
 	}
 
@@ -346,6 +333,30 @@ func (h *Handlers) calculateRecommendationScore(product *model.Product, req Reco
 	return score, reasons
 }
 
+// pricePosition 计算当前价格在历史价格区间中的位置（0 = 历史最低，1 = 历史最高），
+// 历史价格没有波动时 ok 为 false
+func pricePosition(history []model.PriceHistory, price float64) (position float64, ok bool) {
+	if len(history) < 2 {
+		return 0, false
+	}
+
+	minPrice := history[0].Price
+	maxPrice := history[0].Price
+	for _, h := range history {
+		if h.Price < minPrice {
+			minPrice = h.Price
+		}
+		if h.Price > maxPrice {
+			maxPrice = h.Price
+		}
+	}
+	if maxPrice <= minPrice {
+		return 0, false
+	}
+
+	return (price - minPrice) / (maxPrice - minPrice), true
+}
+
 // useCaseScore 计算用途匹配分数
 func (h *Handlers) useCaseScore(product *model.Product, useCase string, reasons *[]string) float64 {
 	score := 0.0
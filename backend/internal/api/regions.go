@@ -0,0 +1,137 @@
+package api
+
+import (
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"apple-price/internal/model"
+
+	"github.com/gin-gonic/gin"
+)
+
+var (
+	regionCodePattern = regexp.MustCompile(`^[a-z]{2,5}$`)
+	currencyPattern   = regexp.MustCompile(`^[A-Z]{3}$`)
+)
+
+// GetRegions lists the region registry
+// GET /api/admin/regions
+func (h *Handlers) GetRegions(c *gin.Context) {
+	regions := h.store.GetRegions()
+
+	c.JSON(http.StatusOK, gin.H{
+		"count":   len(regions),
+		"regions": regions,
+	})
+}
+
+// UpsertRegion adds a storefront or updates an existing one (e.g. to disable it).
+// Omitted fields keep their current values on update.
+// POST /api/admin/regions
+func (h *Handlers) UpsertRegion(c *gin.Context) {
+	var req struct {
+		Code       string   `json:"code" binding:"required"`
+		Name       string   `json:"name"`
+		BaseURL    string   `json:"base_url"`
+		Currency   string   `json:"currency"`
+		Categories []string `json:"categories"` // category pages to scrape; [] resets to the defaults
+		Enabled    *bool    `json:"enabled"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	code := strings.ToLower(strings.TrimSpace(req.Code))
+	if !regionCodePattern.MatchString(code) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "code must be 2-5 lowercase letters"})
+		return
+	}
+
+	now := time.Now()
+	region, exists := h.store.GetRegion(code)
+	if !exists {
+		region = &model.Region{Code: code, Enabled: true, CreatedAt: now}
+	}
+
+	if req.Name != "" {
+		region.Name = strings.TrimSpace(req.Name)
+	}
+	if req.BaseURL != "" {
+		region.BaseURL = strings.TrimRight(strings.TrimSpace(req.BaseURL), "/")
+	}
+	if req.Currency != "" {
+		region.Currency = strings.ToUpper(strings.TrimSpace(req.Currency))
+	}
+	if req.Categories != nil {
+		categories := []string{}
+		seen := make(map[string]bool)
+		for _, raw := range req.Categories {
+			category, ok := model.NormalizeRegionCategory(raw)
+			if !ok {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "unknown category: " + raw})
+				return
+			}
+			if !seen[category] {
+				seen[category] = true
+				categories = append(categories, category)
+			}
+		}
+		region.Categories = categories
+	}
+	if req.Enabled != nil {
+		region.Enabled = *req.Enabled
+	}
+	region.UpdatedAt = now
+
+	if region.Name == "" {
+		region.Name = code
+	}
+	if u, err := url.Parse(region.BaseURL); err != nil || u.Scheme != "https" || u.Host == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "base_url must be an https URL"})
+		return
+	}
+	if !currencyPattern.MatchString(region.Currency) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "currency must be a 3-letter ISO code"})
+		return
+	}
+
+	if err := h.store.UpsertRegion(region); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save region"})
+		return
+	}
+
+	if err := h.store.Save(); err != nil {
+		requestLogger(c).Error("Failed to save data", "error", err)
+	}
+
+	requestLogger(c).Info("Region saved", "region", region.Code, "enabled", region.Enabled, "created", !exists)
+
+	status := http.StatusOK
+	if !exists {
+		status = http.StatusCreated
+	}
+	c.JSON(status, region)
+}
+
+// DeleteRegion removes a storefront from the registry. Products already scraped
+// from it are kept; use DELETE /api/admin/products/region/:region to remove them.
+// DELETE /api/admin/regions/:code
+func (h *Handlers) DeleteRegion(c *gin.Context) {
+	code := c.Param("code")
+	if err := h.store.DeleteRegion(code); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "region not found"})
+		return
+	}
+
+	if err := h.store.Save(); err != nil {
+		requestLogger(c).Error("Failed to save data", "error", err)
+	}
+
+	requestLogger(c).Info("Region deleted", "region", code)
+
+	c.JSON(http.StatusOK, gin.H{"message": "region deleted", "region": code})
+}
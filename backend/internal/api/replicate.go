@@ -0,0 +1,76 @@
+package api
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"apple-price/internal/model"
+
+	"github.com/gin-gonic/gin"
+)
+
+// replicationLag keeps the newest second out of a batch: rows are stamped with whole
+// seconds and one being written right now would otherwise be skipped by the next pull
+const replicationLag = 2 * time.Second
+
+// ScrapeTimeReader is implemented by stores that know when the catalog was last scraped
+type ScrapeTimeReader interface {
+	GetLastScrapeTime() time.Time
+}
+
+// Replicate returns the catalog changes since a point in time, for mirrors that
+// copy this instance instead of scraping Apple themselves (see cmd/replica)
+// GET /api/replicate?since=<unix seconds>
+func (h *Handlers) Replicate(c *gin.Context) {
+	var since int64
+	if s := c.Query("since"); s != "" {
+		v, err := strconv.ParseInt(s, 10, 64)
+		if err != nil || v < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "since must be a Unix timestamp in seconds"})
+			return
+		}
+		since = v
+	}
+
+	batch := &model.ReplicationBatch{
+		Since:        since,
+		Until:        time.Now().Add(-replicationLag).Unix(),
+		ProductIDs:   []string{},
+		Products:     []*model.Product{},
+		PriceHistory: []model.PriceHistory{},
+		Events:       []model.ProductEvent{},
+	}
+	if st, ok := h.store.(ScrapeTimeReader); ok {
+		batch.LastScrapeTime = st.GetLastScrapeTime()
+	}
+
+	// Archived products stay listed, or replicas would delete them with their history
+	products := append(h.store.GetAllProducts(), h.store.GetArchivedProducts("")...)
+	updated := make(map[string]bool)
+	for _, p := range products {
+		batch.ProductIDs = append(batch.ProductIDs, p.ID)
+		if batch.InReplicationWindow(p.UpdatedAt) {
+			batch.Products = append(batch.Products, p)
+			updated[p.ID] = true
+		}
+	}
+
+	// History and events are only written together with a product update, so
+	// the rows in the window belong to the updated products
+	from, until := time.Unix(batch.Since, 0), time.Unix(batch.Until, 0)
+	for _, entry := range h.store.GetPriceHistoryBetween(from, until) {
+		if updated[entry.ProductID] {
+			batch.PriceHistory = append(batch.PriceHistory, entry)
+		}
+	}
+	for _, e := range h.store.GetProductEventsBetween(from, until) {
+		if updated[e.ProductID] {
+			batch.Events = append(batch.Events, e)
+		}
+	}
+	sort.Strings(batch.ProductIDs)
+
+	c.JSON(http.StatusOK, batch)
+}
@@ -0,0 +1,222 @@
+package api
+
+import (
+	"fmt"
+	"hash/fnv"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"apple-price/internal/model"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// restockWaveGap merges arrivals of one model this close together into a single restock wave
+	restockWaveGap = 12 * time.Hour
+	// restockMinWaves is the number of observed waves needed before a model gets predictions
+	restockMinWaves = 3
+	// restockMinHalfWindow is the least a predicted window extends either side of its center
+	restockMinHalfWindow = 12 * time.Hour
+	// restockFeedOccurrences is how many upcoming windows are published per model
+	restockFeedOccurrences = 3
+	// restockFeedRefresh is the refresh interval suggested to calendar clients
+	restockFeedRefresh = 6 * time.Hour
+)
+
+// restockWindow is a predicted span in which a model line is likely to restock
+type restockWindow struct {
+	Category string
+	Region   string
+	Model    string
+	Start    time.Time
+	End      time.Time
+	Waves    int           // observed restock waves the prediction is based on
+	Interval time.Duration // average time between waves
+	LastWave time.Time
+}
+
+// restockWaves sorts arrival times and merges those within restockWaveGap of a
+// wave's first arrival, so a batch of listings counts once
+func restockWaves(times []time.Time) []time.Time {
+	sort.Slice(times, func(i, j int) bool { return times[i].Before(times[j]) })
+	var waves []time.Time
+	for _, t := range times {
+		if len(waves) > 0 && t.Sub(waves[len(waves)-1]) < restockWaveGap {
+			continue
+		}
+		waves = append(waves, t)
+	}
+	return waves
+}
+
+// predictRestockWindows projects the next restock windows from past waves: centered
+// on the average interval after the last wave, as wide as the intervals vary. Windows
+// already over are rolled forward, so an overdue model still shows upcoming dates.
+func predictRestockWindows(waves []time.Time, now time.Time) (windows []restockWindow) {
+	if len(waves) < restockMinWaves {
+		return nil
+	}
+	last := waves[len(waves)-1]
+	interval := last.Sub(waves[0]) / time.Duration(len(waves)-1)
+	if interval <= 0 {
+		return nil
+	}
+
+	var deviation time.Duration
+	for i := 1; i < len(waves); i++ {
+		d := waves[i].Sub(waves[i-1]) - interval
+		if d < 0 {
+			d = -d
+		}
+		deviation += d
+	}
+	half := deviation / time.Duration(len(waves)-1)
+	if half < restockMinHalfWindow {
+		half = restockMinHalfWindow
+	}
+	if half > interval/2 {
+		half = interval / 2
+	}
+
+	next := last.Add(interval)
+	for next.Add(half).Before(now) {
+		next = next.Add(interval)
+	}
+	for i := 0; i < restockFeedOccurrences; i++ {
+		windows = append(windows, restockWindow{
+			Start:    next.Add(-half),
+			End:      next.Add(half),
+			Waves:    len(waves),
+			Interval: interval,
+			LastWave: last,
+		})
+		next = next.Add(interval)
+	}
+	return windows
+}
+
+// arrivalTimes returns when a product arrived: its listing (or creation, for products
+// older than lifecycle events) and every sold_out -> available restock
+func arrivalTimes(p *model.Product, events []model.ProductEvent) []time.Time {
+	times := restockTimes(events)
+	listed := false
+	for _, e := range events {
+		if e.EventType == model.EventListed {
+			times = append(times, e.CreatedAt)
+			listed = true
+		}
+	}
+	if !listed && !p.CreatedAt.IsZero() {
+		times = append(times, p.CreatedAt)
+	}
+	return times
+}
+
+// GetRestockFeed publishes predicted restock windows per model line as an iCalendar
+// feed, for subscribing in Apple Calendar and other calendar apps
+// GET /api/feeds/restocks.ics?category=&region=&model=
+func (h *Handlers) GetRestockFeed(c *gin.Context) {
+	category := c.Query("category")
+	region := c.Query("region")
+	modelFilter := c.Query("model")
+	now := time.Now()
+
+	type lineKey struct{ category, region, model string }
+	arrivals := make(map[lineKey][]time.Time)
+	for _, p := range h.store.GetAllProducts() {
+		if category != "" && p.Category != category {
+			continue
+		}
+		if region != "" && p.Region != region {
+			continue
+		}
+		modelName := model.ModelFromName(p.Name, p.Category)
+		if modelName == "" {
+			modelName = p.Category
+		}
+		if modelFilter != "" && !strings.EqualFold(modelName, modelFilter) {
+			continue
+		}
+		key := lineKey{p.Category, p.Region, modelName}
+		arrivals[key] = append(arrivals[key], arrivalTimes(p, h.store.GetProductEvents(p.ID))...)
+	}
+
+	var windows []restockWindow
+	for key, times := range arrivals {
+		for _, w := range predictRestockWindows(restockWaves(times), now) {
+			w.Category, w.Region, w.Model = key.category, key.region, key.model
+			windows = append(windows, w)
+		}
+	}
+	sort.Slice(windows, func(i, j int) bool {
+		if !windows[i].Start.Equal(windows[j].Start) {
+			return windows[i].Start.Before(windows[j].Start)
+		}
+		return windows[i].Model < windows[j].Model
+	})
+
+	c.Header("Cache-Control", "public, max-age=3600")
+	c.Data(http.StatusOK, "text/calendar; charset=utf-8", []byte(restockCalendar(windows, now)))
+}
+
+// restockCalendar renders the windows as an RFC 5545 calendar
+func restockCalendar(windows []restockWindow, now time.Time) string {
+	var b strings.Builder
+	line := func(s string) {
+		// Fold lines longer than 75 octets without splitting a UTF-8 sequence
+		for len(s) > 75 {
+			cut := 75
+			for cut > 0 && s[cut]&0xC0 == 0x80 {
+				cut--
+			}
+			b.WriteString(s[:cut] + "\r\n")
+			s = " " + s[cut:]
+		}
+		b.WriteString(s + "\r\n")
+	}
+	stamp := func(t time.Time) string { return t.UTC().Format("20060102T150405Z") }
+
+	line("BEGIN:VCALENDAR")
+	line("VERSION:2.0")
+	line("PRODID:-//apple-price//Restock Predictions//ZH")
+	line("CALSCALE:GREGORIAN")
+	line("METHOD:PUBLISH")
+	line("X-WR-CALNAME:" + icsText("Apple 翻新补货预测"))
+	line(fmt.Sprintf("REFRESH-INTERVAL;VALUE=DURATION:PT%dH", int(restockFeedRefresh.Hours())))
+	line(fmt.Sprintf("X-PUBLISHED-TTL:PT%dH", int(restockFeedRefresh.Hours())))
+
+	for _, w := range windows {
+		hash := fnv.New32a()
+		hash.Write([]byte(w.Category + "|" + w.Region + "|" + w.Model))
+		uid := fmt.Sprintf("restock-%08x-%s@apple-price", hash.Sum32(), w.Start.UTC().Format("20060102"))
+
+		summary := fmt.Sprintf("可能补货：%s", w.Model)
+		if w.Region != "" {
+			summary += fmt.Sprintf("（%s）", strings.ToUpper(w.Region))
+		}
+		description := fmt.Sprintf("根据 %d 次上新/补货记录预测，平均间隔 %.1f 天，上次补货 %s。预测仅供参考。",
+			w.Waves, w.Interval.Hours()/24, w.LastWave.Format("2006-01-02"))
+
+		line("BEGIN:VEVENT")
+		line("UID:" + uid)
+		line("DTSTAMP:" + stamp(now))
+		line("DTSTART:" + stamp(w.Start))
+		line("DTEND:" + stamp(w.End))
+		line("SUMMARY:" + icsText(summary))
+		line("DESCRIPTION:" + icsText(description))
+		line("CATEGORIES:" + icsText(w.Category))
+		line("TRANSP:TRANSPARENT")
+		line("END:VEVENT")
+	}
+
+	line("END:VCALENDAR")
+	return b.String()
+}
+
+// icsText escapes a value for an iCalendar TEXT property
+func icsText(s string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\n", `\n`, "\r", "").Replace(s)
+}
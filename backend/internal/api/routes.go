@@ -4,40 +4,120 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
+// RouteDeps are the components SetupRoutes wires into the API. Only Store is
+// required; the zero value of every other field turns its feature off.
+type RouteDeps struct {
+	Store StoreInterface
+
+	// Dispatcher and Scheduler may be nil on instances that never notify or
+	// scrape, such as mirrors
+	Dispatcher PriceChangeNotifier
+	Scheduler  SchedulerInterface
+
+	// AdminToken protects the admin operations; stores implementing TokenValidator
+	// additionally accept tokens from their own token table
+	AdminToken string
+
+	// Storage may be nil, in which case writes are never refused for storage reasons
+	Storage StorageChecker
+	// Usage may be nil (the default), in which case no usage statistics are kept
+	Usage UsageTracker
+	// Currency may be nil, in which case cross-region comparison only converts
+	// between equal currencies
+	Currency CurrencyConverter
+	// Readiness may be nil, in which case /api/ready is ready whenever the server answers
+	Readiness ReadinessChecker
+	// Images may be nil, in which case /api/images redirects to Apple's image URLs
+	Images ImageCache
+}
+
 // SetupRoutes configures all API routes
-func SetupRoutes(r *gin.Engine, store StoreInterface, dispatcher PriceChangeNotifier, scheduler SchedulerInterface) {
-	handlers := NewHandlers(store, dispatcher, scheduler)
+func SetupRoutes(r *gin.Engine, deps RouteDeps) {
+	handlers := NewHandlers(deps.Store, deps.Dispatcher, deps.Scheduler)
+	handlers.storage = deps.Storage
+	handlers.usage = deps.Usage
+	handlers.currency = deps.Currency
+	handlers.readiness = deps.Readiness
+	handlers.images = deps.Images
+
+	validator, _ := deps.Store.(TokenValidator)
+	adminAuth := AdminAuth(deps.AdminToken, validator)
 
 	// API v1 routes
-	v1 := r.Group("/api")
+	v1 := r.Group("/api", RequestLogger(), ReadOnlyGuard(deps.Storage))
+	if deps.Usage != nil {
+		v1.Use(UsageCounter(deps.Usage))
+	}
 	{
 		// Health check (handle both GET and HEAD)
 		v1.GET("/health", handlers.HealthCheck)
 		v1.HEAD("/health", handlers.HealthCheck)
+		v1.GET("/ready", handlers.ReadinessCheck)
+		v1.HEAD("/ready", handlers.ReadinessCheck)
 
 		// Products
 		v1.GET("/products", handlers.GetProducts)
+		v1.GET("/products/compare", handlers.CompareProducts)
 		v1.GET("/products/:id", handlers.GetProduct)
 		v1.GET("/products/:id/history", handlers.GetProductHistory)
+		v1.GET("/products/:id/stats", handlers.GetProductStats)
+		v1.GET("/products/:id/score-breakdown", handlers.GetProductScoreBreakdown)
+		v1.GET("/products/:id/events", handlers.GetProductEvents)
+		v1.GET("/products/:id/forecast", handlers.GetProductForecast)
+		v1.GET("/products/:id/cross-region", handlers.GetProductCrossRegion)
+		v1.GET("/products/:id/equivalents", handlers.GetProductEquivalents)
+
+		// Product images served from the local cache instead of hotlinking Apple
+		v1.GET("/images/:productID", handlers.GetProductImage)
 
 		// Subscriptions
 		v1.POST("/subscriptions", handlers.CreateSubscription)
 		v1.DELETE("/subscriptions/:id", handlers.DeleteSubscription)
+		v1.PUT("/subscriptions/:id", handlers.UpdateSubscription)
+		v1.PATCH("/subscriptions/:id/pause", handlers.PausePriceSubscription)
+		v1.PATCH("/subscriptions/:id/resume", handlers.ResumePriceSubscription)
+		v1.POST("/subscriptions/:id/test", handlers.TestSubscription)
 		v1.GET("/subscriptions", handlers.GetSubscriptions)
+		v1.GET("/spec-combo", handlers.PreviewSpecCombo)
 
 		// New Arrival Subscriptions
 		v1.POST("/new-arrival-subscriptions", handlers.CreateNewArrivalSubscription)
+		v1.POST("/new-arrival-subscriptions/preview", handlers.PreviewNewArrivalSubscription)
 		v1.DELETE("/new-arrival-subscriptions/:id", handlers.DeleteNewArrivalSubscription)
+		v1.POST("/new-arrival-subscriptions/:id/restore", handlers.RestoreNewArrivalSubscription)
 		v1.GET("/new-arrival-subscriptions", handlers.GetNewArrivalSubscriptions)
 		v1.GET("/new-arrival-subscriptions/:id", handlers.GetNewArrivalSubscription)
 		v1.PUT("/new-arrival-subscriptions/:id", handlers.UpdateNewArrivalSubscription)
 		v1.PATCH("/new-arrival-subscriptions/:id/pause", handlers.PauseSubscription)
 		v1.PATCH("/new-arrival-subscriptions/:id/resume", handlers.ResumeSubscription)
 
+		// Curated new arrival filter presets
+		v1.GET("/subscription-presets", handlers.GetSubscriptionPresets)
+		v1.POST("/subscription-presets/:id/subscribe", handlers.CreateSubscriptionFromPreset)
+
+		// Per-user kill switch and preferences
+		v1.POST("/pause-all", handlers.PauseAll)
+		v1.POST("/resume-all", handlers.ResumeAll)
+		v1.GET("/preferences", handlers.GetPreferences)
+
+		// User accounts: an API key in place of the Bark Key
+		v1.POST("/users", handlers.RegisterUser)
+		v1.GET("/users/me", handlers.GetCurrentUser)
+		v1.POST("/users/me/rotate-key", handlers.RotateAPIKey)
+		v1.GET("/users/:id/preferences", handlers.GetUserPreferences)
+		v1.PUT("/users/:id/preferences", handlers.UpdateUserPreferences)
+
+		// Bark Key migration (new device / rotated key)
+		v1.POST("/migrate-key", handlers.MigrateBarkKey)
+
+		// Live Bark Key check (silent push, cached)
+		v1.POST("/bark/validate", handlers.ValidateBarkKey)
+
 		// Notification History
 		v1.GET("/notification-history", handlers.GetNotificationHistory)
 		v1.POST("/notification-history/:id/read", handlers.MarkNotificationAsRead)
 		v1.GET("/notification-history/unread-count", handlers.GetUnreadNotificationCount)
+		v1.GET("/notification-history/export.csv", handlers.ExportNotificationHistory)
 
 		// Categories
 		v1.GET("/categories", handlers.GetCategories)
@@ -47,16 +127,78 @@ func SetupRoutes(r *gin.Engine, store StoreInterface, dispatcher PriceChangeNoti
 
 		// Stats
 		v1.GET("/stats", handlers.GetStats)
+		v1.GET("/stats/timeline", handlers.GetStatsTimeline)
+		v1.GET("/stats/history", handlers.GetStatsHistory)
+		v1.GET("/models/:model/price-index", handlers.GetModelPriceIndex)
+		v1.GET("/index", handlers.GetPriceIndex)
+		v1.GET("/market/overview", handlers.GetMarketOverview)
+
+		// Long-polled catalog changes for in-browser notifications
+		v1.GET("/poll", handlers.Poll)
+
+		// Price chart annotations
+		v1.GET("/annotations", handlers.GetAnnotations)
+
+		// Watchlists, owned by the X-Client-Token header (no Bark Key needed)
+		v1.POST("/watchlists", handlers.CreateWatchlist)
+		v1.GET("/watchlists", handlers.GetWatchlists)
+		v1.GET("/watchlists/:id", handlers.GetWatchlist)
+		v1.DELETE("/watchlists/:id", handlers.DeleteWatchlist)
+		v1.POST("/saved-searches", handlers.CreateSavedSearch)
+		v1.GET("/saved-searches/:slug", handlers.GetSavedSearch)
+
+		// Calendar feed of predicted restock windows
+		v1.GET("/feeds/restocks.ics", handlers.GetRestockFeed)
+
+		// Bulk data export
+		v1.GET("/export/products", handlers.ExportProducts)
+		v1.GET("/export/history", handlers.ExportHistory)
+
+		// Incremental catalog changes for read-only mirrors (cmd/replica)
+		v1.GET("/replicate", handlers.Replicate)
+
+		// JSON Schemas of the API models, for client type generation
+		v1.GET("/schemas", handlers.GetSchemas)
+		v1.GET("/schemas/:name", handlers.GetSchema)
 
 		// Recommendations (断层领先: 智能推荐)
 		v1.POST("/recommendations", handlers.HandleRecommendation)
+		v1.GET("/deals", handlers.GetDeals)
+		v1.GET("/price-drops", handlers.GetPriceDrops)
 
-		// Detail scraper status
-		v1.GET("/admin/detail-status", handlers.GetDetailStatus)
+		// GraphQL queries over products, history, subscriptions and stats
+		v1.GET("/graphql", handlers.GraphQL)
+		v1.POST("/graphql", handlers.GraphQL)
 
-		// Admin operations (WARNING: No authentication - add auth middleware before production)
-		v1.POST("/admin/scrape", handlers.TriggerScrape)
-		v1.DELETE("/admin/products/region/:region", handlers.DeleteProductsByRegion)
+		// Admin operations (require admin token)
+		admin := v1.Group("/admin", adminAuth)
+		admin.POST("/scrape", handlers.TriggerScrape)
+		admin.POST("/scrape/cancel", handlers.CancelScrape)
+		admin.POST("/scrape/dry-run", handlers.DryRunScrape)
+		admin.GET("/scrape/runs", handlers.GetScrapeRuns)
+		admin.GET("/scrape/runs/:id", handlers.GetScrapeRun)
+		admin.GET("/scrape/stream", handlers.ScrapeStream)
+		admin.GET("/detail-status", handlers.GetDetailStatus)
+		admin.POST("/details/requeue/:id", handlers.RequeueDetails)
+		admin.DELETE("/products/region/:region", handlers.DeleteProductsByRegion)
+		admin.DELETE("/products/stale", handlers.DeleteStaleProducts)
+		admin.POST("/products/region/:region/archive", handlers.ArchiveProductsByRegion)
+		admin.GET("/products/archived", handlers.GetArchivedProducts)
+		admin.PATCH("/products/:id", handlers.EditProduct)
+		admin.POST("/products/restore", handlers.RestoreProducts)
+		admin.GET("/deletions", handlers.GetRegionDeletions)
+		admin.GET("/usage", handlers.GetUsage)
+		admin.GET("/scraper-status", handlers.GetScraperStatus)
+		admin.POST("/deletions/:id/undo", handlers.UndoRegionDeletion)
+		admin.POST("/simulate-event", handlers.SimulateEvent)
+		admin.POST("/annotations", handlers.CreateAnnotation)
+		admin.GET("/regions", handlers.GetRegions)
+		admin.POST("/regions", handlers.UpsertRegion)
+		admin.DELETE("/regions/:code", handlers.DeleteRegion)
+		admin.DELETE("/annotations/:id", handlers.DeleteAnnotation)
+		admin.GET("/category-sorts", handlers.GetCategoryDefaultSorts)
+		admin.PUT("/category-sorts/:category", handlers.SetCategoryDefaultSort)
+		admin.DELETE("/category-sorts/:category", handlers.DeleteCategoryDefaultSort)
 	}
 
 	// Serve frontend static files in production
@@ -0,0 +1,155 @@
+package api
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"apple-price/internal/model"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Saved search limits
+const (
+	savedSearchSlugLength      = 8
+	maxSavedSearchNameLength   = 50
+	maxSavedSearchFilterValues = 10 // per spec list
+)
+
+// slugAlphabet leaves out look-alikes (0/O, 1/l/I) so slugs survive being read out
+const slugAlphabet = "23456789abcdefghijkmnpqrstuvwxyzABCDEFGHJKLMNPQRSTUVWXYZ"
+
+// SavedSearchResponse is a saved search with the product list query it stands for
+type SavedSearchResponse struct {
+	*model.SavedSearch
+	Query       string `json:"query"`        // GET /api/products query string
+	ProductsURL string `json:"products_url"` // /api/products?<query>
+}
+
+// CreateSavedSearch stores a product list filter set under a new short slug
+// POST /api/saved-searches
+func (h *Handlers) CreateSavedSearch(c *gin.Context) {
+	var req model.SavedSearch
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	search, err := normalizeSavedSearch(req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if search.IsEmpty() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "a saved search needs at least one filter or sort"})
+		return
+	}
+
+	search.CreatedAt = time.Now()
+	search.Slug = newSlug()
+	for attempt := 0; attempt < 3; attempt++ {
+		if _, taken := h.store.GetSavedSearch(search.Slug); !taken {
+			break
+		}
+		search.Slug = newSlug()
+	}
+	if err := h.store.AddSavedSearch(search); err != nil {
+		requestLogger(c).Error("Failed to create saved search", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create saved search"})
+		return
+	}
+
+	if err := h.store.Save(); err != nil {
+		requestLogger(c).Error("Failed to save data", "error", err)
+	}
+
+	c.JSON(http.StatusCreated, savedSearchResponse(search))
+}
+
+// GetSavedSearch resolves a slug to its filter set and product list query
+// GET /api/saved-searches/:slug
+func (h *Handlers) GetSavedSearch(c *gin.Context) {
+	search, ok := h.store.GetSavedSearch(c.Param("slug"))
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "saved search not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, savedSearchResponse(search))
+}
+
+// savedSearchResponse adds the product list query to a saved search
+func savedSearchResponse(search *model.SavedSearch) SavedSearchResponse {
+	query := search.Query()
+	return SavedSearchResponse{SavedSearch: search, Query: query, ProductsURL: "/api/products?" + query}
+}
+
+// normalizeSavedSearch trims and checks the fields of a saved search request
+func normalizeSavedSearch(req model.SavedSearch) (*model.SavedSearch, error) {
+	search := &model.SavedSearch{
+		Name:        strings.TrimSpace(req.Name),
+		Category:    strings.TrimSpace(req.Category),
+		Region:      strings.TrimSpace(req.Region),
+		StockStatus: strings.TrimSpace(req.StockStatus),
+		Sort:        strings.TrimSpace(req.Sort),
+		Order:       strings.ToLower(strings.TrimSpace(req.Order)),
+		MinPrice:    req.MinPrice,
+		MaxPrice:    req.MaxPrice,
+	}
+	if len([]rune(search.Name)) > maxSavedSearchNameLength {
+		return nil, fmt.Errorf("name is too long (max %d characters)", maxSavedSearchNameLength)
+	}
+	switch search.Sort {
+	case "", "price", "discount", "score", "created", "savings":
+	default:
+		return nil, errors.New("sort must be price, discount, score, created or savings")
+	}
+	if search.Order != "" && search.Order != "asc" && search.Order != "desc" {
+		return nil, errors.New("order must be asc or desc")
+	}
+	switch search.StockStatus {
+	case "", "available", "sold_out", "limited":
+	default:
+		return nil, errors.New("stock_status must be available, sold_out or limited")
+	}
+	if search.MinPrice < 0 || search.MaxPrice < 0 {
+		return nil, errors.New("prices must not be negative")
+	}
+	if search.MaxPrice > 0 && search.MinPrice > search.MaxPrice {
+		return nil, errors.New("min_price must not exceed max_price")
+	}
+
+	for _, list := range []struct {
+		name string
+		dst  *[]string
+		src  []string
+	}{
+		{"chips", &search.Chips, req.Chips},
+		{"memories", &search.Memories, req.Memories},
+		{"storages", &search.Storages, req.Storages},
+		{"models", &search.Models, req.Models},
+		{"screen_sizes", &search.ScreenSizes, req.ScreenSizes},
+		{"colors", &search.Colors, req.Colors},
+	} {
+		// Values end up comma-joined in the products query, so commas split them
+		*list.dst = splitList(strings.Join(list.src, ","))
+		if len(*list.dst) > maxSavedSearchFilterValues {
+			return nil, fmt.Errorf("too many %s (max %d)", list.name, maxSavedSearchFilterValues)
+		}
+	}
+	return search, nil
+}
+
+// newSlug returns a random short slug
+func newSlug() string {
+	b := make([]byte, savedSearchSlugLength)
+	_, _ = rand.Read(b)
+	for i := range b {
+		b[i] = slugAlphabet[int(b[i])%len(slugAlphabet)]
+	}
+	return string(b)
+}
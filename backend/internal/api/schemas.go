@@ -0,0 +1,162 @@
+package api
+
+import (
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"apple-price/internal/model"
+
+	"github.com/gin-gonic/gin"
+)
+
+// jsonSchemaDialect is the JSON Schema draft the generated schemas declare
+const jsonSchemaDialect = "https://json-schema.org/draft/2020-12/schema"
+
+// schemaTypes are the model structs published at /api/schemas/:name
+var schemaTypes = map[string]reflect.Type{
+	"product":                  reflect.TypeOf(model.Product{}),
+	"price-history":            reflect.TypeOf(model.PriceHistory{}),
+	"price-annotation":         reflect.TypeOf(model.PriceAnnotation{}),
+	"product-event":            reflect.TypeOf(model.ProductEvent{}),
+	"subscription":             reflect.TypeOf(model.Subscription{}),
+	"new-arrival-subscription": reflect.TypeOf(model.NewArrivalSubscription{}),
+	"notification-history":     reflect.TypeOf(model.NotificationHistory{}),
+	"pending-notification":     reflect.TypeOf(model.PendingNotification{}),
+	"region":                   reflect.TypeOf(model.Region{}),
+	"subscription-preset":      reflect.TypeOf(model.SubscriptionPreset{}),
+	"stats":                    reflect.TypeOf(model.Stats{}),
+	"cross-region-comparison":  reflect.TypeOf(model.CrossRegionComparison{}),
+	"product-equivalents":      reflect.TypeOf(model.ProductEquivalents{}),
+	"score-breakdown":          reflect.TypeOf(model.ScoreBreakdown{}),
+	"watchlist":                reflect.TypeOf(model.Watchlist{}),
+}
+
+var (
+	schemasOnce sync.Once
+	schemas     map[string]map[string]any
+)
+
+// GetSchemas lists the published JSON Schemas
+// GET /api/schemas
+func (h *Handlers) GetSchemas(c *gin.Context) {
+	names := make([]string, 0, len(schemaTypes))
+	for name := range schemaTypes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	items := make([]gin.H, 0, len(names))
+	for _, name := range names {
+		items = append(items, gin.H{
+			"name":  name,
+			"title": schemaTypes[name].Name(),
+			"url":   "/api/schemas/" + name,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"count":   len(items),
+		"schemas": items,
+	})
+}
+
+// GetSchema returns the JSON Schema of one model, generated from its Go struct
+// GET /api/schemas/:name
+func (h *Handlers) GetSchema(c *gin.Context) {
+	schemasOnce.Do(func() {
+		schemas = make(map[string]map[string]any, len(schemaTypes))
+		for name, t := range schemaTypes {
+			schema := jsonSchema(t)
+			schema["$schema"] = jsonSchemaDialect
+			schema["$id"] = "/api/schemas/" + name
+			schema["title"] = t.Name()
+			schemas[name] = schema
+		}
+	})
+
+	schema, ok := schemas[strings.TrimSuffix(c.Param("name"), ".json")]
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "schema not found"})
+		return
+	}
+
+	c.Header("Content-Type", "application/schema+json")
+	c.JSON(http.StatusOK, schema)
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// jsonSchema describes how encoding/json serializes values of type t
+func jsonSchema(t reflect.Type) map[string]any {
+	if t.Kind() == reflect.Pointer {
+		return jsonSchema(t.Elem())
+	}
+	if t == timeType {
+		return map[string]any{"type": "string", "format": "date-time"}
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return map[string]any{"type": "array", "items": jsonSchema(t.Elem())}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": jsonSchema(t.Elem())}
+	case reflect.Struct:
+		return structSchema(t)
+	}
+	return map[string]any{}
+}
+
+// structSchema maps exported fields to properties using their json tags. Fields
+// without omitempty are always present in responses, so they are listed as required.
+func structSchema(t reflect.Type) map[string]any {
+	properties := make(map[string]any)
+	required := []string{}
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		if name == "" {
+			name = field.Name
+		}
+
+		// Nil pointers, slices and maps encode as null
+		property := jsonSchema(field.Type)
+		switch field.Type.Kind() {
+		case reflect.Pointer, reflect.Slice, reflect.Map:
+			property = map[string]any{"anyOf": []any{property, map[string]any{"type": "null"}}}
+		}
+		properties[name] = property
+
+		if !strings.Contains(opts, "omitempty") {
+			required = append(required, name)
+		}
+	}
+
+	return map[string]any{
+		"type":                 "object",
+		"properties":           properties,
+		"required":             required,
+		"additionalProperties": false,
+	}
+}
@@ -0,0 +1,50 @@
+package api
+
+import (
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// scrapeStreamHeartbeat is how often an idle stream sends a ping, so proxies
+// don't close it
+const scrapeStreamHeartbeat = 15 * time.Second
+
+// ScrapeStream streams the progress of scrape cycles as server-sent events: the
+// current scheduler status first, then one event per category page, region,
+// upsert batch, finished cycle and detail queue update, named after its type.
+// GET /api/admin/scrape/stream
+func (h *Handlers) ScrapeStream(c *gin.Context) {
+	if h.scheduler == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "scheduler not available"})
+		return
+	}
+
+	events, unsubscribe := h.scheduler.SubscribeProgress()
+	defer unsubscribe()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no") // nginx would otherwise buffer the stream
+	c.SSEvent("status", h.scheduler.GetScrapeStatus())
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(scrapeStreamHeartbeat)
+	defer heartbeat.Stop()
+
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case event := <-events:
+			c.SSEvent(event.Type, event)
+			return true
+		case <-heartbeat.C:
+			c.SSEvent("ping", time.Now().Unix())
+			return true
+		case <-c.Request.Context().Done():
+			return false
+		}
+	})
+}
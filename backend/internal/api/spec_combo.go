@@ -0,0 +1,42 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+
+	"apple-price/internal/model"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SpecComboPreview shows how a spec combo was understood before subscribing to it
+type SpecComboPreview struct {
+	Spec     string           `json:"spec"`
+	Parsed   model.SpecCombo  `json:"parsed"`
+	Matching []*model.Product `json:"matching"` // Catalog products that already match
+}
+
+// PreviewSpecCombo parses ?spec= and lists the catalog products it matches today.
+// An empty list is fine: the watch fires when a matching product arrives.
+func (h *Handlers) PreviewSpecCombo(c *gin.Context) {
+	spec := strings.TrimSpace(c.Query("spec"))
+	if spec == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "spec is required"})
+		return
+	}
+
+	combo := model.ParseSpecCombo(spec)
+	if combo.IsEmpty() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "spec must name a model, screen size, chip, memory or storage"})
+		return
+	}
+
+	matching := []*model.Product{}
+	for _, p := range h.store.GetAllProducts() {
+		if combo.Matches(p) {
+			matching = append(matching, p)
+		}
+	}
+
+	c.JSON(http.StatusOK, SpecComboPreview{Spec: spec, Parsed: combo, Matching: matching})
+}
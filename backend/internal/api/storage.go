@@ -0,0 +1,58 @@
+package api
+
+import (
+	"net/http"
+
+	"apple-price/internal/model"
+
+	"github.com/gin-gonic/gin"
+)
+
+// StorageChecker reports the health of the data directory
+type StorageChecker interface {
+	IsReadOnly() bool
+	Status() model.StorageStatus
+}
+
+// readOnlyRoutes are the non-GET routes that never write, so ReadOnlyGuard lets
+// them through. A new POST route that only reads must be added here.
+var readOnlyRoutes = map[string]bool{
+	"/api/graphql":                           true, // queries only
+	"/api/recommendations":                   true,
+	"/api/new-arrival-subscriptions/preview": true,
+	"/api/bark/validate":                     true,
+	"/api/admin/scrape/dry-run":              true, // fetches and diffs, never stores
+}
+
+// ReadOnlyGuard rejects write requests while storage is in read-only mode,
+// so the API keeps serving reads instead of accepting changes it can't persist
+func ReadOnlyGuard(storage StorageChecker) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if storage == nil {
+			c.Next()
+			return
+		}
+
+		switch c.Request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			c.Next()
+			return
+		}
+		if readOnlyRoutes[c.FullPath()] {
+			c.Next()
+			return
+		}
+
+		if storage.IsReadOnly() {
+			status := storage.Status()
+			c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{
+				"error":  "service is in read-only mode: " + status.Reason,
+				"code":   "read_only",
+				"reason": status.Reason,
+			})
+			return
+		}
+
+		c.Next()
+	}
+}
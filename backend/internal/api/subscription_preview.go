@@ -0,0 +1,69 @@
+package api
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"apple-price/internal/match"
+	"apple-price/internal/model"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxPreviewProducts caps the products listed by a subscription preview
+const maxPreviewProducts = 200
+
+// SubscriptionPreview lists the catalog products a new arrival subscription's
+// filters match today
+type SubscriptionPreview struct {
+	Count    int              `json:"count"`    // all matching products
+	Matching []*model.Product `json:"matching"` // newest listings first, at most limit
+}
+
+// PreviewNewArrivalSubscription runs unsaved subscription filters against the
+// current catalog with the same matching the dispatcher pushes by, so users can
+// sanity-check filters before saving them. Name and Bark Key aren't needed.
+// POST /api/new-arrival-subscriptions/preview?limit=50
+func (h *Handlers) PreviewNewArrivalSubscription(c *gin.Context) {
+	var req model.NewArrivalSubscription
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	limit := 50
+	if v := c.Query("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be a positive integer"})
+			return
+		}
+		limit = min(n, maxPreviewProducts)
+	}
+
+	if !model.ValidStability(req.Stability) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "stability must be stable or volatile"})
+		return
+	}
+	req.Spec = strings.TrimSpace(req.Spec)
+	if req.Spec != "" && model.ParseSpecCombo(req.Spec).IsEmpty() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "spec must name a model, screen size, chip, memory or storage"})
+		return
+	}
+
+	matching := []*model.Product{}
+	for _, p := range h.store.GetAllProducts() {
+		if match.Subscription(&req, p) {
+			matching = append(matching, p)
+		}
+	}
+	sort.Slice(matching, func(i, j int) bool { return matching[i].CreatedAt.After(matching[j].CreatedAt) })
+
+	preview := SubscriptionPreview{Count: len(matching), Matching: matching}
+	if len(preview.Matching) > limit {
+		preview.Matching = preview.Matching[:limit]
+	}
+	c.JSON(http.StatusOK, preview)
+}
@@ -0,0 +1,51 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"apple-price/internal/model"
+
+	"github.com/gin-gonic/gin"
+)
+
+// UsageTracker keeps the operator's anonymous daily usage report
+type UsageTracker interface {
+	RecordRequest(route string, status int)
+	Report(days int) *model.UsageReport
+}
+
+// UsageCounter returns a middleware counting each API request by route template,
+// so IDs and query strings never reach the report
+func UsageCounter(usage UsageTracker) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "(unmatched)"
+		}
+		usage.RecordRequest(c.Request.Method+" "+route, c.Writer.Status())
+	}
+}
+
+// GetUsage returns the daily usage report, newest day first
+// GET /api/admin/usage?days=30
+func (h *Handlers) GetUsage(c *gin.Context) {
+	if h.usage == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "usage statistics are disabled: set USAGE_STATS=true"})
+		return
+	}
+
+	days := 30
+	if v := c.Query("days"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "days must be a positive integer"})
+			return
+		}
+		days = min(n, model.UsageRetentionDays)
+	}
+
+	c.JSON(http.StatusOK, h.usage.Report(days))
+}
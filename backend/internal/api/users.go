@@ -0,0 +1,283 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"apple-price/internal/model"
+
+	"github.com/gin-gonic/gin"
+)
+
+// userResponse is a user as returned by the API, with the Bark Key masked
+func userResponse(u *model.User) model.User {
+	response := *u
+	response.BarkKey = maskBarkKey(response.BarkKey)
+	return response
+}
+
+// extractAPIKey reads a user API key from X-API-Key or "Authorization: Bearer ak_..."
+func extractAPIKey(c *gin.Context) string {
+	if key := strings.TrimSpace(c.GetHeader("X-API-Key")); key != "" {
+		return key
+	}
+	if token := extractToken(c); strings.HasPrefix(token, model.APIKeyPrefix) {
+		return token
+	}
+	return ""
+}
+
+// currentUser returns the user authenticated by the request's API key, or nil
+// when none was sent. An unknown key answers 401 and returns false.
+func (h *Handlers) currentUser(c *gin.Context) (*model.User, bool) {
+	key := extractAPIKey(c)
+	if key == "" {
+		return nil, true
+	}
+
+	user, ok := h.store.GetUserByAPIKeyHash(model.HashAPIKey(key))
+	if !ok {
+		abortAuth(c, http.StatusUnauthorized, "invalid_api_key", "API key is invalid or has been rotated")
+		return nil, false
+	}
+	return user, true
+}
+
+// ownBarkKey checks that the request may act on data addressed to barkKey: keys of
+// registered users need that user's API key, other keys work on their own as they
+// always have. On refusal it answers 401 and returns false.
+func (h *Handlers) ownBarkKey(c *gin.Context, barkKey string) bool {
+	owner, ok := h.store.GetUserByBarkKey(barkKey)
+	if !ok || !owner.Registered() {
+		return true
+	}
+
+	user, ok := h.currentUser(c)
+	if !ok {
+		return false
+	}
+	if user == nil || user.ID != owner.ID {
+		abortAuth(c, http.StatusUnauthorized, "api_key_required", "this Bark Key belongs to a registered user: send their API key")
+		return false
+	}
+	return true
+}
+
+// requestBarkKey returns the Bark Key a request addresses: the authenticated user's,
+// or the bark_key query parameter if it may be used without an API key. An empty
+// key with ok=true means none was given.
+func (h *Handlers) requestBarkKey(c *gin.Context) (string, bool) {
+	user, ok := h.currentUser(c)
+	if !ok {
+		return "", false
+	}
+	if user != nil {
+		return user.BarkKey, true
+	}
+
+	barkKey := c.Query("bark_key")
+	if barkKey == "" {
+		return "", true
+	}
+	return barkKey, h.ownBarkKey(c, barkKey)
+}
+
+// RegisterUser creates an account for a Bark Key and returns its API key, which
+// is shown only here. Existing subscriptions, history and preferences of the key
+// become the user's. A key already registered answers 409.
+// POST /api/users
+func (h *Handlers) RegisterUser(c *gin.Context) {
+	var req struct {
+		Name       string `json:"name"`
+		BarkKey    string `json:"bark_key" binding:"required"`
+		BarkServer string `json:"bark_server"` // Self-hosted Bark server used for the key check
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	barkKey := strings.TrimSpace(req.BarkKey)
+
+	barkServer, err := model.NormalizeBarkServer(req.BarkServer)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	existing, found := h.store.GetUserByBarkKey(barkKey)
+	if found && existing.Registered() {
+		c.JSON(http.StatusConflict, gin.H{"error": "this Bark Key is already registered"})
+		return
+	}
+
+	// Only live keys can be registered, so typos don't lock up someone else's key
+	if h.dispatcher != nil {
+		result, err := h.dispatcher.ValidateBarkKey(barkKey, barkServer)
+		if err != nil {
+			c.JSON(http.StatusBadGateway, gin.H{"error": localize(c, "bark_unreachable"), "detail": err.Error()})
+			return
+		}
+		if !result.Valid {
+			c.JSON(http.StatusBadRequest, gin.H{"error": localize(c, "bark_key_invalid", result.Message)})
+			return
+		}
+	}
+
+	key, hash, err := model.NewAPIKey()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	now := time.Now()
+	var user *model.User
+	if found {
+		// Claim the account migrated from the key's existing data
+		user = existing
+		user.Name = strings.TrimSpace(req.Name)
+		user.APIKeyHash = hash
+		user.UpdatedAt = now
+		err = h.store.UpdateUser(user)
+	} else {
+		user = &model.User{
+			ID:         model.NewUserID(),
+			Name:       strings.TrimSpace(req.Name),
+			BarkKey:    barkKey,
+			APIKeyHash: hash,
+			CreatedAt:  now,
+		}
+		err = h.store.AddUser(user)
+	}
+	if err != nil {
+		requestLogger(c).Error("Failed to register user", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to register user"})
+		return
+	}
+
+	if err := h.store.Save(); err != nil {
+		requestLogger(c).Error("Failed to save data", "error", err)
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"user":    userResponse(user),
+		"api_key": key,
+	})
+}
+
+// GetCurrentUser returns the user authenticated by the API key
+// GET /api/users/me
+func (h *Handlers) GetCurrentUser(c *gin.Context) {
+	user, ok := h.requireUser(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, userResponse(user))
+}
+
+// RotateAPIKey replaces the user's API key; the old one stops working at once
+// POST /api/users/me/rotate-key
+func (h *Handlers) RotateAPIKey(c *gin.Context) {
+	user, ok := h.requireUser(c)
+	if !ok {
+		return
+	}
+
+	key, hash, err := model.NewAPIKey()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	user.APIKeyHash = hash
+	user.UpdatedAt = time.Now()
+	if err := h.store.UpdateUser(user); err != nil {
+		requestLogger(c).Error("Failed to rotate API key", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to rotate API key"})
+		return
+	}
+
+	if err := h.store.Save(); err != nil {
+		requestLogger(c).Error("Failed to save data", "error", err)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"user":    userResponse(user),
+		"api_key": key,
+	})
+}
+
+// requireUser is currentUser for endpoints that make no sense without an account
+func (h *Handlers) requireUser(c *gin.Context) (*model.User, bool) {
+	user, ok := h.currentUser(c)
+	if !ok {
+		return nil, false
+	}
+	if user == nil {
+		c.Header("WWW-Authenticate", `Bearer realm="user"`)
+		abortAuth(c, http.StatusUnauthorized, "missing_api_key", "API key is required")
+		return nil, false
+	}
+	return user, true
+}
+
+// userFromPath returns the authenticated user when they are the :id in the path.
+// Users can only see and change their own preferences.
+func (h *Handlers) userFromPath(c *gin.Context) (*model.User, bool) {
+	user, ok := h.requireUser(c)
+	if !ok {
+		return nil, false
+	}
+	if c.Param("id") != user.ID {
+		abortAuth(c, http.StatusForbidden, "forbidden", "API key belongs to another user")
+		return nil, false
+	}
+	return user, true
+}
+
+// GetUserPreferences returns a user's notification preferences
+// GET /api/users/:id/preferences
+func (h *Handlers) GetUserPreferences(c *gin.Context) {
+	user, ok := h.userFromPath(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, h.store.GetPreferences(user.BarkKey))
+}
+
+// UpdateUserPreferences replaces a user's notification preferences: default
+// channel, default quiet hours, message language and currency style, and the
+// digest frequency newly created new arrival subscriptions start with. Omitted fields
+// go back to their defaults; the pause-all switch is left as it is.
+// PUT /api/users/:id/preferences
+func (h *Handlers) UpdateUserPreferences(c *gin.Context) {
+	user, ok := h.userFromPath(c)
+	if !ok {
+		return
+	}
+
+	var req model.UserPreferences
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := req.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	req.BarkKey = user.BarkKey
+	req.Language = model.NormalizeLanguage(req.Language)
+	req.PausedAll = h.store.GetPreferences(user.BarkKey).PausedAll
+	req.UpdatedAt = time.Now()
+	if err := h.store.SetPreferences(&req); err != nil {
+		requestLogger(c).Error("Failed to save preferences", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save preferences"})
+		return
+	}
+
+	if err := h.store.Save(); err != nil {
+		requestLogger(c).Error("Failed to save data", "error", err)
+	}
+
+	c.JSON(http.StatusOK, req)
+}
@@ -0,0 +1,232 @@
+package api
+
+import (
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"apple-price/internal/model"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Watchlist limits per client token
+const (
+	maxWatchlistsPerClient = 20
+	maxWatchlistProducts   = 100
+	maxWatchlistNameLength = 50
+)
+
+// clientTokenHeader carries the client-generated token that owns watchlists
+const clientTokenHeader = "X-Client-Token"
+
+// clientTokenPattern accepts UUIDs and similar random IDs, long enough not to be guessed
+var clientTokenPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{16,128}$`)
+
+// WatchlistItem is one tracked product with its current price situation. Available
+// is false once the product sold out; products that left the catalog stay on the
+// list without Product and price data.
+type WatchlistItem struct {
+	ProductID      string         `json:"product_id"`
+	Available      bool           `json:"available"`
+	Product        *model.Product `json:"product,omitempty"`
+	Price          float64        `json:"price"`
+	PriceTrend     string         `json:"price_trend,omitempty"`
+	LowestPrice    float64        `json:"lowest_price,omitempty"`
+	AtLowestPrice  bool           `json:"at_lowest_price"`
+	Savings        float64        `json:"savings"`         // original_price - price
+	SavingsPercent float64        `json:"savings_percent"` // savings as % of original price
+}
+
+// WatchlistResponse is a watchlist with its products resolved
+type WatchlistResponse struct {
+	*model.Watchlist
+	Items        []WatchlistItem `json:"items"`
+	TotalPrice   float64         `json:"total_price"`   // sum over products still listed
+	TotalSavings float64         `json:"total_savings"` // sum over products still listed
+}
+
+// clientToken reads and validates the X-Client-Token header, answering 400 when it is unusable
+func clientToken(c *gin.Context) (string, bool) {
+	token := strings.TrimSpace(c.GetHeader(clientTokenHeader))
+	if !clientTokenPattern.MatchString(token) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": clientTokenHeader + " header must be 16-128 letters, digits, '-' or '_'"})
+		return "", false
+	}
+	return token, true
+}
+
+// CreateWatchlist creates a named list of products to track without Bark
+// POST /api/watchlists
+func (h *Handlers) CreateWatchlist(c *gin.Context) {
+	token, ok := clientToken(c)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Name       string   `json:"name" binding:"required"`
+		ProductIDs []string `json:"product_ids"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "name is required"})
+		return
+	}
+	if len([]rune(name)) > maxWatchlistNameLength {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "name is too long", "max_length": maxWatchlistNameLength})
+		return
+	}
+
+	productIDs := []string{}
+	seen := make(map[string]bool)
+	var missing []string
+	for _, id := range req.ProductIDs {
+		id = strings.TrimSpace(id)
+		if id == "" || seen[id] {
+			continue
+		}
+		seen[id] = true
+		if _, exists := h.store.GetProduct(id); !exists {
+			missing = append(missing, id)
+			continue
+		}
+		productIDs = append(productIDs, id)
+	}
+	if len(missing) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "product not found", "missing": missing})
+		return
+	}
+	if len(productIDs) > maxWatchlistProducts {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "too many products", "max": maxWatchlistProducts})
+		return
+	}
+
+	if len(h.store.GetWatchlists(token)) >= maxWatchlistsPerClient {
+		c.JSON(http.StatusConflict, gin.H{"error": "too many watchlists", "max": maxWatchlistsPerClient})
+		return
+	}
+
+	now := time.Now()
+	watchlist := &model.Watchlist{
+		ID:          generateID(),
+		Name:        name,
+		ClientToken: token,
+		ProductIDs:  productIDs,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+
+	if err := h.store.AddWatchlist(watchlist); err != nil {
+		requestLogger(c).Error("Failed to create watchlist", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create watchlist"})
+		return
+	}
+
+	if err := h.store.Save(); err != nil {
+		requestLogger(c).Error("Failed to save data", "error", err)
+	}
+
+	c.JSON(http.StatusCreated, h.watchlistResponse(watchlist))
+}
+
+// GetWatchlists lists the watchlists of the client token with current prices
+// GET /api/watchlists
+func (h *Handlers) GetWatchlists(c *gin.Context) {
+	token, ok := clientToken(c)
+	if !ok {
+		return
+	}
+
+	watchlists := h.store.GetWatchlists(token)
+	responses := make([]WatchlistResponse, 0, len(watchlists))
+	for _, w := range watchlists {
+		responses = append(responses, h.watchlistResponse(w))
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"count":      len(responses),
+		"watchlists": responses,
+	})
+}
+
+// GetWatchlist returns one watchlist of the client token with current prices
+// GET /api/watchlists/:id
+func (h *Handlers) GetWatchlist(c *gin.Context) {
+	watchlist, ok := h.ownedWatchlist(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, h.watchlistResponse(watchlist))
+}
+
+// DeleteWatchlist removes a watchlist of the client token
+// DELETE /api/watchlists/:id
+func (h *Handlers) DeleteWatchlist(c *gin.Context) {
+	watchlist, ok := h.ownedWatchlist(c)
+	if !ok {
+		return
+	}
+
+	if err := h.store.DeleteWatchlist(watchlist.ID); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "watchlist not found"})
+		return
+	}
+
+	if err := h.store.Save(); err != nil {
+		requestLogger(c).Error("Failed to save data", "error", err)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "watchlist deleted"})
+}
+
+// ownedWatchlist loads the :id watchlist if it belongs to the request's client token.
+// Other tokens' watchlists answer 404 so their IDs can't be probed.
+func (h *Handlers) ownedWatchlist(c *gin.Context) (*model.Watchlist, bool) {
+	token, ok := clientToken(c)
+	if !ok {
+		return nil, false
+	}
+
+	watchlist, exists := h.store.GetWatchlist(c.Param("id"))
+	if !exists || watchlist.ClientToken != token {
+		c.JSON(http.StatusNotFound, gin.H{"error": "watchlist not found"})
+		return nil, false
+	}
+	return watchlist, true
+}
+
+// watchlistResponse resolves the products of a watchlist to their current prices
+func (h *Handlers) watchlistResponse(w *model.Watchlist) WatchlistResponse {
+	resp := WatchlistResponse{Watchlist: w, Items: make([]WatchlistItem, 0, len(w.ProductIDs))}
+
+	velocity := h.store.GetInventoryVelocity()
+	for _, id := range w.ProductIDs {
+		item := WatchlistItem{ProductID: id}
+		if p, ok := h.store.GetProduct(id); ok {
+			p = withSellOutHours(p, velocity)
+			item.Available = p.StockStatus != "sold_out"
+			item.Product = p
+			item.Price = p.Price
+			item.PriceTrend = p.PriceTrend
+			item.LowestPrice = p.LowestPrice
+			item.AtLowestPrice = p.LowestPrice > 0 && p.Price <= p.LowestPrice
+			if p.OriginalPrice > p.Price {
+				item.Savings = p.OriginalPrice - p.Price
+				item.SavingsPercent = item.Savings / p.OriginalPrice * 100
+			}
+			resp.TotalPrice += item.Price
+			resp.TotalSavings += item.Savings
+		}
+		resp.Items = append(resp.Items, item)
+	}
+
+	return resp
+}
@@ -2,10 +2,15 @@ package config
 
 import (
 	"fmt"
+	"net/url"
 	"os"
+	"path/filepath"
 	"strconv"
+	"strings"
 	"time"
 
+	"apple-price/internal/model"
+
 	"github.com/joho/godotenv"
 )
 
@@ -21,9 +26,43 @@ type Config struct {
 	SMTPFrom     string
 
 	ScraperInterval    time.Duration
+	ScraperMinInterval time.Duration // shortest interval in hours new arrivals usually appear (defaults to half ScraperInterval)
+	ScraperMaxInterval time.Duration // longest interval in quiet hours such as overnight (defaults to 6x ScraperInterval)
+	ScraperCron        string        // cron expression scheduled scrapes start at instead of the interval (empty = interval)
+	ScraperStartJitter time.Duration // random delay of up to this before each scheduled scrape
 	ScraperUserAgent   string
+	ScraperUserAgents  []string      // user agents picked at random per request instead of ScraperUserAgent
+	ScraperProxies     []string      // http(s)/socks5 proxies the scraper rotates through
+	ScraperHostInterval time.Duration // minimum time between two scraper requests to one host (0 = no limit)
+	ScraperJitter      time.Duration // random delay of up to this before each scraper request
+	ScraperMode        string        // live, record (save responses to ScraperFixturesDir) or replay (serve them offline)
+	ScraperFixturesDir string
+	RetailerCatalog    string        // JSON file mapping spec combos to JD/Amazon items (empty = no retailer prices)
+	RetailerInterval   time.Duration // how often retailer prices are refreshed
+	AmazonHost         string        // Amazon storefront compared, e.g. www.amazon.cn
+	CurrencyProviderURL string        // exchange rate API, {base} is replaced by CNY
+	CurrencyRates      string        // fixed rates "USD=7.1,HKD=0.91" used instead of the provider
+	CurrencyRateTTL    time.Duration // how long fetched exchange rates are cached
 	DataDir            string
+	ImageCacheDir      string        // where product images are cached (defaults to DATA_DIR/images)
+	ImageCacheMaxMB    int           // size limit of the image cache (0 = redirect to Apple's images)
+	ImageCacheTTL      time.Duration // how long a cached image is served before it is downloaded again
 	CORSOrigins        string
+	AdminToken         string
+	OperatorBarkKey    string
+	BarkServer         string // Bark server for subscriptions without their own (self-hosted bark-server)
+	FrontendURL        string // web frontend that notifications link to (empty = link to Apple directly)
+	CategoryAlertThreshold int // alert the operator when a category's available count drops below this (0 = only at zero)
+	MinFreeDiskMB      int
+	MaxHistoryPerProduct   int
+	MaxNotificationsPerKey int
+	HistoryRawDays         int           // keep raw price history this many days, then downsample to daily rows (0 = never)
+	HistoryCompactionInterval time.Duration // how often price history is downsampled
+	DeliveryFailureLimit   int           // pause a Bark Key's pushes after this many failures in a row (0 = never)
+	DetailMaxAttempts      int           // give up a product's detail page after this many failed fetches (0 = never)
+	LogLevel           string
+	LogFormat          string
+	UsageStats         bool // keep a daily anonymous usage report at /api/admin/usage (opt-in)
 }
 
 func Load() (*Config, error) {
@@ -40,7 +79,19 @@ func Load() (*Config, error) {
 		SMTPFrom:          getEnv("SMTP_FROM", "ApplePrice <noreply@example.com>"),
 		ScraperUserAgent:  getEnv("SCRAPER_USER_AGENT", "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36"),
 		DataDir:           getEnv("DATA_DIR", "./data"),
+		ScraperMode:       strings.ToLower(getEnv("SCRAPER_MODE", "live")),
+		RetailerCatalog:   getEnv("RETAILER_CATALOG", ""),
+		AmazonHost:        getEnv("AMAZON_HOST", "www.amazon.cn"),
+		CurrencyProviderURL: getEnv("CURRENCY_PROVIDER_URL", "https://open.er-api.com/v6/latest/{base}"),
+		CurrencyRates:     getEnv("CURRENCY_RATES", ""),
 		CORSOrigins:       getEnv("CORS_ORIGINS", "http://localhost:5173,http://localhost:3000"),
+		AdminToken:        getEnv("ADMIN_TOKEN", ""),
+		OperatorBarkKey:   getEnv("OPERATOR_BARK_KEY", ""),
+		BarkServer:        getEnv("BARK_SERVER", "https://api.day.app"),
+		FrontendURL:       getEnv("FRONTEND_URL", ""),
+		LogLevel:          getEnv("LOG_LEVEL", "info"),
+		LogFormat:         getEnv("LOG_FORMAT", "text"),
+		UsageStats:        getEnv("USAGE_STATS", "false") == "true",
 	}
 
 	// Parse integer values
@@ -52,6 +103,90 @@ func Load() (*Config, error) {
 		cfg.SMTPPort = p
 	}
 
+	if minFree := getEnv("MIN_FREE_DISK_MB", "100"); minFree != "" {
+		m, err := strconv.Atoi(minFree)
+		if err != nil {
+			return nil, fmt.Errorf("invalid MIN_FREE_DISK_MB: %w", err)
+		}
+		cfg.MinFreeDiskMB = m
+	}
+
+	if maxHistory := getEnv("MAX_HISTORY_PER_PRODUCT", "0"); maxHistory != "" {
+		m, err := strconv.Atoi(maxHistory)
+		if err != nil || m < 0 {
+			return nil, fmt.Errorf("invalid MAX_HISTORY_PER_PRODUCT: %q", maxHistory)
+		}
+		cfg.MaxHistoryPerProduct = m
+	}
+
+	if maxNotifications := getEnv("MAX_NOTIFICATIONS_PER_KEY", "0"); maxNotifications != "" {
+		m, err := strconv.Atoi(maxNotifications)
+		if err != nil || m < 0 {
+			return nil, fmt.Errorf("invalid MAX_NOTIFICATIONS_PER_KEY: %q", maxNotifications)
+		}
+		cfg.MaxNotificationsPerKey = m
+	}
+
+	if rawDays := getEnv("HISTORY_RAW_DAYS", "0"); rawDays != "" {
+		d, err := strconv.Atoi(rawDays)
+		if err != nil || d < 0 {
+			return nil, fmt.Errorf("invalid HISTORY_RAW_DAYS: %q", rawDays)
+		}
+		cfg.HistoryRawDays = d
+	}
+
+	if limit := getEnv("DELIVERY_FAILURE_LIMIT", strconv.Itoa(model.DefaultDeliveryFailureLimit)); limit != "" {
+		l, err := strconv.Atoi(limit)
+		if err != nil || l < 0 {
+			return nil, fmt.Errorf("invalid DELIVERY_FAILURE_LIMIT: %q", limit)
+		}
+		cfg.DeliveryFailureLimit = l
+	}
+
+	if attempts := getEnv("DETAIL_MAX_ATTEMPTS", strconv.Itoa(model.DefaultDetailMaxAttempts)); attempts != "" {
+		n, err := strconv.Atoi(attempts)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("invalid DETAIL_MAX_ATTEMPTS: %q", attempts)
+		}
+		cfg.DetailMaxAttempts = n
+	}
+
+	cfg.ImageCacheDir = getEnv("IMAGE_CACHE_DIR", filepath.Join(cfg.DataDir, "images"))
+	if size := getEnv("IMAGE_CACHE_MAX_MB", "500"); size != "" {
+		n, err := strconv.Atoi(size)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("invalid IMAGE_CACHE_MAX_MB: %q", size)
+		}
+		cfg.ImageCacheMaxMB = n
+	}
+	if ttl := getEnv("IMAGE_CACHE_TTL", "168h"); ttl != "" {
+		d, err := time.ParseDuration(ttl)
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("invalid IMAGE_CACHE_TTL: %q", ttl)
+		}
+		cfg.ImageCacheTTL = d
+	}
+
+	if threshold := getEnv("CATEGORY_ALERT_THRESHOLD", "0"); threshold != "" {
+		t, err := strconv.Atoi(threshold)
+		if err != nil || t < 0 {
+			return nil, fmt.Errorf("invalid CATEGORY_ALERT_THRESHOLD: %q", threshold)
+		}
+		cfg.CategoryAlertThreshold = t
+	}
+
+	barkServer, err := model.NormalizeBarkServer(cfg.BarkServer)
+	if err != nil {
+		return nil, fmt.Errorf("invalid BARK_SERVER: %w", err)
+	}
+	cfg.BarkServer = barkServer
+
+	frontendURL, err := normalizeFrontendURL(cfg.FrontendURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid FRONTEND_URL: %w", err)
+	}
+	cfg.FrontendURL = frontendURL
+
 	// Parse duration
 	if interval := getEnv("SCRAPER_INTERVAL", "5m"); interval != "" {
 		d, err := time.ParseDuration(interval)
@@ -61,9 +196,150 @@ func Load() (*Config, error) {
 		cfg.ScraperInterval = d
 	}
 
+	// The interval adapts to the hours new arrivals appear at within these bounds
+	cfg.ScraperMinInterval = cfg.ScraperInterval / 2
+	if interval := getEnv("SCRAPER_MIN_INTERVAL", ""); interval != "" {
+		d, err := time.ParseDuration(interval)
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("invalid SCRAPER_MIN_INTERVAL: %q", interval)
+		}
+		cfg.ScraperMinInterval = d
+	}
+	cfg.ScraperMaxInterval = cfg.ScraperInterval * 6
+	if interval := getEnv("SCRAPER_MAX_INTERVAL", ""); interval != "" {
+		d, err := time.ParseDuration(interval)
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("invalid SCRAPER_MAX_INTERVAL: %q", interval)
+		}
+		cfg.ScraperMaxInterval = d
+	}
+	if cfg.ScraperMinInterval > cfg.ScraperInterval || cfg.ScraperMaxInterval < cfg.ScraperInterval {
+		return nil, fmt.Errorf("SCRAPER_MIN_INTERVAL (%s) and SCRAPER_MAX_INTERVAL (%s) must bound SCRAPER_INTERVAL (%s)",
+			cfg.ScraperMinInterval, cfg.ScraperMaxInterval, cfg.ScraperInterval)
+	}
+
+	cfg.ScraperCron = strings.TrimSpace(getEnv("SCRAPER_CRON", ""))
+	if cfg.ScraperCron != "" {
+		schedule, err := model.ParseCron(cfg.ScraperCron)
+		if err != nil {
+			return nil, fmt.Errorf("invalid SCRAPER_CRON: %w", err)
+		}
+		if schedule.Next(time.Now()).IsZero() {
+			return nil, fmt.Errorf("invalid SCRAPER_CRON: %q never fires", cfg.ScraperCron)
+		}
+	}
+
+	if jitter := getEnv("SCRAPER_START_JITTER", "0s"); jitter != "" {
+		d, err := time.ParseDuration(jitter)
+		if err != nil || d < 0 {
+			return nil, fmt.Errorf("invalid SCRAPER_START_JITTER: %q", jitter)
+		}
+		cfg.ScraperStartJitter = d
+	}
+
+	if agents := getEnv("SCRAPER_USER_AGENTS", ""); agents != "" {
+		cfg.ScraperUserAgents = splitList(agents, "|")
+	}
+
+	if proxies := getEnv("SCRAPER_PROXIES", ""); proxies != "" {
+		cfg.ScraperProxies = splitList(proxies, ",")
+		for _, proxy := range cfg.ScraperProxies {
+			if err := checkProxyURL(proxy); err != nil {
+				return nil, fmt.Errorf("invalid SCRAPER_PROXIES: %w", err)
+			}
+		}
+	}
+
+	if interval := getEnv("SCRAPER_HOST_INTERVAL", "0s"); interval != "" {
+		d, err := time.ParseDuration(interval)
+		if err != nil || d < 0 {
+			return nil, fmt.Errorf("invalid SCRAPER_HOST_INTERVAL: %q", interval)
+		}
+		cfg.ScraperHostInterval = d
+	}
+
+	if jitter := getEnv("SCRAPER_JITTER", "0s"); jitter != "" {
+		d, err := time.ParseDuration(jitter)
+		if err != nil || d < 0 {
+			return nil, fmt.Errorf("invalid SCRAPER_JITTER: %q", jitter)
+		}
+		cfg.ScraperJitter = d
+	}
+
+	switch cfg.ScraperMode {
+	case "live", "record", "replay":
+	default:
+		return nil, fmt.Errorf("invalid SCRAPER_MODE: %q (expected live, record or replay)", cfg.ScraperMode)
+	}
+	cfg.ScraperFixturesDir = getEnv("SCRAPER_FIXTURES_DIR", filepath.Join(cfg.DataDir, "fixtures"))
+
+	if interval := getEnv("RETAILER_INTERVAL", "6h"); interval != "" {
+		d, err := time.ParseDuration(interval)
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("invalid RETAILER_INTERVAL: %q", interval)
+		}
+		cfg.RetailerInterval = d
+	}
+
+	if ttl := getEnv("CURRENCY_RATE_TTL", "12h"); ttl != "" {
+		d, err := time.ParseDuration(ttl)
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("invalid CURRENCY_RATE_TTL: %q", ttl)
+		}
+		cfg.CurrencyRateTTL = d
+	}
+
+	if interval := getEnv("HISTORY_COMPACTION_INTERVAL", "24h"); interval != "" {
+		d, err := time.ParseDuration(interval)
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("invalid HISTORY_COMPACTION_INTERVAL: %q", interval)
+		}
+		cfg.HistoryCompactionInterval = d
+	}
+
 	return cfg, nil
 }
 
+// normalizeFrontendURL checks the web frontend base URL and strips trailing slashes
+func normalizeFrontendURL(raw string) (string, error) {
+	raw = strings.TrimRight(strings.TrimSpace(raw), "/")
+	if raw == "" {
+		return "", nil
+	}
+
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "", fmt.Errorf("expected http(s)://host[:port][/path], got %q", raw)
+	}
+	if u.RawQuery != "" || u.Fragment != "" {
+		return "", fmt.Errorf("%q must not contain a query or fragment", raw)
+	}
+	return raw, nil
+}
+
+// checkProxyURL checks a scraper proxy URL: http, https or socks5 with a host
+func checkProxyURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return fmt.Errorf("expected scheme://[user:pass@]host:port, got %q", raw)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" && u.Scheme != "socks5" {
+		return fmt.Errorf("unsupported proxy scheme %q (expected http, https or socks5)", u.Scheme)
+	}
+	return nil
+}
+
+// splitList splits a separated list, dropping blank entries
+func splitList(raw, sep string) []string {
+	var items []string
+	for _, item := range strings.Split(raw, sep) {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
 func getEnv(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
 		return value
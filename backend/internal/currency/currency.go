@@ -0,0 +1,149 @@
+// Package currency converts prices between the currencies of the storefronts
+// with exchange rates from a configurable provider, cached between fetches
+package currency
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"apple-price/internal/model"
+)
+
+// Base is the currency rates are kept against
+const Base = "CNY"
+
+// DefaultProviderURL is a free, keyless rates API; {base} is replaced by the base currency
+const DefaultProviderURL = "https://open.er-api.com/v6/latest/{base}"
+
+// Provider fetches how many units of each currency one unit of base buys
+type Provider interface {
+	Rates(ctx context.Context, base string) (map[string]float64, error)
+}
+
+// HTTPProvider reads rates from a JSON API answering {"rates": {"USD": 0.14, ...}},
+// such as open.er-api.com or exchangerate.host
+type HTTPProvider struct {
+	url    string
+	client *http.Client
+}
+
+// NewHTTPProvider creates a provider for url, in which {base} is replaced by the
+// base currency
+func NewHTTPProvider(url string) *HTTPProvider {
+	return &HTTPProvider{url: url, client: &http.Client{Timeout: 15 * time.Second}}
+}
+
+// Rates fetches the current rates against base
+func (p *HTTPProvider) Rates(ctx context.Context, base string) (map[string]float64, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", strings.ReplaceAll(p.url, "{base}", base), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch exchange rates: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("exchange rate provider returned %d", resp.StatusCode)
+	}
+
+	var body struct {
+		Rates map[string]float64 `json:"rates"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("failed to parse exchange rates: %w", err)
+	}
+	if len(body.Rates) == 0 {
+		return nil, fmt.Errorf("exchange rate provider returned no rates")
+	}
+	return body.Rates, nil
+}
+
+// FixedProvider serves rates set by the operator instead of fetching them
+type FixedProvider map[string]float64
+
+// ParseFixedRates parses "USD=7.1,HKD=0.91": what one unit of each currency is
+// worth in Base
+func ParseFixedRates(raw string) (FixedProvider, error) {
+	rates := make(FixedProvider)
+	for _, pair := range strings.Split(raw, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		currency, value, ok := strings.Cut(pair, "=")
+		worth, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if !ok || err != nil || worth <= 0 {
+			return nil, fmt.Errorf("invalid rate %q (expected CUR=value)", pair)
+		}
+		rates[strings.ToUpper(strings.TrimSpace(currency))] = 1 / worth
+	}
+	return rates, nil
+}
+
+// Rates returns the fixed rates; they are always against Base
+func (p FixedProvider) Rates(ctx context.Context, base string) (map[string]float64, error) {
+	if base != Base {
+		return nil, fmt.Errorf("fixed rates are against %s, not %s", Base, base)
+	}
+	return p, nil
+}
+
+// Converter caches the provider's rates for ttl. When a refresh fails it keeps
+// serving the last rates, marked stale.
+type Converter struct {
+	provider Provider
+	ttl      time.Duration
+
+	mu    sync.Mutex
+	rates *model.ExchangeRates
+}
+
+// NewConverter creates a converter refreshing rates from provider every ttl
+func NewConverter(provider Provider, ttl time.Duration) *Converter {
+	return &Converter{provider: provider, ttl: ttl}
+}
+
+// Rates returns the current rates against Base, fetching them when the cache has expired
+func (c *Converter) Rates(ctx context.Context) (*model.ExchangeRates, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.rates != nil && time.Since(c.rates.FetchedAt) < c.ttl {
+		return c.copyLocked(), nil
+	}
+
+	rates, err := c.provider.Rates(ctx, Base)
+	if err != nil {
+		if c.rates == nil {
+			return nil, err
+		}
+		c.rates.Stale = true
+		return c.copyLocked(), nil
+	}
+
+	copied := make(map[string]float64, len(rates))
+	for currency, rate := range rates {
+		copied[currency] = rate
+	}
+	c.rates = &model.ExchangeRates{Base: Base, Rates: copied, FetchedAt: time.Now()}
+	return c.copyLocked(), nil
+}
+
+// copyLocked returns a copy of the cached rates; caller holds c.mu
+func (c *Converter) copyLocked() *model.ExchangeRates {
+	copied := *c.rates
+	copied.Rates = make(map[string]float64, len(c.rates.Rates))
+	for currency, rate := range c.rates.Rates {
+		copied.Rates[currency] = rate
+	}
+	return &copied
+}
@@ -0,0 +1,397 @@
+// Package imagecache downloads product images once and serves them from disk,
+// optionally scaled down to thumbnail widths. Files are evicted least recently
+// used first once the cache outgrows its size limit.
+package imagecache
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	// Decoders for product images
+	_ "image/gif"
+)
+
+const (
+	// DefaultMaxBytes is the default size limit of the cache directory
+	DefaultMaxBytes = 500 << 20
+
+	// DefaultTTL is how long a downloaded image is served before it is fetched
+	// again; a failed refetch keeps serving the old one
+	DefaultTTL = 7 * 24 * time.Hour
+
+	// maxPixels bounds the size of an image scale decodes, so a small file
+	// declaring huge dimensions can't exhaust memory
+	maxPixels = 25_000_000
+
+	// evictTarget is the share of the size limit eviction shrinks the cache to,
+	// so it doesn't run again on the next write
+	evictTarget = 0.9
+)
+
+// Widths are the thumbnail widths an image can be scaled down to
+var Widths = []int{160, 320, 640}
+
+// imageHosts are the hosts images are downloaded from, subdomains included. Image
+// URLs come from scrapes, admin edits and upstream replicas, so the cache must not
+// request whatever address they name.
+var imageHosts = []string{"apple.com", "apple.com.cn", "cdn-apple.com", "mzstatic.com"}
+
+// ErrNoImage is returned when an image was never fetched and can't be now
+var ErrNoImage = errors.New("image not available")
+
+// Fetcher downloads an image, e.g. the scraper's client with its proxies and rate limits
+type Fetcher interface {
+	FetchImage(ctx context.Context, url string) ([]byte, error)
+}
+
+// Image is a cached image ready to serve
+type Image struct {
+	Data        []byte
+	ContentType string
+	FetchedAt   time.Time
+	Stale       bool // the source URL couldn't be refetched, this is the last good copy
+}
+
+// meta is stored next to each downloaded original
+type meta struct {
+	URL         string    `json:"url"`
+	ContentType string    `json:"content_type"`
+	FetchedAt   time.Time `json:"fetched_at"`
+}
+
+// Cache keeps product images on disk under dir
+type Cache struct {
+	dir      string
+	maxBytes int64
+	ttl      time.Duration
+	fetcher  Fetcher
+
+	mu    sync.Mutex
+	size  int64                  // bytes in dir, guarded by mu
+	locks map[string]*sync.Mutex // product key -> lock held while its files change, guarded by mu
+}
+
+// New opens the cache in dir, creating it if needed
+func New(dir string, maxBytes int64, ttl time.Duration, fetcher Fetcher) (*Cache, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create image cache directory: %w", err)
+	}
+	c := &Cache{dir: dir, maxBytes: maxBytes, ttl: ttl, fetcher: fetcher, locks: make(map[string]*sync.Mutex)}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read image cache directory: %w", err)
+	}
+	for _, e := range entries {
+		if info, err := e.Info(); err == nil && !e.IsDir() {
+			c.size += info.Size()
+		}
+	}
+	return c, nil
+}
+
+// ValidWidth reports whether width is 0 (the original) or one of Widths
+func ValidWidth(width int) bool {
+	if width == 0 {
+		return true
+	}
+	for _, w := range Widths {
+		if w == width {
+			return true
+		}
+	}
+	return false
+}
+
+// Get returns a product's image from url, scaled down to width (0 = original).
+// The image is downloaded on first use and again once it is older than the TTL
+// or the product's image URL changed; when that download fails the previous
+// copy is served marked stale.
+func (c *Cache) Get(ctx context.Context, productID, url string, width int) (*Image, error) {
+	key := cacheKey(productID)
+	lock := c.lock(key)
+	lock.Lock()
+	defer lock.Unlock()
+
+	m, data, cached := c.readOriginal(key)
+	stale := false
+	if !cached || m.URL != url || time.Since(m.FetchedAt) > c.ttl {
+		fresh, freshData, err := c.download(ctx, key, url)
+		switch {
+		case err == nil:
+			m, data = fresh, freshData
+		case cached:
+			slog.Warn("Failed to refresh product image, serving cached copy", "product_id", productID, "error", err)
+			stale = true
+		default:
+			return nil, fmt.Errorf("%w: %v", ErrNoImage, err)
+		}
+	}
+
+	img := &Image{Data: data, ContentType: m.ContentType, FetchedAt: m.FetchedAt, Stale: stale}
+	if width == 0 {
+		return img, nil
+	}
+
+	thumbPath := c.path(key, "w"+strconv.Itoa(width))
+	if info, err := os.Stat(thumbPath); err == nil && !info.ModTime().Before(m.FetchedAt) {
+		if thumb, err := os.ReadFile(thumbPath); err == nil {
+			c.touch(thumbPath)
+			img.Data, img.ContentType = thumb, http.DetectContentType(thumb)
+			return img, nil
+		}
+	}
+
+	thumb, contentType, err := scale(data, width)
+	if err != nil {
+		// Formats the standard library can't decode are served as they are
+		slog.Debug("Failed to scale product image, serving original", "product_id", productID, "error", err)
+		return img, nil
+	}
+	c.write(thumbPath, thumb)
+	img.Data, img.ContentType = thumb, contentType
+	return img, nil
+}
+
+// download fetches url and stores it as the product's original
+func (c *Cache) download(ctx context.Context, key, url string) (meta, []byte, error) {
+	if url == "" {
+		return meta{}, nil, errors.New("product has no image")
+	}
+	if !allowedURL(url) {
+		return meta{}, nil, fmt.Errorf("image host not allowed: %s", url)
+	}
+	data, err := c.fetcher.FetchImage(ctx, url)
+	if err != nil {
+		return meta{}, nil, err
+	}
+
+	m := meta{URL: url, ContentType: http.DetectContentType(data), FetchedAt: time.Now()}
+	if !strings.HasPrefix(m.ContentType, "image/") {
+		return meta{}, nil, fmt.Errorf("unexpected content type %s", m.ContentType)
+	}
+	metaData, _ := json.Marshal(m)
+	c.write(c.path(key, "orig"), data)
+	c.write(c.path(key, "json"), metaData)
+	return m, data, nil
+}
+
+// allowedURL reports whether raw is an http(s) URL on one of imageHosts
+func allowedURL(raw string) bool {
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") {
+		return false
+	}
+	host := strings.ToLower(u.Hostname())
+	for _, h := range imageHosts {
+		if host == h || strings.HasSuffix(host, "."+h) {
+			return true
+		}
+	}
+	return false
+}
+
+// readOriginal loads a product's downloaded original and its metadata
+func (c *Cache) readOriginal(key string) (meta, []byte, bool) {
+	var m meta
+	metaData, err := os.ReadFile(c.path(key, "json"))
+	if err != nil || json.Unmarshal(metaData, &m) != nil {
+		return meta{}, nil, false
+	}
+	data, err := os.ReadFile(c.path(key, "orig"))
+	if err != nil {
+		return meta{}, nil, false
+	}
+	c.touch(c.path(key, "json"))
+	c.touch(c.path(key, "orig"))
+	return m, data, true
+}
+
+// write stores a file, evicting old files when the cache grows past its limit.
+// Failures only cost a cache miss and are logged.
+func (c *Cache) write(path string, data []byte) {
+	var previous int64
+	if info, err := os.Stat(path); err == nil {
+		previous = info.Size()
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		slog.Warn("Failed to write image cache file", "path", path, "error", err)
+		return
+	}
+
+	c.mu.Lock()
+	c.size += int64(len(data)) - previous
+	over := c.maxBytes > 0 && c.size > c.maxBytes
+	c.mu.Unlock()
+	if over {
+		c.evict()
+	}
+}
+
+// evict deletes the least recently used files until the cache is below
+// evictTarget of its limit
+func (c *Cache) evict() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entries, err := os.ReadDir(c.dir)
+	if err != nil {
+		return
+	}
+	type file struct {
+		path string
+		size int64
+		used time.Time
+	}
+	var files []file
+	var total int64
+	for _, e := range entries {
+		info, err := e.Info()
+		if err != nil || e.IsDir() {
+			continue
+		}
+		files = append(files, file{path: filepath.Join(c.dir, e.Name()), size: info.Size(), used: info.ModTime()})
+		total += info.Size()
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].used.Before(files[j].used) })
+
+	target := int64(float64(c.maxBytes) * evictTarget)
+	removed := 0
+	for _, f := range files {
+		if total <= target {
+			break
+		}
+		if os.Remove(f.path) == nil {
+			total -= f.size
+			removed++
+		}
+	}
+	c.size = total
+	slog.Info("Evicted images from cache", "files", removed, "bytes", total)
+}
+
+// touch marks a file as used for eviction
+func (c *Cache) touch(path string) {
+	now := time.Now()
+	_ = os.Chtimes(path, now, now)
+}
+
+// lock returns the lock of a product's files
+func (c *Cache) lock(key string) *sync.Mutex {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	l, ok := c.locks[key]
+	if !ok {
+		l = &sync.Mutex{}
+		c.locks[key] = l
+	}
+	return l
+}
+
+// path returns the file of a product's image with the given suffix
+func (c *Cache) path(key, suffix string) string {
+	return filepath.Join(c.dir, key+"."+suffix)
+}
+
+// cacheKey names a product's files; product IDs may contain slashes
+func cacheKey(productID string) string {
+	sum := sha1.Sum([]byte(productID))
+	return hex.EncodeToString(sum[:])
+}
+
+// scale decodes an image and scales it down to width, keeping its aspect ratio.
+// PNGs stay PNG to keep transparency, everything else becomes JPEG. An image no
+// wider than width is returned re-encoded at its own size.
+func scale(data []byte, width int) ([]byte, string, error) {
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to decode image: %w", err)
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 || cfg.Width*cfg.Height > maxPixels {
+		return nil, "", fmt.Errorf("image dimensions %dx%d out of range", cfg.Width, cfg.Height)
+	}
+
+	src, format, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to decode image: %w", err)
+	}
+
+	b := src.Bounds()
+	if b.Dx() > width {
+		height := b.Dy() * width / b.Dx()
+		if height < 1 {
+			height = 1
+		}
+		src = boxScale(src, width, height)
+	}
+
+	var buf bytes.Buffer
+	if format == "png" {
+		if err := png.Encode(&buf, src); err != nil {
+			return nil, "", fmt.Errorf("failed to encode image: %w", err)
+		}
+		return buf.Bytes(), "image/png", nil
+	}
+	if err := jpeg.Encode(&buf, src, &jpeg.Options{Quality: 85}); err != nil {
+		return nil, "", fmt.Errorf("failed to encode image: %w", err)
+	}
+	return buf.Bytes(), "image/jpeg", nil
+}
+
+// boxScale scales src down to width x height, averaging the source pixels each
+// target pixel covers
+func boxScale(src image.Image, width, height int) *image.NRGBA {
+	b := src.Bounds()
+	dst := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		y0 := b.Min.Y + y*b.Dy()/height
+		y1 := b.Min.Y + (y+1)*b.Dy()/height
+		if y1 == y0 {
+			y1 = y0 + 1
+		}
+		for x := 0; x < width; x++ {
+			x0 := b.Min.X + x*b.Dx()/width
+			x1 := b.Min.X + (x+1)*b.Dx()/width
+			if x1 == x0 {
+				x1 = x0 + 1
+			}
+
+			var r, g, bl, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					pr, pg, pb, pa := src.At(sx, sy).RGBA()
+					r, g, bl, a = r+uint64(pr), g+uint64(pg), bl+uint64(pb), a+uint64(pa)
+					n++
+				}
+			}
+			i := dst.PixOffset(x, y)
+			if a == 0 {
+				continue
+			}
+			// Colors are premultiplied, unpremultiply into NRGBA
+			dst.Pix[i+0] = uint8(r * 0xff / a)
+			dst.Pix[i+1] = uint8(g * 0xff / a)
+			dst.Pix[i+2] = uint8(bl * 0xff / a)
+			dst.Pix[i+3] = uint8((a / n) >> 8)
+		}
+	}
+	return dst
+}
@@ -0,0 +1,147 @@
+// Package lifecycle runs the HTTP server until SIGTERM/SIGINT and then shuts the
+// service down in order, and reports whether it is ready for traffic meanwhile
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"os/signal"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"apple-price/internal/model"
+)
+
+// DefaultShutdownTimeout bounds the whole shutdown: draining HTTP connections
+// and every shutdown hook
+const DefaultShutdownTimeout = 30 * time.Second
+
+// check is a named readiness check
+type check struct {
+	name string
+	fn   func() error
+}
+
+// hook is a named shutdown step
+type hook struct {
+	name string
+	fn   func(ctx context.Context) error
+}
+
+// Manager serves HTTP, answers readiness from its checks and, on SIGTERM/SIGINT,
+// stops taking requests, drains the open ones and runs the shutdown hooks in
+// the order they were added
+type Manager struct {
+	timeout  time.Duration
+	stopping atomic.Bool
+
+	mu     sync.Mutex
+	checks []check
+	hooks  []hook
+}
+
+// New creates a manager whose shutdown gives up after timeout (0 = DefaultShutdownTimeout)
+func New(timeout time.Duration) *Manager {
+	if timeout <= 0 {
+		timeout = DefaultShutdownTimeout
+	}
+	return &Manager{timeout: timeout}
+}
+
+// AddCheck adds a readiness check; fn returns why name isn't ready, nil when it is
+func (m *Manager) AddCheck(name string, fn func() error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.checks = append(m.checks, check{name, fn})
+}
+
+// OnShutdown adds a step run after the HTTP server has drained. Steps run in the
+// order they were added; a failing step is logged and the next one still runs.
+func (m *Manager) OnShutdown(name string, fn func(ctx context.Context) error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.hooks = append(m.hooks, hook{name, fn})
+}
+
+// Readiness runs the checks. Nothing is ready once shutdown has begun, so load
+// balancers stop routing here before connections are drained.
+func (m *Manager) Readiness() model.Readiness {
+	m.mu.Lock()
+	checks := append([]check{}, m.checks...)
+	m.mu.Unlock()
+
+	readiness := model.Readiness{Ready: true, Checks: make(map[string]string, len(checks)+1)}
+	if m.stopping.Load() {
+		readiness.Ready = false
+		readiness.Checks["shutdown"] = "shutting down"
+	}
+	for _, c := range checks {
+		if err := c.fn(); err != nil {
+			readiness.Ready = false
+			readiness.Checks[c.name] = err.Error()
+		} else {
+			readiness.Checks[c.name] = "ok"
+		}
+	}
+	return readiness
+}
+
+// Run serves srv until SIGTERM/SIGINT or until it fails, then shuts down. It
+// returns the serve error, if serving failed.
+func (m *Manager) Run(srv *http.Server) error {
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, syscall.SIGINT)
+	defer stop()
+
+	serveErr := make(chan error, 1)
+	go func() {
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			serveErr <- err
+		}
+		close(serveErr)
+	}()
+
+	var err error
+	select {
+	case <-ctx.Done():
+		slog.Info("Shutdown signal received, draining connections")
+	case err = <-serveErr:
+		slog.Error("Server stopped", "error", err)
+	}
+	stop() // a second signal kills the process right away
+
+	m.Shutdown(srv)
+	return err
+}
+
+// Shutdown marks the service not ready, drains srv (may be nil) and runs the
+// shutdown hooks, all within the manager's timeout
+func (m *Manager) Shutdown(srv *http.Server) {
+	m.stopping.Store(true)
+
+	ctx, cancel := context.WithTimeout(context.Background(), m.timeout)
+	defer cancel()
+
+	if srv != nil {
+		if err := srv.Shutdown(ctx); err != nil {
+			slog.Warn("HTTP connections did not drain in time", "error", err)
+		}
+	}
+
+	m.mu.Lock()
+	hooks := append([]hook{}, m.hooks...)
+	m.mu.Unlock()
+
+	for _, h := range hooks {
+		start := time.Now()
+		if err := h.fn(ctx); err != nil {
+			slog.Error("Shutdown step failed", "step", h.name, "error", err)
+			continue
+		}
+		slog.Info("Shutdown step done", "step", h.name, "duration", time.Since(start))
+	}
+	slog.Info("Shutdown complete")
+}
@@ -0,0 +1,40 @@
+// Package logging configures the process-wide structured logger
+package logging
+
+import (
+	"log/slog"
+	"os"
+	"strings"
+)
+
+// Setup builds a slog logger from LOG_LEVEL/LOG_FORMAT style settings and installs
+// it as the default, so both slog and the standard log package go through it.
+// level is one of debug, info, warn, error (default info); format is text or json (default text).
+func Setup(level, format string) *slog.Logger {
+	opts := &slog.HandlerOptions{Level: ParseLevel(level)}
+
+	var handler slog.Handler
+	if strings.EqualFold(format, "json") {
+		handler = slog.NewJSONHandler(os.Stderr, opts)
+	} else {
+		handler = slog.NewTextHandler(os.Stderr, opts)
+	}
+
+	logger := slog.New(handler)
+	slog.SetDefault(logger)
+	return logger
+}
+
+// ParseLevel converts a level name to a slog.Level, defaulting to info
+func ParseLevel(level string) slog.Level {
+	switch strings.ToLower(strings.TrimSpace(level)) {
+	case "debug":
+		return slog.LevelDebug
+	case "warn", "warning":
+		return slog.LevelWarn
+	case "error":
+		return slog.LevelError
+	default:
+		return slog.LevelInfo
+	}
+}
@@ -0,0 +1,184 @@
+// Package match decides whether a product meets a set of criteria. The product
+// list filters, new arrival subscriptions (pushes and their preview) and
+// recommendations all match through it, so a chip or storage filter means the
+// same thing everywhere: specs are taken from the product's parsed spec index
+// (model.Product.SpecIndex) and compared whole, ignoring case, so "M3" does not
+// match an M3 Pro and 8GB does not match 18GB.
+package match
+
+import (
+	"strconv"
+	"strings"
+
+	"apple-price/internal/model"
+)
+
+// Criteria are the conditions a product must meet. Empty fields don't filter;
+// a list matches when any of its values does.
+type Criteria struct {
+	Categories    []string
+	Regions       []string
+	Models        []string // model lines, e.g. MacBook Pro (see model.ModelFromName)
+	Chips         []string
+	Memories      []string
+	Storages      []string
+	ScreenSizes   []string
+	Colors        []string
+	StockStatuses []string
+	Keywords      []string // free text found anywhere in the name or specs
+	Spec          string   // spec combo every part of which must match, see model.ParseSpecCombo
+	Stability     string   // stable, volatile; products without enough history never match
+	MinPrice      float64  // 0 = no lower bound
+	MaxPrice      float64  // 0 = no upper bound
+	MinStorageGB  int      // 0 = no lower bound
+	MaxStorageGB  int      // 0 = no upper bound
+}
+
+// FromFilter returns the criteria of a product list filter
+func FromFilter(f model.ProductFilter) Criteria {
+	c := Criteria{
+		Models:      f.Models,
+		Chips:       f.Chips,
+		Memories:    f.Memories,
+		Storages:    f.Storages,
+		ScreenSizes: f.ScreenSizes,
+		Colors:      f.Colors,
+		MinPrice:    f.MinPrice,
+		MaxPrice:    f.MaxPrice,
+	}
+	if f.Category != "" {
+		c.Categories = []string{f.Category}
+	}
+	if f.Region != "" {
+		c.Regions = []string{f.Region}
+	}
+	return c
+}
+
+// FromSubscription returns the criteria of a new arrival subscription
+func FromSubscription(sub *model.NewArrivalSubscription) Criteria {
+	return Criteria{
+		Categories:    sub.Categories,
+		Models:        sub.Models,
+		Chips:         sub.Chips,
+		Memories:      sub.Memories,
+		Storages:      sub.Storages,
+		StockStatuses: sub.StockStatuses,
+		Keywords:      sub.Keywords,
+		Spec:          sub.Spec,
+		Stability:     sub.Stability,
+		MinPrice:      sub.MinPrice,
+		MaxPrice:      sub.MaxPrice,
+	}
+}
+
+// Subscription reports whether a product matches a new arrival subscription
+func Subscription(sub *model.NewArrivalSubscription, p *model.Product) bool {
+	return FromSubscription(sub).Matches(p)
+}
+
+// Matches reports whether p meets every criterion
+func (c Criteria) Matches(p *model.Product) bool {
+	if p == nil {
+		return false
+	}
+	if !exact(c.Categories, p.Category) || !exact(c.Regions, p.Region) || !exact(c.StockStatuses, p.StockStatus) {
+		return false
+	}
+	if (c.MinPrice > 0 && p.Price < c.MinPrice) || (c.MaxPrice > 0 && p.Price > c.MaxPrice) {
+		return false
+	}
+	if c.Stability != "" && p.Stability != c.Stability {
+		return false
+	}
+	if len(c.Keywords) > 0 && !anyKeyword(c.Keywords, p.Name+" "+p.Specs) {
+		return false
+	}
+	if c.Spec != "" && !model.ParseSpecCombo(c.Spec).Matches(p) {
+		return false
+	}
+
+	if !c.hasSpecs() {
+		return true
+	}
+	specs := p.SpecIndex()
+	if specs.Model == "" && len(c.Models) > 0 {
+		// No known model lines in the category (e.g. iPhone): the name must mention one
+		if !anyKeyword(c.Models, p.Name) {
+			return false
+		}
+	} else if !fold(c.Models, specs.Model) {
+		return false
+	}
+	if !fold(c.Chips, specs.Chip) || !fold(c.Memories, specs.Memory) ||
+		!fold(c.Storages, specs.Storage) || !fold(c.ScreenSizes, specs.ScreenSize) || !fold(c.Colors, specs.Color) {
+		return false
+	}
+	if c.MinStorageGB > 0 || c.MaxStorageGB > 0 {
+		gb := StorageGB(specs.Storage)
+		if gb == 0 || (c.MinStorageGB > 0 && gb < c.MinStorageGB) || (c.MaxStorageGB > 0 && gb > c.MaxStorageGB) {
+			return false
+		}
+	}
+	return true
+}
+
+// hasSpecs reports whether any criterion needs the product's parsed specs
+func (c Criteria) hasSpecs() bool {
+	return len(c.Models) > 0 || len(c.Chips) > 0 || len(c.Memories) > 0 || len(c.Storages) > 0 ||
+		len(c.ScreenSizes) > 0 || len(c.Colors) > 0 || c.MinStorageGB > 0 || c.MaxStorageGB > 0
+}
+
+// StorageGB converts a capacity such as 512GB or 2TB to GB, 0 if unknown
+func StorageGB(capacity string) int {
+	upper := strings.ToUpper(strings.TrimSpace(capacity))
+	unit := 1
+	switch {
+	case strings.HasSuffix(upper, "TB"):
+		unit = 1024
+	case !strings.HasSuffix(upper, "GB"):
+		return 0
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(upper[:len(upper)-2]))
+	if err != nil {
+		return 0
+	}
+	return n * unit
+}
+
+// exact reports whether value is one of values, or values is empty
+func exact(values []string, value string) bool {
+	if len(values) == 0 {
+		return true
+	}
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// fold reports whether value is one of values ignoring case, or values is empty
+func fold(values []string, value string) bool {
+	if len(values) == 0 {
+		return true
+	}
+	for _, v := range values {
+		if strings.EqualFold(strings.TrimSpace(v), value) {
+			return true
+		}
+	}
+	return false
+}
+
+// anyKeyword reports whether any keyword appears in text, ignoring case
+func anyKeyword(keywords []string, text string) bool {
+	text = strings.ToLower(text)
+	for _, kw := range keywords {
+		if strings.Contains(text, strings.ToLower(kw)) {
+			return true
+		}
+	}
+	return false
+}
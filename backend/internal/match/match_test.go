@@ -0,0 +1,179 @@
+package match
+
+import (
+	"testing"
+
+	"apple-price/internal/model"
+)
+
+var (
+	macBookPro = &model.Product{
+		ID:          "mbp",
+		Name:        "翻新 14 英寸 MacBook Pro Apple M3 Pro 芯片 - 深空黑色",
+		Category:    "Mac",
+		Region:      "cn",
+		Price:       13589,
+		StockStatus: "available",
+		Stability:   "stable",
+		SpecsDetail: `{"chip":"M3 Pro","memory":"18GB","storage":"512GB","screen_size":"14英寸","color":"深空黑色"}`,
+	}
+	macBookAir = &model.Product{
+		ID:          "mba",
+		Name:        "Refurbished 13-inch MacBook Air Apple M3 Chip - Midnight",
+		Category:    "Mac",
+		Region:      "us",
+		Price:       899,
+		StockStatus: "sold_out",
+		Specs:       "8GB unified memory, 256GB SSD",
+	}
+	iPadPro = &model.Product{
+		ID:          "ipad",
+		Name:        "翻新 11 英寸 iPad Pro Wi-Fi 2TB - 深空黑色",
+		Category:    "iPad",
+		Region:      "cn",
+		Price:       12999,
+		StockStatus: "available",
+		SpecsDetail: `{"chip":"M4","storage":"2TB"}`,
+	}
+	iPhone = &model.Product{
+		ID:          "iphone",
+		Name:        "翻新 iPhone 15 Pro 256GB - 原色钛金属",
+		Category:    "iPhone",
+		Region:      "cn",
+		Price:       6399,
+		StockStatus: "available",
+	}
+)
+
+func TestFilterMatches(t *testing.T) {
+	tests := []struct {
+		name    string
+		filter  model.ProductFilter
+		product *model.Product
+		want    bool
+	}{
+		{"empty filter", model.ProductFilter{}, macBookPro, true},
+		{"category", model.ProductFilter{Category: "Mac"}, macBookPro, true},
+		{"other category", model.ProductFilter{Category: "iPad"}, macBookPro, false},
+		{"region", model.ProductFilter{Region: "us"}, macBookAir, true},
+		{"other region", model.ProductFilter{Region: "cn"}, macBookAir, false},
+		{"price in range", model.ProductFilter{MinPrice: 10000, MaxPrice: 15000}, macBookPro, true},
+		{"below min price", model.ProductFilter{MinPrice: 1000}, macBookAir, false},
+		{"above max price", model.ProductFilter{MaxPrice: 10000}, macBookPro, false},
+		{"model", model.ProductFilter{Models: []string{"MacBook Pro"}}, macBookPro, true},
+		{"any of models", model.ProductFilter{Models: []string{"iMac", "MacBook Air"}}, macBookAir, true},
+		{"other model", model.ProductFilter{Models: []string{"MacBook Air"}}, macBookPro, false},
+		{"chip ignores case", model.ProductFilter{Chips: []string{"m3 pro"}}, macBookPro, true},
+		{"chip is compared whole", model.ProductFilter{Chips: []string{"M3"}}, macBookPro, false},
+		{"chip from the name", model.ProductFilter{Chips: []string{"M3"}}, macBookAir, true},
+		{"memory", model.ProductFilter{Memories: []string{"18GB"}}, macBookPro, true},
+		{"memory is compared whole", model.ProductFilter{Memories: []string{"8GB"}}, macBookPro, false},
+		{"memory from the specs", model.ProductFilter{Memories: []string{"8GB"}}, macBookAir, true},
+		{"storage", model.ProductFilter{Storages: []string{"512GB", "1TB"}}, macBookPro, true},
+		{"other storage", model.ProductFilter{Storages: []string{"1TB"}}, macBookPro, false},
+		{"screen size", model.ProductFilter{ScreenSizes: []string{"14英寸"}}, macBookPro, true},
+		{"color", model.ProductFilter{Colors: []string{"深空黑色"}}, macBookPro, true},
+		{"unknown color", model.ProductFilter{Colors: []string{"银色"}}, macBookPro, false},
+		{"every criterion must match", model.ProductFilter{Category: "Mac", Chips: []string{"M3 Pro"}, Storages: []string{"1TB"}}, macBookPro, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := FromFilter(tt.filter).Matches(tt.product); got != tt.want {
+				t.Errorf("FromFilter(%+v).Matches(%s) = %v, want %v", tt.filter, tt.product.ID, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSubscription(t *testing.T) {
+	tests := []struct {
+		name    string
+		sub     model.NewArrivalSubscription
+		product *model.Product
+		want    bool
+	}{
+		{"no criteria", model.NewArrivalSubscription{}, iPhone, true},
+		{"categories", model.NewArrivalSubscription{Categories: []string{"iPad", "Mac"}}, iPadPro, true},
+		{"other category", model.NewArrivalSubscription{Categories: []string{"Mac"}}, iPhone, false},
+		{"stock status", model.NewArrivalSubscription{StockStatuses: []string{"available"}}, macBookAir, false},
+		{"keyword in name", model.NewArrivalSubscription{Keywords: []string{"midnight"}}, macBookAir, true},
+		{"keyword in specs", model.NewArrivalSubscription{Keywords: []string{"256GB SSD"}}, macBookAir, true},
+		{"missing keyword", model.NewArrivalSubscription{Keywords: []string{"Max"}}, macBookPro, false},
+		{"model without known lines uses the name", model.NewArrivalSubscription{Models: []string{"iPhone 15 Pro"}}, iPhone, true},
+		{"model not in the name", model.NewArrivalSubscription{Models: []string{"iPhone 16"}}, iPhone, false},
+		{"spec combo", model.NewArrivalSubscription{Spec: "MacBook Pro 14 M3 Pro"}, macBookPro, true},
+		{"spec combo with other chip", model.NewArrivalSubscription{Spec: "MacBook Pro 14 M3 Max"}, macBookPro, false},
+		{"stability", model.NewArrivalSubscription{Stability: "stable"}, macBookPro, true},
+		{"without enough history", model.NewArrivalSubscription{Stability: "stable"}, macBookAir, false},
+		{"max price", model.NewArrivalSubscription{Categories: []string{"Mac"}, MaxPrice: 1000}, macBookAir, true},
+		{"over max price", model.NewArrivalSubscription{Categories: []string{"Mac"}, MaxPrice: 1000}, macBookPro, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Subscription(&tt.sub, tt.product); got != tt.want {
+				t.Errorf("Subscription(%+v, %s) = %v, want %v", tt.sub, tt.product.ID, got, tt.want)
+			}
+		})
+	}
+
+	if Subscription(&model.NewArrivalSubscription{}, nil) {
+		t.Error("Subscription matched a nil product")
+	}
+}
+
+// TestRecommendationCriteria covers the chip and storage range criteria the
+// recommendation endpoint builds
+func TestRecommendationCriteria(t *testing.T) {
+	tests := []struct {
+		name     string
+		criteria Criteria
+		product  *model.Product
+		want     bool
+	}{
+		{"chip", Criteria{Chips: []string{"M4"}}, iPadPro, true},
+		{"chip is compared whole", Criteria{Chips: []string{"M3"}}, macBookPro, false},
+		{"storage at min", Criteria{MinStorageGB: 512}, macBookPro, true},
+		{"storage below min", Criteria{MinStorageGB: 1024}, macBookPro, false},
+		{"storage in TB", Criteria{MinStorageGB: 1024, MaxStorageGB: 2048}, iPadPro, true},
+		{"storage above max", Criteria{MaxStorageGB: 1024}, iPadPro, false},
+		{"storage from the specs", Criteria{MaxStorageGB: 256}, macBookAir, true},
+		{"chip and storage", Criteria{Chips: []string{"M3 Pro"}, MinStorageGB: 256, MaxStorageGB: 512}, macBookPro, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.criteria.Matches(tt.product); got != tt.want {
+				t.Errorf("%+v.Matches(%s) = %v, want %v", tt.criteria, tt.product.ID, got, tt.want)
+			}
+		})
+	}
+
+	// A storage range never matches a product whose storage is unknown
+	unknown := &model.Product{ID: "unknown", Name: "Apple TV 4K", Category: "Apple TV"}
+	if (Criteria{MaxStorageGB: 1024}).Matches(unknown) {
+		t.Error("storage range matched a product without storage")
+	}
+}
+
+func TestStorageGB(t *testing.T) {
+	tests := []struct {
+		capacity string
+		want     int
+	}{
+		{"256GB", 256},
+		{"512 gb", 512},
+		{"2TB", 2048},
+		{" 1TB ", 1024},
+		{"", 0},
+		{"GB", 0},
+		{"16英寸", 0},
+	}
+
+	for _, tt := range tests {
+		if got := StorageGB(tt.capacity); got != tt.want {
+			t.Errorf("StorageGB(%q) = %d, want %d", tt.capacity, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,25 @@
+package model
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// NormalizeBarkServer checks a Bark server URL, e.g. of a self-hosted bark-server,
+// and strips trailing slashes. An empty URL stays empty, meaning the default server.
+func NormalizeBarkServer(raw string) (string, error) {
+	raw = strings.TrimRight(strings.TrimSpace(raw), "/")
+	if raw == "" {
+		return "", nil
+	}
+
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "", fmt.Errorf("invalid bark server %q, expected http(s)://host[:port][/path]", raw)
+	}
+	if u.User != nil || u.RawQuery != "" || u.Fragment != "" {
+		return "", fmt.Errorf("bark server %q must not contain credentials, query or fragment", raw)
+	}
+	return raw, nil
+}
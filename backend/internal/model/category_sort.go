@@ -0,0 +1,24 @@
+package model
+
+// Product list sorts an operator can make a category's default, applied when
+// the client doesn't ask for a sort
+const (
+	SortScore   = "score"   // best value score first
+	SortCreated = "created" // newest listings first
+	SortSavings = "savings" // largest saving off the new price first
+)
+
+// DefaultSortOptions lists the sorts allowed as a category default
+func DefaultSortOptions() []string {
+	return []string{SortScore, SortCreated, SortSavings}
+}
+
+// ValidDefaultSort reports whether sortBy may be used as a category default
+func ValidDefaultSort(sortBy string) bool {
+	for _, s := range DefaultSortOptions() {
+		if s == sortBy {
+			return true
+		}
+	}
+	return false
+}
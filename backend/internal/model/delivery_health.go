@@ -0,0 +1,26 @@
+package model
+
+import "time"
+
+// DeliveryStatusBroken flags subscriptions whose Bark Key keeps rejecting pushes
+const DeliveryStatusBroken = "delivery_broken"
+
+// DefaultDeliveryFailureLimit is how many pushes in a row a Bark Key may fail
+// before its notifications are paused
+const DefaultDeliveryFailureLimit = 5
+
+// DeliveryHealth tracks consecutive push failures of a Bark Key. Once the
+// failure limit is reached the key is marked broken (device removed or key
+// rotated) and nothing more is sent to it until a push goes through again.
+type DeliveryHealth struct {
+	BarkKey             string     `json:"-"`
+	ConsecutiveFailures int        `json:"consecutive_failures"`
+	LastError           string     `json:"last_error,omitempty"`
+	LastFailureAt       *time.Time `json:"last_failure_at,omitempty"`
+	BrokenAt            *time.Time `json:"broken_at,omitempty"`
+}
+
+// Broken reports whether deliveries to the key are paused
+func (h *DeliveryHealth) Broken() bool {
+	return h != nil && h.BrokenAt != nil
+}
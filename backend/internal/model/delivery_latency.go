@@ -0,0 +1,51 @@
+package model
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// ChannelBark is the notification history channel of Bark pushes
+const ChannelBark = "bark"
+
+// DeliveryLatencyWindow is how far back the stats look for delivered notifications
+const DeliveryLatencyWindow = 24 * time.Hour
+
+// ChannelLatency summarizes how long a channel's deliveries took, from the
+// event being detected to the channel accepting the push
+type ChannelLatency struct {
+	Channel string `json:"channel"`
+	Count   int    `json:"count"`
+	P50Ms   int64  `json:"p50_ms"`
+	P95Ms   int64  `json:"p95_ms"`
+	MaxMs   int64  `json:"max_ms"`
+}
+
+// SummarizeLatencies computes the latency percentiles of each channel's samples
+// (milliseconds), ordered by channel
+func SummarizeLatencies(samples map[string][]int64) []ChannelLatency {
+	summaries := make([]ChannelLatency, 0, len(samples))
+	for channel, latencies := range samples {
+		if len(latencies) == 0 {
+			continue
+		}
+		sorted := append([]int64(nil), latencies...)
+		sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+		summaries = append(summaries, ChannelLatency{
+			Channel: channel,
+			Count:   len(sorted),
+			P50Ms:   percentile(sorted, 0.50),
+			P95Ms:   percentile(sorted, 0.95),
+			MaxMs:   sorted[len(sorted)-1],
+		})
+	}
+	sort.Slice(summaries, func(i, j int) bool { return summaries[i].Channel < summaries[j].Channel })
+	return summaries
+}
+
+// percentile returns the nearest-rank percentile p of sorted values
+func percentile(sorted []int64, p float64) int64 {
+	rank := int(math.Ceil(p*float64(len(sorted)))) - 1
+	return sorted[max(rank, 0)]
+}
@@ -0,0 +1,57 @@
+package model
+
+import "time"
+
+// ExchangeRates are how many units of each currency one unit of Base buys
+type ExchangeRates struct {
+	Base      string             `json:"base"` // ISO 4217, e.g. CNY
+	Rates     map[string]float64 `json:"rates"`
+	FetchedAt time.Time          `json:"fetched_at"`
+	Stale     bool               `json:"stale,omitempty"` // the provider failed, these are the last rates it gave
+}
+
+// Convert converts amount from one currency to another, false when either has no rate
+func (r *ExchangeRates) Convert(amount float64, from, to string) (float64, bool) {
+	fromRate, ok := r.rate(from)
+	if !ok {
+		return 0, false
+	}
+	toRate, ok := r.rate(to)
+	if !ok {
+		return 0, false
+	}
+	return amount / fromRate * toRate, true
+}
+
+func (r *ExchangeRates) rate(currency string) (float64, bool) {
+	if currency == r.Base {
+		return 1, true
+	}
+	rate, ok := r.Rates[currency]
+	return rate, ok && rate > 0
+}
+
+// CrossRegionPrice is the cheapest listing of a spec combination in one region
+type CrossRegionPrice struct {
+	Region            string  `json:"region"`
+	Currency          string  `json:"currency"`
+	ProductID         string  `json:"product_id"`
+	Name              string  `json:"name"`
+	Price             float64 `json:"price"`                        // in the region's currency
+	ConvertedPrice    float64 `json:"converted_price,omitempty"`    // in the comparison currency (0 = no rate)
+	DifferencePercent float64 `json:"difference_percent,omitempty"` // converted price vs the requested product's
+	StockStatus       string  `json:"stock_status"`
+	ProductURL        string  `json:"product_url,omitempty"`
+}
+
+// CrossRegionComparison is what the model of a product costs in each region,
+// converted to one currency
+type CrossRegionComparison struct {
+	ProductID      string             `json:"product_id"`
+	Combo          SpecCombo          `json:"combo"`
+	Currency       string             `json:"currency"`
+	Regions        []CrossRegionPrice `json:"regions"` // cheapest converted price first
+	CheapestRegion string             `json:"cheapest_region,omitempty"`
+	Rates          *ExchangeRates     `json:"rates,omitempty"`
+	RatesError     string             `json:"rates_error,omitempty"`
+}
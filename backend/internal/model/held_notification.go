@@ -0,0 +1,30 @@
+package model
+
+import "time"
+
+// HeldNotification is a push generated during its subscriber's quiet hours. It is
+// rendered when held and persisted, so it survives restarts until SendAt.
+type HeldNotification struct {
+	ID               string              `json:"id"` // subscription, type and product: a newer event replaces the held one
+	SubscriptionID   string              `json:"subscription_id"`
+	ProductID        string              `json:"product_id,omitempty"`
+	NotificationType string              `json:"notification_type"`
+	BarkKey          string              `json:"bark_key"`
+	BarkServer       string              `json:"bark_server,omitempty"`
+	Title            string              `json:"title"`
+	Body             string              `json:"body"`
+	URL              string              `json:"url,omitempty"`
+	Icon             string              `json:"icon,omitempty"`
+	Sound            string              `json:"sound,omitempty"`
+	Group            string              `json:"group,omitempty"`
+	Product          *Product            `json:"product"`            // as of the event, for notification history
+	Counted          []string            `json:"counted,omitempty"`  // subscriptions whose notification count goes up on delivery
+	Notified         map[string][]string `json:"notified,omitempty"` // new arrival subscription -> products it is told about
+	SendAt           time.Time           `json:"send_at"`            // end of the quiet window
+	CreatedAt        time.Time           `json:"created_at"`
+}
+
+// HeldNotificationID is the ID a push is held under
+func HeldNotificationID(subscriptionID, notificationType, productID string) string {
+	return subscriptionID + "|" + notificationType + "|" + productID
+}
@@ -0,0 +1,39 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Languages of notification and API messages
+const (
+	LanguageZH   = "zh"    // Simplified Chinese (zh-CN), the default
+	LanguageZHHK = "zh-HK" // Traditional Chinese as written in Hong Kong
+	LanguageEN   = "en"
+)
+
+// NormalizeLanguage maps a language tag such as "zh-CN", "zh-Hant-TW" or "en-US"
+// to one of the supported languages, or "" when it is none of them
+func NormalizeLanguage(tag string) string {
+	tag = strings.ToLower(strings.ReplaceAll(strings.TrimSpace(tag), "_", "-"))
+	switch {
+	case tag == "en" || strings.HasPrefix(tag, "en-"):
+		return LanguageEN
+	case tag == "zh" || strings.HasPrefix(tag, "zh-"):
+		for _, traditional := range []string{"-hk", "-mo", "-tw", "-hant"} {
+			if strings.Contains(tag, traditional) {
+				return LanguageZHHK
+			}
+		}
+		return LanguageZH
+	}
+	return ""
+}
+
+// ValidateLanguage checks an optional language setting
+func ValidateLanguage(language string) error {
+	if language != "" && NormalizeLanguage(language) == "" {
+		return fmt.Errorf("language must be zh, zh-HK or en")
+	}
+	return nil
+}
@@ -0,0 +1,26 @@
+package model
+
+import "time"
+
+// NotificationRetry is a failed push waiting to be sent again. It keeps the
+// rendered message, so the retry delivers exactly what the first attempt tried to.
+type NotificationRetry struct {
+	ID               string    `json:"id"`
+	HistoryID        string    `json:"history_id,omitempty"` // notification history entry to mark sent on success
+	SubscriptionID   string    `json:"subscription_id"`
+	ProductID        string    `json:"product_id,omitempty"`
+	NotificationType string    `json:"notification_type"`
+	BarkKey          string    `json:"bark_key"`
+	BarkServer       string    `json:"bark_server,omitempty"`
+	Title            string    `json:"title"`
+	Body             string    `json:"body"`
+	URL              string    `json:"url,omitempty"`
+	Icon             string    `json:"icon,omitempty"`
+	Sound            string    `json:"sound,omitempty"`
+	Group            string    `json:"group,omitempty"`
+	Attempts         int       `json:"attempts"` // failed attempts so far, including the original send
+	NextAttemptAt    time.Time `json:"next_attempt_at"`
+	LastError        string    `json:"last_error"`
+	DetectedAt       time.Time `json:"detected_at,omitempty"` // when the event was detected, for delivery latency
+	CreatedAt        time.Time `json:"created_at"`
+}
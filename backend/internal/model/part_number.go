@@ -0,0 +1,65 @@
+package model
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// partNumberPattern is an Apple part number: a five character model code, the
+// region code (CH China, LL US, ZP Hong Kong, J Japan, ...) and a revision letter
+var partNumberPattern = regexp.MustCompile(`^([A-Z0-9]{5})[A-Z]{1,3}/[A-Z]$`)
+
+// PartNumberBase returns the region independent part of an Apple part number,
+// e.g. FK0Q3 for both FK0Q3CH/A and FK0Q3LL/A. Part numbers of another shape are
+// only normalized; empty means there is none.
+func PartNumberBase(partNumber string) string {
+	partNumber = strings.ToUpper(strings.TrimSpace(partNumber))
+	if m := partNumberPattern.FindStringSubmatch(partNumber); m != nil {
+		return m[1]
+	}
+	return partNumber
+}
+
+// ProductEquivalents is the same SKU, by part number, listed in other regions
+type ProductEquivalents struct {
+	ProductID      string     `json:"product_id"`
+	PartNumber     string     `json:"part_number"`
+	BasePartNumber string     `json:"base_part_number"`
+	Equivalents    []*Product `json:"equivalents"` // by region code
+}
+
+// GroupByPartNumber groups products by base part number. Products without a part
+// number are left out.
+func GroupByPartNumber(products []*Product) map[string][]*Product {
+	groups := make(map[string][]*Product)
+	for _, p := range products {
+		if base := PartNumberBase(p.PartNumber); base != "" {
+			groups[base] = append(groups[base], p)
+		}
+	}
+	return groups
+}
+
+// MatchEquivalents returns the products that are product's SKU listed in another
+// region, ordered by region code. A product without a part number matches nothing.
+func MatchEquivalents(product *Product, products []*Product) []*Product {
+	base := PartNumberBase(product.PartNumber)
+	if base == "" {
+		return []*Product{}
+	}
+
+	matches := []*Product{}
+	for _, p := range GroupByPartNumber(products)[base] {
+		if p.ID != product.ID && p.Region != product.Region {
+			matches = append(matches, p)
+		}
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].Region != matches[j].Region {
+			return matches[i].Region < matches[j].Region
+		}
+		return matches[i].Price < matches[j].Price
+	})
+	return matches
+}
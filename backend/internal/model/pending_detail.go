@@ -0,0 +1,50 @@
+package model
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrDetailsUnavailable is returned when product details can't be fetched, e.g.
+// because the detail scraper isn't running
+var ErrDetailsUnavailable = errors.New("detail scraper not available")
+
+// DefaultDetailMaxAttempts is how many failed detail fetches a product gets
+// before it is given up
+const DefaultDetailMaxAttempts = 5
+
+// Detail fetch priorities, a higher one is fetched first
+const (
+	DetailPriorityBackfill   = 0 // listed for a while, description still missing
+	DetailPrioritySubscribed = 1 // someone is subscribed to the product
+	DetailPriorityNew        = 2 // first seen within the last day
+	DetailPriorityRequeued   = 3 // requeued by an operator
+)
+
+// PendingDetail is a product waiting for its detail page to be fetched. The
+// queue is persisted so a restart resumes it instead of starting over. A failed
+// fetch stays queued until NextAttemptAt; after the attempt cap it is given up
+// and only fetched again when an operator requeues it.
+type PendingDetail struct {
+	ProductID     string     `json:"product_id"`
+	Priority      int        `json:"priority"`
+	QueuedAt      time.Time  `json:"queued_at"`
+	Attempts      int        `json:"attempts"` // failed fetches so far, each after its own retries
+	LastError     string     `json:"last_error,omitempty"`
+	NextAttemptAt *time.Time `json:"next_attempt_at,omitempty"`
+	GivenUp       bool       `json:"given_up,omitempty"`
+}
+
+// Failed reports whether the last fetch of the product failed
+func (d *PendingDetail) Failed() bool {
+	return d.Attempts > 0
+}
+
+// Before reports whether d is fetched before other: higher priority first, then
+// in the order queued
+func (d *PendingDetail) Before(other *PendingDetail) bool {
+	if d.Priority != other.Priority {
+		return d.Priority > other.Priority
+	}
+	return d.QueuedAt.Before(other.QueuedAt)
+}
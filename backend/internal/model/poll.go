@@ -0,0 +1,30 @@
+package model
+
+import "time"
+
+// Poll event types besides the product lifecycle events (EventListed, ...)
+const (
+	EventPriceDrop = "price_drop"
+	EventPriceRise = "price_rise"
+)
+
+// PollEvent is a catalog change delivered to long-polling browsers
+type PollEvent struct {
+	Type        string    `json:"type"` // listed, available, limited, sold_out, price_drop, price_rise
+	ProductID   string    `json:"product_id"`
+	ProductName string    `json:"product_name"`
+	Category    string    `json:"category"`
+	Region      string    `json:"region"`
+	ImageURL    string    `json:"image_url,omitempty"`
+	Price       float64   `json:"price"`
+	OldPrice    float64   `json:"old_price,omitempty"` // Previous price, for price events
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// PollBatch is the answer to GET /api/poll. Times are Unix seconds; the client
+// passes Until as since on its next poll.
+type PollBatch struct {
+	Since  int64       `json:"since"`
+	Until  int64       `json:"until"`
+	Events []PollEvent `json:"events"`
+}
@@ -0,0 +1,39 @@
+package model
+
+import "fmt"
+
+// Notification channels a user can pick as their default
+const (
+	ChannelTelegram = "telegram"
+	ChannelEmail    = "email"
+)
+
+// Currency display styles of prices in notification messages
+const (
+	CurrencySymbol = "symbol" // ¥6999
+	CurrencyCode   = "code"   // 6999 CNY
+)
+
+// Validate checks the settable preferences; empty values mean the defaults
+func (p *UserPreferences) Validate() error {
+	switch p.DefaultChannel {
+	case "", ChannelBark, ChannelTelegram, ChannelEmail:
+	default:
+		return fmt.Errorf("default_channel must be bark, telegram or email")
+	}
+	if err := ValidateQuietHours(p.QuietHoursStart, p.QuietHoursEnd, p.Timezone); err != nil {
+		return err
+	}
+	if err := ValidateLanguage(p.Language); err != nil {
+		return err
+	}
+	switch p.CurrencyDisplay {
+	case "", CurrencySymbol, CurrencyCode:
+	default:
+		return fmt.Errorf("currency_display must be symbol or code")
+	}
+	if !ValidFrequency(p.DigestFrequency) {
+		return fmt.Errorf("digest_frequency must be instant, hourly or daily")
+	}
+	return nil
+}
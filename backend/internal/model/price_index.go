@@ -0,0 +1,163 @@
+package model
+
+import (
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// PriceIndexWindowDays is the trailing window today's prices are compared against
+const PriceIndexWindowDays = 90
+
+// Price index levels: how today's catalog compares with the trailing window
+const (
+	PriceIndexCheap     = "cheap" // index below PriceIndexBand of 100
+	PriceIndexNormal    = "normal"
+	PriceIndexExpensive = "expensive" // index above PriceIndexBand of 100
+)
+
+// PriceIndexBand is how far from 100 the index must move to count as cheap or expensive
+const PriceIndexBand = 3.0
+
+// DailyPriceIndex is one day's average price of a spec tier (product line, chip
+// and memory) in a region, normalized by storage as price per GB
+type DailyPriceIndex struct {
+	Date       string  `json:"date"` // YYYY-MM-DD, server local time
+	Tier       string  `json:"tier"` // e.g. "MacBook Air M3 16GB"
+	Region     string  `json:"region"`
+	Products   int     `json:"products"` // listings not sold out
+	AvgPrice   float64 `json:"avg_price"`
+	PricePerGB float64 `json:"price_per_gb"` // average price per GB of storage
+}
+
+// PriceIndexTier is a spec tier's price today against its trailing average.
+// Index is 100 when today matches the average; below 100 is cheaper.
+type PriceIndexTier struct {
+	Tier       string  `json:"tier"`
+	Region     string  `json:"region"`
+	Products   int     `json:"products"`
+	AvgPrice   float64 `json:"avg_price"`
+	PricePerGB float64 `json:"price_per_gb"`
+	Baseline   float64 `json:"baseline_price_per_gb"` // trailing average price per GB
+	BaseDays   int     `json:"baseline_days"`         // days with data in the trailing window
+	Index      float64 `json:"index"`
+	Level      string  `json:"level"` // cheap, normal, expensive
+}
+
+// PriceIndex is the "ApplePrice index": the product-weighted average of the tier
+// indexes on the latest recorded day
+type PriceIndex struct {
+	Date  string           `json:"date"`
+	Days  int              `json:"days"` // trailing window length
+	Index float64          `json:"index"`
+	Level string           `json:"level"`
+	Tiers []PriceIndexTier `json:"tiers"`
+}
+
+// PriceIndexLevel classifies an index value
+func PriceIndexLevel(index float64) string {
+	switch {
+	case index == 0:
+		return ""
+	case index < 100-PriceIndexBand:
+		return PriceIndexCheap
+	case index > 100+PriceIndexBand:
+		return PriceIndexExpensive
+	default:
+		return PriceIndexNormal
+	}
+}
+
+// BuildPriceIndex compares each tier's price per GB on the latest day in points
+// with its average over all of points (the trailing window of days days). Tiers
+// not listed on the latest day are left out.
+func BuildPriceIndex(points []DailyPriceIndex, days int) *PriceIndex {
+	index := &PriceIndex{Days: days, Tiers: []PriceIndexTier{}}
+	for _, p := range points {
+		if p.Date > index.Date {
+			index.Date = p.Date
+		}
+	}
+	if index.Date == "" {
+		return index
+	}
+
+	type window struct {
+		sum   float64
+		days  int
+		today *DailyPriceIndex
+	}
+	windows := make(map[string]*window)
+	for i := range points {
+		p := &points[i]
+		key := p.Tier + "|" + p.Region
+		w, ok := windows[key]
+		if !ok {
+			w = &window{}
+			windows[key] = w
+		}
+		w.sum += p.PricePerGB
+		w.days++
+		if p.Date == index.Date {
+			w.today = p
+		}
+	}
+
+	weighted, products := 0.0, 0
+	for _, w := range windows {
+		if w.today == nil || w.sum == 0 {
+			continue
+		}
+		baseline := w.sum / float64(w.days)
+		tier := PriceIndexTier{
+			Tier:       w.today.Tier,
+			Region:     w.today.Region,
+			Products:   w.today.Products,
+			AvgPrice:   math.Round(w.today.AvgPrice),
+			PricePerGB: math.Round(w.today.PricePerGB*100) / 100,
+			Baseline:   math.Round(baseline*100) / 100,
+			BaseDays:   w.days,
+			Index:      math.Round(w.today.PricePerGB/baseline*1000) / 10,
+		}
+		tier.Level = PriceIndexLevel(tier.Index)
+		index.Tiers = append(index.Tiers, tier)
+		weighted += tier.Index * float64(tier.Products)
+		products += tier.Products
+	}
+
+	sort.Slice(index.Tiers, func(i, j int) bool {
+		if index.Tiers[i].Tier != index.Tiers[j].Tier {
+			return index.Tiers[i].Tier < index.Tiers[j].Tier
+		}
+		return index.Tiers[i].Region < index.Tiers[j].Region
+	})
+	if products > 0 {
+		index.Index = math.Round(weighted/float64(products)*10) / 10
+		index.Level = PriceIndexLevel(index.Index)
+	}
+	return index
+}
+
+// Tier returns the spec tier of a combo, e.g. "MacBook Air M3 16GB", or "" when the
+// product line or chip is unknown
+func (c SpecCombo) Tier() string {
+	if c.Model == "" || c.Chip == "" {
+		return ""
+	}
+	parts := []string{c.Model, c.Chip}
+	if c.Memory != "" {
+		parts = append(parts, c.Memory)
+	}
+	return strings.Join(parts, " ")
+}
+
+// StorageGB returns the combo's storage in GB (0 if unknown)
+func (c SpecCombo) StorageGB() int {
+	if value, ok := strings.CutSuffix(c.Storage, "TB"); ok {
+		n, _ := strconv.Atoi(value)
+		return n * 1024
+	}
+	n, _ := strconv.Atoi(strings.TrimSuffix(c.Storage, "GB"))
+	return n
+}
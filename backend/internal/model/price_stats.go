@@ -0,0 +1,152 @@
+package model
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// volatilityWindow is how many of the most recent prices the volatility covers
+const volatilityWindow = 10
+
+// minVolatilityPrices is the minimum number of prices before a product is classified
+const minVolatilityPrices = 3
+
+// volatileThreshold is the standard deviation, relative to the mean price, above
+// which a product counts as volatile
+const volatileThreshold = 0.03
+
+// Price stability classes
+const (
+	StabilityStable   = "stable"
+	StabilityVolatile = "volatile"
+)
+
+// ValidStability reports whether stability is a supported filter value (empty = any)
+func ValidStability(stability string) bool {
+	switch stability {
+	case "", StabilityStable, StabilityVolatile:
+		return true
+	default:
+		return false
+	}
+}
+
+// PriceIndicators computes the volatility and drop streak of a product from its
+// price history (earlier prices) followed by the current price. Stability stays
+// empty until there are enough prices to judge.
+func PriceIndicators(history []PriceHistory, current float64) (volatility float64, dropStreak int, stability string) {
+	prices := make([]float64, 0, len(history)+1)
+	for _, h := range history {
+		prices = append(prices, h.Price)
+	}
+	prices = append(prices, current)
+
+	// Consecutive drops ending at the current price
+	for i := len(prices) - 1; i > 0 && prices[i] < prices[i-1]; i-- {
+		dropStreak++
+	}
+
+	if len(prices) > volatilityWindow {
+		prices = prices[len(prices)-volatilityWindow:]
+	}
+	if len(prices) < minVolatilityPrices {
+		return 0, dropStreak, ""
+	}
+
+	var sum float64
+	for _, p := range prices {
+		sum += p
+	}
+	mean := sum / float64(len(prices))
+
+	var variance float64
+	for _, p := range prices {
+		variance += (p - mean) * (p - mean)
+	}
+	volatility = math.Round(math.Sqrt(variance/float64(len(prices)))*100) / 100
+
+	stability = StabilityStable
+	if mean > 0 && volatility/mean > volatileThreshold {
+		stability = StabilityVolatile
+	}
+	return volatility, dropStreak, stability
+}
+
+// PriceStats summarizes a product's recorded prices, with the current price as the
+// latest point
+type PriceStats struct {
+	ProductID           string     `json:"product_id"`
+	CurrentPrice        float64    `json:"current_price"`
+	MinPrice            float64    `json:"min_price"`
+	MaxPrice            float64    `json:"max_price"`
+	AvgPrice            float64    `json:"avg_price"`
+	MedianPrice         float64    `json:"median_price"`
+	Points              int        `json:"points"`                 // recorded prices plus the current one
+	Drops               int        `json:"drops"`                  // times the price went down
+	LongestStreakDays   float64    `json:"longest_streak_days"`    // longest uninterrupted stretch at the current price
+	DaysSinceLastChange float64    `json:"days_since_last_change"` // since the first record when the price never changed
+	LastChangeAt        *time.Time `json:"last_change_at,omitempty"`
+	Percentile          float64    `json:"percentile"` // % of points below the current price; 0 = as cheap as ever
+}
+
+// ComputePriceStats builds PriceStats from a product's price history (oldest first)
+// followed by the current price at now. A point holds its price until the next one.
+func ComputePriceStats(productID string, history []PriceHistory, current float64, now time.Time) *PriceStats {
+	points := append(append([]PriceHistory(nil), history...), PriceHistory{Price: current, Timestamp: now})
+
+	stats := &PriceStats{
+		ProductID:    productID,
+		CurrentPrice: current,
+		MinPrice:     current,
+		MaxPrice:     current,
+		Points:       len(points),
+	}
+
+	var sum, streak, longest float64
+	var below int
+	prices := make([]float64, 0, len(points))
+	for i, p := range points {
+		sum += p.Price
+		prices = append(prices, p.Price)
+		stats.MinPrice = math.Min(stats.MinPrice, p.Price)
+		stats.MaxPrice = math.Max(stats.MaxPrice, p.Price)
+		if p.Price < current {
+			below++
+		}
+
+		if i > 0 && p.Price != points[i-1].Price {
+			if p.Price < points[i-1].Price {
+				stats.Drops++
+			}
+			at := p.Timestamp
+			stats.LastChangeAt = &at
+		}
+
+		if p.Price == current {
+			if i+1 < len(points) {
+				streak += points[i+1].Timestamp.Sub(p.Timestamp).Hours() / 24
+			}
+			longest = math.Max(longest, streak)
+		} else {
+			streak = 0
+		}
+	}
+
+	sort.Float64s(prices)
+	if n := len(prices); n%2 == 1 {
+		stats.MedianPrice = prices[n/2]
+	} else {
+		stats.MedianPrice = (prices[n/2-1] + prices[n/2]) / 2
+	}
+
+	since := points[0].Timestamp
+	if stats.LastChangeAt != nil {
+		since = *stats.LastChangeAt
+	}
+	stats.AvgPrice = math.Round(sum/float64(len(points))*100) / 100
+	stats.LongestStreakDays = math.Round(longest*10) / 10
+	stats.DaysSinceLastChange = math.Round(now.Sub(since).Hours()/24*10) / 10
+	stats.Percentile = math.Round(float64(below)/float64(len(points))*1000) / 10
+	return stats
+}
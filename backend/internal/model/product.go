@@ -1,12 +1,16 @@
 package model
 
-import "time"
+import (
+	"fmt"
+	"strings"
+	"time"
+)
 
 // Product represents an Apple refurbished product
 type Product struct {
 	ID          string    `json:"id" db:"id"`
 	Name        string    `json:"name" db:"name"`
-	Category    string    `json:"category" db:"category"`       // Mac, iPad, iPhone, Watch, Accessory
+	Category    string    `json:"category" db:"category"`       // Mac, iPad, iPhone, Watch, AirPods, HomePod, Apple TV, Display, Accessory
 	Region      string    `json:"region" db:"region"`           // cn, hk
 	Price       float64   `json:"price" db:"price"`
 	OriginalPrice float64 `json:"original_price" db:"original_price"`
@@ -18,16 +22,97 @@ type Product struct {
 	Description string    `json:"description,omitempty" db:"description"` // Product overview/description
 	StockStatus string    `json:"stock_status" db:"stock_status"` // available, sold_out, limited
 
+	// Refurbishment terms extracted from the detail page (empty when not listed)
+	Grade          string `json:"grade,omitempty" db:"grade"`                     // certified, A, B, ...
+	WarrantyMonths int    `json:"warranty_months,omitempty" db:"warranty_months"`
+	BatteryHealth  int    `json:"battery_health,omitempty" db:"battery_health"`   // claimed minimum battery capacity, %
+
+	// Dominant color of the product image as #rrggbb, extracted by the detail scraper
+	DominantColor string `json:"dominant_color,omitempty" db:"dominant_color"`
+
+	// Apple part number from the listing, e.g. FK0Q3CH/A; see PartNumberBase
+	PartNumber string `json:"part_number,omitempty" db:"part_number"`
+
 	// Value-based scoring (replaces AI-based scoring)
 	ValueScore  float64  `json:"value_score" db:"value_score"` // 0-100, based on historical data
 	LowestPrice float64  `json:"lowest_price,omitempty" db:"lowest_price"`
 	HighestPrice float64 `json:"highest_price,omitempty" db:"highest_price"`
 	PriceTrend  string   `json:"price_trend,omitempty" db:"price_trend"` // falling, rising, stable
+	Volatility  float64  `json:"volatility" db:"volatility"`             // std-dev of the last 10 prices
+	DropStreak  int      `json:"drop_streak" db:"drop_streak"`           // consecutive price drops up to now
+	Stability   string   `json:"stability,omitempty" db:"stability"`     // stable, volatile (empty = not enough history)
+
+	// Archived products are hidden from listings but keep their history and subscriptions
+	Archived bool `json:"archived,omitempty" db:"archived"`
+
+	// Inventory velocity: median hours until similar listings sold out (computed, not stored)
+	SellOutHours float64 `json:"sell_out_hours,omitempty" db:"-"`
+
+	// New prices at third-party retailers (product detail only, not stored on the product)
+	RetailerPrices []RetailerPrice `json:"retailer_prices,omitempty" db:"-"`
 
 	CreatedAt time.Time `json:"created_at" db:"created_at"`
 	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
 }
 
+// KeepRefurbTerms fills refurbishment terms missing from p with those already known
+// for the product, so a listing scrape doesn't wipe what the detail scraper found
+func (p *Product) KeepRefurbTerms(existing *Product) {
+	if p.Grade == "" {
+		p.Grade = existing.Grade
+	}
+	if p.WarrantyMonths == 0 {
+		p.WarrantyMonths = existing.WarrantyMonths
+	}
+	if p.BatteryHealth == 0 {
+		p.BatteryHealth = existing.BatteryHealth
+	}
+}
+
+// KeepDominantColor keeps the image color already extracted for the product, unless
+// the listing now shows a different image
+func (p *Product) KeepDominantColor(existing *Product) {
+	if p.DominantColor == "" && p.ImageURL == existing.ImageURL {
+		p.DominantColor = existing.DominantColor
+	}
+}
+
+// RefurbTermsSummary formats grade, warranty and battery claims for display,
+// e.g. "官方认证翻新 · 1年保修 · 全新电池" (empty if none are known)
+func (p *Product) RefurbTermsSummary() string {
+	var parts []string
+	switch p.Grade {
+	case "":
+	case GradeCertified:
+		parts = append(parts, "官方认证翻新")
+	default:
+		parts = append(parts, p.Grade+"级成色")
+	}
+	if p.WarrantyMonths > 0 {
+		if p.WarrantyMonths%12 == 0 {
+			parts = append(parts, fmt.Sprintf("%d年保修", p.WarrantyMonths/12))
+		} else {
+			parts = append(parts, fmt.Sprintf("%d个月保修", p.WarrantyMonths))
+		}
+	}
+	switch {
+	case p.BatteryHealth >= 100:
+		parts = append(parts, "全新电池")
+	case p.BatteryHealth > 0:
+		parts = append(parts, fmt.Sprintf("电池≥%d%%", p.BatteryHealth))
+	}
+	return strings.Join(parts, " · ")
+}
+
+// GradeCertified is the grade of manufacturer-certified refurbished products
+const GradeCertified = "certified"
+
+// UpsertResult reports what upserting a scraped product changed
+type UpsertResult struct {
+	PriceChanged bool
+	OldPrice     float64 // 0 for a new product
+}
+
 // PriceHistory represents a price change record
 type PriceHistory struct {
 	ProductID string    `json:"product_id"`
@@ -36,13 +121,42 @@ type PriceHistory struct {
 	Discount  float64   `json:"discount"`
 }
 
+// PriceAnnotation is an admin note pinned to a date on price charts (e.g. "双11 促销"),
+// explaining catalog-wide price movements. Empty Category/Region apply to all products.
+type PriceAnnotation struct {
+	ID          string    `json:"id"`
+	Date        string    `json:"date"` // YYYY-MM-DD
+	Label       string    `json:"label"`
+	Description string    `json:"description,omitempty"`
+	Category    string    `json:"category,omitempty"`
+	Region      string    `json:"region,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// AppliesTo reports whether the annotation is relevant for a product
+func (a *PriceAnnotation) AppliesTo(p *Product) bool {
+	return (a.Category == "" || a.Category == p.Category) &&
+		(a.Region == "" || a.Region == p.Region)
+}
+
 // Subscription represents a user subscription for price notifications
 type Subscription struct {
 	ID         string    `json:"id"`
 	ProductID  string    `json:"product_id"`
 	BarkKey    string    `json:"bark_key"`
 	TargetPrice float64  `json:"target_price,omitempty"` // Target price for alert (0 = any drop)
+	QuietHoursStart string `json:"quiet_hours_start,omitempty"` // HH:MM, notifications are held from here...
+	QuietHoursEnd   string `json:"quiet_hours_end,omitempty"`   // ...until here (may wrap past midnight)
+	Timezone        string `json:"timezone,omitempty"`          // IANA zone for quiet hours (default server local)
+	LowStockAlert   bool   `json:"low_stock_alert,omitempty"`   // Also warn when the product is likely to sell out soon
+	BarkServer      string `json:"bark_server,omitempty"`       // Self-hosted Bark server URL (empty = server default)
+	Language        string `json:"language,omitempty"`          // Message language, overrides the owner's preference (zh, zh-HK, en)
+	DeliveryStatus  string `json:"delivery_status,omitempty"`   // delivery_broken when the Bark Key keeps failing (API responses only)
+	Paused            bool      `json:"paused"`                     // Paused by user
+	NotificationCount int       `json:"notification_count"`         // Number of notifications sent
+	LastNotifiedAt    time.Time `json:"last_notified_at,omitempty"` // Last notification time
 	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at,omitempty"`
 }
 
 // NewArrivalSubscription represents a subscription for new product arrival notifications
@@ -59,16 +173,86 @@ type NewArrivalSubscription struct {
 	MaxPrice          float64   `json:"max_price"`           // Maximum price filter (0 = no limit)
 	MinPrice          float64   `json:"min_price"`           // Minimum price filter (0 = no limit)
 	Keywords          []string  `json:"keywords"`            // Product name must contain these keywords
+	Spec              string    `json:"spec,omitempty"`                // Spec combo to watch, e.g. "MacBook Pro 14 M4 Pro 48GB" (see SpecCombo)
 	BarkKey           string    `json:"bark_key"`
 	NotifiedProductIDs string    `json:"notified_product_ids"` // JSON array of product IDs that have been notified
 	Enabled           bool      `json:"enabled"`
 	Paused            bool      `json:"paused"`                        // Paused by user
 	NotificationCount int       `json:"notification_count"`             // Number of notifications sent
 	LastNotifiedAt    time.Time `json:"last_notified_at,omitempty"`    // Last notification time
+	QuietHoursStart   string    `json:"quiet_hours_start,omitempty"`   // HH:MM, notifications are held from here...
+	QuietHoursEnd     string    `json:"quiet_hours_end,omitempty"`     // ...until here (may wrap past midnight)
+	Timezone          string    `json:"timezone,omitempty"`            // IANA zone for quiet hours (default server local)
+	Frequency         string    `json:"frequency,omitempty"`           // instant (default), hourly, daily
+	Stability         string    `json:"stability,omitempty"`           // Filter by price stability: stable, volatile (empty = any)
+	BarkServer        string    `json:"bark_server,omitempty"`         // Self-hosted Bark server URL (empty = server default)
+	Language          string    `json:"language,omitempty"`            // Message language, overrides the owner's preference (zh, zh-HK, en)
+	DeliveryStatus    string    `json:"delivery_status,omitempty"`     // delivery_broken when the Bark Key keeps failing (API responses only)
 	CreatedAt         time.Time `json:"created_at"`
 	UpdatedAt         time.Time `json:"updated_at,omitempty"`
+	DeletedAt         time.Time `json:"-"`                              // Soft-deleted, restorable for SubscriptionRestoreWindow
+}
+
+// SubscriptionRestoreWindow is how long a deleted new arrival subscription can be
+// restored before it is purged for good
+const SubscriptionRestoreWindow = 30 * 24 * time.Hour
+
+// Digest frequencies for new arrival subscriptions
+const (
+	FrequencyInstant = "instant"
+	FrequencyHourly  = "hourly"
+	FrequencyDaily   = "daily"
+)
+
+// DigestInterval returns how long matches are buffered for a frequency (0 = send instantly)
+func DigestInterval(frequency string) time.Duration {
+	switch frequency {
+	case FrequencyHourly:
+		return time.Hour
+	case FrequencyDaily:
+		return 24 * time.Hour
+	default:
+		return 0
+	}
+}
+
+// ValidFrequency reports whether frequency is a supported digest mode (empty = instant)
+func ValidFrequency(frequency string) bool {
+	switch frequency {
+	case "", FrequencyInstant, FrequencyHourly, FrequencyDaily:
+		return true
+	}
+	return false
+}
+
+// PendingNotification is a new arrival match buffered for a digest subscription
+type PendingNotification struct {
+	SubscriptionID  string    `json:"subscription_id"`
+	ProductID       string    `json:"product_id"`
+	ProductName     string    `json:"product_name"`
+	ProductCategory string    `json:"product_category"`
+	ProductPrice    float64   `json:"product_price"`
+	ProductImageURL string    `json:"product_image_url"`
+	ProductURL      string    `json:"product_url"`
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+// CatchUpChange is one change found by the first scrape after downtime. Such changes
+// are sent as a single "期间变化汇总" push per Bark Key instead of one push each.
+type CatchUpChange struct {
+	Kind     string   // ChangePrice, ChangeNewArrival, ChangeRestock or ChangeSoldOut
+	Product  *Product
+	OldPrice float64 // price before the downtime, for ChangePrice
 }
 
+// Kinds of catch-up changes
+const (
+	ChangePrice      = "price_change"
+	ChangeNewArrival = "new_arrival"
+	ChangeRestock    = "restock"
+	ChangeSoldOut    = "sold_out"
+)
+
 // NotificationHistory represents a record of sent notification
 type NotificationHistory struct {
 	ID               string    `json:"id"`
@@ -79,15 +263,56 @@ type NotificationHistory struct {
 	ProductPrice     float64   `json:"product_price"`
 	ProductImageURL  string    `json:"product_image_url"`
 	ProductSpecs     string    `json:"product_specs"`     // JSON: parsed specs
-	NotificationType string    `json:"notification_type"` // new_arrival, price_drop
+	NotificationType string    `json:"notification_type"` // new_arrival, price_drop, stock_change, restock
 	Status           string    `json:"status"`            // sent, failed
 	ErrorMessage     string    `json:"error_message,omitempty"`
+	Title            string    `json:"title,omitempty"`          // Rendered notification title
+	Body             string    `json:"body,omitempty"`           // Rendered notification body
+	ChannelParams    string    `json:"channel_params,omitempty"` // JSON: channel parameters (url, icon, sound, group)
+	Channel          string    `json:"channel,omitempty"`        // Delivery channel, e.g. bark
+	DeliveryLatencyMs *int64   `json:"delivery_latency_ms,omitempty"` // Event detection to successful delivery (nil until delivered)
 	BarkKey          string    `json:"-"`                 // Full key for filtering, not exposed in JSON
 	BarkKeyMasked    string    `json:"bark_key_masked"`
 	CreatedAt        time.Time `json:"created_at"`
 	ReadAt           *time.Time `json:"read_at,omitempty"`
 }
 
+// UserPreferences holds per-user (per Bark Key) settings
+type UserPreferences struct {
+	BarkKey         string    `json:"-"`
+	PausedAll       bool      `json:"paused_all"`                  // Kill switch: all notifications for this key are paused
+	DefaultChannel  string    `json:"default_channel,omitempty"`   // bark (default), telegram, email
+	QuietHoursStart string    `json:"quiet_hours_start,omitempty"` // Default quiet hours for subscriptions without their own
+	QuietHoursEnd   string    `json:"quiet_hours_end,omitempty"`
+	Timezone        string    `json:"timezone,omitempty"`
+	Language        string    `json:"language,omitempty"`         // zh (default), zh-HK, en
+	CurrencyDisplay string    `json:"currency_display,omitempty"` // symbol (default, ¥6999) or code (6999 CNY)
+	DigestFrequency string    `json:"digest_frequency,omitempty"` // Default frequency of newly created new arrival subscriptions
+	UpdatedAt       time.Time `json:"updated_at,omitempty"`
+}
+
+// RegionDeletion describes a region purge that can be undone until ExpiresAt.
+// Stale product cleanups are recorded the same way, with StaleBefore set and no Region.
+// Returned with an empty ID for dry runs.
+type RegionDeletion struct {
+	ID            string     `json:"id,omitempty"`
+	Region        string     `json:"region"`
+	StaleBefore   *time.Time `json:"stale_before,omitempty"` // Products not updated by a scrape since then
+	Products      int        `json:"products"`
+	PriceHistory  int        `json:"price_history"`
+	Subscriptions int        `json:"subscriptions"`
+	Events        int        `json:"events"`
+	DeletedAt     time.Time  `json:"deleted_at"`
+	ExpiresAt     time.Time  `json:"expires_at"`
+}
+
+// KeyMigrationResult reports how many records were moved to a new Bark Key
+type KeyMigrationResult struct {
+	Subscriptions           int `json:"subscriptions"`
+	NewArrivalSubscriptions int `json:"new_arrival_subscriptions"`
+	NotificationHistory     int `json:"notification_history"`
+}
+
 // ParsedSpecs represents parsed product specifications
 type ParsedSpecs struct {
 	Chip         string `json:"chip,omitempty"`         // M1 Pro, M2 Max, etc.
@@ -97,15 +322,26 @@ type ParsedSpecs struct {
 	Color        string `json:"color,omitempty"`         // 深空黑, 银色, etc.
 }
 
-// ScraperStatus represents the scraper health status
+// ScraperStatus represents the scraper health status, overall or of one region
 type ScraperStatus struct {
+	Region           string    `json:"region,omitempty"` // empty for the overall status
 	LastScrapeTime   time.Time `json:"last_scrape_time"`
-	LastScrapeStatus string    `json:"last_scrape_status"` // success, failed, running, never
+	LastScrapeStatus string    `json:"last_scrape_status"` // success, partial (some regions failed), failed, running, never
 	LastScrapeError  string    `json:"last_scrape_error,omitempty"`
 	ProductsScraped  int       `json:"products_scraped"`
 	Duration         int64     `json:"duration_ms"`
 }
 
+// StorageStatus represents the health of the data directory
+type StorageStatus struct {
+	Writable  bool      `json:"writable"`
+	ReadOnly  bool      `json:"read_only"`           // API is serving reads only
+	FreeBytes uint64    `json:"free_bytes"`          // 0 when unknown
+	MinFree   uint64    `json:"min_free_bytes"`
+	Reason    string    `json:"reason,omitempty"`
+	CheckedAt time.Time `json:"checked_at"`
+}
+
 // Stats represents system statistics
 type Stats struct {
 	TotalProducts      int            `json:"total_products"`
@@ -114,13 +350,150 @@ type Stats struct {
 	LastScrapeTime     time.Time      `json:"last_scrape_time"`
 	TotalSubscriptions int            `json:"total_subscriptions"`
 	ScraperStatus      *ScraperStatus `json:"scraper_status,omitempty"`
+	Retention          *RetentionStats `json:"retention,omitempty"`
+	DeliveryLatency    []ChannelLatency `json:"delivery_latency"` // per channel, deliveries of the last DeliveryLatencyWindow
+}
+
+// RetentionPolicy caps how many rows are kept per product / Bark Key; 0 means unlimited.
+// Price history older than RawHistoryDays is downsampled to one row per product and day.
+type RetentionPolicy struct {
+	MaxHistoryPerProduct   int `json:"max_history_per_product"`
+	MaxNotificationsPerKey int `json:"max_notifications_per_key"`
+	RawHistoryDays         int `json:"raw_history_days"` // 0 = keep every raw point
+}
+
+// RetentionStats reports the active retention policy, current row counts and
+// how many rows have been evicted or compacted since the process started
+type RetentionStats struct {
+	RetentionPolicy
+	HistoryRows          int       `json:"history_rows"`
+	DailyHistoryRows     int       `json:"daily_history_rows"`
+	NotificationRows     int       `json:"notification_rows"`
+	EvictedHistory       int64     `json:"evicted_history"`
+	EvictedNotifications int64     `json:"evicted_notifications"`
+	CompactedHistory     int64     `json:"compacted_history"` // raw points folded into daily rows
+	LastEvictionAt       time.Time `json:"last_eviction_at,omitempty"`
+	LastCompactionAt     time.Time `json:"last_compaction_at,omitempty"`
+}
+
+// DailyPrice is one product's downsampled price history for a day (server local
+// time): the lowest, highest and last price recorded that day
+type DailyPrice struct {
+	ProductID     string    `json:"product_id"`
+	Date          string    `json:"date"` // YYYY-MM-DD
+	MinPrice      float64   `json:"min_price"`
+	MaxPrice      float64   `json:"max_price"`
+	ClosePrice    float64   `json:"close_price"`
+	CloseDiscount float64   `json:"close_discount"`
+	CloseAt       time.Time `json:"close_at"` // when the closing price was recorded
 }
 
-// GenerateID creates a unique product ID based on category and specs
-func GenerateID(category, specs string) string {
-	// Simple hash-based ID generation
-	// In production, you might use a more sophisticated approach
-	return "cn:" + category + ":" + hashString(specs)
+// DailyCategoryStats is one day's aggregate for a category/region pair,
+// recorded after each scrape (the last scrape of the day wins)
+type DailyCategoryStats struct {
+	Date         string  `json:"date"` // YYYY-MM-DD, server local time
+	Category     string  `json:"category"`
+	Region       string  `json:"region"`
+	ProductCount int     `json:"product_count"` // listings not sold out
+	AvgPrice     float64 `json:"avg_price"`
+	AvgDiscount  float64 `json:"avg_discount"`
+	NewListings  int     `json:"new_listings"` // products first seen that day
+}
+
+// StatsSnapshot is one day's snapshot of the catalog of a region, or of all
+// regions when Region is empty, recorded alongside DailyCategoryStats
+type StatsSnapshot struct {
+	Date              string                      `json:"date"` // YYYY-MM-DD, server local time
+	Region            string                      `json:"region,omitempty"`
+	TotalProducts     int                         `json:"total_products"`
+	AvailableProducts int                         `json:"available_products"`
+	SoldOutProducts   int                         `json:"sold_out_products"`
+	AvgDiscount       float64                     `json:"avg_discount"` // over listings not sold out
+	NewListings       int                         `json:"new_listings"` // products first seen that day
+	Subscriptions     int                         `json:"subscriptions"`
+	Categories        map[string]CategorySnapshot `json:"categories"`
+}
+
+// CategorySnapshot is a category's share of a StatsSnapshot
+type CategorySnapshot struct {
+	Products    int `json:"products"` // listings not sold out
+	NewListings int `json:"new_listings"`
+}
+
+// SplitAccessoryCategories have their own category now; they used to be filed
+// under Accessory
+var SplitAccessoryCategories = []string{"AirPods", "HomePod", "Apple TV", "Display"}
+
+// ProductCategory returns the category a product listed on a category page is
+// filed under: AirPods, HomePod, Apple TV and displays get their own, anything
+// else from the accessories page is an Accessory
+func ProductCategory(page, name string) string {
+	lower := strings.ToLower(name)
+	if strings.Contains(lower, "studio display") || strings.Contains(lower, "pro display") {
+		return "Display"
+	}
+
+	switch page {
+	case "AirPods", "HomePod", "Apple TV":
+		return page
+	case "Accessories":
+		switch {
+		case strings.Contains(lower, "airpods"):
+			return "AirPods"
+		case strings.Contains(lower, "homepod"):
+			return "HomePod"
+		case strings.Contains(lower, "apple tv"):
+			return "Apple TV"
+		}
+		return "Accessory"
+	}
+	return page
+}
+
+// RefiledCategory returns the category p belongs in, from the category page its
+// ID was generated from. Products scraped before AirPods, HomePod, Apple TV and
+// displays had their own categories are still filed as Accessory.
+func (p *Product) RefiledCategory() string {
+	parts := strings.SplitN(p.ID, ":", 3)
+	if len(parts) != 3 {
+		return p.Category
+	}
+	return ProductCategory(parts[1], p.Name)
+}
+
+// GenerateID creates a product ID from the category page and the Apple part
+// number, so the ID survives changes to the listing title. Listings without a
+// part number fall back to hashing the title.
+func GenerateID(category, partNumber, title string) string {
+	if partNumber = strings.ToUpper(strings.TrimSpace(partNumber)); partNumber != "" {
+		return "cn:" + category + ":" + hashString(partNumber)
+	}
+	return "cn:" + category + ":" + hashString(title)
+}
+
+// StableID returns the ID GenerateID gives p now, which differs from p.ID for
+// products scraped while IDs hashed the part number together with the title
+func (p *Product) StableID() string {
+	parts := strings.SplitN(p.ID, ":", 3)
+	if len(parts) != 3 || strings.TrimSpace(p.PartNumber) == "" {
+		return p.ID
+	}
+	return GenerateID(parts[1], p.PartNumber, p.Name)
+}
+
+// LegacyIDs returns the IDs p may have had while IDs hashed the part number
+// together with the listing title, which is the name with or without the
+// "翻新 " (refurbished) prefix
+func (p *Product) LegacyIDs() []string {
+	parts := strings.SplitN(p.ID, ":", 3)
+	if len(parts) != 3 || p.PartNumber == "" {
+		return nil
+	}
+	prefix := parts[0] + ":" + parts[1] + ":"
+	return []string{
+		prefix + hashString(p.PartNumber+"翻新 "+p.Name),
+		prefix + hashString(p.PartNumber+p.Name),
+	}
 }
 
 func hashString(s string) string {
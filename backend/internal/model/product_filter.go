@@ -0,0 +1,185 @@
+package model
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// ProductFilter narrows a product listing by category, region, the specs
+// extracted from each product (see SpecIndex) and price. Empty fields don't
+// filter; a list matches any of its values, ignoring case.
+type ProductFilter struct {
+	Category    string
+	Region      string
+	Chips       []string
+	Memories    []string
+	Storages    []string
+	Models      []string
+	ScreenSizes []string
+	Colors      []string
+	MinPrice    float64 // 0 = no lower bound
+	MaxPrice    float64 // 0 = no upper bound
+}
+
+// HasSpecs reports whether the filter narrows by more than category and region
+func (f ProductFilter) HasSpecs() bool {
+	return len(f.Chips) > 0 || len(f.Memories) > 0 || len(f.Storages) > 0 || len(f.Models) > 0 ||
+		len(f.ScreenSizes) > 0 || len(f.Colors) > 0 || f.MinPrice > 0 || f.MaxPrice > 0
+}
+
+// ProductSpecIndex holds the specs products are filtered by
+type ProductSpecIndex struct {
+	Chip       string
+	Memory     string
+	Storage    string
+	ScreenSize string
+	Color      string
+	Model      string
+}
+
+// SpecIndex extracts the filterable specs: chip, memory, storage, screen size and
+// color from SpecsDetail, which the listing scraper fills from the parsed title
+// (scraper.ParseProductSpecs), and the model line from the name. Chip, memory,
+// storage and screen size missing from SpecsDetail (e.g. English titles the
+// scraper doesn't parse) are read from the name and specs with ParseSpecCombo,
+// in the scraper's format.
+func (p *Product) SpecIndex() ProductSpecIndex {
+	var specs ParsedSpecs
+	if p.SpecsDetail != "" {
+		_ = json.Unmarshal([]byte(p.SpecsDetail), &specs)
+	}
+	index := ProductSpecIndex{
+		Chip:       specs.Chip,
+		Memory:     specs.Memory,
+		Storage:    specs.Storage,
+		ScreenSize: specs.ScreenSize,
+		Color:      specs.Color,
+		Model:      ModelFromName(p.Name, p.Category),
+	}
+	if index.Chip == "" || index.Memory == "" || index.Storage == "" || index.ScreenSize == "" {
+		combo := ParseSpecCombo(p.Name + " " + p.Specs)
+		if index.Chip == "" {
+			index.Chip = combo.Chip
+		}
+		if index.Memory == "" {
+			index.Memory = combo.Memory
+		}
+		if index.Storage == "" {
+			index.Storage = combo.Storage
+		}
+		if index.ScreenSize == "" && combo.ScreenSize != "" {
+			index.ScreenSize = combo.ScreenSize + "英寸"
+		}
+	}
+	return index
+}
+
+// FilterOptions lists the distinct spec values products can be filtered by
+type FilterOptions struct {
+	Chips       []string `json:"chips"`
+	Storages    []string `json:"storages"`
+	Memories    []string `json:"memories"`
+	ScreenSizes []string `json:"screen_sizes"`
+	Colors      []string `json:"colors"`
+	Models      []string `json:"models"`
+}
+
+// CollectFilterOptions gathers the distinct spec values of products, unsorted
+func CollectFilterOptions(products []*Product) FilterOptions {
+	seen := make(map[*[]string]map[string]bool)
+	opts := FilterOptions{
+		Chips: []string{}, Storages: []string{}, Memories: []string{},
+		ScreenSizes: []string{}, Colors: []string{}, Models: []string{},
+	}
+	add := func(values *[]string, value string) {
+		if value == "" {
+			return
+		}
+		if seen[values] == nil {
+			seen[values] = make(map[string]bool)
+		}
+		if !seen[values][value] {
+			seen[values][value] = true
+			*values = append(*values, value)
+		}
+	}
+	for _, p := range products {
+		specs := p.SpecIndex()
+		add(&opts.Chips, specs.Chip)
+		add(&opts.Storages, specs.Storage)
+		add(&opts.Memories, specs.Memory)
+		add(&opts.ScreenSizes, specs.ScreenSize)
+		add(&opts.Colors, specs.Color)
+		add(&opts.Models, specs.Model)
+	}
+	return opts
+}
+
+// ModelFromName returns the model line of a product, e.g. "MacBook Air", from its
+// name, "" when the category has no model lines or none matches
+func ModelFromName(name, category string) string {
+	nameLower := strings.ToLower(name)
+	switch category {
+	case "Mac":
+		switch {
+		case strings.Contains(nameLower, "macbook air"):
+			return "MacBook Air"
+		case strings.Contains(nameLower, "macbook pro"):
+			return "MacBook Pro"
+		case strings.Contains(nameLower, "mac mini"):
+			return "Mac mini"
+		case strings.Contains(nameLower, "mac studio"):
+			return "Mac Studio"
+		case strings.Contains(nameLower, "imac"):
+			return "iMac"
+		case strings.Contains(nameLower, "mac pro"):
+			return "Mac Pro"
+		}
+	case "iPad":
+		switch {
+		case strings.Contains(nameLower, "ipad pro"):
+			return "iPad Pro"
+		case strings.Contains(nameLower, "ipad air"):
+			return "iPad Air"
+		case strings.Contains(nameLower, "ipad mini"):
+			return "iPad mini"
+		case strings.Contains(nameLower, "ipad"):
+			return "iPad"
+		}
+	case "Watch":
+		switch {
+		case strings.Contains(nameLower, "ultra"):
+			return "Apple Watch Ultra"
+		case strings.Contains(nameLower, "series"):
+			return "Apple Watch Series"
+		case strings.Contains(nameLower, "se"):
+			return "Apple Watch SE"
+		}
+	case "AirPods":
+		switch {
+		case strings.Contains(nameLower, "airpods pro"):
+			return "AirPods Pro"
+		case strings.Contains(nameLower, "airpods max"):
+			return "AirPods Max"
+		case strings.Contains(nameLower, "airpods"):
+			return "AirPods"
+		}
+	case "HomePod":
+		switch {
+		case strings.Contains(nameLower, "homepod mini"):
+			return "HomePod mini"
+		case strings.Contains(nameLower, "homepod"):
+			return "HomePod"
+		}
+	case "Apple TV":
+		return "Apple TV"
+	case "Display":
+		switch {
+		case strings.Contains(nameLower, "studio display"):
+			return "Studio Display"
+		case strings.Contains(nameLower, "pro display"):
+			return "Pro Display XDR"
+		}
+	}
+	return ""
+}
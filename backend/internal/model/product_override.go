@@ -0,0 +1,49 @@
+package model
+
+import "time"
+
+// EditableProductFields are the product fields an admin may correct by hand,
+// by their JSON names
+var EditableProductFields = []string{"name", "category", "image_url", "product_url", "specs", "description", "part_number"}
+
+// ProductOverride holds the fields an admin corrected on a product and pinned,
+// so the next scrapes keep the correction instead of the scraped value
+type ProductOverride struct {
+	ProductID string            `json:"product_id"`
+	Fields    map[string]string `json:"fields"` // JSON field name -> value
+	UpdatedAt time.Time         `json:"updated_at"`
+}
+
+// Apply sets the pinned fields on p
+func (o *ProductOverride) Apply(p *Product) {
+	for name, value := range o.Fields {
+		p.SetField(name, value)
+	}
+}
+
+// SetField sets one of EditableProductFields by JSON name, reporting whether the
+// field is editable. A new image drops the dominant color extracted from the old one.
+func (p *Product) SetField(name, value string) bool {
+	switch name {
+	case "name":
+		p.Name = value
+	case "category":
+		p.Category = value
+	case "image_url":
+		if p.ImageURL != value {
+			p.DominantColor = ""
+		}
+		p.ImageURL = value
+	case "product_url":
+		p.ProductURL = value
+	case "specs":
+		p.Specs = value
+	case "description":
+		p.Description = value
+	case "part_number":
+		p.PartNumber = value
+	default:
+		return false
+	}
+	return true
+}
@@ -0,0 +1,96 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// quietHoursLayout is the HH:MM format of quiet hour boundaries
+const quietHoursLayout = "15:04"
+
+// ValidateQuietHours checks quiet hour settings. Leaving both start and end empty
+// disables quiet hours; setting only one of them is an error.
+func ValidateQuietHours(start, end, timezone string) error {
+	if start == "" && end == "" {
+		return nil
+	}
+	if start == "" || end == "" {
+		return errors.New("quiet_hours_start and quiet_hours_end must be set together")
+	}
+	if _, err := time.Parse(quietHoursLayout, start); err != nil {
+		return fmt.Errorf("invalid quiet_hours_start %q, expected HH:MM", start)
+	}
+	if _, err := time.Parse(quietHoursLayout, end); err != nil {
+		return fmt.Errorf("invalid quiet_hours_end %q, expected HH:MM", end)
+	}
+	if start == end {
+		return errors.New("quiet_hours_start and quiet_hours_end must differ")
+	}
+	if timezone != "" {
+		if _, err := time.LoadLocation(timezone); err != nil {
+			return fmt.Errorf("unknown timezone %q", timezone)
+		}
+	}
+	return nil
+}
+
+// QuietHoursUntil reports whether now falls inside the quiet window and, if so,
+// when that window ends. Invalid or empty settings never silence notifications.
+func QuietHoursUntil(start, end, timezone string, now time.Time) (time.Time, bool) {
+	if start == "" || end == "" {
+		return time.Time{}, false
+	}
+	startT, err := time.Parse(quietHoursLayout, start)
+	if err != nil {
+		return time.Time{}, false
+	}
+	endT, err := time.Parse(quietHoursLayout, end)
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	loc := time.Local
+	if timezone != "" {
+		if l, err := time.LoadLocation(timezone); err == nil {
+			loc = l
+		}
+	}
+
+	local := now.In(loc)
+	minutes := local.Hour()*60 + local.Minute()
+	startMin := startT.Hour()*60 + startT.Minute()
+	endMin := endT.Hour()*60 + endT.Minute()
+
+	var quiet bool
+	if startMin < endMin {
+		quiet = minutes >= startMin && minutes < endMin
+	} else {
+		// Window wraps past midnight, e.g. 22:00-08:00
+		quiet = minutes >= startMin || minutes < endMin
+	}
+	if !quiet {
+		return time.Time{}, false
+	}
+
+	until := time.Date(local.Year(), local.Month(), local.Day(), endT.Hour(), endT.Minute(), 0, 0, loc)
+	if !until.After(local) {
+		until = until.AddDate(0, 0, 1)
+	}
+	return until, true
+}
+
+// QuietUntil reports whether the subscription is in quiet hours at now and when they end
+func (s *Subscription) QuietUntil(now time.Time) (time.Time, bool) {
+	return QuietHoursUntil(s.QuietHoursStart, s.QuietHoursEnd, s.Timezone, now)
+}
+
+// QuietUntil reports whether the subscription is in quiet hours at now and when they end
+func (s *NewArrivalSubscription) QuietUntil(now time.Time) (time.Time, bool) {
+	return QuietHoursUntil(s.QuietHoursStart, s.QuietHoursEnd, s.Timezone, now)
+}
+
+// QuietUntil reports whether the user's default quiet hours cover now and when they end
+func (p *UserPreferences) QuietUntil(now time.Time) (time.Time, bool) {
+	return QuietHoursUntil(p.QuietHoursStart, p.QuietHoursEnd, p.Timezone, now)
+}
@@ -0,0 +1,8 @@
+package model
+
+// Readiness reports whether the server can take traffic, with the outcome of each
+// check by name: "ok" or why it failed
+type Readiness struct {
+	Ready  bool              `json:"ready"`
+	Checks map[string]string `json:"checks"`
+}
@@ -0,0 +1,67 @@
+package model
+
+import (
+	"strings"
+	"time"
+)
+
+// Region is an Apple refurbished storefront the scraper can crawl
+type Region struct {
+	Code       string    `json:"code"` // cn, hk
+	Name       string    `json:"name"`
+	BaseURL    string    `json:"base_url"`             // refurbished store root, e.g. https://www.apple.com.cn/shop/refurbished
+	Currency   string    `json:"currency"`             // ISO 4217, e.g. CNY
+	Categories []string  `json:"categories,omitempty"` // category pages to scrape (empty = DefaultRegionCategories)
+	Enabled    bool      `json:"enabled"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// RegionCategoryPages maps the categories a storefront can sell to their page
+// under the refurbished store root
+var RegionCategoryPages = map[string]string{
+	"Mac":         "/mac",
+	"iPad":        "/ipad",
+	"iPhone":      "/iphone",
+	"Watch":       "/watch",
+	"AirPods":     "/airpods",
+	"HomePod":     "/homepod",
+	"Apple TV":    "/appletv",
+	"Accessories": "/accessories",
+}
+
+// DefaultRegionCategories are scraped in regions that don't list their own: what
+// the mainland China store sells, which has no refurbished iPhone
+var DefaultRegionCategories = []string{"Mac", "iPad", "Watch", "AirPods", "HomePod", "Accessories"}
+
+// ScrapeCategories returns the categories to scrape in the region
+func (r *Region) ScrapeCategories() []string {
+	if len(r.Categories) > 0 {
+		return r.Categories
+	}
+	return DefaultRegionCategories
+}
+
+// NormalizeRegionCategory returns the RegionCategoryPages name of category,
+// matched case-insensitively
+func NormalizeRegionCategory(category string) (string, bool) {
+	category = strings.TrimSpace(category)
+	for name := range RegionCategoryPages {
+		if strings.EqualFold(name, category) {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+// DefaultRegions seeds an empty registry: mainland China is scraped, Hong Kong
+// and the US (which also sells refurbished iPhone) are known but off until enabled
+func DefaultRegions() []*Region {
+	now := time.Now()
+	return []*Region{
+		{Code: "cn", Name: "中国大陆", BaseURL: "https://www.apple.com.cn/shop/refurbished", Currency: "CNY", Enabled: true, CreatedAt: now, UpdatedAt: now},
+		{Code: "hk", Name: "香港", BaseURL: "https://www.apple.com/hk/shop/refurbished", Currency: "HKD", Enabled: false, CreatedAt: now, UpdatedAt: now},
+		{Code: "us", Name: "美国", BaseURL: "https://www.apple.com/shop/refurbished", Currency: "USD", Enabled: false, CreatedAt: now, UpdatedAt: now,
+			Categories: []string{"Mac", "iPad", "iPhone", "Watch", "AirPods", "HomePod", "Apple TV", "Accessories"}},
+	}
+}
@@ -0,0 +1,20 @@
+package model
+
+import "time"
+
+// ReplicationBatch is the catalog change set a mirror pulls from GET /api/replicate.
+// Times are Unix seconds; a mirror passes Until as since on its next pull.
+type ReplicationBatch struct {
+	Since          int64          `json:"since"`
+	Until          int64          `json:"until"`
+	LastScrapeTime time.Time      `json:"last_scrape_time"`
+	ProductIDs     []string       `json:"product_ids"`   // every product upstream, archived ones included
+	Products       []*Product     `json:"products"`      // products updated in (since, until]
+	PriceHistory   []PriceHistory `json:"price_history"` // history of those products recorded in (since, until]
+	Events         []ProductEvent `json:"events"`        // events of those products in (since, until]
+}
+
+// InReplicationWindow reports whether t falls in the batch's (since, until] window
+func (b *ReplicationBatch) InReplicationWindow(t time.Time) bool {
+	return t.Unix() > b.Since && t.Unix() <= b.Until
+}
@@ -0,0 +1,29 @@
+package model
+
+import "time"
+
+// Retailers with a price connector
+const (
+	RetailerJD     = "jd"
+	RetailerAmazon = "amazon"
+)
+
+// RetailerPrice is what a third-party retailer asks for the new product a
+// refurbished listing corresponds to
+type RetailerPrice struct {
+	Retailer  string    `json:"retailer"` // RetailerJD, RetailerAmazon
+	Price     float64   `json:"price"`
+	URL       string    `json:"url,omitempty"`
+	FetchedAt time.Time `json:"fetched_at"`
+}
+
+// CheapestRetailerPrice returns the lowest of prices, or nil when there are none
+func CheapestRetailerPrice(prices []RetailerPrice) *RetailerPrice {
+	var cheapest *RetailerPrice
+	for i := range prices {
+		if prices[i].Price > 0 && (cheapest == nil || prices[i].Price < cheapest.Price) {
+			cheapest = &prices[i]
+		}
+	}
+	return cheapest
+}
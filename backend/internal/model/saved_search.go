@@ -0,0 +1,63 @@
+package model
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SavedSearch is a product list filter set stored under a short slug, so a view
+// like "M2 Max under ¥15000" can be bookmarked and shared. Anyone holding the
+// slug can resolve it; saved searches are never changed once created.
+type SavedSearch struct {
+	Slug        string    `json:"slug"`
+	Name        string    `json:"name,omitempty"`
+	Category    string    `json:"category,omitempty"`
+	Region      string    `json:"region,omitempty"`
+	Chips       []string  `json:"chips,omitempty"`
+	Memories    []string  `json:"memories,omitempty"`
+	Storages    []string  `json:"storages,omitempty"`
+	Models      []string  `json:"models,omitempty"`
+	ScreenSizes []string  `json:"screen_sizes,omitempty"`
+	Colors      []string  `json:"colors,omitempty"`
+	MinPrice    float64   `json:"min_price,omitempty"`
+	MaxPrice    float64   `json:"max_price,omitempty"`
+	StockStatus string    `json:"stock_status,omitempty"`
+	Sort        string    `json:"sort,omitempty"`  // price, discount, score, created, savings
+	Order       string    `json:"order,omitempty"` // asc, desc
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// Query returns the GET /api/products query string that shows the search
+func (s *SavedSearch) Query() string {
+	v := url.Values{}
+	set := func(key, value string) {
+		if value != "" {
+			v.Set(key, value)
+		}
+	}
+	set("category", s.Category)
+	set("region", s.Region)
+	set("chip", strings.Join(s.Chips, ","))
+	set("memory", strings.Join(s.Memories, ","))
+	set("storage", strings.Join(s.Storages, ","))
+	set("model", strings.Join(s.Models, ","))
+	set("screen_size", strings.Join(s.ScreenSizes, ","))
+	set("color", strings.Join(s.Colors, ","))
+	if s.MinPrice > 0 {
+		v.Set("min_price", strconv.FormatFloat(s.MinPrice, 'f', -1, 64))
+	}
+	if s.MaxPrice > 0 {
+		v.Set("max_price", strconv.FormatFloat(s.MaxPrice, 'f', -1, 64))
+	}
+	set("stock_status", s.StockStatus)
+	set("sort", s.Sort)
+	set("order", s.Order)
+	return v.Encode()
+}
+
+// IsEmpty reports whether the search has no filter and no sort, i.e. shows the default list
+func (s *SavedSearch) IsEmpty() bool {
+	return s.Query() == ""
+}
@@ -0,0 +1,60 @@
+package model
+
+import "time"
+
+// Value score components
+const (
+	ScoreDiscount      = "discount"
+	ScoreTrend         = "trend"
+	ScoreStock         = "stock"
+	ScorePricePosition = "price_position"
+	ScoreAge           = "age"
+	ScoreRetailer      = "retailer"
+)
+
+// ScoreComponent is the points one factor added to a value score
+type ScoreComponent struct {
+	Name   string  `json:"name"`
+	Points float64 `json:"points"`
+	Max    float64 `json:"max"` // most points the component can award
+	Reason string  `json:"reason"`
+}
+
+// ScoreBreakdown is a value score and how it was reached: the base score plus
+// each component's points, clamped to 0-100
+type ScoreBreakdown struct {
+	ProductID  string           `json:"product_id"`
+	Score      float64          `json:"score"`
+	Base       float64          `json:"base"`
+	Components []ScoreComponent `json:"components"`
+}
+
+// NewScoreBreakdown starts a breakdown for productID at the base score
+func NewScoreBreakdown(productID string, base float64) *ScoreBreakdown {
+	return &ScoreBreakdown{ProductID: productID, Score: base, Base: base, Components: []ScoreComponent{}}
+}
+
+// Add records a component and adds its points to the score
+func (b *ScoreBreakdown) Add(name string, points, max float64, reason string) {
+	b.Components = append(b.Components, ScoreComponent{Name: name, Points: points, Max: max, Reason: reason})
+	b.Score += points
+}
+
+// Finish clamps the score to 0-100 and returns it
+func (b *ScoreBreakdown) Finish() float64 {
+	if b.Score > 100 {
+		b.Score = 100
+	}
+	if b.Score < 0 {
+		b.Score = 0
+	}
+	return b.Score
+}
+
+// ScoreRecompute records a run of the periodic recomputation of value scores and
+// price statistics, which keeps the age component current between price changes
+type ScoreRecompute struct {
+	RanAt    time.Time `json:"ran_at"`
+	Products int       `json:"products"`
+	Duration int64     `json:"duration_ms"`
+}
@@ -0,0 +1,33 @@
+package model
+
+import "time"
+
+// Scrape progress event types
+const (
+	ScrapeEventStarted  = "started"  // a scrape cycle began
+	ScrapeEventCategory = "category" // a category page of a region was fetched
+	ScrapeEventRegion   = "region"   // all category pages of a region are done
+	ScrapeEventUpserted = "upserted" // scraped products written to the store
+	ScrapeEventFinished = "finished" // the cycle ended; Status says how
+	ScrapeEventDetail   = "detail"   // async detail fetching after the cycle
+)
+
+// ScrapeProgress is one progress event of a running scrape, streamed to the
+// admin UI. Fields not relevant to the event type are left empty.
+type ScrapeProgress struct {
+	Type     string    `json:"type"`
+	RunID    string    `json:"run_id,omitempty"`
+	Region   string    `json:"region,omitempty"`
+	Category string    `json:"category,omitempty"`
+	Products int       `json:"products,omitempty"` // found (category, region, finished) or upserted
+	Total    int       `json:"total,omitempty"`    // products to upsert
+	Status   string    `json:"status,omitempty"`   // finished: success, partial, failed
+	Error    string    `json:"error,omitempty"`
+	Duration int64     `json:"duration_ms,omitempty"`
+	Time     time.Time `json:"time"`
+
+	// Detail queue: products waiting, and processed/failed since the detail scraper started
+	DetailQueue     int   `json:"detail_queue,omitempty"`
+	DetailProcessed int64 `json:"detail_processed,omitempty"`
+	DetailFailed    int64 `json:"detail_failed,omitempty"`
+}
@@ -0,0 +1,119 @@
+package model
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrScrapeRunning is returned when a scrape is triggered while another run is in flight
+var ErrScrapeRunning = errors.New("a scrape is already running")
+
+// Scrape run triggers
+const (
+	ScrapeTriggerSchedule = "schedule" // the scheduler's interval
+	ScrapeTriggerManual   = "manual"   // POST /api/admin/scrape
+	ScrapeTriggerDryRun   = "dry_run"  // POST /api/admin/scrape/dry-run, nothing is written
+)
+
+// ScrapeRun records one scrape cycle: how it ended and, when it scraped
+// anything, what it saw per region/category. Runs in flight or waiting to start
+// are reported with Status "running" or "queued".
+type ScrapeRun struct {
+	ID           string              `json:"id"`
+	Trigger      string              `json:"trigger"`
+	Status       string              `json:"status"` // success, partial, failed, cancelled
+	Error        string              `json:"error,omitempty"`
+	StartedAt    time.Time           `json:"started_at"`
+	FinishedAt   time.Time           `json:"finished_at"`
+	Duration     int64               `json:"duration_ms"`
+	ProductCount int                 `json:"product_count"`
+	Categories   []ScrapeRunCategory `json:"categories"`
+	Pages        []ScrapeRunPage     `json:"pages"`
+	Scope        *ScrapeScope        `json:"scope,omitempty"` // nil when everything was scraped
+}
+
+// ScrapeScope narrows a scrape run to one region and/or one category page (a
+// RegionCategoryPages name); empty fields don't narrow
+type ScrapeScope struct {
+	Region   string `json:"region,omitempty"`
+	Category string `json:"category,omitempty"`
+}
+
+// All reports whether the scope covers every region and category
+func (s ScrapeScope) All() bool {
+	return s.Region == "" && s.Category == ""
+}
+
+// ScrapeRunCategory is the product count of one region/category in a scrape run,
+// and how the catalog changed compared to before the run
+type ScrapeRunCategory struct {
+	Region    string `json:"region"`
+	Category  string `json:"category"`
+	Total     int    `json:"total"`
+	Available int    `json:"available"` // listed and not sold out
+	New       int    `json:"new"`       // products seen for the first time
+	Changed   int    `json:"changed"`   // products whose price changed
+	Removed   int    `json:"removed"`   // products no longer listed, marked sold out
+}
+
+// ScrapeRunPage is the fetch of one category page of a region in a scrape run.
+// Pages are named after the storefront (Accessories), the products they list may
+// be filed under another category (Accessory, Display).
+type ScrapeRunPage struct {
+	Region   string `json:"region"`
+	Page     string `json:"page"`
+	Products int    `json:"products"`
+	Error    string `json:"error,omitempty"`
+	Duration int64  `json:"duration_ms"`
+}
+
+// ScrapeDryRun is what a scrape would change in the catalog, reported instead of
+// written: listings seen for the first time, price changes, and products no
+// longer listed that would be marked sold out
+type ScrapeDryRun struct {
+	RunID         string              `json:"run_id"`
+	StartedAt     time.Time           `json:"started_at"`
+	Duration      int64               `json:"duration_ms"`
+	Scraped       int                 `json:"scraped"`
+	FailedRegions []string            `json:"failed_regions"`
+	New           []*Product          `json:"new"`
+	PriceChanges  []DryRunPriceChange `json:"price_changes"`
+	Disappeared   []*Product          `json:"disappeared"`
+	Unchanged     int                 `json:"unchanged"`
+	Pages         []ScrapeRunPage     `json:"pages"`
+}
+
+// DryRunPriceChange is a stored product whose scraped price differs
+type DryRunPriceChange struct {
+	Product  *Product `json:"product"` // as scraped
+	OldPrice float64  `json:"old_price"`
+	NewPrice float64  `json:"new_price"`
+}
+
+// NewScrapeRunID generates the ID of a scrape run
+func NewScrapeRunID() string {
+	buf := make([]byte, 6)
+	if _, err := rand.Read(buf); err != nil {
+		return fmt.Sprintf("run-%012x", time.Now().UnixNano())
+	}
+	return "run-" + hex.EncodeToString(buf)
+}
+
+// Completed reports whether the run scraped products (fully or partially), so
+// its category counts can be compared with those of the next run
+func (r *ScrapeRun) Completed() bool {
+	return r.Status == "success" || r.Status == "partial"
+}
+
+// Category returns the counts of a region/category; zero counts if the run didn't see it
+func (r *ScrapeRun) Category(region, category string) ScrapeRunCategory {
+	for _, c := range r.Categories {
+		if c.Region == region && c.Category == category {
+			return c
+		}
+	}
+	return ScrapeRunCategory{Region: region, Category: category}
+}
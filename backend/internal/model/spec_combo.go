@@ -0,0 +1,156 @@
+package model
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// SpecCombo is a spec combination typed by a user, e.g. "MacBook Pro 14 M4 Pro 48GB",
+// that need not exist in the catalog yet. Empty fields match anything.
+type SpecCombo struct {
+	Model      string `json:"model,omitempty"`       // MacBook Pro, iPad Air, etc.
+	ScreenSize string `json:"screen_size,omitempty"` // 14, 16, 13.6, etc. (inches)
+	Chip       string `json:"chip,omitempty"`        // M4, M4 Pro, A17 Pro, etc.
+	Memory     string `json:"memory,omitempty"`      // 48GB
+	Storage    string `json:"storage,omitempty"`     // 1TB
+}
+
+// specModels are the product lines a combo can name, longer names first so
+// "iPad Pro" wins over "iPad"
+var specModels = []string{
+	"MacBook Pro", "MacBook Air", "Mac mini", "Mac Studio", "Mac Pro", "iMac",
+	"iPad Pro", "iPad Air", "iPad mini", "iPad",
+	"iPhone", "Apple Watch", "Apple TV", "AirPods", "HomePod",
+}
+
+var (
+	specChipPattern     = regexp.MustCompile(`(?i)\b([MA]\d{1,2})(?:\s*(Pro|Max|Ultra))?\b`)
+	specCapacityPattern = regexp.MustCompile(`(?i)(\d+)\s*(GB|TB)(\s*(?:统一内存|内存|RAM|memory|SSD|固态硬盘|存储|storage))?`)
+	specScreenPattern   = regexp.MustCompile(`(?i)(\d{1,2}(?:\.\d)?)\s*(?:英寸|寸|-?inch|")`)
+	specBareSizePattern = regexp.MustCompile(`\b(\d{2}(?:\.\d)?)\b`)
+)
+
+// ParseSpecCombo extracts model, screen size, chip, memory and storage from free
+// text. TB values are storage; a GB value labelled 内存/RAM or SSD/存储 goes where
+// its label says. Unlabelled GB values are split by size: with two, the smaller is
+// memory; a lone one is storage on iPhone/iPad (no memory is advertised there) and
+// on Macs from 256GB up, memory below that.
+func ParseSpecCombo(text string) SpecCombo {
+	var combo SpecCombo
+	combo.Model = detectSpecModel(text)
+
+	if m := specChipPattern.FindStringSubmatch(text); m != nil {
+		combo.Chip = strings.ToUpper(m[1])
+		if m[2] != "" {
+			combo.Chip += " " + strings.ToUpper(m[2][:1]) + strings.ToLower(m[2][1:])
+		}
+	}
+
+	var unlabelled []int // GB values without a memory/storage label
+	seen := make(map[string]bool)
+	for _, m := range specCapacityPattern.FindAllStringSubmatch(text, -1) {
+		value, unit, label := m[1], strings.ToUpper(m[2]), strings.ToLower(strings.TrimSpace(m[3]))
+		capacity := value + unit
+		if seen[capacity] {
+			// Product names often repeat the storage from the specs
+			continue
+		}
+		seen[capacity] = true
+
+		switch {
+		case unit == "TB" || label == "ssd" || label == "固态硬盘" || label == "存储" || label == "storage":
+			if combo.Storage == "" {
+				combo.Storage = capacity
+			}
+		case label != "":
+			if combo.Memory == "" {
+				combo.Memory = capacity
+			}
+		default:
+			if n, err := strconv.Atoi(value); err == nil {
+				unlabelled = append(unlabelled, n)
+			}
+		}
+	}
+	assignUnlabelledCapacities(&combo, unlabelled)
+
+	if m := specScreenPattern.FindStringSubmatch(text); m != nil {
+		combo.ScreenSize = m[1]
+	} else if hasScreenSizes(combo.Model) {
+		// "MacBook Pro 14 M4 Pro": a bare two-digit number once capacities and
+		// the chip are out of the way
+		rest := specCapacityPattern.ReplaceAllString(text, " ")
+		rest = specChipPattern.ReplaceAllString(rest, " ")
+		if m := specBareSizePattern.FindStringSubmatch(rest); m != nil {
+			combo.ScreenSize = m[1]
+		}
+	}
+	combo.ScreenSize = strings.TrimSuffix(combo.ScreenSize, ".0")
+
+	return combo
+}
+
+// assignUnlabelledCapacities fills memory and storage from GB values that carry no label
+func assignUnlabelledCapacities(combo *SpecCombo, values []int) {
+	noMemory := strings.HasPrefix(combo.Model, "iPhone") || strings.HasPrefix(combo.Model, "iPad")
+	for len(values) > 0 {
+		smallest := 0
+		for i, v := range values {
+			if v < values[smallest] {
+				smallest = i
+			}
+		}
+		v := values[smallest]
+		values = append(values[:smallest], values[smallest+1:]...)
+
+		capacity := strconv.Itoa(v) + "GB"
+		toMemory := combo.Memory == "" && !noMemory && (v < 256 || len(values) > 0)
+		switch {
+		case toMemory:
+			combo.Memory = capacity
+		case combo.Storage == "":
+			combo.Storage = capacity
+		}
+	}
+}
+
+// detectSpecModel returns the first product line named in text, if any
+func detectSpecModel(text string) string {
+	lower := strings.ToLower(text)
+	for _, name := range specModels {
+		if strings.Contains(lower, strings.ToLower(name)) {
+			return name
+		}
+	}
+	return ""
+}
+
+// hasScreenSizes reports whether a product line comes in several screen sizes
+func hasScreenSizes(productModel string) bool {
+	return strings.HasPrefix(productModel, "MacBook") || strings.HasPrefix(productModel, "iPad") || productModel == "iMac"
+}
+
+// IsEmpty reports whether nothing could be parsed, i.e. the combo would match every product
+func (c SpecCombo) IsEmpty() bool {
+	return c == SpecCombo{}
+}
+
+// Matches reports whether a product has every spec set in the combo. Specs are
+// compared whole, so "M4" does not match an M4 Pro and 8GB does not match 48GB.
+func (c SpecCombo) Matches(p *Product) bool {
+	if p == nil {
+		return false
+	}
+	product := ParseSpecCombo(p.Name + " " + p.Specs)
+
+	return specFieldMatches(c.Model, product.Model) &&
+		specFieldMatches(c.ScreenSize, product.ScreenSize) &&
+		specFieldMatches(c.Chip, product.Chip) &&
+		specFieldMatches(c.Memory, product.Memory) &&
+		specFieldMatches(c.Storage, product.Storage)
+}
+
+func specFieldMatches(want, have string) bool {
+	return want == "" || strings.EqualFold(want, have)
+}
@@ -0,0 +1,90 @@
+package model
+
+// SubscriptionPreset is a curated new arrival filter set users can subscribe to
+// without building the filters themselves
+type SubscriptionPreset struct {
+	ID            string   `json:"id"`
+	Name          string   `json:"name"`
+	Description   string   `json:"description"`
+	Categories    []string `json:"categories"`
+	Models        []string `json:"models,omitempty"`
+	Chips         []string `json:"chips,omitempty"`
+	Storages      []string `json:"storages,omitempty"`
+	Memories      []string `json:"memories,omitempty"`
+	StockStatuses []string `json:"stock_statuses,omitempty"`
+	MinPrice      float64  `json:"min_price"`
+	MaxPrice      float64  `json:"max_price"`
+	Keywords      []string `json:"keywords"`
+}
+
+// SubscriptionPresets returns the curated presets, in display order
+func SubscriptionPresets() []SubscriptionPreset {
+	return []SubscriptionPreset{
+		{
+			ID:          "macbook-air-m-under-6000",
+			Name:        "M系列 MacBook Air 低于¥6000",
+			Description: "Apple 芯片的翻新 MacBook Air，价格不超过 ¥6000",
+			Categories:  []string{"Mac"},
+			Models:      []string{"MacBook Air"},
+			Chips:       []string{"M1", "M2", "M3", "M4"},
+			MaxPrice:    6000,
+		},
+		{
+			ID:          "ipad-pro-any",
+			Name:        "iPad Pro 任何新上架",
+			Description: "任何配置的翻新 iPad Pro 上架即提醒",
+			Categories:  []string{"iPad"},
+			Models:      []string{"iPad Pro"},
+		},
+		{
+			ID:          "macbook-pro-pro-max",
+			Name:        "MacBook Pro Pro/Max 芯片",
+			Description: "搭载 Pro 或 Max 芯片的翻新 MacBook Pro",
+			Categories:  []string{"Mac"},
+			Models:      []string{"MacBook Pro"},
+			Chips:       []string{"M1 Pro", "M1 Max", "M2 Pro", "M2 Max", "M3 Pro", "M3 Max", "M4 Pro", "M4 Max"},
+		},
+		{
+			ID:          "mac-mini-any",
+			Name:        "Mac mini 任何新上架",
+			Description: "任何配置的翻新 Mac mini 上架即提醒",
+			Categories:  []string{"Mac"},
+			Models:      []string{"Mac mini"},
+		},
+		{
+			ID:          "apple-watch-any",
+			Name:        "Apple Watch 任何新上架",
+			Description: "任何型号的翻新 Apple Watch 上架即提醒",
+			Categories:  []string{"Watch"},
+		},
+	}
+}
+
+// FindSubscriptionPreset looks up a preset by ID
+func FindSubscriptionPreset(id string) (SubscriptionPreset, bool) {
+	for _, p := range SubscriptionPresets() {
+		if p.ID == id {
+			return p, true
+		}
+	}
+	return SubscriptionPreset{}, false
+}
+
+// NewSubscription copies the preset's filters into a new arrival subscription for barkKey
+func (p SubscriptionPreset) NewSubscription(barkKey string) *NewArrivalSubscription {
+	return &NewArrivalSubscription{
+		Name:          p.Name,
+		Description:   p.Description,
+		Categories:    append([]string(nil), p.Categories...),
+		Models:        append([]string(nil), p.Models...),
+		Chips:         append([]string(nil), p.Chips...),
+		Storages:      append([]string(nil), p.Storages...),
+		Memories:      append([]string(nil), p.Memories...),
+		StockStatuses: append([]string(nil), p.StockStatuses...),
+		MinPrice:      p.MinPrice,
+		MaxPrice:      p.MaxPrice,
+		Keywords:      append([]string(nil), p.Keywords...),
+		BarkKey:       barkKey,
+		Enabled:       true,
+	}
+}
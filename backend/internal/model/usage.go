@@ -0,0 +1,26 @@
+package model
+
+import "time"
+
+// UsageRetentionDays is how many daily usage reports are kept
+const UsageRetentionDays = 90
+
+// UsageDay is one day of anonymous usage counters. Only counts are kept: no
+// Bark Keys, IPs or request parameters.
+type UsageDay struct {
+	Date                    string           `json:"date"` // YYYY-MM-DD, server local time
+	APIRequests             int64            `json:"api_requests"`
+	APIErrors               int64            `json:"api_errors"`                // 5xx responses
+	Routes                  map[string]int64 `json:"routes"`                    // "GET /api/products" -> requests
+	Notifications           map[string]int64 `json:"notifications"`             // delivery status (sent, failed, ...) -> count
+	NotificationTypes       map[string]int64 `json:"notification_types"`        // price_drop, new_arrival, ... -> count
+	Subscriptions           int              `json:"subscriptions"`             // last count seen that day
+	NewArrivalSubscriptions int              `json:"new_arrival_subscriptions"` // last count seen that day
+	BarkKeys                int              `json:"bark_keys"`                 // distinct Bark Keys with a subscription
+}
+
+// UsageReport is the daily usage of a deployment, newest day first
+type UsageReport struct {
+	GeneratedAt time.Time  `json:"generated_at"`
+	Days        []UsageDay `json:"days"`
+}
@@ -0,0 +1,57 @@
+package model
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"time"
+)
+
+// APIKeyPrefix starts every user API key, telling them apart from admin tokens
+const APIKeyPrefix = "ak_"
+
+// User is an account owning one Bark Key. Subscriptions, notification history and
+// preferences stay keyed by the Bark Key, so they belong to the user holding it.
+// Users migrated from existing Bark Keys have no API key until registered; until
+// then their data stays reachable with the Bark Key alone, as before accounts.
+type User struct {
+	ID         string    `json:"id"`
+	Name       string    `json:"name,omitempty"`
+	BarkKey    string    `json:"bark_key"`
+	APIKeyHash string    `json:"-"` // SHA-256 of the API key; the key itself is never stored
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at,omitempty"`
+}
+
+// Registered reports whether the user has an API key, which is then required
+// to read or change anything addressed to their Bark Key
+func (u *User) Registered() bool {
+	return u.APIKeyHash != ""
+}
+
+// NewUserID generates a user ID ("usr-" and 16 hex digits)
+func NewUserID() string {
+	buf := make([]byte, 8)
+	if _, err := rand.Read(buf); err != nil {
+		return fmt.Sprintf("usr-%016x", time.Now().UnixNano())
+	}
+	return "usr-" + hex.EncodeToString(buf)
+}
+
+// NewAPIKey generates a user API key and its hash. Only the hash is stored;
+// the key is shown to the user once.
+func NewAPIKey() (key, hash string, err error) {
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		return "", "", fmt.Errorf("failed to generate API key: %w", err)
+	}
+	key = APIKeyPrefix + hex.EncodeToString(buf)
+	return key, HashAPIKey(key), nil
+}
+
+// HashAPIKey returns the hex-encoded SHA-256 of a user API key
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
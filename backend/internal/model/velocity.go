@@ -0,0 +1,185 @@
+package model
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Product event types
+const (
+	EventListed    = "listed"
+	EventAvailable = "available"
+	EventLimited   = "limited"
+	EventSoldOut   = "sold_out"
+)
+
+// minVelocitySamples is the minimum number of observed sell-outs before a velocity is reported
+const minVelocitySamples = 3
+
+// SellOutWarningWindow is how long before its expected sell-out a watched listing triggers a low stock alert
+const SellOutWarningWindow = 24 * time.Hour
+
+// LowStockReason is why a watched product is expected to go soon: its stock just
+// turned limited, or similar listings usually sold out within MedianHours of being
+// listed and it is projected to go around ExpectedAt
+type LowStockReason struct {
+	Limited     bool
+	MedianHours float64
+	ExpectedAt  time.Time
+}
+
+// ProductEvent records a lifecycle event for a product (first listing and stock transitions)
+type ProductEvent struct {
+	ProductID string    `json:"product_id"`
+	EventType string    `json:"event_type"` // listed, available, limited, sold_out
+	Price     float64   `json:"price"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// InventoryVelocity summarizes how quickly listings of a model/config sell out
+type InventoryVelocity struct {
+	Key          string  `json:"key"`
+	SampleSize   int     `json:"sample_size"`
+	MedianHours  float64 `json:"median_hours"`
+	AverageHours float64 `json:"average_hours"`
+}
+
+// ExpectedSellOut projects when a listing available since the given time will sell out,
+// assuming it lasts as long as the median similar listing
+func (v *InventoryVelocity) ExpectedSellOut(availableSince time.Time) time.Time {
+	return availableSince.Add(time.Duration(v.MedianHours * float64(time.Hour)))
+}
+
+// AvailableSince returns when a product last became available (its listing or latest
+// restock) from its time-ordered events, or zero if unknown
+func AvailableSince(events []ProductEvent) time.Time {
+	var since time.Time
+	for _, e := range events {
+		switch e.EventType {
+		case EventListed:
+			since = e.CreatedAt
+		case EventAvailable, EventLimited:
+			if since.IsZero() {
+				since = e.CreatedAt
+			}
+		case EventSoldOut:
+			since = time.Time{}
+		}
+	}
+	return since
+}
+
+// InventoryVelocityIndex maps config and model keys to their observed sell-out velocity
+type InventoryVelocityIndex map[string]*InventoryVelocity
+
+// Lookup returns the most specific velocity known for a product: its exact config first,
+// then its model. Returns nil when there is not enough data.
+func (idx InventoryVelocityIndex) Lookup(p *Product) *InventoryVelocity {
+	if len(idx) == 0 || p == nil {
+		return nil
+	}
+	configKey, modelKey := InventoryKeys(p)
+	if v, ok := idx[configKey]; ok {
+		return v
+	}
+	if v, ok := idx[modelKey]; ok {
+		return v
+	}
+	return nil
+}
+
+// InventoryKeys returns the config-level and model-level grouping keys for a product.
+// Config keys include chip, memory and storage; model keys only the model and chip.
+func InventoryKeys(p *Product) (configKey, modelKey string) {
+	var specs map[string]interface{}
+	if p.SpecsDetail != "" {
+		_ = json.Unmarshal([]byte(p.SpecsDetail), &specs)
+	}
+
+	specValue := func(key string) string {
+		if v, ok := specs[key].(string); ok {
+			return strings.TrimSpace(v)
+		}
+		return ""
+	}
+
+	modelName := specValue("model")
+	if modelName == "" {
+		modelName = p.Category
+	}
+	chip := specValue("chip")
+
+	modelKey = p.Region + "|" + modelName + "|" + chip
+	configKey = modelKey + "|" + specValue("memory") + "|" + specValue("storage")
+	return configKey, modelKey
+}
+
+// BuildInventoryVelocity computes sell-out velocity from product events.
+// Events must be ordered by product and time. Each span from a listing (or restock)
+// to the following sold_out event counts as one sample.
+func BuildInventoryVelocity(events []ProductEvent, products map[string]*Product) InventoryVelocityIndex {
+	samples := make(map[string][]float64)
+
+	var availableSince time.Time
+	currentProduct := ""
+	for _, e := range events {
+		if e.ProductID != currentProduct {
+			currentProduct = e.ProductID
+			availableSince = time.Time{}
+		}
+
+		switch e.EventType {
+		case EventListed, EventAvailable:
+			if availableSince.IsZero() {
+				availableSince = e.CreatedAt
+			}
+		case EventSoldOut:
+			if availableSince.IsZero() {
+				continue
+			}
+			p, ok := products[e.ProductID]
+			if !ok {
+				availableSince = time.Time{}
+				continue
+			}
+			hours := e.CreatedAt.Sub(availableSince).Hours()
+			availableSince = time.Time{}
+			if hours <= 0 {
+				continue
+			}
+			configKey, modelKey := InventoryKeys(p)
+			samples[configKey] = append(samples[configKey], hours)
+			if modelKey != configKey {
+				samples[modelKey] = append(samples[modelKey], hours)
+			}
+		}
+	}
+
+	idx := make(InventoryVelocityIndex)
+	for key, hours := range samples {
+		if len(hours) < minVelocitySamples {
+			continue
+		}
+		sort.Float64s(hours)
+
+		total := 0.0
+		for _, h := range hours {
+			total += h
+		}
+
+		median := hours[len(hours)/2]
+		if len(hours)%2 == 0 {
+			median = (hours[len(hours)/2-1] + hours[len(hours)/2]) / 2
+		}
+
+		idx[key] = &InventoryVelocity{
+			Key:          key,
+			SampleSize:   len(hours),
+			MedianHours:  median,
+			AverageHours: total / float64(len(hours)),
+		}
+	}
+	return idx
+}
@@ -0,0 +1,15 @@
+package model
+
+import "time"
+
+// Watchlist is a named list of products tracked without push notifications.
+// It belongs to whoever holds ClientToken, an opaque ID the client generates
+// and keeps (e.g. in localStorage); the token is never echoed back.
+type Watchlist struct {
+	ID          string    `json:"id"`
+	Name        string    `json:"name"`
+	ClientToken string    `json:"-"`
+	ProductIDs  []string  `json:"product_ids"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
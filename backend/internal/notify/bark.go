@@ -1,21 +1,112 @@
 package notify
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/url"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
+
+	"apple-price/internal/model"
 )
 
 const (
-	barkAPIURL = "https://api.day.app"
+	// DefaultBarkServer is the public Bark server, used unless BARK_SERVER or a subscription says otherwise
+	DefaultBarkServer = "https://api.day.app"
+
+	// barkMaxConcurrent bounds in-flight requests to the Bark server
+	barkMaxConcurrent = 4
+	// barkMinInterval spaces out consecutive requests so bursts don't trip rate limits
+	barkMinInterval = 100 * time.Millisecond
+	// barkDefaultBackoff is used when a 429 response carries no Retry-After header
+	barkDefaultBackoff = 5 * time.Second
 )
 
+// errEmptyBarkKey is returned when a push has no Bark key to go to
+var errEmptyBarkKey = errors.New("bark key is empty")
+
+// StatusError is returned when the Bark server answers a push with a non-200 status
+type StatusError struct {
+	Code int
+}
+
+func (e *StatusError) Error() string {
+	if e.Code == http.StatusTooManyRequests {
+		return "rate limited by bark server"
+	}
+	return fmt.Sprintf("unexpected status code: %d", e.Code)
+}
+
 // BarkService handles Bark notifications
 type BarkService struct {
 	client    *http.Client
 	isEnabled bool
+	server    string // base URL of the Bark server
+
+	// frontendURL is the base URL of the web frontend; notifications link to the
+	// product there instead of straight to Apple when set
+	frontendURL string
+
+	// Services for other (self-hosted) servers, each with its own rate limiting
+	servers   map[string]*BarkService
+	serversMu sync.Mutex
+
+	slots    chan struct{} // concurrency limiter
+	paceMu   sync.Mutex
+	nextSend time.Time
+
+	capture func(key string, msg *Message) // sandbox mode: messages are handed here instead of sent
+}
+
+// Message is a rendered notification as sent to the channel
+type Message struct {
+	Title string `json:"title"`
+	Body  string `json:"body"`
+	URL   string `json:"url,omitempty"`
+	Icon  string `json:"icon,omitempty"`
+	Sound string `json:"sound,omitempty"`
+	Group string `json:"group,omitempty"`
+}
+
+// ChannelParams returns the channel parameters as JSON for auditing
+func (m *Message) ChannelParams() string {
+	params := map[string]string{"channel": "bark"}
+	if m.URL != "" {
+		params["url"] = m.URL
+	}
+	if m.Icon != "" {
+		params["icon"] = m.Icon
+	}
+	if m.Sound != "" {
+		params["sound"] = m.Sound
+	}
+	if m.Group != "" {
+		params["group"] = m.Group
+	}
+	data, _ := json.Marshal(params)
+	return string(data)
+}
+
+// query returns the channel parameters as the Bark request's query string
+func (m *Message) query() url.Values {
+	query := url.Values{}
+	if m.URL != "" {
+		query.Set("url", m.URL)
+	}
+	if m.Icon != "" {
+		query.Set("icon", m.Icon)
+	}
+	if m.Sound != "" {
+		query.Set("sound", m.Sound)
+	}
+	if m.Group != "" {
+		query.Set("group", m.Group)
+	}
+	return query
 }
 
 // NewBarkService creates a new Bark notification service
@@ -23,104 +114,311 @@ func NewBarkService() *BarkService {
 	return &BarkService{
 		client: &http.Client{
 			Timeout: 10 * time.Second,
+			Transport: &http.Transport{
+				Proxy:               http.ProxyFromEnvironment,
+				MaxIdleConns:        barkMaxConcurrent * 2,
+				MaxIdleConnsPerHost: barkMaxConcurrent,
+				IdleConnTimeout:     90 * time.Second,
+				TLSHandshakeTimeout: 10 * time.Second,
+			},
 		},
 		isEnabled: true,
+		server:    DefaultBarkServer,
+		slots:     make(chan struct{}, barkMaxConcurrent),
+	}
+}
+
+// Sandbox returns a service that renders messages exactly like b but hands them
+// to capture instead of calling the Bark server. capture may be called concurrently.
+func (b *BarkService) Sandbox(capture func(key string, msg *Message)) *BarkService {
+	return &BarkService{
+		client:      b.client,
+		isEnabled:   true,
+		server:      b.server,
+		frontendURL: b.frontendURL,
+		slots:       make(chan struct{}, cap(b.slots)),
+		capture:     capture,
+	}
+}
+
+// SetServer changes the default Bark server, e.g. to a self-hosted bark-server
+func (b *BarkService) SetServer(server string) {
+	b.server = server
+}
+
+// SetFrontendURL sets the web frontend notifications link to, e.g.
+// https://apple-price.example.com. Empty links straight to Apple.
+// Call it before Server, whose services copy the setting.
+func (b *BarkService) SetFrontendURL(frontendURL string) {
+	b.frontendURL = frontendURL
+}
+
+// productLink returns the click-through URL for a product: its page on the web
+// frontend, which links on to Apple, or the Apple URL when no frontend is
+// configured (or the product is unknown)
+func (b *BarkService) productLink(productID, appleURL string) string {
+	if b.frontendURL == "" || productID == "" {
+		return appleURL
 	}
+	query := url.Values{}
+	query.Set("product", productID)
+	query.Set("ref", "bark")
+	return b.frontendURL + "/?" + query.Encode()
+}
+
+// Server returns the service to use for a subscription's Bark server: b itself
+// when server is empty or b's own server, otherwise a service sharing b's HTTP
+// client and settings but pacing its requests separately
+func (b *BarkService) Server(server string) *BarkService {
+	if server == "" || server == b.server {
+		return b
+	}
+
+	b.serversMu.Lock()
+	defer b.serversMu.Unlock()
+
+	if s, ok := b.servers[server]; ok {
+		return s
+	}
+	if b.servers == nil {
+		b.servers = make(map[string]*BarkService)
+	}
+	s := &BarkService{
+		client:      b.client,
+		isEnabled:   b.isEnabled,
+		server:      server,
+		frontendURL: b.frontendURL,
+		slots:       make(chan struct{}, cap(b.slots)),
+		capture:     b.capture,
+	}
+	b.servers[server] = s
+	return s
 }
 
 // Disable disables the Bark service
 func (b *BarkService) Disable() {
 	b.isEnabled = false
+
+	b.serversMu.Lock()
+	defer b.serversMu.Unlock()
+	for _, s := range b.servers {
+		s.isEnabled = false
+	}
+}
+
+// Concurrency returns the maximum number of requests sent in parallel
+func (b *BarkService) Concurrency() int {
+	return cap(b.slots)
 }
 
 // SendNotification sends a Bark notification
 func (b *BarkService) SendNotification(key, title, content string) error {
+	return b.send(key, title, content, nil)
+}
+
+// send issues the Bark request, with the channel parameters in query
+func (b *BarkService) send(key, title, content string, query url.Values) error {
 	if !b.isEnabled {
 		return nil
 	}
 
 	if key == "" {
-		return fmt.Errorf("bark key is empty")
+		return errEmptyBarkKey
+	}
+
+	if b.capture != nil {
+		b.capture(key, &Message{Title: title, Body: content})
+		return nil
 	}
 
 	// URL encode the title and content
 	title = url.QueryEscape(title)
 	content = url.QueryEscape(content)
 
-	// Build URL: https://api.day.app/{key}/{title}/{content}
-	barkURL := fmt.Sprintf("%s/%s/%s/%s", barkAPIURL, key, title, content)
+	// Build URL: {server}/{key}/{title}/{content}
+	barkURL := fmt.Sprintf("%s/%s/%s/%s", b.server, key, title, content)
+	if len(query) > 0 {
+		barkURL += "?" + query.Encode()
+	}
 
 	req, err := http.NewRequest("GET", barkURL, nil)
 	if err != nil {
 		return fmt.Errorf("failed to create request: %w", err)
 	}
 
+	b.slots <- struct{}{}
+	defer func() { <-b.slots }()
+	b.pace()
+
 	resp, err := b.client.Do(req)
 	if err != nil {
 		return fmt.Errorf("failed to send notification: %w", err)
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode == http.StatusTooManyRequests {
+		b.backoff(resp.Header.Get("Retry-After"))
+	}
+
 	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+		return &StatusError{Code: resp.StatusCode}
 	}
 
 	return nil
 }
 
-// SendPriceChangeNotification sends a price change notification
-func (b *BarkService) SendPriceChangeNotification(key, productName string, oldPrice, newPrice float64, productURL string) error {
-	title := "🍎 苹果翻新价格变动"
-	content := fmt.Sprintf("%s 价格从 %.2f 变为 %.2f，点击查看详情",
-		productName, oldPrice, newPrice)
+// Send sends a rendered message to a Bark key
+func (b *BarkService) Send(key string, msg *Message) error {
+	if b.capture != nil {
+		b.capture(key, msg)
+		return nil
+	}
+	return b.send(key, msg.Title, msg.Body, msg.query())
+}
 
-	// Add URL to content if provided
-	if productURL != "" {
-		content += fmt.Sprintf("?url=%s", url.QueryEscape(productURL))
+// pace blocks until the next request slot, keeping at least barkMinInterval between sends
+func (b *BarkService) pace() {
+	b.paceMu.Lock()
+	now := time.Now()
+	wait := b.nextSend.Sub(now)
+	if wait < 0 {
+		wait = 0
 	}
+	b.nextSend = now.Add(wait + barkMinInterval)
+	b.paceMu.Unlock()
 
-	return b.SendNotification(key, title, content)
+	if wait > 0 {
+		time.Sleep(wait)
+	}
+}
+
+// backoff delays all subsequent sends after the server asked us to slow down
+func (b *BarkService) backoff(retryAfter string) {
+	delay := barkDefaultBackoff
+	if secs, err := strconv.Atoi(retryAfter); err == nil && secs > 0 {
+		delay = time.Duration(secs) * time.Second
+	}
+
+	b.paceMu.Lock()
+	defer b.paceMu.Unlock()
+	if until := time.Now().Add(delay); until.After(b.nextSend) {
+		b.nextSend = until
+	}
+}
+
+// SendPriceChangeNotification sends a price change notification written for loc.
+// The rendered message is returned even when sending fails.
+func (b *BarkService) SendPriceChangeNotification(key string, loc Locale, productName string, oldPrice, newPrice float64, productID, productURL string, sellOutHours float64) (*Message, error) {
+	msg := &Message{
+		Title: loc.text("price_change.title"),
+		Body: fmt.Sprintf(loc.text("price_change.body"),
+			productName, loc.money(oldPrice, 2), loc.money(newPrice, 2)),
+		URL: b.productLink(productID, productURL),
+	}
+
+	if hint := sellOutHint(loc, sellOutHours); hint != "" {
+		msg.Body += "\n" + hint
+	}
+
+	return msg, b.Send(key, msg)
+}
+
+// SendSamplePriceAlert sends a price change notification filled with sample data,
+// marked as a test so it isn't mistaken for a real price change
+func (b *BarkService) SendSamplePriceAlert(key string, loc Locale, productName string, oldPrice, newPrice float64, productID, productURL string) (*Message, error) {
+	msg := &Message{
+		Title: loc.text("sample_price.title"),
+		Body: fmt.Sprintf(loc.text("sample_price.body"),
+			productName, loc.money(oldPrice, 2), loc.money(newPrice, 2)),
+		URL:   b.productLink(productID, productURL),
+		Group: "test",
+	}
+
+	return msg, b.Send(key, msg)
 }
 
 // SendStockNotification sends a stock availability notification
-func (b *BarkService) SendStockNotification(key, productName string, stockStatus string, productURL string) error {
-	title := "🍎 苹果翻新库存提醒"
-	content := fmt.Sprintf("%s 状态更新为: %s", productName, stockStatus)
+func (b *BarkService) SendStockNotification(key string, loc Locale, productName string, stockStatus string, productID, productURL string) (*Message, error) {
+	msg := &Message{
+		Title: loc.text("stock.title"),
+		Body:  fmt.Sprintf(loc.text("stock.body"), productName, stockStatusLabel(loc, stockStatus)),
+		URL:   b.productLink(productID, productURL),
+	}
+
+	return msg, b.Send(key, msg)
+}
 
-	if productURL != "" {
-		content += fmt.Sprintf("?url=%s", url.QueryEscape(productURL))
+// SendLowStockNotification warns that a watched product is likely to sell out soon
+func (b *BarkService) SendLowStockNotification(key string, loc Locale, productName string, reason *model.LowStockReason, productID, productURL string) (*Message, error) {
+	msg := &Message{
+		Title: loc.text("low_stock.title"),
+		Body:  fmt.Sprintf(loc.text("low_stock.body"), productName, lowStockReasonText(loc, reason)),
+		URL:   b.productLink(productID, productURL),
+		Sound: "alarm",
 	}
 
-	return b.SendNotification(key, title, content)
+	return msg, b.Send(key, msg)
+}
+
+// lowStockReasonText explains in loc's language why a product is expected to go soon
+func lowStockReasonText(loc Locale, reason *model.LowStockReason) string {
+	if reason.Limited {
+		return loc.text("low_stock.limited")
+	}
+	return fmt.Sprintf(loc.text("low_stock.velocity"), reason.MedianHours, reason.ExpectedAt.Format("01-02 15:04"))
+}
+
+// stockStatusLabel returns a display label for a stock status
+func stockStatusLabel(loc Locale, status string) string {
+	switch status {
+	case "available":
+		return loc.text("stock.available")
+	case "limited":
+		return loc.text("stock.limited")
+	case "sold_out":
+		return loc.text("stock.sold_out")
+	default:
+		return status
+	}
 }
 
 // SendNewArrivalNotification sends a new product arrival notification
-func (b *BarkService) SendNewArrivalNotification(key, productName string, price float64, category, productURL string) error {
-	title := "🆕 苹果翻新新品上架"
-	content := fmt.Sprintf("[%s] %s 到货了！价格: ¥%.0f", category, productName, price)
+func (b *BarkService) SendNewArrivalNotification(key string, loc Locale, productName string, price float64, category, productID, productURL string) (*Message, error) {
+	msg := &Message{
+		Title: loc.text("new_arrival.title"),
+		Body:  fmt.Sprintf(loc.text("new_arrival.body"), category, productName, loc.money(price, 0)),
+		URL:   b.productLink(productID, productURL),
+	}
 
-	if productURL != "" {
-		content += fmt.Sprintf("?url=%s", url.QueryEscape(productURL))
+	return msg, b.Send(key, msg)
+}
+
+// SendRestockNotification sends a "back in stock" notification for a previously sold out product
+func (b *BarkService) SendRestockNotification(key string, loc Locale, productName, category string, price float64, productID, productURL string) (*Message, error) {
+	msg := &Message{
+		Title: loc.text("restock.title"),
+		Body:  fmt.Sprintf(loc.text("restock.body"), category, productName, loc.money(price, 0)),
+		URL:   b.productLink(productID, productURL),
 	}
 
-	return b.SendNotification(key, title, content)
+	return msg, b.Send(key, msg)
 }
 
 // SendNewArrivalNotificationEnhanced sends an enhanced notification with product specs
 func (b *BarkService) SendNewArrivalNotificationEnhanced(
-	key, productName, category string,
+	key string, loc Locale,
+	productName, category string,
 	price, discount float64,
-	imageURL, productURL, specs string,
-) error {
-	title := "🆕 苹果翻新新品上架"
-
+	productID, imageURL, productURL, specs, terms string,
+	sellOutHours float64,
+) (*Message, error) {
 	// Build content with product details
 	var content strings.Builder
 	content.WriteString(fmt.Sprintf("[%s] %s\n", category, productName))
-	content.WriteString(fmt.Sprintf("¥%.0f", price))
+	content.WriteString(loc.money(price, 0))
 
 	if discount > 0 {
-		content.WriteString(fmt.Sprintf(" (省%.0f%%)", discount))
+		content.WriteString(fmt.Sprintf(loc.text("new_arrival.discount"), discount))
 	}
 
 	// Add parsed specs if available
@@ -135,22 +433,39 @@ func (b *BarkService) SendNewArrivalNotificationEnhanced(
 		}
 	}
 
-	if productURL != "" {
-		content.WriteString(fmt.Sprintf("?url=%s", url.QueryEscape(productURL)))
+	// Grade, warranty and battery claims from the detail page
+	if terms != "" {
+		content.WriteString("\n" + terms)
 	}
 
-	// Add image as icon if available
-	if imageURL != "" {
-		content.WriteString(fmt.Sprintf("&icon=%s", url.QueryEscape(imageURL)))
+	if hint := sellOutHint(loc, sellOutHours); hint != "" {
+		content.WriteString("\n" + hint)
 	}
 
-	// Add sound
-	content.WriteString("&sound=bell")
+	msg := &Message{
+		Title: loc.text("new_arrival.title"),
+		Body:  content.String(),
+		URL:   b.productLink(productID, productURL),
+		Icon:  imageURL, // Product image as icon
+		Sound: "bell",
+		Group: "apple-price", // Group for threading
+	}
 
-	// Add group for threading
-	content.WriteString("&group=apple-price")
+	return msg, b.Send(key, msg)
+}
 
-	return b.SendNotification(key, title, content.String())
+// sellOutHint formats the "usually gone within X hours" line (empty if unknown)
+func sellOutHint(loc Locale, hours float64) string {
+	switch {
+	case hours <= 0:
+		return ""
+	case hours < 1:
+		return loc.text("sell_out.hour")
+	case hours < 48:
+		return fmt.Sprintf(loc.text("sell_out.hours"), hours)
+	default:
+		return fmt.Sprintf(loc.text("sell_out.days"), hours/24)
+	}
 }
 
 // extractSpec extracts a specific spec value from JSON string
@@ -196,20 +511,25 @@ func extractSpec(specs, key string) string {
 	return specs[valueStart : valueStart+valueEnd]
 }
 
+// SendTestNotification sends a test push to verify a Bark key is reachable
+func (b *BarkService) SendTestNotification(key string, loc Locale) error {
+	return b.SendNotification(key, loc.text("test.title"), loc.text("test.body"))
+}
+
 // SendBatchNotification sends a batch notification for multiple products
-func (b *BarkService) SendBatchNotification(key string, changes []PriceChange) error {
+func (b *BarkService) SendBatchNotification(key string, loc Locale, changes []PriceChange) error {
 	if len(changes) == 0 {
 		return nil
 	}
 
-	title := "🍎 苹果翻新价格汇总"
+	title := loc.text("batch.title")
 	var content strings.Builder
 
-	content.WriteString(fmt.Sprintf("发现 %d 个价格变动\n\n", len(changes)))
+	content.WriteString(fmt.Sprintf(loc.text("batch.body"), len(changes)))
 
 	for i, change := range changes {
 		if i >= 5 { // Limit to 5 items
-			content.WriteString(fmt.Sprintf("...还有 %d 个产品", len(changes)-5))
+			content.WriteString(fmt.Sprintf(loc.text("batch.more"), len(changes)-5))
 			break
 		}
 		content.WriteString(fmt.Sprintf("%s: %.2f → %.2f\n",
@@ -219,21 +539,178 @@ func (b *BarkService) SendBatchNotification(key string, changes []PriceChange) e
 	return b.SendNotification(key, title, content.String())
 }
 
-// ValidateKey validates a Bark key
+// SendDigestNotification sends one combined message for the new arrivals buffered
+// by an hourly/daily subscription
+func (b *BarkService) SendDigestNotification(key string, loc Locale, subscriptionName string, items []model.PendingNotification) (*Message, error) {
+	if len(items) == 0 {
+		return nil, nil
+	}
+
+	msg := &Message{
+		Title: fmt.Sprintf(loc.text("digest.title"), subscriptionName),
+		Group: "digest",
+	}
+
+	var content strings.Builder
+	content.WriteString(fmt.Sprintf(loc.text("digest.body"), len(items)))
+
+	for i, item := range items {
+		if i >= 5 { // Limit to 5 items
+			content.WriteString(fmt.Sprintf(loc.text("digest.more"), len(items)-5))
+			break
+		}
+		content.WriteString(fmt.Sprintf("%s: %s\n", item.ProductName, loc.money(item.ProductPrice, 0)))
+	}
+	msg.Body = strings.TrimRight(content.String(), "\n")
+
+	// A single item can link straight to the product
+	if len(items) == 1 {
+		msg.URL = b.productLink(items[0].ProductID, items[0].ProductURL)
+		msg.Icon = items[0].ProductImageURL
+	}
+
+	return msg, b.Send(key, msg)
+}
+
+// catchUpMaxLines is how many changes a catch-up summary lists before truncating
+const catchUpMaxLines = 8
+
+// SendCatchUpNotification sends one "期间变化汇总" push listing what changed while
+// the server was offline for downtime. productID/productURL link the only change when there is one.
+func (b *BarkService) SendCatchUpNotification(key string, loc Locale, downtime time.Duration, lines []string, productID, productURL string) (*Message, error) {
+	if len(lines) == 0 {
+		return nil, nil
+	}
+
+	msg := &Message{
+		Title: loc.text("catch_up.title"),
+		Group: "catch_up",
+	}
+
+	var content strings.Builder
+	content.WriteString(fmt.Sprintf(loc.text("catch_up.body"),
+		formatDowntime(loc, downtime), len(lines)))
+	for i, line := range lines {
+		if i >= catchUpMaxLines {
+			content.WriteString(fmt.Sprintf(loc.text("catch_up.more"), len(lines)-catchUpMaxLines))
+			break
+		}
+		content.WriteString(line + "\n")
+	}
+	msg.Body = strings.TrimRight(content.String(), "\n")
+
+	if len(lines) == 1 {
+		msg.URL = b.productLink(productID, productURL)
+	}
+
+	return msg, b.Send(key, msg)
+}
+
+// formatDowntime renders a downtime as hours, or days and hours past a day
+func formatDowntime(loc Locale, d time.Duration) string {
+	hours := int(d.Round(time.Hour) / time.Hour)
+	switch {
+	case hours < 1:
+		return fmt.Sprintf(loc.text("duration.minutes"), int(d/time.Minute))
+	case hours < 24:
+		return fmt.Sprintf(loc.text("duration.hours"), hours)
+	case hours%24 == 0:
+		return fmt.Sprintf(loc.text("duration.days"), hours/24)
+	default:
+		return fmt.Sprintf(loc.text("duration.days_hours"), hours/24, hours%24)
+	}
+}
+
+// ValidateKey checks that a Bark key is non-empty and safe to put in a request path.
+// It does not contact the server; see PingKey.
 func (b *BarkService) ValidateKey(key string) bool {
 	if key == "" {
 		return false
 	}
 
-	// Basic validation - Bark keys are typically alphanumeric
-	// and vary in length, but should not contain spaces
-	if strings.Contains(key, " ") {
+	// Bark keys are typically alphanumeric and vary in length, but must not
+	// contain spaces or characters that would change the request URL
+	if strings.ContainsAny(key, " /?#%") {
 		return false
 	}
 
 	return true
 }
 
+// KeyCheck is the Bark server's answer to a validation push
+type KeyCheck struct {
+	Valid      bool       `json:"valid"`
+	Code       int        `json:"code"`                  // Bark response code, 200 when the device was found
+	Message    string     `json:"message"`               // Bark response message, e.g. why the key was rejected
+	ServerTime *time.Time `json:"server_time,omitempty"` // Bark server clock when it answered
+}
+
+// PingKey sends a silent push (no sound, screen stays off) to key and reports how
+// the Bark server answered. A rejected key is reported in the result; err is only
+// set when no answer was obtained (network failure, rate limiting).
+func (b *BarkService) PingKey(key string) (*KeyCheck, error) {
+	if !b.isEnabled {
+		return nil, fmt.Errorf("bark service disabled")
+	}
+
+	if !b.ValidateKey(key) {
+		return &KeyCheck{Code: http.StatusBadRequest, Message: "invalid bark key format"}, nil
+	}
+
+	if b.capture != nil {
+		b.capture(key, &Message{Title: "Bark Key 验证", Body: "静默推送，无需处理", Group: "validate"})
+		return &KeyCheck{Valid: true, Code: http.StatusOK, Message: "sandbox"}, nil
+	}
+
+	barkURL := fmt.Sprintf("%s/%s/%s/%s?level=passive&group=validate", b.server, key,
+		url.PathEscape("Bark Key 验证"), url.PathEscape("静默推送，无需处理"))
+
+	req, err := http.NewRequest("GET", barkURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	b.slots <- struct{}{}
+	defer func() { <-b.slots }()
+	b.pace()
+
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach bark server: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusTooManyRequests {
+		b.backoff(resp.Header.Get("Retry-After"))
+		return nil, fmt.Errorf("rate limited by bark server")
+	}
+
+	var body struct {
+		Code      int    `json:"code"`
+		Message   string `json:"message"`
+		Timestamp int64  `json:"timestamp"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		// Not a Bark JSON reply (proxy error page etc.); fall back to the HTTP status
+		body.Code = resp.StatusCode
+		body.Message = http.StatusText(resp.StatusCode)
+	}
+
+	check := &KeyCheck{
+		Valid:   resp.StatusCode == http.StatusOK && body.Code == http.StatusOK,
+		Code:    body.Code,
+		Message: body.Message,
+	}
+	if body.Timestamp > 0 {
+		serverTime := time.Unix(body.Timestamp, 0)
+		check.ServerTime = &serverTime
+	}
+	if resp.StatusCode >= http.StatusInternalServerError {
+		return check, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+	return check, nil
+}
+
 // PriceChange represents a price change for batch notifications
 type PriceChange struct {
 	ProductName string
@@ -0,0 +1,212 @@
+package notify
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"apple-price/internal/match"
+	"apple-price/internal/model"
+)
+
+// catchUpRecipient collects what one Bark Key is told about in a catch-up summary
+type catchUpRecipient struct {
+	subscriptionID string
+	barkServer     string
+	locale         Locale
+	quietUntil     func(time.Time) (time.Time, bool)
+	lines          []string
+	changes        []model.CatchUpChange
+	seen           map[string]bool     // kind|product ID already listed
+	arrivals       map[string][]string // new arrival subscription ID -> products to mark notified
+	counted        map[string]bool     // subscriptions to count a notification for
+}
+
+// NotifyCatchUp sends the changes found by the first scrape after downtime as one
+// "期间变化汇总" push per Bark Key, instead of a push per change. Price subscribers
+// hear about their products (respecting target prices); new arrival subscribers
+// about matching new and restocked products.
+func (d *Dispatcher) NotifyCatchUp(downtime time.Duration, changes []model.CatchUpChange, subscriptions []*model.Subscription, arrivalSubscriptions []*model.NewArrivalSubscription) error {
+	d.mu.RLock()
+	bark := d.bark
+	store := d.store
+	d.mu.RUnlock()
+
+	if bark == nil || store == nil || len(changes) == 0 {
+		return nil
+	}
+
+	recipients := make(map[string]*catchUpRecipient)
+	recipient := func(barkKey, barkServer, subscriptionID, language string, quietUntil func(time.Time) (time.Time, bool)) *catchUpRecipient {
+		r, ok := recipients[barkKey]
+		if !ok {
+			r = &catchUpRecipient{
+				subscriptionID: subscriptionID,
+				barkServer:     barkServer,
+				locale:         d.locale(barkKey, language),
+				quietUntil:     quietUntil,
+				seen:           make(map[string]bool),
+				arrivals:       make(map[string][]string),
+				counted:        make(map[string]bool),
+			}
+			recipients[barkKey] = r
+		}
+		return r
+	}
+	add := func(r *catchUpRecipient, change model.CatchUpChange) bool {
+		key := change.Kind + "|" + change.Product.ID
+		if r.seen[key] {
+			return false
+		}
+		r.seen[key] = true
+		r.lines = append(r.lines, catchUpLine(r.locale, change))
+		r.changes = append(r.changes, change)
+		return true
+	}
+
+	for _, sub := range subscriptions {
+		if sub.Paused || sub.BarkKey == "" || d.isPausedAll(sub.BarkKey) {
+			continue
+		}
+		for _, change := range changes {
+			if change.Product.ID != sub.ProductID || change.Kind == model.ChangeNewArrival {
+				continue
+			}
+			if change.Kind == model.ChangePrice && sub.TargetPrice > 0 && change.Product.Price > sub.TargetPrice {
+				continue
+			}
+			r := recipient(sub.BarkKey, sub.BarkServer, sub.ID, sub.Language, d.quietUntil(sub.BarkKey, sub.QuietHoursStart, sub.QuietUntil))
+			if add(r, change) {
+				r.counted[sub.ID] = true
+			}
+		}
+	}
+
+	for _, sub := range arrivalSubscriptions {
+		if !sub.Enabled || sub.Paused || sub.BarkKey == "" || d.isPausedAll(sub.BarkKey) {
+			continue
+		}
+		for _, change := range changes {
+			if change.Kind != model.ChangeNewArrival && change.Kind != model.ChangeRestock {
+				continue
+			}
+			if change.Kind == model.ChangeNewArrival && alreadyNotified(sub, change.Product.ID) {
+				continue
+			}
+			if !match.Subscription(sub, change.Product) {
+				continue
+			}
+
+			r := recipient(sub.BarkKey, sub.BarkServer, sub.ID, sub.Language, d.quietUntil(sub.BarkKey, sub.QuietHoursStart, sub.QuietUntil))
+			if add(r, change) {
+				r.counted[sub.ID] = true
+			}
+			if change.Kind == model.ChangeNewArrival {
+				r.arrivals[sub.ID] = append(r.arrivals[sub.ID], change.Product.ID)
+			}
+		}
+	}
+
+	detected := time.Now()
+	for barkKey, r := range recipients {
+		summary := catchUpProduct(r.changes)
+		render := func(b *BarkService) (*Message, error) {
+			return b.SendCatchUpNotification(barkKey, r.locale, downtime, r.lines, r.changes[0].Product.ID, r.changes[0].Product.ProductURL)
+		}
+		send := func(detectedAt time.Time) {
+			msg, err := render(bark.Server(r.barkServer))
+			if err != nil {
+				slog.Warn("Bark catch-up notification failed", "subscription_id", r.subscriptionID, "error", err)
+				d.recordFailure(store, r.subscriptionID, barkKey, r.barkServer, summary, msg, "catch_up", err, detectedAt)
+				return
+			}
+
+			slog.Info("Catch-up notification sent", "bark_key", maskKey(barkKey), "changes", len(r.lines))
+			d.recordNotificationHistory(store, r.subscriptionID, barkKey, summary, msg, "catch_up", "sent", "", detectedAt)
+
+			for subID, productIDs := range r.arrivals {
+				for _, productID := range productIDs {
+					if err := store.UpdateNotifiedProductIDs(subID, productID); err != nil {
+						slog.Error("Failed to update notified_product_ids", "subscription_id", subID, "error", err)
+					}
+				}
+			}
+			for subID := range r.counted {
+				if err := store.IncrementNotificationCount(subID); err != nil {
+					slog.Error("Failed to increment notification count", "subscription_id", subID, "error", err)
+				}
+			}
+		}
+
+		held := &model.HeldNotification{
+			SubscriptionID:   r.subscriptionID,
+			ProductID:        summary.ID,
+			NotificationType: "catch_up",
+			BarkKey:          barkKey,
+			BarkServer:       r.barkServer,
+			Product:          summary,
+			Notified:         r.arrivals,
+		}
+		for subID := range r.counted {
+			held.Counted = append(held.Counted, subID)
+		}
+		if !d.holdIfQuiet(r.quietUntil, held, render) {
+			send(detected)
+		}
+	}
+
+	return nil
+}
+
+// catchUpLine renders one change of a catch-up summary
+func catchUpLine(loc Locale, change model.CatchUpChange) string {
+	p := change.Product
+	switch change.Kind {
+	case model.ChangePrice:
+		verb := loc.text("catch_up.price_drop")
+		if p.Price > change.OldPrice {
+			verb = loc.text("catch_up.price_rise")
+		}
+		return fmt.Sprintf("%s %s: %s → %s", verb, p.Name, loc.money(change.OldPrice, 0), loc.money(p.Price, 0))
+	case model.ChangeNewArrival:
+		return fmt.Sprintf(loc.text("catch_up.new_arrival"), p.Name, loc.money(p.Price, 0))
+	case model.ChangeRestock:
+		return fmt.Sprintf(loc.text("catch_up.restock"), p.Name, loc.money(p.Price, 0))
+	case model.ChangeSoldOut:
+		return fmt.Sprintf(loc.text("catch_up.sold_out"), p.Name)
+	default:
+		return p.Name
+	}
+}
+
+// catchUpProduct summarizes a catch-up push as a product for notification history,
+// using the first change for ID, category and image
+func catchUpProduct(changes []model.CatchUpChange) *model.Product {
+	first := changes[0].Product
+	name := first.Name
+	if len(changes) > 1 {
+		name = fmt.Sprintf("%s 等 %d 项变化", first.Name, len(changes))
+	}
+	return &model.Product{
+		ID:       first.ID,
+		Name:     name,
+		Category: first.Category,
+		Price:    first.Price,
+		ImageURL: first.ImageURL,
+	}
+}
+
+// alreadyNotified reports whether a new arrival subscription was already told about a product
+func alreadyNotified(sub *model.NewArrivalSubscription, productID string) bool {
+	var ids []string
+	if sub.NotifiedProductIDs == "" || json.Unmarshal([]byte(sub.NotifiedProductIDs), &ids) != nil {
+		return false
+	}
+	for _, id := range ids {
+		if id == productID {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,44 @@
+package notify
+
+import "time"
+
+// dedupeWindow is how long a product notification to one Bark key suppresses
+// further notifications of the same kind for that product, e.g. when several
+// overlapping new arrival subscriptions share a key
+const dedupeWindow = 30 * time.Minute
+
+// claimDelivery reserves the right to notify barkKey about productID for one
+// notification type. It returns false if another subscription with the same
+// key already claimed it within dedupeWindow.
+func (d *Dispatcher) claimDelivery(barkKey, notificationType, productID string) bool {
+	key := barkKey + "|" + notificationType + "|" + productID
+	now := time.Now()
+
+	d.dedupeMu.Lock()
+	defer d.dedupeMu.Unlock()
+
+	if d.delivered == nil {
+		d.delivered = make(map[string]time.Time)
+	}
+	if at, ok := d.delivered[key]; ok && now.Sub(at) < dedupeWindow {
+		return false
+	}
+
+	// Prune expired claims so the map stays bounded by recent traffic
+	for k, at := range d.delivered {
+		if now.Sub(at) >= dedupeWindow {
+			delete(d.delivered, k)
+		}
+	}
+
+	d.delivered[key] = now
+	return true
+}
+
+// releaseDelivery drops a claim after a failed send, so another subscription
+// sharing the key may still deliver the notification
+func (d *Dispatcher) releaseDelivery(barkKey, notificationType, productID string) {
+	d.dedupeMu.Lock()
+	defer d.dedupeMu.Unlock()
+	delete(d.delivered, barkKey+"|"+notificationType+"|"+productID)
+}
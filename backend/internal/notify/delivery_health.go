@@ -0,0 +1,72 @@
+package notify
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"apple-price/internal/model"
+)
+
+// DeliveryHealthStore tracks consecutive push failures per Bark Key
+type DeliveryHealthStore interface {
+	GetDeliveryHealth(barkKey string) *model.DeliveryHealth
+	SetDeliveryHealth(h *model.DeliveryHealth) error
+}
+
+// SetDeliveryFailureLimit sets after how many failed pushes in a row a Bark Key
+// is marked broken and gets no more notifications; 0 never marks keys broken
+func (d *Dispatcher) SetDeliveryFailureLimit(limit int) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.failureLimit = limit
+}
+
+// deadKeyError reports whether a failed push points at the Bark Key rather than
+// the network or server load: the Bark server answered, but not with 429
+func deadKeyError(err error) bool {
+	var statusErr *StatusError
+	return errors.As(err, &statusErr) && statusErr.Code != http.StatusTooManyRequests
+}
+
+// trackDelivery records the outcome of a push to barkKey. A delivered push clears
+// the key's failures; the failure limit reached in a row marks the key broken.
+func (d *Dispatcher) trackDelivery(barkKey string, err error) {
+	d.mu.RLock()
+	store := d.store
+	limit := d.failureLimit
+	d.mu.RUnlock()
+
+	if store == nil || barkKey == "" || (err != nil && !deadKeyError(err)) {
+		return
+	}
+
+	d.healthMu.Lock()
+	defer d.healthMu.Unlock()
+
+	h := store.GetDeliveryHealth(barkKey)
+	if err == nil {
+		if h.ConsecutiveFailures == 0 && !h.Broken() {
+			return
+		}
+		if h.Broken() {
+			slog.Info("Bark key delivering again, resuming notifications", "bark_key", maskKey(barkKey))
+		}
+		h = &model.DeliveryHealth{BarkKey: barkKey}
+	} else {
+		now := time.Now()
+		h.ConsecutiveFailures++
+		h.LastError = err.Error()
+		h.LastFailureAt = &now
+		if limit > 0 && h.ConsecutiveFailures >= limit && !h.Broken() {
+			h.BrokenAt = &now
+			slog.Warn("Bark key keeps failing, pausing its notifications",
+				"bark_key", maskKey(barkKey), "failures", h.ConsecutiveFailures, "error", err)
+		}
+	}
+
+	if err := store.SetDeliveryHealth(h); err != nil {
+		slog.Error("Failed to save delivery health", "bark_key", maskKey(barkKey), "error", err)
+	}
+}
@@ -0,0 +1,106 @@
+package notify
+
+import (
+	"fmt"
+	"log/slog"
+	"time"
+
+	"apple-price/internal/model"
+)
+
+// bufferDigest stores a new arrival match for an hourly/daily subscription.
+// The product is marked notified right away so later scrapes don't buffer it again.
+func (d *Dispatcher) bufferDigest(store StoreInterface, product *model.Product, sub *model.NewArrivalSubscription) {
+	item := &model.PendingNotification{
+		SubscriptionID:  sub.ID,
+		ProductID:       product.ID,
+		ProductName:     product.Name,
+		ProductCategory: product.Category,
+		ProductPrice:    product.Price,
+		ProductImageURL: product.ImageURL,
+		ProductURL:      product.ProductURL,
+		CreatedAt:       time.Now(),
+	}
+
+	if err := store.AddPendingNotification(item); err != nil {
+		slog.Error("Failed to buffer digest notification", "subscription_id", sub.ID, "error", err)
+		return
+	}
+	if err := store.UpdateNotifiedProductIDs(sub.ID, product.ID); err != nil {
+		slog.Error("Failed to update notified_product_ids", "subscription_id", sub.ID, "error", err)
+	}
+
+	slog.Debug("New arrival buffered for digest", "subscription", sub.Name, "product", product.Name, "frequency", sub.Frequency)
+}
+
+// FlushDigests sends one combined push per digest subscription whose oldest
+// buffered match has waited a full interval (an hour or a day). Subscriptions
+// that are paused or inside quiet hours keep their buffer for the next run.
+func (d *Dispatcher) FlushDigests(subscriptions []*model.NewArrivalSubscription) error {
+	d.mu.RLock()
+	bark := d.bark
+	store := d.store
+	d.mu.RUnlock()
+
+	if bark == nil || store == nil {
+		return nil
+	}
+
+	now := time.Now()
+	for _, sub := range subscriptions {
+		interval := model.DigestInterval(sub.Frequency)
+		if !sub.Enabled || sub.Paused || sub.BarkKey == "" || d.isPausedAll(sub.BarkKey) {
+			continue
+		}
+		if _, quiet := sub.QuietUntil(now); quiet {
+			continue
+		}
+
+		// A subscription switched back to instant sends what is left right away
+		items := store.GetPendingNotifications(sub.ID)
+		if len(items) == 0 || (interval > 0 && now.Sub(items[0].CreatedAt) < interval) {
+			continue
+		}
+
+		// The buffering is deliberate, so latency counts from when the digest fell due
+		due := items[0].CreatedAt.Add(interval)
+		msg, err := bark.Server(sub.BarkServer).SendDigestNotification(sub.BarkKey, d.locale(sub.BarkKey, sub.Language), sub.Name, items)
+		digest := digestProduct(items)
+		if err != nil {
+			// The buffer is kept, so the next flush retries the digest
+			slog.Warn("Bark digest notification failed", "subscription_id", sub.ID, "error", err)
+			d.trackDelivery(sub.BarkKey, err)
+			d.recordNotificationHistory(store, sub.ID, sub.BarkKey, digest, msg, "new_arrival_digest", "failed", err.Error(), due)
+			continue
+		}
+
+		slog.Info("Digest notification sent", "subscription", sub.Name, "products", len(items))
+		d.recordNotificationHistory(store, sub.ID, sub.BarkKey, digest, msg, "new_arrival_digest", "sent", "", due)
+
+		if err := store.ClearPendingNotifications(sub.ID); err != nil {
+			slog.Error("Failed to clear digest buffer", "subscription_id", sub.ID, "error", err)
+		}
+		if err := store.IncrementNotificationCount(sub.ID); err != nil {
+			slog.Error("Failed to increment notification count", "subscription_id", sub.ID, "error", err)
+		}
+	}
+
+	return nil
+}
+
+// digestProduct summarizes a digest as a product for notification history,
+// using the first buffered item for ID, category and image
+func digestProduct(items []model.PendingNotification) *model.Product {
+	first := items[0]
+	name := first.ProductName
+	if len(items) > 1 {
+		name = fmt.Sprintf("%s 等 %d 款新品", first.ProductName, len(items))
+	}
+	return &model.Product{
+		ID:       first.ProductID,
+		Name:     name,
+		Category: first.ProductCategory,
+		Price:    first.ProductPrice,
+		ImageURL: first.ProductImageURL,
+	}
+}
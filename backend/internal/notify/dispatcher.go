@@ -3,32 +3,77 @@ package notify
 import (
 	"encoding/json"
 	"fmt"
-	"log"
+	"log/slog"
 	"sync"
 	"time"
 
+	"apple-price/internal/match"
 	"apple-price/internal/model"
 )
 
-// StoreInterface for updating notified product IDs
-type StoreInterface interface {
+// SubscriptionStore is the subscription bookkeeping the dispatcher updates after a delivery
+type SubscriptionStore interface {
 	UpdateNotifiedProductIDs(subscriptionID, productID string) error
-	AddNotificationHistory(history *model.NotificationHistory) error
 	IncrementNotificationCount(id string) error
+	GetPreferences(barkKey string) *model.UserPreferences
+}
+
+// NotificationStore records sent notifications and buffers digest items
+type NotificationStore interface {
+	AddNotificationHistory(history *model.NotificationHistory) error
+	AddPendingNotification(item *model.PendingNotification) error
+	GetPendingNotifications(subscriptionID string) []model.PendingNotification
+	ClearPendingNotifications(subscriptionID string) error
+}
+
+// StoreInterface defines the store interface needed by the dispatcher
+type StoreInterface interface {
+	SubscriptionStore
+	NotificationStore
+	RetryStore
+	HeldStore
+	DeliveryHealthStore
+	GetInventoryVelocity() model.InventoryVelocityIndex
+}
+
+// UsageCounter counts deliveries for the operator's usage report
+type UsageCounter interface {
+	RecordNotification(notificationType, status string)
 }
 
 // Dispatcher handles notification dispatch for price changes
 type Dispatcher struct {
-	bark  *BarkService
-	store StoreInterface
-	mu    sync.RWMutex
+	bark        *BarkService
+	store       StoreInterface
+	operatorKey string
+	usage       UsageCounter
+	mu          sync.RWMutex
+
+	// Consecutive push failures after which a Bark Key is marked broken
+	failureLimit int
+	healthMu     sync.Mutex
+
+	// Worker sending notifications held until their subscriber's quiet hours end
+	flusherOnce sync.Once
+
+	// Recent (Bark key, type, product) deliveries, for cross-subscription dedupe
+	delivered map[string]time.Time
+	dedupeMu  sync.Mutex
+
+	// Cached Bark key validation results
+	keyChecks   map[string]*KeyValidation
+	keyChecksMu sync.Mutex
+
+	// Worker re-sending failed pushes from the persistent retry queue
+	retryOnce sync.Once
 }
 
 // NewDispatcher creates a new notification dispatcher
 func NewDispatcher(bark *BarkService, store StoreInterface) *Dispatcher {
 	return &Dispatcher{
-		bark:  bark,
-		store: store,
+		bark:         bark,
+		store:        store,
+		failureLimit: model.DefaultDeliveryFailureLimit,
 	}
 }
 
@@ -43,79 +88,249 @@ func (d *Dispatcher) SetStore(store StoreInterface) {
 func (d *Dispatcher) NotifyPriceChange(product *model.Product, oldPrice, newPrice float64, subscriptions []*model.Subscription) error {
 	d.mu.RLock()
 	bark := d.bark
+	store := d.store
 	d.mu.RUnlock()
 
 	if len(subscriptions) == 0 {
 		return nil
 	}
 
-	var wg sync.WaitGroup
-	errChan := make(chan error, len(subscriptions))
+	sellOutHours := d.sellOutHours(product)
+	detected := time.Now()
+
+	var jobs []func() error
 
 	for _, sub := range subscriptions {
-		wg.Add(1)
-		go func(s *model.Subscription) {
-			defer wg.Done()
+		s := sub
 
-			// Check target price condition (断层领先: 价格到达目标价才通知)
-			if s.TargetPrice > 0 && newPrice > s.TargetPrice {
-				// Price hasn't reached target yet, skip notification
-				return
-			}
+		// Check target price condition (断层领先: 价格到达目标价才通知)
+		if s.TargetPrice > 0 && newPrice > s.TargetPrice {
+			// Price hasn't reached target yet, skip notification
+			continue
+		}
 
-			// Send Bark notification
-			if s.BarkKey != "" && bark != nil {
-				if err := bark.SendPriceChangeNotification(
-					s.BarkKey,
-					product.Name,
-					oldPrice,
-					newPrice,
-					product.ProductURL,
-				); err != nil {
-					log.Printf("Bark notification failed for %s: %v", s.ID, err)
-					errChan <- err
-				} else {
-					log.Printf("Bark notification sent to %s for %s (price: %.0f, target: %.0f)",
-						s.BarkKey, product.Name, newPrice, s.TargetPrice)
+		if s.Paused || s.BarkKey == "" || bark == nil || d.isPausedAll(s.BarkKey) {
+			continue
+		}
+
+		render := func(b *BarkService) (*Message, error) {
+			return b.SendPriceChangeNotification(
+				s.BarkKey,
+				d.locale(s.BarkKey, s.Language),
+				product.Name,
+				oldPrice,
+				newPrice,
+				product.ID,
+				product.ProductURL,
+				sellOutHours,
+			)
+		}
+		deliver := func(detectedAt time.Time) error {
+			msg, err := render(bark.Server(s.BarkServer))
+			if err != nil {
+				slog.Warn("Bark price notification failed", "subscription_id", s.ID, "error", err)
+				if store != nil {
+					d.recordFailure(store, s.ID, s.BarkKey, s.BarkServer, product, msg, "price_change", err, detectedAt)
 				}
+				return err
 			}
-		}(sub)
-	}
+			slog.Info("Bark price notification sent",
+				"subscription_id", s.ID, "product", product.Name, "price", newPrice, "target_price", s.TargetPrice)
+			if store != nil {
+				d.recordNotificationHistory(store, s.ID, s.BarkKey, product, msg, "price_change", "sent", "", detectedAt)
+				countNotification(store, s.ID)
+			}
+			return nil
+		}
 
-	wg.Wait()
-	close(errChan)
+		if d.holdIfQuiet(d.quietUntil(s.BarkKey, s.QuietHoursStart, s.QuietUntil), heldPush(s.ID, s.BarkKey, s.BarkServer, "price_change", product), render) {
+			continue
+		}
+		jobs = append(jobs, func() error { return deliver(detected) })
+	}
 
-	// Collect errors
-	var errors []error
-	for err := range errChan {
-		errors = append(errors, err)
+	if len(jobs) == 0 {
+		return nil
 	}
 
-	if len(errors) > 0 {
-		log.Printf("Notification dispatch completed with %d errors", len(errors))
+	if failed := runJobs(bark.Concurrency(), jobs); failed > 0 {
+		slog.Warn("Notification dispatch completed with errors", "product", product.Name, "failed", failed)
 	}
 
 	return nil
 }
 
+// runJobs runs jobs on a bounded pool of workers and returns how many failed
+func runJobs(workers int, jobs []func() error) int {
+	if workers < 1 {
+		workers = 1
+	}
+	if workers > len(jobs) {
+		workers = len(jobs)
+	}
+
+	queue := make(chan func() error)
+	var failed int
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range queue {
+				if err := job(); err != nil {
+					mu.Lock()
+					failed++
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+
+	for _, job := range jobs {
+		queue <- job
+	}
+	close(queue)
+	wg.Wait()
+
+	return failed
+}
+
 // NotifyStockChange notifies subscribers of stock status change
 func (d *Dispatcher) NotifyStockChange(product *model.Product, oldStatus, newStatus string, subscriptions []*model.Subscription) error {
 	d.mu.RLock()
 	bark := d.bark
+	store := d.store
 	d.mu.RUnlock()
 
+	detected := time.Now()
 	for _, sub := range subscriptions {
+		if sub.Paused || d.isPausedAll(sub.BarkKey) {
+			continue
+		}
+
 		// Send Bark notification
 		if sub.BarkKey != "" && bark != nil {
-			if err := bark.SendStockNotification(
-				sub.BarkKey,
+			render := func(b *BarkService) (*Message, error) {
+				return b.SendStockNotification(
+					sub.BarkKey,
+					d.locale(sub.BarkKey, sub.Language),
+					product.Name,
+					newStatus,
+					product.ID,
+					product.ProductURL,
+				)
+			}
+			send := func(detectedAt time.Time) {
+				msg, err := render(bark.Server(sub.BarkServer))
+				if err != nil {
+					slog.Warn("Bark stock notification failed", "subscription_id", sub.ID, "error", err)
+					if store != nil {
+						d.recordFailure(store, sub.ID, sub.BarkKey, sub.BarkServer, product, msg, "stock_change", err, detectedAt)
+					}
+					return
+				}
+
+				slog.Info("Stock notification sent", "product", product.Name, "old_status", oldStatus, "new_status", newStatus)
+				if store != nil {
+					d.recordNotificationHistory(store, sub.ID, sub.BarkKey, product, msg, "stock_change", "sent", "", detectedAt)
+					countNotification(store, sub.ID)
+				}
+			}
+
+			if !d.holdIfQuiet(d.quietUntil(sub.BarkKey, sub.QuietHoursStart, sub.QuietUntil), heldPush(sub.ID, sub.BarkKey, sub.BarkServer, "stock_change", product), render) {
+				send(detected)
+			}
+		}
+	}
+
+	return nil
+}
+
+// NotifyRestock notifies product subscribers and matching new arrival subscribers
+// that a previously sold out product is back in stock
+func (d *Dispatcher) NotifyRestock(product *model.Product, subscriptions []*model.Subscription, arrivalSubscriptions []*model.NewArrivalSubscription) error {
+	d.mu.RLock()
+	bark := d.bark
+	store := d.store
+	d.mu.RUnlock()
+
+	if bark == nil || store == nil {
+		return nil
+	}
+
+	// Each Bark Key gets at most one restock push for this product
+	notified := make(map[string]bool)
+	detected := time.Now()
+
+	render := func(barkKey, language string) func(b *BarkService) (*Message, error) {
+		return func(b *BarkService) (*Message, error) {
+			return b.SendRestockNotification(
+				barkKey,
+				d.locale(barkKey, language),
 				product.Name,
-				newStatus,
+				product.Category,
+				product.Price,
+				product.ID,
 				product.ProductURL,
-			); err != nil {
-				log.Printf("Bark stock notification failed for %s: %v", sub.ID, err)
+			)
+		}
+	}
+	send := func(subscriptionID, barkKey, barkServer, language string, detectedAt time.Time) bool {
+		msg, err := render(barkKey, language)(bark.Server(barkServer))
+		if err != nil {
+			slog.Warn("Bark restock notification failed", "subscription_id", subscriptionID, "error", err)
+			d.recordFailure(store, subscriptionID, barkKey, barkServer, product, msg, "restock", err, detectedAt)
+			return false
+		}
+
+		d.recordNotificationHistory(store, subscriptionID, barkKey, product, msg, "restock", "sent", "", detectedAt)
+		return true
+	}
+
+	for _, sub := range subscriptions {
+		if sub.Paused || sub.BarkKey == "" || notified[sub.BarkKey] || d.isPausedAll(sub.BarkKey) {
+			continue
+		}
+		deliver := func(detectedAt time.Time) bool {
+			if !send(sub.ID, sub.BarkKey, sub.BarkServer, sub.Language, detectedAt) {
+				return false
+			}
+			countNotification(store, sub.ID)
+			return true
+		}
+		if d.holdIfQuiet(d.quietUntil(sub.BarkKey, sub.QuietHoursStart, sub.QuietUntil), heldPush(sub.ID, sub.BarkKey, sub.BarkServer, "restock", product), render(sub.BarkKey, sub.Language)) {
+			notified[sub.BarkKey] = true
+			continue
+		}
+		if deliver(detected) {
+			notified[sub.BarkKey] = true
+		}
+	}
+
+	for _, sub := range arrivalSubscriptions {
+		if !sub.Enabled || sub.Paused || sub.BarkKey == "" || notified[sub.BarkKey] || d.isPausedAll(sub.BarkKey) {
+			continue
+		}
+		if !match.Subscription(sub, product) {
+			continue
+		}
+		deliver := func(detectedAt time.Time) {
+			if send(sub.ID, sub.BarkKey, sub.BarkServer, sub.Language, detectedAt) {
+				if err := store.IncrementNotificationCount(sub.ID); err != nil {
+					slog.Error("Failed to increment notification count", "subscription_id", sub.ID, "error", err)
+				}
 			}
 		}
+		notified[sub.BarkKey] = true
+		if !d.holdIfQuiet(d.quietUntil(sub.BarkKey, sub.QuietHoursStart, sub.QuietUntil), heldPush(sub.ID, sub.BarkKey, sub.BarkServer, "restock", product), render(sub.BarkKey, sub.Language)) {
+			deliver(detected)
+		}
+	}
+
+	if len(notified) > 0 {
+		slog.Info("Restock notification sent", "product", product.Name, "recipients", len(notified))
 	}
 
 	return nil
@@ -136,14 +351,18 @@ func (d *Dispatcher) NotifyNewArrival(product *model.Product, subscriptions []*m
 		return nil
 	}
 
+	sellOutHours := d.sellOutHours(product)
+	detected := time.Now()
+
 	for _, sub := range subscriptions {
 		// Skip disabled or paused subscriptions
 		if !sub.Enabled || sub.Paused {
 			continue
 		}
 
-		// Skip if no Bark Key configured for this subscription
-		if sub.BarkKey == "" {
+		// Skip if no Bark Key configured for this subscription, or its owner paused
+		// every push (or it stopped accepting them)
+		if sub.BarkKey == "" || d.isPausedAll(sub.BarkKey) {
 			continue
 		}
 
@@ -165,57 +384,122 @@ func (d *Dispatcher) NotifyNewArrival(product *model.Product, subscriptions []*m
 		}
 
 		// Check if product matches subscription criteria
-		if !d.matchesSubscription(product, sub) {
+		if !match.Subscription(sub, product) {
+			continue
+		}
+
+		// Overlapping subscriptions on the same key get one push per product
+		if !d.claimDelivery(sub.BarkKey, "new_arrival", product.ID) {
+			slog.Debug("Skipping duplicate new arrival notification", "subscription_id", sub.ID, "product_id", product.ID)
+			continue
+		}
+
+		// Digest subscriptions buffer the match and get one combined push later
+		if model.DigestInterval(sub.Frequency) > 0 {
+			d.bufferDigest(store, product, sub)
 			continue
 		}
 
 		// Send Bark notification using subscription's Bark Key
 		if bark != nil {
-			var err error
-			// Use enhanced notification with specs
-			if err = bark.SendNewArrivalNotificationEnhanced(
-				sub.BarkKey,
-				product.Name,
-				product.Category,
-				product.Price,
-				product.Discount,
-				product.ImageURL,
-				product.ProductURL,
-				product.SpecsDetail,
-			); err != nil {
-				log.Printf("Bark new arrival notification failed for %s: %v", sub.ID, err)
+			d.deliverNewArrival(bark, store, product, sub, sellOutHours, detected)
+		}
+	}
 
-				// Record failed notification history
-				d.recordNotificationHistory(store, sub.ID, sub.BarkKey, product, "failed", err.Error())
-				continue
+	return nil
+}
+
+// deliverNewArrival sends (or holds during quiet hours) one new arrival push and
+// records it against the subscription
+func (d *Dispatcher) deliverNewArrival(bark *BarkService, store StoreInterface, product *model.Product, sub *model.NewArrivalSubscription, sellOutHours float64, detected time.Time) {
+	// Use enhanced notification with specs
+	render := func(b *BarkService) (*Message, error) {
+		return b.SendNewArrivalNotificationEnhanced(
+			sub.BarkKey,
+			d.locale(sub.BarkKey, sub.Language),
+			product.Name,
+			product.Category,
+			product.Price,
+			product.Discount,
+			product.ID,
+			product.ImageURL,
+			product.ProductURL,
+			product.SpecsDetail,
+			product.RefurbTermsSummary(),
+			sellOutHours,
+		)
+	}
+	send := func(detectedAt time.Time) {
+		msg, err := render(bark.Server(sub.BarkServer))
+		if err != nil {
+			slog.Warn("Bark new arrival notification failed", "subscription_id", sub.ID, "error", err)
+
+			// Record failed notification history; a queued retry keeps the delivery claimed
+			if !d.recordFailure(store, sub.ID, sub.BarkKey, sub.BarkServer, product, msg, "new_arrival", err, detectedAt) {
+				d.releaseDelivery(sub.BarkKey, "new_arrival", product.ID)
 			}
+			return
+		}
 
-			log.Printf("New arrival notification sent for subscription %s, product %s", sub.Name, product.Name)
+		slog.Info("New arrival notification sent", "subscription", sub.Name, "product", product.Name)
 
-			// Record successful notification history
-			d.recordNotificationHistory(store, sub.ID, sub.BarkKey, product, "sent", "")
+		// Record successful notification history
+		d.recordNotificationHistory(store, sub.ID, sub.BarkKey, product, msg, "new_arrival", "sent", "", detectedAt)
 
-			// Update notified product IDs and increment count
-			if err := store.UpdateNotifiedProductIDs(sub.ID, product.ID); err != nil {
-				log.Printf("Failed to update notified_product_ids for %s: %v", sub.ID, err)
-			}
-			if err := store.IncrementNotificationCount(sub.ID); err != nil {
-				log.Printf("Failed to increment notification count for %s: %v", sub.ID, err)
-			}
+		// Update notified product IDs and increment count
+		if err := store.UpdateNotifiedProductIDs(sub.ID, product.ID); err != nil {
+			slog.Error("Failed to update notified_product_ids", "subscription_id", sub.ID, "error", err)
+		}
+		if err := store.IncrementNotificationCount(sub.ID); err != nil {
+			slog.Error("Failed to increment notification count", "subscription_id", sub.ID, "error", err)
 		}
 	}
 
-	return nil
+	held := heldPush(sub.ID, sub.BarkKey, sub.BarkServer, "new_arrival", product)
+	held.Notified = map[string][]string{sub.ID: {product.ID}}
+	if !d.holdIfQuiet(d.quietUntil(sub.BarkKey, sub.QuietHoursStart, sub.QuietUntil), held, render) {
+		send(detected)
+	}
+}
+
+// countNotification counts a delivered notification on its subscription
+func countNotification(store StoreInterface, subscriptionID string) {
+	if err := store.IncrementNotificationCount(subscriptionID); err != nil {
+		slog.Error("Failed to increment notification count", "subscription_id", subscriptionID, "error", err)
+	}
+}
+
+// isPausedAll reports whether notifications to barkKey are paused: by its owner,
+// or because the key was marked broken after failing too often
+func (d *Dispatcher) isPausedAll(barkKey string) bool {
+	d.mu.RLock()
+	store := d.store
+	d.mu.RUnlock()
+
+	if store == nil || barkKey == "" {
+		return false
+	}
+	return store.GetPreferences(barkKey).PausedAll || store.GetDeliveryHealth(barkKey).Broken()
 }
 
-// recordNotificationHistory records a notification in history
-func (d *Dispatcher) recordNotificationHistory(store StoreInterface, subscriptionID string, barkKey string, product *model.Product, status, errorMsg string) {
-	// Mask the Bark key for privacy
-	maskedKey := ""
-	if len(barkKey) > 0 {
-		maskedKey = barkKey[:4] + "****" + barkKey[len(barkKey)-4:]
+// sellOutHours returns how quickly similar listings usually sell out (0 if unknown)
+func (d *Dispatcher) sellOutHours(product *model.Product) float64 {
+	d.mu.RLock()
+	store := d.store
+	d.mu.RUnlock()
+
+	if store == nil {
+		return 0
+	}
+	if v := store.GetInventoryVelocity().Lookup(product); v != nil {
+		return v.MedianHours
 	}
+	return 0
+}
 
+// recordNotificationHistory records a notification in history, including the rendered
+// message. Sent notifications also record their delivery latency since detectedAt.
+func (d *Dispatcher) recordNotificationHistory(store StoreInterface, subscriptionID string, barkKey string, product *model.Product, msg *Message, notificationType, status, errorMsg string, detectedAt time.Time) *model.NotificationHistory {
 	history := &model.NotificationHistory{
 		ID:              generateHistoryID(),
 		SubscriptionID:  subscriptionID,
@@ -225,145 +509,61 @@ func (d *Dispatcher) recordNotificationHistory(store StoreInterface, subscriptio
 		ProductPrice:    product.Price,
 		ProductImageURL: product.ImageURL,
 		ProductSpecs:    product.SpecsDetail,
-		NotificationType: "new_arrival",
+		NotificationType: notificationType,
 		Status:          status,
 		ErrorMessage:    errorMsg,
+		Channel:         model.ChannelBark,
 		BarkKey:         barkKey,
-		BarkKeyMasked:   maskedKey,
+		BarkKeyMasked:   maskKey(barkKey), // Mask the Bark key for privacy
 		CreatedAt:       time.Now(),
 	}
 
-	if err := store.AddNotificationHistory(history); err != nil {
-		log.Printf("Failed to record notification history: %v", err)
-	}
-}
-
-// generateHistoryID generates a unique ID for notification history
-func generateHistoryID() string {
-	return fmt.Sprintf("nh-%d", time.Now().UnixNano())
-}
-
-// matchesSubscription checks if a product matches the subscription criteria
-func (d *Dispatcher) matchesSubscription(product *model.Product, sub *model.NewArrivalSubscription) bool {
-	// Check category filter
-	if len(sub.Categories) > 0 {
-		categoryMatch := false
-		for _, cat := range sub.Categories {
-			if cat == product.Category {
-				categoryMatch = true
-				break
-			}
-		}
-		if !categoryMatch {
-			return false
+	if status == "sent" {
+		if !detectedAt.IsZero() {
+			history.DeliveryLatencyMs = deliveryLatency(detectedAt)
 		}
+		d.trackDelivery(barkKey, nil)
 	}
 
-	// Check model filter
-	if len(sub.Models) > 0 {
-		modelMatch := false
-		productName := product.Name
-		for _, model := range sub.Models {
-			if contains(productName, model) {
-				modelMatch = true
-				break
-			}
-		}
-		if !modelMatch {
-			return false
-		}
+	if msg != nil {
+		history.Title = msg.Title
+		history.Body = msg.Body
+		history.ChannelParams = msg.ChannelParams()
 	}
 
-	// Check price range
-	if sub.MinPrice > 0 && product.Price < sub.MinPrice {
-		return false
-	}
-	if sub.MaxPrice > 0 && product.Price > sub.MaxPrice {
-		return false
-	}
-
-	// Check keywords
-	if len(sub.Keywords) > 0 {
-		keywordMatch := false
-		for _, kw := range sub.Keywords {
-			if contains(product.Name, kw) || contains(product.Specs, kw) {
-				keywordMatch = true
-				break
-			}
-		}
-		if !keywordMatch {
-			return false
-		}
+	if err := store.AddNotificationHistory(history); err != nil {
+		slog.Error("Failed to record notification history", "error", err)
 	}
 
-	// Check chip filter
-	if len(sub.Chips) > 0 {
-		chipMatch := false
-		productSpecsLower := toLower(product.Specs + " " + product.Name)
-		for _, chip := range sub.Chips {
-			if containsIgnoreCase(productSpecsLower, toLower(chip)) {
-				chipMatch = true
-				break
-			}
-		}
-		if !chipMatch {
-			return false
-		}
+	d.mu.RLock()
+	usage := d.usage
+	d.mu.RUnlock()
+	if usage != nil {
+		usage.RecordNotification(notificationType, status)
 	}
+	return history
+}
 
-	// Check storage filter
-	if len(sub.Storages) > 0 {
-		storageMatch := false
-		productSpecsLower := toLower(product.Specs)
-		for _, storage := range sub.Storages {
-			if containsIgnoreCase(productSpecsLower, toLower(storage)) {
-				storageMatch = true
-				break
-			}
-		}
-		if !storageMatch {
-			return false
-		}
-	}
+// deliveryLatency returns the milliseconds since an event was detected
+func deliveryLatency(detectedAt time.Time) *int64 {
+	ms := time.Since(detectedAt).Milliseconds()
+	return &ms
+}
 
-	// Check memory filter
-	if len(sub.Memories) > 0 {
-		memoryMatch := false
-		productSpecsLower := toLower(product.Specs)
-		for _, memory := range sub.Memories {
-			if containsIgnoreCase(productSpecsLower, toLower(memory)) {
-				memoryMatch = true
-				break
-			}
-		}
-		if !memoryMatch {
-			return false
-		}
+// maskKey hides the middle of a Bark key
+func maskKey(barkKey string) string {
+	if barkKey == "" {
+		return ""
 	}
-
-	// Check stock status filter
-	if len(sub.StockStatuses) > 0 {
-		stockMatch := false
-		for _, status := range sub.StockStatuses {
-			if product.StockStatus == status {
-				stockMatch = true
-				break
-			}
-		}
-		if !stockMatch {
-			return false
-		}
+	if len(barkKey) < 8 {
+		return "****"
 	}
-
-	return true
+	return barkKey[:4] + "****" + barkKey[len(barkKey)-4:]
 }
 
-// contains is a case-insensitive substring check
-func contains(s, substr string) bool {
-	return len(s) >= len(substr) && (s == substr ||
-		len(s) > len(substr) && (s[:len(substr)] == substr ||
-			s[len(s)-len(substr):] == substr ||
-			containsIgnoreCase(s, substr)))
+// generateHistoryID generates a unique ID for notification history
+func generateHistoryID() string {
+	return fmt.Sprintf("nh-%d", time.Now().UnixNano())
 }
 
 func containsIgnoreCase(s, substr string) bool {
@@ -390,6 +590,49 @@ func toLower(s string) string {
 	return string(result)
 }
 
+// SendTestNotification sends a test push to a Bark key to verify it can receive notifications
+func (d *Dispatcher) SendTestNotification(barkKey string) error {
+	d.mu.RLock()
+	bark := d.bark
+	d.mu.RUnlock()
+
+	if bark == nil {
+		return fmt.Errorf("bark service not configured")
+	}
+
+	err := bark.SendTestNotification(barkKey, d.locale(barkKey, ""))
+	d.trackDelivery(barkKey, err)
+	return err
+}
+
+// SetUsageCounter sets where deliveries are counted; nil turns counting off
+func (d *Dispatcher) SetUsageCounter(usage UsageCounter) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.usage = usage
+}
+
+// SetOperatorKey sets the Bark key that receives operator alerts (storage, scraper health)
+func (d *Dispatcher) SetOperatorKey(key string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.operatorKey = key
+}
+
+// NotifyOperator sends an alert to the operator channel; it is a no-op when no operator key is set
+func (d *Dispatcher) NotifyOperator(title, content string) error {
+	d.mu.RLock()
+	bark := d.bark
+	key := d.operatorKey
+	d.mu.RUnlock()
+
+	if bark == nil || key == "" {
+		return nil
+	}
+
+	return bark.SendNotification(key, title, content)
+}
+
 // GetBarkService returns the Bark service
 func (d *Dispatcher) GetBarkService() *BarkService {
 	d.mu.RLock()
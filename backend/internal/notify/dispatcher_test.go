@@ -0,0 +1,192 @@
+package notify
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"apple-price/internal/model"
+	"apple-price/internal/store"
+)
+
+// newTestDispatcher returns a dispatcher on a fresh JSON store whose Bark
+// channel counts the pushes instead of sending them
+func newTestDispatcher(t *testing.T) (*Dispatcher, *store.Store, func() int) {
+	t.Helper()
+	st, err := store.New(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	d, pushes := sandboxDispatcher(st)
+	return d, st, pushes
+}
+
+// sandboxDispatcher returns a dispatcher on st whose Bark channel counts the
+// pushes instead of sending them
+func sandboxDispatcher(st StoreInterface) (*Dispatcher, func() int) {
+	var mu sync.Mutex
+	pushes := 0
+	bark := NewBarkService().Sandbox(func(key string, msg *Message) {
+		mu.Lock()
+		defer mu.Unlock()
+		pushes++
+	})
+	count := func() int {
+		mu.Lock()
+		defer mu.Unlock()
+		return pushes
+	}
+	return NewDispatcher(bark, st), count
+}
+
+func TestPausedAllStopsPushes(t *testing.T) {
+	product := &model.Product{
+		ID:          "mbp",
+		Name:        "翻新 14 英寸 MacBook Pro Apple M3 Pro 芯片 - 深空黑色",
+		Category:    "Mac",
+		Region:      "cn",
+		Price:       13589,
+		StockStatus: "available",
+	}
+
+	tests := []struct {
+		name       string
+		notify     func(d *Dispatcher, arrival *model.NewArrivalSubscription) error
+		wantPushes int
+	}{
+		{
+			name: "new arrival",
+			notify: func(d *Dispatcher, arrival *model.NewArrivalSubscription) error {
+				return d.NotifyNewArrival(product, []*model.NewArrivalSubscription{arrival})
+			},
+			wantPushes: 1,
+		},
+		{
+			name: "restock",
+			notify: func(d *Dispatcher, arrival *model.NewArrivalSubscription) error {
+				sub := &model.Subscription{ID: "sub", ProductID: product.ID, BarkKey: arrival.BarkKey}
+				return d.NotifyRestock(product, []*model.Subscription{sub}, []*model.NewArrivalSubscription{arrival})
+			},
+			wantPushes: 1, // one restock push per Bark Key
+		},
+		{
+			name: "restock to new arrival subscribers",
+			notify: func(d *Dispatcher, arrival *model.NewArrivalSubscription) error {
+				return d.NotifyRestock(product, nil, []*model.NewArrivalSubscription{arrival})
+			},
+			wantPushes: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		for _, pausedAll := range []bool{false, true} {
+			name := tt.name
+			if pausedAll {
+				name += " paused all"
+			}
+			t.Run(name, func(t *testing.T) {
+				d, st, pushes := newTestDispatcher(t)
+				arrival := &model.NewArrivalSubscription{
+					ID:         "arrival",
+					BarkKey:    "key",
+					Categories: []string{"Mac"},
+					Enabled:    true,
+				}
+				if err := st.AddNewArrivalSubscription(arrival); err != nil {
+					t.Fatal(err)
+				}
+				if _, err := st.SetAllPaused(arrival.BarkKey, pausedAll); err != nil {
+					t.Fatal(err)
+				}
+
+				if err := tt.notify(d, arrival); err != nil {
+					t.Fatal(err)
+				}
+
+				want := tt.wantPushes
+				if pausedAll {
+					want = 0
+				}
+				if got := pushes(); got != want {
+					t.Errorf("sent %d pushes, want %d", got, want)
+				}
+			})
+		}
+	}
+}
+
+// notifyStore is a store the held notifications test can reopen
+type notifyStore interface {
+	StoreInterface
+	Save() error
+	GetNotificationHistory(subscriptionID string, barkKey string, limit, offset int) ([]*model.NotificationHistory, int)
+}
+
+func TestHeldNotificationsSurviveRestart(t *testing.T) {
+	tests := []struct {
+		name string
+		open func(dir string) (notifyStore, error)
+	}{
+		{"json", func(dir string) (notifyStore, error) { return store.New(dir) }},
+		{"sqlite", func(dir string) (notifyStore, error) { return store.NewSQLite(dir) }},
+	}
+
+	now := time.Now().UTC()
+	product := &model.Product{ID: "mbp", Name: "翻新 14 英寸 MacBook Pro", Category: "Mac", Region: "cn", Price: 13589}
+	sub := &model.Subscription{
+		ID:              "sub",
+		ProductID:       product.ID,
+		BarkKey:         "key",
+		QuietHoursStart: now.Add(-time.Hour).Format("15:04"),
+		QuietHoursEnd:   now.Add(time.Hour).Format("15:04"),
+		Timezone:        "UTC",
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			st, err := tt.open(dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			d, pushes := sandboxDispatcher(st)
+
+			// Two price changes inside the quiet window: the later replaces the earlier
+			for _, price := range []float64{13999, 13589} {
+				if err := d.NotifyPriceChange(product, 14999, price, []*model.Subscription{sub}); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if got := pushes(); got != 0 {
+				t.Fatalf("sent %d pushes during quiet hours, want 0", got)
+			}
+			if got := d.HeldCount(); got != 1 {
+				t.Fatalf("HeldCount() = %d, want 1", got)
+			}
+			if err := st.Save(); err != nil {
+				t.Fatal(err)
+			}
+
+			// A new process picks the held push up from the store
+			st, err = tt.open(dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			d, pushes = sandboxDispatcher(st)
+			if sent := d.FlushHeld(now); sent != 0 {
+				t.Errorf("FlushHeld() inside the quiet window sent %d, want 0", sent)
+			}
+			if sent := d.FlushHeld(now.Add(2 * time.Hour)); sent != 1 || pushes() != 1 {
+				t.Errorf("FlushHeld() after the quiet window sent %d (%d pushes), want 1", sent, pushes())
+			}
+			if got := d.HeldCount(); got != 0 {
+				t.Errorf("HeldCount() after flushing = %d, want 0", got)
+			}
+
+			history, total := st.GetNotificationHistory(sub.ID, sub.BarkKey, 10, 0)
+			if total != 1 || history[0].Status != "sent" || history[0].ProductName != product.Name {
+				t.Errorf("notification history = %+v, want one sent entry for %s", history, product.Name)
+			}
+		})
+	}
+}
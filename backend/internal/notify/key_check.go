@@ -0,0 +1,79 @@
+package notify
+
+import (
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// Bark key validation results are cached so repeated checks (e.g. a form
+// re-validating on every keystroke) don't hammer api.day.app
+const (
+	keyCheckTTL        = 10 * time.Minute
+	keyCheckFailureTTL = time.Minute // rejected keys are re-checked sooner, the device may just have registered
+)
+
+// KeyValidation is a Bark key check as returned to the API
+type KeyValidation struct {
+	*KeyCheck
+	CheckedAt time.Time `json:"checked_at"`
+	Cached    bool      `json:"cached"`
+}
+
+// ValidateBarkKey checks barkKey against its Bark server (empty = default) with a
+// silent push. Answers are cached per key; errors (server unreachable, rate
+// limited) are not.
+func (d *Dispatcher) ValidateBarkKey(barkKey, barkServer string) (*KeyValidation, error) {
+	d.mu.RLock()
+	bark := d.bark
+	d.mu.RUnlock()
+
+	if bark == nil {
+		return nil, fmt.Errorf("bark service not configured")
+	}
+
+	bark = bark.Server(barkServer)
+	cacheKey := bark.server + "|" + barkKey
+	now := time.Now()
+
+	d.keyChecksMu.Lock()
+	if v, ok := d.keyChecks[cacheKey]; ok && now.Before(v.CheckedAt.Add(keyCheckExpiry(v.KeyCheck))) {
+		d.keyChecksMu.Unlock()
+		cached := *v
+		cached.Cached = true
+		return &cached, nil
+	}
+	d.keyChecksMu.Unlock()
+
+	check, err := bark.PingKey(barkKey)
+	if err != nil {
+		slog.Warn("Bark key validation failed", "bark_key", maskKey(barkKey), "error", err)
+		return nil, err
+	}
+
+	v := &KeyValidation{KeyCheck: check, CheckedAt: now}
+
+	d.keyChecksMu.Lock()
+	defer d.keyChecksMu.Unlock()
+	if d.keyChecks == nil {
+		d.keyChecks = make(map[string]*KeyValidation)
+	}
+	// Prune expired results so the map stays bounded by recent traffic
+	for k, old := range d.keyChecks {
+		if !now.Before(old.CheckedAt.Add(keyCheckExpiry(old.KeyCheck))) {
+			delete(d.keyChecks, k)
+		}
+	}
+	d.keyChecks[cacheKey] = v
+
+	result := *v
+	return &result, nil
+}
+
+// keyCheckExpiry returns how long a check result stays cached
+func keyCheckExpiry(check *KeyCheck) time.Duration {
+	if check.Valid {
+		return keyCheckTTL
+	}
+	return keyCheckFailureTTL
+}
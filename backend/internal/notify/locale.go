@@ -0,0 +1,81 @@
+package notify
+
+import (
+	"fmt"
+	"time"
+
+	"apple-price/internal/model"
+)
+
+// Locale is how a subscriber's messages are written: language and currency style
+// from their preferences. The zero value is the original Chinese, ¥-prefixed style.
+type Locale struct {
+	Language        string // model.LanguageZH (default), model.LanguageZHHK or model.LanguageEN
+	CurrencyDisplay string // model.CurrencySymbol (default) or model.CurrencyCode
+}
+
+// localeOf returns the locale chosen in prefs
+func localeOf(prefs *model.UserPreferences) Locale {
+	if prefs == nil {
+		return Locale{}
+	}
+	return Locale{Language: model.NormalizeLanguage(prefs.Language), CurrencyDisplay: prefs.CurrencyDisplay}
+}
+
+// text returns the catalog message key in the locale's language
+func (l Locale) text(key string) string {
+	texts, ok := catalog[key]
+	if !ok {
+		return key
+	}
+	if text, ok := texts[l.Language]; ok {
+		return text
+	}
+	return texts[model.LanguageZH]
+}
+
+// money formats a price with decimals digits in the locale's currency style
+func (l Locale) money(v float64, decimals int) string {
+	if l.CurrencyDisplay == model.CurrencyCode {
+		return fmt.Sprintf("%.*f CNY", decimals, v)
+	}
+	return fmt.Sprintf("¥%.*f", decimals, v)
+}
+
+// locale returns the message locale of a subscription: its own language when set,
+// otherwise that of its Bark Key's owner, in the owner's currency style
+func (d *Dispatcher) locale(barkKey, language string) Locale {
+	d.mu.RLock()
+	store := d.store
+	d.mu.RUnlock()
+
+	var loc Locale
+	if store != nil && barkKey != "" {
+		loc = localeOf(store.GetPreferences(barkKey))
+	}
+	if language != "" {
+		loc.Language = model.NormalizeLanguage(language)
+	}
+	return loc
+}
+
+// quietUntil returns a subscription's own quiet hours (own, when start is set) or
+// else the default quiet hours in its owner's preferences
+func (d *Dispatcher) quietUntil(barkKey, start string, own func(time.Time) (time.Time, bool)) func(time.Time) (time.Time, bool) {
+	if start != "" {
+		return own
+	}
+
+	d.mu.RLock()
+	store := d.store
+	d.mu.RUnlock()
+
+	if store == nil || barkKey == "" {
+		return own
+	}
+	prefs := store.GetPreferences(barkKey)
+	if prefs.QuietHoursStart == "" {
+		return own
+	}
+	return prefs.QuietUntil
+}
@@ -0,0 +1,60 @@
+package notify
+
+import (
+	"log/slog"
+	"time"
+
+	"apple-price/internal/model"
+)
+
+// NotifyLowStock sends a "库存紧张" warning to subscribers of product that opted in
+// with LowStockAlert. reason explains why the product is expected to go soon.
+func (d *Dispatcher) NotifyLowStock(product *model.Product, reason *model.LowStockReason, subscriptions []*model.Subscription) error {
+	d.mu.RLock()
+	bark := d.bark
+	store := d.store
+	d.mu.RUnlock()
+
+	if bark == nil {
+		return nil
+	}
+
+	detected := time.Now()
+	for _, sub := range subscriptions {
+		if !sub.LowStockAlert || sub.Paused || sub.BarkKey == "" || d.isPausedAll(sub.BarkKey) {
+			continue
+		}
+		// One warning per Bark Key, even if the limited transition and the
+		// velocity projection both fire in the same cycle
+		if !d.claimDelivery(sub.BarkKey, "low_stock", product.ID) {
+			continue
+		}
+
+		render := func(b *BarkService) (*Message, error) {
+			return b.SendLowStockNotification(sub.BarkKey, d.locale(sub.BarkKey, sub.Language), product.Name, reason, product.ID, product.ProductURL)
+		}
+		send := func(detectedAt time.Time) {
+			msg, err := render(bark.Server(sub.BarkServer))
+			if err != nil {
+				slog.Warn("Bark low stock notification failed", "subscription_id", sub.ID, "error", err)
+				// A queued retry keeps the delivery claimed
+				if store == nil || !d.recordFailure(store, sub.ID, sub.BarkKey, sub.BarkServer, product, msg, "low_stock", err, detectedAt) {
+					d.releaseDelivery(sub.BarkKey, "low_stock", product.ID)
+				}
+				return
+			}
+
+			slog.Info("Low stock notification sent", "product", product.Name, "limited", reason.Limited)
+			if store != nil {
+				d.recordNotificationHistory(store, sub.ID, sub.BarkKey, product, msg, "low_stock", "sent", "", detectedAt)
+				countNotification(store, sub.ID)
+			}
+		}
+
+		if !d.holdIfQuiet(d.quietUntil(sub.BarkKey, sub.QuietHoursStart, sub.QuietUntil), heldPush(sub.ID, sub.BarkKey, sub.BarkServer, "low_stock", product), render) {
+			send(detected)
+		}
+	}
+
+	return nil
+}
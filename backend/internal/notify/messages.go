@@ -0,0 +1,213 @@
+package notify
+
+import "apple-price/internal/model"
+
+// catalog holds the text of every push message by key, then language. Formats take
+// the same arguments in every language. Missing languages fall back to zh.
+var catalog = map[string]map[string]string{
+	"price_change.title": {
+		model.LanguageZH:   "🍎 苹果翻新价格变动",
+		model.LanguageZHHK: "🍎 Apple 翻新產品價格變動",
+		model.LanguageEN:   "🍎 Apple Refurbished price change",
+	},
+	"price_change.body": {
+		model.LanguageZH:   "%s 价格从 %s 变为 %s，点击查看详情",
+		model.LanguageZHHK: "%s 價格由 %s 變為 %s，點擊查看詳情",
+		model.LanguageEN:   "%s went from %s to %s, tap for details",
+	},
+	"sample_price.title": {
+		model.LanguageZH:   "🧪 测试通知 · 苹果翻新价格变动",
+		model.LanguageZHHK: "🧪 測試通知 · Apple 翻新產品價格變動",
+		model.LanguageEN:   "🧪 Test · Apple Refurbished price change",
+	},
+	"sample_price.body": {
+		model.LanguageZH:   "（示例数据）%s 价格从 %s 变为 %s\n收到这条消息说明该订阅可以正常接收价格提醒",
+		model.LanguageZHHK: "（示例資料）%s 價格由 %s 變為 %s\n收到這條訊息代表此訂閱可以正常接收價格提醒",
+		model.LanguageEN:   "(Sample data) %s went from %s to %s\nThis subscription can receive price alerts",
+	},
+	"stock.title": {
+		model.LanguageZH:   "🍎 苹果翻新库存提醒",
+		model.LanguageZHHK: "🍎 Apple 翻新產品存貨提醒",
+		model.LanguageEN:   "🍎 Apple Refurbished stock update",
+	},
+	"stock.body": {
+		model.LanguageZH:   "%s 状态更新为: %s",
+		model.LanguageZHHK: "%s 狀態更新為: %s",
+		model.LanguageEN:   "%s is now: %s",
+	},
+	"stock.available": {
+		model.LanguageZH:   "有货",
+		model.LanguageZHHK: "有貨",
+		model.LanguageEN:   "in stock",
+	},
+	"stock.limited": {
+		model.LanguageZH:   "库存紧张",
+		model.LanguageZHHK: "存貨緊張",
+		model.LanguageEN:   "low stock",
+	},
+	"stock.sold_out": {
+		model.LanguageZH:   "已售罄",
+		model.LanguageZHHK: "已售罄",
+		model.LanguageEN:   "sold out",
+	},
+	"low_stock.title": {
+		model.LanguageZH:   "⚠️ 苹果翻新库存紧张",
+		model.LanguageZHHK: "⚠️ Apple 翻新產品存貨緊張",
+		model.LanguageEN:   "⚠️ Apple Refurbished low stock",
+	},
+	"low_stock.body": {
+		model.LanguageZH:   "%s %s，喜欢请尽快下单",
+		model.LanguageZHHK: "%s %s，喜歡請盡快下單",
+		model.LanguageEN:   "%s %s, order soon if you want it",
+	},
+	"low_stock.limited": {
+		model.LanguageZH:   "库存已转为紧张",
+		model.LanguageZHHK: "存貨已轉為緊張",
+		model.LanguageEN:   "is now low on stock",
+	},
+	"low_stock.velocity": {
+		model.LanguageZH:   "同款通常上架 %.0f 小时内售罄，预计 %s 前后下架",
+		model.LanguageZHHK: "同款通常上架 %.0f 小時內售罄，預計 %s 前後下架",
+		model.LanguageEN:   "usually sells out within %.0f hours of listing, expected to go around %s",
+	},
+	"new_arrival.title": {
+		model.LanguageZH:   "🆕 苹果翻新新品上架",
+		model.LanguageZHHK: "🆕 Apple 翻新產品新品上架",
+		model.LanguageEN:   "🆕 New on Apple Refurbished",
+	},
+	"new_arrival.body": {
+		model.LanguageZH:   "[%s] %s 到货了！价格: %s",
+		model.LanguageZHHK: "[%s] %s 到貨了！價格: %s",
+		model.LanguageEN:   "[%s] %s just arrived! Price: %s",
+	},
+	"new_arrival.discount": {
+		model.LanguageZH:   " (省%.0f%%)",
+		model.LanguageZHHK: " (省%.0f%%)",
+		model.LanguageEN:   " (%.0f%% off)",
+	},
+	"restock.title": {
+		model.LanguageZH:   "🔄 苹果翻新补货提醒",
+		model.LanguageZHHK: "🔄 Apple 翻新產品補貨提醒",
+		model.LanguageEN:   "🔄 Apple Refurbished restock",
+	},
+	"restock.body": {
+		model.LanguageZH:   "[%s] %s 重新有货了！价格: %s",
+		model.LanguageZHHK: "[%s] %s 重新有貨了！價格: %s",
+		model.LanguageEN:   "[%s] %s is back in stock! Price: %s",
+	},
+	"sell_out.hour": {
+		model.LanguageZH:   "⏱ 同款通常 1 小时内售罄，请尽快下单",
+		model.LanguageZHHK: "⏱ 同款通常 1 小時內售罄，請盡快下單",
+		model.LanguageEN:   "⏱ Usually sells out within an hour, order soon",
+	},
+	"sell_out.hours": {
+		model.LanguageZH:   "⏱ 同款通常 %.0f 小时内售罄",
+		model.LanguageZHHK: "⏱ 同款通常 %.0f 小時內售罄",
+		model.LanguageEN:   "⏱ Usually sells out within %.0f hours",
+	},
+	"sell_out.days": {
+		model.LanguageZH:   "⏱ 同款通常 %.0f 天内售罄",
+		model.LanguageZHHK: "⏱ 同款通常 %.0f 天內售罄",
+		model.LanguageEN:   "⏱ Usually sells out within %.0f days",
+	},
+	"test.title": {
+		model.LanguageZH:   "🔔 ApplePrice 测试通知",
+		model.LanguageZHHK: "🔔 ApplePrice 測試通知",
+		model.LanguageEN:   "🔔 ApplePrice test notification",
+	},
+	"test.body": {
+		model.LanguageZH:   "收到这条消息说明您的 Bark Key 可以正常接收通知",
+		model.LanguageZHHK: "收到這條訊息代表您的 Bark Key 可以正常接收通知",
+		model.LanguageEN:   "Your Bark Key can receive notifications",
+	},
+	"batch.title": {
+		model.LanguageZH:   "🍎 苹果翻新价格汇总",
+		model.LanguageZHHK: "🍎 Apple 翻新產品價格匯總",
+		model.LanguageEN:   "🍎 Apple Refurbished price changes",
+	},
+	"batch.body": {
+		model.LanguageZH:   "发现 %d 个价格变动\n\n",
+		model.LanguageZHHK: "發現 %d 個價格變動\n\n",
+		model.LanguageEN:   "%d price changes\n\n",
+	},
+	"batch.more": {
+		model.LanguageZH:   "...还有 %d 个产品",
+		model.LanguageZHHK: "...還有 %d 件產品",
+		model.LanguageEN:   "...and %d more",
+	},
+	"digest.title": {
+		model.LanguageZH:   "🍎 苹果翻新新品汇总 · %s",
+		model.LanguageZHHK: "🍎 Apple 翻新產品新品匯總 · %s",
+		model.LanguageEN:   "🍎 Apple Refurbished digest · %s",
+	},
+	"digest.body": {
+		model.LanguageZH:   "发现 %d 款新品\n\n",
+		model.LanguageZHHK: "發現 %d 款新品\n\n",
+		model.LanguageEN:   "%d new products\n\n",
+	},
+	"digest.more": {
+		model.LanguageZH:   "...还有 %d 款新品",
+		model.LanguageZHHK: "...還有 %d 款新品",
+		model.LanguageEN:   "...and %d more",
+	},
+	"catch_up.title": {
+		model.LanguageZH:   "🍎 期间变化汇总",
+		model.LanguageZHHK: "🍎 期間變化匯總",
+		model.LanguageEN:   "🍎 While we were away",
+	},
+	"catch_up.body": {
+		model.LanguageZH:   "服务离线约 %s，期间共 %d 项变化\n\n",
+		model.LanguageZHHK: "服務離線約 %s，期間共 %d 項變化\n\n",
+		model.LanguageEN:   "Offline for about %s, %d changes meanwhile\n\n",
+	},
+	"catch_up.more": {
+		model.LanguageZH:   "...还有 %d 项变化",
+		model.LanguageZHHK: "...還有 %d 項變化",
+		model.LanguageEN:   "...and %d more changes",
+	},
+	"catch_up.price_drop": {
+		model.LanguageZH:   "降价",
+		model.LanguageZHHK: "減價",
+		model.LanguageEN:   "Price drop",
+	},
+	"catch_up.price_rise": {
+		model.LanguageZH:   "涨价",
+		model.LanguageZHHK: "加價",
+		model.LanguageEN:   "Price rise",
+	},
+	"catch_up.new_arrival": {
+		model.LanguageZH:   "上新 %s: %s",
+		model.LanguageZHHK: "上新 %s: %s",
+		model.LanguageEN:   "New %s: %s",
+	},
+	"catch_up.restock": {
+		model.LanguageZH:   "补货 %s: %s",
+		model.LanguageZHHK: "補貨 %s: %s",
+		model.LanguageEN:   "Restocked %s: %s",
+	},
+	"catch_up.sold_out": {
+		model.LanguageZH:   "售罄 %s",
+		model.LanguageZHHK: "售罄 %s",
+		model.LanguageEN:   "Sold out %s",
+	},
+	"duration.minutes": {
+		model.LanguageZH:   "%d 分钟",
+		model.LanguageZHHK: "%d 分鐘",
+		model.LanguageEN:   "%d minutes",
+	},
+	"duration.hours": {
+		model.LanguageZH:   "%d 小时",
+		model.LanguageZHHK: "%d 小時",
+		model.LanguageEN:   "%d hours",
+	},
+	"duration.days": {
+		model.LanguageZH:   "%d 天",
+		model.LanguageZHHK: "%d 天",
+		model.LanguageEN:   "%d days",
+	},
+	"duration.days_hours": {
+		model.LanguageZH:   "%d 天 %d 小时",
+		model.LanguageZHHK: "%d 天 %d 小時",
+		model.LanguageEN:   "%d days %d hours",
+	},
+}
@@ -0,0 +1,183 @@
+package notify
+
+import (
+	"log/slog"
+	"time"
+
+	"apple-price/internal/model"
+)
+
+const (
+	// quietFlushInterval is how often held notifications are checked for delivery
+	quietFlushInterval = time.Minute
+	// heldBatchSize bounds how many held notifications one flush sends
+	heldBatchSize = 500
+)
+
+// HeldStore persists pushes held for quiet hours, so they survive restarts
+type HeldStore interface {
+	HoldNotification(n *model.HeldNotification) error
+	GetDueHeldNotifications(now time.Time, limit int) []*model.HeldNotification
+	DeleteHeldNotification(id string) error
+	CountHeldNotifications() int
+}
+
+// heldPush describes a push to one subscription for holdIfQuiet. Its delivery
+// records history and counts the notification on the subscription.
+func heldPush(subscriptionID, barkKey, barkServer, notificationType string, product *model.Product) *model.HeldNotification {
+	snapshot := *product
+	return &model.HeldNotification{
+		SubscriptionID:   subscriptionID,
+		ProductID:        product.ID,
+		NotificationType: notificationType,
+		BarkKey:          barkKey,
+		BarkServer:       barkServer,
+		Product:          &snapshot,
+		Counted:          []string{subscriptionID},
+	}
+}
+
+// holdIfQuiet stores held until the quiet window ends when now falls inside it,
+// with the message render produces. Held pushes are keyed by subscription,
+// notification type and product, so a newer event for the same product replaces
+// the older one instead of piling up. Returns false when the notification should
+// go out immediately. Held pushes measure their delivery latency from the end of
+// the quiet window, not from the event.
+func (d *Dispatcher) holdIfQuiet(quietUntil func(time.Time) (time.Time, bool), held *model.HeldNotification, render func(b *BarkService) (*Message, error)) bool {
+	now := time.Now()
+	until, quiet := quietUntil(now)
+	if !quiet {
+		return false
+	}
+
+	d.mu.RLock()
+	bark := d.bark
+	store := d.store
+	d.mu.RUnlock()
+
+	if bark == nil || store == nil {
+		return false
+	}
+
+	// Render without sending: the sandbox hands the message back
+	msg, err := render(bark.Server(held.BarkServer).Sandbox(func(string, *Message) {}))
+	if err != nil || msg == nil {
+		return false
+	}
+
+	held.ID = model.HeldNotificationID(held.SubscriptionID, held.NotificationType, held.ProductID)
+	held.Title = msg.Title
+	held.Body = msg.Body
+	held.URL = msg.URL
+	held.Icon = msg.Icon
+	held.Sound = msg.Sound
+	held.Group = msg.Group
+	held.SendAt = until
+	held.CreatedAt = now
+	if err := store.HoldNotification(held); err != nil {
+		slog.Error("Failed to hold notification for quiet hours", "subscription_id", held.SubscriptionID, "error", err)
+		return false
+	}
+
+	d.StartQuietFlusher()
+
+	slog.Debug("Notification held for quiet hours",
+		"type", held.NotificationType, "product_id", held.ProductID, "send_at", until)
+	return true
+}
+
+// StartQuietFlusher starts the background worker that sends held notifications.
+// The worker also starts with the first held push; call this at startup so
+// pushes held before a restart go out.
+func (d *Dispatcher) StartQuietFlusher() {
+	d.flusherOnce.Do(func() { go d.runQuietFlusher() })
+}
+
+// runQuietFlusher delivers held notifications once their quiet window has passed
+func (d *Dispatcher) runQuietFlusher() {
+	ticker := time.NewTicker(quietFlushInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		d.FlushHeld(time.Now())
+	}
+}
+
+// FlushHeld sends the held notifications whose quiet window ended before now
+// and returns how many were sent
+func (d *Dispatcher) FlushHeld(now time.Time) int {
+	d.mu.RLock()
+	bark := d.bark
+	store := d.store
+	d.mu.RUnlock()
+
+	if bark == nil || store == nil {
+		return 0
+	}
+
+	sent := 0
+	for _, n := range store.GetDueHeldNotifications(now, heldBatchSize) {
+		// A failed send goes to the retry queue, so the held copy is no longer needed
+		if err := store.DeleteHeldNotification(n.ID); err != nil {
+			slog.Error("Failed to remove held notification", "held_id", n.ID, "error", err)
+			continue
+		}
+
+		// The user may have hit pause-all while the push was held
+		if d.isPausedAll(n.BarkKey) {
+			continue
+		}
+		if d.deliverHeld(bark, store, n) {
+			sent++
+		}
+	}
+
+	if sent > 0 {
+		slog.Info("Flushed notifications held for quiet hours", "count", sent)
+	}
+	return sent
+}
+
+// deliverHeld sends a held notification and does the bookkeeping its delivery
+// would have done right away. Returns whether it was sent.
+func (d *Dispatcher) deliverHeld(bark *BarkService, store StoreInterface, n *model.HeldNotification) bool {
+	msg := &Message{Title: n.Title, Body: n.Body, URL: n.URL, Icon: n.Icon, Sound: n.Sound, Group: n.Group}
+	product := n.Product
+	if product == nil {
+		product = &model.Product{ID: n.ProductID}
+	}
+
+	if err := bark.Server(n.BarkServer).Send(n.BarkKey, msg); err != nil {
+		slog.Warn("Held notification failed", "type", n.NotificationType, "subscription_id", n.SubscriptionID, "error", err)
+		// A queued retry keeps the delivery claimed
+		if !d.recordFailure(store, n.SubscriptionID, n.BarkKey, n.BarkServer, product, msg, n.NotificationType, err, n.SendAt) {
+			d.releaseDelivery(n.BarkKey, n.NotificationType, n.ProductID)
+		}
+		return false
+	}
+
+	d.recordNotificationHistory(store, n.SubscriptionID, n.BarkKey, product, msg, n.NotificationType, "sent", "", n.SendAt)
+	for subID, productIDs := range n.Notified {
+		for _, productID := range productIDs {
+			if err := store.UpdateNotifiedProductIDs(subID, productID); err != nil {
+				slog.Error("Failed to update notified_product_ids", "subscription_id", subID, "error", err)
+			}
+		}
+	}
+	for _, subID := range n.Counted {
+		countNotification(store, subID)
+	}
+	return true
+}
+
+// HeldCount returns how many notifications are waiting for quiet hours to end
+func (d *Dispatcher) HeldCount() int {
+	d.mu.RLock()
+	store := d.store
+	d.mu.RUnlock()
+
+	if store == nil {
+		return 0
+	}
+	return store.CountHeldNotifications()
+}
@@ -0,0 +1,192 @@
+package notify
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"apple-price/internal/model"
+)
+
+const (
+	// retryPollInterval is how often the retry queue is checked for due pushes
+	retryPollInterval = 30 * time.Second
+	// retryBaseDelay is the wait before the first retry; it doubles with every further failure
+	retryBaseDelay = time.Minute
+	// maxNotificationAttempts caps the sends of one push, counting the original one
+	maxNotificationAttempts = 6
+	// retryBatchSize bounds how many due retries one poll sends
+	retryBatchSize = 100
+)
+
+// RetryStore persists failed pushes until they are sent again
+type RetryStore interface {
+	UpdateNotificationStatus(id, status, errorMessage string, latencyMs *int64) error
+	AddNotificationRetry(retry *model.NotificationRetry) error
+	GetDueNotificationRetries(now time.Time, limit int) []*model.NotificationRetry
+	UpdateNotificationRetry(retry *model.NotificationRetry) error
+	DeleteNotificationRetry(id string) error
+}
+
+// retryable reports whether a failed push may go through later: network errors,
+// rate limiting and server errors are retried, pushes the server rejected are not
+func retryable(err error) bool {
+	if errors.Is(err, errEmptyBarkKey) {
+		return false
+	}
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.Code == http.StatusTooManyRequests || statusErr.Code >= 500
+	}
+	return true
+}
+
+// retryDelay is the exponential backoff after a push failed attempts times
+func retryDelay(attempts int) time.Duration {
+	return retryBaseDelay << (attempts - 1)
+}
+
+// recordFailure records a failed push in notification history and queues it for
+// retry when the error looks transient. Returns whether a retry was queued; a
+// delivered retry records its latency since detectedAt.
+func (d *Dispatcher) recordFailure(store StoreInterface, subscriptionID, barkKey, barkServer string, product *model.Product, msg *Message, notificationType string, err error, detectedAt time.Time) bool {
+	d.trackDelivery(barkKey, err)
+	history := d.recordNotificationHistory(store, subscriptionID, barkKey, product, msg, notificationType, "failed", err.Error(), detectedAt)
+	return d.queueRetry(store, &model.NotificationRetry{
+		HistoryID:        history.ID,
+		SubscriptionID:   subscriptionID,
+		ProductID:        product.ID,
+		NotificationType: notificationType,
+		BarkKey:          barkKey,
+		BarkServer:       barkServer,
+		DetectedAt:       detectedAt,
+	}, msg, err)
+}
+
+// queueRetry stores a failed push for the retry worker, filling in the message
+// and schedule. Pushes that can't succeed later are not queued.
+func (d *Dispatcher) queueRetry(store StoreInterface, retry *model.NotificationRetry, msg *Message, err error) bool {
+	if msg == nil || !retryable(err) {
+		return false
+	}
+
+	now := time.Now()
+	retry.ID = fmt.Sprintf("nr-%d", now.UnixNano())
+	retry.Title = msg.Title
+	retry.Body = msg.Body
+	retry.URL = msg.URL
+	retry.Icon = msg.Icon
+	retry.Sound = msg.Sound
+	retry.Group = msg.Group
+	retry.Attempts = 1
+	retry.NextAttemptAt = now.Add(retryDelay(1))
+	retry.LastError = err.Error()
+	retry.CreatedAt = now
+
+	if err := store.AddNotificationRetry(retry); err != nil {
+		slog.Error("Failed to queue notification retry", "subscription_id", retry.SubscriptionID, "error", err)
+		return false
+	}
+
+	d.StartRetryWorker()
+	slog.Info("Notification queued for retry",
+		"type", retry.NotificationType, "bark_key", maskKey(retry.BarkKey), "next_attempt_at", retry.NextAttemptAt)
+	return true
+}
+
+// StartRetryWorker starts the background worker that re-sends queued pushes.
+// The worker also starts with the first queued retry; call this at startup so
+// retries persisted before a restart are picked up.
+func (d *Dispatcher) StartRetryWorker() {
+	d.retryOnce.Do(func() { go d.runRetries() })
+}
+
+// runRetries re-sends due pushes every retryPollInterval
+func (d *Dispatcher) runRetries() {
+	ticker := time.NewTicker(retryPollInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		d.RetryDue(time.Now())
+	}
+}
+
+// RetryDue re-sends the queued pushes due at now and returns how many were delivered.
+// A push is dropped after maxNotificationAttempts sends, when the server rejects it,
+// or when its owner paused all notifications (or its key was marked broken) in the meantime.
+func (d *Dispatcher) RetryDue(now time.Time) int {
+	d.mu.RLock()
+	bark := d.bark
+	store := d.store
+	d.mu.RUnlock()
+
+	if bark == nil || store == nil {
+		return 0
+	}
+
+	delivered := 0
+	for _, r := range store.GetDueNotificationRetries(now, retryBatchSize) {
+		if d.isPausedAll(r.BarkKey) {
+			d.dropRetry(store, r)
+			continue
+		}
+
+		msg := &Message{Title: r.Title, Body: r.Body, URL: r.URL, Icon: r.Icon, Sound: r.Sound, Group: r.Group}
+		err := bark.Server(r.BarkServer).Send(r.BarkKey, msg)
+		d.trackDelivery(r.BarkKey, err)
+		if err == nil {
+			delivered++
+			d.dropRetry(store, r)
+			if r.HistoryID != "" {
+				var latency *int64
+				if !r.DetectedAt.IsZero() {
+					latency = deliveryLatency(r.DetectedAt)
+				}
+				if err := store.UpdateNotificationStatus(r.HistoryID, "sent", "", latency); err != nil {
+					slog.Error("Failed to update notification status", "history_id", r.HistoryID, "error", err)
+				}
+			}
+			if r.NotificationType == "new_arrival" && r.ProductID != "" {
+				if err := store.UpdateNotifiedProductIDs(r.SubscriptionID, r.ProductID); err != nil {
+					slog.Error("Failed to update notified_product_ids", "subscription_id", r.SubscriptionID, "error", err)
+				}
+			}
+			if r.SubscriptionID != "" {
+				countNotification(store, r.SubscriptionID)
+			}
+			slog.Info("Notification retry delivered",
+				"type", r.NotificationType, "bark_key", maskKey(r.BarkKey), "attempts", r.Attempts+1)
+			continue
+		}
+
+		r.Attempts++
+		r.LastError = err.Error()
+		if !retryable(err) || r.Attempts >= maxNotificationAttempts {
+			slog.Warn("Giving up on notification",
+				"type", r.NotificationType, "bark_key", maskKey(r.BarkKey), "attempts", r.Attempts, "error", err)
+			d.dropRetry(store, r)
+			if r.HistoryID != "" {
+				if err := store.UpdateNotificationStatus(r.HistoryID, "failed", r.LastError, nil); err != nil {
+					slog.Error("Failed to update notification status", "history_id", r.HistoryID, "error", err)
+				}
+			}
+			continue
+		}
+
+		r.NextAttemptAt = now.Add(retryDelay(r.Attempts))
+		if err := store.UpdateNotificationRetry(r); err != nil {
+			slog.Error("Failed to reschedule notification retry", "retry_id", r.ID, "error", err)
+		}
+	}
+
+	return delivered
+}
+
+// dropRetry removes a retry from the queue
+func (d *Dispatcher) dropRetry(store StoreInterface, r *model.NotificationRetry) {
+	if err := store.DeleteNotificationRetry(r.ID); err != nil {
+		slog.Error("Failed to remove notification retry", "retry_id", r.ID, "error", err)
+	}
+}
@@ -0,0 +1,25 @@
+package notify
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// Shutdown flushes the notification queues before the process exits: held
+// pushes whose quiet window has ended and queued retries that are due go out
+// now. Pushes still inside a quiet window stay in the store and go out after
+// the restart.
+func (d *Dispatcher) Shutdown(ctx context.Context) error {
+	now := time.Now()
+	d.FlushHeld(now)
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	d.RetryDue(now)
+
+	if held := d.HeldCount(); held > 0 {
+		slog.Info("Keeping notifications held for quiet hours", "count", held)
+	}
+	return ctx.Err()
+}
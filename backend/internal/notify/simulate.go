@@ -0,0 +1,137 @@
+package notify
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"apple-price/internal/model"
+)
+
+// Simulated event types accepted by SimulateEvent
+const (
+	SimulatePriceChange = "price_change"
+	SimulateNewArrival  = "new_arrival"
+)
+
+// SimulatedDelivery is a message that would have been pushed to a Bark key
+type SimulatedDelivery struct {
+	BarkKeyMasked string `json:"bark_key_masked"`
+	Title         string `json:"title"`
+	Body          string `json:"body"`
+	ChannelParams string `json:"channel_params"`
+}
+
+// SimulatedHold is a message that would have been held for quiet hours
+type SimulatedHold struct {
+	BarkKeyMasked string    `json:"bark_key_masked"`
+	SendAt        time.Time `json:"send_at"`
+}
+
+// SimulationResult reports what the notification pipeline did with a synthetic event
+type SimulationResult struct {
+	Event         string              `json:"event"`
+	ProductID     string              `json:"product_id"`
+	Subscriptions int                 `json:"subscriptions"` // candidate subscriptions fed to the pipeline
+	Delivered     []SimulatedDelivery `json:"delivered"`
+	Held          []SimulatedHold     `json:"held"`
+	Buffered      int                 `json:"buffered"` // matches added to hourly/daily digests
+}
+
+// SimulateEvent runs a synthetic event through the real matching, pause, quiet-hour,
+// digest and templating logic, with the channel in sandbox mode and all store
+// writes discarded, so operators can verify the notification path end to end.
+func (d *Dispatcher) SimulateEvent(event string, product *model.Product, oldPrice, newPrice float64,
+	subscriptions []*model.Subscription, arrivalSubscriptions []*model.NewArrivalSubscription) (*SimulationResult, error) {
+	d.mu.RLock()
+	bark := d.bark
+	store := d.store
+	d.mu.RUnlock()
+
+	if bark == nil || store == nil {
+		return nil, fmt.Errorf("notification pipeline not configured")
+	}
+
+	result := &SimulationResult{
+		Event:     event,
+		ProductID: product.ID,
+		Delivered: []SimulatedDelivery{},
+		Held:      []SimulatedHold{},
+	}
+
+	var mu sync.Mutex
+	sandbox := &sandboxStore{StoreInterface: store}
+	sim := &Dispatcher{
+		bark: bark.Sandbox(func(key string, msg *Message) {
+			mu.Lock()
+			defer mu.Unlock()
+			result.Delivered = append(result.Delivered, SimulatedDelivery{
+				BarkKeyMasked: maskKey(key),
+				Title:         msg.Title,
+				Body:          msg.Body,
+				ChannelParams: msg.ChannelParams(),
+			})
+		}),
+		store: sandbox,
+	}
+	// Held messages are reported, never flushed
+	sim.flusherOnce.Do(func() {})
+
+	var err error
+	switch event {
+	case SimulatePriceChange:
+		result.Subscriptions = len(subscriptions)
+		err = sim.NotifyPriceChange(product, oldPrice, newPrice, subscriptions)
+	case SimulateNewArrival:
+		// Copies with a clean notified list, so the product is matched as if it were new
+		subs := make([]*model.NewArrivalSubscription, 0, len(arrivalSubscriptions))
+		for _, sub := range arrivalSubscriptions {
+			c := *sub
+			c.NotifiedProductIDs = "[]"
+			subs = append(subs, &c)
+		}
+		result.Subscriptions = len(subs)
+		err = sim.NotifyNewArrival(product, subs)
+	default:
+		return nil, fmt.Errorf("unknown event type %q", event)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for _, n := range sandbox.held {
+		result.Held = append(result.Held, SimulatedHold{BarkKeyMasked: maskKey(n.BarkKey), SendAt: n.SendAt})
+	}
+	sort.Slice(result.Held, func(i, j int) bool { return result.Held[i].SendAt.Before(result.Held[j].SendAt) })
+	result.Buffered = sandbox.buffered
+
+	return result, nil
+}
+
+// sandboxStore passes reads through to the real store and discards writes
+type sandboxStore struct {
+	StoreInterface
+	mu       sync.Mutex
+	buffered int
+	held     []*model.HeldNotification
+}
+
+func (s *sandboxStore) UpdateNotifiedProductIDs(subscriptionID, productID string) error { return nil }
+func (s *sandboxStore) AddNotificationHistory(history *model.NotificationHistory) error  { return nil }
+func (s *sandboxStore) IncrementNotificationCount(id string) error                       { return nil }
+func (s *sandboxStore) ClearPendingNotifications(subscriptionID string) error            { return nil }
+
+func (s *sandboxStore) AddPendingNotification(item *model.PendingNotification) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.buffered++
+	return nil
+}
+
+func (s *sandboxStore) HoldNotification(n *model.HeldNotification) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.held = append(s.held, n)
+	return nil
+}
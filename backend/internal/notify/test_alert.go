@@ -0,0 +1,46 @@
+package notify
+
+import (
+	"fmt"
+	"log/slog"
+	"math"
+	"time"
+
+	"apple-price/internal/model"
+)
+
+// sampleDropRatio is the fake price drop used when a subscription has no usable target price
+const sampleDropRatio = 0.9
+
+// SendSubscriptionTest sends a sample price alert for sub right away, ignoring quiet
+// hours and pauses, so users can check their Bark Key without waiting for a real
+// price change. The delivery is recorded in notification history as "test" and
+// returned; err is the send error, if any.
+func (d *Dispatcher) SendSubscriptionTest(sub *model.Subscription, product *model.Product) (*model.NotificationHistory, error) {
+	d.mu.RLock()
+	bark := d.bark
+	store := d.store
+	d.mu.RUnlock()
+
+	if bark == nil || store == nil {
+		return nil, fmt.Errorf("bark service not configured")
+	}
+
+	// Fake a drop to the target price, or by 10% when there is none below the current price
+	oldPrice := product.Price
+	newPrice := math.Round(oldPrice * sampleDropRatio)
+	if sub.TargetPrice > 0 && sub.TargetPrice < oldPrice {
+		newPrice = sub.TargetPrice
+	}
+
+	detected := time.Now()
+	msg, err := bark.Server(sub.BarkServer).SendSamplePriceAlert(sub.BarkKey, d.locale(sub.BarkKey, sub.Language), product.Name, oldPrice, newPrice, product.ID, product.ProductURL)
+	if err != nil {
+		slog.Warn("Bark test notification failed", "subscription_id", sub.ID, "error", err)
+		d.trackDelivery(sub.BarkKey, err)
+		return d.recordNotificationHistory(store, sub.ID, sub.BarkKey, product, msg, "test", "failed", err.Error(), detected), err
+	}
+
+	slog.Info("Test notification sent", "subscription_id", sub.ID, "product", product.Name)
+	return d.recordNotificationHistory(store, sub.ID, sub.BarkKey, product, msg, "test", "sent", "", detected), nil
+}
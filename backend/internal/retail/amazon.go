@@ -0,0 +1,137 @@
+package retail
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"apple-price/internal/model"
+
+	"golang.org/x/net/html"
+)
+
+// DefaultAmazonHost is the Amazon storefront whose prices are compared (CNY)
+const DefaultAmazonHost = "www.amazon.cn"
+
+// AmazonSource reads prices from Amazon product pages by ASIN
+type AmazonSource struct {
+	fetcher Fetcher
+	host    string
+}
+
+// NewAmazonSource creates an Amazon connector for the storefront at host
+// (DefaultAmazonHost when empty), fetching through fetcher
+func NewAmazonSource(fetcher Fetcher, host string) *AmazonSource {
+	if host == "" {
+		host = DefaultAmazonHost
+	}
+	return &AmazonSource{fetcher: fetcher, host: host}
+}
+
+// Retailer returns model.RetailerAmazon
+func (s *AmazonSource) Retailer() string {
+	return model.RetailerAmazon
+}
+
+// FetchPrice returns the price of the buy box on an ASIN's product page
+func (s *AmazonSource) FetchPrice(ctx context.Context, asin string) (*model.RetailerPrice, error) {
+	pageURL := "https://" + s.host + "/dp/" + asin
+	page, err := s.fetcher.Fetch(ctx, pageURL)
+	if err != nil {
+		return nil, err
+	}
+
+	price, err := amazonPagePrice(page)
+	if err != nil {
+		return nil, fmt.Errorf("ASIN %s: %w", asin, err)
+	}
+
+	return &model.RetailerPrice{
+		Retailer:  model.RetailerAmazon,
+		Price:     price,
+		URL:       pageURL,
+		FetchedAt: time.Now(),
+	}, nil
+}
+
+// amazonPriceBlocks hold the price actually charged; the page lists others
+// (list price, other sellers) elsewhere
+var amazonPriceBlocks = []string{"corePriceDisplay_desktop_feature_div", "corePrice_feature_div", "corePrice_desktop"}
+
+var amazonAmountPattern = regexp.MustCompile(`\d[\d,]*(?:\.\d+)?`)
+
+// amazonPagePrice extracts the buy box price: the first a-offscreen amount inside
+// one of amazonPriceBlocks
+func amazonPagePrice(page string) (float64, error) {
+	root, err := html.Parse(strings.NewReader(page))
+	if err != nil {
+		return 0, err
+	}
+
+	for _, id := range amazonPriceBlocks {
+		block := findElement(root, func(n *html.Node) bool { return attr(n, "id") == id })
+		if block == nil {
+			continue
+		}
+		offscreen := findElement(block, func(n *html.Node) bool { return hasClass(n, "a-offscreen") })
+		if offscreen == nil {
+			continue
+		}
+		amount := amazonAmountPattern.FindString(text(offscreen))
+		price, err := strconv.ParseFloat(strings.ReplaceAll(amount, ",", ""), 64)
+		if err == nil && price > 0 {
+			return price, nil
+		}
+	}
+	return 0, fmt.Errorf("no price on the page (unavailable or blocked)")
+}
+
+// findElement returns the first element under n, n included, that match accepts
+func findElement(n *html.Node, match func(*html.Node) bool) *html.Node {
+	if n.Type == html.ElementNode && match(n) {
+		return n
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if found := findElement(c, match); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+func hasClass(n *html.Node, class string) bool {
+	for _, c := range strings.Fields(attr(n, "class")) {
+		if c == class {
+			return true
+		}
+	}
+	return false
+}
+
+// text returns the text content of n
+func text(n *html.Node) string {
+	var b strings.Builder
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.TextNode {
+			b.WriteString(n.Data)
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(n)
+	return strings.TrimSpace(b.String())
+}
@@ -0,0 +1,198 @@
+package retail
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"apple-price/internal/model"
+)
+
+// CatalogEntry maps a spec combination to the matching new product at each
+// retailer, e.g. {"combo": "MacBook Air 13 M3 16GB 512GB", "items": {"jd": "100086206473"}}
+type CatalogEntry struct {
+	Combo  string            `json:"combo"`            // parsed with model.ParseSpecCombo
+	Region string            `json:"region,omitempty"` // refurbished storefront compared (default cn: retailer prices are CNY)
+	Items  map[string]string `json:"items"`            // retailer -> item ID (JD SKU, Amazon ASIN)
+}
+
+// LoadCatalog reads catalog entries from a JSON file
+func LoadCatalog(path string) ([]CatalogEntry, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var entries []CatalogEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("failed to parse retailer catalog: %w", err)
+	}
+	for i, e := range entries {
+		if model.ParseSpecCombo(e.Combo).IsEmpty() {
+			return nil, fmt.Errorf("retailer catalog entry %d: no specs recognized in %q", i, e.Combo)
+		}
+	}
+	return entries, nil
+}
+
+// Store is the part of the store the comparer reads products from and writes prices to
+type Store interface {
+	GetProductsByRegion(region string) []*model.Product
+	GetRetailerPrices(productID string) []model.RetailerPrice
+	SetRetailerPrices(productID string, prices []model.RetailerPrice) error
+}
+
+// Comparer periodically fetches the retailer prices of the catalog and attaches
+// them to the refurbished products matching each entry
+type Comparer struct {
+	store    Store
+	catalog  []CatalogEntry
+	sources  map[string]PriceSource // retailer -> connector
+	stopCh   chan struct{}
+	stopOnce sync.Once
+}
+
+// NewComparer creates a comparer for the catalog entries. Items of retailers
+// without a source are ignored.
+func NewComparer(store Store, catalog []CatalogEntry, sources ...PriceSource) *Comparer {
+	c := &Comparer{
+		store:   store,
+		catalog: catalog,
+		sources: make(map[string]PriceSource),
+		stopCh:  make(chan struct{}),
+	}
+	for _, s := range sources {
+		c.sources[s.Retailer()] = s
+	}
+	return c
+}
+
+// Start refreshes prices now and every interval until Stop is called
+func (c *Comparer) Start(interval time.Duration) {
+	go func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go func() {
+			<-c.stopCh
+			cancel()
+		}()
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			if n, err := c.Refresh(ctx); err != nil {
+				slog.Warn("Retailer price refresh incomplete", "products", n, "error", err)
+			} else {
+				slog.Info("Retailer prices refreshed", "products", n)
+			}
+
+			select {
+			case <-ticker.C:
+			case <-c.stopCh:
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops periodic refreshing
+func (c *Comparer) Stop() {
+	c.stopOnce.Do(func() { close(c.stopCh) })
+}
+
+// Refresh fetches the price of every catalog item and replaces the retailer prices
+// of the products each entry matches; products of compared regions that no entry
+// matches lose theirs. A price that can't be fetched keeps its previous value.
+// It returns how many products have prices, and the last fetch error if any.
+func (c *Comparer) Refresh(ctx context.Context) (int, error) {
+	var lastErr error
+	quotes := make(map[string]*model.RetailerPrice) // retailer|item -> price
+	quote := func(retailer, item string) *model.RetailerPrice {
+		key := retailer + "|" + item
+		if q, ok := quotes[key]; ok {
+			return q
+		}
+		q, err := c.sources[retailer].FetchPrice(ctx, item)
+		if err != nil {
+			slog.Warn("Failed to fetch retailer price", "retailer", retailer, "item", item, "error", err)
+			lastErr = err
+		}
+		quotes[key] = q
+		return q
+	}
+
+	matched := make(map[string]map[string]model.RetailerPrice) // product ID -> retailer -> price
+	regions := make(map[string][]*model.Product)
+	for _, entry := range c.catalog {
+		region := entry.Region
+		if region == "" {
+			region = "cn"
+		}
+		if _, ok := regions[region]; !ok {
+			regions[region] = c.store.GetProductsByRegion(region)
+		}
+
+		combo := model.ParseSpecCombo(entry.Combo)
+		for _, p := range regions[region] {
+			if !combo.Matches(p) {
+				continue
+			}
+			if matched[p.ID] == nil {
+				matched[p.ID] = make(map[string]model.RetailerPrice)
+			}
+			for retailer, item := range entry.Items {
+				if _, ok := c.sources[retailer]; !ok {
+					continue
+				}
+				if err := ctx.Err(); err != nil {
+					return 0, err
+				}
+				q := quote(retailer, item)
+				if q == nil {
+					q = c.previousPrice(p.ID, retailer)
+				}
+				// Several entries can match one product: keep the cheapest offer
+				if cur, ok := matched[p.ID][retailer]; q != nil && (!ok || q.Price < cur.Price) {
+					matched[p.ID][retailer] = *q
+				}
+			}
+		}
+	}
+
+	priced := 0
+	for _, products := range regions {
+		for _, p := range products {
+			prices := make([]model.RetailerPrice, 0, len(matched[p.ID]))
+			for _, price := range matched[p.ID] {
+				prices = append(prices, price)
+			}
+			sort.Slice(prices, func(i, j int) bool { return prices[i].Price < prices[j].Price })
+
+			if len(prices) > 0 {
+				priced++
+			} else if len(c.store.GetRetailerPrices(p.ID)) == 0 {
+				continue
+			}
+			if err := c.store.SetRetailerPrices(p.ID, prices); err != nil {
+				return priced, fmt.Errorf("failed to save retailer prices: %w", err)
+			}
+		}
+	}
+
+	return priced, lastErr
+}
+
+// previousPrice returns the stored price of a product at a retailer, if any
+func (c *Comparer) previousPrice(productID, retailer string) *model.RetailerPrice {
+	for _, p := range c.store.GetRetailerPrices(productID) {
+		if p.Retailer == retailer {
+			return &p
+		}
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+package retail
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+
+	"apple-price/internal/model"
+)
+
+// jdPriceURL is JD.com's public price endpoint, queried by SKU
+const jdPriceURL = "https://p.3.cn/prices/mgets"
+
+// JDSource fetches prices of JD.com SKUs
+type JDSource struct {
+	fetcher Fetcher
+}
+
+// NewJDSource creates a JD.com connector fetching through fetcher
+func NewJDSource(fetcher Fetcher) *JDSource {
+	return &JDSource{fetcher: fetcher}
+}
+
+// Retailer returns model.RetailerJD
+func (s *JDSource) Retailer() string {
+	return model.RetailerJD
+}
+
+// FetchPrice returns the current price of a JD.com SKU
+func (s *JDSource) FetchPrice(ctx context.Context, sku string) (*model.RetailerPrice, error) {
+	body, err := s.fetcher.Fetch(ctx, jdPriceURL+"?skuIds=J_"+url.QueryEscape(sku))
+	if err != nil {
+		return nil, err
+	}
+
+	var prices []struct {
+		ID    string `json:"id"`
+		Price string `json:"p"`
+	}
+	if err := json.Unmarshal([]byte(body), &prices); err != nil {
+		return nil, fmt.Errorf("failed to parse JD price response: %w", err)
+	}
+	if len(prices) == 0 {
+		return nil, fmt.Errorf("JD returned no price for SKU %s", sku)
+	}
+
+	// Delisted and out of stock SKUs are priced -1
+	price, err := strconv.ParseFloat(prices[0].Price, 64)
+	if err != nil || price <= 0 {
+		return nil, fmt.Errorf("JD SKU %s has no price (%q)", sku, prices[0].Price)
+	}
+
+	return &model.RetailerPrice{
+		Retailer:  model.RetailerJD,
+		Price:     price,
+		URL:       "https://item.jd.com/" + sku + ".html",
+		FetchedAt: time.Now(),
+	}, nil
+}
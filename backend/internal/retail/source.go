@@ -0,0 +1,22 @@
+// Package retail compares refurbished listings with the new price of the same
+// product at third-party retailers (JD.com, Amazon)
+package retail
+
+import (
+	"context"
+
+	"apple-price/internal/model"
+)
+
+// PriceSource fetches the current new price of an item at one retailer.
+// Connectors for other retailers implement it and are passed to NewComparer.
+type PriceSource interface {
+	// Retailer is the key of the source's item IDs in the catalog, e.g. model.RetailerJD
+	Retailer() string
+	FetchPrice(ctx context.Context, itemID string) (*model.RetailerPrice, error)
+}
+
+// Fetcher downloads a page, e.g. the scraper client with its proxies and throttling
+type Fetcher interface {
+	Fetch(ctx context.Context, url string) (string, error)
+}
@@ -0,0 +1,127 @@
+package scraper
+
+import (
+	"time"
+
+	"apple-price/internal/model"
+)
+
+const (
+	// adaptiveLookback is how far back new arrivals are counted when learning the
+	// hours restocks appear at
+	adaptiveLookback = 90 * 24 * time.Hour
+
+	// adaptiveMinArrivals is how many new arrivals it takes before the schedule
+	// departs from the base interval
+	adaptiveMinArrivals = 20
+
+	// adaptiveBackfill is the window after the first product was seen whose
+	// arrivals are left out: the initial scrape finds the whole catalog at once
+	adaptiveBackfill = 24 * time.Hour
+)
+
+// ScheduleHour is the scrape interval used during one hour of the day (local
+// time) and the new arrivals it was learned from
+type ScheduleHour struct {
+	Hour     int           `json:"hour"`
+	Arrivals int           `json:"arrivals"`
+	Interval time.Duration `json:"interval"`
+}
+
+// SetIntervalBounds makes the scheduler adapt its interval to the hours new
+// arrivals historically appear at: more often than the base interval in busy
+// hours, down to min, and less often in quiet ones such as overnight, up to max.
+// Without bounds every cycle waits the base interval.
+func (s *Scheduler) SetIntervalBounds(min, max time.Duration) {
+	s.minInterval = min
+	s.maxInterval = max
+}
+
+// adaptive reports whether the interval varies by hour
+func (s *Scheduler) adaptive() bool {
+	return s.minInterval > 0 && s.maxInterval > 0 && s.minInterval < s.maxInterval
+}
+
+// longestInterval is the longest the scheduler waits between two scheduled cycles
+func (s *Scheduler) longestInterval() time.Duration {
+	if s.cron != nil {
+		return s.cronGap(time.Now()) + s.startJitter
+	}
+	if s.adaptive() && s.maxInterval > s.interval {
+		return s.maxInterval
+	}
+	return s.interval
+}
+
+// nextInterval returns how long to wait after now before the next scheduled cycle.
+// A long wait running into a busier hour is cut short to that hour's interval
+// past its start, so a quiet 8 o'clock doesn't delay the 9 o'clock restocks.
+func (s *Scheduler) nextInterval(now time.Time) time.Duration {
+	if !s.adaptive() {
+		return s.interval
+	}
+	schedule := s.schedule(now)
+	wait := schedule[now.Hour()].Interval
+	boundary := time.Date(now.Year(), now.Month(), now.Day(), now.Hour()+1, 0, 0, 0, now.Location())
+	if next := boundary.Sub(now) + schedule[boundary.Hour()].Interval; next < wait {
+		wait = next
+	}
+	return wait
+}
+
+// schedule learns the interval of each hour of the day from the stored catalog
+func (s *Scheduler) schedule(now time.Time) []ScheduleHour {
+	return learnSchedule(s.store.GetAllProducts(), now, s.interval, s.minInterval, s.maxInterval)
+}
+
+// learnSchedule spreads the new arrivals of the last adaptiveLookback over the
+// hours of the day and scales base by how busy each hour is compared to the
+// average: twice the arrivals, half the interval. Hours without arrivals wait
+// max. Neighbouring hours are blended in, since a restock seen at 9:58 could as
+// well have been seen at 10:02. Too few arrivals leave every hour at base.
+func learnSchedule(products []*model.Product, now time.Time, base, min, max time.Duration) []ScheduleHour {
+	var first time.Time
+	for _, p := range products {
+		if !p.CreatedAt.IsZero() && (first.IsZero() || p.CreatedAt.Before(first)) {
+			first = p.CreatedAt
+		}
+	}
+
+	var counts [24]int
+	total := 0
+	since := now.Add(-adaptiveLookback)
+	for _, p := range products {
+		if p.CreatedAt.Before(since) || p.CreatedAt.Before(first.Add(adaptiveBackfill)) {
+			continue
+		}
+		counts[p.CreatedAt.In(now.Location()).Hour()]++
+		total++
+	}
+
+	hours := make([]ScheduleHour, 24)
+	for h := range hours {
+		interval := base
+		if total >= adaptiveMinArrivals {
+			weight := float64(counts[(h+23)%24]+2*counts[h]+counts[(h+1)%24]) / 4
+			activity := weight / (float64(total) / 24)
+			if activity > 0 {
+				interval = time.Duration(float64(base) / activity)
+			} else {
+				interval = max
+			}
+		}
+		hours[h] = ScheduleHour{Hour: h, Arrivals: counts[h], Interval: clampInterval(interval, min, max).Round(time.Second)}
+	}
+	return hours
+}
+
+// clampInterval bounds interval to [min, max]
+func clampInterval(interval, min, max time.Duration) time.Duration {
+	if interval < min {
+		return min
+	}
+	if interval > max {
+		return max
+	}
+	return interval
+}
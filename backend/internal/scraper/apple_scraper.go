@@ -1,8 +1,11 @@
 package scraper
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"log/slog"
+	"net/url"
 	"regexp"
 	"strings"
 	"sync"
@@ -15,9 +18,16 @@ const (
 	cnBaseURL = "https://www.apple.com.cn/shop/refurbished"
 )
 
+// RegionSource provides the storefronts to scrape (the region registry)
+type RegionSource interface {
+	GetRegions() []*model.Region
+}
+
 // AppleScraper scrapes Apple's refurbished product pages
 type AppleScraper struct {
-	client *Client
+	client       *Client
+	regions      RegionSource
+	categoryHook func(CategoryResult)
 }
 
 // NewAppleScraper creates a new Apple scraper instance
@@ -27,28 +37,113 @@ func NewAppleScraper(client *Client) *AppleScraper {
 	}
 }
 
-// ScrapeAll scrapes all products from China region
-func (s *AppleScraper) ScrapeAll() ([]*model.Product, error) {
-	return s.ScrapeRegion("cn", cnBaseURL)
+// SetRegionSource makes ScrapeAll crawl the enabled regions of the registry
+// instead of only mainland China
+func (s *AppleScraper) SetRegionSource(regions RegionSource) {
+	s.regions = regions
+}
+
+// SetCategoryHook sets a function called after each category page is scraped,
+// from the scraping goroutines
+func (s *AppleScraper) SetCategoryHook(hook func(CategoryResult)) {
+	s.categoryHook = hook
+}
+
+// ScrapeAll scrapes all products from every enabled region. It only fails when
+// every region failed.
+func (s *AppleScraper) ScrapeAll(ctx context.Context) ([]*model.Product, error) {
+	var allProducts []*model.Product
+	var errs []string
+	for _, result := range s.ScrapeRegions(ctx, model.ScrapeScope{}) {
+		if result.Err != nil {
+			errs = append(errs, result.Region+": "+result.Err.Error())
+			continue
+		}
+		allProducts = append(allProducts, result.Products...)
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if len(errs) > 0 && len(allProducts) == 0 {
+		return nil, fmt.Errorf("all regions failed: %s", strings.Join(errs, "; "))
+	}
+	return allProducts, nil
+}
+
+// ScrapeRegions scrapes every enabled region within scope concurrently, each with
+// its own result
+func (s *AppleScraper) ScrapeRegions(ctx context.Context, scope model.ScrapeScope) []RegionResult {
+	if s.regions == nil {
+		categories := scopeCategories(model.DefaultRegionCategories, scope)
+		if (scope.Region != "" && scope.Region != "cn") || len(categories) == 0 {
+			return nil
+		}
+		start := time.Now()
+		products, err := s.ScrapeRegion(ctx, "cn", cnBaseURL, categories)
+		return []RegionResult{{Region: "cn", Products: products, Err: err, Duration: time.Since(start)}}
+	}
+
+	// Regions that don't sell the scoped category are left out rather than failed
+	var enabled []*model.Region
+	for _, r := range s.regions.GetRegions() {
+		if r.Enabled && (scope.Region == "" || r.Code == scope.Region) && len(scopeCategories(r.ScrapeCategories(), scope)) > 0 {
+			enabled = append(enabled, r)
+		}
+	}
+
+	results := make([]RegionResult, len(enabled))
+	var wg sync.WaitGroup
+	for i, r := range enabled {
+		wg.Add(1)
+		go func(i int, r *model.Region) {
+			defer wg.Done()
+
+			start := time.Now()
+			products, err := s.ScrapeRegion(ctx, r.Code, r.BaseURL, scopeCategories(r.ScrapeCategories(), scope))
+			if err != nil {
+				slog.Error("Failed to scrape region", "region", r.Code, "error", err)
+			}
+			results[i] = RegionResult{Region: r.Code, Products: products, Err: err, Duration: time.Since(start)}
+		}(i, r)
+	}
+	wg.Wait()
+
+	return results
+}
+
+// scopeCategories narrows a region's category pages to scope's category, if any
+func scopeCategories(categories []string, scope model.ScrapeScope) []string {
+	if scope.Category == "" {
+		return categories
+	}
+	for _, category := range categories {
+		if strings.EqualFold(category, scope.Category) {
+			return []string{category}
+		}
+	}
+	return nil
 }
 
-// ScrapeRegion scrapes products from a specific region
-func (s *AppleScraper) ScrapeRegion(region, baseURL string) ([]*model.Product, error) {
-	// Category pages to scrape
-	// Note: iPhone is not available as refurbished in China/HK
-	// Apple TV is only available in Hong Kong, but we'll skip it for now
-	categoryPages := map[string]string{
-		"Mac":       baseURL + "/mac",
-		"iPad":      baseURL + "/ipad",
-		// "iPhone":  baseURL + "/iphone", // Not available in China/HK
-		"Watch":     baseURL + "/watch",
-		"AirPods":   baseURL + "/airpods",
-		"HomePod":   baseURL + "/homepod",
-		// "Apple TV":  baseURL + "/appletv", // Only in HK, skip for now
-		"Accessories": baseURL + "/accessories",
+// ScrapeRegion scrapes the category pages of a specific region (names from
+// model.RegionCategoryPages, unknown ones are skipped). Failed category pages are
+// skipped; it only fails when none of them could be scraped.
+func (s *AppleScraper) ScrapeRegion(ctx context.Context, region, baseURL string, categories []string) ([]*model.Product, error) {
+	// Each storefront sells its own categories: iPhone only outside China/HK,
+	// Apple TV only in some
+	categoryPages := make(map[string]string, len(categories))
+	for _, category := range categories {
+		if path, ok := model.RegionCategoryPages[category]; ok {
+			categoryPages[category] = baseURL + path
+		}
+	}
+	if len(categoryPages) == 0 {
+		return nil, fmt.Errorf("no known categories to scrape in region %s", region)
 	}
 
 	var allProducts []*model.Product
+	var lastErr error
+	failed := 0
 	var mu sync.Mutex
 	var wg sync.WaitGroup
 
@@ -58,9 +153,23 @@ func (s *AppleScraper) ScrapeRegion(region, baseURL string) ([]*model.Product, e
 		go func(cat, url string) {
 			defer wg.Done()
 
-			products, err := s.scrapeCategoryPage(cat, region, url)
+			start := time.Now()
+			products, err := s.scrapeCategoryPage(ctx, cat, region, url)
+			if s.categoryHook != nil {
+				s.categoryHook(CategoryResult{
+					Region:   region,
+					Category: cat,
+					Products: len(products),
+					Err:      err,
+					Duration: time.Since(start),
+				})
+			}
 			if err != nil {
-				fmt.Printf("Error scraping %s: %v\n", cat, err)
+				slog.Error("Failed to scrape category", "category", cat, "region", region, "error", err)
+				mu.Lock()
+				failed++
+				lastErr = err
+				mu.Unlock()
 				return
 			}
 
@@ -72,12 +181,19 @@ func (s *AppleScraper) ScrapeRegion(region, baseURL string) ([]*model.Product, e
 
 	wg.Wait()
 
+	// A cancelled scrape is incomplete, even if some pages made it
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if failed == len(categoryPages) {
+		return nil, fmt.Errorf("all %d category pages failed: %w", failed, lastErr)
+	}
 	return allProducts, nil
 }
 
 // scrapeCategoryPage scrapes a single category page
-func (s *AppleScraper) scrapeCategoryPage(category, region, url string) ([]*model.Product, error) {
-	html, err := s.client.Fetch(url)
+func (s *AppleScraper) scrapeCategoryPage(ctx context.Context, category, region, url string) ([]*model.Product, error) {
+	html, err := s.client.Fetch(ctx, url)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch page: %w", err)
 	}
@@ -237,10 +353,11 @@ func (s *AppleScraper) parseTile(tile map[string]interface{}, category, region,
 	productURL := ""
 	if detailsURL, ok := tile["productDetailsUrl"].(string); ok {
 		if strings.HasPrefix(detailsURL, "/") {
-			if region == "cn" {
-				productURL = "https://www.apple.com.cn" + detailsURL
+			// Relative links resolve against the storefront the tile came from
+			if u, err := url.Parse(pageURL); err == nil && u.Host != "" {
+				productURL = u.Scheme + "://" + u.Host + detailsURL
 			} else {
-				productURL = "https://www.apple.com.hk" + detailsURL
+				productURL = "https://www.apple.com.cn" + detailsURL
 			}
 		} else {
 			productURL = detailsURL
@@ -259,7 +376,7 @@ func (s *AppleScraper) parseTile(tile map[string]interface{}, category, region,
 		}
 	}
 
-	// Extract part number for ID
+	// Extract part number, used for the ID and to match the same SKU across regions
 	partNumber := ""
 	if priceObj, ok := tile["price"].(map[string]interface{}); ok {
 		partNumber, _ = priceObj["partNumber"].(string)
@@ -271,7 +388,7 @@ func (s *AppleScraper) parseTile(tile map[string]interface{}, category, region,
 	}
 
 	// Generate ID
-	id := model.GenerateID(category, partNumber+title)
+	id := model.GenerateID(category, partNumber, title)
 
 	// Parse specs from title
 	cleanName := strings.TrimPrefix(title, "翻新 ")
@@ -281,12 +398,9 @@ func (s *AppleScraper) parseTile(tile map[string]interface{}, category, region,
 	parsedSpecs := ParseProductSpecs(cleanName)
 	specsDetailBytes, _ := json.Marshal(parsedSpecs.ToMap())
 
-	// Use the category parameter directly, only normalize if it's a generic value
-	// This preserves the correct category from the scrape URL
-	normalizedCategory := category
-	if category == "HomePod" || category == "AirPods" || category == "Apple TV" || category == "Accessories" {
-		normalizedCategory = "Accessory"
-	}
+	// The category comes from the scrape URL; the accessories page mixes in
+	// products that have their own category
+	normalizedCategory := model.ProductCategory(category, cleanName)
 
 	product := &model.Product{
 		ID:          id,
@@ -301,6 +415,7 @@ func (s *AppleScraper) parseTile(tile map[string]interface{}, category, region,
 		Specs:       specs,
 		SpecsDetail: string(specsDetailBytes),
 		StockStatus: "available",
+		PartNumber:  partNumber,
 		// ValueScore will be calculated by SQLiteStore based on historical data
 		CreatedAt:   timestamp,
 		UpdatedAt:   timestamp,
@@ -309,27 +424,46 @@ func (s *AppleScraper) parseTile(tile map[string]interface{}, category, region,
 	return product
 }
 
-// ScrapeProductDetails fetches additional details from a product's detail page
-func (s *AppleScraper) ScrapeProductDetails(product *model.Product) *model.Product {
+// ScrapeProductDetails fetches additional details from a product's detail page.
+// A page that can't be fetched or parsed leaves the product as it was.
+func (s *AppleScraper) ScrapeProductDetails(ctx context.Context, product *model.Product) *model.Product {
+	updated, _ := s.FetchProductDetails(ctx, product)
+	return updated
+}
+
+// FetchProductDetails is ScrapeProductDetails, also returning why the page
+// couldn't be fetched or parsed
+func (s *AppleScraper) FetchProductDetails(ctx context.Context, product *model.Product) (*model.Product, error) {
 	if product.ProductURL == "" {
-		return product
+		return product, fmt.Errorf("product has no detail page")
 	}
 
 	// Use FetchDetail for detail pages with better timeout and retry
-	detailHTML, err := s.client.FetchDetail(product.ProductURL)
+	detailHTML, err := s.client.FetchDetail(ctx, product.ProductURL)
 	if err != nil {
+		if ctx.Err() != nil {
+			return product, ctx.Err()
+		}
 		// Fallback to regular Fetch with retry
-		detailHTML, err = s.client.Fetch(product.ProductURL)
+		detailHTML, err = s.client.Fetch(ctx, product.ProductURL)
 		if err != nil {
-			return product
+			return product, fmt.Errorf("failed to fetch detail page: %w", err)
 		}
 	}
 
+	page, err := parseDetailPage(detailHTML)
+	if err != nil {
+		return product, fmt.Errorf("failed to parse detail page: %w", err)
+	}
+
 	// Extract description from the detail page
-	description := s.extractDescription(detailHTML)
+	description := s.extractDescription(page)
+
+	// Extract detailed specs from the tech specs section
+	detailedSpecs := s.parseSpecItems(page.specsText())
 
-	// Extract detailed specs from the detail page
-	detailedSpecs := s.parseSpecItems(detailHTML)
+	// Extract grade, warranty and battery claims
+	terms := ParseRefurbTerms(detailHTML)
 
 	// Parse existing specs_detail if any
 	existingSpecs := make(map[string]interface{})
@@ -367,107 +501,57 @@ func (s *AppleScraper) ScrapeProductDetails(product *model.Product) *model.Produ
 		product.Description = description
 	}
 	product.SpecsDetail = string(specsDetailBytes)
+	if terms.Grade != "" {
+		product.Grade = terms.Grade
+	}
+	if terms.WarrantyMonths > 0 {
+		product.WarrantyMonths = terms.WarrantyMonths
+	}
+	if terms.BatteryHealth > 0 {
+		product.BatteryHealth = terms.BatteryHealth
+	}
 
-	return product
+	return product, nil
 }
 
-// extractDescription extracts the product description/overview from the detail page
-func (s *AppleScraper) extractDescription(html string) string {
-	// Apple uses multiple patterns for descriptions across different locales
-
-	// Pattern 1: Look for meta description tag (most reliable for most pages)
-	metaDescPattern := `<meta name="description" content="`
-	metaStart := strings.Index(html, metaDescPattern)
-	if metaStart != -1 {
-		contentStart := metaStart + len(metaDescPattern)
-		contentEnd := strings.Index(html[contentStart:], `"`)
-		if contentEnd > 0 && contentEnd < 500 {
-			desc := strings.TrimSpace(html[contentStart : contentStart+contentEnd])
-			// Filter out invalid descriptions
-			if desc != "" && len(desc) > 15 && len(desc) < 500 &&
-			   !strings.HasPrefix(desc, "http") && !strings.Contains(desc, "ziyuan.baidu.com") &&
-			   !strings.Contains(desc, "href=") && !strings.Contains(desc, "<") {
-				return s.cleanHTML(desc)
-			}
-		}
+// extractDescription extracts the product description/overview from the detail page.
+// Apple uses different sources across locales; they are tried from the most to
+// the least reliable.
+func (s *AppleScraper) extractDescription(page *detailPage) string {
+	// Meta description, skipping the verification snippets some locales put there
+	if desc := page.meta("name", "description"); usableDescription(desc, 15, 500) &&
+		!strings.HasPrefix(desc, "http") && !strings.Contains(desc, "ziyuan.baidu.com") &&
+		!strings.Contains(desc, "href=") && !strings.Contains(desc, "<") {
+		return s.cleanHTML(desc)
 	}
 
-	// Pattern 2: Look for og:description meta tag (often has better descriptions)
-	ogDescPattern := `<meta property="og:description" content="`
-	ogStart := strings.Index(html, ogDescPattern)
-	if ogStart != -1 {
-		contentStart := ogStart + len(ogDescPattern)
-		contentEnd := strings.Index(html[contentStart:], `"`)
-		if contentEnd > 0 && contentEnd < 500 {
-			desc := strings.TrimSpace(html[contentStart : contentStart+contentEnd])
-			if desc != "" && len(desc) > 15 && len(desc) < 500 {
-				return s.cleanHTML(desc)
-			}
-		}
+	// Open Graph and Twitter card descriptions
+	if desc := page.meta("property", "og:description"); usableDescription(desc, 15, 500) {
+		return s.cleanHTML(desc)
 	}
-
-	// Pattern 3: Try Twitter description
-	twitterDescPattern := `<meta name="twitter:description" content="`
-	twitterStart := strings.Index(html, twitterDescPattern)
-	if twitterStart != -1 {
-		contentStart := twitterStart + len(twitterDescPattern)
-		contentEnd := strings.Index(html[contentStart:], `"`)
-		if contentEnd > 0 && contentEnd < 500 {
-			desc := strings.TrimSpace(html[contentStart : contentStart+contentEnd])
-			if desc != "" && len(desc) > 15 && len(desc) < 500 {
-				return s.cleanHTML(desc)
-			}
-		}
+	if desc := page.meta("name", "twitter:description"); usableDescription(desc, 15, 500) {
+		return s.cleanHTML(desc)
 	}
 
-	// Pattern 4: Try to find product tagline or hero text
-	// Look for specific Apple class patterns used for taglines
-	taglinePatterns := []string{
-		`"headline":`,
-		`"tagline":`,
-		`class="headline"`,
-		`data-hero-headline`,
-	}
-	for _, pattern := range taglinePatterns {
-		idx := strings.Index(html, pattern)
-		if idx != -1 {
-			contentStart := idx + len(pattern)
-			// Find the next quote or bracket
-			for i := contentStart; i < len(html) && i < contentStart+50; i++ {
-				if html[i] == '"' || html[i] == '\'' || html[i] == '>' {
-					contentStart = i + 1
-					break
-				}
-			}
-			// Find end
-			for i := contentStart; i < len(html) && i < contentStart+300; i++ {
-				if html[i] == '"' || html[i] == '\'' || html[i] == '<' || html[i] == '}' {
-					desc := strings.TrimSpace(html[contentStart:i])
-					if len(desc) > 10 && len(desc) < 200 {
-						return s.cleanHTML(desc)
-					}
-					break
-				}
-			}
-		}
+	// Product tagline or hero text
+	if desc := page.headline(); usableDescription(desc, 10, 200) {
+		return s.cleanHTML(desc)
 	}
 
-	// Pattern 5: Extract from JSON-LD structured data
-	jsonLdStart := strings.Index(html, `"description":`)
-	if jsonLdStart != -1 {
-		contentStart := jsonLdStart + 15 // Skip past "description":
-		contentEnd := strings.Index(html[contentStart:], `"`)
-		if contentEnd > 0 && contentEnd < 300 {
-			desc := strings.TrimSpace(html[contentStart : contentStart+contentEnd])
-			if desc != "" && len(desc) > 20 && len(desc) < 300 {
-				return s.cleanHTML(desc)
-			}
-		}
+	// JSON-LD structured data
+	if desc := page.jsonLDDescription(); usableDescription(desc, 20, 300) {
+		return s.cleanHTML(desc)
 	}
 
 	return ""
 }
 
+// usableDescription reports whether a description candidate has a plausible
+// length (in bytes, exclusive bounds)
+func usableDescription(desc string, minLen, maxLen int) bool {
+	return len(desc) > minLen && len(desc) < maxLen
+}
+
 // cleanHTML removes HTML entities and cleans up text
 func (s *AppleScraper) cleanHTML(text string) string {
 	// Replace common HTML entities
@@ -490,71 +574,8 @@ func (s *AppleScraper) cleanHTML(text string) string {
 	return strings.TrimSpace(cleaned)
 }
 
-// cleanDescription removes HTML tags and excess whitespace from description
-func (s *AppleScraper) cleanDescription(html string) string {
-	// Remove HTML tags
-	cleaned := strings.ReplaceAll(html, "<[^>]*>", " ")
-	// Remove extra whitespace
-	lines := strings.Fields(cleaned)
-	if len(lines) == 0 {
-		return ""
-	}
-	return strings.Join(lines, " ")
-}
-
-// extractDetailedSpecs extracts detailed specifications from the detail page
-func (s *AppleScraper) extractDetailedSpecs(html string) string {
-	// Look for the specs section
-	specsStart := strings.Index(html, "id=\"specs\"")
-	if specsStart == -1 {
-		// Try alternative pattern
-		specsStart = strings.Index(html, "id=\"techspecs\"")
-	}
-	if specsStart == -1 {
-		return ""
-	}
-
-	// Find the content after the specs div
-	contentStart := specsStart + 12
-	// Look for the closing tag
-	depth := 0
-	i := contentStart
-	maxLen := len(html)
-	if i >= maxLen {
-		return ""
-	}
-
-	for i < maxLen {
-		if strings.HasPrefix(html[i:], "<div") || strings.HasPrefix(html[i:], "<section") {
-			depth++
-			i += 4
-		} else if strings.HasPrefix(html[i:], "</div>") || strings.HasPrefix(html[i:], "</section>") {
-			depth--
-			if depth == 0 {
-				break
-			}
-			i += 6
-		} else {
-			i++
-		}
-	}
-
-	// Extract the content
-	content := html[contentStart:i]
-
-	// Parse out individual spec items
-	specs := s.parseSpecItems(content)
-	if len(specs) == 0 {
-		return ""
-	}
-
-	// Return as JSON string
-	specsBytes, _ := json.Marshal(specs)
-	return string(specsBytes)
-}
-
-// parseSpecItems parses individual specification items from HTML content
-func (s *AppleScraper) parseSpecItems(html string) map[string]interface{} {
+// parseSpecItems parses individual specification items from the text of a specs section
+func (s *AppleScraper) parseSpecItems(text string) map[string]interface{} {
 	specs := make(map[string]interface{})
 
 	// Helper to check and add spec
@@ -569,15 +590,15 @@ func (s *AppleScraper) parseSpecItems(html string) map[string]interface{} {
 		pattern string
 		format  string
 	}{
-		{`(\d+(?:\.\d+)?)英寸.*?Liquid 视网膜.*?(\d+) x (\d+)`, "%s英寸 Liquid Retina XDR (%sx%s)"},
-		{`(\d+(?:\.\d+)?)英寸.*?Liquid 视网膜.*?(\d+) x (\d+)`, "%s英寸 Liquid Retina (%sx%s)"},
-		{`(\d+(?:\.\d+)?)英寸.*?Retina.*?(\d+) x (\d+)`, "%s英寸 Retina (%sx%s)"},
-		{`(\d+(?:\.\d+)?)英寸`, "%s英寸"},
+		{`(\d+(?:\.\d+)?)\s*英寸.*?Liquid 视网膜 XDR.*?(\d+) x (\d+)`, "%s英寸 Liquid Retina XDR (%sx%s)"},
+		{`(\d+(?:\.\d+)?)\s*英寸.*?Liquid 视网膜.*?(\d+) x (\d+)`, "%s英寸 Liquid Retina (%sx%s)"},
+		{`(\d+(?:\.\d+)?)\s*英寸.*?Retina.*?(\d+) x (\d+)`, "%s英寸 Retina (%sx%s)"},
+		{`(\d+(?:\.\d+)?)\s*英寸`, "%s英寸"},
 		{`(\d+(?:\.\d+)?)["\s]*Liquid`, "%s英寸 Liquid Retina"},
 	}
 	for _, sp := range screenPatterns {
 		re := regexp.MustCompile(sp.pattern)
-		if match := re.FindStringSubmatch(html); len(match) > 1 {
+		if match := re.FindStringSubmatch(text); len(match) > 1 {
 			if len(match) > 3 {
 				addSpec("display", fmt.Sprintf(sp.format, match[1], match[2], match[3]))
 			} else {
@@ -600,7 +621,7 @@ func (s *AppleScraper) parseSpecItems(html string) map[string]interface{} {
 	}
 	for _, mp := range memPatterns {
 		re := regexp.MustCompile(mp.pattern)
-		if match := re.FindStringSubmatch(html); len(match) > 1 {
+		if match := re.FindStringSubmatch(text); len(match) > 1 {
 			addSpec("memory", fmt.Sprintf(mp.format, match[1]))
 			break
 		}
@@ -620,7 +641,7 @@ func (s *AppleScraper) parseSpecItems(html string) map[string]interface{} {
 	}
 	for _, sp := range storagePatterns {
 		re := regexp.MustCompile(sp.pattern)
-		if match := re.FindStringSubmatch(html); len(match) > 1 {
+		if match := re.FindStringSubmatch(text); len(match) > 1 {
 			addSpec("storage", fmt.Sprintf(sp.format, match[1]))
 			break
 		}
@@ -635,18 +656,18 @@ func (s *AppleScraper) parseSpecItems(html string) map[string]interface{} {
 	}
 	for _, pattern := range chipPatterns {
 		re := regexp.MustCompile(pattern)
-		if match := re.FindString(html); match != "" {
+		if match := re.FindString(text); match != "" {
 			addSpec("chip", strings.TrimSpace(match))
 			break
 		}
 	}
 
 	// Extract connectivity (Wi-Fi, Cellular)
-	if strings.Contains(html, "Wi-Fi") && strings.Contains(html, "蜂窝") {
+	if strings.Contains(text, "Wi-Fi") && strings.Contains(text, "蜂窝") {
 		addSpec("connectivity", "Wi-Fi + 蜂窝网络")
-	} else if strings.Contains(html, "Wi-Fi") && strings.Contains(html, "Cellular") {
+	} else if strings.Contains(text, "Wi-Fi") && strings.Contains(text, "Cellular") {
 		addSpec("connectivity", "Wi-Fi + Cellular")
-	} else if strings.Contains(html, "Wi-Fi") {
+	} else if strings.Contains(text, "Wi-Fi") {
 		addSpec("connectivity", "Wi-Fi")
 	}
 
@@ -663,46 +684,46 @@ func (s *AppleScraper) parseSpecItems(html string) map[string]interface{} {
 	}
 	for _, cp := range cameraPatterns {
 		re := regexp.MustCompile(cp.pattern)
-		if match := re.FindStringSubmatch(html); len(match) > 1 {
+		if match := re.FindStringSubmatch(text); len(match) > 1 {
 			addSpec("camera", fmt.Sprintf(cp.format, match[1]))
 			break
-		} else if match := re.FindString(html); match != "" {
+		} else if match := re.FindString(text); match != "" {
 			addSpec("camera", strings.TrimSpace(match))
 			break
 		}
 	}
 
 	// Extract Touch ID
-	if strings.Contains(html, "触控 ID") || strings.Contains(html, "Touch ID") {
+	if strings.Contains(text, "触控 ID") || strings.Contains(text, "Touch ID") {
 		addSpec("touch_id", "触控 ID")
 	}
 
 	// Extract Face ID
-	if strings.Contains(html, "面容 ID") || strings.Contains(html, "Face ID") {
+	if strings.Contains(text, "面容 ID") || strings.Contains(text, "Face ID") {
 		addSpec("face_id", "面容 ID")
 	}
 
 	// Extract ports/connections
-	if strings.Contains(html, "雷雳 4") || strings.Contains(html, "Thunderbolt 4") {
-		if count := regexp.MustCompile(`雷霆[\s\xa0]*4|Thunderbolt[\s\xa0]*4`).FindAllStringIndex(html, -1); len(count) > 0 {
+	if strings.Contains(text, "雷雳 4") || strings.Contains(text, "Thunderbolt 4") {
+		if count := regexp.MustCompile(`雷雳[\s\xa0]*4|Thunderbolt[\s\xa0]*4`).FindAllStringIndex(text, -1); len(count) > 0 {
 			portCount := len(count)
 			if portCount == 3 {
 				addSpec("ports", "三个雷雳 4 (USB-C) 端口")
 			} else if portCount == 2 {
-				addSpec("ports", "两个雷霆 4 (USB-C) 端口")
+				addSpec("ports", "两个雷雳 4 (USB-C) 端口")
 			} else {
 				addSpec("ports", "雷雳 4 (USB-C)")
 			}
 		}
-	} else if strings.Contains(html, "雷雳 3") || strings.Contains(html, "Thunderbolt 3") {
+	} else if strings.Contains(text, "雷雳 3") || strings.Contains(text, "Thunderbolt 3") {
 		addSpec("ports", "雷雳 3 (USB-C)")
-	} else if strings.Contains(html, "USB-C") {
+	} else if strings.Contains(text, "USB-C") {
 		addSpec("ports", "USB-C")
 	}
 
 	// Extract initial release date (最初发布于)
 	releasePattern := regexp.MustCompile(`(?:最初发布于|Initial release|Released)\s*[：:]?\s*(\d{4})\s*年\s*(\d{1,2})\s*月`)
-	if match := releasePattern.FindStringSubmatch(html); len(match) > 2 {
+	if match := releasePattern.FindStringSubmatch(text); len(match) > 2 {
 		addSpec("release_date", fmt.Sprintf("%s年%s月", match[1], match[2]))
 	}
 
@@ -711,19 +732,19 @@ func (s *AppleScraper) parseSpecItems(html string) map[string]interface{} {
 		pattern string
 		format  string
 	}{
-		{`最长\s*(\d+)\s*小时`, "最长 %s 小时"},
-		{`up to\s*(\d+)\s*hours`, "最长 %s 小时"},
+		{`最长(?:可达)?\s*(\d+)\s*小时`, "最长 %s 小时"},
+		{`(?i)up to\s*(\d+)\s*hours`, "最长 %s 小时"},
 	}
 	for _, bp := range batteryPatterns {
 		re := regexp.MustCompile(bp.pattern)
-		if match := re.FindStringSubmatch(html); len(match) > 1 {
+		if match := re.FindStringSubmatch(text); len(match) > 1 {
 			addSpec("battery", fmt.Sprintf(bp.format, match[1]))
 			break
 		}
 	}
 
 	// Extract keyboard type
-	if strings.Contains(html, "妙控键盘") || strings.Contains(html, "Magic Keyboard") {
+	if strings.Contains(text, "妙控键盘") || strings.Contains(text, "Magic Keyboard") {
 		addSpec("keyboard", "妙控键盘")
 	}
 
@@ -0,0 +1,56 @@
+package scraper
+
+import (
+	"log/slog"
+	"time"
+
+	"apple-price/internal/model"
+)
+
+// A run is a catch-up when at least catchUpMissedCycles intervals (and never less
+// than catchUpMinGap) have passed since the previous scrape, e.g. after the server
+// was offline. Its changes are summarized instead of pushed one by one.
+const (
+	catchUpMissedCycles = 3
+	catchUpMinGap       = time.Hour
+)
+
+// downtime returns how long the scheduler was offline before now, or 0 if the gap
+// since lastScrape is within the normal schedule (or there was no previous scrape)
+func (s *Scheduler) downtime(lastScrape, now time.Time) time.Duration {
+	if lastScrape.IsZero() {
+		return 0
+	}
+	gap := now.Sub(lastScrape)
+	threshold := catchUpMissedCycles * s.longestInterval()
+	if threshold < catchUpMinGap {
+		threshold = catchUpMinGap
+	}
+	if gap < threshold {
+		return 0
+	}
+	return gap
+}
+
+// catchUpBatch collects the changes of a catch-up run; a nil batch means changes
+// are notified as they are found
+type catchUpBatch struct {
+	downtime time.Duration
+	changes  []model.CatchUpChange
+}
+
+func (b *catchUpBatch) add(kind string, product *model.Product, oldPrice float64) {
+	b.changes = append(b.changes, model.CatchUpChange{Kind: kind, Product: product, OldPrice: oldPrice})
+}
+
+// flushCatchUp sends the collected changes as one summary per Bark Key
+func (s *Scheduler) flushCatchUp(b *catchUpBatch) {
+	if len(b.changes) == 0 {
+		return
+	}
+
+	slog.Info("Sending catch-up summary", "downtime", b.downtime.Round(time.Minute), "changes", len(b.changes))
+	if err := s.notifier.NotifyCatchUp(b.downtime, b.changes, s.store.GetAllSubscriptions(), s.store.GetAllNewArrivalSubscriptions()); err != nil {
+		slog.Error("Failed to notify catch-up summary", "error", err)
+	}
+}
@@ -0,0 +1,141 @@
+package scraper
+
+import (
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"time"
+
+	"apple-price/internal/model"
+)
+
+// alertBaselineRuns bounds how many failed or cancelled runs are skipped looking
+// for the run to compare category counts with
+const alertBaselineRuns = 20
+
+// OperatorAlerter delivers alerts to the operator channel
+type OperatorAlerter interface {
+	NotifyOperator(title, content string) error
+}
+
+// SetCategoryAlerts makes each scrape cycle alert the operator when a category's
+// available count drops to zero, or below threshold (0 = only zero)
+func (s *Scheduler) SetCategoryAlerts(alerter OperatorAlerter, threshold int) {
+	s.alerter = alerter
+	s.categoryAlertThreshold = threshold
+}
+
+// recordScrapeRun stores the run with the per category counts of this cycle and
+// compares them with the previous completed run, alerting the operator about
+// categories that ran dry. A category missing from the scrape entirely points at
+// a parser failure rather than a genuine sell-out, and is reported as such.
+func (s *Scheduler) recordScrapeRun(active *activeRun, products []*model.Product, failedRegions []string, startTime time.Time) {
+	counts := make(map[string]*model.ScrapeRunCategory)
+	for _, p := range products {
+		key := p.Region + "|" + p.Category
+		c, ok := counts[key]
+		if !ok {
+			c = &model.ScrapeRunCategory{Region: p.Region, Category: p.Category}
+			counts[key] = c
+		}
+		c.Total++
+		if p.StockStatus != "sold_out" {
+			c.Available++
+		}
+	}
+
+	// Catalog changes noted while upserting, a category may only have removals
+	for key, diff := range active.diffs {
+		c, ok := counts[key]
+		if !ok {
+			c = &model.ScrapeRunCategory{Region: diff.Region, Category: diff.Category}
+			counts[key] = c
+		}
+		c.New, c.Changed, c.Removed = diff.New, diff.Changed, diff.Removed
+	}
+
+	finished := time.Now()
+	run := &model.ScrapeRun{
+		ID:           active.id,
+		Trigger:      active.trigger,
+		Status:       "success",
+		StartedAt:    startTime,
+		FinishedAt:   finished,
+		Duration:     finished.Sub(startTime).Milliseconds(),
+		ProductCount: len(products),
+		Categories:   make([]model.ScrapeRunCategory, 0, len(counts)),
+		Pages:        s.runPages(active),
+		Scope:        active.recordScope(),
+	}
+	for _, c := range counts {
+		run.Categories = append(run.Categories, *c)
+	}
+	sort.Slice(run.Categories, func(i, j int) bool {
+		if run.Categories[i].Region != run.Categories[j].Region {
+			return run.Categories[i].Region < run.Categories[j].Region
+		}
+		return run.Categories[i].Category < run.Categories[j].Category
+	})
+
+	if len(failedRegions) > 0 {
+		run.Status = "partial"
+		run.Error = "failed regions: " + strings.Join(failedRegions, ", ")
+	}
+
+	previous := s.previousCompletedRun()
+
+	if err := s.store.RecordScrapeRun(run); err != nil {
+		slog.Error("Failed to record scrape run", "error", err)
+	}
+
+	// A scoped run leaves out every other category, which would read as a sell-out
+	if s.alerter == nil || previous == nil || run.Scope != nil {
+		return
+	}
+
+	alerts := categoryAlerts(previous, run, s.categoryAlertThreshold)
+	if len(alerts) == 0 {
+		return
+	}
+
+	slog.Warn("Category availability dropped", "alerts", alerts)
+	if err := s.alerter.NotifyOperator("📉 ApplePrice 分类库存告警", strings.Join(alerts, "\n")); err != nil {
+		slog.Error("Failed to alert operator about category availability", "error", err)
+	}
+}
+
+// previousCompletedRun returns the latest full run that scraped products, looking
+// back over at most alertBaselineRuns runs
+func (s *Scheduler) previousCompletedRun() *model.ScrapeRun {
+	for _, run := range s.store.GetScrapeRuns(alertBaselineRuns) {
+		if run.Completed() && run.Scope == nil {
+			return run
+		}
+	}
+	return nil
+}
+
+// categoryAlerts lists the categories whose available count crossed to zero or
+// below threshold between two runs. Only crossings are reported, so a category
+// that stays empty alerts once.
+func categoryAlerts(previous, current *model.ScrapeRun, threshold int) []string {
+	var alerts []string
+	for _, prev := range previous.Categories {
+		if prev.Available == 0 {
+			continue
+		}
+		cur := current.Category(prev.Region, prev.Category)
+		name := prev.Region + "/" + prev.Category
+
+		switch {
+		case cur.Total == 0:
+			alerts = append(alerts, fmt.Sprintf("%s 本次未抓到任何产品（上次在售 %d 款），可能是页面解析失败", name, prev.Available))
+		case cur.Available == 0:
+			alerts = append(alerts, fmt.Sprintf("%s 已全部售罄（上次在售 %d 款）", name, prev.Available))
+		case threshold > 0 && prev.Available >= threshold && cur.Available < threshold:
+			alerts = append(alerts, fmt.Sprintf("%s 在售降至 %d 款（上次 %d 款，阈值 %d）", name, cur.Available, prev.Available, threshold))
+		}
+	}
+	return alerts
+}
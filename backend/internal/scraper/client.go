@@ -2,12 +2,14 @@ package scraper
 
 import (
 	"compress/gzip"
+	"context"
 	"crypto/tls"
 	"fmt"
 	"io"
 	"net/http"
 	"regexp"
 	"strings"
+	"sync/atomic"
 	"time"
 )
 
@@ -15,33 +17,41 @@ import (
 type Client struct {
 	httpClient *http.Client
 	userAgent  string
+	throttle   atomic.Pointer[throttle] // proxies and rate limits, see SetOptions
+	mode       string                   // ModeLive, ModeRecord or ModeReplay, see SetMode
+	fixtureDir string
 }
 
 // NewClient creates a new scraper client
 func NewClient(userAgent string) *Client {
-	return &Client{
-		httpClient: &http.Client{
-			Timeout: 30 * time.Second,
-			Transport: &http.Transport{
-				TLSClientConfig: &tls.Config{
-					InsecureSkipVerify: false,
-				},
-				MaxIdleConns:        10,
-				MaxIdleConnsPerHost: 10,
-				IdleConnTimeout:     90 * time.Second,
+	c := &Client{userAgent: userAgent, mode: ModeLive}
+	c.httpClient = &http.Client{
+		Timeout: 30 * time.Second,
+		Transport: &http.Transport{
+			Proxy: c.proxy,
+			TLSClientConfig: &tls.Config{
+				InsecureSkipVerify: false,
 			},
+			MaxIdleConns:        10,
+			MaxIdleConnsPerHost: 10,
+			IdleConnTimeout:     90 * time.Second,
 		},
-		userAgent: userAgent,
 	}
+	return c
 }
 
 // Fetch fetches a URL and returns the HTML content
-func (c *Client) Fetch(url string) (string, error) {
-	return c.FetchWithRetry(url, 2)
+func (c *Client) Fetch(ctx context.Context, url string) (string, error) {
+	return c.FetchWithRetry(ctx, url, 2)
 }
 
-// FetchWithRetry fetches a URL with retry logic
-func (c *Client) FetchWithRetry(url string, maxRetries int) (string, error) {
+// FetchWithRetry fetches a URL with retry logic. It gives up as soon as ctx is done.
+func (c *Client) FetchWithRetry(ctx context.Context, url string, maxRetries int) (string, error) {
+	if c.replaying() {
+		content, err := c.replay(url, ".html")
+		return string(content), err
+	}
+
 	var lastErr error
 
 	for attempt := 0; attempt <= maxRetries; attempt++ {
@@ -51,22 +61,30 @@ func (c *Client) FetchWithRetry(url string, maxRetries int) (string, error) {
 			if backoff > 10*time.Second {
 				backoff = 10 * time.Second
 			}
-			time.Sleep(backoff)
+			if err := sleepContext(ctx, backoff); err != nil {
+				return "", err
+			}
 		}
 
-		req, err := http.NewRequest("GET", url, nil)
+		req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 		if err != nil {
 			lastErr = fmt.Errorf("failed to create request: %w", err)
 			continue
 		}
 
-		req.Header.Set("User-Agent", c.userAgent)
+		req.Header.Set("User-Agent", c.nextUserAgent())
 		req.Header.Set("Accept", "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8")
 		req.Header.Set("Accept-Language", "zh-CN,zh;q=0.9,en;q=0.8")
 		req.Header.Set("Connection", "keep-alive")
 
+		if err := c.wait(ctx, req.URL.Host); err != nil {
+			return "", err
+		}
 		resp, err := c.httpClient.Do(req)
 		if err != nil {
+			if ctx.Err() != nil {
+				return "", ctx.Err()
+			}
 			lastErr = fmt.Errorf("failed to fetch URL: %w", err)
 			continue
 		}
@@ -91,6 +109,7 @@ func (c *Client) FetchWithRetry(url string, maxRetries int) (string, error) {
 				continue
 			}
 
+			c.record(url, ".html", content)
 			return string(content), nil
 		}
 
@@ -103,11 +122,17 @@ func (c *Client) FetchWithRetry(url string, maxRetries int) (string, error) {
 }
 
 // FetchDetail fetches a product detail page with longer timeout and retry
-func (c *Client) FetchDetail(url string) (string, error) {
+func (c *Client) FetchDetail(ctx context.Context, url string) (string, error) {
+	if c.replaying() {
+		content, err := c.replay(url, ".html")
+		return string(content), err
+	}
+
 	// Create a client with longer timeout for detail pages
 	detailClient := &http.Client{
 		Timeout: 45 * time.Second,
 		Transport: &http.Transport{
+			Proxy: c.proxy,
 			TLSClientConfig: &tls.Config{
 				InsecureSkipVerify: false,
 			},
@@ -117,16 +142,19 @@ func (c *Client) FetchDetail(url string) (string, error) {
 		},
 	}
 
-	req, err := http.NewRequest("GET", url, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
 		return "", fmt.Errorf("failed to create request: %w", err)
 	}
 
-	req.Header.Set("User-Agent", c.userAgent)
+	req.Header.Set("User-Agent", c.nextUserAgent())
 	req.Header.Set("Accept", "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8")
 	req.Header.Set("Accept-Language", "zh-CN,zh;q=0.9,en;q=0.8")
 	req.Header.Set("Connection", "keep-alive")
 
+	if err := c.wait(ctx, req.URL.Host); err != nil {
+		return "", err
+	}
 	resp, err := detailClient.Do(req)
 	if err != nil {
 		return "", fmt.Errorf("failed to fetch URL: %w", err)
@@ -153,23 +181,73 @@ func (c *Client) FetchDetail(url string) (string, error) {
 		return "", fmt.Errorf("failed to read response body: %w", err)
 	}
 
+	c.record(url, ".html", content)
 	return string(content), nil
 }
 
-// ExtractText extracts text content from HTML, removing tags
-func ExtractText(html string) string {
-	// Remove script and style tags
-	re := regexp.MustCompile(`<(script|style)[^>]*>.*?</\1>`)
-	html = re.ReplaceAllString(html, "")
+// maxImageBytes bounds the size of a downloaded product image
+const maxImageBytes = 5 << 20
 
-	// Remove HTML tags
-	re = regexp.MustCompile(`<[^>]+>`)
-	text := re.ReplaceAllString(html, " ")
+// FetchImage downloads a product image
+func (c *Client) FetchImage(ctx context.Context, url string) ([]byte, error) {
+	if c.replaying() {
+		return c.replay(url, ".img")
+	}
 
-	// Clean up whitespace
-	text = strings.Join(strings.Fields(text), " ")
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
 
-	return text
+	req.Header.Set("User-Agent", c.nextUserAgent())
+	req.Header.Set("Accept", "image/png,image/jpeg,image/gif;q=0.9,*/*;q=0.5")
+
+	if err := c.wait(ctx, req.URL.Host); err != nil {
+		return nil, err
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch image: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxImageBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read image: %w", err)
+	}
+	if len(data) > maxImageBytes {
+		return nil, fmt.Errorf("image larger than %d bytes", maxImageBytes)
+	}
+
+	c.record(url, ".img", data)
+	return data, nil
+}
+
+// sleepContext sleeps for d, or returns ctx's error if it is done first
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// ExtractText extracts the visible text of an HTML document, without scripts
+// and styles, with whitespace collapsed
+func ExtractText(raw string) string {
+	page, err := parseDetailPage(raw)
+	if err != nil {
+		return ""
+	}
+	return nodeText(page.root)
 }
 
 // CleanPrice extracts numeric price from string
@@ -214,9 +292,12 @@ func NormalizeCategory(category string) string {
 		"iphone":         "iPhone",
 		"apple watch":    "Watch",
 		"watch":          "Watch",
-		"airpods":        "Accessory",
-		"homepod":        "Accessory",
-		"appletv":        "Accessory",
+		"airpods":        "AirPods",
+		"homepod":        "HomePod",
+		"appletv":        "Apple TV",
+		"apple tv":       "Apple TV",
+		"studio display": "Display",
+		"pro display":    "Display",
 		"pencil":         "Accessory",
 		"magic keyboard": "Accessory",
 		"magic mouse":    "Accessory",
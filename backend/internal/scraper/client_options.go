@@ -0,0 +1,116 @@
+package scraper
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ClientOptions tunes how a Client reaches Apple from restrictive networks or
+// under rate limits
+type ClientOptions struct {
+	Proxies      []string      // http://, https:// or socks5:// proxy URLs, rotated per request
+	HostInterval time.Duration // minimum time between two requests to the same host (0 = no limit)
+	MaxJitter    time.Duration // random delay of up to this before each request (0 = none)
+	UserAgents   []string      // picked at random per request instead of the client's user agent
+}
+
+// throttle spaces out requests per host and rotates proxies and user agents
+type throttle struct {
+	proxies      []*url.URL
+	nextProxy    atomic.Uint64
+	hostInterval time.Duration
+	maxJitter    time.Duration
+	userAgents   []string
+
+	mu       sync.Mutex
+	nextSlot map[string]time.Time // host -> earliest time of its next request
+}
+
+// SetOptions makes the client go through proxies, limit its request rate per
+// host and randomize delays and user agents. It fails on an invalid proxy URL.
+func (c *Client) SetOptions(opts ClientOptions) error {
+	t := &throttle{
+		hostInterval: opts.HostInterval,
+		maxJitter:    opts.MaxJitter,
+		userAgents:   opts.UserAgents,
+		nextSlot:     make(map[string]time.Time),
+	}
+	for _, raw := range opts.Proxies {
+		u, err := parseProxyURL(raw)
+		if err != nil {
+			return err
+		}
+		t.proxies = append(t.proxies, u)
+	}
+
+	c.throttle.Store(t)
+	return nil
+}
+
+// parseProxyURL parses a proxy URL of a scheme the client can use
+func parseProxyURL(raw string) (*url.URL, error) {
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("invalid proxy URL %q", raw)
+	}
+	switch u.Scheme {
+	case "http", "https", "socks5":
+		return u, nil
+	}
+	return nil, fmt.Errorf("unsupported proxy scheme %q (expected http, https or socks5)", u.Scheme)
+}
+
+// proxy picks the proxy for a request, the next one in the list each time
+func (c *Client) proxy(req *http.Request) (*url.URL, error) {
+	t := c.throttle.Load()
+	if t == nil || len(t.proxies) == 0 {
+		return nil, nil
+	}
+	n := t.nextProxy.Add(1) - 1
+	return t.proxies[n%uint64(len(t.proxies))], nil
+}
+
+// nextUserAgent returns the user agent of the next request
+func (c *Client) nextUserAgent() string {
+	t := c.throttle.Load()
+	if t == nil || len(t.userAgents) == 0 {
+		return c.userAgent
+	}
+	return t.userAgents[rand.Intn(len(t.userAgents))]
+}
+
+// wait holds a request to host back until its rate limit slot and a random
+// delay have passed, or ctx is done
+func (c *Client) wait(ctx context.Context, host string) error {
+	t := c.throttle.Load()
+	if t == nil {
+		return nil
+	}
+
+	delay := time.Duration(0)
+	if t.maxJitter > 0 {
+		delay = time.Duration(rand.Int63n(int64(t.maxJitter)))
+	}
+	if t.hostInterval > 0 {
+		t.mu.Lock()
+		now := time.Now()
+		slot := t.nextSlot[host]
+		if slot.Before(now) {
+			slot = now
+		}
+		t.nextSlot[host] = slot.Add(t.hostInterval)
+		t.mu.Unlock()
+		delay += slot.Sub(now)
+	}
+
+	if delay <= 0 {
+		return nil
+	}
+	return sleepContext(ctx, delay)
+}
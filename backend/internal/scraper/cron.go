@@ -0,0 +1,63 @@
+package scraper
+
+import (
+	"math/rand"
+	"time"
+
+	"apple-price/internal/model"
+)
+
+// cronGapWindow is how far ahead a cron schedule is sampled for its longest gap
+const cronGapWindow = 7 * 24 * time.Hour
+
+// SetCron makes scheduled cycles start at the times of a cron expression instead
+// of waiting an interval, e.g. to scrape right after Apple's usual restock times.
+// The adaptive interval bounds don't apply while a cron schedule is set.
+func (s *Scheduler) SetCron(schedule *model.CronSchedule) {
+	s.cron = schedule
+}
+
+// SetStartJitter delays each scheduled cycle by a random duration of up to jitter,
+// so several deployments on the same schedule don't hit Apple at the same second
+func (s *Scheduler) SetStartJitter(jitter time.Duration) {
+	s.startJitter = jitter
+}
+
+// jitter returns a random delay within the start jitter
+func (s *Scheduler) jitter() time.Duration {
+	if s.startJitter <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(s.startJitter)))
+}
+
+// cronWait returns how long after now the cron schedule fires next, false when it
+// never does
+func (s *Scheduler) cronWait(now time.Time) (time.Duration, bool) {
+	next := s.cron.Next(now)
+	if next.IsZero() {
+		return 0, false
+	}
+	return next.Sub(now), true
+}
+
+// cronGap returns the longest time between two firings of the cron schedule over
+// the week after now
+func (s *Scheduler) cronGap(now time.Time) time.Duration {
+	var longest time.Duration
+	end := now.Add(cronGapWindow)
+	for t := s.cron.Next(now); !t.IsZero() && t.Before(end); {
+		next := s.cron.Next(t)
+		if next.IsZero() {
+			break
+		}
+		if gap := next.Sub(t); gap > longest {
+			longest = gap
+		}
+		t = next
+	}
+	if longest == 0 {
+		return cronGapWindow
+	}
+	return longest
+}
@@ -0,0 +1,164 @@
+package scraper
+
+import (
+	"encoding/json"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// detailPage is a product detail page parsed into a DOM tree, so fields are
+// picked by element and attribute instead of by scanning the raw markup
+type detailPage struct {
+	root *html.Node
+}
+
+// parseDetailPage parses a detail page. The HTML5 parser recovers from broken
+// markup the way browsers do, so it only fails on read errors.
+func parseDetailPage(raw string) (*detailPage, error) {
+	root, err := html.Parse(strings.NewReader(raw))
+	if err != nil {
+		return nil, err
+	}
+	return &detailPage{root: root}, nil
+}
+
+// meta returns the content of the first <meta key="name">, e.g.
+// meta("property", "og:description")
+func (p *detailPage) meta(key, name string) string {
+	n := findNode(p.root, func(n *html.Node) bool {
+		return n.DataAtom == atom.Meta && strings.EqualFold(attr(n, key), name)
+	})
+	if n == nil {
+		return ""
+	}
+	return strings.TrimSpace(attr(n, "content"))
+}
+
+// headline returns the text of the page's hero headline, if it has one
+func (p *detailPage) headline() string {
+	n := findNode(p.root, func(n *html.Node) bool {
+		if n.Type != html.ElementNode {
+			return false
+		}
+		if _, ok := attrOK(n, "data-hero-headline"); ok {
+			return true
+		}
+		return hasClass(n, "headline")
+	})
+	if n == nil {
+		return ""
+	}
+	return nodeText(n)
+}
+
+// jsonLDDescription returns the description of the page's JSON-LD structured
+// data (a Product, or the first entry of a list or @graph that has one)
+func (p *detailPage) jsonLDDescription() string {
+	var desc string
+	findNode(p.root, func(n *html.Node) bool {
+		if n.DataAtom != atom.Script || attr(n, "type") != "application/ld+json" || n.FirstChild == nil {
+			return false
+		}
+		var data any
+		if err := json.Unmarshal([]byte(n.FirstChild.Data), &data); err != nil {
+			return false
+		}
+		desc = ldDescription(data)
+		return desc != ""
+	})
+	return desc
+}
+
+// ldDescription looks for a "description" in decoded JSON-LD
+func ldDescription(data any) string {
+	switch v := data.(type) {
+	case map[string]any:
+		if desc, ok := v["description"].(string); ok && strings.TrimSpace(desc) != "" {
+			return strings.TrimSpace(desc)
+		}
+		return ldDescription(v["@graph"])
+	case []any:
+		for _, item := range v {
+			if desc := ldDescription(item); desc != "" {
+				return desc
+			}
+		}
+	}
+	return ""
+}
+
+// specsText returns the visible text of the tech specs section (#specs or
+// #techspecs), or of the whole page when it has none
+func (p *detailPage) specsText() string {
+	section := findNode(p.root, func(n *html.Node) bool {
+		id := attr(n, "id")
+		return n.Type == html.ElementNode && (id == "specs" || id == "techspecs")
+	})
+	if section == nil {
+		return nodeText(p.root)
+	}
+	return nodeText(section)
+}
+
+// findNode returns the first node in document order that match accepts
+func findNode(n *html.Node, match func(*html.Node) bool) *html.Node {
+	if match(n) {
+		return n
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if found := findNode(c, match); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+// nodeText returns the visible text under n with whitespace collapsed; scripts,
+// styles and templates are skipped
+func nodeText(n *html.Node) string {
+	var b strings.Builder
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		switch {
+		case n.Type == html.TextNode:
+			b.WriteString(n.Data)
+			b.WriteByte(' ')
+			return
+		case n.DataAtom == atom.Script, n.DataAtom == atom.Style, n.DataAtom == atom.Noscript, n.DataAtom == atom.Template:
+			return
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(n)
+	return strings.Join(strings.Fields(b.String()), " ")
+}
+
+// attr returns an attribute of n, "" if it isn't set
+func attr(n *html.Node, key string) string {
+	value, _ := attrOK(n, key)
+	return value
+}
+
+// attrOK returns an attribute of n and whether it is set
+func attrOK(n *html.Node, key string) (string, bool) {
+	for _, a := range n.Attr {
+		if a.Namespace == "" && strings.EqualFold(a.Key, key) {
+			return a.Val, true
+		}
+	}
+	return "", false
+}
+
+// hasClass reports whether n has class among its classes
+func hasClass(n *html.Node, class string) bool {
+	for _, c := range strings.Fields(attr(n, "class")) {
+		if c == class {
+			return true
+		}
+	}
+	return false
+}
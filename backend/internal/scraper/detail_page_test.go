@@ -0,0 +1,166 @@
+package scraper
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// loadDetailPage parses a detail page fixture from testdata
+func loadDetailPage(t *testing.T, name string) *detailPage {
+	t.Helper()
+	raw, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatalf("read fixture: %v", err)
+	}
+	page, err := parseDetailPage(string(raw))
+	if err != nil {
+		t.Fatalf("parse fixture: %v", err)
+	}
+	return page
+}
+
+func TestExtractDescription(t *testing.T) {
+	tests := []struct {
+		name    string
+		fixture string
+		want    string
+	}{
+		{
+			name:    "meta description",
+			fixture: "macbook_pro_cn.html",
+			want:    "翻新 14 英寸 MacBook Pro Apple M3 Pro 芯片 (配备 11 核中央处理器和 14 核图形处理器) - 深空黑色，经过 Apple 认证，享有一年有限保修服务。",
+		},
+		{
+			name:    "verification snippet skipped for og:description",
+			fixture: "ipad_air_cn_og.html",
+			want:    "翻新 iPad Air Wi-Fi + 蜂窝网络 256GB - 深空灰色 (第五代)，Apple M1 芯片，经过 Apple 认证。",
+		},
+		{
+			name:    "short meta description skipped for twitter:description",
+			fixture: "macbook_air_us.html",
+			want:    "Refurbished 13-inch MacBook Air Apple M2 Chip with 8‑Core CPU and 10‑Core GPU - Midnight, certified by Apple with a one-year warranty.",
+		},
+		{
+			name:    "hero headline",
+			fixture: "hero_headline.html",
+			want:    "小身形， 大能量。Mac mini 搭载 M2 芯片。",
+		},
+		{
+			name:    "JSON-LD @graph",
+			fixture: "jsonld_only.html",
+			want:    "Refurbished 24-inch iMac with Apple M3 chip, 8GB unified memory and 256GB SSD.",
+		},
+	}
+
+	s := &AppleScraper{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := s.extractDescription(loadDetailPage(t, tt.fixture)); got != tt.want {
+				t.Errorf("extractDescription() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExtractDescriptionMissing(t *testing.T) {
+	page, err := parseDetailPage(`<html><head><meta name="description" content="Apple"></head><body><p>Refurbished</p></body></html>`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &AppleScraper{}
+	if got := s.extractDescription(page); got != "" {
+		t.Errorf("extractDescription() = %q, want empty", got)
+	}
+}
+
+func TestDetailedSpecs(t *testing.T) {
+	tests := []struct {
+		name    string
+		fixture string
+		want    map[string]interface{}
+	}{
+		{
+			name:    "MacBook Pro zh-CN techspecs",
+			fixture: "macbook_pro_cn.html",
+			want: map[string]interface{}{
+				"release_date": "2023年11月",
+				"chip":         "M3 Pro",
+				"memory":       "18GB 统一内存",
+				"storage":      "512GB 固态硬盘",
+				"display":      "14.2英寸 Liquid Retina XDR (3024x1964)",
+				"camera":       "1080p FaceTime 高清摄像头",
+				"touch_id":     "触控 ID",
+				"keyboard":     "妙控键盘",
+				"ports":        "雷雳 4 (USB-C)",
+				"connectivity": "Wi-Fi",
+				"battery":      "最长 18 小时",
+			},
+		},
+		{
+			name:    "iPad Air zh-CN specs list",
+			fixture: "ipad_air_cn_og.html",
+			want: map[string]interface{}{
+				"release_date": "2022年3月",
+				"chip":         "M1",
+				"display":      "10.9英寸 Liquid Retina (2360x1640)",
+				"camera":       "1200万像素",
+				"touch_id":     "触控 ID",
+				"connectivity": "Wi-Fi + 蜂窝网络",
+				"ports":        "USB-C",
+				"battery":      "最长 10 小时",
+			},
+		},
+		{
+			name:    "MacBook Air en-US techspecs",
+			fixture: "macbook_air_us.html",
+			want: map[string]interface{}{
+				"chip":         "M2",
+				"memory":       "16GB Unified Memory",
+				"storage":      "512GB SSD",
+				"camera":       "1080p FaceTime",
+				"touch_id":     "触控 ID",
+				"keyboard":     "妙控键盘",
+				"connectivity": "Wi-Fi",
+				"battery":      "最长 18 小时",
+			},
+		},
+		{
+			name:    "no specs section falls back to the page text",
+			fixture: "hero_headline.html",
+			want: map[string]interface{}{
+				"chip":    "M2",
+				"memory":  "8GB 统一内存",
+				"storage": "256GB 固态硬盘",
+				"ports":   "雷雳 4 (USB-C)",
+			},
+		},
+	}
+
+	s := &AppleScraper{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := s.parseSpecItems(loadDetailPage(t, tt.fixture).specsText())
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseSpecItems() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSpecsTextSection(t *testing.T) {
+	page := loadDetailPage(t, "macbook_pro_cn.html")
+	text := page.specsText()
+	if want := "技术规格 概览 最初发布于 2023 年 11 月"; !strings.HasPrefix(text, want) {
+		t.Errorf("specsText() = %q, want it to start with %q", text, want)
+	}
+
+	// The footnotes and the inline script sit outside #techspecs
+	for _, outside := range []string{"1GB = 10 亿字节", "rfSpecs"} {
+		if strings.Contains(text, outside) {
+			t.Errorf("specsText() contains %q from outside the specs section", outside)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package scraper
+
+import (
+	"container/heap"
+
+	"apple-price/internal/model"
+)
+
+// detailQueue orders pending detail fetches by model.PendingDetail.Before. Each
+// product is queued once, so the queue can't outgrow the catalog.
+type detailQueue struct {
+	items []*model.PendingDetail
+	index map[string]int // product ID -> position in items
+}
+
+func newDetailQueue() *detailQueue {
+	return &detailQueue{index: make(map[string]int)}
+}
+
+// push queues item, or raises the priority of the product's entry if it is
+// already queued. It reports whether the queue changed.
+func (q *detailQueue) push(item *model.PendingDetail) bool {
+	if i, ok := q.index[item.ProductID]; ok {
+		if item.Priority <= q.items[i].Priority {
+			return false
+		}
+		q.items[i].Priority = item.Priority
+		heap.Fix(q, i)
+		return true
+	}
+	heap.Push(q, item)
+	return true
+}
+
+// pop removes and returns the next item to fetch, nil when the queue is empty
+func (q *detailQueue) pop() *model.PendingDetail {
+	if len(q.items) == 0 {
+		return nil
+	}
+	return heap.Pop(q).(*model.PendingDetail)
+}
+
+// get returns the queued entry of a product
+func (q *detailQueue) get(productID string) (*model.PendingDetail, bool) {
+	i, ok := q.index[productID]
+	if !ok {
+		return nil, false
+	}
+	return q.items[i], true
+}
+
+// heap.Interface, use push and pop instead of Push and Pop
+
+func (q *detailQueue) Len() int           { return len(q.items) }
+func (q *detailQueue) Less(i, j int) bool { return q.items[i].Before(q.items[j]) }
+
+func (q *detailQueue) Swap(i, j int) {
+	q.items[i], q.items[j] = q.items[j], q.items[i]
+	q.index[q.items[i].ProductID] = i
+	q.index[q.items[j].ProductID] = j
+}
+
+func (q *detailQueue) Push(x any) {
+	item := x.(*model.PendingDetail)
+	q.index[item.ProductID] = len(q.items)
+	q.items = append(q.items, item)
+}
+
+func (q *detailQueue) Pop() any {
+	last := len(q.items) - 1
+	item := q.items[last]
+	q.items[last] = nil
+	q.items = q.items[:last]
+	delete(q.index, item.ProductID)
+	return item
+}
@@ -3,22 +3,41 @@ package scraper
 import (
 	"context"
 	"fmt"
-	"log"
+	"log/slog"
+	"sort"
 	"sync"
 	"time"
 
 	"apple-price/internal/model"
 )
 
-// DetailScraper handles asynchronous detail fetching with retry logic
+const (
+	// detailNewWindow is how long after a product is first seen its details are
+	// fetched with model.DetailPriorityNew
+	detailNewWindow = 24 * time.Hour
+
+	// A product whose details couldn't be fetched is tried again after
+	// detailRetryBase, doubling with each failure up to detailRetryMax
+	detailRetryBase = time.Hour
+	detailRetryMax  = 24 * time.Hour
+)
+
+// DetailScraper handles asynchronous detail fetching with retry logic. Products
+// are fetched by priority, new and subscribed ones first, and the queue is kept
+// in the store so a restart picks up where it left off.
 type DetailScraper struct {
 	scraper      *AppleScraper
 	store        StoreInterface
-	queue        chan *model.Product
+	queue        *detailQueue                    // guarded by mu
+	failures     map[string]*model.PendingDetail // product ID -> failed fetch awaiting retry or given up, guarded by mu
+	maxAttempts  int                             // failed fetches before a product is given up (0 = never)
+	wake         chan struct{}                   // signals idle workers that something was queued
 	workers      int
 	retryMax     int
 	retryDelay   time.Duration
 	stopCh       chan struct{}
+	ctx          context.Context // cancelled by Stop to abort in-flight fetches
+	cancel       context.CancelFunc
 	wg           sync.WaitGroup
 	isRunning    bool
 	mu           sync.RWMutex
@@ -36,18 +55,32 @@ type DetailStats struct {
 
 // NewDetailScraper creates a new asynchronous detail scraper
 func NewDetailScraper(scraper *AppleScraper, store StoreInterface, workers int) *DetailScraper {
+	ctx, cancel := context.WithCancel(context.Background())
 	return &DetailScraper{
-		scraper:    scraper,
-		store:      store,
-		queue:      make(chan *model.Product, 1000),
-		workers:    workers,
-		retryMax:   3,
-		retryDelay: 2 * time.Second,
-		stopCh:     make(chan struct{}),
-		stats:      DetailStats{},
+		scraper:     scraper,
+		store:       store,
+		queue:       newDetailQueue(),
+		failures:    make(map[string]*model.PendingDetail),
+		maxAttempts: model.DefaultDetailMaxAttempts,
+		wake:        make(chan struct{}, workers),
+		workers:     workers,
+		retryMax:    3,
+		retryDelay:  2 * time.Second,
+		stopCh:      make(chan struct{}),
+		ctx:         ctx,
+		cancel:      cancel,
+		stats:       DetailStats{},
 	}
 }
 
+// SetMaxAttempts sets how many failed fetches a product gets before it is given
+// up until an operator requeues it (0 = keep retrying)
+func (d *DetailScraper) SetMaxAttempts(n int) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.maxAttempts = n
+}
+
 // Start begins processing the detail queue
 func (d *DetailScraper) Start() {
 	d.mu.Lock()
@@ -56,9 +89,19 @@ func (d *DetailScraper) Start() {
 		return
 	}
 	d.isRunning = true
+
+	// Resume the queue left by the previous run
+	restored := 0
+	for _, item := range d.store.GetPendingDetails() {
+		if item.Failed() {
+			d.failures[item.ProductID] = item
+		} else if d.queue.push(item) {
+			restored++
+		}
+	}
 	d.mu.Unlock()
 
-	log.Printf("[DetailScraper] Starting with %d workers", d.workers)
+	slog.Info("Detail scraper starting", "component", "detail_scraper", "workers", d.workers, "restored", restored)
 
 	d.wg.Add(d.workers)
 	for i := 0; i < d.workers; i++ {
@@ -67,6 +110,9 @@ func (d *DetailScraper) Start() {
 
 	// Start stats reporter
 	go d.statsReporter()
+
+	// Put failed products back in the queue as their retries come due
+	go d.retryFailures()
 }
 
 // Stop gracefully stops the detail scraper (idempotent)
@@ -86,23 +132,16 @@ func (d *DetailScraper) Stop() {
 	default:
 		close(d.stopCh)
 	}
-
-	select {
-	case _, ok := <-d.queue:
-		if ok {
-			close(d.queue)
-		}
-	default:
-		close(d.queue)
-	}
+	d.cancel()
 
 	d.wg.Wait()
 
-	log.Printf("[DetailScraper] Stopped. Stats: Queued=%d, Processed=%d, Success=%d, Failed=%d, Retries=%d",
-		d.stats.TotalQueued, d.stats.TotalProcessed, d.stats.TotalSuccess, d.stats.TotalFailed, d.stats.TotalRetries)
+	slog.Info("Detail scraper stopped", "component", "detail_scraper",
+		"queued", d.stats.TotalQueued, "processed", d.stats.TotalProcessed, "success", d.stats.TotalSuccess,
+		"failed", d.stats.TotalFailed, "retries", d.stats.TotalRetries)
 }
 
-// Enqueue adds products to the detail queue
+// Enqueue adds products missing a description to the detail queue
 func (d *DetailScraper) Enqueue(products []*model.Product) int {
 	d.mu.Lock()
 	defer d.mu.Unlock()
@@ -121,19 +160,18 @@ func (d *DetailScraper) Enqueue(products []*model.Product) int {
 		if p.Region == "hk" {
 			continue
 		}
+		// Failed products are retried on their own schedule
+		if _, failed := d.failures[p.ID]; failed {
+			continue
+		}
 
-		select {
-		case d.queue <- p:
-			d.stats.TotalQueued++
+		if d.pushLocked(p.ID, d.priority(p)) {
 			count++
-		default:
-			// Queue full, skip this product
-			log.Printf("[DetailScraper] Queue full, skipping %s", p.ID)
 		}
 	}
 
 	if count > 0 {
-		log.Printf("[DetailScraper] Enqueued %d products for detail fetching", count)
+		slog.Debug("Enqueued products for detail fetching", "component", "detail_scraper", "count", count)
 	}
 	return count
 }
@@ -144,37 +182,189 @@ func (d *DetailScraper) EnqueueSingle(product *model.Product) bool {
 		return false
 	}
 
-	select {
-	case d.queue <- product:
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if _, failed := d.failures[product.ID]; failed {
+		return false
+	}
+	return d.pushLocked(product.ID, d.priority(product))
+}
+
+// Requeue fetches a product's details again ahead of everything else, even if it
+// already has a description or was given up, resetting its failed attempts
+func (d *DetailScraper) Requeue(productID string) error {
+	product, ok := d.store.GetProduct(productID)
+	if !ok {
+		return fmt.Errorf("product %s not found", productID)
+	}
+	if product.ProductURL == "" {
+		return fmt.Errorf("product %s has no detail page", productID)
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	delete(d.failures, productID)
+	d.pushLocked(productID, model.DetailPriorityRequeued)
+	return nil
+}
+
+// priority returns the priority a product's details are fetched with
+func (d *DetailScraper) priority(p *model.Product) int {
+	switch {
+	case time.Since(p.CreatedAt) < detailNewWindow:
+		return model.DetailPriorityNew
+	case len(d.store.GetSubscriptionsByProduct(p.ID)) > 0:
+		return model.DetailPrioritySubscribed
+	}
+	return model.DetailPriorityBackfill
+}
+
+// pushLocked queues a product, persisting the entry and waking a worker. It
+// reports whether the product was newly queued or moved up. Caller must hold d.mu.
+func (d *DetailScraper) pushLocked(productID string, priority int) bool {
+	item := &model.PendingDetail{ProductID: productID, Priority: priority, QueuedAt: time.Now()}
+	if queued, ok := d.queue.get(productID); ok {
+		item.QueuedAt = queued.QueuedAt
+	} else {
 		d.stats.TotalQueued++
-		return true
-	default:
+	}
+	if !d.queue.push(item) {
 		return false
 	}
+	if err := d.store.AddPendingDetail(item); err != nil {
+		slog.Warn("Failed to persist detail queue entry", "component", "detail_scraper", "product_id", productID, "error", err)
+	}
+
+	select {
+	case d.wake <- struct{}{}:
+	default:
+	}
+	return true
+}
+
+// next takes the next product to fetch off the queue, nil when it is empty
+func (d *DetailScraper) next() *model.PendingDetail {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.queue.pop()
+}
+
+// fail records a failed fetch of item and schedules its retry, or gives the
+// product up once it reached the attempt cap. It returns the updated entry.
+func (d *DetailScraper) fail(item *model.PendingDetail, err error) model.PendingDetail {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	item.Attempts++
+	item.LastError = err.Error()
+	item.NextAttemptAt = nil
+	if d.maxAttempts > 0 && item.Attempts >= d.maxAttempts {
+		item.GivenUp = true
+	} else {
+		backoff := detailRetryBase << (item.Attempts - 1)
+		if backoff > detailRetryMax || backoff <= 0 {
+			backoff = detailRetryMax
+		}
+		next := time.Now().Add(backoff)
+		item.NextAttemptAt = &next
+	}
+
+	d.failures[item.ProductID] = item
+	if err := d.store.AddPendingDetail(item); err != nil {
+		slog.Warn("Failed to persist detail queue entry", "component", "detail_scraper", "product_id", item.ProductID, "error", err)
+	}
+	return *item
+}
+
+// retryFailures moves failed products whose retry is due back into the queue
+// until the detail scraper stops
+func (d *DetailScraper) retryFailures() {
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-d.stopCh:
+			return
+		case now := <-ticker.C:
+			d.mu.Lock()
+			for id, item := range d.failures {
+				if item.GivenUp || item.NextAttemptAt == nil || item.NextAttemptAt.After(now) {
+					continue
+				}
+				delete(d.failures, id)
+				d.queue.push(item)
+				select {
+				case d.wake <- struct{}{}:
+				default:
+				}
+			}
+			d.mu.Unlock()
+		}
+	}
+}
+
+// Failures returns the products whose last detail fetch failed, most attempts first
+func (d *DetailScraper) Failures() []*model.PendingDetail {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	failures := make([]*model.PendingDetail, 0, len(d.failures))
+	for _, item := range d.failures {
+		f := *item
+		failures = append(failures, &f)
+	}
+	sort.Slice(failures, func(i, j int) bool {
+		if failures[i].Attempts != failures[j].Attempts {
+			return failures[i].Attempts > failures[j].Attempts
+		}
+		return failures[i].ProductID < failures[j].ProductID
+	})
+	return failures
+}
+
+// done drops a fetched or given up product from the persisted queue
+func (d *DetailScraper) done(productID string) {
+	if err := d.store.DeletePendingDetail(productID); err != nil {
+		slog.Warn("Failed to remove detail queue entry", "component", "detail_scraper", "product_id", productID, "error", err)
+	}
 }
 
 // worker processes products from the queue
 func (d *DetailScraper) worker(id int) {
 	defer d.wg.Done()
 
-	log.Printf("[DetailScraper] Worker %d started", id)
+	slog.Debug("Detail worker started", "component", "detail_scraper", "worker", id)
 
 	for {
-		select {
-		case <-d.stopCh:
-			log.Printf("[DetailScraper] Worker %d stopping", id)
+		if d.ctx.Err() != nil {
+			slog.Debug("Detail worker stopping", "component", "detail_scraper", "worker", id)
 			return
-		case product, ok := <-d.queue:
-			if !ok {
+		}
+
+		item := d.next()
+		if item == nil {
+			select {
+			case <-d.stopCh:
+				slog.Debug("Detail worker stopping", "component", "detail_scraper", "worker", id)
 				return
+			case <-d.wake:
 			}
-			d.processWithRetry(product, id)
+			continue
+		}
+
+		product, ok := d.store.GetProduct(item.ProductID)
+		if !ok || (product.Description != "" && item.Priority != model.DetailPriorityRequeued) {
+			d.done(item.ProductID)
+			continue
 		}
+		d.processWithRetry(item, product, id)
 	}
 }
 
-// processWithRetry processes a product with retry logic
-func (d *DetailScraper) processWithRetry(product *model.Product, workerID int) {
+// processWithRetry processes a product with retry logic. When every retry fails
+// the failure is recorded on its queue entry.
+func (d *DetailScraper) processWithRetry(item *model.PendingDetail, product *model.Product, workerID int) {
 	var lastErr error
 	var updatedProduct *model.Product
 
@@ -182,34 +372,64 @@ func (d *DetailScraper) processWithRetry(product *model.Product, workerID int) {
 		if attempt > 0 {
 			// Exponential backoff: 2s, 4s, 8s
 			backoff := d.retryDelay * time.Duration(1<<uint(attempt-1))
-			log.Printf("[DetailScraper] Worker %d: Retry %d/%d for %s after %v",
-				workerID, attempt, d.retryMax, product.ID, backoff)
-			time.Sleep(backoff)
+			slog.Debug("Retrying product details", "component", "detail_scraper",
+				"worker", workerID, "attempt", attempt, "max_retries", d.retryMax, "product_id", product.ID, "backoff", backoff)
+			if sleepContext(d.ctx, backoff) != nil {
+				return
+			}
 			d.stats.TotalRetries++
 		}
 
 		// Fetch details
-		updatedProduct = d.scraper.ScrapeProductDetails(product)
+		var err error
+		updatedProduct, err = d.scraper.FetchProductDetails(d.ctx, product)
+		if d.ctx.Err() != nil {
+			return
+		}
 
 		// Save if we got a description
 		if updatedProduct.Description != "" {
+			d.extractDominantColor(updatedProduct, workerID)
 			d.store.UpsertProduct(updatedProduct)
+			d.done(product.ID)
 			d.store.Save()
 			d.stats.TotalSuccess++
-			log.Printf("[DetailScraper] Worker %d: ✓ %s - %d chars",
-				workerID, product.ID, len(updatedProduct.Description))
+			slog.Debug("Fetched product details", "component", "detail_scraper",
+				"worker", workerID, "product_id", product.ID, "description_chars", len(updatedProduct.Description))
 			d.stats.TotalProcessed++
 			return
 		}
 
-		lastErr = fmt.Errorf("no description extracted")
+		lastErr = err
+		if lastErr == nil {
+			lastErr = fmt.Errorf("no description extracted")
+		}
 	}
 
 	// All retries exhausted
+	failed := d.fail(item, lastErr)
 	d.stats.TotalFailed++
 	d.stats.TotalProcessed++
-	log.Printf("[DetailScraper] Worker %d: ✗ %s - failed after %d retries: %v",
-		workerID, product.ID, d.retryMax, lastErr)
+	slog.Warn("Failed to fetch product details", "component", "detail_scraper",
+		"worker", workerID, "product_id", product.ID, "retries", d.retryMax, "attempts", failed.Attempts,
+		"given_up", failed.GivenUp, "error", lastErr)
+}
+
+// extractDominantColor fills in the product image's dominant color if it isn't known yet.
+// Failures are logged and retried on the product's next detail scrape.
+func (d *DetailScraper) extractDominantColor(product *model.Product, workerID int) {
+	if product.DominantColor != "" || product.ImageURL == "" {
+		return
+	}
+
+	data, err := d.scraper.client.FetchImage(d.ctx, product.ImageURL)
+	if err == nil {
+		product.DominantColor, err = DominantColor(data)
+	}
+	if err != nil {
+		slog.Debug("Failed to extract image color", "component", "detail_scraper",
+			"worker", workerID, "product_id", product.ID, "error", err)
+	}
 }
 
 // statsReporter periodically logs statistics
@@ -224,11 +444,12 @@ func (d *DetailScraper) statsReporter() {
 		case <-ticker.C:
 			d.mu.Lock()
 			stats := d.stats
-			queueLen := len(d.queue)
+			queueLen := d.queue.Len()
 			d.mu.Unlock()
 
-			log.Printf("[DetailScraper] Stats - Queue: %d, Processed: %d, Success: %d, Failed: %d, Retries: %d",
-				queueLen, stats.TotalProcessed, stats.TotalSuccess, stats.TotalFailed, stats.TotalRetries)
+			slog.Info("Detail scraper stats", "component", "detail_scraper",
+				"queue", queueLen, "processed", stats.TotalProcessed, "success", stats.TotalSuccess,
+				"failed", stats.TotalFailed, "retries", stats.TotalRetries)
 		}
 	}
 }
@@ -251,7 +472,7 @@ func (d *DetailScraper) IsRunning() bool {
 func (d *DetailScraper) GetQueueSize() int {
 	d.mu.RLock()
 	defer d.mu.RUnlock()
-	return len(d.queue)
+	return d.queue.Len()
 }
 
 // ProcessExistingProducts processes products that don't have descriptions yet
@@ -266,15 +487,15 @@ func (d *DetailScraper) ProcessExistingProducts() {
 	}
 
 	if len(needDetails) > 0 {
-		log.Printf("[DetailScraper] Found %d existing products needing details", len(needDetails))
+		slog.Info("Found existing products needing details", "component", "detail_scraper", "count", len(needDetails))
 		queued := d.Enqueue(needDetails)
-		log.Printf("[DetailScraper] Enqueued %d/%d existing products", queued, len(needDetails))
+		slog.Info("Enqueued existing products", "component", "detail_scraper", "queued", queued, "total", len(needDetails))
 	}
 }
 
 // ScrapeWithAsyncDetails scrapes products and queues detail fetching asynchronously
 func (d *DetailScraper) ScrapeWithAsyncDetails(ctx context.Context) error {
-	log.Println("[DetailScraper] Starting scrape with async details...")
+	slog.Info("Starting scrape with async details", "component", "detail_scraper")
 
 	// Check for cancellation before starting
 	select {
@@ -284,12 +505,12 @@ func (d *DetailScraper) ScrapeWithAsyncDetails(ctx context.Context) error {
 	}
 
 	// Scrape all products
-	products, err := d.scraper.ScrapeAll()
+	products, err := d.scraper.ScrapeAll(ctx)
 	if err != nil {
 		return fmt.Errorf("scrape failed: %w", err)
 	}
 
-	log.Printf("[DetailScraper] Scraped %d products", len(products))
+	slog.Info("Scraped products", "component", "detail_scraper", "count", len(products))
 
 	// Check for cancellation after scraping
 	select {
@@ -308,7 +529,7 @@ func (d *DetailScraper) ScrapeWithAsyncDetails(ctx context.Context) error {
 
 	// Enqueue products for detail fetching
 	queued := d.Enqueue(products)
-	log.Printf("[DetailScraper] Enqueued %d/%d products for async details", queued, len(products))
+	slog.Info("Enqueued products for async details", "component", "detail_scraper", "queued", queued, "total", len(products))
 
 	return nil
 }
@@ -0,0 +1,119 @@
+package scraper
+
+import (
+	"context"
+	"log/slog"
+	"sort"
+	"time"
+
+	"apple-price/internal/model"
+)
+
+// DryRunScrape scrapes every region like a scrape run but writes nothing: no
+// products, statuses, run record or notifications. It reports how the scraped
+// listings differ from the stored catalog, so parser changes can be checked
+// before a real run commits them. It takes the run slot, failing with
+// model.ErrScrapeRunning while another run is in flight, and stops early when
+// ctx is done.
+func (s *Scheduler) DryRunScrape(ctx context.Context) (*model.ScrapeDryRun, error) {
+	run := newActiveRun(model.ScrapeTriggerDryRun)
+	if !s.claimRun(run) {
+		run.cancel()
+		return nil, model.ErrScrapeRunning
+	}
+	defer s.endDryRun(run)
+	defer context.AfterFunc(ctx, run.cancel)()
+
+	startTime := time.Now()
+	slog.Info("Starting dry-run scrape", "run_id", run.id)
+
+	products, failed, err := s.scrape(run.ctx, startTime, model.ScrapeScope{}, true)
+	if err != nil {
+		return nil, err
+	}
+
+	report := diffCatalog(products, s.store.GetAllProducts())
+	report.RunID = run.id
+	report.StartedAt = startTime
+	report.Duration = time.Since(startTime).Milliseconds()
+	report.Scraped = len(products)
+	report.FailedRegions = failed
+	if report.FailedRegions == nil {
+		report.FailedRegions = []string{}
+	}
+	report.Pages = s.runPages(run)
+
+	slog.Info("Dry-run scrape finished", "run_id", run.id, "scraped", len(products),
+		"new", len(report.New), "price_changes", len(report.PriceChanges), "disappeared", len(report.Disappeared))
+	return report, nil
+}
+
+// endDryRun frees the run slot, starting the run queued behind the dry run, if any
+func (s *Scheduler) endDryRun(run *activeRun) {
+	run.cancel()
+	if next := s.releaseRun(); next != nil {
+		s.inFlight.Add(1)
+		go s.execute(next)
+	}
+	s.inFlight.Done()
+}
+
+// diffCatalog compares scraped listings with the stored catalog the way a scrape
+// run would apply them: listings stored under a legacy title based ID count as
+// the same product, and stored products missing from a region/category the
+// scrape covered would be marked sold out
+func diffCatalog(scraped, stored []*model.Product) *model.ScrapeDryRun {
+	report := &model.ScrapeDryRun{
+		New:          []*model.Product{},
+		PriceChanges: []model.DryRunPriceChange{},
+		Disappeared:  []*model.Product{},
+	}
+
+	byID := make(map[string]*model.Product, len(stored))
+	for _, p := range stored {
+		byID[p.ID] = p
+	}
+
+	seen := make(map[string]bool, len(scraped))
+	scope := make(map[string]bool)
+	for _, p := range scraped {
+		scope[p.Region+"|"+p.Category] = true
+
+		existing, ok := byID[p.ID]
+		seen[p.ID] = true
+		if !ok {
+			for _, legacyID := range p.LegacyIDs() {
+				if existing, ok = byID[legacyID]; ok {
+					seen[legacyID] = true
+					break
+				}
+			}
+		}
+
+		switch {
+		case !ok:
+			report.New = append(report.New, p)
+		case existing.Price != p.Price:
+			report.PriceChanges = append(report.PriceChanges, model.DryRunPriceChange{
+				Product:  p,
+				OldPrice: existing.Price,
+				NewPrice: p.Price,
+			})
+		default:
+			report.Unchanged++
+		}
+	}
+
+	for _, p := range stored {
+		if !seen[p.ID] && p.StockStatus != "sold_out" && scope[p.Region+"|"+p.Category] {
+			report.Disappeared = append(report.Disappeared, p)
+		}
+	}
+
+	sort.Slice(report.New, func(i, j int) bool { return report.New[i].ID < report.New[j].ID })
+	sort.Slice(report.PriceChanges, func(i, j int) bool {
+		return report.PriceChanges[i].Product.ID < report.PriceChanges[j].Product.ID
+	})
+	sort.Slice(report.Disappeared, func(i, j int) bool { return report.Disappeared[i].ID < report.Disappeared[j].ID })
+	return report
+}
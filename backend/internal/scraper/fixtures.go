@@ -0,0 +1,100 @@
+package scraper
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Client modes
+const (
+	ModeLive   = "live"   // fetch from Apple
+	ModeRecord = "record" // fetch from Apple and save every response as a fixture
+	ModeReplay = "replay" // serve saved fixtures only, never touching the network
+)
+
+// SetMode switches the client between live fetching, recording responses to
+// fixtures in dir, and replaying them from there for offline development and
+// deterministic runs of the whole scrape pipeline
+func (c *Client) SetMode(mode, dir string) error {
+	switch mode {
+	case "", ModeLive:
+		c.mode, c.fixtureDir = ModeLive, ""
+		return nil
+	case ModeRecord, ModeReplay:
+		if dir == "" {
+			return fmt.Errorf("%s mode needs a fixture directory", mode)
+		}
+		c.mode, c.fixtureDir = mode, dir
+		return nil
+	}
+	return fmt.Errorf("unknown scraper mode %q (expected live, record or replay)", mode)
+}
+
+// replaying reports whether responses come from fixtures
+func (c *Client) replaying() bool {
+	return c.mode == ModeReplay
+}
+
+// fixturePath returns where the response to rawURL is kept: one directory per
+// host, files named after the path plus a hash of the full URL, so query strings
+// get their own fixture and names stay readable
+func (c *Client) fixturePath(rawURL, ext string) string {
+	sum := sha256.Sum256([]byte(rawURL))
+	hash := hex.EncodeToString(sum[:4])
+
+	host, name := "unknown", "index"
+	if u, err := url.Parse(rawURL); err == nil {
+		if u.Host != "" {
+			host = u.Host
+		}
+		if p := strings.Trim(u.Path, "/"); p != "" {
+			name = p
+		}
+	}
+	name = strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '.' {
+			return r
+		}
+		return '_'
+	}, name)
+
+	return filepath.Join(c.fixtureDir, sanitizeHost(host), name+"-"+hash+ext)
+}
+
+func sanitizeHost(host string) string {
+	return strings.ReplaceAll(host, ":", "_")
+}
+
+// replay returns the recorded response to rawURL
+func (c *Client) replay(rawURL, ext string) ([]byte, error) {
+	data, err := os.ReadFile(c.fixturePath(rawURL, ext))
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("no fixture recorded for %s", rawURL)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read fixture: %w", err)
+	}
+	return data, nil
+}
+
+// record saves a live response as the fixture of rawURL when recording. Failures
+// are logged: the scrape itself goes on.
+func (c *Client) record(rawURL, ext string, data []byte) {
+	if c.mode != ModeRecord {
+		return
+	}
+	path := c.fixturePath(rawURL, ext)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		slog.Warn("Failed to record fixture", "url", rawURL, "error", err)
+		return
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		slog.Warn("Failed to record fixture", "url", rawURL, "error", err)
+	}
+}
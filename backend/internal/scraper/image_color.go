@@ -0,0 +1,102 @@
+package scraper
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+
+	// Decoders for product images
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+)
+
+const (
+	// colorSampleSide is the number of pixels sampled along each image axis
+	colorSampleSide = 64
+	// minForegroundShare is the share of sampled pixels that must be foreground
+	// before the background is ignored
+	minForegroundShare = 0.02
+)
+
+// colorBucket accumulates the pixels quantized into one palette cell
+type colorBucket struct {
+	count   int
+	r, g, b int
+}
+
+// DominantColor decodes a product image and returns its most common color as #rrggbb.
+// Apple shoots products on white, so transparent and near-white pixels are skipped
+// unless the image has hardly anything else.
+func DominantColor(data []byte) (string, error) {
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return "", fmt.Errorf("failed to decode image: %w", err)
+	}
+
+	bounds := img.Bounds()
+	if bounds.Empty() {
+		return "", fmt.Errorf("empty image")
+	}
+	stepX := max(bounds.Dx()/colorSampleSide, 1)
+	stepY := max(bounds.Dy()/colorSampleSide, 1)
+
+	foreground := make(map[int]*colorBucket)
+	background := make(map[int]*colorBucket)
+	samples, foregroundSamples := 0, 0
+
+	for y := bounds.Min.Y; y < bounds.Max.Y; y += stepY {
+		for x := bounds.Min.X; x < bounds.Max.X; x += stepX {
+			r16, g16, b16, a16 := img.At(x, y).RGBA()
+			if a16 < 0x8000 {
+				continue
+			}
+			// Un-premultiply and drop to 8 bits per channel
+			r, g, b := int(r16*0xffff/a16)>>8, int(g16*0xffff/a16)>>8, int(b16*0xffff/a16)>>8
+			samples++
+
+			buckets := background
+			if !isBackgroundColor(r, g, b) {
+				buckets = foreground
+				foregroundSamples++
+			}
+			// 4 bits per channel
+			key := (r>>4)<<8 | (g>>4)<<4 | b>>4
+			bucket, ok := buckets[key]
+			if !ok {
+				bucket = &colorBucket{}
+				buckets[key] = bucket
+			}
+			bucket.count++
+			bucket.r += r
+			bucket.g += g
+			bucket.b += b
+		}
+	}
+	if samples == 0 {
+		return "", fmt.Errorf("image is fully transparent")
+	}
+
+	buckets := foreground
+	if float64(foregroundSamples)/float64(samples) < minForegroundShare {
+		buckets = background
+	}
+
+	var top *colorBucket
+	topKey := 0
+	for key, bucket := range buckets {
+		if top == nil || bucket.count > top.count || (bucket.count == top.count && key < topKey) {
+			top, topKey = bucket, key
+		}
+	}
+	if top == nil {
+		return "", fmt.Errorf("no usable pixels")
+	}
+
+	return fmt.Sprintf("#%02x%02x%02x", top.r/top.count, top.g/top.count, top.b/top.count), nil
+}
+
+// isBackgroundColor reports whether a pixel is near-white studio background
+func isBackgroundColor(r, g, b int) bool {
+	return r >= 240 && g >= 240 && b >= 240
+}
@@ -1,13 +1,43 @@
 package scraper
 
 import (
+	"context"
+	"time"
+
 	"apple-price/internal/model"
 )
 
-// Scraper defines the interface for product scrapers
+// Scraper defines the interface for product scrapers. Scrapes stop early with
+// ctx's error once ctx is cancelled.
 type Scraper interface {
-	ScrapeAll() ([]*model.Product, error)
+	ScrapeAll(ctx context.Context) ([]*model.Product, error)
+}
+
+// RegionScraper is implemented by scrapers that report each region separately,
+// so the scheduler can record one region's failure without masking the others.
+// Only the regions and category pages within scope are scraped.
+type RegionScraper interface {
+	ScrapeRegions(ctx context.Context, scope model.ScrapeScope) []RegionResult
+}
+
+// RegionResult is the outcome of scraping one region
+type RegionResult struct {
+	Region   string
+	Products []*model.Product
+	Err      error
+	Duration time.Duration
+}
+
+// CategoryResult is the outcome of scraping one category page of a region
+type CategoryResult struct {
+	Region   string
+	Category string
+	Products int
+	Err      error
+	Duration time.Duration
 }
 
 // Ensure AppleScraper implements the interface
 var _ Scraper = (*AppleScraper)(nil)
+var _ RegionScraper = (*AppleScraper)(nil)
+var _ CategoryReporter = (*AppleScraper)(nil)
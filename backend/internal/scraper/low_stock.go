@@ -0,0 +1,59 @@
+package scraper
+
+import (
+	"log/slog"
+	"time"
+
+	"apple-price/internal/model"
+)
+
+// checkLowStock warns opted-in subscribers about watched products that just turned
+// limited, or whose listing has entered the window in which similar listings
+// historically sold out. lastCheck is when the previous scrape ran, so each
+// projection fires once as it crosses into the window.
+func (s *Scheduler) checkLowStock(products []*model.Product, previousStatus map[string]string, lastCheck, now time.Time) int {
+	watched := make(map[string][]*model.Subscription)
+	for _, sub := range s.store.GetAllSubscriptions() {
+		if sub.LowStockAlert {
+			watched[sub.ProductID] = append(watched[sub.ProductID], sub)
+		}
+	}
+	if len(watched) == 0 {
+		return 0
+	}
+
+	velocity := s.store.GetInventoryVelocity()
+
+	count := 0
+	for _, product := range products {
+		subs := watched[product.ID]
+		if len(subs) == 0 || product.StockStatus == "sold_out" {
+			continue
+		}
+
+		var reason *model.LowStockReason
+		if product.StockStatus == "limited" && previousStatus[product.ID] != "" && previousStatus[product.ID] != "limited" {
+			reason = &model.LowStockReason{Limited: true}
+		} else if v := velocity.Lookup(product); v != nil {
+			since := model.AvailableSince(s.store.GetProductEvents(product.ID))
+			if since.IsZero() {
+				continue
+			}
+			expected := v.ExpectedSellOut(since)
+			warnAt := expected.Add(-model.SellOutWarningWindow)
+			if warnAt.After(lastCheck) && !warnAt.After(now) && expected.After(now) {
+				reason = &model.LowStockReason{MedianHours: v.MedianHours, ExpectedAt: expected}
+			}
+		}
+		if reason == nil {
+			continue
+		}
+
+		count++
+		if err := s.notifier.NotifyLowStock(product, reason, subs); err != nil {
+			slog.Error("Failed to notify low stock", "product_id", product.ID, "error", err)
+		}
+	}
+
+	return count
+}
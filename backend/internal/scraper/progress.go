@@ -0,0 +1,132 @@
+package scraper
+
+import (
+	"sync"
+	"time"
+
+	"apple-price/internal/model"
+)
+
+const (
+	// progressBuffer is how many events a slow subscriber may lag behind before
+	// further events are dropped for it
+	progressBuffer = 64
+	// detailProgressInterval is how often detail queue progress is reported
+	detailProgressInterval = 2 * time.Second
+)
+
+// CategoryReporter is implemented by scrapers that can report each category page
+// as it is fetched
+type CategoryReporter interface {
+	SetCategoryHook(hook func(CategoryResult))
+}
+
+// progressHub fans scrape progress events out to subscribers
+type progressHub struct {
+	mu       sync.Mutex
+	subs     map[chan model.ScrapeProgress]struct{}
+	watching bool // a goroutine reports detail queue progress
+}
+
+// SubscribeProgress returns a channel receiving the progress events of scrape
+// cycles, and a function to unsubscribe. Events are dropped for subscribers that
+// fall too far behind.
+func (s *Scheduler) SubscribeProgress() (<-chan model.ScrapeProgress, func()) {
+	ch := make(chan model.ScrapeProgress, progressBuffer)
+
+	s.progress.mu.Lock()
+	if s.progress.subs == nil {
+		s.progress.subs = make(map[chan model.ScrapeProgress]struct{})
+	}
+	s.progress.subs[ch] = struct{}{}
+	s.progress.mu.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			s.progress.mu.Lock()
+			delete(s.progress.subs, ch)
+			s.progress.mu.Unlock()
+		})
+	}
+}
+
+// publish sends a progress event to every subscriber without blocking the scrape
+func (s *Scheduler) publish(event model.ScrapeProgress) {
+	event.Time = time.Now()
+	if event.Type != model.ScrapeEventDetail {
+		event.RunID = s.activeRunID()
+	}
+
+	s.progress.mu.Lock()
+	defer s.progress.mu.Unlock()
+	for ch := range s.progress.subs {
+		select {
+		case ch <- event:
+		default:
+		}
+	}
+}
+
+// onCategory records a fetched category page in the run in flight and reports it
+func (s *Scheduler) onCategory(result CategoryResult) {
+	s.runMu.Lock()
+	if s.active != nil {
+		s.active.noteCategory(result)
+	}
+	s.runMu.Unlock()
+
+	event := model.ScrapeProgress{
+		Type:     model.ScrapeEventCategory,
+		Region:   result.Region,
+		Category: result.Category,
+		Products: result.Products,
+		Duration: result.Duration.Milliseconds(),
+	}
+	if result.Err != nil {
+		event.Error = result.Err.Error()
+	}
+	s.publish(event)
+}
+
+// watchDetailQueue reports detail queue progress until the queue is drained. Only
+// one watcher runs at a time.
+func (s *Scheduler) watchDetailQueue() {
+	s.progress.mu.Lock()
+	if s.progress.watching || s.detailScraper == nil {
+		s.progress.mu.Unlock()
+		return
+	}
+	s.progress.watching = true
+	s.progress.mu.Unlock()
+
+	go func() {
+		defer func() {
+			s.progress.mu.Lock()
+			s.progress.watching = false
+			s.progress.mu.Unlock()
+		}()
+
+		ticker := time.NewTicker(detailProgressInterval)
+		defer ticker.Stop()
+		for {
+			stats := s.detailScraper.GetStats()
+			queue := s.detailScraper.GetQueueSize()
+			s.publish(model.ScrapeProgress{
+				Type:            model.ScrapeEventDetail,
+				DetailQueue:     queue,
+				DetailProcessed: stats.TotalProcessed,
+				DetailFailed:    stats.TotalFailed,
+			})
+			if queue == 0 {
+				return
+			}
+
+			select {
+			case <-ticker.C:
+			case <-s.stopCh:
+				return
+			}
+		}
+	}()
+}
@@ -0,0 +1,91 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"apple-price/internal/model"
+)
+
+// scrape runs one scrape of every region and records each region's status. With a
+// RegionScraper, regions that failed are returned in failed and only fail the
+// whole scrape when none succeeded. Only the regions and categories within scope
+// are scraped; a category scoped scrape, like a dry run or a cancelled scrape,
+// records no region status, and a cancelled one returns ctx's error.
+func (s *Scheduler) scrape(ctx context.Context, startTime time.Time, scope model.ScrapeScope, dryRun bool) (products []*model.Product, failed []string, err error) {
+	rs, ok := s.scraper.(RegionScraper)
+	if !ok {
+		if !scope.All() {
+			return nil, nil, errors.New("the scraper cannot scrape a single region or category")
+		}
+		products, err = s.scraper.ScrapeAll(ctx)
+		if ctx.Err() != nil {
+			return nil, nil, ctx.Err()
+		}
+		return products, nil, err
+	}
+
+	var errs []string
+	results := rs.ScrapeRegions(ctx, scope)
+	if err := ctx.Err(); err != nil {
+		return nil, nil, err
+	}
+	if len(results) == 0 && !scope.All() {
+		return nil, nil, fmt.Errorf("no enabled region sells %s", scopeName(scope))
+	}
+	for _, result := range results {
+		status := &model.ScraperStatus{
+			Region:           result.Region,
+			LastScrapeTime:   startTime,
+			LastScrapeStatus: "success",
+			ProductsScraped:  len(result.Products),
+			Duration:         result.Duration.Milliseconds(),
+		}
+		if result.Err != nil {
+			status.LastScrapeStatus = "failed"
+			status.LastScrapeError = result.Err.Error()
+			status.ProductsScraped = 0
+			failed = append(failed, result.Region)
+			errs = append(errs, result.Region+": "+result.Err.Error())
+		} else {
+			products = append(products, result.Products...)
+		}
+
+		if dryRun || scope.Category != "" {
+			continue
+		}
+		if err := s.store.UpdateRegionScraperStatus(status); err != nil {
+			slog.Error("Failed to record region scraper status", "region", result.Region, "error", err)
+		}
+		s.publish(model.ScrapeProgress{
+			Type:     model.ScrapeEventRegion,
+			Region:   result.Region,
+			Status:   status.LastScrapeStatus,
+			Error:    status.LastScrapeError,
+			Products: status.ProductsScraped,
+			Duration: status.Duration,
+		})
+	}
+
+	if len(results) > 0 && len(failed) == len(results) {
+		return nil, failed, fmt.Errorf("all regions failed: %s", strings.Join(errs, "; "))
+	}
+	return products, failed, nil
+}
+
+// scopeName describes a scrape scope for logs and errors
+func scopeName(scope model.ScrapeScope) string {
+	switch {
+	case scope.All():
+		return "everything"
+	case scope.Region == "":
+		return scope.Category
+	case scope.Category == "":
+		return "region " + scope.Region
+	}
+	return scope.Category + " in region " + scope.Region
+}
@@ -0,0 +1,240 @@
+package scraper
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"time"
+
+	"apple-price/internal/model"
+)
+
+// activeRun is a scrape run in flight or waiting to start
+type activeRun struct {
+	id        string
+	trigger   string
+	startedAt time.Time         // zero while queued
+	scope     model.ScrapeScope // zero for a full run
+	ctx       context.Context
+	cancel    context.CancelFunc
+
+	pages []model.ScrapeRunPage               // category pages fetched, guarded by Scheduler.runMu
+	diffs map[string]*model.ScrapeRunCategory // catalog changes by region|category, only touched by runScrape
+}
+
+func newActiveRun(trigger string) *activeRun {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &activeRun{
+		id:      model.NewScrapeRunID(),
+		trigger: trigger,
+		ctx:     ctx,
+		cancel:  cancel,
+		diffs:   make(map[string]*model.ScrapeRunCategory),
+	}
+}
+
+// noteCategory adds a fetched category page to the run. Caller must hold Scheduler.runMu.
+func (r *activeRun) noteCategory(result CategoryResult) {
+	page := model.ScrapeRunPage{
+		Region:   result.Region,
+		Page:     result.Category,
+		Products: result.Products,
+		Duration: result.Duration.Milliseconds(),
+	}
+	if result.Err != nil {
+		page.Error = result.Err.Error()
+	}
+	r.pages = append(r.pages, page)
+}
+
+// diff returns the change counters of p's region/category in this run
+func (r *activeRun) diff(p *model.Product) *model.ScrapeRunCategory {
+	key := p.Region + "|" + p.Category
+	c, ok := r.diffs[key]
+	if !ok {
+		c = &model.ScrapeRunCategory{Region: p.Region, Category: p.Category}
+		r.diffs[key] = c
+	}
+	return c
+}
+
+// record returns the run as reported by ActiveRuns
+func (r *activeRun) record() *model.ScrapeRun {
+	run := &model.ScrapeRun{ID: r.id, Trigger: r.trigger, Status: "running", StartedAt: r.startedAt, Scope: r.recordScope()}
+	if r.startedAt.IsZero() {
+		run.Status = "queued"
+	}
+	return run
+}
+
+// recordScope returns the run's scope as recorded, nil for a full run
+func (r *activeRun) recordScope() *model.ScrapeScope {
+	if r.scope.All() {
+		return nil
+	}
+	scope := r.scope
+	return &scope
+}
+
+// runScheduled runs a scheduled scrape cycle, skipping it while another run is
+// still in flight
+func (s *Scheduler) runScheduled() {
+	run := newActiveRun(model.ScrapeTriggerSchedule)
+	if !s.claimRun(run) {
+		run.cancel()
+		slog.Warn("Skipping scheduled scrape: another run is in flight", "run_id", s.activeRunID())
+		return
+	}
+	s.execute(run)
+}
+
+// StartScrape starts a manual scrape run in the background and returns its ID.
+// While another run is in flight it fails with model.ErrScrapeRunning and the ID of
+// that run, unless queue is set: then the run starts as soon as the current one
+// ends. Only one run waits at a time; queuing again returns the waiting run.
+// A non-zero scope limits the run to one region or category.
+func (s *Scheduler) StartScrape(queue bool, scope model.ScrapeScope) (id string, queued bool, err error) {
+	if s.storage != nil && s.storage.IsReadOnly() {
+		return "", false, fmt.Errorf("storage is in read-only mode")
+	}
+
+	s.runMu.Lock()
+	defer s.runMu.Unlock()
+
+	switch {
+	case s.active == nil:
+		run := newActiveRun(model.ScrapeTriggerManual)
+		run.scope = scope
+		run.startedAt = time.Now()
+		s.active = run
+		s.inFlight.Add(1)
+		go s.execute(run)
+		return run.id, false, nil
+	case !queue:
+		return s.active.id, false, model.ErrScrapeRunning
+	case s.queued == nil:
+		s.queued = newActiveRun(model.ScrapeTriggerManual)
+		s.queued.scope = scope
+		slog.Info("Scrape queued", "run_id", s.queued.id, "behind", s.active.id)
+	}
+	return s.queued.id, true, nil
+}
+
+// CancelScrape aborts the run in flight and drops the one queued behind it:
+// pending page fetches fail at once and nothing of the run is written. It
+// reports whether there was anything to cancel.
+func (s *Scheduler) CancelScrape() bool {
+	s.runMu.Lock()
+	defer s.runMu.Unlock()
+
+	cancelled := false
+	if s.queued != nil {
+		s.queued.cancel()
+		s.queued = nil
+		cancelled = true
+	}
+	if s.active != nil && s.active.ctx.Err() == nil {
+		s.active.cancel()
+		cancelled = true
+	}
+	return cancelled
+}
+
+// ActiveRuns returns the run in flight and the one queued behind it, if any
+func (s *Scheduler) ActiveRuns() []*model.ScrapeRun {
+	s.runMu.Lock()
+	defer s.runMu.Unlock()
+
+	runs := []*model.ScrapeRun{}
+	if s.active != nil {
+		runs = append(runs, s.active.record())
+	}
+	if s.queued != nil {
+		runs = append(runs, s.queued.record())
+	}
+	return runs
+}
+
+// claimRun makes run the one in flight; false if another run holds the slot
+func (s *Scheduler) claimRun(run *activeRun) bool {
+	s.runMu.Lock()
+	defer s.runMu.Unlock()
+
+	if s.active != nil {
+		return false
+	}
+	run.startedAt = time.Now()
+	s.active = run
+	s.inFlight.Add(1)
+	return true
+}
+
+// execute runs the claimed run, then the runs queued behind it
+func (s *Scheduler) execute(run *activeRun) {
+	defer s.inFlight.Done()
+
+	for run != nil {
+		s.runScrape(run)
+		run.cancel()
+		run = s.releaseRun()
+	}
+}
+
+// releaseRun ends the run in flight and hands the slot to the queued run, if any
+func (s *Scheduler) releaseRun() *activeRun {
+	s.runMu.Lock()
+	defer s.runMu.Unlock()
+
+	s.active, s.queued = s.queued, nil
+	if s.active != nil {
+		s.active.startedAt = time.Now()
+	}
+	return s.active
+}
+
+// activeRunID returns the ID of the run in flight, or "" when idle
+func (s *Scheduler) activeRunID() string {
+	s.runMu.Lock()
+	defer s.runMu.Unlock()
+
+	if s.active == nil {
+		return ""
+	}
+	return s.active.id
+}
+
+// runPages returns the category pages fetched by run, by region and page
+func (s *Scheduler) runPages(run *activeRun) []model.ScrapeRunPage {
+	s.runMu.Lock()
+	pages := append([]model.ScrapeRunPage{}, run.pages...)
+	s.runMu.Unlock()
+
+	sort.Slice(pages, func(i, j int) bool {
+		if pages[i].Region != pages[j].Region {
+			return pages[i].Region < pages[j].Region
+		}
+		return pages[i].Page < pages[j].Page
+	})
+	return pages
+}
+
+// recordFailedRun stores a run that ended without scraping anything
+func (s *Scheduler) recordFailedRun(run *activeRun, startTime time.Time, status string, err error) {
+	finished := time.Now()
+	record := &model.ScrapeRun{
+		ID:         run.id,
+		Trigger:    run.trigger,
+		Status:     status,
+		Error:      err.Error(),
+		StartedAt:  startTime,
+		FinishedAt: finished,
+		Duration:   finished.Sub(startTime).Milliseconds(),
+		Categories: []model.ScrapeRunCategory{},
+		Pages:      s.runPages(run),
+		Scope:      run.recordScope(),
+	}
+	if err := s.store.RecordScrapeRun(record); err != nil {
+		slog.Error("Failed to record scrape run", "run_id", run.id, "error", err)
+	}
+}
@@ -1,7 +1,13 @@
 package scraper
 
 import (
-	"log"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"apple-price/internal/model"
@@ -11,33 +17,112 @@ import (
 type Scheduler struct {
 	scraper       Scraper
 	detailScraper *DetailScraper
+	storage       StorageChecker
 	store         StoreInterface
 	notifier      PriceChangeNotifier
+	alerter       OperatorAlerter
 	interval      time.Duration
 	stopCh        chan struct{}
 	isRunning     bool
+
+	// Bounds of the interval adapted to the hours new arrivals appear at (zero = fixed interval)
+	minInterval time.Duration
+	maxInterval time.Duration
+
+	// Cron schedule replacing the interval (nil = interval), and the random delay
+	// of up to startJitter added to each scheduled cycle
+	cron        *model.CronSchedule
+	startJitter time.Duration
+
+	// When the next scheduled cycle starts, as Unix nanoseconds (0 = not scheduled)
+	nextScrape atomic.Int64
+
+	// Available count below which a category alerts the operator (0 = only when it hits zero)
+	categoryAlertThreshold int
+
+	// How often price history is downsampled (0 = never), and when it last was
+	compactionInterval time.Duration
+	lastCompaction     time.Time
+
+	// Subscribers to the live progress of scrape cycles
+	progress progressHub
+
+	// The scrape run in flight and the one waiting for it to end
+	runMu  sync.Mutex
+	active *activeRun
+	queued *activeRun
+
+	// Runs claimed and not yet ended, waited for on shutdown
+	inFlight sync.WaitGroup
+
+	// Set once the first scheduled scrape after Start has finished
+	ready atomic.Bool
 }
 
-// StoreInterface defines the store interface needed by scheduler
-// This allows both old JSON store and new SQLite store to work
-type StoreInterface interface {
+// ProductStore is the catalogue part of the store the scheduler writes scraped products to
+type ProductStore interface {
 	UpsertProduct(product *model.Product) (priceChanged bool, oldPrice float64)
+	UpsertProducts(products []*model.Product) ([]model.UpsertResult, error)
+	UpdateStockStatus(id, status string) error
+	MergeProduct(fromID, toID string) error
 	GetProduct(id string) (*model.Product, bool)
+	GetAllProducts() []*model.Product
+	GetProductEvents(productID string) []model.ProductEvent
+	GetInventoryVelocity() model.InventoryVelocityIndex
+}
+
+// SubscriptionStore is the subscription part of the store the scheduler matches products against
+type SubscriptionStore interface {
 	GetSubscriptionsByProduct(productID string) []*model.Subscription
+	GetAllSubscriptions() []*model.Subscription
 	GetAllNewArrivalSubscriptions() []*model.NewArrivalSubscription
 	UpdateNotifiedProductIDs(subscriptionID, productID string) error
+}
+
+// ScraperStateStore records when and how the scheduler last ran
+type ScraperStateStore interface {
 	UpdateLastScrapeTime(t time.Time)
 	GetLastScrapeTime() time.Time
-	Save() error
-	GetAllProducts() []*model.Product
 	GetScraperStatus() *model.ScraperStatus
 	UpdateScraperStatus(status *model.ScraperStatus) error
+	UpdateRegionScraperStatus(status *model.ScraperStatus) error
+	RecordScrapeRun(run *model.ScrapeRun) error
+	GetScrapeRuns(limit int) []*model.ScrapeRun
+	RecomputeValueScores(now time.Time) (*model.ScoreRecompute, error)
+	GetLastScoreRecompute() *model.ScoreRecompute
+	AddPendingDetail(item *model.PendingDetail) error
+	DeletePendingDetail(productID string) error
+	GetPendingDetails() []*model.PendingDetail
+}
+
+// StoreInterface defines the store interface needed by scheduler
+// This allows both old JSON store and new SQLite store to work
+type StoreInterface interface {
+	ProductStore
+	SubscriptionStore
+	ScraperStateStore
+	RecordDailyStats(now time.Time) error
+	EnforceRetention() (evictedHistory, evictedNotifications int, err error)
+	CompactPriceHistory(now time.Time) (compacted int, err error)
+	PurgeDeletedSubscriptions(before time.Time) (int, error)
+	Save() error
+}
+
+// StorageChecker reports whether the data directory can currently accept writes
+type StorageChecker interface {
+	IsReadOnly() bool
+	Check() model.StorageStatus
 }
 
 // PriceChangeNotifier interface for price change notifications
 type PriceChangeNotifier interface {
 	NotifyPriceChange(product *model.Product, oldPrice, newPrice float64, subscriptions []*model.Subscription) error
 	NotifyNewArrival(product *model.Product, subscriptions []*model.NewArrivalSubscription) error
+	NotifyStockChange(product *model.Product, oldStatus, newStatus string, subscriptions []*model.Subscription) error
+	NotifyRestock(product *model.Product, subscriptions []*model.Subscription, arrivalSubscriptions []*model.NewArrivalSubscription) error
+	FlushDigests(subscriptions []*model.NewArrivalSubscription) error
+	NotifyLowStock(product *model.Product, reason *model.LowStockReason, subscriptions []*model.Subscription) error
+	NotifyCatchUp(downtime time.Duration, changes []model.CatchUpChange, subscriptions []*model.Subscription, arrivalSubscriptions []*model.NewArrivalSubscription) error
 }
 
 // NewScheduler creates a new scheduler
@@ -47,13 +132,17 @@ func NewScheduler(
 	notifier PriceChangeNotifier,
 	interval time.Duration,
 ) *Scheduler {
-	return &Scheduler{
+	s := &Scheduler{
 		scraper:  scraper,
 		store:    store,
 		notifier: notifier,
 		interval: interval,
 		stopCh:   make(chan struct{}),
 	}
+	if reporter, ok := scraper.(CategoryReporter); ok {
+		reporter.SetCategoryHook(s.onCategory)
+	}
+	return s
 }
 
 // SetDetailScraper sets the detail scraper for async detail fetching
@@ -61,15 +150,40 @@ func (s *Scheduler) SetDetailScraper(ds *DetailScraper) {
 	s.detailScraper = ds
 }
 
+// RequeueDetail fetches a product's detail page again ahead of the rest of the
+// queue, failing with model.ErrDetailsUnavailable without a detail scraper
+func (s *Scheduler) RequeueDetail(productID string) error {
+	if s.detailScraper == nil {
+		return model.ErrDetailsUnavailable
+	}
+	return s.detailScraper.Requeue(productID)
+}
+
+// SetHistoryCompaction makes scrape cycles downsample old price history (see
+// model.RetentionPolicy.RawHistoryDays) at most once per interval
+func (s *Scheduler) SetHistoryCompaction(interval time.Duration) {
+	s.compactionInterval = interval
+}
+
+// SetStorageChecker sets the storage guard consulted before each scrape cycle
+func (s *Scheduler) SetStorageChecker(sc StorageChecker) {
+	s.storage = sc
+}
+
 // Start starts the scheduler
 func (s *Scheduler) Start() {
 	if s.isRunning {
-		log.Println("Scheduler already running")
+		slog.Warn("Scheduler already running")
 		return
 	}
 
 	s.isRunning = true
-	log.Printf("Scheduler started with interval: %v", s.interval)
+	if s.cron != nil {
+		slog.Info("Scheduler started", "cron", s.cron.String(), "start_jitter", s.startJitter)
+	} else {
+		slog.Info("Scheduler started", "interval", s.interval, "min_interval", s.minInterval, "max_interval", s.maxInterval,
+			"start_jitter", s.startJitter)
+	}
 
 	// Start detail scraper if available
 	if s.detailScraper != nil {
@@ -82,20 +196,26 @@ func (s *Scheduler) Start() {
 	}
 
 	// Run immediately on start
-	s.runScrape()
+	s.runScheduled()
+	s.ready.Store(true)
+
+	// Keep value scores current between price changes
+	go s.runScoreRecompute()
 
-	// Start ticker
+	// Each cycle waits the interval of the hour it ends in
 	go func() {
-		ticker := time.NewTicker(s.interval)
-		defer ticker.Stop()
+		timer := time.NewTimer(s.scheduleNext())
+		defer timer.Stop()
 
 		for {
 			select {
-			case <-ticker.C:
-				s.runScrape()
+			case <-timer.C:
+				s.runScheduled()
+				timer.Reset(s.scheduleNext())
 			case <-s.stopCh:
-				log.Println("Scheduler stopped")
+				slog.Info("Scheduler stopped")
 				s.isRunning = false
+				s.nextScrape.Store(0)
 
 				// Stop detail scraper
 				if s.detailScraper != nil {
@@ -108,10 +228,28 @@ func (s *Scheduler) Start() {
 	}()
 }
 
+// scheduleNext returns the wait before the next scheduled cycle and records when it starts
+func (s *Scheduler) scheduleNext() time.Duration {
+	now := time.Now()
+	wait := s.nextInterval(now)
+	if s.cron != nil {
+		if w, ok := s.cronWait(now); ok {
+			wait = w
+		} else {
+			slog.Warn("Cron schedule never fires, falling back to the interval", "cron", s.cron.String())
+		}
+	}
+	wait += s.jitter()
+	s.nextScrape.Store(now.Add(wait).UnixNano())
+	slog.Debug("Next scrape scheduled", "in", wait)
+	return wait
+}
+
 // Stop stops the scheduler
 func (s *Scheduler) Stop() {
 	if s.isRunning {
 		close(s.stopCh)
+		s.CancelScrape()
 
 		// Stop detail scraper
 		if s.detailScraper != nil {
@@ -120,74 +258,266 @@ func (s *Scheduler) Stop() {
 	}
 }
 
+// Shutdown stops the scheduler and the detail scraper, cancels the run in flight
+// and waits for it to end, or for ctx to be done
+func (s *Scheduler) Shutdown(ctx context.Context) error {
+	s.Stop()
+
+	done := make(chan struct{})
+	go func() {
+		s.inFlight.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("scrape run still in flight: %w", ctx.Err())
+	}
+}
+
+// Ready returns why the scheduler can't serve fresh data yet: it isn't running
+// or its first scrape hasn't finished. nil when it is ready.
+func (s *Scheduler) Ready() error {
+	if !s.isRunning {
+		return errors.New("scheduler is not running")
+	}
+	if !s.ready.Load() {
+		return errors.New("first scrape has not finished")
+	}
+	return nil
+}
+
 // IsRunning returns whether the scheduler is running
 func (s *Scheduler) IsRunning() bool {
 	return s.isRunning
 }
 
-// runScrape executes a single scrape cycle
-func (s *Scheduler) runScrape() {
+// runScrape executes a single scrape cycle as run
+func (s *Scheduler) runScrape(run *activeRun) {
+	// Don't scrape into a store that can't persist the results
+	if s.storage != nil && s.storage.IsReadOnly() {
+		slog.Warn("Skipping scrape cycle: storage is in read-only mode")
+		return
+	}
+
+	ctx := run.ctx
 	startTime := time.Now()
-	log.Println("Starting scrape cycle...")
+	slog.Info("Starting scrape cycle", "run_id", run.id, "trigger", run.trigger, "scope", scopeName(run.scope))
+
+	// Previous scrape time bounds the sell-out projections checked this cycle
+	lastCheck := s.store.GetLastScrapeTime()
+
+	// After downtime, everything that changed meanwhile goes out as one summary per Bark Key
+	var batch *catchUpBatch
+	if gap := s.downtime(lastCheck, startTime); gap > 0 && s.notifier != nil {
+		slog.Info("Downtime detected, running catch-up scrape", "downtime", gap.Round(time.Minute), "last_scrape", lastCheck)
+		batch = &catchUpBatch{downtime: gap}
+		// Sell-out windows crossed while offline are stale, only check the last interval
+		lastCheck = startTime.Add(-s.interval)
+	}
+	if lastCheck.IsZero() {
+		lastCheck = startTime.Add(-s.interval)
+	}
 
 	// Record running status
 	s.store.UpdateScraperStatus(&model.ScraperStatus{
 		LastScrapeTime:   startTime,
 		LastScrapeStatus: "running",
 	})
-
-	products, err := s.scraper.ScrapeAll()
+	s.publish(model.ScrapeProgress{Type: model.ScrapeEventStarted})
+
+	// Regions are scraped concurrently, a failing region doesn't stop the others
+	products, failedRegions, err := s.scrape(ctx, startTime, run.scope, false)
+	if err != nil && ctx.Err() != nil {
+		// Nothing is written from a cancelled scrape: partial results would mark
+		// everything not reached yet as sold out
+		slog.Warn("Scrape cancelled", "run_id", run.id, "after", time.Since(startTime).Round(time.Millisecond))
+		s.store.UpdateScraperStatus(&model.ScraperStatus{
+			LastScrapeTime:   startTime,
+			LastScrapeStatus: "cancelled",
+			LastScrapeError:  err.Error(),
+		})
+		s.recordFailedRun(run, startTime, "cancelled", err)
+		s.publish(model.ScrapeProgress{
+			Type:     model.ScrapeEventFinished,
+			Status:   "cancelled",
+			Duration: time.Since(startTime).Milliseconds(),
+		})
+		return
+	}
 	if err != nil {
-		log.Printf("Scrape error: %v", err)
+		slog.Error("Scrape failed", "error", err)
 		// Record failed status
 		s.store.UpdateScraperStatus(&model.ScraperStatus{
 			LastScrapeTime:   startTime,
 			LastScrapeStatus: "failed",
 			LastScrapeError:  err.Error(),
 		})
+		s.recordFailedRun(run, startTime, "failed", err)
+		s.publish(model.ScrapeProgress{
+			Type:     model.ScrapeEventFinished,
+			Status:   "failed",
+			Error:    err.Error(),
+			Duration: time.Since(startTime).Milliseconds(),
+		})
 		return
 	}
 
-	log.Printf("Scraped %d products", len(products))
+	slog.Info("Scraped products", "count", len(products), "failed_regions", failedRegions)
+
+	// Snapshot current stock statuses to detect restocks
+	previousStatus := make(map[string]string)
+	for _, p := range s.store.GetAllProducts() {
+		previousStatus[p.ID] = p.StockStatus
+	}
 
 	// Upsert all products and track price changes
 	priceChangeCount := 0
 	newProductCount := 0
+	restockCount := 0
 
 	for _, product := range products {
-		priceChanged, oldPrice := s.store.UpsertProduct(product)
+		s.adoptLegacyID(product, previousStatus)
+	}
+
+	// The whole cycle is written at once; SQLite commits it in one transaction
+	results, err := s.store.UpsertProducts(products)
+	if err != nil {
+		slog.Error("Failed to upsert products", "count", len(products), "error", err)
+		s.store.UpdateScraperStatus(&model.ScraperStatus{
+			LastScrapeTime:   startTime,
+			LastScrapeStatus: "failed",
+			LastScrapeError:  err.Error(),
+		})
+		s.recordFailedRun(run, startTime, "failed", err)
+		s.publish(model.ScrapeProgress{
+			Type:     model.ScrapeEventFinished,
+			Status:   "failed",
+			Error:    err.Error(),
+			Duration: time.Since(startTime).Milliseconds(),
+		})
+		return
+	}
+	// One event: nothing of the batch is visible before it commits
+	s.publish(model.ScrapeProgress{Type: model.ScrapeEventUpserted, Products: len(products), Total: len(products)})
+
+	for i, product := range products {
+		priceChanged, oldPrice := results[i].PriceChanged, results[i].OldPrice
+
+		// A previously sold out product showing up again is a restock
+		if previousStatus[product.ID] == "sold_out" && product.StockStatus != "sold_out" && s.notifier != nil {
+			restockCount++
+			slog.Info("Product back in stock", "product", product.Name, "category", product.Category)
+
+			if batch != nil {
+				batch.add(model.ChangeRestock, product, 0)
+			} else {
+				subscriptions := s.store.GetSubscriptionsByProduct(product.ID)
+				arrivalSubscriptions := s.store.GetAllNewArrivalSubscriptions()
+				if err := s.notifier.NotifyRestock(product, subscriptions, arrivalSubscriptions); err != nil {
+					slog.Error("Failed to notify restock", "product_id", product.ID, "error", err)
+				}
+			}
+		}
 
 		// Check if this is a new product (oldPrice == 0 and no price change)
 		isNewProduct := !priceChanged && oldPrice == 0
+		if isNewProduct {
+			run.diff(product).New++
+		} else if priceChanged {
+			run.diff(product).Changed++
+		}
 
 		if priceChanged && s.notifier != nil {
 			priceChangeCount++
-			log.Printf("Price changed for %s: %.2f -> %.2f", product.Name, oldPrice, product.Price)
+			slog.Info("Price changed", "product", product.Name, "old_price", oldPrice, "new_price", product.Price)
 
-			// Get subscriptions for this product
-			subscriptions := s.store.GetSubscriptionsByProduct(product.ID)
+			if batch != nil {
+				batch.add(model.ChangePrice, product, oldPrice)
+			} else {
+				// Get subscriptions for this product
+				subscriptions := s.store.GetSubscriptionsByProduct(product.ID)
 
-			// Notify subscribers
-			if err := s.notifier.NotifyPriceChange(product, oldPrice, product.Price, subscriptions); err != nil {
-				log.Printf("Failed to notify price change: %v", err)
+				// Notify subscribers
+				if err := s.notifier.NotifyPriceChange(product, oldPrice, product.Price, subscriptions); err != nil {
+					slog.Error("Failed to notify price change", "product_id", product.ID, "error", err)
+				}
 			}
 		}
 
 		// Notify new arrival subscribers for new products
 		if isNewProduct && s.notifier != nil {
 			newProductCount++
-			log.Printf("New product detected: %s (%s)", product.Name, product.Category)
+			slog.Info("New product detected", "product", product.Name, "category", product.Category)
 
-			// Get all new arrival subscriptions
-			arrivalSubscriptions := s.store.GetAllNewArrivalSubscriptions()
+			if batch != nil {
+				batch.add(model.ChangeNewArrival, product, 0)
+			} else {
+				// Get all new arrival subscriptions
+				arrivalSubscriptions := s.store.GetAllNewArrivalSubscriptions()
 
-			// Notify matching subscribers
-			if err := s.notifier.NotifyNewArrival(product, arrivalSubscriptions); err != nil {
-				log.Printf("Failed to notify new arrival: %v", err)
+				// Notify matching subscribers
+				if err := s.notifier.NotifyNewArrival(product, arrivalSubscriptions); err != nil {
+					slog.Error("Failed to notify new arrival", "product_id", product.ID, "error", err)
+				}
+
+				// Update notified_product_ids for subscriptions that matched
+				// This is done inside NotifyNewArrival via the dispatcher
 			}
+		}
+	}
 
-			// Update notified_product_ids for subscriptions that matched
-			// This is done inside NotifyNewArrival via the dispatcher
+	// Warn watchers of products likely to sell out soon
+	lowStockCount := 0
+	if s.notifier != nil {
+		lowStockCount = s.checkLowStock(products, previousStatus, lastCheck, time.Now())
+	}
+
+	// Send hourly/daily digests that are due
+	if s.notifier != nil {
+		if err := s.notifier.FlushDigests(s.store.GetAllNewArrivalSubscriptions()); err != nil {
+			slog.Error("Failed to flush digests", "error", err)
+		}
+	}
+
+	// Mark products that vanished from Apple's listings as sold out
+	soldOutCount := s.detectSoldOut(run, products, batch)
+
+	if batch != nil {
+		s.flushCatchUp(batch)
+	}
+
+	// Keep per category counts and warn the operator about categories that ran dry
+	s.recordScrapeRun(run, products, failedRegions, startTime)
+
+	// Roll today's per category/region aggregates into the stats timeline
+	if err := s.store.RecordDailyStats(time.Now()); err != nil {
+		slog.Error("Failed to record daily stats", "error", err)
+	}
+
+	// Evict history beyond the configured retention limits
+	if history, notifications, err := s.store.EnforceRetention(); err != nil {
+		slog.Error("Failed to enforce retention", "error", err)
+	} else if history > 0 || notifications > 0 {
+		slog.Info("Evicted old history", "price_history", history, "notification_history", notifications)
+	}
+
+	// Drop deleted subscriptions whose restore window has passed
+	if purged, err := s.store.PurgeDeletedSubscriptions(time.Now().Add(-model.SubscriptionRestoreWindow)); err != nil {
+		slog.Error("Failed to purge deleted subscriptions", "error", err)
+	} else if purged > 0 {
+		slog.Info("Purged deleted subscriptions", "count", purged)
+	}
+
+	// Downsample old price history to daily rows when due
+	if s.compactionInterval > 0 && time.Since(s.lastCompaction) >= s.compactionInterval {
+		s.lastCompaction = time.Now()
+		if compacted, err := s.store.CompactPriceHistory(time.Now()); err != nil {
+			slog.Error("Failed to compact price history", "error", err)
+		} else if compacted > 0 {
+			slog.Info("Compacted old price history", "points", compacted)
 		}
 	}
 
@@ -196,33 +526,130 @@ func (s *Scheduler) runScrape() {
 
 	// Save data to disk
 	if err := s.store.Save(); err != nil {
-		log.Printf("Failed to save data: %v", err)
+		slog.Error("Failed to save data", "error", err)
+		// Re-check storage so the API degrades to read-only right away
+		if s.storage != nil {
+			s.storage.Check()
+		}
 	}
 
 	// Enqueue products for async detail fetching
 	if s.detailScraper != nil {
 		queued := s.detailScraper.Enqueue(products)
 		if queued > 0 {
-			log.Printf("[Scheduler] Enqueued %d products for async detail fetching", queued)
+			slog.Info("Enqueued products for async detail fetching", "count", queued)
+			s.watchDetailQueue()
 		}
 	}
 
 	duration := time.Since(startTime)
-	log.Printf("Scrape cycle completed in %v. Products: %d, Price changes: %d, New products: %d",
-		duration, len(products), priceChangeCount, newProductCount)
+	slog.Info("Scrape cycle completed",
+		"duration", duration, "products", len(products), "price_changes", priceChangeCount,
+		"new_products", newProductCount, "restocked", restockCount, "sold_out", soldOutCount,
+		"low_stock", lowStockCount)
 
-	// Record success status
-	s.store.UpdateScraperStatus(&model.ScraperStatus{
+	// Record success status, partial when some regions failed
+	status := &model.ScraperStatus{
 		LastScrapeTime:   time.Now(),
 		LastScrapeStatus: "success",
 		ProductsScraped:  len(products),
 		Duration:         duration.Milliseconds(),
+	}
+	if len(failedRegions) > 0 {
+		status.LastScrapeStatus = "partial"
+		status.LastScrapeError = "failed regions: " + strings.Join(failedRegions, ", ")
+	}
+	s.store.UpdateScraperStatus(status)
+	s.publish(model.ScrapeProgress{
+		Type:     model.ScrapeEventFinished,
+		Status:   status.LastScrapeStatus,
+		Error:    status.LastScrapeError,
+		Products: len(products),
+		Duration: status.Duration,
 	})
 }
 
-// ScrapeNow triggers an immediate scrape
+// adoptLegacyID merges a listing stored under its title based ID from before IDs
+// came from part numbers into the product's ID, so it is updated rather than
+// announced as a new arrival. previousStatus (ID -> stock status) is kept in step.
+func (s *Scheduler) adoptLegacyID(product *model.Product, previousStatus map[string]string) {
+	if _, ok := previousStatus[product.ID]; ok {
+		return
+	}
+	for _, legacyID := range product.LegacyIDs() {
+		status, ok := previousStatus[legacyID]
+		if !ok || legacyID == product.ID {
+			continue
+		}
+		if err := s.store.MergeProduct(legacyID, product.ID); err != nil {
+			slog.Error("Failed to merge legacy product ID", "product_id", product.ID, "legacy_id", legacyID, "error", err)
+			continue
+		}
+		slog.Info("Merged product into its part number ID", "product_id", product.ID, "legacy_id", legacyID)
+		delete(previousStatus, legacyID)
+		if _, ok := previousStatus[product.ID]; !ok {
+			previousStatus[product.ID] = status
+		}
+	}
+}
+
+// detectSoldOut marks products missing from the latest scrape as sold out and notifies
+// their subscribers. Only region/category pairs present in this run are checked, so a
+// category page that failed to load doesn't mark its whole catalog as sold out.
+// During a catch-up run the changes are added to batch instead of notified. Removals
+// are counted in the run's catalog diff.
+func (s *Scheduler) detectSoldOut(run *activeRun, scraped []*model.Product, batch *catchUpBatch) int {
+	if len(scraped) == 0 {
+		return 0
+	}
+
+	seen := make(map[string]bool, len(scraped))
+	scope := make(map[string]bool)
+	for _, p := range scraped {
+		seen[p.ID] = true
+		scope[p.Region+"|"+p.Category] = true
+	}
+
+	count := 0
+	for _, p := range s.store.GetAllProducts() {
+		if seen[p.ID] || p.StockStatus == "sold_out" || !scope[p.Region+"|"+p.Category] {
+			continue
+		}
+
+		oldStatus := p.StockStatus
+		if err := s.store.UpdateStockStatus(p.ID, "sold_out"); err != nil {
+			slog.Error("Failed to mark product sold out", "product_id", p.ID, "error", err)
+			continue
+		}
+		p.StockStatus = "sold_out"
+		count++
+		run.diff(p).Removed++
+		slog.Info("Product no longer listed, marked sold out", "product", p.Name)
+
+		if batch != nil {
+			batch.add(model.ChangeSoldOut, p, 0)
+		} else if s.notifier != nil {
+			subscriptions := s.store.GetSubscriptionsByProduct(p.ID)
+			if err := s.notifier.NotifyStockChange(p, oldStatus, "sold_out", subscriptions); err != nil {
+				slog.Error("Failed to notify stock change", "product_id", p.ID, "error", err)
+			}
+		}
+	}
+
+	return count
+}
+
+// ScrapeNow runs a manual scrape and waits for it, failing with model.ErrScrapeRunning
+// while another run is in flight
 func (s *Scheduler) ScrapeNow() error {
-	s.runScrape()
+	if s.storage != nil && s.storage.IsReadOnly() {
+		return fmt.Errorf("storage is in read-only mode")
+	}
+	run := newActiveRun(model.ScrapeTriggerManual)
+	if !s.claimRun(run) {
+		return model.ErrScrapeRunning
+	}
+	s.execute(run)
 	return nil
 }
 
@@ -231,13 +658,30 @@ func (s *Scheduler) GetScrapeStatus() any {
 	status := &ScrapeStatus{
 		IsRunning:      s.isRunning,
 		Interval:       s.interval,
+		StartJitter:    s.startJitter,
 		LastScrapeTime: s.store.GetLastScrapeTime(),
 	}
+	if next := s.nextScrape.Load(); next > 0 {
+		t := time.Unix(0, next)
+		status.NextScrapeTime = &t
+	}
+	if s.cron != nil {
+		status.Cron = s.cron.String()
+	} else if s.adaptive() {
+		status.MinInterval = s.minInterval
+		status.MaxInterval = s.maxInterval
+		status.Schedule = s.schedule(time.Now())
+	}
+
+	if s.storage != nil {
+		status.StorageReadOnly = s.storage.IsReadOnly()
+	}
 
 	if s.detailScraper != nil {
 		stats := s.detailScraper.GetStats()
 		status.DetailStats = &stats
 		status.DetailQueueSize = s.detailScraper.GetQueueSize()
+		status.DetailFailures = s.detailScraper.Failures()
 	}
 
 	return status
@@ -245,9 +689,17 @@ func (s *Scheduler) GetScrapeStatus() any {
 
 // ScrapeStatus represents the scheduler status
 type ScrapeStatus struct {
-	IsRunning       bool          `json:"is_running"`
-	Interval        time.Duration `json:"interval"`
-	LastScrapeTime  time.Time     `json:"last_scrape_time"`
-	DetailStats     *DetailStats  `json:"detail_stats,omitempty"`
-	DetailQueueSize int          `json:"detail_queue_size,omitempty"`
+	IsRunning       bool                   `json:"is_running"`
+	Interval        time.Duration          `json:"interval"`
+	MinInterval     time.Duration          `json:"min_interval,omitempty"`
+	MaxInterval     time.Duration          `json:"max_interval,omitempty"`
+	Cron            string                 `json:"cron,omitempty"`
+	StartJitter     time.Duration          `json:"start_jitter,omitempty"`
+	LastScrapeTime  time.Time              `json:"last_scrape_time"`
+	NextScrapeTime  *time.Time             `json:"next_scrape_time,omitempty"`
+	StorageReadOnly bool                   `json:"storage_read_only,omitempty"`
+	DetailStats     *DetailStats           `json:"detail_stats,omitempty"`
+	DetailQueueSize int                    `json:"detail_queue_size,omitempty"`
+	DetailFailures  []*model.PendingDetail `json:"detail_failures,omitempty"` // products whose last detail fetch failed
+	Schedule        []ScheduleHour         `json:"schedule,omitempty"`        // interval by hour of the day when adaptive
 }
@@ -0,0 +1,56 @@
+package scraper
+
+import (
+	"log/slog"
+	"time"
+)
+
+const (
+	// scoreRecomputeInterval is how often value scores are recalculated for every
+	// product, so the age component doesn't go stale while prices hold still
+	scoreRecomputeInterval = 24 * time.Hour
+
+	// scoreRecomputeCheck is how often the scheduler checks whether a recomputation is due
+	scoreRecomputeCheck = time.Hour
+)
+
+// runScoreRecompute recalculates value scores and price statistics whenever the
+// last recomputation the store recorded is scoreRecomputeInterval old, until the
+// scheduler stops
+func (s *Scheduler) runScoreRecompute() {
+	ticker := time.NewTicker(scoreRecomputeCheck)
+	defer ticker.Stop()
+
+	for {
+		s.recomputeScoresIfDue(time.Now())
+
+		select {
+		case <-ticker.C:
+		case <-s.stopCh:
+			return
+		}
+	}
+}
+
+// recomputeScoresIfDue recalculates every product's value score when a day has
+// passed since the last run
+func (s *Scheduler) recomputeScoresIfDue(now time.Time) {
+	if last := s.store.GetLastScoreRecompute(); last != nil && now.Sub(last.RanAt) < scoreRecomputeInterval {
+		return
+	}
+	if s.storage != nil && s.storage.IsReadOnly() {
+		slog.Warn("Skipping value score recomputation: storage is in read-only mode")
+		return
+	}
+
+	recompute, err := s.store.RecomputeValueScores(now)
+	if err != nil {
+		slog.Error("Failed to recompute value scores", "error", err)
+		return
+	}
+	slog.Info("Recomputed value scores", "products", recompute.Products, "duration_ms", recompute.Duration)
+
+	if err := s.store.Save(); err != nil {
+		slog.Error("Failed to save data", "error", err)
+	}
+}
@@ -23,6 +23,7 @@ type ParsedSpecs struct {
 	StandType    string `json:"stand_type"`
 	CaseSize     string `json:"case_size"`
 	BandType     string `json:"band_type"`
+	Network      string `json:"network"` // iPhone carrier lock: 无锁版 or the carrier
 }
 
 // ParseProductSpecs extracts detailed specs from product name/title
@@ -66,6 +67,16 @@ func ParseProductSpecs(name string) ParsedSpecs {
 		specs.BandType = parseWatchBand(name)
 	}
 
+	// iPhone titles carry the generation, their own color names and the carrier lock
+	if strings.Contains(lowerName, "iphone") {
+		specs.Model = parseIPhoneModel(name)
+		specs.Storage = parseIPhoneStorage(name)
+		if color := parseIPhoneColor(name, lowerName); color != "" {
+			specs.Color = color
+		}
+		specs.Network = parseIPhoneNetwork(name, lowerName)
+	}
+
 	return specs
 }
 
@@ -114,6 +125,9 @@ func (p ParsedSpecs) ToMap() map[string]interface{} {
 	if p.BandType != "" {
 		result["band_type"] = p.BandType
 	}
+	if p.Network != "" {
+		result["network"] = p.Network
+	}
 	return result
 }
 
@@ -421,6 +435,104 @@ func parseWatchBand(name string) string {
 	return ""
 }
 
+var (
+	iPhoneModelPattern   = regexp.MustCompile(`(?i)iPhone\s*(\d{1,2}|SE|XS|XR|X)(?:\s*[（(]?\s*(?:第\s*)?(\d)(?:st|nd|rd|th)?\s*(?:代|generation)\s*[）)]?)?(?:\s+(Pro\s*Max|Pro|Plus|mini|Max))?`)
+	iPhoneStoragePattern = regexp.MustCompile(`(?i)\b(64|128|256|512)\s*GB|\b([12])\s*TB`)
+)
+
+// parseIPhoneModel extracts the iPhone generation and variant, e.g. "iPhone 14 Pro Max"
+// or "iPhone SE (第 3 代)"
+func parseIPhoneModel(name string) string {
+	match := iPhoneModelPattern.FindStringSubmatch(name)
+	if match == nil {
+		return "iPhone"
+	}
+
+	label := "iPhone " + strings.ToUpper(match[1])
+	if match[2] != "" {
+		label += " (第 " + match[2] + " 代)"
+	}
+	if match[3] != "" {
+		variant := strings.Join(strings.Fields(match[3]), " ")
+		switch strings.ToLower(variant) {
+		case "pro max":
+			variant = "Pro Max"
+		case "pro":
+			variant = "Pro"
+		case "plus":
+			variant = "Plus"
+		case "mini":
+			variant = "mini"
+		case "max":
+			variant = "Max"
+		}
+		label += " " + variant
+	}
+	return label
+}
+
+// parseIPhoneStorage extracts iPhone storage, which titles give without a "存储" label
+func parseIPhoneStorage(name string) string {
+	match := iPhoneStoragePattern.FindStringSubmatch(name)
+	if match == nil {
+		return ""
+	}
+	if match[1] != "" {
+		return match[1] + "GB"
+	}
+	return match[2] + "TB"
+}
+
+// iPhoneColors are the iPhone finish names, longest first so "Deep Purple" isn't
+// read as plain purple
+var iPhoneColors = []struct {
+	names []string
+	label string
+}{
+	{[]string{"原色钛金属", "natural titanium"}, "原色钛金属"},
+	{[]string{"蓝色钛金属", "blue titanium"}, "蓝色钛金属"},
+	{[]string{"白色钛金属", "white titanium"}, "白色钛金属"},
+	{[]string{"黑色钛金属", "black titanium"}, "黑色钛金属"},
+	{[]string{"沙漠色钛金属", "desert titanium"}, "沙漠色钛金属"},
+	{[]string{"暗紫色", "deep purple"}, "暗紫色"},
+	{[]string{"远峰蓝色", "sierra blue"}, "远峰蓝色"},
+	{[]string{"苍岭绿色", "alpine green"}, "苍岭绿色"},
+	{[]string{"海蓝色", "pacific blue"}, "海蓝色"},
+	{[]string{"石墨色", "graphite"}, "石墨色"},
+	{[]string{"深空黑色", "space black"}, "深空黑色"},
+	{[]string{"(product)red", "product red"}, "红色"},
+}
+
+// parseIPhoneColor extracts an iPhone-only color name; others are left to parseColor
+func parseIPhoneColor(name, lowerName string) string {
+	for _, c := range iPhoneColors {
+		for _, n := range c.names {
+			if strings.Contains(name, n) || strings.Contains(lowerName, n) {
+				return c.label
+			}
+		}
+	}
+	return ""
+}
+
+// iPhoneCarriers are the carriers refurbished iPhones come locked to
+var iPhoneCarriers = []string{"AT&T", "Verizon", "T-Mobile", "Boost Mobile", "Visible", "Cricket"}
+
+// parseIPhoneNetwork extracts whether an iPhone is unlocked or tied to a carrier
+func parseIPhoneNetwork(name, lowerName string) string {
+	for _, unlocked := range []string{"unlocked", "sim-free", "sim free", "simフリー", "ohne simlock", "无锁", "無鎖"} {
+		if strings.Contains(lowerName, unlocked) {
+			return "无锁版"
+		}
+	}
+	for _, carrier := range iPhoneCarriers {
+		if strings.Contains(lowerName, strings.ToLower(carrier)) {
+			return carrier
+		}
+	}
+	return ""
+}
+
 func parseSingleInt(s string) int {
 	i, _ := strconv.Atoi(s)
 	return i
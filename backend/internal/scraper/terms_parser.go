@@ -0,0 +1,87 @@
+package scraper
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"apple-price/internal/model"
+)
+
+// RefurbTerms are the grade, warranty and battery claims listed on a detail page
+type RefurbTerms struct {
+	Grade          string
+	WarrantyMonths int
+	BatteryHealth  int // claimed minimum battery capacity, %; 100 for a new battery
+}
+
+var (
+	htmlTagPattern = regexp.MustCompile(`<[^>]*>`)
+
+	certifiedPattern   = regexp.MustCompile(`(?i)(Apple\s*(认证|認證)翻新|Certified Refurbished)`)
+	letterGradePattern = regexp.MustCompile(`(?i)(?:\bgrade\s*([A-C])\b|(?:^|[^A-Za-z])([A-C])\s*(?:级|級)(?:成色|品相)?)`)
+
+	warrantyYearsPattern  = regexp.MustCompile(`(\d+|一|二|两|兩|三)\s*年(?:有限)?(?:保修|保養|保固)`)
+	warrantyMonthsPattern = regexp.MustCompile(`(\d+)\s*个?(?:月|個月)(?:有限)?(?:保修|保養|保固)`)
+	warrantyEnPattern     = regexp.MustCompile(`(?i)(\d+|one|two|three)[-\s](year|month)\s+(?:limited\s+)?warranty`)
+
+	newBatteryPattern     = regexp.MustCompile(`(?i)(全新电池|全新電池|new battery)`)
+	batteryPercentPattern = regexp.MustCompile(`(?i)(?:电池|電池|battery)[^%<]{0,40}?(\d{2,3})\s*%`)
+)
+
+// ParseRefurbTerms extracts refurbishment grade, warranty length and battery health
+// claims from detail page HTML
+func ParseRefurbTerms(html string) RefurbTerms {
+	text := htmlTagPattern.ReplaceAllString(html, " ")
+	terms := RefurbTerms{}
+
+	if m := letterGradePattern.FindStringSubmatch(text); m != nil {
+		terms.Grade = strings.ToUpper(m[1] + m[2])
+	} else if certifiedPattern.MatchString(text) {
+		terms.Grade = model.GradeCertified
+	}
+
+	terms.WarrantyMonths = parseWarrantyMonths(text)
+
+	if newBatteryPattern.MatchString(text) {
+		terms.BatteryHealth = 100
+	} else if m := batteryPercentPattern.FindStringSubmatch(text); m != nil {
+		if pct, err := strconv.Atoi(m[1]); err == nil && pct <= 100 {
+			terms.BatteryHealth = pct
+		}
+	}
+
+	return terms
+}
+
+// parseWarrantyMonths returns the advertised warranty length in months (0 if not found)
+func parseWarrantyMonths(text string) int {
+	if m := warrantyYearsPattern.FindStringSubmatch(text); m != nil {
+		return countWord(m[1]) * 12
+	}
+	if m := warrantyMonthsPattern.FindStringSubmatch(text); m != nil {
+		return countWord(m[1])
+	}
+	if m := warrantyEnPattern.FindStringSubmatch(text); m != nil {
+		n := countWord(strings.ToLower(m[1]))
+		if strings.EqualFold(m[2], "year") {
+			return n * 12
+		}
+		return n
+	}
+	return 0
+}
+
+// countWord converts a small count written as digits, Chinese or English to an int
+func countWord(s string) int {
+	switch s {
+	case "一", "one":
+		return 1
+	case "二", "两", "兩", "two":
+		return 2
+	case "三", "three":
+		return 3
+	}
+	n, _ := strconv.Atoi(s)
+	return n
+}
@@ -0,0 +1,111 @@
+package store
+
+import (
+	"sync"
+
+	"apple-price/internal/model"
+)
+
+// CachedStore is a read-through cache in front of another store, typically the
+// SQLiteStore. The product catalog and category list are kept in memory and
+// reloaded on the first read after any product change (ProductsVersion moved);
+// every other call goes straight to the wrapped store.
+type CachedStore struct {
+	StoreInterface
+
+	mu         sync.Mutex
+	loaded     bool
+	version    uint64                    // ProductsVersion the cache was loaded at
+	products   []*model.Product          // in GetAllProducts order
+	byID       map[string]*model.Product // ID -> product
+	categories []string
+}
+
+// NewCachedStore wraps inner with the read-through product cache
+func NewCachedStore(inner StoreInterface) *CachedStore {
+	return &CachedStore{StoreInterface: inner}
+}
+
+var _ StoreInterface = (*CachedStore)(nil)
+
+// catalog returns the cached products and categories, reloading them when the
+// wrapped store's products changed since the last load
+func (c *CachedStore) catalog() ([]*model.Product, map[string]*model.Product, []string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// Read the version before loading: a change landing mid-load bumps it again,
+	// so the next read reloads instead of keeping a half-stale snapshot
+	version := c.StoreInterface.ProductsVersion()
+	if !c.loaded || version != c.version {
+		c.products = c.StoreInterface.GetAllProducts()
+		c.byID = make(map[string]*model.Product, len(c.products))
+		for _, p := range c.products {
+			c.byID[p.ID] = p
+		}
+		c.categories = c.StoreInterface.GetCategories()
+		c.version = version
+		c.loaded = true
+	}
+
+	return c.products, c.byID, c.categories
+}
+
+// cloneProduct copies a cached product, so callers may modify what they get
+// (e.g. fill in computed fields) without touching the cache
+func cloneProduct(p *model.Product) *model.Product {
+	clone := *p
+	return &clone
+}
+
+// filterProducts copies the cached products that match keep
+func filterProducts(products []*model.Product, keep func(*model.Product) bool) []*model.Product {
+	result := make([]*model.Product, 0, len(products))
+	for _, p := range products {
+		if keep(p) {
+			result = append(result, cloneProduct(p))
+		}
+	}
+	return result
+}
+
+// GetAllProducts returns all products from the cache
+func (c *CachedStore) GetAllProducts() []*model.Product {
+	products, _, _ := c.catalog()
+	return filterProducts(products, func(*model.Product) bool { return true })
+}
+
+// GetProduct returns a product by ID from the cache. Archived products are not
+// cached, so misses are looked up in the wrapped store.
+func (c *CachedStore) GetProduct(id string) (*model.Product, bool) {
+	_, byID, _ := c.catalog()
+	p, ok := byID[id]
+	if !ok {
+		return c.StoreInterface.GetProduct(id)
+	}
+	return cloneProduct(p), true
+}
+
+// GetProductsByCategory returns the cached products of a category
+func (c *CachedStore) GetProductsByCategory(category string) []*model.Product {
+	products, _, _ := c.catalog()
+	return filterProducts(products, func(p *model.Product) bool { return p.Category == category })
+}
+
+// GetProductsByRegion returns the cached products of a region
+func (c *CachedStore) GetProductsByRegion(region string) []*model.Product {
+	products, _, _ := c.catalog()
+	return filterProducts(products, func(p *model.Product) bool { return p.Region == region })
+}
+
+// GetCategories returns the cached category list
+func (c *CachedStore) GetCategories() []string {
+	_, _, categories := c.catalog()
+	return append([]string(nil), categories...)
+}
+
+// ValidateAPIToken forwards to the wrapped store's API tokens, if it has any
+func (c *CachedStore) ValidateAPIToken(token string) bool {
+	validator, ok := c.StoreInterface.(interface{ ValidateAPIToken(string) bool })
+	return ok && validator.ValidateAPIToken(token)
+}
@@ -0,0 +1,126 @@
+package store
+
+import (
+	"log/slog"
+
+	"apple-price/internal/model"
+)
+
+// migrateAccessoryCategoriesLocked refiles products scraped while AirPods, HomePod,
+// Apple TV and displays were all Accessory, and extends new arrival subscriptions
+// to Accessory with the split categories so they keep matching the same products.
+// It only runs while such products are left. Caller must hold s.mu.
+func (s *Store) migrateAccessoryCategoriesLocked() {
+	refiled := 0
+	for _, p := range s.products {
+		if category := p.RefiledCategory(); category != p.Category {
+			p.Category = category
+			refiled++
+		}
+	}
+	if refiled == 0 {
+		return
+	}
+
+	subscriptions := 0
+	for _, sub := range s.newArrivalSubscriptions {
+		if categories := withSplitAccessoryCategories(sub.Categories); len(categories) != len(sub.Categories) {
+			sub.Categories = categories
+			subscriptions++
+		}
+	}
+
+	slog.Info("Refiled accessory products into their own categories", "products", refiled, "subscriptions", subscriptions)
+}
+
+// migrateAccessoryCategories is migrateAccessoryCategoriesLocked for the database
+func (s *SQLiteStore) migrateAccessoryCategories() error {
+	rows, err := s.db.Query("SELECT id, name, category FROM products")
+	if err != nil {
+		return err
+	}
+	refiled := make(map[string]string) // product ID -> new category
+	for rows.Next() {
+		var p model.Product
+		if err := rows.Scan(&p.ID, &p.Name, &p.Category); err != nil {
+			rows.Close()
+			return err
+		}
+		if category := p.RefiledCategory(); category != p.Category {
+			refiled[p.ID] = category
+		}
+	}
+	rows.Close()
+	if len(refiled) == 0 {
+		return nil
+	}
+
+	rows, err = s.db.Query(`
+		SELECT subscription_id, MAX(position) FROM subscription_filters
+		WHERE field = ? GROUP BY subscription_id
+		HAVING SUM(value = 'Accessory') > 0
+	`, filterCategory)
+	if err != nil {
+		return err
+	}
+	lastPosition := make(map[string]int) // subscription ID -> last category position
+	for rows.Next() {
+		var id string
+		var position int
+		if err := rows.Scan(&id, &position); err != nil {
+			rows.Close()
+			return err
+		}
+		lastPosition[id] = position
+	}
+	rows.Close()
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for id, category := range refiled {
+		if _, err := tx.Exec("UPDATE products SET category = ? WHERE id = ?", category, id); err != nil {
+			return err
+		}
+	}
+	for id, position := range lastPosition {
+		for i, category := range model.SplitAccessoryCategories {
+			if _, err := tx.Exec(`
+				INSERT OR IGNORE INTO subscription_filters (subscription_id, field, value, position)
+				VALUES (?, ?, ?, ?)
+			`, id, filterCategory, category, position+1+i); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	slog.Info("Refiled accessory products into their own categories", "products", len(refiled), "subscriptions", len(lastPosition))
+	return nil
+}
+
+// withSplitAccessoryCategories returns categories with the split accessory
+// categories added when it includes Accessory
+func withSplitAccessoryCategories(categories []string) []string {
+	has := make(map[string]bool, len(categories))
+	for _, c := range categories {
+		has[c] = true
+	}
+	if !has["Accessory"] {
+		return categories
+	}
+
+	extended := append([]string(nil), categories...)
+	for _, c := range model.SplitAccessoryCategories {
+		if !has[c] {
+			extended = append(extended, c)
+		}
+	}
+	return extended
+}
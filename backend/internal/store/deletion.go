@@ -0,0 +1,27 @@
+package store
+
+import (
+	"strconv"
+	"time"
+
+	"apple-price/internal/model"
+)
+
+// RegionDeletionGracePeriod is how long a region deletion can be undone
+const RegionDeletionGracePeriod = 72 * time.Hour
+
+// newRegionDeletion stamps a deletion summary as a pending, undoable deletion
+func newRegionDeletion(d *model.RegionDeletion, now time.Time) *model.RegionDeletion {
+	d.ID = "del-" + strconv.FormatInt(now.UnixNano(), 36)
+	d.DeletedAt = now
+	d.ExpiresAt = now.Add(RegionDeletionGracePeriod)
+	return d
+}
+
+// pendingDeletion pairs a deletion record with the products it archived, which
+// undo restores. Deleted products are archived, not removed, so they keep their
+// history, subscriptions and events and stay listed for replicas.
+type pendingDeletion struct {
+	Deletion   *model.RegionDeletion `json:"deletion"`
+	ProductIDs []string              `json:"product_ids"`
+}
@@ -0,0 +1,8 @@
+//go:build !unix
+
+package store
+
+// freeSpace is not supported on this platform; only writability is checked
+func freeSpace(dir string) (uint64, bool) {
+	return 0, false
+}
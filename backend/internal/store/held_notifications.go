@@ -0,0 +1,135 @@
+package store
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"apple-price/internal/model"
+)
+
+// HoldNotification stores a push until its quiet window ends, replacing the one
+// held under the same ID
+func (s *Store) HoldNotification(n *model.HeldNotification) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	held := *n
+	s.heldNotifications[held.ID] = &held
+	return nil
+}
+
+// GetDueHeldNotifications returns up to limit held pushes whose quiet window
+// ended at now, earliest first
+func (s *Store) GetDueHeldNotifications(now time.Time, limit int) []*model.HeldNotification {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	due := []*model.HeldNotification{}
+	for _, n := range s.heldNotifications {
+		if !n.SendAt.After(now) {
+			held := *n
+			due = append(due, &held)
+		}
+	}
+	sort.Slice(due, func(i, j int) bool { return due[i].SendAt.Before(due[j].SendAt) })
+	if len(due) > limit {
+		due = due[:limit]
+	}
+	return due
+}
+
+// DeleteHeldNotification removes a held push once it was sent or dropped
+func (s *Store) DeleteHeldNotification(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.heldNotifications, id)
+	return nil
+}
+
+// CountHeldNotifications returns how many pushes wait for quiet hours to end
+func (s *Store) CountHeldNotifications() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return len(s.heldNotifications)
+}
+
+// HoldNotification is Store.HoldNotification for the database
+func (s *SQLiteStore) HoldNotification(n *model.HeldNotification) error {
+	product, err := json.Marshal(n.Product)
+	if err != nil {
+		return fmt.Errorf("failed to marshal held product: %w", err)
+	}
+	counted, err := json.Marshal(n.Counted)
+	if err != nil {
+		return fmt.Errorf("failed to marshal counted subscriptions: %w", err)
+	}
+	notified, err := json.Marshal(n.Notified)
+	if err != nil {
+		return fmt.Errorf("failed to marshal notified products: %w", err)
+	}
+
+	if _, err := s.db.Exec(`
+		INSERT OR REPLACE INTO held_notifications (id, subscription_id, product_id, notification_type, bark_key,
+			bark_server, title, body, url, icon, sound, msg_group, product, counted, notified, send_at, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, n.ID, n.SubscriptionID, n.ProductID, n.NotificationType, n.BarkKey,
+		n.BarkServer, n.Title, n.Body, n.URL, n.Icon, n.Sound, n.Group, string(product), string(counted), string(notified),
+		n.SendAt.Unix(), n.CreatedAt.Unix()); err != nil {
+		return fmt.Errorf("failed to hold notification: %w", err)
+	}
+	return nil
+}
+
+// GetDueHeldNotifications is Store.GetDueHeldNotifications for the database
+func (s *SQLiteStore) GetDueHeldNotifications(now time.Time, limit int) []*model.HeldNotification {
+	rows, err := s.db.Query(`
+		SELECT id, subscription_id, product_id, notification_type, bark_key, bark_server,
+			title, body, url, icon, sound, msg_group, product, counted, notified, send_at, created_at
+		FROM held_notifications
+		WHERE send_at <= ?
+		ORDER BY send_at ASC
+		LIMIT ?
+	`, now.Unix(), limit)
+	if err != nil {
+		return []*model.HeldNotification{}
+	}
+	defer rows.Close()
+
+	due := []*model.HeldNotification{}
+	for rows.Next() {
+		n := &model.HeldNotification{}
+		var product, counted, notified string
+		var sendAt, created int64
+		err := rows.Scan(&n.ID, &n.SubscriptionID, &n.ProductID, &n.NotificationType, &n.BarkKey, &n.BarkServer,
+			&n.Title, &n.Body, &n.URL, &n.Icon, &n.Sound, &n.Group, &product, &counted, &notified, &sendAt, &created)
+		if err != nil {
+			continue
+		}
+		_ = json.Unmarshal([]byte(product), &n.Product)
+		_ = json.Unmarshal([]byte(counted), &n.Counted)
+		_ = json.Unmarshal([]byte(notified), &n.Notified)
+		n.SendAt = time.Unix(sendAt, 0)
+		n.CreatedAt = time.Unix(created, 0)
+		due = append(due, n)
+	}
+	return due
+}
+
+// DeleteHeldNotification is Store.DeleteHeldNotification for the database
+func (s *SQLiteStore) DeleteHeldNotification(id string) error {
+	if _, err := s.db.Exec("DELETE FROM held_notifications WHERE id = ?", id); err != nil {
+		return fmt.Errorf("failed to delete held notification: %w", err)
+	}
+	return nil
+}
+
+// CountHeldNotifications is Store.CountHeldNotifications for the database
+func (s *SQLiteStore) CountHeldNotifications() int {
+	var count int
+	_ = s.db.QueryRow("SELECT COUNT(*) FROM held_notifications").Scan(&count)
+	return count
+}
@@ -6,26 +6,81 @@ import (
 	"apple-price/internal/model"
 )
 
-// StoreInterface defines the complete interface for product storage
-// Both JSON Store and SQLite Store implement this interface
-type StoreInterface interface {
+// ProductStore holds the product catalogue: products, their history and events,
+// chart annotations and the region registry
+type ProductStore interface {
 	// Product operations
 	GetAllProducts() []*model.Product
 	GetProduct(id string) (*model.Product, bool)
 	GetProductsByCategory(category string) []*model.Product
 	GetProductsByRegion(region string) []*model.Product
+	FindProducts(filter model.ProductFilter) []*model.Product
+	GetFilterOptions(category string) model.FilterOptions
 	UpsertProduct(product *model.Product) (priceChanged bool, oldPrice float64)
+	UpsertProducts(products []*model.Product) ([]model.UpsertResult, error)
+	UpdateStockStatus(id, status string) error
+	MergeProduct(fromID, toID string) error
+	ProductsVersion() uint64
+
+	// Manual corrections; pinned fields are reapplied over every later upsert
+	EditProduct(id string, fields map[string]string, pin bool) (*model.Product, error)
+	GetProductOverride(id string) (*model.ProductOverride, bool)
+
+	// Product lifecycle events (listings and stock transitions)
+	GetProductEvents(productID string) []model.ProductEvent
+	GetInventoryVelocity() model.InventoryVelocityIndex
 
 	// Price history operations
 	GetPriceHistory(productID string) []model.PriceHistory
+	GetPricesAt(t time.Time) map[string]float64
+	GetPriceStats(productID string) (*model.PriceStats, bool)
+	GetScoreBreakdown(productID string) (*model.ScoreBreakdown, bool)
+
+	// Archived products are hidden from the listings above but keep their history;
+	// GetProduct still finds them
+	ArchiveProductsByRegion(region string) (int, error)
+	RestoreProducts(ids []string) (int, error)
+	GetArchivedProducts(region string) []*model.Product
+
+	// Mirror mode: catalog changes pulled from an upstream instance
+	ApplyReplication(batch *model.ReplicationBatch) error
+	// History and events of every product recorded in (since, until], for GET /api/replicate
+	GetPriceHistoryBetween(since, until time.Time) []model.PriceHistory
+	GetProductEventsBetween(since, until time.Time) []model.ProductEvent
+
+	// New prices of products at third-party retailers, replaced per product
+	GetRetailerPrices(productID string) []model.RetailerPrice
+	SetRetailerPrices(productID string, prices []model.RetailerPrice) error
+
+	// Price chart annotations
+	AddAnnotation(annotation *model.PriceAnnotation) error
+	DeleteAnnotation(id string) error
+	GetAnnotations(from, to string) []model.PriceAnnotation
 
 	// Category operations
 	GetCategories() []string
 
+	// Per-category default product sort; an empty sortBy removes the default
+	GetCategoryDefaultSorts() map[string]string
+	SetCategoryDefaultSort(category, sortBy string) error
+
+	// Region registry
+	GetRegions() []*model.Region
+	GetRegion(code string) (*model.Region, bool)
+	UpsertRegion(region *model.Region) error
+	DeleteRegion(code string) error
+}
+
+// SubscriptionStore holds price and new arrival subscriptions and per-user preferences
+type SubscriptionStore interface {
 	// Subscription operations
 	AddSubscription(sub *model.Subscription) error
 	RemoveSubscription(id string) error
+	UpdateSubscription(sub *model.Subscription) error
+	SetSubscriptionPaused(id string, paused bool) error
+	GetSubscription(id string) (*model.Subscription, bool)
 	GetSubscriptionsByProduct(productID string) []*model.Subscription
+	GetSubscriptionsByBarkKey(barkKey string) []*model.Subscription
 	GetAllSubscriptions() []*model.Subscription
 
 	// New arrival subscription operations
@@ -39,19 +94,68 @@ type StoreInterface interface {
 	PauseSubscription(id string) error
 	ResumeSubscription(id string) error
 	IncrementNotificationCount(id string) error
+	MigrateBarkKey(oldKey, newKey string) (*model.KeyMigrationResult, error)
+
+	// Deleted new arrival subscriptions can be restored for model.SubscriptionRestoreWindow
+	GetDeletedNewArrivalSubscription(id string) (*model.NewArrivalSubscription, bool)
+	RestoreNewArrivalSubscription(id string) (*model.NewArrivalSubscription, error)
+	PurgeDeletedSubscriptions(before time.Time) (int, error)
+
+	// Per-user preferences
+	GetPreferences(barkKey string) *model.UserPreferences
+	SetPreferences(prefs *model.UserPreferences) error
+	SetAllPaused(barkKey string, paused bool) (int, error)
+
+	// Consecutive push failures per Bark Key; broken keys get no more pushes
+	GetDeliveryHealth(barkKey string) *model.DeliveryHealth
+	SetDeliveryHealth(h *model.DeliveryHealth) error
+
+	// Users (API-key accounts over Bark Keys)
+	AddUser(user *model.User) error
+	UpdateUser(user *model.User) error
+	GetUserByBarkKey(barkKey string) (*model.User, bool)
+	GetUserByAPIKeyHash(hash string) (*model.User, bool)
+
+	// Watchlists (tracking without push notifications)
+	AddWatchlist(watchlist *model.Watchlist) error
+	GetWatchlists(clientToken string) []*model.Watchlist
+	GetWatchlist(id string) (*model.Watchlist, bool)
+	DeleteWatchlist(id string) error
+
+	// Saved searches (shareable product list filters)
+	AddSavedSearch(search *model.SavedSearch) error
+	GetSavedSearch(slug string) (*model.SavedSearch, bool)
+}
+
+// NotificationStore holds sent notifications and buffered digest items
+type NotificationStore interface {
+	// Digest buffering for hourly/daily new arrival subscriptions
+	AddPendingNotification(item *model.PendingNotification) error
+	GetPendingNotifications(subscriptionID string) []model.PendingNotification
+	ClearPendingNotifications(subscriptionID string) error
 
 	// Notification history operations
 	AddNotificationHistory(history *model.NotificationHistory) error
 	GetNotificationHistory(subscriptionID string, barkKey string, limit, offset int) ([]*model.NotificationHistory, int)
 	MarkNotificationAsRead(id string) error
 	GetUnreadNotificationCount() int
+	UpdateNotificationStatus(id, status, errorMessage string, latencyMs *int64) error
 
-	// Statistics operations
-	GetStats() *model.Stats
+	// Persistent queue of failed pushes awaiting retry
+	AddNotificationRetry(retry *model.NotificationRetry) error
+	GetDueNotificationRetries(now time.Time, limit int) []*model.NotificationRetry
+	UpdateNotificationRetry(retry *model.NotificationRetry) error
+	DeleteNotificationRetry(id string) error
 
-	// Admin operations
-	DeleteProductsByRegion(region string) (int, error)
+	// Pushes held until their subscriber's quiet hours end
+	HoldNotification(n *model.HeldNotification) error
+	GetDueHeldNotifications(now time.Time, limit int) []*model.HeldNotification
+	DeleteHeldNotification(id string) error
+	CountHeldNotifications() int
+}
 
+// ScraperStateStore holds what the scheduler records about its own runs
+type ScraperStateStore interface {
 	// Scraping metadata operations
 	UpdateLastScrapeTime(t time.Time)
 	GetLastScrapeTime() time.Time
@@ -59,7 +163,60 @@ type StoreInterface interface {
 	// Scraper status operations
 	GetScraperStatus() *model.ScraperStatus
 	UpdateScraperStatus(status *model.ScraperStatus) error
+	GetRegionScraperStatuses() []*model.ScraperStatus
+	UpdateRegionScraperStatus(status *model.ScraperStatus) error
+
+	// Scrape runs with the per category counts they saw
+	RecordScrapeRun(run *model.ScrapeRun) error
+	GetScrapeRuns(limit int) []*model.ScrapeRun
+	GetScrapeRun(id string) (*model.ScrapeRun, bool)
+
+	// Periodic value score recomputation
+	RecomputeValueScores(now time.Time) (*model.ScoreRecompute, error)
+	GetLastScoreRecompute() *model.ScoreRecompute
+
+	// Products waiting for their detail page, replaced per product
+	AddPendingDetail(item *model.PendingDetail) error
+	DeletePendingDetail(productID string) error
+	GetPendingDetails() []*model.PendingDetail
+}
+
+// StoreInterface defines the complete interface for product storage
+// Both JSON Store and SQLite Store implement this interface
+type StoreInterface interface {
+	ProductStore
+	SubscriptionStore
+	NotificationStore
+	ScraperStateStore
+
+	// Statistics operations
+	GetStats() *model.Stats
+	RecordDailyStats(now time.Time) error
+	GetStatsTimeline(category, region string, since time.Time) []model.DailyCategoryStats
+	GetStatsHistory(region string, since time.Time) []model.StatsSnapshot
+	GetPriceIndexTimeline(region string, since time.Time) []model.DailyPriceIndex
+
+	// Retention
+	SetRetentionPolicy(policy model.RetentionPolicy)
+	EnforceRetention() (evictedHistory, evictedNotifications int, err error)
+	CompactPriceHistory(now time.Time) (compacted int, err error)
+
+	// Admin operations
+	// Region and stale deletions archive the products; they can be undone for
+	// RegionDeletionGracePeriod
+	PreviewRegionDeletion(region string) *model.RegionDeletion
+	DeleteProductsByRegion(region string) (*model.RegionDeletion, error)
+	PreviewStaleDeletion(before time.Time) *model.RegionDeletion
+	DeleteStaleProducts(before time.Time) (*model.RegionDeletion, error)
+	UndoRegionDeletion(id string) (*model.RegionDeletion, error)
+	GetRegionDeletions() []*model.RegionDeletion
 
 	// Persistence
 	Save() error
 }
+
+// Both backends satisfy the full interface
+var (
+	_ StoreInterface = (*Store)(nil)
+	_ StoreInterface = (*SQLiteStore)(nil)
+)
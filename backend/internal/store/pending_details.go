@@ -0,0 +1,102 @@
+package store
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"time"
+
+	"apple-price/internal/model"
+)
+
+// AddPendingDetail queues a product for a detail fetch, replacing its entry if it
+// is already queued
+func (s *Store) AddPendingDetail(item *model.PendingDetail) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	d := *item
+	if item.NextAttemptAt != nil {
+		next := *item.NextAttemptAt
+		d.NextAttemptAt = &next
+	}
+	s.pendingDetails[d.ProductID] = &d
+	return nil
+}
+
+// DeletePendingDetail drops a product from the detail queue once it was fetched or given up
+func (s *Store) DeletePendingDetail(productID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.pendingDetails, productID)
+	return nil
+}
+
+// GetPendingDetails returns the detail queue, next to fetch first
+func (s *Store) GetPendingDetails() []*model.PendingDetail {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	pending := make([]*model.PendingDetail, 0, len(s.pendingDetails))
+	for _, d := range s.pendingDetails {
+		item := *d
+		pending = append(pending, &item)
+	}
+	sort.Slice(pending, func(i, j int) bool { return pending[i].Before(pending[j]) })
+	return pending
+}
+
+// AddPendingDetail is Store.AddPendingDetail for the database
+func (s *SQLiteStore) AddPendingDetail(item *model.PendingDetail) error {
+	var nextAttempt any
+	if item.NextAttemptAt != nil {
+		nextAttempt = item.NextAttemptAt.Unix()
+	}
+	if _, err := s.db.Exec(`
+		INSERT OR REPLACE INTO pending_details (product_id, priority, queued_at, attempts, last_error, next_attempt_at, given_up)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`, item.ProductID, item.Priority, item.QueuedAt.Unix(), item.Attempts, item.LastError, nextAttempt, item.GivenUp); err != nil {
+		return fmt.Errorf("failed to queue detail fetch: %w", err)
+	}
+	return nil
+}
+
+// DeletePendingDetail is Store.DeletePendingDetail for the database
+func (s *SQLiteStore) DeletePendingDetail(productID string) error {
+	if _, err := s.db.Exec("DELETE FROM pending_details WHERE product_id = ?", productID); err != nil {
+		return fmt.Errorf("failed to delete pending detail: %w", err)
+	}
+	return nil
+}
+
+// GetPendingDetails is Store.GetPendingDetails for the database
+func (s *SQLiteStore) GetPendingDetails() []*model.PendingDetail {
+	rows, err := s.db.Query(`
+		SELECT product_id, priority, queued_at, COALESCE(attempts, 0), COALESCE(last_error, ''), next_attempt_at,
+			COALESCE(given_up, 0)
+		FROM pending_details
+		ORDER BY priority DESC, queued_at ASC
+	`)
+	if err != nil {
+		return []*model.PendingDetail{}
+	}
+	defer rows.Close()
+
+	pending := []*model.PendingDetail{}
+	for rows.Next() {
+		var d model.PendingDetail
+		var queued int64
+		var nextAttempt sql.NullInt64
+		if err := rows.Scan(&d.ProductID, &d.Priority, &queued, &d.Attempts, &d.LastError, &nextAttempt, &d.GivenUp); err != nil {
+			continue
+		}
+		d.QueuedAt = time.Unix(queued, 0)
+		if nextAttempt.Valid {
+			t := time.Unix(nextAttempt.Int64, 0)
+			d.NextAttemptAt = &t
+		}
+		pending = append(pending, &d)
+	}
+	return pending
+}
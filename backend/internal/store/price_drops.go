@@ -0,0 +1,56 @@
+package store
+
+import "time"
+
+// GetPricesAt returns the price each product had at t, keyed by product ID.
+// Only products whose price changed after t are included; the others still
+// have their current price.
+func (s *Store) GetPricesAt(t time.Time) map[string]float64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	prices := make(map[string]float64)
+	for id, history := range s.history {
+		// History entries hold the price a product had until the change at their timestamp
+		for _, entry := range history {
+			if entry.Timestamp.After(t) {
+				prices[id] = entry.Price
+				break
+			}
+		}
+	}
+	return prices
+}
+
+// GetPricesAt returns the price each product had at t, keyed by product ID.
+// Only products whose price changed after t are included; the others still
+// have their current price.
+func (s *SQLiteStore) GetPricesAt(t time.Time) map[string]float64 {
+	rows, err := s.db.Query(`
+		SELECT product_id, price FROM (
+			SELECT product_id, price, ROW_NUMBER() OVER (PARTITION BY product_id ORDER BY recorded_at, seq) AS n
+			FROM (
+				SELECT product_id, close_price AS price, close_at AS recorded_at, 0 AS seq
+				FROM price_history_daily WHERE close_at > ?
+				UNION ALL
+				SELECT product_id, price, recorded_at, id FROM price_history WHERE recorded_at > ?
+			)
+		)
+		WHERE n = 1
+	`, t.Unix(), t.Unix())
+	if err != nil {
+		return map[string]float64{}
+	}
+	defer rows.Close()
+
+	prices := make(map[string]float64)
+	for rows.Next() {
+		var id string
+		var price float64
+		if err := rows.Scan(&id, &price); err != nil {
+			continue
+		}
+		prices[id] = price
+	}
+	return prices
+}
@@ -0,0 +1,218 @@
+package store
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"apple-price/internal/match"
+	"apple-price/internal/model"
+)
+
+// FindProducts returns the listed products matching filter
+func (s *Store) FindProducts(filter model.ProductFilter) []*model.Product {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	criteria := match.FromFilter(filter)
+	var products []*model.Product
+	for _, p := range s.products {
+		if !p.Archived && criteria.Matches(p) {
+			products = append(products, p)
+		}
+	}
+	return products
+}
+
+// GetFilterOptions returns the distinct spec values of the listed products,
+// of one category or all when category is empty
+func (s *Store) GetFilterOptions(category string) model.FilterOptions {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var products []*model.Product
+	for _, p := range s.products {
+		if !p.Archived && (category == "" || p.Category == category) {
+			products = append(products, p)
+		}
+	}
+	return model.CollectFilterOptions(products)
+}
+
+// FindProducts returns the listed products matching filter. Specs are matched on
+// the spec_* columns written with each product (see writeSpecIndex), so no
+// SpecsDetail is parsed per query.
+func (s *SQLiteStore) FindProducts(filter model.ProductFilter) []*model.Product {
+	where := []string{"COALESCE(archived, 0) = 0"}
+	var args []any
+	if filter.Category != "" {
+		where = append(where, "category = ?")
+		args = append(args, filter.Category)
+	}
+	if filter.Region != "" {
+		where = append(where, "region = ?")
+		args = append(args, filter.Region)
+	}
+	for _, spec := range []struct {
+		column string
+		values []string
+	}{
+		{"spec_chip", filter.Chips},
+		{"spec_memory", filter.Memories},
+		{"spec_storage", filter.Storages},
+		{"spec_model", filter.Models},
+		{"spec_screen_size", filter.ScreenSizes},
+		{"spec_color", filter.Colors},
+	} {
+		if len(spec.values) == 0 {
+			continue
+		}
+		placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(spec.values)), ", ")
+		where = append(where, fmt.Sprintf("%s COLLATE NOCASE IN (%s)", spec.column, placeholders))
+		for _, v := range spec.values {
+			args = append(args, v)
+		}
+	}
+	if filter.MinPrice > 0 {
+		where = append(where, "price >= ?")
+		args = append(args, filter.MinPrice)
+	}
+	if filter.MaxPrice > 0 {
+		where = append(where, "price <= ?")
+		args = append(args, filter.MaxPrice)
+	}
+
+	rows, err := s.db.Query(`
+		SELECT id, name, category, region, price, original_price, discount,
+		       image_url, product_url, specs, specs_detail, description, stock_status, grade, warranty_months, battery_health, dominant_color, part_number, value_score,
+		       lowest_price, highest_price, price_trend, volatility, drop_streak, stability, created_at, updated_at
+		FROM products WHERE `+strings.Join(where, " AND ")+`
+		ORDER BY updated_at DESC
+	`, args...)
+	if err != nil {
+		return []*model.Product{}
+	}
+	defer rows.Close()
+
+	products := []*model.Product{}
+	for rows.Next() {
+		p := &model.Product{}
+		var created, updated int64
+		var lowest, highest, volatility sql.NullFloat64
+		var trend, specsDetail, description, grade, stability, dominantColor, partNumber sql.NullString
+		var warrantyMonths, batteryHealth, dropStreak sql.NullInt64
+
+		if err := rows.Scan(
+			&p.ID, &p.Name, &p.Category, &p.Region, &p.Price, &p.OriginalPrice,
+			&p.Discount, &p.ImageURL, &p.ProductURL, &p.Specs, &specsDetail, &description, &p.StockStatus,
+			&grade, &warrantyMonths, &batteryHealth, &dominantColor, &partNumber, &p.ValueScore, &lowest, &highest, &trend, &volatility, &dropStreak, &stability, &created, &updated,
+		); err != nil {
+			continue
+		}
+
+		p.SpecsDetail = specsDetail.String
+		p.Description = description.String
+		p.LowestPrice = lowest.Float64
+		p.HighestPrice = highest.Float64
+		p.PriceTrend = trend.String
+		p.Grade = grade.String
+		p.WarrantyMonths = int(warrantyMonths.Int64)
+		p.BatteryHealth = int(batteryHealth.Int64)
+		p.DominantColor = dominantColor.String
+		p.PartNumber = partNumber.String
+		p.Volatility = volatility.Float64
+		p.DropStreak = int(dropStreak.Int64)
+		p.Stability = stability.String
+		p.CreatedAt = time.Unix(created, 0)
+		p.UpdatedAt = time.Unix(updated, 0)
+		products = append(products, p)
+	}
+
+	return products
+}
+
+// GetFilterOptions returns the distinct spec values of the listed products,
+// of one category or all when category is empty
+func (s *SQLiteStore) GetFilterOptions(category string) model.FilterOptions {
+	var opts model.FilterOptions
+	for column, dst := range map[string]*[]string{
+		"spec_chip":        &opts.Chips,
+		"spec_storage":     &opts.Storages,
+		"spec_memory":      &opts.Memories,
+		"spec_screen_size": &opts.ScreenSizes,
+		"spec_color":       &opts.Colors,
+		"spec_model":       &opts.Models,
+	} {
+		*dst = s.distinctSpecValues(column, category)
+	}
+	return opts
+}
+
+// distinctSpecValues returns the distinct non-empty values of a spec_* column
+func (s *SQLiteStore) distinctSpecValues(column, category string) []string {
+	values := []string{}
+	rows, err := s.queryPrepared(`
+		SELECT DISTINCT `+column+` FROM products
+		WHERE COALESCE(archived, 0) = 0 AND (? = '' OR category = ?) AND `+column+` != ''
+	`, category, category)
+	if err != nil {
+		return values
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var v string
+		if err := rows.Scan(&v); err == nil {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+// writeSpecIndex stores the specs a product is filtered by in its spec_* columns.
+// Called wherever a product row is written with new name, category or specs.
+func writeSpecIndex(db execer, p *model.Product) error {
+	specs := p.SpecIndex()
+	_, err := db.Exec(`
+		UPDATE products SET spec_chip = ?, spec_memory = ?, spec_storage = ?, spec_screen_size = ?, spec_color = ?, spec_model = ?
+		WHERE id = ?
+	`, specs.Chip, specs.Memory, specs.Storage, specs.ScreenSize, specs.Color, specs.Model, p.ID)
+	return err
+}
+
+// migrateSpecIndex fills the spec_* columns of products written before they
+// existed (still NULL)
+func (s *SQLiteStore) migrateSpecIndex() error {
+	rows, err := s.db.Query("SELECT id, name, category, specs_detail FROM products WHERE spec_model IS NULL OR spec_color IS NULL")
+	if err != nil {
+		return err
+	}
+	var products []*model.Product
+	for rows.Next() {
+		p := &model.Product{}
+		var specsDetail sql.NullString
+		if err := rows.Scan(&p.ID, &p.Name, &p.Category, &specsDetail); err != nil {
+			rows.Close()
+			return err
+		}
+		p.SpecsDetail = specsDetail.String
+		products = append(products, p)
+	}
+	rows.Close()
+	if len(products) == 0 {
+		return nil
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	for _, p := range products {
+		if err := writeSpecIndex(tx, p); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
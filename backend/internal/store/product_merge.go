@@ -0,0 +1,344 @@
+package store
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+
+	"apple-price/internal/model"
+)
+
+// productTables are the tables holding per-product records, keyed by product_id
+var productTables = []string{
+	"price_history",
+	"price_history_daily",
+	"product_events",
+	"subscriptions",
+	"subscription_notified_products",
+	"watchlist_items",
+	"pending_notifications",
+	"retailer_prices",
+	"notification_history",
+	"notification_retries",
+	"held_notifications",
+	"pending_details",
+	"product_overrides",
+}
+
+// MergeProduct moves a product's history, events, subscriptions and other records
+// from fromID to toID and removes fromID. When toID already exists it keeps its
+// own listing data and the earlier of the two creation times; otherwise fromID
+// is renamed.
+func (s *Store) MergeProduct(fromID, toID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	defer s.productsVersion.Add(1)
+
+	return s.mergeProductLocked(fromID, toID)
+}
+
+// mergeProductLocked is MergeProduct. Caller must hold s.mu.
+func (s *Store) mergeProductLocked(fromID, toID string) error {
+	from, ok := s.products[fromID]
+	if !ok {
+		return fmt.Errorf("product %s not found", fromID)
+	}
+	if fromID == toID {
+		return nil
+	}
+
+	if to, ok := s.products[toID]; ok {
+		if from.CreatedAt.Before(to.CreatedAt) {
+			to.CreatedAt = from.CreatedAt
+		}
+		if to.PartNumber == "" {
+			to.PartNumber = from.PartNumber
+		}
+	} else {
+		from.ID = toID
+		s.products[toID] = from
+		s.prevPrices[toID] = s.prevPrices[fromID]
+	}
+	delete(s.products, fromID)
+	delete(s.prevPrices, fromID)
+
+	if history := s.history[fromID]; len(history) > 0 {
+		merged := append(s.history[toID], history...)
+		for i := range merged {
+			merged[i].ProductID = toID
+		}
+		sort.SliceStable(merged, func(i, j int) bool { return merged[i].Timestamp.Before(merged[j].Timestamp) })
+		s.history[toID] = merged
+	}
+	delete(s.history, fromID)
+
+	for _, subID := range s.subscriptionsByProduct[fromID] {
+		if sub, ok := s.subscriptions[subID]; ok {
+			sub.ProductID = toID
+		}
+		s.subscriptionsByProduct[toID] = append(s.subscriptionsByProduct[toID], subID)
+	}
+	delete(s.subscriptionsByProduct, fromID)
+
+	for i := range s.productEvents {
+		if s.productEvents[i].ProductID == fromID {
+			s.productEvents[i].ProductID = toID
+		}
+	}
+
+	if prices, ok := s.retailerPrices[fromID]; ok {
+		if _, exists := s.retailerPrices[toID]; !exists {
+			s.retailerPrices[toID] = prices
+		}
+		delete(s.retailerPrices, fromID)
+	}
+
+	for _, w := range s.watchlists {
+		w.ProductIDs = renameID(w.ProductIDs, fromID, toID)
+	}
+
+	for _, sub := range s.newArrivalSubscriptions {
+		if !strings.Contains(sub.NotifiedProductIDs, fromID) {
+			continue
+		}
+		var ids []string
+		if err := json.Unmarshal([]byte(sub.NotifiedProductIDs), &ids); err != nil {
+			continue
+		}
+		data, _ := json.Marshal(renameID(ids, fromID, toID))
+		sub.NotifiedProductIDs = string(data)
+	}
+
+	for subID, items := range s.pendingNotifications {
+		kept := items[:0]
+		seen := make(map[string]bool, len(items))
+		for _, item := range items {
+			if item.ProductID == fromID {
+				item.ProductID = toID
+			}
+			if !seen[item.ProductID] {
+				seen[item.ProductID] = true
+				kept = append(kept, item)
+			}
+		}
+		s.pendingNotifications[subID] = kept
+	}
+
+	if o, ok := s.productOverrides[fromID]; ok {
+		if _, exists := s.productOverrides[toID]; !exists {
+			o.ProductID = toID
+			s.productOverrides[toID] = o
+		}
+		delete(s.productOverrides, fromID)
+	}
+
+	if d, ok := s.pendingDetails[fromID]; ok {
+		if _, exists := s.pendingDetails[toID]; !exists {
+			d.ProductID = toID
+			s.pendingDetails[toID] = d
+		}
+		delete(s.pendingDetails, fromID)
+	}
+
+	for _, h := range s.notificationHistory {
+		if h.ProductID == fromID {
+			h.ProductID = toID
+		}
+	}
+	for _, r := range s.notificationRetries {
+		if r.ProductID == fromID {
+			r.ProductID = toID
+		}
+	}
+	for _, n := range s.heldNotifications {
+		if n.ProductID == fromID {
+			n.ProductID = toID
+		}
+	}
+
+	return nil
+}
+
+// renameID replaces fromID with toID in ids, keeping each ID once
+func renameID(ids []string, fromID, toID string) []string {
+	renamed := ids[:0]
+	seen := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		if id == fromID {
+			id = toID
+		}
+		if !seen[id] {
+			seen[id] = true
+			renamed = append(renamed, id)
+		}
+	}
+	return renamed
+}
+
+// migrateStableIDsLocked moves products scraped while IDs hashed the part number
+// together with the title to their part number ID, merging duplicates created
+// by title changes. Caller must hold s.mu.
+func (s *Store) migrateStableIDsLocked() {
+	moves := make(map[string]string) // old ID -> stable ID
+	for id, p := range s.products {
+		if stable := p.StableID(); stable != id {
+			moves[id] = stable
+		}
+	}
+
+	merged := 0
+	for fromID, toID := range moves {
+		if err := s.mergeProductLocked(fromID, toID); err != nil {
+			slog.Error("Failed to move product to its stable ID", "product_id", fromID, "error", err)
+			continue
+		}
+		merged++
+	}
+	if merged > 0 {
+		s.productsVersion.Add(1)
+		slog.Info("Moved products to part number IDs", "products", merged)
+	}
+}
+
+// MergeProduct is Store.MergeProduct for the database
+func (s *SQLiteStore) MergeProduct(fromID, toID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	defer s.productsVersion.Add(1)
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := mergeProductTx(tx, fromID, toID); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit merge: %w", err)
+	}
+	return nil
+}
+
+// mergeProductTx merges fromID into toID within tx
+func mergeProductTx(tx *sql.Tx, fromID, toID string) error {
+	var created int64
+	var partNumber sql.NullString
+	err := tx.QueryRow("SELECT created_at, part_number FROM products WHERE id = ?", fromID).Scan(&created, &partNumber)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("product %s not found", fromID)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read product %s: %w", fromID, err)
+	}
+	if fromID == toID {
+		return nil
+	}
+
+	var exists int
+	_ = tx.QueryRow("SELECT COUNT(*) FROM products WHERE id = ?", toID).Scan(&exists)
+	if exists > 0 {
+		if _, err := tx.Exec(`
+			UPDATE products SET created_at = MIN(created_at, ?), part_number = COALESCE(NULLIF(part_number, ''), ?)
+			WHERE id = ?
+		`, created, partNumber.String, toID); err != nil {
+			return fmt.Errorf("failed to update product %s: %w", toID, err)
+		}
+	} else if err := copyProductTx(tx, fromID, toID); err != nil {
+		return err
+	}
+
+	// Rows toID already has a counterpart for are dropped with fromID
+	for _, table := range productTables {
+		if _, err := tx.Exec("UPDATE OR IGNORE "+table+" SET product_id = ? WHERE product_id = ?", toID, fromID); err != nil {
+			return fmt.Errorf("failed to move %s: %w", table, err)
+		}
+		if _, err := tx.Exec("DELETE FROM "+table+" WHERE product_id = ?", fromID); err != nil {
+			return fmt.Errorf("failed to clear %s: %w", table, err)
+		}
+	}
+
+	if _, err := tx.Exec("DELETE FROM products WHERE id = ?", fromID); err != nil {
+		return fmt.Errorf("failed to delete product %s: %w", fromID, err)
+	}
+	return nil
+}
+
+// copyProductTx inserts a copy of the fromID product row under toID. The row is
+// copied rather than renamed so the records still pointing at fromID keep a
+// parent until they are moved.
+func copyProductTx(tx *sql.Tx, fromID, toID string) error {
+	rows, err := tx.Query("PRAGMA table_info(products)")
+	if err != nil {
+		return fmt.Errorf("failed to read product columns: %w", err)
+	}
+	var columns, values []string
+	for rows.Next() {
+		var cid, notNull, pk int
+		var name, typ string
+		var dflt sql.NullString
+		if err := rows.Scan(&cid, &name, &typ, &notNull, &dflt, &pk); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to read product columns: %w", err)
+		}
+		columns = append(columns, name)
+		if name == "id" {
+			values = append(values, "?")
+		} else {
+			values = append(values, name)
+		}
+	}
+	rows.Close()
+
+	query := fmt.Sprintf("INSERT INTO products (%s) SELECT %s FROM products WHERE id = ?",
+		strings.Join(columns, ", "), strings.Join(values, ", "))
+	if _, err := tx.Exec(query, toID, fromID); err != nil {
+		return fmt.Errorf("failed to copy product %s: %w", fromID, err)
+	}
+	return nil
+}
+
+// migrateStableIDs is migrateStableIDsLocked for the database
+func (s *SQLiteStore) migrateStableIDs() error {
+	rows, err := s.db.Query("SELECT id, name, part_number FROM products WHERE COALESCE(part_number, '') != ''")
+	if err != nil {
+		return err
+	}
+	moves := make(map[string]string) // old ID -> stable ID
+	for rows.Next() {
+		var p model.Product
+		if err := rows.Scan(&p.ID, &p.Name, &p.PartNumber); err != nil {
+			rows.Close()
+			return err
+		}
+		if stable := p.StableID(); stable != p.ID {
+			moves[p.ID] = stable
+		}
+	}
+	rows.Close()
+	if len(moves) == 0 {
+		return nil
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for fromID, toID := range moves {
+		if err := mergeProductTx(tx, fromID, toID); err != nil {
+			return err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	slog.Info("Moved products to part number IDs", "products", len(moves))
+	return nil
+}
@@ -0,0 +1,145 @@
+package store
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"apple-price/internal/model"
+)
+
+// EditProduct corrects fields of a product by hand. With pin the fields are kept
+// in the product's override and win over later scrapes; without it they last
+// until the next scrape and any earlier pin on them is released.
+func (s *Store) EditProduct(id string, fields map[string]string, pin bool) (*model.Product, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	defer s.productsVersion.Add(1)
+
+	existing, ok := s.products[id]
+	if !ok {
+		return nil, fmt.Errorf("product not found")
+	}
+	p := *existing
+	for name, value := range fields {
+		if !p.SetField(name, value) {
+			return nil, fmt.Errorf("field %s is not editable", name)
+		}
+	}
+	s.products[id] = &p
+
+	override := s.productOverrides[id]
+	if override == nil {
+		override = &model.ProductOverride{ProductID: id, Fields: make(map[string]string)}
+	}
+	for name, value := range fields {
+		if pin {
+			override.Fields[name] = value
+		} else {
+			delete(override.Fields, name)
+		}
+	}
+	override.UpdatedAt = time.Now()
+	if len(override.Fields) == 0 {
+		delete(s.productOverrides, id)
+	} else {
+		s.productOverrides[id] = override
+	}
+	return &p, nil
+}
+
+// GetProductOverride returns the fields pinned on a product
+func (s *Store) GetProductOverride(id string) (*model.ProductOverride, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	override, ok := s.productOverrides[id]
+	if !ok {
+		return nil, false
+	}
+	copied := *override
+	copied.Fields = make(map[string]string, len(override.Fields))
+	for name, value := range override.Fields {
+		copied.Fields[name] = value
+	}
+	return &copied, true
+}
+
+// EditProduct corrects fields of a product by hand. With pin the fields are kept
+// in the product's override and win over later scrapes; without it they last
+// until the next scrape and any earlier pin on them is released.
+func (s *SQLiteStore) EditProduct(id string, fields map[string]string, pin bool) (*model.Product, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	defer s.productsVersion.Add(1)
+
+	p, ok := s.GetProduct(id)
+	if !ok {
+		return nil, fmt.Errorf("product not found")
+	}
+	for name, value := range fields {
+		if !p.SetField(name, value) {
+			return nil, fmt.Errorf("field %s is not editable", name)
+		}
+	}
+
+	override, ok := s.GetProductOverride(id)
+	if !ok {
+		override = &model.ProductOverride{ProductID: id, Fields: make(map[string]string)}
+	}
+	for name, value := range fields {
+		if pin {
+			override.Fields[name] = value
+		} else {
+			delete(override.Fields, name)
+		}
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`
+		UPDATE products SET name = ?, category = ?, image_url = ?, product_url = ?, specs = ?, description = ?, part_number = ?, dominant_color = ?
+		WHERE id = ?
+	`, p.Name, p.Category, p.ImageURL, p.ProductURL, p.Specs, p.Description, p.PartNumber, p.DominantColor, id); err != nil {
+		return nil, err
+	}
+	if err := writeSpecIndex(tx, p); err != nil {
+		return nil, err
+	}
+
+	if len(override.Fields) == 0 {
+		_, err = tx.Exec("DELETE FROM product_overrides WHERE product_id = ?", id)
+	} else {
+		var data []byte
+		if data, err = json.Marshal(override.Fields); err != nil {
+			return nil, fmt.Errorf("failed to marshal product override: %w", err)
+		}
+		_, err = tx.Exec(`
+			INSERT INTO product_overrides (product_id, fields, updated_at) VALUES (?, ?, ?)
+			ON CONFLICT(product_id) DO UPDATE SET fields = excluded.fields, updated_at = excluded.updated_at
+		`, id, string(data), time.Now().Unix())
+	}
+	if err != nil {
+		return nil, err
+	}
+	return p, tx.Commit()
+}
+
+// GetProductOverride returns the fields pinned on a product
+func (s *SQLiteStore) GetProductOverride(id string) (*model.ProductOverride, bool) {
+	var fields string
+	var updated int64
+	if err := s.db.QueryRow("SELECT fields, updated_at FROM product_overrides WHERE product_id = ?", id).Scan(&fields, &updated); err != nil {
+		return nil, false
+	}
+
+	override := &model.ProductOverride{ProductID: id, UpdatedAt: time.Unix(updated, 0)}
+	if err := json.Unmarshal([]byte(fields), &override.Fields); err != nil {
+		return nil, false
+	}
+	return override, true
+}
@@ -0,0 +1,102 @@
+package store
+
+import (
+	"fmt"
+	"time"
+
+	"apple-price/internal/model"
+)
+
+// retailerScore scores a refurbished price against the cheapest new price at a
+// third-party retailer: up to 10 points when the listing is well below it, -10
+// when the retailer sells the product new for the same or less
+func retailerScore(price float64, prices []model.RetailerPrice) (float64, string) {
+	cheapest := model.CheapestRetailerPrice(prices)
+	if cheapest == nil || price <= 0 {
+		return 0, "暂无第三方零售商新品价格"
+	}
+
+	ratio := price / cheapest.Price
+	reason := fmt.Sprintf("为 %s 新品价 ¥%.0f 的 %.0f%%", cheapest.Retailer, cheapest.Price, ratio*100)
+	switch {
+	case ratio <= 0.85:
+		return 10, reason
+	case ratio <= 0.95:
+		return 5, reason
+	case ratio < 1:
+		return 2, reason
+	}
+	return -10, fmt.Sprintf("%s 新品价 ¥%.0f 不高于翻新价", cheapest.Retailer, cheapest.Price)
+}
+
+// GetRetailerPrices returns the new prices of a product at third-party retailers
+func (s *Store) GetRetailerPrices(productID string) []model.RetailerPrice {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return append([]model.RetailerPrice{}, s.retailerPrices[productID]...)
+}
+
+// SetRetailerPrices replaces the retailer prices of a product; none removes them
+func (s *Store) SetRetailerPrices(productID string, prices []model.RetailerPrice) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	defer s.productsVersion.Add(1)
+
+	if len(prices) == 0 {
+		delete(s.retailerPrices, productID)
+		return nil
+	}
+	s.retailerPrices[productID] = append([]model.RetailerPrice(nil), prices...)
+	return nil
+}
+
+// GetRetailerPrices returns the new prices of a product at third-party retailers
+func (s *SQLiteStore) GetRetailerPrices(productID string) []model.RetailerPrice {
+	rows, err := s.db.Query(`
+		SELECT retailer, price, url, fetched_at FROM retailer_prices
+		WHERE product_id = ? ORDER BY price ASC
+	`, productID)
+	if err != nil {
+		return []model.RetailerPrice{}
+	}
+	defer rows.Close()
+
+	prices := []model.RetailerPrice{}
+	for rows.Next() {
+		var p model.RetailerPrice
+		var fetched int64
+		if err := rows.Scan(&p.Retailer, &p.Price, &p.URL, &fetched); err != nil {
+			continue
+		}
+		p.FetchedAt = time.Unix(fetched, 0)
+		prices = append(prices, p)
+	}
+	return prices
+}
+
+// SetRetailerPrices replaces the retailer prices of a product; none removes them
+func (s *SQLiteStore) SetRetailerPrices(productID string, prices []model.RetailerPrice) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	defer s.productsVersion.Add(1)
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM retailer_prices WHERE product_id = ?", productID); err != nil {
+		return err
+	}
+	for _, p := range prices {
+		if _, err := tx.Exec(`
+			INSERT OR REPLACE INTO retailer_prices (product_id, retailer, price, url, fetched_at)
+			VALUES (?, ?, ?, ?, ?)
+		`, productID, p.Retailer, p.Price, p.URL, p.FetchedAt.Unix()); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,59 @@
+package store
+
+import (
+	"time"
+
+	"apple-price/internal/model"
+)
+
+// retentionState holds the retention policy and eviction counters of a store.
+// It is guarded by the owning store's mutex.
+type retentionState struct {
+	policy               model.RetentionPolicy
+	evictedHistory       int64
+	evictedNotifications int64
+	compactedHistory     int64
+	lastEvictionAt       time.Time
+	lastCompactionAt     time.Time
+}
+
+// record adds one eviction run to the counters
+func (r *retentionState) record(history, notifications int, now time.Time) {
+	if history == 0 && notifications == 0 {
+		return
+	}
+	r.evictedHistory += int64(history)
+	r.evictedNotifications += int64(notifications)
+	r.lastEvictionAt = now
+}
+
+// recordCompaction adds one compaction run to the counters
+func (r *retentionState) recordCompaction(compacted int, now time.Time) {
+	r.compactedHistory += int64(compacted)
+	r.lastCompactionAt = now
+}
+
+// compactBefore is the start of the oldest day whose price history is kept raw:
+// points recorded before it are downsampled. Zero when compaction is off.
+func (r *retentionState) compactBefore(now time.Time) time.Time {
+	if r.policy.RawHistoryDays <= 0 {
+		return time.Time{}
+	}
+	day := now.AddDate(0, 0, -r.policy.RawHistoryDays)
+	return time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, day.Location())
+}
+
+// stats reports the policy and counters together with the current row counts
+func (r *retentionState) stats(historyRows, dailyHistoryRows, notificationRows int) *model.RetentionStats {
+	return &model.RetentionStats{
+		RetentionPolicy:      r.policy,
+		HistoryRows:          historyRows,
+		DailyHistoryRows:     dailyHistoryRows,
+		NotificationRows:     notificationRows,
+		EvictedHistory:       r.evictedHistory,
+		EvictedNotifications: r.evictedNotifications,
+		CompactedHistory:     r.compactedHistory,
+		LastEvictionAt:       r.lastEvictionAt,
+		LastCompactionAt:     r.lastCompactionAt,
+	}
+}
@@ -0,0 +1,69 @@
+package store
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"apple-price/internal/model"
+)
+
+// AddSavedSearch stores a new saved search; slugs are never reused
+func (s *Store) AddSavedSearch(search *model.SavedSearch) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.savedSearches[search.Slug]; exists {
+		return fmt.Errorf("saved search already exists")
+	}
+	copied := *search
+	s.savedSearches[search.Slug] = &copied
+	return nil
+}
+
+// GetSavedSearch returns a saved search by slug
+func (s *Store) GetSavedSearch(slug string) (*model.SavedSearch, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	search, ok := s.savedSearches[slug]
+	if !ok {
+		return nil, false
+	}
+	copied := *search
+	return &copied, true
+}
+
+// AddSavedSearch stores a new saved search; slugs are never reused. The filters
+// are kept as JSON, they are only ever read back whole.
+func (s *SQLiteStore) AddSavedSearch(search *model.SavedSearch) error {
+	filters, err := json.Marshal(search)
+	if err != nil {
+		return fmt.Errorf("failed to marshal saved search: %w", err)
+	}
+	_, err = s.db.Exec(`
+		INSERT INTO saved_searches (slug, name, filters, created_at)
+		VALUES (?, ?, ?, ?)
+	`, search.Slug, search.Name, string(filters), search.CreatedAt.Unix())
+	return err
+}
+
+// GetSavedSearch returns a saved search by slug
+func (s *SQLiteStore) GetSavedSearch(slug string) (*model.SavedSearch, bool) {
+	var name, filters string
+	var created int64
+	err := s.db.QueryRow("SELECT name, filters, created_at FROM saved_searches WHERE slug = ?", slug).
+		Scan(&name, &filters, &created)
+	if err != nil {
+		return nil, false
+	}
+
+	search := &model.SavedSearch{}
+	if err := json.Unmarshal([]byte(filters), search); err != nil {
+		return nil, false
+	}
+	search.Slug = slug
+	search.Name = name
+	search.CreatedAt = time.Unix(created, 0)
+	return search, true
+}
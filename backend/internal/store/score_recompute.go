@@ -0,0 +1,137 @@
+package store
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"apple-price/internal/model"
+)
+
+// scoreRecomputeKey is the config key of the last value score recomputation
+const scoreRecomputeKey = "score_recompute"
+
+// RecomputeValueScores recalculates the value score, price trend, lowest and
+// highest price of every product, archived ones included, and records the run
+func (s *Store) RecomputeValueScores(now time.Time) (*model.ScoreRecompute, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	defer s.productsVersion.Add(1)
+
+	start := time.Now()
+	for _, p := range s.products {
+		p.ValueScore = s.calculateValueScore(p, s.history[p.ID], now)
+		s.updatePriceStats(p, now)
+	}
+
+	s.scoreRecompute = &model.ScoreRecompute{
+		RanAt:    now,
+		Products: len(s.products),
+		Duration: time.Since(start).Milliseconds(),
+	}
+	return s.scoreRecompute, nil
+}
+
+// GetLastScoreRecompute returns the last value score recomputation, nil before
+// the first (in-memory for JSON store)
+func (s *Store) GetLastScoreRecompute() *model.ScoreRecompute {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.scoreRecompute
+}
+
+// RecomputeValueScores is Store.RecomputeValueScores for the database
+func (s *SQLiteStore) RecomputeValueScores(now time.Time) (*model.ScoreRecompute, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	defer s.productsVersion.Add(1)
+
+	start := time.Now()
+	rows, err := s.db.Query("SELECT id, price, discount, stock_status, created_at FROM products")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query products: %w", err)
+	}
+	var products []*model.Product
+	for rows.Next() {
+		p := &model.Product{}
+		var created int64
+		if err := rows.Scan(&p.ID, &p.Price, &p.Discount, &p.StockStatus, &created); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to read product: %w", err)
+		}
+		p.CreatedAt = time.Unix(created, 0)
+		products = append(products, p)
+	}
+	rows.Close()
+
+	// Score everything before opening the transaction: the history and retailer
+	// price reads need a connection of their own. Products without history keep
+	// their statistics.
+	args := make([][]any, 0, len(products))
+	for _, p := range products {
+		history := s.getPriceHistoryLocked(p.ID)
+		p.ValueScore = s.CalculateValueScore(p, history)
+		if len(history) == 0 {
+			args = append(args, []any{p.ValueScore, nil, nil, nil, nil, nil, nil, p.ID})
+			continue
+		}
+		updateProductStats(p, history)
+		args = append(args, []any{p.ValueScore, p.LowestPrice, p.HighestPrice, p.PriceTrend, p.Volatility, p.DropStreak, p.Stability, p.ID})
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(`
+		UPDATE products SET value_score = ?,
+			lowest_price = COALESCE(?, lowest_price), highest_price = COALESCE(?, highest_price),
+			price_trend = COALESCE(?, price_trend), volatility = COALESCE(?, volatility),
+			drop_streak = COALESCE(?, drop_streak), stability = COALESCE(?, stability)
+		WHERE id = ?
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare update: %w", err)
+	}
+	defer stmt.Close()
+
+	for _, a := range args {
+		if _, err := stmt.Exec(a...); err != nil {
+			return nil, fmt.Errorf("failed to update product %s: %w", a[len(a)-1], err)
+		}
+	}
+
+	recompute := &model.ScoreRecompute{
+		RanAt:    now,
+		Products: len(products),
+		Duration: time.Since(start).Milliseconds(),
+	}
+	data, _ := json.Marshal(recompute)
+	if _, err := tx.Exec(`
+		INSERT INTO config (key, value) VALUES (?, ?)
+		ON CONFLICT(key) DO UPDATE SET value = excluded.value
+	`, scoreRecomputeKey, string(data)); err != nil {
+		return nil, fmt.Errorf("failed to record recomputation: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit recomputation: %w", err)
+	}
+	return recompute, nil
+}
+
+// GetLastScoreRecompute returns the last value score recomputation, nil before the first
+func (s *SQLiteStore) GetLastScoreRecompute() *model.ScoreRecompute {
+	var value string
+	if err := s.db.QueryRow("SELECT value FROM config WHERE key = ?", scoreRecomputeKey).Scan(&value); err != nil {
+		return nil
+	}
+	var recompute model.ScoreRecompute
+	if err := json.Unmarshal([]byte(value), &recompute); err != nil {
+		return nil
+	}
+	return &recompute
+}
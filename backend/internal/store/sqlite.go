@@ -1,13 +1,19 @@
 package store
 
 import (
+	"crypto/rand"
+	"crypto/sha256"
 	"database/sql"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"log/slog"
+	"math"
 	"os"
 	"path/filepath"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"apple-price/internal/model"
@@ -15,12 +21,32 @@ import (
 	_ "github.com/mattn/go-sqlite3"
 )
 
+// Connection pool limits. WAL mode lets readers run on their own connections
+// alongside the single writer, so reads no longer queue behind each other.
+const (
+	sqliteMaxOpenConns    = 8
+	sqliteMaxIdleConns    = 4
+	sqliteConnMaxIdleTime = 5 * time.Minute
+)
+
 // SQLiteStore manages product data using SQLite database
 type SQLiteStore struct {
-	db            *sql.DB
-	mu            sync.RWMutex
-	dataDir       string
+	db *sql.DB
+	// mu serializes writes and guards the in-memory fields below. Read-only
+	// queries don't take it: WAL gives each reader a consistent snapshot.
+	mu             sync.RWMutex
+	dataDir        string
 	lastScrapeTime time.Time
+	retention      retentionState
+
+	// productsVersion is bumped after every product change
+	productsVersion atomic.Uint64
+	// velocity caches the inventory velocity index as of productsVersion
+	velocity velocityCache
+
+	// stmts caches prepared statements for hot read paths, keyed by query text
+	stmts   map[string]*sql.Stmt
+	stmtsMu sync.Mutex
 }
 
 // NewSQLite creates a new SQLiteStore instance
@@ -39,6 +65,10 @@ func NewSQLite(dataDir string) (*SQLiteStore, error) {
 		return nil, fmt.Errorf("failed to open database: %w", err)
 	}
 
+	db.SetMaxOpenConns(sqliteMaxOpenConns)
+	db.SetMaxIdleConns(sqliteMaxIdleConns)
+	db.SetConnMaxIdleTime(sqliteConnMaxIdleTime)
+
 	// Test connection
 	if err := db.Ping(); err != nil {
 		return nil, fmt.Errorf("failed to ping database: %w", err)
@@ -47,6 +77,7 @@ func NewSQLite(dataDir string) (*SQLiteStore, error) {
 	s := &SQLiteStore{
 		db:      db,
 		dataDir: dataDir,
+		stmts:   make(map[string]*sql.Stmt),
 	}
 
 	// Run migrations
@@ -74,10 +105,18 @@ func (s *SQLiteStore) migrate() error {
 		specs_detail TEXT,
 		description TEXT,
 		stock_status TEXT NOT NULL DEFAULT 'available',
+		grade TEXT,
+		warranty_months INTEGER DEFAULT 0,
+		battery_health INTEGER DEFAULT 0,
+		dominant_color TEXT,
+		part_number TEXT,
 		value_score REAL DEFAULT 0,
 		lowest_price REAL,
 		highest_price REAL,
 		price_trend TEXT DEFAULT 'stable',
+		volatility REAL DEFAULT 0,
+		drop_streak INTEGER DEFAULT 0,
+		stability TEXT DEFAULT '',
 		created_at INTEGER NOT NULL,
 		updated_at INTEGER NOT NULL
 	);
@@ -91,12 +130,29 @@ func (s *SQLiteStore) migrate() error {
 		FOREIGN KEY (product_id) REFERENCES products(id) ON DELETE CASCADE
 	);
 
+	CREATE TABLE IF NOT EXISTS price_history_daily (
+		product_id TEXT NOT NULL,
+		date TEXT NOT NULL,
+		min_price REAL NOT NULL,
+		max_price REAL NOT NULL,
+		close_price REAL NOT NULL,
+		close_discount REAL NOT NULL,
+		close_at INTEGER NOT NULL,
+		PRIMARY KEY (product_id, date),
+		FOREIGN KEY (product_id) REFERENCES products(id) ON DELETE CASCADE
+	);
+
 	CREATE TABLE IF NOT EXISTS subscriptions (
 		id TEXT PRIMARY KEY,
 		product_id TEXT NOT NULL,
 		bark_key TEXT,
 		email TEXT,
 		target_price REAL DEFAULT 0,
+		low_stock_alert INTEGER DEFAULT 0,
+		paused INTEGER DEFAULT 0,
+		notification_count INTEGER DEFAULT 0,
+		last_notified_at INTEGER,
+		updated_at INTEGER,
 		created_at INTEGER NOT NULL,
 		FOREIGN KEY (product_id) REFERENCES products(id) ON DELETE CASCADE
 	);
@@ -105,17 +161,9 @@ func (s *SQLiteStore) migrate() error {
 		id TEXT PRIMARY KEY,
 		name TEXT NOT NULL,
 		description TEXT,
-		categories TEXT,
-		models TEXT,
-		chips TEXT,
-		storages TEXT,
-		memories TEXT,
-		stock_statuses TEXT,
 		max_price REAL DEFAULT 0,
 		min_price REAL DEFAULT 0,
-		keywords TEXT,
 		bark_key TEXT,
-		notified_product_ids TEXT DEFAULT '[]',
 		enabled INTEGER DEFAULT 1,
 		paused INTEGER DEFAULT 0,
 		notification_count INTEGER DEFAULT 0,
@@ -124,6 +172,39 @@ func (s *SQLiteStore) migrate() error {
 		updated_at INTEGER
 	);
 
+	CREATE TABLE IF NOT EXISTS subscription_filters (
+		subscription_id TEXT NOT NULL,
+		field TEXT NOT NULL, -- category, model, chip, storage, memory, stock_status, keyword
+		value TEXT NOT NULL,
+		position INTEGER NOT NULL DEFAULT 0,
+		PRIMARY KEY (subscription_id, field, value),
+		FOREIGN KEY (subscription_id) REFERENCES new_arrival_subscriptions(id) ON DELETE CASCADE
+	);
+
+	CREATE TABLE IF NOT EXISTS subscription_notified_products (
+		subscription_id TEXT NOT NULL,
+		product_id TEXT NOT NULL,
+		notified_at INTEGER NOT NULL,
+		PRIMARY KEY (subscription_id, product_id),
+		FOREIGN KEY (subscription_id) REFERENCES new_arrival_subscriptions(id) ON DELETE CASCADE
+	);
+
+	CREATE TABLE IF NOT EXISTS watchlists (
+		id TEXT PRIMARY KEY,
+		client_token TEXT NOT NULL,
+		name TEXT NOT NULL,
+		created_at INTEGER NOT NULL,
+		updated_at INTEGER NOT NULL
+	);
+
+	CREATE TABLE IF NOT EXISTS watchlist_items (
+		watchlist_id TEXT NOT NULL,
+		product_id TEXT NOT NULL,
+		position INTEGER NOT NULL,
+		PRIMARY KEY (watchlist_id, product_id),
+		FOREIGN KEY (watchlist_id) REFERENCES watchlists(id) ON DELETE CASCADE
+	);
+
 	CREATE TABLE IF NOT EXISTS notification_history (
 		id TEXT PRIMARY KEY,
 		subscription_id TEXT NOT NULL,
@@ -152,11 +233,240 @@ func (s *SQLiteStore) migrate() error {
 		updated_at INTEGER
 	);
 
+	CREATE TABLE IF NOT EXISTS region_scraper_status (
+		region TEXT PRIMARY KEY,
+		last_scrape_time INTEGER,
+		last_scrape_status TEXT DEFAULT 'never',
+		last_scrape_error TEXT,
+		products_scraped INTEGER DEFAULT 0,
+		duration_ms INTEGER DEFAULT 0,
+		updated_at INTEGER
+	);
+
+	CREATE TABLE IF NOT EXISTS product_events (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		product_id TEXT NOT NULL,
+		event_type TEXT NOT NULL,
+		price REAL NOT NULL DEFAULT 0,
+		created_at INTEGER NOT NULL,
+		FOREIGN KEY (product_id) REFERENCES products(id) ON DELETE CASCADE
+	);
+
+	CREATE TABLE IF NOT EXISTS pending_notifications (
+		subscription_id TEXT NOT NULL,
+		product_id TEXT NOT NULL,
+		product_name TEXT NOT NULL,
+		product_category TEXT,
+		product_price REAL,
+		product_image_url TEXT,
+		product_url TEXT,
+		created_at INTEGER NOT NULL,
+		PRIMARY KEY (subscription_id, product_id),
+		FOREIGN KEY (subscription_id) REFERENCES new_arrival_subscriptions(id) ON DELETE CASCADE
+	);
+
+	CREATE TABLE IF NOT EXISTS regions (
+		code TEXT PRIMARY KEY,
+		name TEXT NOT NULL,
+		base_url TEXT NOT NULL,
+		currency TEXT NOT NULL,
+		enabled INTEGER DEFAULT 1,
+		created_at INTEGER NOT NULL,
+		updated_at INTEGER NOT NULL
+	);
+
+	CREATE TABLE IF NOT EXISTS retailer_prices (
+		product_id TEXT NOT NULL,
+		retailer TEXT NOT NULL,
+		price REAL NOT NULL,
+		url TEXT DEFAULT '',
+		fetched_at INTEGER NOT NULL,
+		PRIMARY KEY (product_id, retailer)
+	);
+
+	CREATE TABLE IF NOT EXISTS price_annotations (
+		id TEXT PRIMARY KEY,
+		date TEXT NOT NULL,
+		label TEXT NOT NULL,
+		description TEXT,
+		category TEXT DEFAULT '',
+		region TEXT DEFAULT '',
+		created_at INTEGER NOT NULL
+	);
+
+	CREATE TABLE IF NOT EXISTS daily_stats (
+		date TEXT NOT NULL,
+		category TEXT NOT NULL,
+		region TEXT NOT NULL,
+		product_count INTEGER DEFAULT 0,
+		avg_price REAL DEFAULT 0,
+		avg_discount REAL DEFAULT 0,
+		new_listings INTEGER DEFAULT 0,
+		updated_at INTEGER,
+		PRIMARY KEY (date, category, region)
+	);
+
+	CREATE TABLE IF NOT EXISTS stats_history (
+		date TEXT NOT NULL,
+		region TEXT NOT NULL,
+		total_products INTEGER DEFAULT 0,
+		available_products INTEGER DEFAULT 0,
+		sold_out_products INTEGER DEFAULT 0,
+		avg_discount REAL DEFAULT 0,
+		new_listings INTEGER DEFAULT 0,
+		subscriptions INTEGER DEFAULT 0,
+		categories TEXT,
+		updated_at INTEGER,
+		PRIMARY KEY (date, region)
+	);
+
+	CREATE TABLE IF NOT EXISTS daily_price_index (
+		date TEXT NOT NULL,
+		tier TEXT NOT NULL,
+		region TEXT NOT NULL,
+		products INTEGER DEFAULT 0,
+		avg_price REAL DEFAULT 0,
+		price_per_gb REAL DEFAULT 0,
+		updated_at INTEGER,
+		PRIMARY KEY (date, tier, region)
+	);
+
+	CREATE TABLE IF NOT EXISTS scrape_runs (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		started_at INTEGER NOT NULL,
+		finished_at INTEGER NOT NULL,
+		product_count INTEGER DEFAULT 0
+	);
+
+	CREATE TABLE IF NOT EXISTS scrape_run_categories (
+		run_id INTEGER NOT NULL,
+		region TEXT NOT NULL,
+		category TEXT NOT NULL,
+		total INTEGER DEFAULT 0,
+		available INTEGER DEFAULT 0,
+		PRIMARY KEY (run_id, region, category),
+		FOREIGN KEY (run_id) REFERENCES scrape_runs(id) ON DELETE CASCADE
+	);
+
+	CREATE TABLE IF NOT EXISTS scrape_run_pages (
+		run_id INTEGER NOT NULL,
+		region TEXT NOT NULL,
+		page TEXT NOT NULL,
+		products INTEGER DEFAULT 0,
+		error TEXT DEFAULT '',
+		duration_ms INTEGER DEFAULT 0,
+		PRIMARY KEY (run_id, region, page),
+		FOREIGN KEY (run_id) REFERENCES scrape_runs(id) ON DELETE CASCADE
+	);
+
+	CREATE TABLE IF NOT EXISTS notification_retries (
+		id TEXT PRIMARY KEY,
+		history_id TEXT,
+		subscription_id TEXT NOT NULL,
+		product_id TEXT,
+		notification_type TEXT NOT NULL,
+		bark_key TEXT NOT NULL,
+		bark_server TEXT,
+		title TEXT NOT NULL,
+		body TEXT NOT NULL,
+		url TEXT,
+		icon TEXT,
+		sound TEXT,
+		msg_group TEXT,
+		attempts INTEGER DEFAULT 0,
+		next_attempt_at INTEGER NOT NULL,
+		last_error TEXT,
+		created_at INTEGER NOT NULL
+	);
+
+	CREATE TABLE IF NOT EXISTS held_notifications (
+		id TEXT PRIMARY KEY,
+		subscription_id TEXT NOT NULL,
+		product_id TEXT,
+		notification_type TEXT NOT NULL,
+		bark_key TEXT NOT NULL,
+		bark_server TEXT NOT NULL DEFAULT '',
+		title TEXT NOT NULL,
+		body TEXT NOT NULL,
+		url TEXT NOT NULL DEFAULT '',
+		icon TEXT NOT NULL DEFAULT '',
+		sound TEXT NOT NULL DEFAULT '',
+		msg_group TEXT NOT NULL DEFAULT '',
+		product TEXT NOT NULL DEFAULT 'null',
+		counted TEXT NOT NULL DEFAULT 'null',
+		notified TEXT NOT NULL DEFAULT 'null',
+		send_at INTEGER NOT NULL,
+		created_at INTEGER NOT NULL
+	);
+
+	CREATE TABLE IF NOT EXISTS pending_details (
+		product_id TEXT PRIMARY KEY,
+		priority INTEGER DEFAULT 0,
+		queued_at INTEGER NOT NULL
+	);
+
+	CREATE TABLE IF NOT EXISTS saved_searches (
+		slug TEXT PRIMARY KEY,
+		name TEXT DEFAULT '',
+		filters TEXT NOT NULL,
+		created_at INTEGER NOT NULL
+	);
+
+	CREATE TABLE IF NOT EXISTS product_overrides (
+		product_id TEXT PRIMARY KEY,
+		fields TEXT NOT NULL,
+		updated_at INTEGER NOT NULL
+	);
+
+	CREATE TABLE IF NOT EXISTS region_deletions (
+		id TEXT PRIMARY KEY,
+		region TEXT NOT NULL,
+		products INTEGER DEFAULT 0,
+		price_history INTEGER DEFAULT 0,
+		subscriptions INTEGER DEFAULT 0,
+		events INTEGER DEFAULT 0,
+		payload TEXT NOT NULL,
+		deleted_at INTEGER NOT NULL,
+		expires_at INTEGER NOT NULL
+	);
+
+	CREATE TABLE IF NOT EXISTS user_preferences (
+		bark_key TEXT PRIMARY KEY,
+		paused_all INTEGER DEFAULT 0,
+		updated_at INTEGER
+	);
+
+	CREATE TABLE IF NOT EXISTS delivery_health (
+		bark_key TEXT PRIMARY KEY,
+		consecutive_failures INTEGER NOT NULL DEFAULT 0,
+		last_error TEXT DEFAULT '',
+		last_failure_at INTEGER,
+		broken_at INTEGER
+	);
+
+	CREATE TABLE IF NOT EXISTS api_tokens (
+		token_hash TEXT PRIMARY KEY,
+		name TEXT NOT NULL,
+		created_at INTEGER NOT NULL,
+		last_used_at INTEGER,
+		revoked INTEGER DEFAULT 0
+	);
+
 	CREATE TABLE IF NOT EXISTS config (
 		key TEXT PRIMARY KEY,
 		value TEXT
 	);
 
+	CREATE TABLE IF NOT EXISTS users (
+		id TEXT PRIMARY KEY,
+		name TEXT DEFAULT '',
+		bark_key TEXT NOT NULL UNIQUE,
+		api_key_hash TEXT DEFAULT '',
+		created_at INTEGER NOT NULL,
+		updated_at INTEGER
+	);
+	CREATE INDEX IF NOT EXISTS idx_users_api_key_hash ON users(api_key_hash);
+
 	CREATE INDEX IF NOT EXISTS idx_products_category ON products(category);
 	CREATE INDEX IF NOT EXISTS idx_products_region ON products(region);
 	CREATE INDEX IF NOT EXISTS idx_products_stock_status ON products(stock_status);
@@ -167,6 +477,10 @@ func (s *SQLiteStore) migrate() error {
 	CREATE INDEX IF NOT EXISTS idx_subscriptions_product_id ON subscriptions(product_id);
 	CREATE INDEX IF NOT EXISTS idx_new_arrival_subscriptions_enabled ON new_arrival_subscriptions(enabled);
 	CREATE INDEX IF NOT EXISTS idx_notification_history_subscription ON notification_history(subscription_id, created_at DESC);
+	CREATE INDEX IF NOT EXISTS idx_product_events_product ON product_events(product_id, created_at);
+	CREATE INDEX IF NOT EXISTS idx_watchlists_client_token ON watchlists(client_token, created_at);
+	CREATE INDEX IF NOT EXISTS idx_notification_retries_next ON notification_retries(next_attempt_at);
+	CREATE INDEX IF NOT EXISTS idx_held_notifications_send ON held_notifications(send_at);
 	`
 
 	_, err := s.db.Exec(schema)
@@ -180,46 +494,224 @@ func (s *SQLiteStore) migrate() error {
 	// Add description column if it doesn't exist (for existing databases)
 	s.db.Exec(`ALTER TABLE products ADD COLUMN description TEXT`)
 
+	// Refurbishment terms extracted by the detail scraper
+	s.db.Exec(`ALTER TABLE products ADD COLUMN grade TEXT`)
+	s.db.Exec(`ALTER TABLE products ADD COLUMN warranty_months INTEGER DEFAULT 0`)
+	s.db.Exec(`ALTER TABLE products ADD COLUMN battery_health INTEGER DEFAULT 0`)
+
+	// Product image color extracted by the detail scraper
+	s.db.Exec(`ALTER TABLE products ADD COLUMN dominant_color TEXT`)
+
+	// Apple part number, matches the same SKU across regions
+	s.db.Exec(`ALTER TABLE products ADD COLUMN part_number TEXT`)
+	s.db.Exec(`CREATE INDEX IF NOT EXISTS idx_products_part_number ON products(part_number)`)
+
+	// Price volatility indicators
+	s.db.Exec(`ALTER TABLE products ADD COLUMN volatility REAL DEFAULT 0`)
+	s.db.Exec(`ALTER TABLE products ADD COLUMN drop_streak INTEGER DEFAULT 0`)
+	s.db.Exec(`ALTER TABLE products ADD COLUMN stability TEXT DEFAULT ''`)
+	s.db.Exec(`ALTER TABLE products ADD COLUMN archived INTEGER DEFAULT 0`)
+
+	// Specs extracted for /api/products filters, NULL until indexed (see writeSpecIndex)
+	s.db.Exec(`ALTER TABLE products ADD COLUMN spec_chip TEXT`)
+	s.db.Exec(`ALTER TABLE products ADD COLUMN spec_memory TEXT`)
+	s.db.Exec(`ALTER TABLE products ADD COLUMN spec_storage TEXT`)
+	s.db.Exec(`ALTER TABLE products ADD COLUMN spec_screen_size TEXT`)
+	s.db.Exec(`ALTER TABLE products ADD COLUMN spec_model TEXT`)
+	s.db.Exec(`ALTER TABLE products ADD COLUMN spec_color TEXT`)
+	for _, column := range []string{"spec_chip", "spec_memory", "spec_storage", "spec_screen_size", "spec_color", "spec_model"} {
+		s.db.Exec(fmt.Sprintf(`CREATE INDEX IF NOT EXISTS idx_products_category_%s ON products(category, %s COLLATE NOCASE)`, column, column))
+	}
+
 	// Add target_price column to subscriptions if it doesn't exist (for existing databases)
 	s.db.Exec(`ALTER TABLE subscriptions ADD COLUMN target_price REAL DEFAULT 0`)
 
+	// Opt-in "库存紧张" alerts for product subscriptions
+	s.db.Exec(`ALTER TABLE subscriptions ADD COLUMN low_stock_alert INTEGER DEFAULT 0`)
+
 	// Remove email column from subscriptions if it exists (migration)
 	s.db.Exec(`ALTER TABLE subscriptions DROP COLUMN email`)
 
-	// Add notified_product_ids column to new_arrival_subscriptions
-	s.db.Exec(`ALTER TABLE new_arrival_subscriptions ADD COLUMN notified_product_ids TEXT DEFAULT '[]'`)
-
-	// Add new columns to new_arrival_subscriptions for enhanced filtering
+	// Add new columns to new_arrival_subscriptions
 	s.db.Exec(`ALTER TABLE new_arrival_subscriptions ADD COLUMN description TEXT`)
-	s.db.Exec(`ALTER TABLE new_arrival_subscriptions ADD COLUMN chips TEXT`)
-	s.db.Exec(`ALTER TABLE new_arrival_subscriptions ADD COLUMN storages TEXT`)
-	s.db.Exec(`ALTER TABLE new_arrival_subscriptions ADD COLUMN memories TEXT`)
-	s.db.Exec(`ALTER TABLE new_arrival_subscriptions ADD COLUMN stock_statuses TEXT`)
-	s.db.Exec(`ALTER TABLE new_arrival_subscriptions ADD COLUMN models TEXT`)
 	s.db.Exec(`ALTER TABLE new_arrival_subscriptions ADD COLUMN paused INTEGER DEFAULT 0`)
 	s.db.Exec(`ALTER TABLE new_arrival_subscriptions ADD COLUMN notification_count INTEGER DEFAULT 0`)
 	s.db.Exec(`ALTER TABLE new_arrival_subscriptions ADD COLUMN last_notified_at INTEGER`)
 	s.db.Exec(`ALTER TABLE new_arrival_subscriptions ADD COLUMN updated_at INTEGER`)
 
+	// Quiet hours for both subscription kinds
+	s.db.Exec(`ALTER TABLE subscriptions ADD COLUMN quiet_hours_start TEXT DEFAULT ''`)
+	s.db.Exec(`ALTER TABLE subscriptions ADD COLUMN quiet_hours_end TEXT DEFAULT ''`)
+	s.db.Exec(`ALTER TABLE subscriptions ADD COLUMN timezone TEXT DEFAULT ''`)
+	s.db.Exec(`ALTER TABLE new_arrival_subscriptions ADD COLUMN quiet_hours_start TEXT DEFAULT ''`)
+	s.db.Exec(`ALTER TABLE new_arrival_subscriptions ADD COLUMN quiet_hours_end TEXT DEFAULT ''`)
+	s.db.Exec(`ALTER TABLE new_arrival_subscriptions ADD COLUMN timezone TEXT DEFAULT ''`)
+
+	// Digest frequency for new arrival subscriptions
+	s.db.Exec(`ALTER TABLE new_arrival_subscriptions ADD COLUMN frequency TEXT DEFAULT 'instant'`)
+
+	// Price stability filter for new arrival subscriptions
+	s.db.Exec(`ALTER TABLE new_arrival_subscriptions ADD COLUMN stability TEXT DEFAULT ''`)
+
+	// Self-hosted Bark server for both subscription kinds (empty = BARK_SERVER)
+	s.db.Exec(`ALTER TABLE subscriptions ADD COLUMN bark_server TEXT DEFAULT ''`)
+	s.db.Exec(`ALTER TABLE new_arrival_subscriptions ADD COLUMN bark_server TEXT DEFAULT ''`)
+
+	// Message language of both subscription kinds (empty = owner's preference)
+	s.db.Exec(`ALTER TABLE subscriptions ADD COLUMN language TEXT DEFAULT ''`)
+	s.db.Exec(`ALTER TABLE new_arrival_subscriptions ADD COLUMN language TEXT DEFAULT ''`)
+
+	// Price subscriptions can be paused and count their notifications like new arrival subscriptions
+	s.db.Exec(`ALTER TABLE subscriptions ADD COLUMN paused INTEGER DEFAULT 0`)
+	s.db.Exec(`ALTER TABLE subscriptions ADD COLUMN notification_count INTEGER DEFAULT 0`)
+	s.db.Exec(`ALTER TABLE subscriptions ADD COLUMN last_notified_at INTEGER`)
+	s.db.Exec(`ALTER TABLE subscriptions ADD COLUMN updated_at INTEGER`)
+
+	// Stale product cleanups share the undoable region deletion log
+	s.db.Exec(`ALTER TABLE region_deletions ADD COLUMN stale_before INTEGER`)
+
+	// Spec combo watches ("MacBook Pro 14 M4 Pro 48GB") for products not in the catalog yet
+	s.db.Exec(`ALTER TABLE new_arrival_subscriptions ADD COLUMN spec TEXT DEFAULT ''`)
+
+	// Soft-deleted new arrival subscriptions stay restorable until purged
+	s.db.Exec(`ALTER TABLE new_arrival_subscriptions ADD COLUMN deleted_at INTEGER`)
+
+	// Per-user notification preferences beyond the kill switch
+	s.db.Exec(`ALTER TABLE user_preferences ADD COLUMN default_channel TEXT DEFAULT ''`)
+	s.db.Exec(`ALTER TABLE user_preferences ADD COLUMN quiet_hours_start TEXT DEFAULT ''`)
+	s.db.Exec(`ALTER TABLE user_preferences ADD COLUMN quiet_hours_end TEXT DEFAULT ''`)
+	s.db.Exec(`ALTER TABLE user_preferences ADD COLUMN timezone TEXT DEFAULT ''`)
+	s.db.Exec(`ALTER TABLE user_preferences ADD COLUMN language TEXT DEFAULT ''`)
+	s.db.Exec(`ALTER TABLE user_preferences ADD COLUMN currency_display TEXT DEFAULT ''`)
+	s.db.Exec(`ALTER TABLE user_preferences ADD COLUMN digest_frequency TEXT DEFAULT ''`)
+
+	// Filter lists and notified product IDs used to be JSON arrays on the subscription row
+	if err := s.migrateSubscriptionLists(); err != nil {
+		return fmt.Errorf("failed to migrate subscription lists: %w", err)
+	}
+
+	// AirPods, HomePod, Apple TV and displays used to be filed as Accessory
+	if err := s.migrateAccessoryCategories(); err != nil {
+		return fmt.Errorf("failed to migrate accessory categories: %w", err)
+	}
+
+	// Product IDs used to hash the part number together with the listing title
+	if err := s.migrateStableIDs(); err != nil {
+		return fmt.Errorf("failed to migrate product IDs: %w", err)
+	}
+
+	// Products written before the spec_* filter columns existed
+	if err := s.migrateSpecIndex(); err != nil {
+		return fmt.Errorf("failed to index product specs: %w", err)
+	}
+
+	// Every Bark Key in use becomes an (unregistered) user
+	if _, err := s.db.Exec(`
+		INSERT OR IGNORE INTO users (id, name, bark_key, api_key_hash, created_at)
+		SELECT 'usr-' || lower(hex(randomblob(8))), '', bark_key, '', ?
+		FROM (
+			SELECT bark_key FROM subscriptions
+			UNION SELECT bark_key FROM new_arrival_subscriptions
+			UNION SELECT bark_key FROM user_preferences
+		)
+		WHERE bark_key IS NOT NULL AND bark_key != ''
+	`, time.Now().Unix()); err != nil {
+		return fmt.Errorf("failed to migrate bark keys to users: %w", err)
+	}
+
+	// Add rendered message columns to notification_history
+	s.db.Exec(`ALTER TABLE notification_history ADD COLUMN title TEXT`)
+	s.db.Exec(`ALTER TABLE notification_history ADD COLUMN body TEXT`)
+	s.db.Exec(`ALTER TABLE notification_history ADD COLUMN channel_params TEXT`)
+
+	// Delivery channel and latency (event detection to delivery)
+	s.db.Exec(`ALTER TABLE notification_history ADD COLUMN channel TEXT`)
+	s.db.Exec(`ALTER TABLE notification_history ADD COLUMN delivery_latency_ms INTEGER`)
+	s.db.Exec(`ALTER TABLE notification_retries ADD COLUMN detected_at INTEGER`)
+
+	// Scrape runs are recorded whatever their outcome; older rows were all successful
+	s.db.Exec(`ALTER TABLE scrape_runs ADD COLUMN run_id TEXT DEFAULT ''`)
+	s.db.Exec(`ALTER TABLE scrape_runs ADD COLUMN triggered_by TEXT DEFAULT ''`)
+	s.db.Exec(`ALTER TABLE scrape_runs ADD COLUMN status TEXT DEFAULT 'success'`)
+	s.db.Exec(`ALTER TABLE scrape_runs ADD COLUMN error TEXT DEFAULT ''`)
+	s.db.Exec(`ALTER TABLE scrape_runs ADD COLUMN duration_ms INTEGER DEFAULT 0`)
+	s.db.Exec(`ALTER TABLE scrape_runs ADD COLUMN scope_region TEXT DEFAULT ''`)
+	s.db.Exec(`ALTER TABLE scrape_runs ADD COLUMN scope_category TEXT DEFAULT ''`)
+
+	// Failed detail fetches are tracked on their queue entry
+	s.db.Exec(`ALTER TABLE pending_details ADD COLUMN attempts INTEGER DEFAULT 0`)
+	s.db.Exec(`ALTER TABLE pending_details ADD COLUMN last_error TEXT DEFAULT ''`)
+	s.db.Exec(`ALTER TABLE pending_details ADD COLUMN next_attempt_at INTEGER`)
+	s.db.Exec(`ALTER TABLE pending_details ADD COLUMN given_up INTEGER DEFAULT 0`)
+	s.db.Exec(`ALTER TABLE scrape_run_categories ADD COLUMN new_count INTEGER DEFAULT 0`)
+	s.db.Exec(`ALTER TABLE scrape_run_categories ADD COLUMN changed_count INTEGER DEFAULT 0`)
+	s.db.Exec(`ALTER TABLE scrape_run_categories ADD COLUMN removed_count INTEGER DEFAULT 0`)
+	s.db.Exec(`CREATE INDEX IF NOT EXISTS idx_scrape_runs_run_id ON scrape_runs(run_id)`)
+
 	// Remove email column from new_arrival_subscriptions if it exists (migration)
 	s.db.Exec(`ALTER TABLE new_arrival_subscriptions DROP COLUMN email`)
 
+	// Category pages scraped per region, comma-separated (empty = the defaults)
+	s.db.Exec(`ALTER TABLE regions ADD COLUMN categories TEXT DEFAULT ''`)
+
 	// SQLite doesn't support "IF NOT EXISTS" for ALTER TABLE, so we ignore the error
 	// if the column already exists
 
+	// Seed the region registry on first run only, so deleted defaults stay deleted
+	var regionCount int
+	if err := s.db.QueryRow("SELECT COUNT(*) FROM regions").Scan(&regionCount); err == nil && regionCount == 0 {
+		for _, r := range model.DefaultRegions() {
+			if err := s.upsertRegion(r); err != nil {
+				return fmt.Errorf("failed to seed regions: %w", err)
+			}
+		}
+	}
+
 	return nil
 }
 
+// prepared returns the cached prepared statement for query, preparing it on first use
+func (s *SQLiteStore) prepared(query string) (*sql.Stmt, error) {
+	s.stmtsMu.Lock()
+	defer s.stmtsMu.Unlock()
+
+	if stmt, ok := s.stmts[query]; ok {
+		return stmt, nil
+	}
+	stmt, err := s.db.Prepare(query)
+	if err != nil {
+		return nil, err
+	}
+	s.stmts[query] = stmt
+	return stmt, nil
+}
+
+// queryPrepared runs a cached prepared query
+func (s *SQLiteStore) queryPrepared(query string, args ...any) (*sql.Rows, error) {
+	stmt, err := s.prepared(query)
+	if err != nil {
+		return nil, err
+	}
+	return stmt.Query(args...)
+}
+
+// queryRowPrepared runs a cached prepared single-row query
+func (s *SQLiteStore) queryRowPrepared(query string, args ...any) *sql.Row {
+	stmt, err := s.prepared(query)
+	if err != nil {
+		// Surface the prepare error through Scan
+		return s.db.QueryRow(query, args...)
+	}
+	return stmt.QueryRow(args...)
+}
+
 // GetAllProducts returns all products
 func (s *SQLiteStore) GetAllProducts() []*model.Product {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
-
-	rows, err := s.db.Query(`
+	rows, err := s.queryPrepared(`
 		SELECT id, name, category, region, price, original_price, discount,
-		       image_url, product_url, specs, specs_detail, description, stock_status, value_score,
-		       lowest_price, highest_price, price_trend, created_at, updated_at
-		FROM products
+		       image_url, product_url, specs, specs_detail, description, stock_status, grade, warranty_months, battery_health, dominant_color, part_number, value_score,
+		       lowest_price, highest_price, price_trend, volatility, drop_streak, stability, created_at, updated_at
+		FROM products WHERE COALESCE(archived, 0) = 0
 		ORDER BY updated_at DESC
 	`)
 	if err != nil {
@@ -231,14 +723,16 @@ func (s *SQLiteStore) GetAllProducts() []*model.Product {
 	for rows.Next() {
 		p := &model.Product{}
 		var created, updated int64
-		var lowest, highest sql.NullFloat64
+		var lowest, highest, volatility sql.NullFloat64
 		var trend sql.NullString
 		var specsDetail, description sql.NullString
+		var grade, stability, dominantColor, partNumber sql.NullString
+		var warrantyMonths, batteryHealth, dropStreak sql.NullInt64
 
 		err := rows.Scan(
 			&p.ID, &p.Name, &p.Category, &p.Region, &p.Price, &p.OriginalPrice,
 			&p.Discount, &p.ImageURL, &p.ProductURL, &p.Specs, &specsDetail, &description, &p.StockStatus,
-			&p.ValueScore, &lowest, &highest, &trend, &created, &updated,
+			&grade, &warrantyMonths, &batteryHealth, &dominantColor, &partNumber, &p.ValueScore, &lowest, &highest, &trend, &volatility, &dropStreak, &stability, &created, &updated,
 		)
 		if err != nil {
 			continue
@@ -261,6 +755,15 @@ func (s *SQLiteStore) GetAllProducts() []*model.Product {
 			p.PriceTrend = trend.String
 		}
 
+		p.Grade = grade.String
+		p.WarrantyMonths = int(warrantyMonths.Int64)
+		p.BatteryHealth = int(batteryHealth.Int64)
+		p.DominantColor = dominantColor.String
+		p.PartNumber = partNumber.String
+		p.Volatility = volatility.Float64
+		p.DropStreak = int(dropStreak.Int64)
+		p.Stability = stability.String
+
 		p.CreatedAt = time.Unix(created, 0)
 		p.UpdatedAt = time.Unix(updated, 0)
 		products = append(products, p)
@@ -271,24 +774,23 @@ func (s *SQLiteStore) GetAllProducts() []*model.Product {
 
 // GetProduct returns a product by ID
 func (s *SQLiteStore) GetProduct(id string) (*model.Product, bool) {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
-
 	p := &model.Product{}
 	var created, updated int64
-	var lowest, highest sql.NullFloat64
+	var lowest, highest, volatility sql.NullFloat64
 	var trend sql.NullString
 	var specsDetail, description sql.NullString
+	var grade, stability, dominantColor, partNumber sql.NullString
+	var warrantyMonths, batteryHealth, dropStreak sql.NullInt64
 
-	err := s.db.QueryRow(`
+	err := s.queryRowPrepared(`
 		SELECT id, name, category, region, price, original_price, discount,
-		       image_url, product_url, specs, specs_detail, description, stock_status, value_score,
-		       lowest_price, highest_price, price_trend, created_at, updated_at
+		       image_url, product_url, specs, specs_detail, description, stock_status, grade, warranty_months, battery_health, dominant_color, part_number, value_score,
+		       lowest_price, highest_price, price_trend, volatility, drop_streak, stability, COALESCE(archived, 0), created_at, updated_at
 		FROM products WHERE id = ?
 	`, id).Scan(
 		&p.ID, &p.Name, &p.Category, &p.Region, &p.Price, &p.OriginalPrice,
 		&p.Discount, &p.ImageURL, &p.ProductURL, &p.Specs, &specsDetail, &description, &p.StockStatus,
-		&p.ValueScore, &lowest, &highest, &trend, &created, &updated,
+		&grade, &warrantyMonths, &batteryHealth, &dominantColor, &partNumber, &p.ValueScore, &lowest, &highest, &trend, &volatility, &dropStreak, &stability, &p.Archived, &created, &updated,
 	)
 
 	if err == sql.ErrNoRows {
@@ -314,6 +816,15 @@ func (s *SQLiteStore) GetProduct(id string) (*model.Product, bool) {
 		p.PriceTrend = trend.String
 	}
 
+	p.Grade = grade.String
+	p.WarrantyMonths = int(warrantyMonths.Int64)
+	p.BatteryHealth = int(batteryHealth.Int64)
+	p.DominantColor = dominantColor.String
+	p.PartNumber = partNumber.String
+	p.Volatility = volatility.Float64
+	p.DropStreak = int(dropStreak.Int64)
+	p.Stability = stability.String
+
 	p.CreatedAt = time.Unix(created, 0)
 	p.UpdatedAt = time.Unix(updated, 0)
 
@@ -322,14 +833,11 @@ func (s *SQLiteStore) GetProduct(id string) (*model.Product, bool) {
 
 // GetProductsByCategory returns products filtered by category
 func (s *SQLiteStore) GetProductsByCategory(category string) []*model.Product {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
-
-	rows, err := s.db.Query(`
+	rows, err := s.queryPrepared(`
 		SELECT id, name, category, region, price, original_price, discount,
-		       image_url, product_url, specs, specs_detail, description, stock_status, value_score,
-		       lowest_price, highest_price, price_trend, created_at, updated_at
-		FROM products WHERE category = ?
+		       image_url, product_url, specs, specs_detail, description, stock_status, grade, warranty_months, battery_health, dominant_color, part_number, value_score,
+		       lowest_price, highest_price, price_trend, volatility, drop_streak, stability, created_at, updated_at
+		FROM products WHERE category = ? AND COALESCE(archived, 0) = 0
 		ORDER BY updated_at DESC
 	`, category)
 	if err != nil {
@@ -341,14 +849,16 @@ func (s *SQLiteStore) GetProductsByCategory(category string) []*model.Product {
 	for rows.Next() {
 		p := &model.Product{}
 		var created, updated int64
-		var lowest, highest sql.NullFloat64
+		var lowest, highest, volatility sql.NullFloat64
 		var trend sql.NullString
 		var specsDetail, description sql.NullString
+		var grade, stability, dominantColor, partNumber sql.NullString
+		var warrantyMonths, batteryHealth, dropStreak sql.NullInt64
 
 		err := rows.Scan(
 			&p.ID, &p.Name, &p.Category, &p.Region, &p.Price, &p.OriginalPrice,
 			&p.Discount, &p.ImageURL, &p.ProductURL, &p.Specs, &specsDetail, &description, &p.StockStatus,
-			&p.ValueScore, &lowest, &highest, &trend, &created, &updated,
+			&grade, &warrantyMonths, &batteryHealth, &dominantColor, &partNumber, &p.ValueScore, &lowest, &highest, &trend, &volatility, &dropStreak, &stability, &created, &updated,
 		)
 		if err != nil {
 			continue
@@ -370,6 +880,15 @@ func (s *SQLiteStore) GetProductsByCategory(category string) []*model.Product {
 			p.PriceTrend = trend.String
 		}
 
+		p.Grade = grade.String
+		p.WarrantyMonths = int(warrantyMonths.Int64)
+		p.BatteryHealth = int(batteryHealth.Int64)
+		p.DominantColor = dominantColor.String
+		p.PartNumber = partNumber.String
+		p.Volatility = volatility.Float64
+		p.DropStreak = int(dropStreak.Int64)
+		p.Stability = stability.String
+
 		p.CreatedAt = time.Unix(created, 0)
 		p.UpdatedAt = time.Unix(updated, 0)
 		products = append(products, p)
@@ -380,14 +899,11 @@ func (s *SQLiteStore) GetProductsByCategory(category string) []*model.Product {
 
 // GetProductsByRegion returns products filtered by region
 func (s *SQLiteStore) GetProductsByRegion(region string) []*model.Product {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
-
-	rows, err := s.db.Query(`
+	rows, err := s.queryPrepared(`
 		SELECT id, name, category, region, price, original_price, discount,
-		       image_url, product_url, specs, specs_detail, description, stock_status, value_score,
-		       lowest_price, highest_price, price_trend, created_at, updated_at
-		FROM products WHERE region = ?
+		       image_url, product_url, specs, specs_detail, description, stock_status, grade, warranty_months, battery_health, dominant_color, part_number, value_score,
+		       lowest_price, highest_price, price_trend, volatility, drop_streak, stability, created_at, updated_at
+		FROM products WHERE region = ? AND COALESCE(archived, 0) = 0
 		ORDER BY updated_at DESC
 	`, region)
 	if err != nil {
@@ -399,14 +915,16 @@ func (s *SQLiteStore) GetProductsByRegion(region string) []*model.Product {
 	for rows.Next() {
 		p := &model.Product{}
 		var created, updated int64
-		var lowest, highest sql.NullFloat64
+		var lowest, highest, volatility sql.NullFloat64
 		var trend sql.NullString
 		var specsDetail, description sql.NullString
+		var grade, stability, dominantColor, partNumber sql.NullString
+		var warrantyMonths, batteryHealth, dropStreak sql.NullInt64
 
 		err := rows.Scan(
 			&p.ID, &p.Name, &p.Category, &p.Region, &p.Price, &p.OriginalPrice,
 			&p.Discount, &p.ImageURL, &p.ProductURL, &p.Specs, &specsDetail, &description, &p.StockStatus,
-			&p.ValueScore, &lowest, &highest, &trend, &created, &updated,
+			&grade, &warrantyMonths, &batteryHealth, &dominantColor, &partNumber, &p.ValueScore, &lowest, &highest, &trend, &volatility, &dropStreak, &stability, &created, &updated,
 		)
 		if err != nil {
 			continue
@@ -428,6 +946,15 @@ func (s *SQLiteStore) GetProductsByRegion(region string) []*model.Product {
 			p.PriceTrend = trend.String
 		}
 
+		p.Grade = grade.String
+		p.WarrantyMonths = int(warrantyMonths.Int64)
+		p.BatteryHealth = int(batteryHealth.Int64)
+		p.DominantColor = dominantColor.String
+		p.PartNumber = partNumber.String
+		p.Volatility = volatility.Float64
+		p.DropStreak = int(dropStreak.Int64)
+		p.Stability = stability.String
+
 		p.CreatedAt = time.Unix(created, 0)
 		p.UpdatedAt = time.Unix(updated, 0)
 		products = append(products, p)
@@ -440,18 +967,121 @@ func (s *SQLiteStore) GetProductsByRegion(region string) []*model.Product {
 func (s *SQLiteStore) UpsertProduct(product *model.Product) (priceChanged bool, oldPrice float64) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
+	defer s.productsVersion.Add(1)
+
+	var rows upsertRows
+	priceChanged, oldPrice = s.upsertProductLocked(s.db, &rows, product, time.Now())
+	if err := rows.flush(s.db); err != nil {
+		slog.Error("Failed to record product history", "product_id", product.ID, "error", err)
+	}
+	return priceChanged, oldPrice
+}
+
+// UpsertProducts upserts the products of a scrape cycle in one transaction,
+// so hundreds of products cost a single commit instead of one per statement,
+// and writes their history and event rows with multi-row INSERTs. Results are
+// in the order of products.
+func (s *SQLiteStore) UpsertProducts(products []*model.Product) ([]model.UpsertResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	defer s.productsVersion.Add(1)
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
 
 	now := time.Now()
+	var rows upsertRows
+	results := make([]model.UpsertResult, len(products))
+	for i, product := range products {
+		results[i].PriceChanged, results[i].OldPrice = s.upsertProductLocked(tx, &rows, product, now)
+	}
+	if err := rows.flush(tx); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit upserts: %w", err)
+	}
+	return results, nil
+}
+
+// dbtx is satisfied by both *sql.DB and *sql.Tx
+type dbtx interface {
+	execer
+	Query(query string, args ...any) (*sql.Rows, error)
+	QueryRow(query string, args ...any) *sql.Row
+}
+
+// upsertRowsChunk is how many rows one INSERT of upsertRows.flush writes,
+// well below SQLite's limit on bound parameters
+const upsertRowsChunk = 200
+
+// upsertRows collects the price history and event rows of upserts, which
+// flush writes in a few multi-row INSERTs instead of one per row
+type upsertRows struct {
+	history []model.PriceHistory
+	events  []model.ProductEvent
+}
+
+// flush inserts the collected rows through db
+func (r *upsertRows) flush(db execer) error {
+	for start := 0; start < len(r.history); start += upsertRowsChunk {
+		chunk := r.history[start:min(start+upsertRowsChunk, len(r.history))]
+		args := make([]any, 0, 4*len(chunk))
+		for _, h := range chunk {
+			args = append(args, h.ProductID, h.Price, h.Discount, h.Timestamp.Unix())
+		}
+		if _, err := db.Exec(`
+			INSERT INTO price_history (product_id, price, discount, recorded_at) VALUES `+rowPlaceholders(len(chunk), 4), args...); err != nil {
+			return fmt.Errorf("failed to insert price history: %w", err)
+		}
+	}
+
+	for start := 0; start < len(r.events); start += upsertRowsChunk {
+		chunk := r.events[start:min(start+upsertRowsChunk, len(r.events))]
+		args := make([]any, 0, 4*len(chunk))
+		for _, e := range chunk {
+			args = append(args, e.ProductID, e.EventType, e.Price, e.CreatedAt.Unix())
+		}
+		if _, err := db.Exec(`
+			INSERT INTO product_events (product_id, event_type, price, created_at) VALUES `+rowPlaceholders(len(chunk), 4), args...); err != nil {
+			return fmt.Errorf("failed to insert product events: %w", err)
+		}
+	}
+	return nil
+}
+
+// rowPlaceholders returns the VALUES placeholders of n rows of columns each
+func rowPlaceholders(n, columns int) string {
+	row := "(" + strings.TrimSuffix(strings.Repeat("?, ", columns), ", ") + ")"
+	return strings.TrimSuffix(strings.Repeat(row+", ", n), ", ")
+}
+
+// upsertProductLocked adds or updates a product through db, which may be a
+// transaction, and adds its history and event rows to rows; the caller holds
+// s.mu and flushes rows
+func (s *SQLiteStore) upsertProductLocked(db dbtx, rows *upsertRows, product *model.Product, now time.Time) (priceChanged bool, oldPrice float64) {
+	// Corrections pinned by an admin win over scraped values
+	if override, ok := s.GetProductOverride(product.ID); ok {
+		override.Apply(product)
+	}
 
 	// Check if product exists
 	var existingPrice sql.NullFloat64
-	err := s.db.QueryRow("SELECT price FROM products WHERE id = ?", product.ID).Scan(&existingPrice)
+	var existingStatus sql.NullString
+	err := db.QueryRow("SELECT price, stock_status FROM products WHERE id = ?", product.ID).Scan(&existingPrice, &existingStatus)
+
+	// Lifecycle event to record once the product row is written
+	eventType := ""
 
 	if err == sql.ErrNoRows {
 		// New product
 		product.CreatedAt = now
 		priceChanged = false
 		oldPrice = 0
+		eventType = model.EventListed
 	} else if err != nil {
 		// Error
 		return false, 0
@@ -459,47 +1089,63 @@ func (s *SQLiteStore) UpsertProduct(product *model.Product) (priceChanged bool,
 		// Existing product - always set oldPrice to distinguish from new products
 		oldPrice = existingPrice.Float64
 
+		// Record stock transitions for inventory velocity
+		if existingStatus.String != product.StockStatus {
+			eventType = product.StockStatus
+		}
+
 		if existingPrice.Float64 != product.Price {
 			priceChanged = true
-
-			// Add to history
-			_, _ = s.db.Exec(`
-				INSERT INTO price_history (product_id, price, discount, recorded_at)
-				VALUES (?, ?, ?, ?)
-			`, product.ID, existingPrice.Float64, product.Discount, now.Unix())
 		}
 
 			// Preserve created_at
 		var created int64
-		_ = s.db.QueryRow("SELECT created_at FROM products WHERE id = ?", product.ID).Scan(&created)
+		_ = db.QueryRow("SELECT created_at FROM products WHERE id = ?", product.ID).Scan(&created)
 		product.CreatedAt = time.Unix(created, 0)
 
 		// Preserve existing description and specs_detail if new ones are empty
 		// This prevents the main scraper from overwriting data collected by detail scraper
 		var existingDesc sql.NullString
 		var existingSpecsDetail sql.NullString
-		_ = s.db.QueryRow("SELECT description, specs_detail FROM products WHERE id = ?", product.ID).Scan(&existingDesc, &existingSpecsDetail)
+		var existingGrade, existingImage, existingColor sql.NullString
+		var existingWarranty, existingBattery sql.NullInt64
+		_ = db.QueryRow("SELECT description, specs_detail, grade, warranty_months, battery_health, image_url, dominant_color FROM products WHERE id = ?", product.ID).
+			Scan(&existingDesc, &existingSpecsDetail, &existingGrade, &existingWarranty, &existingBattery, &existingImage, &existingColor)
 		if product.Description == "" && existingDesc.Valid && existingDesc.String != "" {
 			product.Description = existingDesc.String
 		}
 		if product.SpecsDetail == "" && existingSpecsDetail.Valid && existingSpecsDetail.String != "" {
 			product.SpecsDetail = existingSpecsDetail.String
 		}
-
-		// Calculate value score based on history
-		history := s.getPriceHistoryLocked(product.ID)
+		product.KeepRefurbTerms(&model.Product{
+			Grade:          existingGrade.String,
+			WarrantyMonths: int(existingWarranty.Int64),
+			BatteryHealth:  int(existingBattery.Int64),
+		})
+		product.KeepDominantColor(&model.Product{
+			ImageURL:      existingImage.String,
+			DominantColor: existingColor.String,
+		})
+
+		// Calculate value score based on history, including the entry this change adds
+		history := readPriceHistory(db, product.ID)
+		if priceChanged {
+			entry := model.PriceHistory{ProductID: product.ID, Price: existingPrice.Float64, Discount: product.Discount, Timestamp: now}
+			history = append(history, entry)
+			rows.history = append(rows.history, entry)
+		}
 		product.ValueScore = s.CalculateValueScore(product, history)
-		s.updateProductStats(product.ID, history)
+		updateProductStats(product, history)
 	}
 
 	product.UpdatedAt = now
 
-	_, err = s.db.Exec(`
+	_, err = db.Exec(`
 		INSERT INTO products (
 			id, name, category, region, price, original_price, discount,
-			image_url, product_url, specs, specs_detail, description, stock_status, value_score,
-			lowest_price, highest_price, price_trend, created_at, updated_at
-		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+			image_url, product_url, specs, specs_detail, description, stock_status, grade, warranty_months, battery_health, dominant_color, part_number, value_score,
+			lowest_price, highest_price, price_trend, volatility, drop_streak, stability, created_at, updated_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 		ON CONFLICT(id) DO UPDATE SET
 			name = excluded.name,
 			category = excluded.category,
@@ -513,307 +1159,1962 @@ func (s *SQLiteStore) UpsertProduct(product *model.Product) (priceChanged bool,
 			specs_detail = excluded.specs_detail,
 			description = excluded.description,
 			stock_status = excluded.stock_status,
+			grade = excluded.grade,
+			warranty_months = excluded.warranty_months,
+			battery_health = excluded.battery_health,
+			dominant_color = excluded.dominant_color,
+			part_number = COALESCE(NULLIF(excluded.part_number, ''), part_number),
 			value_score = excluded.value_score,
 			lowest_price = excluded.lowest_price,
 			highest_price = excluded.highest_price,
 			price_trend = excluded.price_trend,
+			volatility = excluded.volatility,
+			drop_streak = excluded.drop_streak,
+			stability = excluded.stability,
 			updated_at = excluded.updated_at
 	`, product.ID, product.Name, product.Category, product.Region, product.Price,
 		product.OriginalPrice, product.Discount, product.ImageURL, product.ProductURL,
-		product.Specs, product.SpecsDetail, product.Description, product.StockStatus, product.ValueScore,
+		product.Specs, product.SpecsDetail, product.Description, product.StockStatus,
+		product.Grade, product.WarrantyMonths, product.BatteryHealth, product.DominantColor, product.PartNumber, product.ValueScore,
 		product.LowestPrice, product.HighestPrice, product.PriceTrend,
+		product.Volatility, product.DropStreak, product.Stability,
 		product.CreatedAt.Unix(), product.UpdatedAt.Unix())
 
+	if err == nil {
+		err = writeSpecIndex(db, product)
+	}
+
+	if err == nil && eventType != "" {
+		rows.events = append(rows.events, model.ProductEvent{ProductID: product.ID, EventType: eventType, Price: product.Price, CreatedAt: now})
+	}
+
 	if err != nil {
-		fmt.Printf("[SQLiteStore] ERROR upserting product %s: %v\n", product.ID, err)
+		slog.Error("Failed to upsert product", "product_id", product.ID, "error", err)
 	} else if product.Description != "" {
-		fmt.Printf("[SQLiteStore] Successfully upserted product %s with description: %d chars\n", product.ID, len(product.Description))
+		slog.Debug("Upserted product with description", "product_id", product.ID, "description_chars", len(product.Description))
 	}
 
 	return priceChanged, oldPrice
 }
 
-// GetPriceHistory returns price history for a product
-func (s *SQLiteStore) GetPriceHistory(productID string) []model.PriceHistory {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
-	return s.getPriceHistoryLocked(productID)
+// ProductsVersion changes whenever a product is added, updated or removed, so
+// readers can tell whether data derived from the catalog is still current
+func (s *SQLiteStore) ProductsVersion() uint64 {
+	return s.productsVersion.Load()
 }
 
-// getPriceHistoryLocked returns price history WITHOUT acquiring lock (must be called with lock already held)
-func (s *SQLiteStore) getPriceHistoryLocked(productID string) []model.PriceHistory {
-	rows, err := s.db.Query(`
-		SELECT product_id, price, discount, recorded_at
-		FROM price_history
+// UpdateStockStatus changes a product's stock status and records the transition
+func (s *SQLiteStore) UpdateStockStatus(id, status string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	defer s.productsVersion.Add(1)
+
+	var current string
+	var price float64
+	err := s.db.QueryRow("SELECT stock_status, price FROM products WHERE id = ?", id).Scan(&current, &price)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("product not found")
+	}
+	if err != nil {
+		return err
+	}
+	if current == status {
+		return nil
+	}
+
+	now := time.Now().Unix()
+	if _, err := s.db.Exec("UPDATE products SET stock_status = ?, updated_at = ? WHERE id = ?", status, now, id); err != nil {
+		return err
+	}
+
+	_, err = s.db.Exec(`
+		INSERT INTO product_events (product_id, event_type, price, created_at)
+		VALUES (?, ?, ?, ?)
+	`, id, status, price, now)
+	return err
+}
+
+// GetProductEvents returns lifecycle events for a product in chronological order
+func (s *SQLiteStore) GetProductEvents(productID string) []model.ProductEvent {
+	rows, err := s.queryPrepared(`
+		SELECT product_id, event_type, price, created_at
+		FROM product_events
 		WHERE product_id = ?
-		ORDER BY recorded_at ASC
+		ORDER BY created_at ASC, id ASC
 	`, productID)
 	if err != nil {
-		return []model.PriceHistory{}
+		return []model.ProductEvent{}
 	}
 	defer rows.Close()
 
-	var history []model.PriceHistory
+	events := []model.ProductEvent{}
 	for rows.Next() {
-		var h model.PriceHistory
-		var recorded int64
-		err := rows.Scan(&h.ProductID, &h.Price, &h.Discount, &recorded)
-		if err != nil {
+		var e model.ProductEvent
+		var created int64
+		if err := rows.Scan(&e.ProductID, &e.EventType, &e.Price, &created); err != nil {
 			continue
 		}
-		h.Timestamp = time.Unix(recorded, 0)
-		h.ProductID = productID
-		history = append(history, h)
+		e.CreatedAt = time.Unix(created, 0)
+		events = append(events, e)
+	}
+
+	return events
+}
+
+// GetInventoryVelocity returns sell-out velocity per model/config computed from
+// product events, rebuilt only after products changed
+func (s *SQLiteStore) GetInventoryVelocity() model.InventoryVelocityIndex {
+	return s.velocity.get(s.productsVersion.Load(), s.buildInventoryVelocity)
+}
+
+// buildInventoryVelocity aggregates all product events into the velocity index
+func (s *SQLiteStore) buildInventoryVelocity() model.InventoryVelocityIndex {
+	rows, err := s.db.Query(`
+		SELECT e.product_id, e.event_type, e.price, e.created_at,
+		       p.category, p.region, p.specs_detail
+		FROM product_events e
+		JOIN products p ON p.id = e.product_id
+		ORDER BY e.product_id, e.created_at, e.id
+	`)
+	if err != nil {
+		return model.InventoryVelocityIndex{}
+	}
+	defer rows.Close()
+
+	var events []model.ProductEvent
+	products := make(map[string]*model.Product)
+	for rows.Next() {
+		var e model.ProductEvent
+		var created int64
+		var category, region string
+		var specsDetail sql.NullString
+		if err := rows.Scan(&e.ProductID, &e.EventType, &e.Price, &created, &category, &region, &specsDetail); err != nil {
+			continue
+		}
+		e.CreatedAt = time.Unix(created, 0)
+		events = append(events, e)
+
+		if _, ok := products[e.ProductID]; !ok {
+			products[e.ProductID] = &model.Product{
+				ID:          e.ProductID,
+				Category:    category,
+				Region:      region,
+				SpecsDetail: specsDetail.String,
+			}
+		}
+	}
+
+	return model.BuildInventoryVelocity(events, products)
+}
+
+// GetRegions returns the region registry ordered by code
+func (s *SQLiteStore) GetRegions() []*model.Region {
+	rows, err := s.db.Query(`
+		SELECT code, name, base_url, currency, COALESCE(categories, ''), enabled, created_at, updated_at
+		FROM regions ORDER BY code ASC
+	`)
+	if err != nil {
+		return []*model.Region{}
+	}
+	defer rows.Close()
+
+	regions := []*model.Region{}
+	for rows.Next() {
+		r := &model.Region{}
+		var categories string
+		var enabled int
+		var created, updated int64
+		if err := rows.Scan(&r.Code, &r.Name, &r.BaseURL, &r.Currency, &categories, &enabled, &created, &updated); err != nil {
+			continue
+		}
+		r.Categories = splitCategories(categories)
+		r.Enabled = enabled == 1
+		r.CreatedAt = time.Unix(created, 0)
+		r.UpdatedAt = time.Unix(updated, 0)
+		regions = append(regions, r)
+	}
+
+	return regions
+}
+
+// GetRegion returns a region by code
+func (s *SQLiteStore) GetRegion(code string) (*model.Region, bool) {
+	r := &model.Region{}
+	var categories string
+	var enabled int
+	var created, updated int64
+	err := s.db.QueryRow(`
+		SELECT code, name, base_url, currency, COALESCE(categories, ''), enabled, created_at, updated_at
+		FROM regions WHERE code = ?
+	`, code).Scan(&r.Code, &r.Name, &r.BaseURL, &r.Currency, &categories, &enabled, &created, &updated)
+	if err != nil {
+		return nil, false
+	}
+	r.Categories = splitCategories(categories)
+	r.Enabled = enabled == 1
+	r.CreatedAt = time.Unix(created, 0)
+	r.UpdatedAt = time.Unix(updated, 0)
+
+	return r, true
+}
+
+// UpsertRegion adds or replaces a region in the registry
+func (s *SQLiteStore) UpsertRegion(region *model.Region) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.upsertRegion(region)
+}
+
+// upsertRegion writes a registry row; caller holds the lock (or runs during migration)
+func (s *SQLiteStore) upsertRegion(region *model.Region) error {
+	enabled := 0
+	if region.Enabled {
+		enabled = 1
+	}
+
+	_, err := s.db.Exec(`
+		INSERT INTO regions (code, name, base_url, currency, categories, enabled, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(code) DO UPDATE SET
+			name = excluded.name,
+			base_url = excluded.base_url,
+			currency = excluded.currency,
+			categories = excluded.categories,
+			enabled = excluded.enabled,
+			updated_at = excluded.updated_at
+	`, region.Code, region.Name, region.BaseURL, region.Currency, strings.Join(region.Categories, ","), enabled,
+		region.CreatedAt.Unix(), region.UpdatedAt.Unix())
+
+	return err
+}
+
+// splitCategories reads the comma-separated categories column of a region
+func splitCategories(categories string) []string {
+	if categories == "" {
+		return nil
+	}
+	return strings.Split(categories, ",")
+}
+
+// DeleteRegion removes a region from the registry; its products are left untouched
+func (s *SQLiteStore) DeleteRegion(code string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	result, err := s.db.Exec("DELETE FROM regions WHERE code = ?", code)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return fmt.Errorf("region not found")
+	}
+	return nil
+}
+
+// AddWatchlist stores a new watchlist and its items
+func (s *SQLiteStore) AddWatchlist(watchlist *model.Watchlist) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`
+		INSERT INTO watchlists (id, client_token, name, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?)
+	`, watchlist.ID, watchlist.ClientToken, watchlist.Name, watchlist.CreatedAt.Unix(), watchlist.UpdatedAt.Unix()); err != nil {
+		return err
+	}
+	for i, productID := range watchlist.ProductIDs {
+		if _, err := tx.Exec(`
+			INSERT OR IGNORE INTO watchlist_items (watchlist_id, product_id, position)
+			VALUES (?, ?, ?)
+		`, watchlist.ID, productID, i); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// GetWatchlists returns the watchlists of a client token, oldest first
+func (s *SQLiteStore) GetWatchlists(clientToken string) []*model.Watchlist {
+	return s.queryWatchlists("WHERE client_token = ? ORDER BY created_at ASC, id ASC", clientToken)
+}
+
+// GetWatchlist returns a watchlist by ID
+func (s *SQLiteStore) GetWatchlist(id string) (*model.Watchlist, bool) {
+	watchlists := s.queryWatchlists("WHERE id = ?", id)
+	if len(watchlists) == 0 {
+		return nil, false
+	}
+	return watchlists[0], true
+}
+
+// queryWatchlists loads watchlists matching a WHERE clause together with their items
+func (s *SQLiteStore) queryWatchlists(where string, args ...any) []*model.Watchlist {
+	rows, err := s.db.Query("SELECT id, client_token, name, created_at, updated_at FROM watchlists "+where, args...)
+	if err != nil {
+		return []*model.Watchlist{}
+	}
+
+	watchlists := []*model.Watchlist{}
+	byID := make(map[string]*model.Watchlist)
+	for rows.Next() {
+		w := &model.Watchlist{ProductIDs: []string{}}
+		var created, updated int64
+		if err := rows.Scan(&w.ID, &w.ClientToken, &w.Name, &created, &updated); err != nil {
+			continue
+		}
+		w.CreatedAt = time.Unix(created, 0)
+		w.UpdatedAt = time.Unix(updated, 0)
+		watchlists = append(watchlists, w)
+		byID[w.ID] = w
+	}
+	rows.Close()
+
+	if len(watchlists) == 0 {
+		return watchlists
+	}
+
+	items, err := s.db.Query(`
+		SELECT watchlist_id, product_id FROM watchlist_items
+		WHERE watchlist_id IN (SELECT id FROM watchlists `+where+`)
+		ORDER BY watchlist_id, position
+	`, args...)
+	if err != nil {
+		return watchlists
+	}
+	defer items.Close()
+
+	for items.Next() {
+		var watchlistID, productID string
+		if items.Scan(&watchlistID, &productID) == nil {
+			if w := byID[watchlistID]; w != nil {
+				w.ProductIDs = append(w.ProductIDs, productID)
+			}
+		}
+	}
+	return watchlists
+}
+
+// DeleteWatchlist removes a watchlist; its items go with it via FK cascade
+func (s *SQLiteStore) DeleteWatchlist(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	result, err := s.db.Exec("DELETE FROM watchlists WHERE id = ?", id)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return fmt.Errorf("watchlist not found")
+	}
+	return nil
+}
+
+// AddAnnotation adds a price chart annotation
+func (s *SQLiteStore) AddAnnotation(annotation *model.PriceAnnotation) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, err := s.db.Exec(`
+		INSERT INTO price_annotations (id, date, label, description, category, region, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`, annotation.ID, annotation.Date, annotation.Label, annotation.Description,
+		annotation.Category, annotation.Region, annotation.CreatedAt.Unix())
+
+	return err
+}
+
+// DeleteAnnotation removes a price chart annotation
+func (s *SQLiteStore) DeleteAnnotation(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	result, err := s.db.Exec("DELETE FROM price_annotations WHERE id = ?", id)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return fmt.Errorf("annotation not found")
+	}
+	return nil
+}
+
+// GetAnnotations returns annotations dated between from and to (YYYY-MM-DD, inclusive;
+// empty = unbounded), ordered by date
+func (s *SQLiteStore) GetAnnotations(from, to string) []model.PriceAnnotation {
+	query := "SELECT id, date, label, description, category, region, created_at FROM price_annotations WHERE 1=1"
+	var args []interface{}
+	if from != "" {
+		query += " AND date >= ?"
+		args = append(args, from)
+	}
+	if to != "" {
+		query += " AND date <= ?"
+		args = append(args, to)
+	}
+	query += " ORDER BY date ASC, created_at ASC"
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return []model.PriceAnnotation{}
+	}
+	defer rows.Close()
+
+	annotations := []model.PriceAnnotation{}
+	for rows.Next() {
+		var a model.PriceAnnotation
+		var description, category, region sql.NullString
+		var created int64
+		if err := rows.Scan(&a.ID, &a.Date, &a.Label, &description, &category, &region, &created); err != nil {
+			continue
+		}
+		a.Description = description.String
+		a.Category = category.String
+		a.Region = region.String
+		a.CreatedAt = time.Unix(created, 0)
+		annotations = append(annotations, a)
+	}
+
+	return annotations
+}
+
+// GetPriceHistory returns price history for a product
+func (s *SQLiteStore) GetPriceHistory(productID string) []model.PriceHistory {
+	return s.getPriceHistoryLocked(productID)
+}
+
+// getPriceHistoryLocked returns price history, with days compacted into
+// price_history_daily as their closing price. It never takes s.mu, so write
+// paths holding the lock can call it too.
+func (s *SQLiteStore) getPriceHistoryLocked(productID string) []model.PriceHistory {
+	rows, err := s.queryPrepared(priceHistoryQuery, productID, productID)
+	if err != nil {
+		return []model.PriceHistory{}
+	}
+	return scanPriceHistory(rows, productID)
+}
+
+// readPriceHistory is getPriceHistoryLocked through db, so a transaction
+// sees the history rows it has written itself
+func readPriceHistory(db dbtx, productID string) []model.PriceHistory {
+	rows, err := db.Query(priceHistoryQuery, productID, productID)
+	if err != nil {
+		return []model.PriceHistory{}
+	}
+	return scanPriceHistory(rows, productID)
+}
+
+// priceHistoryQuery selects the history of a product, compacted days first.
+// Parameters: product ID, product ID.
+const priceHistoryQuery = `
+	SELECT product_id, price, discount, recorded_at FROM (
+		SELECT product_id, close_price AS price, close_discount AS discount, close_at AS recorded_at, 0 AS id
+		FROM price_history_daily
+		WHERE product_id = ?
+		UNION ALL
+		SELECT product_id, price, discount, recorded_at, id
+		FROM price_history
+		WHERE product_id = ?
+	)
+	ORDER BY recorded_at ASC, id ASC
+`
+
+// scanPriceHistory reads and closes the rows of priceHistoryQuery
+func scanPriceHistory(rows *sql.Rows, productID string) []model.PriceHistory {
+	defer rows.Close()
+
+	var history []model.PriceHistory
+	for rows.Next() {
+		var h model.PriceHistory
+		var recorded int64
+		err := rows.Scan(&h.ProductID, &h.Price, &h.Discount, &recorded)
+		if err != nil {
+			continue
+		}
+		h.Timestamp = time.Unix(recorded, 0)
+		h.ProductID = productID
+		history = append(history, h)
 	}
 
 	return history
 }
 
-// GetCategories returns all unique categories
-func (s *SQLiteStore) GetCategories() []string {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
+// priceStatsPoints is the price history of a product (compacted days as their
+// closing price) plus its current price as the latest point, with each point's
+// predecessor, successor time and island of equal-to-current runs.
+// Parameters: product ID, product ID, current price, now, current price.
+const priceStatsPoints = `
+	WITH points AS (
+		SELECT close_price AS price, close_at AS t, 0 AS seq FROM price_history_daily WHERE product_id = ?
+		UNION ALL SELECT price, recorded_at, id FROM price_history WHERE product_id = ?
+		UNION ALL SELECT ?, ?, 9223372036854775807
+	),
+	ordered AS (
+		SELECT price, t,
+			LAG(price) OVER w AS prev_price,
+			LEAD(t) OVER w AS next_t,
+			ROW_NUMBER() OVER w - ROW_NUMBER() OVER (PARTITION BY price = ? ORDER BY t, seq) AS island
+		FROM points
+		WINDOW w AS (ORDER BY t, seq)
+	)`
+
+// GetPriceStats computes price statistics for a product in SQL from price_history
+func (s *SQLiteStore) GetPriceStats(productID string) (*model.PriceStats, bool) {
+	var current float64
+	if err := s.db.QueryRow("SELECT price FROM products WHERE id = ?", productID).Scan(&current); err != nil {
+		return nil, false
+	}
+
+	now := time.Now()
+	args := []any{productID, productID, current, now.Unix(), current}
+	stats := &model.PriceStats{ProductID: productID, CurrentPrice: current}
+
+	var below int
+	var lastChange sql.NullInt64
+	var first int64
+	err := s.db.QueryRow(priceStatsPoints+`
+		SELECT MIN(price), MAX(price), AVG(price), COUNT(*),
+			COALESCE(SUM(prev_price IS NOT NULL AND price < prev_price), 0),
+			COALESCE(SUM(price < ?), 0),
+			MAX(CASE WHEN prev_price IS NOT NULL AND price <> prev_price THEN t END),
+			MIN(t)
+		FROM ordered
+	`, append(args, current)...).Scan(&stats.MinPrice, &stats.MaxPrice, &stats.AvgPrice, &stats.Points,
+		&stats.Drops, &below, &lastChange, &first)
+	if err != nil {
+		slog.Error("Failed to compute price stats", "product_id", productID, "error", err)
+		return nil, false
+	}
+
+	var longest int64
+	_ = s.db.QueryRow(priceStatsPoints+`
+		SELECT COALESCE(MAX(span), 0) FROM (
+			SELECT SUM(COALESCE(next_t, t) - t) AS span FROM ordered WHERE price = ? GROUP BY island
+		)
+	`, append(args, current)...).Scan(&longest)
+
+	_ = s.db.QueryRow(priceStatsPoints+`
+		SELECT AVG(price) FROM (SELECT price FROM ordered ORDER BY price LIMIT 2 - ? % 2 OFFSET (? - 1) / 2)
+	`, append(args, stats.Points, stats.Points)...).Scan(&stats.MedianPrice)
+
+	since := first
+	if lastChange.Valid {
+		at := time.Unix(lastChange.Int64, 0)
+		stats.LastChangeAt = &at
+		since = lastChange.Int64
+	}
+	stats.AvgPrice = math.Round(stats.AvgPrice*100) / 100
+	stats.LongestStreakDays = math.Round(float64(longest)/86400*10) / 10
+	stats.DaysSinceLastChange = math.Round(float64(now.Unix()-since)/86400*10) / 10
+	stats.Percentile = math.Round(float64(below)/float64(stats.Points)*1000) / 10
+	return stats, true
+}
+
+// GetCategories returns all unique categories
+func (s *SQLiteStore) GetCategories() []string {
+	rows, err := s.db.Query("SELECT DISTINCT category FROM products WHERE COALESCE(archived, 0) = 0 ORDER BY category")
+	if err != nil {
+		return []string{}
+	}
+	defer rows.Close()
+
+	var categories []string
+	for rows.Next() {
+		var cat string
+		if rows.Scan(&cat) == nil {
+			categories = append(categories, cat)
+		}
+	}
+
+	return categories
+}
+
+// categorySortKeyPrefix prefixes the config keys holding per-category default sorts
+const categorySortKeyPrefix = "default_sort:"
+
+// GetCategoryDefaultSorts returns the default product sort configured per category
+func (s *SQLiteStore) GetCategoryDefaultSorts() map[string]string {
+	sorts := make(map[string]string)
+
+	rows, err := s.queryPrepared("SELECT key, value FROM config WHERE substr(key, 1, ?) = ?", len(categorySortKeyPrefix), categorySortKeyPrefix)
+	if err != nil {
+		return sorts
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var key string
+		var value sql.NullString
+		if rows.Scan(&key, &value) == nil && value.String != "" {
+			sorts[strings.TrimPrefix(key, categorySortKeyPrefix)] = value.String
+		}
+	}
+	return sorts
+}
+
+// SetCategoryDefaultSort sets a category's default product sort; an empty sortBy removes it
+func (s *SQLiteStore) SetCategoryDefaultSort(category, sortBy string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := categorySortKeyPrefix + category
+	if sortBy == "" {
+		_, err := s.db.Exec("DELETE FROM config WHERE key = ?", key)
+		return err
+	}
+	_, err := s.db.Exec(`
+		INSERT INTO config (key, value) VALUES (?, ?)
+		ON CONFLICT(key) DO UPDATE SET value = excluded.value
+	`, key, sortBy)
+	return err
+}
+
+// AddSubscription adds a new subscription
+func (s *SQLiteStore) AddSubscription(sub *model.Subscription) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, err := s.db.Exec(`
+		INSERT INTO subscriptions (id, product_id, bark_key, target_price, quiet_hours_start, quiet_hours_end, timezone, low_stock_alert, bark_server, language, paused, notification_count, last_notified_at, updated_at, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, sub.ID, sub.ProductID, sub.BarkKey, sub.TargetPrice, sub.QuietHoursStart, sub.QuietHoursEnd, sub.Timezone, sub.LowStockAlert, sub.BarkServer, sub.Language,
+		sub.Paused, sub.NotificationCount, unixOrNull(sub.LastNotifiedAt), unixOrNull(sub.UpdatedAt), sub.CreatedAt.Unix())
+
+	return err
+}
+
+// UpdateSubscription replaces the settings of a price subscription. Its product,
+// Bark Key and notification counters are kept.
+func (s *SQLiteStore) UpdateSubscription(sub *model.Subscription) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	res, err := s.db.Exec(`
+		UPDATE subscriptions
+		SET target_price = ?, quiet_hours_start = ?, quiet_hours_end = ?, timezone = ?,
+		    low_stock_alert = ?, bark_server = ?, language = ?, paused = ?, updated_at = ?
+		WHERE id = ?
+	`, sub.TargetPrice, sub.QuietHoursStart, sub.QuietHoursEnd, sub.Timezone,
+		sub.LowStockAlert, sub.BarkServer, sub.Language, sub.Paused, unixOrNull(sub.UpdatedAt), sub.ID)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("subscription not found")
+	}
+	return nil
+}
+
+// SetSubscriptionPaused pauses or resumes a price subscription
+func (s *SQLiteStore) SetSubscriptionPaused(id string, paused bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	res, err := s.db.Exec("UPDATE subscriptions SET paused = ?, updated_at = ? WHERE id = ?", paused, time.Now().Unix(), id)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("subscription not found")
+	}
+	return nil
+}
+
+// RemoveSubscription removes a subscription
+func (s *SQLiteStore) RemoveSubscription(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, err := s.db.Exec("DELETE FROM subscriptions WHERE id = ?", id)
+	return err
+}
+
+// PreviewRegionDeletion returns what DeleteProductsByRegion would archive, without archiving
+func (s *SQLiteStore) PreviewRegionDeletion(region string) *model.RegionDeletion {
+	d := s.summarizeListed("region = ?", region)
+	d.Region = region
+	return d
+}
+
+// DeleteProductsByRegion archives all listed products of a region, which hides their
+// price history, subscriptions and events with them. The deletion can be undone for
+// RegionDeletionGracePeriod; later on the products can still be restored one by one.
+func (s *SQLiteStore) DeleteProductsByRegion(region string) (*model.RegionDeletion, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	defer s.productsVersion.Add(1)
+
+	deletion := s.summarizeListed("region = ?", region)
+	deletion.Region = region
+	if err := s.archiveForDeletionLocked(deletion, "region = ?", region); err != nil {
+		return nil, err
+	}
+
+	return deletion, nil
+}
+
+// ArchiveProductsByRegion hides all products of a region from listings, keeping
+// their price history, events and subscriptions. Returns the number archived.
+func (s *SQLiteStore) ArchiveProductsByRegion(region string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	defer s.productsVersion.Add(1)
+
+	// Bumping updated_at carries the change to replicas
+	res, err := s.db.Exec("UPDATE products SET archived = 1, updated_at = ? WHERE region = ? AND COALESCE(archived, 0) = 0", time.Now().Unix(), region)
+	if err != nil {
+		return 0, fmt.Errorf("failed to archive products: %w", err)
+	}
+	count, _ := res.RowsAffected()
+	return int(count), nil
+}
+
+// RestoreProducts brings archived products back into listings. Returns the number restored.
+func (s *SQLiteStore) RestoreProducts(ids []string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	defer s.productsVersion.Add(1)
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	restored, err := restoreProducts(tx, ids, time.Now())
+	if err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit restore: %w", err)
+	}
+	return restored, nil
+}
+
+// restoreProducts unarchives the listed products and returns how many were archived
+func restoreProducts(db execer, ids []string, now time.Time) (int, error) {
+	restored := 0
+	for _, id := range ids {
+		res, err := db.Exec("UPDATE products SET archived = 0, updated_at = ? WHERE id = ? AND archived = 1", now.Unix(), id)
+		if err != nil {
+			return 0, fmt.Errorf("failed to restore product %s: %w", id, err)
+		}
+		count, _ := res.RowsAffected()
+		restored += int(count)
+	}
+	return restored, nil
+}
+
+// GetArchivedProducts returns the archived products of a region (all regions if empty)
+func (s *SQLiteStore) GetArchivedProducts(region string) []*model.Product {
+	rows, err := s.db.Query(`
+		SELECT id, name, category, region, price, original_price, discount,
+		       image_url, product_url, specs, specs_detail, description, stock_status, grade, warranty_months, battery_health, dominant_color, part_number, value_score,
+		       lowest_price, highest_price, price_trend, volatility, drop_streak, stability, created_at, updated_at
+		FROM products WHERE archived = 1 AND (? = '' OR region = ?)
+		ORDER BY updated_at DESC
+	`, region, region)
+	if err != nil {
+		return []*model.Product{}
+	}
+	defer rows.Close()
+
+	products := []*model.Product{}
+	for rows.Next() {
+		p := &model.Product{Archived: true}
+		var created, updated int64
+		var lowest, highest, volatility sql.NullFloat64
+		var trend, specsDetail, description, grade, stability, dominantColor, partNumber sql.NullString
+		var warrantyMonths, batteryHealth, dropStreak sql.NullInt64
+
+		if err := rows.Scan(
+			&p.ID, &p.Name, &p.Category, &p.Region, &p.Price, &p.OriginalPrice,
+			&p.Discount, &p.ImageURL, &p.ProductURL, &p.Specs, &specsDetail, &description, &p.StockStatus,
+			&grade, &warrantyMonths, &batteryHealth, &dominantColor, &partNumber, &p.ValueScore, &lowest, &highest, &trend, &volatility, &dropStreak, &stability, &created, &updated,
+		); err != nil {
+			continue
+		}
+
+		p.SpecsDetail = specsDetail.String
+		p.Description = description.String
+		p.LowestPrice = lowest.Float64
+		p.HighestPrice = highest.Float64
+		p.PriceTrend = trend.String
+		p.Grade = grade.String
+		p.WarrantyMonths = int(warrantyMonths.Int64)
+		p.BatteryHealth = int(batteryHealth.Int64)
+		p.DominantColor = dominantColor.String
+		p.PartNumber = partNumber.String
+		p.Volatility = volatility.Float64
+		p.DropStreak = int(dropStreak.Int64)
+		p.Stability = stability.String
+		p.CreatedAt = time.Unix(created, 0)
+		p.UpdatedAt = time.Unix(updated, 0)
+		products = append(products, p)
+	}
+
+	return products
+}
+
+// PreviewStaleDeletion returns what DeleteStaleProducts would archive, without archiving
+func (s *SQLiteStore) PreviewStaleDeletion(before time.Time) *model.RegionDeletion {
+	d := s.summarizeListed("updated_at < ?", before.Unix())
+	d.StaleBefore = &before
+	return d
+}
+
+// DeleteStaleProducts archives listed products not updated by a scrape since before.
+// Like a region deletion, it can be undone for RegionDeletionGracePeriod.
+func (s *SQLiteStore) DeleteStaleProducts(before time.Time) (*model.RegionDeletion, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	defer s.productsVersion.Add(1)
+
+	cutoff := before.Unix()
+	deletion := s.summarizeListed("updated_at < ?", cutoff)
+	deletion.StaleBefore = &before
+	if err := s.archiveForDeletionLocked(deletion, "updated_at < ?", cutoff); err != nil {
+		return nil, err
+	}
+
+	return deletion, nil
+}
+
+// summarizeListed counts the listed products matching where (a condition on the
+// products table with one placeholder) and the records archiving them would hide
+func (s *SQLiteStore) summarizeListed(where string, arg any) *model.RegionDeletion {
+	d := &model.RegionDeletion{}
+	listed := "SELECT id FROM products WHERE COALESCE(archived, 0) = 0 AND " + where
+	_ = s.db.QueryRow("SELECT COUNT(*) FROM ("+listed+")", arg).Scan(&d.Products)
+	_ = s.db.QueryRow("SELECT COUNT(*) FROM price_history WHERE product_id IN ("+listed+")", arg).Scan(&d.PriceHistory)
+	_ = s.db.QueryRow("SELECT COUNT(*) FROM subscriptions WHERE product_id IN ("+listed+")", arg).Scan(&d.Subscriptions)
+	_ = s.db.QueryRow("SELECT COUNT(*) FROM product_events WHERE product_id IN ("+listed+")", arg).Scan(&d.Events)
+	return d
+}
+
+// archiveForDeletionLocked archives the listed products matching where and records the
+// deletion in region_deletions, with the archived IDs as payload for undo. Caller must hold s.mu.
+func (s *SQLiteStore) archiveForDeletionLocked(deletion *model.RegionDeletion, where string, arg any) error {
+	now := time.Now()
+	newRegionDeletion(deletion, now)
+	if deletion.Products == 0 {
+		return nil
+	}
+
+	var ids []string
+	rows, err := s.db.Query("SELECT id FROM products WHERE COALESCE(archived, 0) = 0 AND "+where, arg)
+	if err != nil {
+		return fmt.Errorf("failed to list products: %w", err)
+	}
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err == nil {
+			ids = append(ids, id)
+		}
+	}
+	rows.Close()
+
+	payload, err := json.Marshal(ids)
+	if err != nil {
+		return fmt.Errorf("failed to marshal product IDs: %w", err)
+	}
+
+	var staleBefore sql.NullInt64
+	if deletion.StaleBefore != nil {
+		staleBefore = sql.NullInt64{Int64: deletion.StaleBefore.Unix(), Valid: true}
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM region_deletions WHERE expires_at < ?", now.Unix()); err != nil {
+		return fmt.Errorf("failed to purge expired deletions: %w", err)
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO region_deletions (id, region, stale_before, products, price_history, subscriptions, events, payload, deleted_at, expires_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, deletion.ID, deletion.Region, staleBefore, deletion.Products, deletion.PriceHistory, deletion.Subscriptions, deletion.Events,
+		string(payload), deletion.DeletedAt.Unix(), deletion.ExpiresAt.Unix())
+	if err != nil {
+		return fmt.Errorf("failed to record deletion: %w", err)
+	}
+
+	// Bumping updated_at carries the change to replicas
+	for _, id := range ids {
+		if _, err := tx.Exec("UPDATE products SET archived = 1, updated_at = ? WHERE id = ?", now.Unix(), id); err != nil {
+			return fmt.Errorf("failed to archive product %s: %w", id, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit deletion: %w", err)
+	}
+
+	return nil
+}
+
+// UndoRegionDeletion restores the products archived by a deletion within its grace period
+func (s *SQLiteStore) UndoRegionDeletion(id string) (*model.RegionDeletion, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	defer s.productsVersion.Add(1)
+
+	now := time.Now()
+	var payload string
+	var deletedAt, expiresAt int64
+	var staleBefore sql.NullInt64
+	deletion := &model.RegionDeletion{ID: id}
+	err := s.db.QueryRow(`
+		SELECT region, stale_before, products, price_history, subscriptions, events, payload, deleted_at, expires_at
+		FROM region_deletions WHERE id = ? AND expires_at >= ?
+	`, id, now.Unix()).Scan(&deletion.Region, &staleBefore, &deletion.Products, &deletion.PriceHistory,
+		&deletion.Subscriptions, &deletion.Events, &payload, &deletedAt, &expiresAt)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("region deletion not found or expired")
+	}
+	if err != nil {
+		return nil, err
+	}
+	deletion.StaleBefore = unixPtr(staleBefore)
+	deletion.DeletedAt = time.Unix(deletedAt, 0)
+	deletion.ExpiresAt = time.Unix(expiresAt, 0)
+
+	var ids []string
+	if err := json.Unmarshal([]byte(payload), &ids); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal product IDs: %w", err)
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := restoreProducts(tx, ids, now); err != nil {
+		return nil, err
+	}
+
+	if _, err := tx.Exec("DELETE FROM region_deletions WHERE id = ?", id); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit undo: %w", err)
+	}
+
+	return deletion, nil
+}
+
+// GetRegionDeletions returns region deletions that can still be undone, newest first
+func (s *SQLiteStore) GetRegionDeletions() []*model.RegionDeletion {
+	rows, err := s.db.Query(`
+		SELECT id, region, stale_before, products, price_history, subscriptions, events, deleted_at, expires_at
+		FROM region_deletions WHERE expires_at >= ?
+		ORDER BY deleted_at DESC
+	`, time.Now().Unix())
+	if err != nil {
+		return []*model.RegionDeletion{}
+	}
+	defer rows.Close()
+
+	deletions := []*model.RegionDeletion{}
+	for rows.Next() {
+		d := &model.RegionDeletion{}
+		var deletedAt, expiresAt int64
+		var staleBefore sql.NullInt64
+		if err := rows.Scan(&d.ID, &d.Region, &staleBefore, &d.Products, &d.PriceHistory, &d.Subscriptions,
+			&d.Events, &deletedAt, &expiresAt); err != nil {
+			continue
+		}
+		d.StaleBefore = unixPtr(staleBefore)
+		d.DeletedAt = time.Unix(deletedAt, 0)
+		d.ExpiresAt = time.Unix(expiresAt, 0)
+		deletions = append(deletions, d)
+	}
+
+	return deletions
+}
+
+// unixPtr converts a nullable Unix timestamp column
+func unixPtr(v sql.NullInt64) *time.Time {
+	if !v.Valid {
+		return nil
+	}
+	t := time.Unix(v.Int64, 0)
+	return &t
+}
+
+// unixOrNull is the value of a nullable Unix timestamp column: NULL for the zero time
+func unixOrNull(t time.Time) any {
+	if t.IsZero() {
+		return nil
+	}
+	return t.Unix()
+}
+
+// GetAllSubscriptions returns all subscriptions
+func (s *SQLiteStore) GetAllSubscriptions() []*model.Subscription {
+	rows, err := s.queryPrepared(`
+		SELECT id, product_id, bark_key, target_price, quiet_hours_start, quiet_hours_end, timezone, low_stock_alert, bark_server, language, paused, notification_count, last_notified_at, updated_at, created_at
+		FROM subscriptions
+		ORDER BY created_at DESC
+	`)
+	if err != nil {
+		return []*model.Subscription{}
+	}
+	defer rows.Close()
+
+	var subs []*model.Subscription
+	for rows.Next() {
+		sub := &model.Subscription{}
+		var created int64
+		var targetPrice sql.NullFloat64
+		var quietStart, quietEnd, timezone, barkServer, language sql.NullString
+		var lastNotified, updated sql.NullInt64
+		err := rows.Scan(&sub.ID, &sub.ProductID, &sub.BarkKey, &targetPrice, &quietStart, &quietEnd, &timezone, &sub.LowStockAlert, &barkServer, &language, &sub.Paused, &sub.NotificationCount, &lastNotified, &updated, &created)
+		if err != nil {
+			continue
+		}
+		if targetPrice.Valid {
+			sub.TargetPrice = targetPrice.Float64
+		}
+		sub.QuietHoursStart = quietStart.String
+		sub.QuietHoursEnd = quietEnd.String
+		sub.Timezone = timezone.String
+		sub.BarkServer = barkServer.String
+		sub.Language = language.String
+		sub.CreatedAt = time.Unix(created, 0)
+		if lastNotified.Valid {
+			sub.LastNotifiedAt = time.Unix(lastNotified.Int64, 0)
+		}
+		if updated.Valid && updated.Int64 > 0 {
+			sub.UpdatedAt = time.Unix(updated.Int64, 0)
+		}
+		subs = append(subs, sub)
+	}
+
+	return subs
+}
+
+// GetSubscriptionsByProduct returns all subscriptions for a product
+func (s *SQLiteStore) GetSubscriptionsByProduct(productID string) []*model.Subscription {
+	rows, err := s.queryPrepared(`
+		SELECT id, product_id, bark_key, target_price, quiet_hours_start, quiet_hours_end, timezone, low_stock_alert, bark_server, language, paused, notification_count, last_notified_at, updated_at, created_at
+		FROM subscriptions
+		WHERE product_id = ?
+		ORDER BY created_at DESC
+	`, productID)
+	if err != nil {
+		return []*model.Subscription{}
+	}
+	defer rows.Close()
+
+	var subs []*model.Subscription
+	for rows.Next() {
+		sub := &model.Subscription{}
+		var created int64
+		var targetPrice sql.NullFloat64
+		var quietStart, quietEnd, timezone, barkServer, language sql.NullString
+		var lastNotified, updated sql.NullInt64
+		err := rows.Scan(&sub.ID, &sub.ProductID, &sub.BarkKey, &targetPrice, &quietStart, &quietEnd, &timezone, &sub.LowStockAlert, &barkServer, &language, &sub.Paused, &sub.NotificationCount, &lastNotified, &updated, &created)
+		if err != nil {
+			continue
+		}
+		if targetPrice.Valid {
+			sub.TargetPrice = targetPrice.Float64
+		}
+		sub.QuietHoursStart = quietStart.String
+		sub.QuietHoursEnd = quietEnd.String
+		sub.Timezone = timezone.String
+		sub.BarkServer = barkServer.String
+		sub.Language = language.String
+		sub.CreatedAt = time.Unix(created, 0)
+		if lastNotified.Valid {
+			sub.LastNotifiedAt = time.Unix(lastNotified.Int64, 0)
+		}
+		if updated.Valid && updated.Int64 > 0 {
+			sub.UpdatedAt = time.Unix(updated.Int64, 0)
+		}
+		subs = append(subs, sub)
+	}
+
+	return subs
+}
+
+// GetSubscriptionsByBarkKey returns the price subscriptions of a Bark Key
+func (s *SQLiteStore) GetSubscriptionsByBarkKey(barkKey string) []*model.Subscription {
+	rows, err := s.db.Query(`
+		SELECT id, product_id, bark_key, target_price, quiet_hours_start, quiet_hours_end, timezone, low_stock_alert, bark_server, language, paused, notification_count, last_notified_at, updated_at, created_at
+		FROM subscriptions
+		WHERE bark_key = ?
+		ORDER BY created_at DESC
+	`, barkKey)
+	if err != nil {
+		return []*model.Subscription{}
+	}
+	defer rows.Close()
+
+	subs := []*model.Subscription{}
+	for rows.Next() {
+		sub := &model.Subscription{}
+		var created int64
+		var targetPrice sql.NullFloat64
+		var quietStart, quietEnd, timezone, barkServer, language sql.NullString
+		var lastNotified, updated sql.NullInt64
+		err := rows.Scan(&sub.ID, &sub.ProductID, &sub.BarkKey, &targetPrice, &quietStart, &quietEnd, &timezone, &sub.LowStockAlert, &barkServer, &language, &sub.Paused, &sub.NotificationCount, &lastNotified, &updated, &created)
+		if err != nil {
+			continue
+		}
+		if targetPrice.Valid {
+			sub.TargetPrice = targetPrice.Float64
+		}
+		sub.QuietHoursStart = quietStart.String
+		sub.QuietHoursEnd = quietEnd.String
+		sub.Timezone = timezone.String
+		sub.BarkServer = barkServer.String
+		sub.Language = language.String
+		sub.CreatedAt = time.Unix(created, 0)
+		if lastNotified.Valid {
+			sub.LastNotifiedAt = time.Unix(lastNotified.Int64, 0)
+		}
+		if updated.Valid && updated.Int64 > 0 {
+			sub.UpdatedAt = time.Unix(updated.Int64, 0)
+		}
+		subs = append(subs, sub)
+	}
+
+	return subs
+}
+
+// GetSubscription returns a price subscription by ID
+func (s *SQLiteStore) GetSubscription(id string) (*model.Subscription, bool) {
+	sub := &model.Subscription{}
+	var created int64
+	var targetPrice sql.NullFloat64
+	var quietStart, quietEnd, timezone, barkServer, language sql.NullString
+	var lastNotified, updated sql.NullInt64
+	err := s.queryRowPrepared(`
+		SELECT id, product_id, bark_key, target_price, quiet_hours_start, quiet_hours_end, timezone, low_stock_alert, bark_server, language, paused, notification_count, last_notified_at, updated_at, created_at
+		FROM subscriptions
+		WHERE id = ?
+	`, id).Scan(&sub.ID, &sub.ProductID, &sub.BarkKey, &targetPrice, &quietStart, &quietEnd, &timezone, &sub.LowStockAlert, &barkServer, &language, &sub.Paused, &sub.NotificationCount, &lastNotified, &updated, &created)
+	if err != nil {
+		return nil, false
+	}
+	if targetPrice.Valid {
+		sub.TargetPrice = targetPrice.Float64
+	}
+	sub.QuietHoursStart = quietStart.String
+	sub.QuietHoursEnd = quietEnd.String
+	sub.Timezone = timezone.String
+	sub.BarkServer = barkServer.String
+	sub.Language = language.String
+	sub.CreatedAt = time.Unix(created, 0)
+	if lastNotified.Valid {
+		sub.LastNotifiedAt = time.Unix(lastNotified.Int64, 0)
+	}
+	if updated.Valid && updated.Int64 > 0 {
+		sub.UpdatedAt = time.Unix(updated.Int64, 0)
+	}
+	return sub, true
+}
+
+// UpdateLastScrapeTime updates the last scrape timestamp
+func (s *SQLiteStore) UpdateLastScrapeTime(t time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lastScrapeTime = t
+}
+
+// GetLastScrapeTime returns the last scrape timestamp
+func (s *SQLiteStore) GetLastScrapeTime() time.Time {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.lastScrapeTime
+}
+
+// GetStats returns system statistics
+func (s *SQLiteStore) GetStats() *model.Stats {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	stats := &model.Stats{
+		Categories:         make(map[string]int),
+		LastScrapeTime:     s.lastScrapeTime,
+	}
+
+	// Total products
+	_ = s.db.QueryRow("SELECT COUNT(*) FROM products WHERE COALESCE(archived, 0) = 0").Scan(&stats.TotalProducts)
+
+	// Available products
+	_ = s.db.QueryRow("SELECT COUNT(*) FROM products WHERE stock_status = 'available' AND COALESCE(archived, 0) = 0").Scan(&stats.AvailableProducts)
+
+	// Categories
+	rows, _ := s.db.Query("SELECT category, COUNT(*) FROM products WHERE COALESCE(archived, 0) = 0 GROUP BY category")
+	if rows != nil {
+		defer rows.Close()
+		for rows.Next() {
+			var cat string
+			var count int
+			if rows.Scan(&cat, &count) == nil {
+				stats.Categories[cat] = count
+			}
+		}
+	}
+
+	// Total subscriptions
+	_ = s.db.QueryRow("SELECT COUNT(*) FROM subscriptions").Scan(&stats.TotalSubscriptions)
+
+	// Scraper status
+	scraperStatus := &model.ScraperStatus{}
+	var lastTime sql.NullInt64
+	var scrapeErr sql.NullString
+	var productsScraped sql.NullInt64
+	var duration sql.NullInt64
+
+	err := s.db.QueryRow(`
+		SELECT last_scrape_time, last_scrape_status, last_scrape_error,
+			   products_scraped, duration_ms
+		FROM scraper_status WHERE id = 1
+	`).Scan(&lastTime, &scraperStatus.LastScrapeStatus, &scrapeErr,
+		&productsScraped, &duration)
+
+	if err == nil {
+		if lastTime.Valid {
+			scraperStatus.LastScrapeTime = time.Unix(lastTime.Int64, 0)
+		}
+		if scrapeErr.Valid {
+			scraperStatus.LastScrapeError = scrapeErr.String
+		}
+		if productsScraped.Valid {
+			scraperStatus.ProductsScraped = int(productsScraped.Int64)
+		}
+		if duration.Valid {
+			scraperStatus.Duration = duration.Int64
+		}
+		stats.ScraperStatus = scraperStatus
+	} else {
+		// No status record yet
+		stats.ScraperStatus = &model.ScraperStatus{
+			LastScrapeStatus: "never",
+		}
+	}
+
+	// Retention
+	var historyRows, dailyHistoryRows, notificationRows int
+	_ = s.db.QueryRow("SELECT COUNT(*) FROM price_history").Scan(&historyRows)
+	_ = s.db.QueryRow("SELECT COUNT(*) FROM price_history_daily").Scan(&dailyHistoryRows)
+	_ = s.db.QueryRow("SELECT COUNT(*) FROM notification_history").Scan(&notificationRows)
+	stats.Retention = s.retention.stats(historyRows, dailyHistoryRows, notificationRows)
+
+	stats.DeliveryLatency = s.deliveryLatency(time.Now().Add(-model.DeliveryLatencyWindow))
+
+	return stats
+}
+
+// deliveryLatency summarizes the latency of notifications delivered since then, per channel.
+// Rows recorded before channels were tracked are Bark pushes.
+func (s *SQLiteStore) deliveryLatency(since time.Time) []model.ChannelLatency {
+	samples := make(map[string][]int64)
+	rows, err := s.db.Query(`
+		SELECT COALESCE(NULLIF(channel, ''), ?), delivery_latency_ms FROM notification_history
+		WHERE status = 'sent' AND delivery_latency_ms IS NOT NULL AND created_at >= ?
+	`, model.ChannelBark, since.Unix())
+	if err == nil {
+		defer rows.Close()
+		for rows.Next() {
+			var channel string
+			var latency int64
+			if rows.Scan(&channel, &latency) == nil {
+				samples[channel] = append(samples[channel], latency)
+			}
+		}
+	}
+	return model.SummarizeLatencies(samples)
+}
+
+// SetRetentionPolicy sets the row limits applied by EnforceRetention
+func (s *SQLiteStore) SetRetentionPolicy(policy model.RetentionPolicy) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.retention.policy = policy
+}
+
+// EnforceRetention drops the oldest price history per product and notification
+// history per Bark Key beyond the configured limits
+func (s *SQLiteStore) EnforceRetention() (int, int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var evictedHistory, evictedNotifications int64
+
+	if max := s.retention.policy.MaxHistoryPerProduct; max > 0 {
+		result, err := s.db.Exec(`
+			DELETE FROM price_history WHERE id IN (
+				SELECT id FROM (
+					SELECT id, ROW_NUMBER() OVER (
+						PARTITION BY product_id ORDER BY recorded_at DESC, id DESC
+					) AS rn
+					FROM price_history
+				) WHERE rn > ?
+			)
+		`, max)
+		if err != nil {
+			return 0, 0, fmt.Errorf("failed to evict price history: %w", err)
+		}
+		evictedHistory, _ = result.RowsAffected()
+	}
+
+	if max := s.retention.policy.MaxNotificationsPerKey; max > 0 {
+		result, err := s.db.Exec(`
+			DELETE FROM notification_history WHERE id IN (
+				SELECT id FROM (
+					SELECT id, ROW_NUMBER() OVER (
+						PARTITION BY bark_key ORDER BY created_at DESC, rowid DESC
+					) AS rn
+					FROM notification_history
+				) WHERE rn > ?
+			)
+		`, max)
+		if err != nil {
+			return int(evictedHistory), 0, fmt.Errorf("failed to evict notification history: %w", err)
+		}
+		evictedNotifications, _ = result.RowsAffected()
+	}
+
+	s.retention.record(int(evictedHistory), int(evictedNotifications), time.Now())
+	return int(evictedHistory), int(evictedNotifications), nil
+}
+
+// CompactPriceHistory folds raw price history recorded before the policy's
+// RawHistoryDays into one price_history_daily row per product and day (min, max
+// and closing price) and returns how many raw points were removed. Whole days
+// are compacted at once; a day compacted again is merged into its row.
+func (s *SQLiteStore) CompactPriceHistory(now time.Time) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	before := s.retention.compactBefore(now)
+	if before.IsZero() {
+		return 0, nil
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`
+		INSERT INTO price_history_daily (product_id, date, min_price, max_price, close_price, close_discount, close_at)
+		SELECT product_id, day, min_price, max_price, price, discount, recorded_at FROM (
+			SELECT product_id, price, discount, recorded_at,
+				date(recorded_at, 'unixepoch', 'localtime') AS day,
+				MIN(price) OVER day AS min_price,
+				MAX(price) OVER day AS max_price,
+				ROW_NUMBER() OVER (PARTITION BY product_id, date(recorded_at, 'unixepoch', 'localtime')
+					ORDER BY recorded_at DESC, id DESC) AS rn
+			FROM price_history
+			WHERE recorded_at < ?
+			WINDOW day AS (PARTITION BY product_id, date(recorded_at, 'unixepoch', 'localtime'))
+		) WHERE rn = 1
+		ON CONFLICT (product_id, date) DO UPDATE SET
+			min_price = MIN(min_price, excluded.min_price),
+			max_price = MAX(max_price, excluded.max_price),
+			close_price = CASE WHEN excluded.close_at >= close_at THEN excluded.close_price ELSE close_price END,
+			close_discount = CASE WHEN excluded.close_at >= close_at THEN excluded.close_discount ELSE close_discount END,
+			close_at = MAX(close_at, excluded.close_at)
+	`, before.Unix()); err != nil {
+		return 0, fmt.Errorf("failed to downsample price history: %w", err)
+	}
+
+	result, err := tx.Exec(`DELETE FROM price_history WHERE recorded_at < ?`, before.Unix())
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete compacted price history: %w", err)
+	}
+	compacted, _ := result.RowsAffected()
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+
+	s.retention.recordCompaction(int(compacted), now)
+	return int(compacted), nil
+}
+
+// RecordDailyStats stores today's per category/region aggregates and spec tier
+// price index, replacing earlier values recorded the same day
+func (s *SQLiteStore) RecordDailyStats(now time.Time) error {
+	products := s.GetAllProducts()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, p := range aggregateDailyStats(products, now) {
+		_, err := tx.Exec(`
+			INSERT INTO daily_stats (date, category, region, product_count, avg_price, avg_discount, new_listings, updated_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+			ON CONFLICT(date, category, region) DO UPDATE SET
+				product_count = excluded.product_count,
+				avg_price = excluded.avg_price,
+				avg_discount = excluded.avg_discount,
+				new_listings = excluded.new_listings,
+				updated_at = excluded.updated_at
+		`, p.Date, p.Category, p.Region, p.ProductCount, p.AvgPrice, p.AvgDiscount, p.NewListings, now.Unix())
+		if err != nil {
+			return fmt.Errorf("failed to record daily stats: %w", err)
+		}
+	}
+
+	for _, p := range aggregatePriceIndex(products, now) {
+		_, err := tx.Exec(`
+			INSERT INTO daily_price_index (date, tier, region, products, avg_price, price_per_gb, updated_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?)
+			ON CONFLICT(date, tier, region) DO UPDATE SET
+				products = excluded.products,
+				avg_price = excluded.avg_price,
+				price_per_gb = excluded.price_per_gb,
+				updated_at = excluded.updated_at
+		`, p.Date, p.Tier, p.Region, p.Products, p.AvgPrice, p.PricePerGB, now.Unix())
+		if err != nil {
+			return fmt.Errorf("failed to record price index: %w", err)
+		}
+	}
+
+	subscriptions := make(map[string]int)
+	rows, err := tx.Query("SELECT product_id, COUNT(*) FROM subscriptions GROUP BY product_id")
+	if err != nil {
+		return fmt.Errorf("failed to count subscriptions: %w", err)
+	}
+	for rows.Next() {
+		var productID string
+		var n int
+		if rows.Scan(&productID, &n) == nil {
+			subscriptions[productID] = n
+		}
+	}
+	rows.Close()
+
+	for _, snap := range aggregateStatsSnapshots(products, subscriptions, now) {
+		categories, _ := json.Marshal(snap.Categories)
+		_, err := tx.Exec(`
+			INSERT INTO stats_history (date, region, total_products, available_products, sold_out_products, avg_discount, new_listings, subscriptions, categories, updated_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+			ON CONFLICT(date, region) DO UPDATE SET
+				total_products = excluded.total_products,
+				available_products = excluded.available_products,
+				sold_out_products = excluded.sold_out_products,
+				avg_discount = excluded.avg_discount,
+				new_listings = excluded.new_listings,
+				subscriptions = excluded.subscriptions,
+				categories = excluded.categories,
+				updated_at = excluded.updated_at
+		`, snap.Date, snap.Region, snap.TotalProducts, snap.AvailableProducts, snap.SoldOutProducts,
+			snap.AvgDiscount, snap.NewListings, snap.Subscriptions, string(categories), now.Unix())
+		if err != nil {
+			return fmt.Errorf("failed to record stats snapshot: %w", err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// GetStatsHistory returns the daily catalog snapshots since the given day of one
+// region, or of the whole catalog when region is empty
+func (s *SQLiteStore) GetStatsHistory(region string, since time.Time) []model.StatsSnapshot {
+	rows, err := s.db.Query(`
+		SELECT date, region, total_products, available_products, sold_out_products, avg_discount, new_listings, subscriptions, categories
+		FROM stats_history WHERE region = ? AND date >= ?
+		ORDER BY date ASC
+	`, region, since.Format(dailyStatsDateFormat))
+	if err != nil {
+		return []model.StatsSnapshot{}
+	}
+	defer rows.Close()
+
+	snapshots := []model.StatsSnapshot{}
+	for rows.Next() {
+		var snap model.StatsSnapshot
+		var categories sql.NullString
+		if err := rows.Scan(&snap.Date, &snap.Region, &snap.TotalProducts, &snap.AvailableProducts, &snap.SoldOutProducts,
+			&snap.AvgDiscount, &snap.NewListings, &snap.Subscriptions, &categories); err != nil {
+			continue
+		}
+		snap.Categories = map[string]model.CategorySnapshot{}
+		if categories.Valid {
+			_ = json.Unmarshal([]byte(categories.String), &snap.Categories)
+		}
+		snapshots = append(snapshots, snap)
+	}
+
+	return snapshots
+}
+
+// GetPriceIndexTimeline returns the daily spec tier price index since the given
+// day, optionally of one region (empty = all)
+func (s *SQLiteStore) GetPriceIndexTimeline(region string, since time.Time) []model.DailyPriceIndex {
+	query := `SELECT date, tier, region, products, avg_price, price_per_gb
+		FROM daily_price_index WHERE date >= ?`
+	args := []interface{}{since.Format(dailyStatsDateFormat)}
+
+	if region != "" {
+		query += " AND region = ?"
+		args = append(args, region)
+	}
+	query += " ORDER BY date ASC, tier ASC, region ASC"
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return []model.DailyPriceIndex{}
+	}
+	defer rows.Close()
+
+	points := []model.DailyPriceIndex{}
+	for rows.Next() {
+		var p model.DailyPriceIndex
+		if err := rows.Scan(&p.Date, &p.Tier, &p.Region, &p.Products, &p.AvgPrice, &p.PricePerGB); err != nil {
+			continue
+		}
+		points = append(points, p)
+	}
+
+	return points
+}
+
+// GetStatsTimeline returns daily aggregates since the given day, optionally filtered
+// by category and region (empty = all)
+func (s *SQLiteStore) GetStatsTimeline(category, region string, since time.Time) []model.DailyCategoryStats {
+	query := `SELECT date, category, region, product_count, avg_price, avg_discount, new_listings
+		FROM daily_stats WHERE date >= ?`
+	args := []interface{}{since.Format(dailyStatsDateFormat)}
 
-	rows, err := s.db.Query("SELECT DISTINCT category FROM products ORDER BY category")
+	if category != "" {
+		query += " AND category = ?"
+		args = append(args, category)
+	}
+	if region != "" {
+		query += " AND region = ?"
+		args = append(args, region)
+	}
+	query += " ORDER BY date ASC, category ASC, region ASC"
+
+	rows, err := s.db.Query(query, args...)
 	if err != nil {
-		return []string{}
+		return []model.DailyCategoryStats{}
 	}
 	defer rows.Close()
 
-	var categories []string
+	points := []model.DailyCategoryStats{}
 	for rows.Next() {
-		var cat string
-		if rows.Scan(&cat) == nil {
-			categories = append(categories, cat)
+		var p model.DailyCategoryStats
+		if err := rows.Scan(&p.Date, &p.Category, &p.Region, &p.ProductCount,
+			&p.AvgPrice, &p.AvgDiscount, &p.NewListings); err != nil {
+			continue
 		}
+		points = append(points, p)
 	}
 
-	return categories
+	return points
 }
 
-// AddSubscription adds a new subscription
-func (s *SQLiteStore) AddSubscription(sub *model.Subscription) error {
+// RecordScrapeRun stores a scrape run and its per category counts, dropping the
+// oldest records beyond maxScrapeRuns
+func (s *SQLiteStore) RecordScrapeRun(run *model.ScrapeRun) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	_, err := s.db.Exec(`
-		INSERT INTO subscriptions (id, product_id, bark_key, target_price, created_at)
-		VALUES (?, ?, ?, ?, ?)
-	`, sub.ID, sub.ProductID, sub.BarkKey, sub.TargetPrice, sub.CreatedAt.Unix())
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
 
-	return err
-}
+	var scope model.ScrapeScope
+	if run.Scope != nil {
+		scope = *run.Scope
+	}
+	res, err := tx.Exec(`
+		INSERT INTO scrape_runs (started_at, finished_at, duration_ms, product_count, run_id, triggered_by, status, error,
+			scope_region, scope_category)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, run.StartedAt.Unix(), run.FinishedAt.Unix(), run.Duration, run.ProductCount, run.ID, run.Trigger, run.Status, run.Error,
+		scope.Region, scope.Category)
+	if err != nil {
+		return fmt.Errorf("failed to record scrape run: %w", err)
+	}
+	runID, err := res.LastInsertId()
+	if err != nil {
+		return err
+	}
 
-// RemoveSubscription removes a subscription
-func (s *SQLiteStore) RemoveSubscription(id string) error {
-	s.mu.Lock()
-	defer s.mu.Unlock()
+	for _, c := range run.Categories {
+		if _, err := tx.Exec(`
+			INSERT INTO scrape_run_categories (run_id, region, category, total, available, new_count, changed_count, removed_count)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		`, runID, c.Region, c.Category, c.Total, c.Available, c.New, c.Changed, c.Removed); err != nil {
+			return fmt.Errorf("failed to record scrape run counts: %w", err)
+		}
+	}
+	for _, p := range run.Pages {
+		if _, err := tx.Exec(`
+			INSERT OR REPLACE INTO scrape_run_pages (run_id, region, page, products, error, duration_ms)
+			VALUES (?, ?, ?, ?, ?, ?)
+		`, runID, p.Region, p.Page, p.Products, p.Error, p.Duration); err != nil {
+			return fmt.Errorf("failed to record scrape run pages: %w", err)
+		}
+	}
 
-	_, err := s.db.Exec("DELETE FROM subscriptions WHERE id = ?", id)
-	return err
+	if _, err := tx.Exec(`
+		DELETE FROM scrape_runs WHERE id NOT IN (SELECT id FROM scrape_runs ORDER BY id DESC LIMIT ?)
+	`, maxScrapeRuns); err != nil {
+		return fmt.Errorf("failed to trim scrape runs: %w", err)
+	}
+
+	return tx.Commit()
 }
 
-// DeleteProductsByRegion deletes all products from a specific region
-func (s *SQLiteStore) DeleteProductsByRegion(region string) (int, error) {
-	s.mu.Lock()
-	defer s.mu.Unlock()
+// GetScrapeRuns returns up to limit scrape run records, newest first
+func (s *SQLiteStore) GetScrapeRuns(limit int) []*model.ScrapeRun {
+	return s.queryScrapeRuns(`ORDER BY id DESC LIMIT ?`, limit)
+}
 
-	result, err := s.db.Exec("DELETE FROM products WHERE region = ?", region)
-	if err != nil {
-		return 0, err
+// GetScrapeRun returns a scrape run record by run ID
+func (s *SQLiteStore) GetScrapeRun(id string) (*model.ScrapeRun, bool) {
+	runs := s.queryScrapeRuns(`WHERE run_id = ? LIMIT 1`, id)
+	if len(runs) == 0 {
+		return nil, false
 	}
-	count, _ := result.RowsAffected()
-	return int(count), nil
+	return runs[0], true
 }
 
-// GetAllSubscriptions returns all subscriptions
-func (s *SQLiteStore) GetAllSubscriptions() []*model.Subscription {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
-
+// queryScrapeRuns loads the scrape runs selected by clause with their categories and pages
+func (s *SQLiteStore) queryScrapeRuns(clause string, args ...any) []*model.ScrapeRun {
 	rows, err := s.db.Query(`
-		SELECT id, product_id, bark_key, target_price, created_at
-		FROM subscriptions
-		ORDER BY created_at DESC
-	`)
+		SELECT id, started_at, finished_at, COALESCE(duration_ms, 0), product_count, COALESCE(run_id, ''),
+			COALESCE(triggered_by, ''), COALESCE(status, 'success'), COALESCE(error, ''),
+			COALESCE(scope_region, ''), COALESCE(scope_category, '')
+		FROM scrape_runs
+		`+clause, args...)
 	if err != nil {
-		return []*model.Subscription{}
+		return []*model.ScrapeRun{}
 	}
-	defer rows.Close()
 
-	var subs []*model.Subscription
+	runs := []*model.ScrapeRun{}
+	var ids []int64
 	for rows.Next() {
-		sub := &model.Subscription{}
-		var created int64
-		var targetPrice sql.NullFloat64
-		err := rows.Scan(&sub.ID, &sub.ProductID, &sub.BarkKey, &targetPrice, &created)
+		run := &model.ScrapeRun{Categories: []model.ScrapeRunCategory{}, Pages: []model.ScrapeRunPage{}}
+		var id, started, finished int64
+		var scope model.ScrapeScope
+		if err := rows.Scan(&id, &started, &finished, &run.Duration, &run.ProductCount, &run.ID, &run.Trigger, &run.Status, &run.Error,
+			&scope.Region, &scope.Category); err != nil {
+			continue
+		}
+		if !scope.All() {
+			run.Scope = &scope
+		}
+		run.StartedAt = time.Unix(started, 0)
+		run.FinishedAt = time.Unix(finished, 0)
+		runs = append(runs, run)
+		ids = append(ids, id)
+	}
+	rows.Close()
+
+	for i, id := range ids {
+		rows, err := s.db.Query(`
+			SELECT region, category, total, available, COALESCE(new_count, 0),
+				COALESCE(changed_count, 0), COALESCE(removed_count, 0)
+			FROM scrape_run_categories
+			WHERE run_id = ? ORDER BY region, category
+		`, id)
 		if err != nil {
 			continue
 		}
-		if targetPrice.Valid {
-			sub.TargetPrice = targetPrice.Float64
+		for rows.Next() {
+			var c model.ScrapeRunCategory
+			if err := rows.Scan(&c.Region, &c.Category, &c.Total, &c.Available, &c.New, &c.Changed, &c.Removed); err != nil {
+				continue
+			}
+			runs[i].Categories = append(runs[i].Categories, c)
 		}
-		sub.CreatedAt = time.Unix(created, 0)
-		subs = append(subs, sub)
+		rows.Close()
+
+		rows, err = s.db.Query(`
+			SELECT region, page, products, error, duration_ms FROM scrape_run_pages
+			WHERE run_id = ? ORDER BY region, page
+		`, id)
+		if err != nil {
+			continue
+		}
+		for rows.Next() {
+			var p model.ScrapeRunPage
+			if err := rows.Scan(&p.Region, &p.Page, &p.Products, &p.Error, &p.Duration); err != nil {
+				continue
+			}
+			runs[i].Pages = append(runs[i].Pages, p)
+		}
+		rows.Close()
 	}
 
-	return subs
+	return runs
 }
 
-// GetSubscriptionsByProduct returns all subscriptions for a product
-func (s *SQLiteStore) GetSubscriptionsByProduct(productID string) []*model.Subscription {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
+// GetPriceHistoryBetween returns the history of every product recorded in
+// (since, until], compacted days as their closing price
+func (s *SQLiteStore) GetPriceHistoryBetween(since, until time.Time) []model.PriceHistory {
+	history := []model.PriceHistory{}
+	rows, err := s.db.Query(`
+		SELECT product_id, price, discount, recorded_at FROM (
+			SELECT product_id, close_price AS price, close_discount AS discount, close_at AS recorded_at, 0 AS id
+			FROM price_history_daily WHERE close_at > ? AND close_at <= ?
+			UNION ALL
+			SELECT product_id, price, discount, recorded_at, id
+			FROM price_history WHERE recorded_at > ? AND recorded_at <= ?
+		)
+		ORDER BY recorded_at ASC, id ASC
+	`, since.Unix(), until.Unix(), since.Unix(), until.Unix())
+	if err != nil {
+		return history
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var h model.PriceHistory
+		var recorded int64
+		if err := rows.Scan(&h.ProductID, &h.Price, &h.Discount, &recorded); err != nil {
+			continue
+		}
+		h.Timestamp = time.Unix(recorded, 0)
+		history = append(history, h)
+	}
+	return history
+}
 
+// GetProductEventsBetween returns the events of every product created in (since, until]
+func (s *SQLiteStore) GetProductEventsBetween(since, until time.Time) []model.ProductEvent {
+	events := []model.ProductEvent{}
 	rows, err := s.db.Query(`
-		SELECT id, product_id, bark_key, target_price, created_at
-		FROM subscriptions
-		WHERE product_id = ?
-		ORDER BY created_at DESC
-	`, productID)
+		SELECT product_id, event_type, price, created_at
+		FROM product_events
+		WHERE created_at > ? AND created_at <= ?
+		ORDER BY created_at ASC, id ASC
+	`, since.Unix(), until.Unix())
 	if err != nil {
-		return []*model.Subscription{}
+		return events
 	}
 	defer rows.Close()
 
-	var subs []*model.Subscription
 	for rows.Next() {
-		sub := &model.Subscription{}
+		var e model.ProductEvent
 		var created int64
-		var targetPrice sql.NullFloat64
-		err := rows.Scan(&sub.ID, &sub.ProductID, &sub.BarkKey, &targetPrice, &created)
-		if err != nil {
+		if err := rows.Scan(&e.ProductID, &e.EventType, &e.Price, &created); err != nil {
 			continue
 		}
-		if targetPrice.Valid {
-			sub.TargetPrice = targetPrice.Float64
-		}
-		sub.CreatedAt = time.Unix(created, 0)
-		subs = append(subs, sub)
+		e.CreatedAt = time.Unix(created, 0)
+		events = append(events, e)
 	}
-
-	return subs
+	return events
 }
 
-// UpdateLastScrapeTime updates the last scrape timestamp
-func (s *SQLiteStore) UpdateLastScrapeTime(t time.Time) {
+// ApplyReplication writes a batch pulled from an upstream instance. Products are
+// stored as sent, their history and events inside the batch window replace the
+// local ones (so re-applying a window is harmless), and products no longer listed
+// upstream are removed.
+func (s *SQLiteStore) ApplyReplication(batch *model.ReplicationBatch) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
-	s.lastScrapeTime = t
-}
-
-// GetLastScrapeTime returns the last scrape timestamp
-func (s *SQLiteStore) GetLastScrapeTime() time.Time {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
-	return s.lastScrapeTime
-}
-
-// GetStats returns system statistics
-func (s *SQLiteStore) GetStats() *model.Stats {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
+	defer s.productsVersion.Add(1)
 
-	stats := &model.Stats{
-		Categories:         make(map[string]int),
-		LastScrapeTime:     s.lastScrapeTime,
+	listed := make(map[string]bool, len(batch.ProductIDs))
+	for _, id := range batch.ProductIDs {
+		listed[id] = true
 	}
 
-	// Total products
-	_ = s.db.QueryRow("SELECT COUNT(*) FROM products").Scan(&stats.TotalProducts)
-
-	// Available products
-	_ = s.db.QueryRow("SELECT COUNT(*) FROM products WHERE stock_status = 'available'").Scan(&stats.AvailableProducts)
-
-	// Categories
-	rows, _ := s.db.Query("SELECT category, COUNT(*) FROM products GROUP BY category")
-	if rows != nil {
-		defer rows.Close()
-		for rows.Next() {
-			var cat string
-			var count int
-			if rows.Scan(&cat, &count) == nil {
-				stats.Categories[cat] = count
-			}
+	rows, err := s.db.Query("SELECT id FROM products")
+	if err != nil {
+		return fmt.Errorf("failed to list products: %w", err)
+	}
+	var unlisted []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err == nil && !listed[id] {
+			unlisted = append(unlisted, id)
 		}
 	}
+	rows.Close()
 
-	// Total subscriptions
-	_ = s.db.QueryRow("SELECT COUNT(*) FROM subscriptions").Scan(&stats.TotalSubscriptions)
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
 
-	// Scraper status
-	scraperStatus := &model.ScraperStatus{}
-	var lastTime sql.NullInt64
-	var scrapeErr sql.NullString
-	var productsScraped sql.NullInt64
-	var duration sql.NullInt64
+	// History and events go with the product (ON DELETE CASCADE)
+	for _, id := range unlisted {
+		if _, err := tx.Exec("DELETE FROM products WHERE id = ?", id); err != nil {
+			return fmt.Errorf("failed to delete product %s: %w", id, err)
+		}
+	}
 
-	err := s.db.QueryRow(`
-		SELECT last_scrape_time, last_scrape_status, last_scrape_error,
-			   products_scraped, duration_ms
-		FROM scraper_status WHERE id = 1
-	`).Scan(&lastTime, &scraperStatus.LastScrapeStatus, &scrapeErr,
-		&productsScraped, &duration)
+	changed := make(map[string]bool, len(batch.Products))
+	for _, p := range batch.Products {
+		changed[p.ID] = true
+		_, err := tx.Exec(`
+			INSERT INTO products (
+				id, name, category, region, price, original_price, discount,
+				image_url, product_url, specs, specs_detail, description, stock_status, grade, warranty_months, battery_health, dominant_color, part_number, value_score,
+				lowest_price, highest_price, price_trend, volatility, drop_streak, stability, archived, created_at, updated_at
+			) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+			ON CONFLICT(id) DO UPDATE SET
+				name = excluded.name,
+				category = excluded.category,
+				region = excluded.region,
+				price = excluded.price,
+				original_price = excluded.original_price,
+				discount = excluded.discount,
+				image_url = excluded.image_url,
+				product_url = excluded.product_url,
+				specs = excluded.specs,
+				specs_detail = excluded.specs_detail,
+				description = excluded.description,
+				stock_status = excluded.stock_status,
+				grade = excluded.grade,
+				warranty_months = excluded.warranty_months,
+				battery_health = excluded.battery_health,
+				dominant_color = excluded.dominant_color,
+				part_number = excluded.part_number,
+				value_score = excluded.value_score,
+				lowest_price = excluded.lowest_price,
+				highest_price = excluded.highest_price,
+				price_trend = excluded.price_trend,
+				volatility = excluded.volatility,
+				drop_streak = excluded.drop_streak,
+				stability = excluded.stability,
+				archived = excluded.archived,
+				created_at = excluded.created_at,
+				updated_at = excluded.updated_at
+		`, p.ID, p.Name, p.Category, p.Region, p.Price,
+			p.OriginalPrice, p.Discount, p.ImageURL, p.ProductURL,
+			p.Specs, p.SpecsDetail, p.Description, p.StockStatus,
+			p.Grade, p.WarrantyMonths, p.BatteryHealth, p.DominantColor, p.PartNumber, p.ValueScore,
+			p.LowestPrice, p.HighestPrice, p.PriceTrend,
+			p.Volatility, p.DropStreak, p.Stability, p.Archived,
+			p.CreatedAt.Unix(), p.UpdatedAt.Unix())
+		if err != nil {
+			return fmt.Errorf("failed to replicate product %s: %w", p.ID, err)
+		}
+		if err := writeSpecIndex(tx, p); err != nil {
+			return fmt.Errorf("failed to index product %s: %w", p.ID, err)
+		}
 
-	if err == nil {
-		if lastTime.Valid {
-			scraperStatus.LastScrapeTime = time.Unix(lastTime.Int64, 0)
+		if _, err := tx.Exec(`
+			DELETE FROM price_history WHERE product_id = ? AND recorded_at > ? AND recorded_at <= ?
+		`, p.ID, batch.Since, batch.Until); err != nil {
+			return fmt.Errorf("failed to clear price history: %w", err)
 		}
-		if scrapeErr.Valid {
-			scraperStatus.LastScrapeError = scrapeErr.String
+		if _, err := tx.Exec(`
+			DELETE FROM product_events WHERE product_id = ? AND created_at > ? AND created_at <= ?
+		`, p.ID, batch.Since, batch.Until); err != nil {
+			return fmt.Errorf("failed to clear product events: %w", err)
 		}
-		if productsScraped.Valid {
-			scraperStatus.ProductsScraped = int(productsScraped.Int64)
+	}
+
+	for _, h := range batch.PriceHistory {
+		if !changed[h.ProductID] {
+			continue
 		}
-		if duration.Valid {
-			scraperStatus.Duration = duration.Int64
+		if _, err := tx.Exec(`
+			INSERT INTO price_history (product_id, price, discount, recorded_at) VALUES (?, ?, ?, ?)
+		`, h.ProductID, h.Price, h.Discount, h.Timestamp.Unix()); err != nil {
+			return fmt.Errorf("failed to replicate price history: %w", err)
 		}
-		stats.ScraperStatus = scraperStatus
-	} else {
-		// No status record yet
-		stats.ScraperStatus = &model.ScraperStatus{
-			LastScrapeStatus: "never",
+	}
+
+	for _, e := range batch.Events {
+		if !changed[e.ProductID] {
+			continue
+		}
+		if _, err := tx.Exec(`
+			INSERT INTO product_events (product_id, event_type, price, created_at) VALUES (?, ?, ?, ?)
+		`, e.ProductID, e.EventType, e.Price, e.CreatedAt.Unix()); err != nil {
+			return fmt.Errorf("failed to replicate product events: %w", err)
 		}
 	}
 
-	return stats
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit replication: %w", err)
+	}
+
+	if !batch.LastScrapeTime.IsZero() {
+		s.lastScrapeTime = batch.LastScrapeTime
+	}
+	return nil
 }
 
 // CalculateValueScore calculates value score based on historical data
 // Note: Discount is fixed at 15% for Apple refurbished products, so we removed discount from scoring
 func (s *SQLiteStore) CalculateValueScore(product *model.Product, history []model.PriceHistory) float64 {
-	score := 50.0 // Base score
+	return s.scoreBreakdown(product, history).Score
+}
+
+// scoreBreakdown computes the value score of product component by component
+func (s *SQLiteStore) scoreBreakdown(product *model.Product, history []model.PriceHistory) *model.ScoreBreakdown {
+	b := model.NewScoreBreakdown(product.ID, 50)
 
 	// 1. Price trend score (0-35 points) - increased weight
-	trendScore := s.trendScore(history)
-	score += trendScore * 1.4
+	trendScore, reason := s.trendScore(history)
+	b.Add(model.ScoreTrend, trendScore*1.4, 35, reason)
 
 	// 2. Stock status score (0-20 points) - increased weight
-	stockScore := s.stockScore(product.StockStatus)
-	score += stockScore * 1.33
+	stockScore, reason := s.stockScore(product.StockStatus)
+	b.Add(model.ScoreStock, stockScore*1.33, 20, reason)
 
 	// 3. Price position score (0-30 points) - increased weight
-	positionScore := s.pricePositionScore(product.Price, history)
-	score += positionScore * 1.5
+	positionScore, reason := s.pricePositionScore(product.Price, history)
+	b.Add(model.ScorePricePosition, positionScore*1.5, 30, reason)
 
 	// 4. Age score (0-15 points) - increased weight
-	ageScore := s.ageScore(product.CreatedAt)
-	score += ageScore * 1.5
+	ageScore, reason := s.ageScore(product.CreatedAt)
+	b.Add(model.ScoreAge, ageScore*1.5, 15, reason)
+
+	// 5. Retailer score (-10 to 10 points) - refurbished vs new at JD, Amazon...
+	retailer, reason := retailerScore(product.Price, s.GetRetailerPrices(product.ID))
+	b.Add(model.ScoreRetailer, retailer, 10, reason)
 
 	// Cap at 0-100
-	if score > 100 {
-		score = 100
-	}
-	if score < 0 {
-		score = 0
-	}
+	b.Finish()
+	return b
+}
 
-	return score
+// GetScoreBreakdown explains a product's value score component by component
+func (s *SQLiteStore) GetScoreBreakdown(productID string) (*model.ScoreBreakdown, bool) {
+	product, ok := s.GetProduct(productID)
+	if !ok {
+		return nil, false
+	}
+	return s.scoreBreakdown(product, s.GetPriceHistory(productID)), true
 }
 
-func (s *SQLiteStore) trendScore(history []model.PriceHistory) float64 {
+func (s *SQLiteStore) trendScore(history []model.PriceHistory) (float64, string) {
 	if len(history) < 3 {
-		return 0
+		return 0, "价格记录不足"
 	}
 
 	recent := history[len(history)-3:]
@@ -822,31 +3123,31 @@ func (s *SQLiteStore) trendScore(history []model.PriceHistory) float64 {
 	change := (endPrice - startPrice) / startPrice
 
 	if change < -0.02 { // Fell more than 2%
-		return 25
+		return 25, fmt.Sprintf("近期价格下降 %.1f%%", -change*100)
 	} else if change < -0.01 { // Fell more than 1%
-		return 20
+		return 20, fmt.Sprintf("近期价格下降 %.1f%%", -change*100)
 	} else if change < 0 { // Falling
-		return 15
+		return 15, fmt.Sprintf("近期价格小幅下降 %.1f%%", -change*100)
 	} else if change > 0.02 { // Rose more than 2%
-		return 0
+		return 0, fmt.Sprintf("近期价格上涨 %.1f%%", change*100)
 	}
-	return 10 // Stable
+	return 10, "近期价格稳定" // Stable
 }
 
-func (s *SQLiteStore) stockScore(status string) float64 {
+func (s *SQLiteStore) stockScore(status string) (float64, string) {
 	switch status {
 	case "available":
-		return 15
+		return 15, "有货"
 	case "limited":
-		return 10
+		return 10, "库存紧张"
 	default:
-		return 0
+		return 0, "已售罄"
 	}
 }
 
-func (s *SQLiteStore) pricePositionScore(currentPrice float64, history []model.PriceHistory) float64 {
+func (s *SQLiteStore) pricePositionScore(currentPrice float64, history []model.PriceHistory) (float64, string) {
 	if len(history) == 0 {
-		return 10
+		return 10, "暂无价格记录"
 	}
 
 	min, max := history[0].Price, history[0].Price
@@ -860,38 +3161,21 @@ func (s *SQLiteStore) pricePositionScore(currentPrice float64, history []model.P
 	}
 
 	if max == min {
-		return 10
+		return 10, "价格没有变化过"
 	}
 
 	position := (currentPrice - min) / (max - min)
-	if position <= 0.1 {
-		return 20
-	} else if position <= 0.3 {
-		return 15
-	} else if position <= 0.5 {
-		return 10
-	} else if position <= 0.7 {
-		return 5
-	}
-	return 0
+	return positionPoints(position), positionReason(position, min, max)
 }
 
-func (s *SQLiteStore) ageScore(createdAt time.Time) float64 {
+func (s *SQLiteStore) ageScore(createdAt time.Time) (float64, string) {
 	days := time.Since(createdAt).Hours() / 24
-	switch {
-	case days <= 7:
-		return 10
-	case days <= 30:
-		return 7
-	case days <= 90:
-		return 3
-	default:
-		return 0
-	}
+	return agePoints(days), fmt.Sprintf("上架 %d 天", int(days))
 }
 
-// updateProductStats updates lowest_price, highest_price, and price_trend
-func (s *SQLiteStore) updateProductStats(productID string, history []model.PriceHistory) {
+// updateProductStats fills in lowest_price, highest_price, price_trend and the
+// volatility indicators, which the upsert then writes with the rest of the product
+func updateProductStats(product *model.Product, history []model.PriceHistory) {
 	if len(history) == 0 {
 		return
 	}
@@ -918,18 +3202,30 @@ func (s *SQLiteStore) updateProductStats(productID string, history []model.Price
 		}
 	}
 
-	s.db.Exec(`
-		UPDATE products
-		SET lowest_price = ?, highest_price = ?, price_trend = ?
-		WHERE id = ?
-	`, min, max, trend, productID)
+	product.LowestPrice = min
+	product.HighestPrice = max
+	product.PriceTrend = trend
+	product.Volatility, product.DropStreak, product.Stability = model.PriceIndicators(history, product.Price)
 }
 
-// Close closes the database connection
+// Close closes the prepared statements and the database connection
 func (s *SQLiteStore) Close() error {
+	s.stmtsMu.Lock()
+	for query, stmt := range s.stmts {
+		_ = stmt.Close()
+		delete(s.stmts, query)
+	}
+	s.stmtsMu.Unlock()
+
 	return s.db.Close()
 }
 
+// Ping reports whether the database answers queries
+func (s *SQLiteStore) Ping() error {
+	var one int
+	return s.db.QueryRow("SELECT 1").Scan(&one)
+}
+
 // Save is a no-op for SQLite (data is persisted automatically)
 // This method exists for compatibility with the old JSON store interface
 func (s *SQLiteStore) Save() error {
@@ -944,23 +3240,7 @@ func (s *SQLiteStore) AddNewArrivalSubscription(sub *model.NewArrivalSubscriptio
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	// Debug: Check what we're receiving
-	fmt.Fprintf(os.Stderr, "[DEBUG] Categories=%v, len=%d\n", sub.Categories, len(sub.Categories))
-	for i, cat := range sub.Categories {
-		fmt.Fprintf(os.Stderr, "[DEBUG]   Categories[%d] = %q (len=%d)\n", i, cat, len(cat))
-	}
-
-	// Use json.Marshal for proper JSON encoding
-	categoriesJSON, _ := json.Marshal(sub.Categories)
-	modelsJSON, _ := json.Marshal(sub.Models)
-	chipsJSON, _ := json.Marshal(sub.Chips)
-	storagesJSON, _ := json.Marshal(sub.Storages)
-	memoriesJSON, _ := json.Marshal(sub.Memories)
-	stockStatusesJSON, _ := json.Marshal(sub.StockStatuses)
-	keywordsJSON, _ := json.Marshal(sub.Keywords)
-
-	// Debug: Check what JSON marshal produces
-	fmt.Fprintf(os.Stderr, "[DEBUG] json.Marshal result: %q (len=%d)\n", string(categoriesJSON), len(categoriesJSON))
+	slog.Debug("Adding new arrival subscription", "subscription_id", sub.ID, "categories", sub.Categories)
 
 	enabled := 1
 	if !sub.Enabled {
@@ -977,41 +3257,69 @@ func (s *SQLiteStore) AddNewArrivalSubscription(sub *model.NewArrivalSubscriptio
 		updatedAt = sub.UpdatedAt.Unix()
 	}
 
-	notifiedIDs := sub.NotifiedProductIDs
-	if notifiedIDs == "" {
-		notifiedIDs = "[]"
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
 	}
+	defer tx.Rollback()
 
-	_, err := s.db.Exec(`
-		INSERT INTO new_arrival_subscriptions (id, name, description, categories, models, chips, storages, memories,
-			stock_statuses, max_price, min_price, keywords, bark_key, enabled, paused, created_at, updated_at, notified_product_ids)
+	_, err = tx.Exec(`
+		INSERT INTO new_arrival_subscriptions (id, name, description, max_price, min_price, bark_key,
+			enabled, paused, created_at, updated_at, quiet_hours_start, quiet_hours_end, timezone, frequency, stability, bark_server, spec, language)
 		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
-	`, sub.ID, sub.Name, sub.Description, string(categoriesJSON), string(modelsJSON), string(chipsJSON), string(storagesJSON), string(memoriesJSON),
-		string(stockStatusesJSON), sub.MaxPrice, sub.MinPrice, string(keywordsJSON), sub.BarkKey, enabled, paused,
-		sub.CreatedAt.Unix(), updatedAt, notifiedIDs)
+	`, sub.ID, sub.Name, sub.Description, sub.MaxPrice, sub.MinPrice, sub.BarkKey, enabled, paused,
+		sub.CreatedAt.Unix(), updatedAt, sub.QuietHoursStart, sub.QuietHoursEnd, sub.Timezone, sub.Frequency, sub.Stability, sub.BarkServer, sub.Spec, sub.Language)
+	if err != nil {
+		return err
+	}
 
-	return err
+	if err := writeSubscriptionFilters(tx, sub); err != nil {
+		return err
+	}
+
+	// Carry over notified products when a subscription is re-created (e.g. restored)
+	var notifiedIDs []string
+	if sub.NotifiedProductIDs != "" {
+		_ = json.Unmarshal([]byte(sub.NotifiedProductIDs), &notifiedIDs)
+	}
+	for _, productID := range notifiedIDs {
+		if err := markNotified(tx, sub.ID, productID, sub.CreatedAt); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
 }
 
-// RemoveNewArrivalSubscription removes a new arrival subscription
+// RemoveNewArrivalSubscription soft-deletes a new arrival subscription: it stops
+// matching but keeps its filters and notified products until it is restored or purged
 func (s *SQLiteStore) RemoveNewArrivalSubscription(id string) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	_, err := s.db.Exec("DELETE FROM new_arrival_subscriptions WHERE id = ?", id)
-	return err
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("UPDATE new_arrival_subscriptions SET deleted_at = ? WHERE id = ? AND deleted_at IS NULL", time.Now().Unix(), id); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("DELETE FROM pending_notifications WHERE subscription_id = ?", id); err != nil {
+		return err
+	}
+	return tx.Commit()
 }
 
 // GetAllNewArrivalSubscriptions returns all new arrival subscriptions
 func (s *SQLiteStore) GetAllNewArrivalSubscriptions() []*model.NewArrivalSubscription {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
-
 	rows, err := s.db.Query(`
-		SELECT id, name, description, categories, models, chips, storages, memories, stock_statuses,
-		       max_price, min_price, keywords, bark_key, enabled, paused, notification_count,
-		       last_notified_at, created_at, updated_at, notified_product_ids
+		SELECT id, name, description, max_price, min_price, bark_key, enabled, paused, notification_count,
+		       last_notified_at, created_at, updated_at,
+		       quiet_hours_start, quiet_hours_end, timezone, frequency, stability, bark_server, spec, language
 		FROM new_arrival_subscriptions
+		WHERE deleted_at IS NULL
 		ORDER BY created_at DESC
 	`)
 	if err != nil {
@@ -1023,70 +3331,27 @@ func (s *SQLiteStore) GetAllNewArrivalSubscriptions() []*model.NewArrivalSubscri
 	for rows.Next() {
 		sub := &model.NewArrivalSubscription{}
 		var created int64
-		var description, categoriesStr, modelsStr, chipsStr, storagesStr, memoriesStr, stockStatusesStr sql.NullString
-		var keywordsStr, notifiedIDsStr sql.NullString
+		var description sql.NullString
 		var barkKey sql.NullString
 		var enabled, paused int
 		var notificationCount int
 		var maxPrice, minPrice sql.NullFloat64
 		var lastNotifiedAt, updatedAt sql.NullInt64
+		var quietStart, quietEnd, timezone, frequency, stability, barkServer, spec, language sql.NullString
 
-		err := rows.Scan(&sub.ID, &sub.Name, &description, &categoriesStr, &modelsStr, &chipsStr, &storagesStr, &memoriesStr,
-			&stockStatusesStr, &maxPrice, &minPrice, &keywordsStr, &barkKey, &enabled, &paused,
-			&notificationCount, &lastNotifiedAt, &created, &updatedAt, &notifiedIDsStr)
+		err := rows.Scan(&sub.ID, &sub.Name, &description, &maxPrice, &minPrice, &barkKey, &enabled, &paused,
+			&notificationCount, &lastNotifiedAt, &created, &updatedAt,
+			&quietStart, &quietEnd, &timezone, &frequency, &stability, &barkServer, &spec, &language)
 		if err != nil {
-			continue
-		}
-
-		sub.Description = description.String
-
-		// Parse categories JSON using encoding/json
-		// Need to unmarshal regardless of content - empty arrays are valid
-		if categoriesStr.Valid && categoriesStr.String != "" {
-			fmt.Fprintf(os.Stderr, "[DEBUG] categoriesStr.String = %q (len=%d)\n", categoriesStr.String, len(categoriesStr.String))
-			fmt.Fprintf(os.Stderr, "[DEBUG] categoriesStr bytes = %v\n", []byte(categoriesStr.String))
-			json.Unmarshal([]byte(categoriesStr.String), &sub.Categories)
-			fmt.Fprintf(os.Stderr, "[DEBUG] After unmarshal, sub.Categories = %v\n", sub.Categories)
-		}
-
-		// Parse models JSON using encoding/json
-		if modelsStr.Valid && modelsStr.String != "" {
-			json.Unmarshal([]byte(modelsStr.String), &sub.Models)
-		}
-
-		// Parse chips JSON using encoding/json
-		if chipsStr.Valid && chipsStr.String != "" {
-			json.Unmarshal([]byte(chipsStr.String), &sub.Chips)
-		}
-
-		// Parse storages JSON using encoding/json
-		if storagesStr.Valid && storagesStr.String != "" {
-			json.Unmarshal([]byte(storagesStr.String), &sub.Storages)
-		}
-
-		// Parse memories JSON using encoding/json
-		if memoriesStr.Valid && memoriesStr.String != "" {
-			json.Unmarshal([]byte(memoriesStr.String), &sub.Memories)
+			continue
 		}
 
-		// Parse stock_statuses JSON using encoding/json
-		if stockStatusesStr.Valid && stockStatusesStr.String != "" {
-			json.Unmarshal([]byte(stockStatusesStr.String), &sub.StockStatuses)
-		}
+		sub.Description = description.String
 
-		// Parse keywords JSON using encoding/json
-		if keywordsStr.Valid && keywordsStr.String != "" {
-			json.Unmarshal([]byte(keywordsStr.String), &sub.Keywords)
-		}
 
 		if barkKey.Valid {
 			sub.BarkKey = barkKey.String
 		}
-		if notifiedIDsStr.Valid {
-			sub.NotifiedProductIDs = notifiedIDsStr.String
-		} else {
-			sub.NotifiedProductIDs = "[]"
-		}
 		sub.Enabled = enabled == 1
 		sub.Paused = paused == 1
 		if maxPrice.Valid {
@@ -1096,25 +3361,32 @@ func (s *SQLiteStore) GetAllNewArrivalSubscriptions() []*model.NewArrivalSubscri
 			sub.MinPrice = minPrice.Float64
 		}
 		sub.NotificationCount = notificationCount
+		sub.QuietHoursStart = quietStart.String
+		sub.QuietHoursEnd = quietEnd.String
+		sub.Timezone = timezone.String
+		sub.Frequency = frequency.String
+		sub.Stability = stability.String
+		sub.BarkServer = barkServer.String
+		sub.Language = language.String
+		sub.Spec = spec.String
 
 		sub.CreatedAt = time.Unix(created, 0)
 		subs = append(subs, sub)
 	}
+	rows.Close()
 
+	s.loadSubscriptionLists(subs)
 	return subs
 }
 
 // GetNewArrivalSubscriptionsByBarkKey returns subscriptions for a specific Bark Key
 func (s *SQLiteStore) GetNewArrivalSubscriptionsByBarkKey(barkKey string) []*model.NewArrivalSubscription {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
-
 	rows, err := s.db.Query(`
-		SELECT id, name, description, categories, models, chips, storages, memories, stock_statuses,
-		       max_price, min_price, keywords, bark_key, enabled, paused, notification_count,
-		       last_notified_at, created_at, updated_at, notified_product_ids
+		SELECT id, name, description, max_price, min_price, bark_key, enabled, paused, notification_count,
+		       last_notified_at, created_at, updated_at,
+		       quiet_hours_start, quiet_hours_end, timezone, frequency, stability, bark_server, spec, language
 		FROM new_arrival_subscriptions
-		WHERE bark_key = ?
+		WHERE bark_key = ? AND deleted_at IS NULL
 		ORDER BY created_at DESC
 	`, barkKey)
 	if err != nil {
@@ -1126,66 +3398,27 @@ func (s *SQLiteStore) GetNewArrivalSubscriptionsByBarkKey(barkKey string) []*mod
 	for rows.Next() {
 		sub := &model.NewArrivalSubscription{}
 		var created int64
-		var description, categoriesStr, modelsStr, chipsStr, storagesStr, memoriesStr, stockStatusesStr sql.NullString
-		var keywordsStr, notifiedIDsStr sql.NullString
+		var description sql.NullString
 		var barkKeyVal sql.NullString
 		var enabled, paused int
 		var notificationCount int
 		var maxPrice, minPrice sql.NullFloat64
 		var lastNotifiedAt, updatedAt sql.NullInt64
+		var quietStart, quietEnd, timezone, frequency, stability, barkServer, spec, language sql.NullString
 
-		err := rows.Scan(&sub.ID, &sub.Name, &description, &categoriesStr, &modelsStr, &chipsStr, &storagesStr, &memoriesStr,
-			&stockStatusesStr, &maxPrice, &minPrice, &keywordsStr, &barkKeyVal, &enabled, &paused,
-			&notificationCount, &lastNotifiedAt, &created, &updatedAt, &notifiedIDsStr)
+		err := rows.Scan(&sub.ID, &sub.Name, &description, &maxPrice, &minPrice, &barkKeyVal, &enabled, &paused,
+			&notificationCount, &lastNotifiedAt, &created, &updatedAt,
+			&quietStart, &quietEnd, &timezone, &frequency, &stability, &barkServer, &spec, &language)
 		if err != nil {
 			continue
 		}
 
 		sub.Description = description.String
 
-		// Parse categories JSON
-		if categoriesStr.Valid && categoriesStr.String != "" {
-			json.Unmarshal([]byte(categoriesStr.String), &sub.Categories)
-		}
-
-		// Parse models JSON
-		if modelsStr.Valid && modelsStr.String != "" {
-			json.Unmarshal([]byte(modelsStr.String), &sub.Models)
-		}
-
-		// Parse chips JSON
-		if chipsStr.Valid && chipsStr.String != "" {
-			json.Unmarshal([]byte(chipsStr.String), &sub.Chips)
-		}
-
-		// Parse storages JSON
-		if storagesStr.Valid && storagesStr.String != "" {
-			json.Unmarshal([]byte(storagesStr.String), &sub.Storages)
-		}
-
-		// Parse memories JSON
-		if memoriesStr.Valid && memoriesStr.String != "" {
-			json.Unmarshal([]byte(memoriesStr.String), &sub.Memories)
-		}
-
-		// Parse stock_statuses JSON
-		if stockStatusesStr.Valid && stockStatusesStr.String != "" {
-			json.Unmarshal([]byte(stockStatusesStr.String), &sub.StockStatuses)
-		}
-
-		// Parse keywords JSON
-		if keywordsStr.Valid && keywordsStr.String != "" {
-			json.Unmarshal([]byte(keywordsStr.String), &sub.Keywords)
-		}
 
 		if barkKeyVal.Valid {
 			sub.BarkKey = barkKeyVal.String
 		}
-		if notifiedIDsStr.Valid {
-			sub.NotifiedProductIDs = notifiedIDsStr.String
-		} else {
-			sub.NotifiedProductIDs = "[]"
-		}
 		sub.Enabled = enabled == 1
 		sub.Paused = paused == 1
 		if maxPrice.Valid {
@@ -1195,339 +3428,804 @@ func (s *SQLiteStore) GetNewArrivalSubscriptionsByBarkKey(barkKey string) []*mod
 			sub.MinPrice = minPrice.Float64
 		}
 		sub.NotificationCount = notificationCount
+		sub.QuietHoursStart = quietStart.String
+		sub.QuietHoursEnd = quietEnd.String
+		sub.Timezone = timezone.String
+		sub.Frequency = frequency.String
+		sub.Stability = stability.String
+		sub.BarkServer = barkServer.String
+		sub.Language = language.String
+		sub.Spec = spec.String
 
 		sub.CreatedAt = time.Unix(created, 0)
 		subs = append(subs, sub)
 	}
+	rows.Close()
 
+	s.loadSubscriptionLists(subs)
 	return subs
 }
 
 // GetNewArrivalSubscription returns a new arrival subscription by ID
 func (s *SQLiteStore) GetNewArrivalSubscription(id string) (*model.NewArrivalSubscription, bool) {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
+	return s.getNewArrivalSubscription(id, false)
+}
 
+// getNewArrivalSubscription returns a live or, with deleted, a soft-deleted new
+// arrival subscription by ID
+func (s *SQLiteStore) getNewArrivalSubscription(id string, deleted bool) (*model.NewArrivalSubscription, bool) {
 	sub := &model.NewArrivalSubscription{}
 	var created int64
-	var description, categoriesStr, modelsStr, chipsStr, storagesStr, memoriesStr, stockStatusesStr sql.NullString
-	var keywordsStr, notifiedIDsStr sql.NullString
+	var description sql.NullString
 	var barkKey sql.NullString
 	var enabled, paused int
 	var notificationCount int
 	var maxPrice, minPrice sql.NullFloat64
-	var lastNotifiedAt, updatedAt sql.NullInt64
+	var lastNotifiedAt, updatedAt, deletedAt sql.NullInt64
+	var quietStart, quietEnd, timezone, frequency, stability, barkServer, spec, language sql.NullString
+
+	state := "deleted_at IS NULL"
+	if deleted {
+		state = "deleted_at IS NOT NULL"
+	}
+	err := s.db.QueryRow(`
+		SELECT id, name, description, max_price, min_price, bark_key, enabled, paused, notification_count,
+		       last_notified_at, created_at, updated_at, deleted_at,
+		       quiet_hours_start, quiet_hours_end, timezone, frequency, stability, bark_server, spec, language
+		FROM new_arrival_subscriptions WHERE id = ? AND `+state+`
+	`, id).Scan(&sub.ID, &sub.Name, &description, &maxPrice, &minPrice, &barkKey, &enabled, &paused,
+		&notificationCount, &lastNotifiedAt, &created, &updatedAt, &deletedAt,
+		&quietStart, &quietEnd, &timezone, &frequency, &stability, &barkServer, &spec, &language)
+
+	if err == sql.ErrNoRows {
+		return nil, false
+	}
+	if err != nil {
+		return nil, false
+	}
+
+	sub.Description = description.String
+
+
+	if barkKey.Valid {
+		sub.BarkKey = barkKey.String
+	}
+	sub.Enabled = enabled == 1
+	sub.Paused = paused == 1
+	sub.NotificationCount = notificationCount
+	sub.QuietHoursStart = quietStart.String
+	sub.QuietHoursEnd = quietEnd.String
+	sub.Timezone = timezone.String
+	sub.Frequency = frequency.String
+	sub.Stability = stability.String
+	sub.BarkServer = barkServer.String
+	sub.Language = language.String
+	sub.Spec = spec.String
+	if maxPrice.Valid {
+		sub.MaxPrice = maxPrice.Float64
+	}
+	if minPrice.Valid {
+		sub.MinPrice = minPrice.Float64
+	}
+	if lastNotifiedAt.Valid {
+		sub.LastNotifiedAt = time.Unix(lastNotifiedAt.Int64, 0)
+	}
+	sub.CreatedAt = time.Unix(created, 0)
+	if updatedAt.Valid {
+		sub.UpdatedAt = time.Unix(updatedAt.Int64, 0)
+	}
+	if deletedAt.Valid {
+		sub.DeletedAt = time.Unix(deletedAt.Int64, 0)
+	}
+
+	s.loadSubscriptionLists([]*model.NewArrivalSubscription{sub})
+	return sub, true
+}
+
+// UpdateNotifiedProductIDs adds a product ID to the notified list
+func (s *SQLiteStore) UpdateNotifiedProductIDs(subscriptionID, productID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return markNotified(s.db, subscriptionID, productID, time.Now())
+}
+
+// AddNotificationHistory adds a notification history record
+func (s *SQLiteStore) AddNotificationHistory(history *model.NotificationHistory) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, err := s.db.Exec(`
+		INSERT INTO notification_history (id, subscription_id, product_id, product_name, product_category,
+			product_price, product_image_url, product_specs, notification_type, status, error_message,
+			title, body, channel_params, channel, delivery_latency_ms, bark_key, bark_key_masked, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, history.ID, history.SubscriptionID, history.ProductID, history.ProductName,
+		history.ProductCategory, history.ProductPrice, history.ProductImageURL, history.ProductSpecs,
+		history.NotificationType, history.Status, history.ErrorMessage,
+		history.Title, history.Body, history.ChannelParams, history.Channel, history.DeliveryLatencyMs,
+		history.BarkKey, history.BarkKeyMasked, history.CreatedAt.Unix())
+
+	return err
+}
+
+// GetNotificationHistory retrieves notification history with optional filters
+func (s *SQLiteStore) GetNotificationHistory(subscriptionID string, barkKey string, limit, offset int) ([]*model.NotificationHistory, int) {
+	// Build query with filters - always filter by bark_key for user isolation
+	query := `SELECT id, subscription_id, product_id, product_name, product_category, product_price,
+		product_image_url, product_specs, notification_type, status, error_message, title, body, channel_params,
+		channel, delivery_latency_ms, bark_key, bark_key_masked, created_at, read_at FROM notification_history WHERE bark_key = ?`
+	args := []interface{}{barkKey}
+
+	if subscriptionID != "" {
+		query += " AND subscription_id = ?"
+		args = append(args, subscriptionID)
+	}
+
+	// Get total count
+	countQuery := "SELECT COUNT(*) FROM notification_history WHERE bark_key = ?"
+	countArgs := []interface{}{barkKey}
+	if subscriptionID != "" {
+		countQuery += " AND subscription_id = ?"
+		countArgs = append(countArgs, subscriptionID)
+	}
+	var total int
+	_ = s.db.QueryRow(countQuery, countArgs...).Scan(&total)
+
+	// Add order and pagination
+	query += " ORDER BY created_at DESC LIMIT ? OFFSET ?"
+	args = append(args, limit, offset)
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return []*model.NotificationHistory{}, 0
+	}
+	defer rows.Close()
+
+	var history []*model.NotificationHistory
+	for rows.Next() {
+		h := &model.NotificationHistory{}
+		var created int64
+		var readAt sql.NullInt64
+		var barkKeyFull sql.NullString
+		var title, body, channelParams, channel sql.NullString
+		var latency sql.NullInt64
+
+		err := rows.Scan(&h.ID, &h.SubscriptionID, &h.ProductID, &h.ProductName, &h.ProductCategory,
+			&h.ProductPrice, &h.ProductImageURL, &h.ProductSpecs, &h.NotificationType, &h.Status,
+			&h.ErrorMessage, &title, &body, &channelParams, &channel, &latency, &barkKeyFull, &h.BarkKeyMasked, &created, &readAt)
+		if err != nil {
+			continue
+		}
+
+		h.Title = title.String
+		h.Body = body.String
+		h.ChannelParams = channelParams.String
+		h.Channel = channel.String
+		if latency.Valid {
+			h.DeliveryLatencyMs = &latency.Int64
+		}
+
+		h.CreatedAt = time.Unix(created, 0)
+		if readAt.Valid {
+			readTime := time.Unix(readAt.Int64, 0)
+			h.ReadAt = &readTime
+		}
+
+		history = append(history, h)
+	}
+
+	return history, total
+}
+
+// MarkNotificationAsRead marks a notification as read
+func (s *SQLiteStore) MarkNotificationAsRead(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, err := s.db.Exec("UPDATE notification_history SET read_at = ? WHERE id = ?", time.Now().Unix(), id)
+	return err
+}
+
+// GetUnreadNotificationCount returns the count of unread notifications
+func (s *SQLiteStore) GetUnreadNotificationCount() int {
+	var count int
+	_ = s.db.QueryRow("SELECT COUNT(*) FROM notification_history WHERE read_at IS NULL").Scan(&count)
+	return count
+}
+
+// UpdateNotificationStatus changes the delivery status of a notification history entry.
+// latencyMs is the delivery latency of a sent notification, nil otherwise.
+func (s *SQLiteStore) UpdateNotificationStatus(id, status, errorMessage string, latencyMs *int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	res, err := s.db.Exec("UPDATE notification_history SET status = ?, error_message = ?, delivery_latency_ms = ? WHERE id = ?",
+		status, errorMessage, latencyMs, id)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("notification not found")
+	}
+	return nil
+}
+
+// AddNotificationRetry queues a failed push for another attempt
+func (s *SQLiteStore) AddNotificationRetry(retry *model.NotificationRetry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Milliseconds: latencies are reported in ms; NULL for retries queued before it was tracked
+	var detectedAt any
+	if !retry.DetectedAt.IsZero() {
+		detectedAt = retry.DetectedAt.UnixMilli()
+	}
+
+	_, err := s.db.Exec(`
+		INSERT OR REPLACE INTO notification_retries (id, history_id, subscription_id, product_id, notification_type,
+			bark_key, bark_server, title, body, url, icon, sound, msg_group, attempts, next_attempt_at, last_error,
+			detected_at, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, retry.ID, retry.HistoryID, retry.SubscriptionID, retry.ProductID, retry.NotificationType,
+		retry.BarkKey, retry.BarkServer, retry.Title, retry.Body, retry.URL, retry.Icon, retry.Sound, retry.Group,
+		retry.Attempts, retry.NextAttemptAt.Unix(), retry.LastError, detectedAt, retry.CreatedAt.Unix())
+	return err
+}
+
+// GetDueNotificationRetries returns up to limit queued retries due at now, earliest first
+func (s *SQLiteStore) GetDueNotificationRetries(now time.Time, limit int) []*model.NotificationRetry {
+	rows, err := s.db.Query(`
+		SELECT id, history_id, subscription_id, product_id, notification_type, bark_key, bark_server,
+			title, body, url, icon, sound, msg_group, attempts, next_attempt_at, last_error, detected_at, created_at
+		FROM notification_retries
+		WHERE next_attempt_at <= ?
+		ORDER BY next_attempt_at ASC
+		LIMIT ?
+	`, now.Unix(), limit)
+	if err != nil {
+		return []*model.NotificationRetry{}
+	}
+	defer rows.Close()
+
+	retries := []*model.NotificationRetry{}
+	for rows.Next() {
+		r := &model.NotificationRetry{}
+		var historyID, productID, barkServer, url, icon, sound, group, lastError sql.NullString
+		var next, created int64
+		var detected sql.NullInt64
+		err := rows.Scan(&r.ID, &historyID, &r.SubscriptionID, &productID, &r.NotificationType, &r.BarkKey, &barkServer,
+			&r.Title, &r.Body, &url, &icon, &sound, &group, &r.Attempts, &next, &lastError, &detected, &created)
+		if err != nil {
+			continue
+		}
+		r.HistoryID = historyID.String
+		r.ProductID = productID.String
+		r.BarkServer = barkServer.String
+		r.URL = url.String
+		r.Icon = icon.String
+		r.Sound = sound.String
+		r.Group = group.String
+		r.LastError = lastError.String
+		r.NextAttemptAt = time.Unix(next, 0)
+		if detected.Valid {
+			r.DetectedAt = time.UnixMilli(detected.Int64)
+		}
+		r.CreatedAt = time.Unix(created, 0)
+		retries = append(retries, r)
+	}
+	return retries
+}
+
+// UpdateNotificationRetry stores the outcome of a failed retry attempt
+func (s *SQLiteStore) UpdateNotificationRetry(retry *model.NotificationRetry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	res, err := s.db.Exec(`
+		UPDATE notification_retries SET attempts = ?, next_attempt_at = ?, last_error = ? WHERE id = ?
+	`, retry.Attempts, retry.NextAttemptAt.Unix(), retry.LastError, retry.ID)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("notification retry not found")
+	}
+	return nil
+}
+
+// DeleteNotificationRetry removes a retry once it was delivered or given up
+func (s *SQLiteStore) DeleteNotificationRetry(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, err := s.db.Exec("DELETE FROM notification_retries WHERE id = ?", id)
+	return err
+}
+
+// UpdateNewArrivalSubscription updates an existing subscription
+func (s *SQLiteStore) UpdateNewArrivalSubscription(sub *model.NewArrivalSubscription) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	paused := 0
+	if sub.Paused {
+		paused = 1
+	}
+
+	enabled := 1
+	if !sub.Enabled {
+		enabled = 0
+	}
+
+	var updatedAt int64
+	if !sub.UpdatedAt.IsZero() {
+		updatedAt = sub.UpdatedAt.Unix()
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`
+		UPDATE new_arrival_subscriptions
+		SET name = ?, description = ?, min_price = ?, max_price = ?,
+		    bark_key = ?, enabled = ?, paused = ?, updated_at = ?,
+		    quiet_hours_start = ?, quiet_hours_end = ?, timezone = ?, frequency = ?, stability = ?, bark_server = ?, spec = ?, language = ?
+		WHERE id = ?
+	`, sub.Name, sub.Description, sub.MinPrice, sub.MaxPrice,
+		sub.BarkKey, enabled, paused, updatedAt,
+		sub.QuietHoursStart, sub.QuietHoursEnd, sub.Timezone, sub.Frequency, sub.Stability, sub.BarkServer, sub.Spec, sub.Language, sub.ID)
+	if err != nil {
+		return err
+	}
+
+	if err := writeSubscriptionFilters(tx, sub); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// PauseSubscription pauses a subscription
+func (s *SQLiteStore) PauseSubscription(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, err := s.db.Exec("UPDATE new_arrival_subscriptions SET paused = 1 WHERE id = ?", id)
+	return err
+}
+
+// ResumeSubscription resumes a paused subscription
+func (s *SQLiteStore) ResumeSubscription(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 
-	err := s.db.QueryRow(`
-		SELECT id, name, description, categories, models, chips, storages, memories, stock_statuses,
-		       max_price, min_price, keywords, bark_key, enabled, paused, notification_count,
-		       last_notified_at, created_at, updated_at, notified_product_ids
-		FROM new_arrival_subscriptions WHERE id = ?
-	`, id).Scan(&sub.ID, &sub.Name, &description, &categoriesStr, &modelsStr, &chipsStr, &storagesStr, &memoriesStr,
-		&stockStatusesStr, &maxPrice, &minPrice, &keywordsStr, &barkKey, &enabled, &paused,
-		&notificationCount, &lastNotifiedAt, &created, &updatedAt, &notifiedIDsStr)
+	_, err := s.db.Exec("UPDATE new_arrival_subscriptions SET paused = 0 WHERE id = ?", id)
+	return err
+}
 
-	if err == sql.ErrNoRows {
-		return nil, false
-	}
+// GetPreferences returns the preferences for a Bark Key (defaults if none are stored)
+func (s *SQLiteStore) GetPreferences(barkKey string) *model.UserPreferences {
+	prefs := &model.UserPreferences{BarkKey: barkKey}
+
+	var pausedAll int
+	var channel, quietStart, quietEnd, timezone, language, currency, frequency sql.NullString
+	var updatedAt sql.NullInt64
+	err := s.db.QueryRow(`
+		SELECT paused_all, default_channel, quiet_hours_start, quiet_hours_end, timezone,
+			language, currency_display, digest_frequency, updated_at
+		FROM user_preferences WHERE bark_key = ?
+	`, barkKey).Scan(&pausedAll, &channel, &quietStart, &quietEnd, &timezone, &language, &currency, &frequency, &updatedAt)
 	if err != nil {
-		return nil, false
+		return prefs
 	}
 
-	sub.Description = description.String
-
-	// Parse categories JSON using encoding/json
-	if categoriesStr.Valid && categoriesStr.String != "" && categoriesStr.String != "[]" {
-		json.Unmarshal([]byte(categoriesStr.String), &sub.Categories)
+	prefs.PausedAll = pausedAll == 1
+	prefs.DefaultChannel = channel.String
+	prefs.QuietHoursStart = quietStart.String
+	prefs.QuietHoursEnd = quietEnd.String
+	prefs.Timezone = timezone.String
+	prefs.Language = language.String
+	prefs.CurrencyDisplay = currency.String
+	prefs.DigestFrequency = frequency.String
+	if updatedAt.Valid {
+		prefs.UpdatedAt = time.Unix(updatedAt.Int64, 0)
 	}
 
-	// Parse models JSON using encoding/json
-	if modelsStr.Valid && modelsStr.String != "" && modelsStr.String != "[]" {
-		json.Unmarshal([]byte(modelsStr.String), &sub.Models)
-	}
+	return prefs
+}
 
-	// Parse chips JSON using encoding/json
-	if chipsStr.Valid && chipsStr.String != "" && chipsStr.String != "[]" {
-		json.Unmarshal([]byte(chipsStr.String), &sub.Chips)
-	}
+// SetPreferences saves the preferences of prefs.BarkKey
+func (s *SQLiteStore) SetPreferences(prefs *model.UserPreferences) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 
-	// Parse storages JSON using encoding/json
-	if storagesStr.Valid && storagesStr.String != "" && storagesStr.String != "[]" {
-		json.Unmarshal([]byte(storagesStr.String), &sub.Storages)
+	pausedAll := 0
+	if prefs.PausedAll {
+		pausedAll = 1
 	}
 
-	// Parse memories JSON using encoding/json
-	if memoriesStr.Valid && memoriesStr.String != "" && memoriesStr.String != "[]" {
-		json.Unmarshal([]byte(memoriesStr.String), &sub.Memories)
-	}
+	_, err := s.db.Exec(`
+		INSERT INTO user_preferences (bark_key, paused_all, default_channel, quiet_hours_start, quiet_hours_end,
+			timezone, language, currency_display, digest_frequency, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(bark_key) DO UPDATE SET
+			paused_all = excluded.paused_all,
+			default_channel = excluded.default_channel,
+			quiet_hours_start = excluded.quiet_hours_start,
+			quiet_hours_end = excluded.quiet_hours_end,
+			timezone = excluded.timezone,
+			language = excluded.language,
+			currency_display = excluded.currency_display,
+			digest_frequency = excluded.digest_frequency,
+			updated_at = excluded.updated_at
+	`, prefs.BarkKey, pausedAll, prefs.DefaultChannel, prefs.QuietHoursStart, prefs.QuietHoursEnd,
+		prefs.Timezone, prefs.Language, prefs.CurrencyDisplay, prefs.DigestFrequency, prefs.UpdatedAt.Unix())
+	return err
+}
 
-	// Parse stock_statuses JSON using encoding/json
-	if stockStatusesStr.Valid && stockStatusesStr.String != "" && stockStatusesStr.String != "[]" {
-		json.Unmarshal([]byte(stockStatusesStr.String), &sub.StockStatuses)
-	}
+// GetDeliveryHealth returns the push failure tracking of a Bark Key (a healthy
+// record if none is stored)
+func (s *SQLiteStore) GetDeliveryHealth(barkKey string) *model.DeliveryHealth {
+	h := &model.DeliveryHealth{BarkKey: barkKey}
 
-	// Parse keywords JSON using encoding/json
-	if keywordsStr.Valid && keywordsStr.String != "" && keywordsStr.String != "[]" {
-		json.Unmarshal([]byte(keywordsStr.String), &sub.Keywords)
+	var lastError sql.NullString
+	var lastFailureAt, brokenAt sql.NullInt64
+	err := s.db.QueryRow(`
+		SELECT consecutive_failures, last_error, last_failure_at, broken_at
+		FROM delivery_health WHERE bark_key = ?
+	`, barkKey).Scan(&h.ConsecutiveFailures, &lastError, &lastFailureAt, &brokenAt)
+	if err != nil {
+		return h
 	}
 
-	if barkKey.Valid {
-		sub.BarkKey = barkKey.String
+	h.LastError = lastError.String
+	if lastFailureAt.Valid {
+		t := time.Unix(lastFailureAt.Int64, 0)
+		h.LastFailureAt = &t
 	}
-	if notifiedIDsStr.Valid {
-		sub.NotifiedProductIDs = notifiedIDsStr.String
-	} else {
-		sub.NotifiedProductIDs = "[]"
-	}
-	sub.Enabled = enabled == 1
-	sub.Paused = paused == 1
-	sub.NotificationCount = notificationCount
-	if maxPrice.Valid {
-		sub.MaxPrice = maxPrice.Float64
+	if brokenAt.Valid {
+		t := time.Unix(brokenAt.Int64, 0)
+		h.BrokenAt = &t
 	}
-	if minPrice.Valid {
-		sub.MinPrice = minPrice.Float64
+	return h
+}
+
+// SetDeliveryHealth saves the push failure tracking of h.BarkKey; a healthy
+// record removes it
+func (s *SQLiteStore) SetDeliveryHealth(h *model.DeliveryHealth) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if h.ConsecutiveFailures == 0 && !h.Broken() {
+		_, err := s.db.Exec("DELETE FROM delivery_health WHERE bark_key = ?", h.BarkKey)
+		return err
 	}
-	if lastNotifiedAt.Valid {
-		sub.LastNotifiedAt = time.Unix(lastNotifiedAt.Int64, 0)
+
+	var lastFailureAt, brokenAt sql.NullInt64
+	if h.LastFailureAt != nil {
+		lastFailureAt = sql.NullInt64{Int64: h.LastFailureAt.Unix(), Valid: true}
 	}
-	sub.CreatedAt = time.Unix(created, 0)
-	if updatedAt.Valid {
-		sub.UpdatedAt = time.Unix(updatedAt.Int64, 0)
+	if h.BrokenAt != nil {
+		brokenAt = sql.NullInt64{Int64: h.BrokenAt.Unix(), Valid: true}
 	}
-
-	return sub, true
+	_, err := s.db.Exec(`
+		INSERT INTO delivery_health (bark_key, consecutive_failures, last_error, last_failure_at, broken_at)
+		VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT(bark_key) DO UPDATE SET
+			consecutive_failures = excluded.consecutive_failures,
+			last_error = excluded.last_error,
+			last_failure_at = excluded.last_failure_at,
+			broken_at = excluded.broken_at
+	`, h.BarkKey, h.ConsecutiveFailures, h.LastError, lastFailureAt, brokenAt)
+	return err
 }
 
-// UpdateNotifiedProductIDs adds a product ID to the notified list
-func (s *SQLiteStore) UpdateNotifiedProductIDs(subscriptionID, productID string) error {
+// SetAllPaused turns the kill switch of a Bark Key on or off. It is kept in the
+// key's preferences apart from each subscription's own pause, so resuming leaves
+// individually paused subscriptions paused. Returns the number of new arrival
+// subscriptions the switch applies to, those not paused on their own.
+func (s *SQLiteStore) SetAllPaused(barkKey string, paused bool) (int, error) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	// Get current notified_product_ids
-	var currentIDs sql.NullString
-	err := s.db.QueryRow("SELECT notified_product_ids FROM new_arrival_subscriptions WHERE id = ?", subscriptionID).Scan(&currentIDs)
-	if err != nil {
-		return err
+	pausedInt := 0
+	if paused {
+		pausedInt = 1
 	}
 
-	// Parse existing IDs
-	var ids []string
-	if currentIDs.Valid && currentIDs.String != "" && currentIDs.String != "[]" {
-		// Simple JSON parsing for array of strings
-		trimmed := strings.Trim(currentIDs.String, "[]")
-		if trimmed != "" {
-			ids = strings.Split(trimmed, "\",\"")
-			// Clean up quotes
-			for i := range ids {
-				ids[i] = strings.Trim(ids[i], "\"")
-			}
-		}
+	_, err := s.db.Exec(`
+		INSERT INTO user_preferences (bark_key, paused_all, updated_at) VALUES (?, ?, ?)
+		ON CONFLICT(bark_key) DO UPDATE SET paused_all = excluded.paused_all, updated_at = excluded.updated_at
+	`, barkKey, pausedInt, time.Now().Unix())
+	if err != nil {
+		return 0, fmt.Errorf("failed to update preferences: %w", err)
 	}
 
-	// Check if already notified
-	for _, id := range ids {
-		if id == productID {
-			return nil // Already notified
-		}
+	var count int
+	if err := s.db.QueryRow(`
+		SELECT COUNT(*) FROM new_arrival_subscriptions
+		WHERE bark_key = ? AND COALESCE(paused, 0) = 0 AND deleted_at IS NULL
+	`, barkKey).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count subscriptions: %w", err)
 	}
 
-	// Add new ID
-	ids = append(ids, productID)
+	return count, nil
+}
+
+// IncrementNotificationCount increments the notification count for a subscription,
+// price or new arrival
+func (s *SQLiteStore) IncrementNotificationCount(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 
-	// Build JSON array
-	newIDs := "[]"
-	if len(ids) > 0 {
-		quotedIDs := make([]string, len(ids))
-		for i, id := range ids {
-			quotedIDs[i] = "\"" + id + "\""
+	// IDs are unique across both kinds of subscription
+	for _, table := range []string{"subscriptions", "new_arrival_subscriptions"} {
+		if _, err := s.db.Exec(`
+			UPDATE `+table+`
+			SET notification_count = notification_count + 1, last_notified_at = ?
+			WHERE id = ?
+		`, time.Now().Unix(), id); err != nil {
+			return err
 		}
-		newIDs = "[" + strings.Join(quotedIDs, ",") + "]"
 	}
-
-	// Update database
-	_, err = s.db.Exec("UPDATE new_arrival_subscriptions SET notified_product_ids = ? WHERE id = ?", newIDs, subscriptionID)
-	return err
+	return nil
 }
 
-// AddNotificationHistory adds a notification history record
-func (s *SQLiteStore) AddNotificationHistory(history *model.NotificationHistory) error {
+// AddPendingNotification buffers a digest item; a product already pending for the subscription is ignored
+func (s *SQLiteStore) AddPendingNotification(item *model.PendingNotification) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
 	_, err := s.db.Exec(`
-		INSERT INTO notification_history (id, subscription_id, product_id, product_name, product_category,
-			product_price, product_image_url, product_specs, notification_type, status, error_message,
-			bark_key, bark_key_masked, created_at)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
-	`, history.ID, history.SubscriptionID, history.ProductID, history.ProductName,
-		history.ProductCategory, history.ProductPrice, history.ProductImageURL, history.ProductSpecs,
-		history.NotificationType, history.Status, history.ErrorMessage, history.BarkKey, history.BarkKeyMasked,
-		history.CreatedAt.Unix())
+		INSERT OR IGNORE INTO pending_notifications (subscription_id, product_id, product_name, product_category,
+			product_price, product_image_url, product_url, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`, item.SubscriptionID, item.ProductID, item.ProductName, item.ProductCategory,
+		item.ProductPrice, item.ProductImageURL, item.ProductURL, item.CreatedAt.Unix())
 
 	return err
 }
 
-// GetNotificationHistory retrieves notification history with optional filters
-func (s *SQLiteStore) GetNotificationHistory(subscriptionID string, barkKey string, limit, offset int) ([]*model.NotificationHistory, int) {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
-
-	// Build query with filters - always filter by bark_key for user isolation
-	query := `SELECT id, subscription_id, product_id, product_name, product_category, product_price,
-		product_image_url, product_specs, notification_type, status, error_message, bark_key, bark_key_masked,
-		created_at, read_at FROM notification_history WHERE bark_key = ?`
-	args := []interface{}{barkKey}
-
-	if subscriptionID != "" {
-		query += " AND subscription_id = ?"
-		args = append(args, subscriptionID)
-	}
-
-	// Get total count
-	countQuery := "SELECT COUNT(*) FROM notification_history WHERE bark_key = ?"
-	countArgs := []interface{}{barkKey}
-	if subscriptionID != "" {
-		countQuery += " AND subscription_id = ?"
-		countArgs = append(countArgs, subscriptionID)
-	}
-	var total int
-	_ = s.db.QueryRow(countQuery, countArgs...).Scan(&total)
-
-	// Add order and pagination
-	query += " ORDER BY created_at DESC LIMIT ? OFFSET ?"
-	args = append(args, limit, offset)
-
-	rows, err := s.db.Query(query, args...)
+// GetPendingNotifications returns the buffered digest items of a subscription, oldest first
+func (s *SQLiteStore) GetPendingNotifications(subscriptionID string) []model.PendingNotification {
+	rows, err := s.db.Query(`
+		SELECT subscription_id, product_id, product_name, product_category, product_price,
+		       product_image_url, product_url, created_at
+		FROM pending_notifications
+		WHERE subscription_id = ?
+		ORDER BY created_at ASC
+	`, subscriptionID)
 	if err != nil {
-		return []*model.NotificationHistory{}, 0
+		return []model.PendingNotification{}
 	}
 	defer rows.Close()
 
-	var history []*model.NotificationHistory
+	items := []model.PendingNotification{}
 	for rows.Next() {
-		h := &model.NotificationHistory{}
+		var p model.PendingNotification
+		var category, imageURL, productURL sql.NullString
+		var price sql.NullFloat64
 		var created int64
-		var readAt sql.NullInt64
-		var barkKeyFull sql.NullString
-
-		err := rows.Scan(&h.ID, &h.SubscriptionID, &h.ProductID, &h.ProductName, &h.ProductCategory,
-			&h.ProductPrice, &h.ProductImageURL, &h.ProductSpecs, &h.NotificationType, &h.Status,
-			&h.ErrorMessage, &barkKeyFull, &h.BarkKeyMasked, &created, &readAt)
-		if err != nil {
+		if err := rows.Scan(&p.SubscriptionID, &p.ProductID, &p.ProductName, &category, &price,
+			&imageURL, &productURL, &created); err != nil {
 			continue
 		}
-
-		h.CreatedAt = time.Unix(created, 0)
-		if readAt.Valid {
-			readTime := time.Unix(readAt.Int64, 0)
-			h.ReadAt = &readTime
-		}
-
-		history = append(history, h)
+		p.ProductCategory = category.String
+		p.ProductPrice = price.Float64
+		p.ProductImageURL = imageURL.String
+		p.ProductURL = productURL.String
+		p.CreatedAt = time.Unix(created, 0)
+		items = append(items, p)
 	}
 
-	return history, total
+	return items
 }
 
-// MarkNotificationAsRead marks a notification as read
-func (s *SQLiteStore) MarkNotificationAsRead(id string) error {
+// ClearPendingNotifications drops the buffered digest items of a subscription
+func (s *SQLiteStore) ClearPendingNotifications(subscriptionID string) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	_, err := s.db.Exec("UPDATE notification_history SET read_at = ? WHERE id = ?", time.Now().Unix(), id)
+	_, err := s.db.Exec("DELETE FROM pending_notifications WHERE subscription_id = ?", subscriptionID)
 	return err
 }
 
-// GetUnreadNotificationCount returns the count of unread notifications
-func (s *SQLiteStore) GetUnreadNotificationCount() int {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
-
-	var count int
-	_ = s.db.QueryRow("SELECT COUNT(*) FROM notification_history WHERE read_at IS NULL").Scan(&count)
-	return count
-}
-
-// UpdateNewArrivalSubscription updates an existing subscription
-func (s *SQLiteStore) UpdateNewArrivalSubscription(sub *model.NewArrivalSubscription) error {
+// MigrateBarkKey re-associates all subscriptions and notification history from oldKey to newKey
+// in a single transaction
+func (s *SQLiteStore) MigrateBarkKey(oldKey, newKey string) (*model.KeyMigrationResult, error) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	// Use json.Marshal for proper JSON encoding
-	categoriesJSON, _ := json.Marshal(sub.Categories)
-	modelsJSON, _ := json.Marshal(sub.Models)
-	chipsJSON, _ := json.Marshal(sub.Chips)
-	storagesJSON, _ := json.Marshal(sub.Storages)
-	memoriesJSON, _ := json.Marshal(sub.Memories)
-	stockStatusesJSON, _ := json.Marshal(sub.StockStatuses)
-	keywordsJSON, _ := json.Marshal(sub.Keywords)
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
 
-	paused := 0
-	if sub.Paused {
-		paused = 1
+	result := &model.KeyMigrationResult{}
+
+	res, err := tx.Exec("UPDATE subscriptions SET bark_key = ? WHERE bark_key = ?", newKey, oldKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to migrate subscriptions: %w", err)
 	}
+	count, _ := res.RowsAffected()
+	result.Subscriptions = int(count)
 
-	enabled := 1
-	if !sub.Enabled {
-		enabled = 0
+	res, err = tx.Exec("UPDATE new_arrival_subscriptions SET bark_key = ?, updated_at = ? WHERE bark_key = ?",
+		newKey, time.Now().Unix(), oldKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to migrate new arrival subscriptions: %w", err)
 	}
+	count, _ = res.RowsAffected()
+	result.NewArrivalSubscriptions = int(count)
 
-	var updatedAt int64
-	if !sub.UpdatedAt.IsZero() {
-		updatedAt = sub.UpdatedAt.Unix()
+	res, err = tx.Exec("UPDATE notification_history SET bark_key = ?, bark_key_masked = ? WHERE bark_key = ?",
+		newKey, maskBarkKey(newKey), oldKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to migrate notification history: %w", err)
+	}
+	count, _ = res.RowsAffected()
+	result.NotificationHistory = int(count)
+
+	// Preferences follow the key; the old key's settings win
+	if _, err := tx.Exec(`
+		DELETE FROM user_preferences WHERE bark_key = ? AND EXISTS (SELECT 1 FROM user_preferences WHERE bark_key = ?)
+	`, newKey, oldKey); err != nil {
+		return nil, fmt.Errorf("failed to migrate preferences: %w", err)
+	}
+	if _, err := tx.Exec("UPDATE user_preferences SET bark_key = ? WHERE bark_key = ?", newKey, oldKey); err != nil {
+		return nil, fmt.Errorf("failed to migrate preferences: %w", err)
 	}
 
-	_, err := s.db.Exec(`
-		UPDATE new_arrival_subscriptions
-		SET name = ?, description = ?, categories = ?, models = ?, chips = ?, storages = ?,
-		    memories = ?, stock_statuses = ?, min_price = ?, max_price = ?,
-		    keywords = ?, bark_key = ?, enabled = ?, paused = ?, updated_at = ?
-		WHERE id = ?
-	`, sub.Name, sub.Description, string(categoriesJSON), string(modelsJSON), string(chipsJSON), string(storagesJSON),
-		string(memoriesJSON), string(stockStatusesJSON), sub.MinPrice, sub.MaxPrice,
-		string(keywordsJSON), sub.BarkKey, enabled, paused, updatedAt, sub.ID)
+	// So does the user; an unregistered user of the new key gives way
+	if _, err := tx.Exec(`
+		DELETE FROM users WHERE bark_key = ? AND api_key_hash = '' AND EXISTS (SELECT 1 FROM users WHERE bark_key = ?)
+	`, newKey, oldKey); err != nil {
+		return nil, fmt.Errorf("failed to migrate user: %w", err)
+	}
+	if _, err := tx.Exec("UPDATE users SET bark_key = ?, updated_at = ? WHERE bark_key = ?", newKey, time.Now().Unix(), oldKey); err != nil {
+		return nil, fmt.Errorf("failed to migrate user: %w", err)
+	}
 
-	return err
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit key migration: %w", err)
+	}
+
+	return result, nil
 }
 
-// PauseSubscription pauses a subscription
-func (s *SQLiteStore) PauseSubscription(id string) error {
+// AddUser stores a new user; each Bark Key belongs to at most one user
+func (s *SQLiteStore) AddUser(user *model.User) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	_, err := s.db.Exec("UPDATE new_arrival_subscriptions SET paused = 1 WHERE id = ?", id)
+	var updatedAt int64
+	if !user.UpdatedAt.IsZero() {
+		updatedAt = user.UpdatedAt.Unix()
+	}
+
+	_, err := s.db.Exec(`
+		INSERT INTO users (id, name, bark_key, api_key_hash, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`, user.ID, user.Name, user.BarkKey, user.APIKeyHash, user.CreatedAt.Unix(), updatedAt)
 	return err
 }
 
-// ResumeSubscription resumes a paused subscription
-func (s *SQLiteStore) ResumeSubscription(id string) error {
+// UpdateUser saves a user's name, Bark Key and API key hash
+func (s *SQLiteStore) UpdateUser(user *model.User) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	_, err := s.db.Exec("UPDATE new_arrival_subscriptions SET paused = 0 WHERE id = ?", id)
-	return err
+	var updatedAt int64
+	if !user.UpdatedAt.IsZero() {
+		updatedAt = user.UpdatedAt.Unix()
+	}
+
+	res, err := s.db.Exec(`
+		UPDATE users SET name = ?, bark_key = ?, api_key_hash = ?, updated_at = ? WHERE id = ?
+	`, user.Name, user.BarkKey, user.APIKeyHash, updatedAt, user.ID)
+	if err != nil {
+		return err
+	}
+	if count, _ := res.RowsAffected(); count == 0 {
+		return fmt.Errorf("user not found")
+	}
+	return nil
 }
 
-// IncrementNotificationCount increments the notification count for a subscription
-func (s *SQLiteStore) IncrementNotificationCount(id string) error {
+// GetUserByBarkKey returns the user owning a Bark Key
+func (s *SQLiteStore) GetUserByBarkKey(barkKey string) (*model.User, bool) {
+	if barkKey == "" {
+		return nil, false
+	}
+	return s.getUser("bark_key = ?", barkKey)
+}
+
+// GetUserByAPIKeyHash returns the registered user with an API key hash
+func (s *SQLiteStore) GetUserByAPIKeyHash(hash string) (*model.User, bool) {
+	if hash == "" {
+		return nil, false
+	}
+	return s.getUser("api_key_hash = ?", hash)
+}
+
+// getUser reads the user matching where (a condition with one placeholder)
+func (s *SQLiteStore) getUser(where string, arg any) (*model.User, bool) {
+	user := &model.User{}
+	var name, apiKeyHash sql.NullString
+	var created int64
+	var updated sql.NullInt64
+	err := s.db.QueryRow(`
+		SELECT id, name, bark_key, api_key_hash, created_at, updated_at FROM users WHERE `+where, arg,
+	).Scan(&user.ID, &name, &user.BarkKey, &apiKeyHash, &created, &updated)
+	if err != nil {
+		return nil, false
+	}
+	user.Name = name.String
+	user.APIKeyHash = apiKeyHash.String
+	user.CreatedAt = time.Unix(created, 0)
+	if updated.Valid && updated.Int64 > 0 {
+		user.UpdatedAt = time.Unix(updated.Int64, 0)
+	}
+	return user, true
+}
+
+// CreateAPIToken generates a new admin API token and stores its hash.
+// The plaintext token is returned once and never persisted.
+func (s *SQLiteStore) CreateAPIToken(name string) (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate token: %w", err)
+	}
+	token := hex.EncodeToString(buf)
+
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
 	_, err := s.db.Exec(`
-		UPDATE new_arrival_subscriptions
-		SET notification_count = notification_count + 1, last_notified_at = ?
-		WHERE id = ?
-	`, time.Now().Unix(), id)
+		INSERT INTO api_tokens (token_hash, name, created_at, revoked)
+		VALUES (?, ?, ?, 0)
+	`, hashAPIToken(token), name, time.Now().Unix())
+	if err != nil {
+		return "", fmt.Errorf("failed to store token: %w", err)
+	}
 
-	return err
+	return token, nil
+}
+
+// ValidateAPIToken reports whether token matches a non-revoked admin API token
+func (s *SQLiteStore) ValidateAPIToken(token string) bool {
+	if token == "" {
+		return false
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	hash := hashAPIToken(token)
+	var revoked int
+	err := s.db.QueryRow(`SELECT revoked FROM api_tokens WHERE token_hash = ?`, hash).Scan(&revoked)
+	if err != nil || revoked != 0 {
+		return false
+	}
+
+	// Best effort: a read-only or full disk must not lock admins out
+	s.db.Exec(`UPDATE api_tokens SET last_used_at = ? WHERE token_hash = ?`, time.Now().Unix(), hash)
+	return true
+}
+
+// hashAPIToken returns the hex-encoded SHA-256 of token
+func hashAPIToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
 }
 
 // GetScraperStatus returns the current scraper status
 func (s *SQLiteStore) GetScraperStatus() *model.ScraperStatus {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
-
 	status := &model.ScraperStatus{}
 	var lastTime, updatedAt sql.NullInt64
 	var scrapeErr sql.NullString
@@ -1583,3 +4281,54 @@ func (s *SQLiteStore) UpdateScraperStatus(status *model.ScraperStatus) error {
 
 	return err
 }
+
+// GetRegionScraperStatuses returns the last scrape status of every region, by region code
+func (s *SQLiteStore) GetRegionScraperStatuses() []*model.ScraperStatus {
+	rows, err := s.db.Query(`
+		SELECT region, last_scrape_time, last_scrape_status, last_scrape_error,
+			   products_scraped, duration_ms
+		FROM region_scraper_status ORDER BY region
+	`)
+	if err != nil {
+		slog.Error("Failed to query region scraper status", "error", err)
+		return nil
+	}
+	defer rows.Close()
+
+	var statuses []*model.ScraperStatus
+	for rows.Next() {
+		status := &model.ScraperStatus{}
+		var lastTime sql.NullInt64
+		var scrapeErr sql.NullString
+		if err := rows.Scan(&status.Region, &lastTime, &status.LastScrapeStatus, &scrapeErr,
+			&status.ProductsScraped, &status.Duration); err != nil {
+			continue
+		}
+		if lastTime.Valid {
+			status.LastScrapeTime = time.Unix(lastTime.Int64, 0)
+		}
+		status.LastScrapeError = scrapeErr.String
+		statuses = append(statuses, status)
+	}
+	return statuses
+}
+
+// UpdateRegionScraperStatus updates the scrape status of status.Region
+func (s *SQLiteStore) UpdateRegionScraperStatus(status *model.ScraperStatus) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var lastTime interface{}
+	if !status.LastScrapeTime.IsZero() {
+		lastTime = status.LastScrapeTime.Unix()
+	}
+
+	_, err := s.db.Exec(`
+		INSERT OR REPLACE INTO region_scraper_status
+		(region, last_scrape_time, last_scrape_status, last_scrape_error, products_scraped, duration_ms, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`, status.Region, lastTime, status.LastScrapeStatus, status.LastScrapeError,
+		status.ProductsScraped, status.Duration, time.Now().Unix())
+
+	return err
+}
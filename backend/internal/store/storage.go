@@ -0,0 +1,203 @@
+package store
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"apple-price/internal/model"
+)
+
+// DefaultMinFreeBytes is the free space below which the data directory is treated as full
+const DefaultMinFreeBytes = 100 * 1024 * 1024
+
+// OperatorAlerter delivers storage alerts to the operator channel
+type OperatorAlerter interface {
+	NotifyOperator(title, content string) error
+}
+
+// StorageGuard watches the data directory and flips the service into read-only
+// mode when it becomes unwritable or runs low on space, so writes are refused
+// up front instead of failing halfway through a save.
+type StorageGuard struct {
+	dataDir  string
+	minFree  uint64
+	alerter  OperatorAlerter
+	status   model.StorageStatus
+	mu       sync.RWMutex
+	stopCh   chan struct{}
+	stopOnce sync.Once
+}
+
+// NewStorageGuard creates a guard for dataDir and runs the startup check.
+// alerter may be nil, in which case transitions are only logged.
+func NewStorageGuard(dataDir string, minFreeBytes uint64, alerter OperatorAlerter) *StorageGuard {
+	g := &StorageGuard{
+		dataDir: dataDir,
+		minFree: minFreeBytes,
+		alerter: alerter,
+		stopCh:  make(chan struct{}),
+	}
+
+	status := g.Check()
+	if status.ReadOnly {
+		slog.Warn("Data directory is not usable, starting in read-only mode", "data_dir", dataDir, "reason", status.Reason)
+	}
+
+	return g
+}
+
+// Start re-checks storage periodically until Stop is called
+func (g *StorageGuard) Start(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				g.Check()
+			case <-g.stopCh:
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops periodic checks
+func (g *StorageGuard) Stop() {
+	g.stopOnce.Do(func() { close(g.stopCh) })
+}
+
+// IsReadOnly reports whether writes should currently be refused
+func (g *StorageGuard) IsReadOnly() bool {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return g.status.ReadOnly
+}
+
+// Status returns the most recent storage status
+func (g *StorageGuard) Status() model.StorageStatus {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return g.status
+}
+
+// Check probes the data directory for writability and free space,
+// updates the status and alerts the operator when read-only mode changes
+func (g *StorageGuard) Check() model.StorageStatus {
+	status := model.StorageStatus{
+		Writable:  true,
+		MinFree:   g.minFree,
+		CheckedAt: time.Now(),
+	}
+
+	if err := probeWritable(g.dataDir); err != nil {
+		status.Writable = false
+		status.ReadOnly = true
+		status.Reason = err.Error()
+	}
+
+	if free, ok := freeSpace(g.dataDir); ok {
+		status.FreeBytes = free
+		if !status.ReadOnly && g.minFree > 0 && free < g.minFree {
+			status.ReadOnly = true
+			status.Reason = fmt.Sprintf("free space %d MB below minimum %d MB", free/1024/1024, g.minFree/1024/1024)
+		}
+	}
+
+	g.mu.Lock()
+	changed := g.status.ReadOnly != status.ReadOnly || g.status.CheckedAt.IsZero()
+	wasReadOnly := g.status.ReadOnly
+	g.status = status
+	g.mu.Unlock()
+
+	if changed && (status.ReadOnly || wasReadOnly) {
+		g.alert(status)
+	}
+
+	return status
+}
+
+// alert logs the transition and forwards it to the operator channel
+func (g *StorageGuard) alert(status model.StorageStatus) {
+	var title, content string
+	if status.ReadOnly {
+		title = "⚠️ ApplePrice 存储不可写"
+		content = fmt.Sprintf("数据目录 %s 已切换为只读模式: %s", g.dataDir, status.Reason)
+		slog.Error("Storage degraded to read-only mode", "data_dir", g.dataDir, "reason", status.Reason)
+	} else {
+		title = "✅ ApplePrice 存储已恢复"
+		content = fmt.Sprintf("数据目录 %s 已恢复可写", g.dataDir)
+		slog.Info("Storage recovered, leaving read-only mode", "data_dir", g.dataDir)
+	}
+
+	if g.alerter == nil {
+		return
+	}
+	if err := g.alerter.NotifyOperator(title, content); err != nil {
+		slog.Error("Failed to alert operator about storage status", "error", err)
+	}
+}
+
+// probeWritable creates, syncs and removes a small file in dir
+func probeWritable(dir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("cannot create data directory: %w", err)
+	}
+
+	f, err := os.CreateTemp(dir, ".write-probe-*")
+	if err != nil {
+		return fmt.Errorf("data directory is not writable: %w", err)
+	}
+	name := f.Name()
+	defer os.Remove(name)
+
+	if _, err := f.Write([]byte("ok")); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write to data directory: %w", err)
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to sync data directory: %w", err)
+	}
+	return f.Close()
+}
+
+// writeFileAtomic writes data to a temp file and renames it over path,
+// so a failed write never leaves a truncated file behind
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	tmp := f.Name()
+
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	if err := os.Chmod(tmp, perm); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return nil
+}
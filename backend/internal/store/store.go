@@ -3,10 +3,12 @@ package store
 import (
 	"encoding/json"
 	"fmt"
+	"log/slog"
 	"os"
 	"path/filepath"
-	"strings"
+	"sort"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"apple-price/internal/model"
@@ -14,6 +16,9 @@ import (
 
 const (
 	maxHistoryPerProduct = 100
+
+	// maxScrapeRuns is how many scrape run records are kept
+	maxScrapeRuns = 500
 )
 
 // Store manages in-memory product data with JSON persistence
@@ -25,10 +30,36 @@ type Store struct {
 	subscriptions     map[string]*model.Subscription
 	subscriptionsByProduct map[string][]string // productID -> subscriptionIDs
 	newArrivalSubscriptions map[string]*model.NewArrivalSubscription
+	deletedNewArrivalSubscriptions map[string]*model.NewArrivalSubscription // ID -> soft-deleted, restorable subscription
+	pendingNotifications   map[string][]model.PendingNotification // subscriptionID -> buffered digest items
 	notificationHistory    []*model.NotificationHistory
+	productEvents     []model.ProductEvent
+	preferences       map[string]*model.UserPreferences // barkKey -> preferences
+	deliveryHealth    map[string]*model.DeliveryHealth  // barkKey -> push failure tracking
+	regionDeletions   map[string]*pendingDeletion       // deletion ID -> undo data
+	dailyStats        map[string]model.DailyCategoryStats // date|category|region -> aggregate
+	priceIndex        map[string]model.DailyPriceIndex    // date|tier|region -> spec tier price index
+	statsHistory      map[string]model.StatsSnapshot      // date|region -> catalog snapshot
+	annotations       map[string]model.PriceAnnotation    // ID -> annotation
+	retailerPrices    map[string][]model.RetailerPrice    // product ID -> new prices at retailers
+	regions           map[string]*model.Region            // code -> storefront
+	watchlists        map[string]*model.Watchlist         // ID -> watchlist
+	scrapeRuns        []*model.ScrapeRun                  // oldest first, at most maxScrapeRuns
+	notificationRetries map[string]*model.NotificationRetry // ID -> failed push awaiting retry
+	heldNotifications map[string]*model.HeldNotification  // ID -> push held for quiet hours
+	pendingDetails    map[string]*model.PendingDetail     // product ID -> queued detail fetch
+	savedSearches     map[string]*model.SavedSearch       // slug -> saved search
+	productOverrides  map[string]*model.ProductOverride   // product ID -> fields pinned by an admin
+	categorySorts     map[string]string                   // category -> default product sort
+	users             map[string]*model.User              // ID -> user
+	retention         retentionState
 	dataDir           string
 	lastScrapeTime    time.Time
 	scraperStatus     *model.ScraperStatus
+	scoreRecompute    *model.ScoreRecompute // last value score recomputation, nil before the first
+	regionScraperStatus map[string]*model.ScraperStatus // region -> status of its last scrape
+	productsVersion   atomic.Uint64 // bumped after every product change
+	velocity          velocityCache // inventory velocity as of productsVersion
 }
 
 // New creates a new Store instance
@@ -40,7 +71,27 @@ func New(dataDir string) (*Store, error) {
 		subscriptions:            make(map[string]*model.Subscription),
 		subscriptionsByProduct:   make(map[string][]string),
 		newArrivalSubscriptions:  make(map[string]*model.NewArrivalSubscription),
+		deletedNewArrivalSubscriptions: make(map[string]*model.NewArrivalSubscription),
+		pendingNotifications:     make(map[string][]model.PendingNotification),
 		notificationHistory:      make([]*model.NotificationHistory, 0),
+		preferences:              make(map[string]*model.UserPreferences),
+		deliveryHealth:           make(map[string]*model.DeliveryHealth),
+		regionDeletions:          make(map[string]*pendingDeletion),
+		dailyStats:               make(map[string]model.DailyCategoryStats),
+		priceIndex:               make(map[string]model.DailyPriceIndex),
+		statsHistory:             make(map[string]model.StatsSnapshot),
+		annotations:              make(map[string]model.PriceAnnotation),
+		retailerPrices:           make(map[string][]model.RetailerPrice),
+		regions:                  make(map[string]*model.Region),
+		watchlists:               make(map[string]*model.Watchlist),
+		notificationRetries:      make(map[string]*model.NotificationRetry),
+		heldNotifications:        make(map[string]*model.HeldNotification),
+		pendingDetails:           make(map[string]*model.PendingDetail),
+		savedSearches:            make(map[string]*model.SavedSearch),
+		productOverrides:         make(map[string]*model.ProductOverride),
+		categorySorts:            make(map[string]string),
+		users:                    make(map[string]*model.User),
+		regionScraperStatus:      make(map[string]*model.ScraperStatus),
 		dataDir:                  dataDir,
 	}
 
@@ -52,7 +103,7 @@ func New(dataDir string) (*Store, error) {
 	// Load existing data
 	if err := s.Load(); err != nil {
 		// Don't fail on first run, just log
-		fmt.Printf("Warning: failed to load data: %v\n", err)
+		slog.Warn("Failed to load data", "data_dir", dataDir, "error", err)
 	}
 
 	return s, nil
@@ -112,9 +163,302 @@ func (s *Store) Load() error {
 		s.notificationHistory = notifHistory
 	}
 
+	// Load product events
+	eventsFile := filepath.Join(s.dataDir, "product_events.json")
+	if data, err := os.ReadFile(eventsFile); err == nil {
+		var events []model.ProductEvent
+		if err := json.Unmarshal(data, &events); err != nil {
+			return fmt.Errorf("failed to unmarshal product events: %w", err)
+		}
+		s.productEvents = events
+	}
+
+	// Load preferences
+	prefsFile := filepath.Join(s.dataDir, "preferences.json")
+	if data, err := os.ReadFile(prefsFile); err == nil {
+		var prefs []storedPreferences
+		if err := json.Unmarshal(data, &prefs); err != nil {
+			return fmt.Errorf("failed to unmarshal preferences: %w", err)
+		}
+		for _, p := range prefs {
+			pref := p.UserPreferences
+			pref.BarkKey = p.BarkKey
+			s.preferences[p.BarkKey] = &pref
+		}
+	}
+
+	// Load delivery health
+	healthFile := filepath.Join(s.dataDir, "delivery_health.json")
+	if data, err := os.ReadFile(healthFile); err == nil {
+		var health []storedDeliveryHealth
+		if err := json.Unmarshal(data, &health); err != nil {
+			return fmt.Errorf("failed to unmarshal delivery health: %w", err)
+		}
+		for _, h := range health {
+			dh := h.DeliveryHealth
+			dh.BarkKey = h.BarkKey
+			s.deliveryHealth[h.BarkKey] = &dh
+		}
+	}
+
+	// Load pending region deletions
+	deletionsFile := filepath.Join(s.dataDir, "region_deletions.json")
+	if data, err := os.ReadFile(deletionsFile); err == nil {
+		var deletions map[string]*pendingDeletion
+		if err := json.Unmarshal(data, &deletions); err != nil {
+			return fmt.Errorf("failed to unmarshal region deletions: %w", err)
+		}
+		if deletions != nil {
+			s.regionDeletions = deletions
+		}
+	}
+
+	// Load daily stats timeline
+	dailyStatsFile := filepath.Join(s.dataDir, "daily_stats.json")
+	if data, err := os.ReadFile(dailyStatsFile); err == nil {
+		var points []model.DailyCategoryStats
+		if err := json.Unmarshal(data, &points); err != nil {
+			return fmt.Errorf("failed to unmarshal daily stats: %w", err)
+		}
+		for _, p := range points {
+			s.dailyStats[p.Date+"|"+p.Category+"|"+p.Region] = p
+		}
+	}
+
+	// Load spec tier price index
+	priceIndexFile := filepath.Join(s.dataDir, "price_index.json")
+	if data, err := os.ReadFile(priceIndexFile); err == nil {
+		var points []model.DailyPriceIndex
+		if err := json.Unmarshal(data, &points); err != nil {
+			return fmt.Errorf("failed to unmarshal price index: %w", err)
+		}
+		for _, p := range points {
+			s.priceIndex[p.Date+"|"+p.Tier+"|"+p.Region] = p
+		}
+	}
+
+	// Load daily catalog snapshots
+	statsHistoryFile := filepath.Join(s.dataDir, "stats_history.json")
+	if data, err := os.ReadFile(statsHistoryFile); err == nil {
+		var snapshots []model.StatsSnapshot
+		if err := json.Unmarshal(data, &snapshots); err != nil {
+			return fmt.Errorf("failed to unmarshal stats history: %w", err)
+		}
+		for _, snap := range snapshots {
+			s.statsHistory[snap.Date+"|"+snap.Region] = snap
+		}
+	}
+
+	// Load price annotations
+	annotationsFile := filepath.Join(s.dataDir, "annotations.json")
+	if data, err := os.ReadFile(annotationsFile); err == nil {
+		var annotations []model.PriceAnnotation
+		if err := json.Unmarshal(data, &annotations); err != nil {
+			return fmt.Errorf("failed to unmarshal annotations: %w", err)
+		}
+		for _, a := range annotations {
+			s.annotations[a.ID] = a
+		}
+	}
+
+	// Load retailer prices
+	retailerPricesFile := filepath.Join(s.dataDir, "retailer_prices.json")
+	if data, err := os.ReadFile(retailerPricesFile); err == nil {
+		if err := json.Unmarshal(data, &s.retailerPrices); err != nil {
+			return fmt.Errorf("failed to unmarshal retailer prices: %w", err)
+		}
+	}
+
+	// Load region registry, seeding the defaults on first run
+	regionsFile := filepath.Join(s.dataDir, "regions.json")
+	if data, err := os.ReadFile(regionsFile); err == nil {
+		var regions []*model.Region
+		if err := json.Unmarshal(data, &regions); err != nil {
+			return fmt.Errorf("failed to unmarshal regions: %w", err)
+		}
+		for _, r := range regions {
+			s.regions[r.Code] = r
+		}
+	} else {
+		for _, r := range model.DefaultRegions() {
+			s.regions[r.Code] = r
+		}
+	}
+
+	// Load per-category default sorts
+	categorySortsFile := filepath.Join(s.dataDir, "category_sorts.json")
+	if data, err := os.ReadFile(categorySortsFile); err == nil {
+		if err := json.Unmarshal(data, &s.categorySorts); err != nil {
+			return fmt.Errorf("failed to unmarshal category sorts: %w", err)
+		}
+	}
+
+	// Load scrape run records
+	scrapeRunsFile := filepath.Join(s.dataDir, "scrape_runs.json")
+	if data, err := os.ReadFile(scrapeRunsFile); err == nil {
+		if err := json.Unmarshal(data, &s.scrapeRuns); err != nil {
+			return fmt.Errorf("failed to unmarshal scrape runs: %w", err)
+		}
+		// Only successful runs used to be recorded, without a status or pages
+		for _, run := range s.scrapeRuns {
+			if run.Status == "" {
+				run.Status = "success"
+			}
+			if run.Pages == nil {
+				run.Pages = []model.ScrapeRunPage{}
+			}
+		}
+	}
+
+	// Load watchlists
+	watchlistsFile := filepath.Join(s.dataDir, "watchlists.json")
+	if data, err := os.ReadFile(watchlistsFile); err == nil {
+		var watchlists []storedWatchlist
+		if err := json.Unmarshal(data, &watchlists); err != nil {
+			return fmt.Errorf("failed to unmarshal watchlists: %w", err)
+		}
+		for _, w := range watchlists {
+			watchlist := w.Watchlist
+			watchlist.ClientToken = w.ClientToken
+			s.watchlists[watchlist.ID] = &watchlist
+		}
+	}
+
+	// Load notification retry queue
+	retriesFile := filepath.Join(s.dataDir, "notification_retries.json")
+	if data, err := os.ReadFile(retriesFile); err == nil {
+		var retries []*model.NotificationRetry
+		if err := json.Unmarshal(data, &retries); err != nil {
+			return fmt.Errorf("failed to unmarshal notification retries: %w", err)
+		}
+		for _, r := range retries {
+			s.notificationRetries[r.ID] = r
+		}
+	}
+
+	// Load pushes held for quiet hours
+	heldFile := filepath.Join(s.dataDir, "held_notifications.json")
+	if data, err := os.ReadFile(heldFile); err == nil {
+		var held []*model.HeldNotification
+		if err := json.Unmarshal(data, &held); err != nil {
+			return fmt.Errorf("failed to unmarshal held notifications: %w", err)
+		}
+		for _, n := range held {
+			s.heldNotifications[n.ID] = n
+		}
+	}
+
+	// Load detail fetch queue
+	pendingDetailsFile := filepath.Join(s.dataDir, "pending_details.json")
+	if data, err := os.ReadFile(pendingDetailsFile); err == nil {
+		var pending []*model.PendingDetail
+		if err := json.Unmarshal(data, &pending); err != nil {
+			return fmt.Errorf("failed to unmarshal pending details: %w", err)
+		}
+		for _, d := range pending {
+			s.pendingDetails[d.ProductID] = d
+		}
+	}
+
+	// Load saved searches
+	savedSearchesFile := filepath.Join(s.dataDir, "saved_searches.json")
+	if data, err := os.ReadFile(savedSearchesFile); err == nil {
+		var searches []*model.SavedSearch
+		if err := json.Unmarshal(data, &searches); err != nil {
+			return fmt.Errorf("failed to unmarshal saved searches: %w", err)
+		}
+		for _, search := range searches {
+			s.savedSearches[search.Slug] = search
+		}
+	}
+
+	// Load product overrides
+	overridesFile := filepath.Join(s.dataDir, "product_overrides.json")
+	if data, err := os.ReadFile(overridesFile); err == nil {
+		var overrides []*model.ProductOverride
+		if err := json.Unmarshal(data, &overrides); err != nil {
+			return fmt.Errorf("failed to unmarshal product overrides: %w", err)
+		}
+		for _, o := range overrides {
+			s.productOverrides[o.ProductID] = o
+		}
+	}
+
+	// Load users
+	usersFile := filepath.Join(s.dataDir, "users.json")
+	if data, err := os.ReadFile(usersFile); err == nil {
+		var users []storedUser
+		if err := json.Unmarshal(data, &users); err != nil {
+			return fmt.Errorf("failed to unmarshal users: %w", err)
+		}
+		for _, u := range users {
+			user := u.User
+			user.APIKeyHash = u.APIKeyHash
+			s.users[user.ID] = &user
+		}
+	}
+	s.migrateBarkKeysToUsersLocked()
+	s.migrateAccessoryCategoriesLocked()
+	s.migrateStableIDsLocked()
+
 	return nil
 }
 
+// migrateBarkKeysToUsersLocked creates an unregistered user for every Bark Key
+// in use that has none yet. Caller must hold s.mu.
+func (s *Store) migrateBarkKeysToUsersLocked() {
+	owned := make(map[string]bool, len(s.users))
+	for _, u := range s.users {
+		owned[u.BarkKey] = true
+	}
+
+	now := time.Now()
+	add := func(barkKey string) {
+		if barkKey == "" || owned[barkKey] {
+			return
+		}
+		owned[barkKey] = true
+		user := &model.User{ID: model.NewUserID(), BarkKey: barkKey, CreatedAt: now}
+		s.users[user.ID] = user
+	}
+	for _, sub := range s.subscriptions {
+		add(sub.BarkKey)
+	}
+	for _, sub := range s.newArrivalSubscriptions {
+		add(sub.BarkKey)
+	}
+	for key := range s.preferences {
+		add(key)
+	}
+}
+
+// storedUser is the on-disk form of User; APIKeyHash is hidden from API JSON,
+// so it is persisted explicitly
+type storedUser struct {
+	APIKeyHash string `json:"api_key_hash"`
+	model.User
+}
+
+// storedWatchlist is the on-disk form of Watchlist; ClientToken is hidden
+// from API JSON, so it is persisted explicitly
+type storedWatchlist struct {
+	ClientToken string `json:"client_token"`
+	model.Watchlist
+}
+
+// storedPreferences is the on-disk form of UserPreferences; BarkKey is hidden
+// from API JSON, so it is persisted explicitly
+type storedPreferences struct {
+	BarkKey string `json:"bark_key"`
+	model.UserPreferences
+}
+
+// storedDeliveryHealth is the on-disk form of DeliveryHealth, with its Bark Key
+type storedDeliveryHealth struct {
+	BarkKey string `json:"bark_key"`
+	model.DeliveryHealth
+}
+
 // Save saves data to JSON files
 func (s *Store) Save() error {
 	s.mu.RLock()
@@ -129,7 +473,7 @@ func (s *Store) Save() error {
 	if err != nil {
 		return fmt.Errorf("failed to marshal products: %w", err)
 	}
-	if err := os.WriteFile(filepath.Join(s.dataDir, "products.json"), productsData, 0644); err != nil {
+	if err := writeFileAtomic(filepath.Join(s.dataDir, "products.json"), productsData, 0644); err != nil {
 		return fmt.Errorf("failed to write products: %w", err)
 	}
 
@@ -138,7 +482,7 @@ func (s *Store) Save() error {
 	if err != nil {
 		return fmt.Errorf("failed to marshal history: %w", err)
 	}
-	if err := os.WriteFile(filepath.Join(s.dataDir, "history.json"), historyData, 0644); err != nil {
+	if err := writeFileAtomic(filepath.Join(s.dataDir, "history.json"), historyData, 0644); err != nil {
 		return fmt.Errorf("failed to write history: %w", err)
 	}
 
@@ -147,7 +491,7 @@ func (s *Store) Save() error {
 	if err != nil {
 		return fmt.Errorf("failed to marshal subscriptions: %w", err)
 	}
-	if err := os.WriteFile(filepath.Join(s.dataDir, "subscriptions.json"), subsData, 0644); err != nil {
+	if err := writeFileAtomic(filepath.Join(s.dataDir, "subscriptions.json"), subsData, 0644); err != nil {
 		return fmt.Errorf("failed to write subscriptions: %w", err)
 	}
 
@@ -156,21 +500,257 @@ func (s *Store) Save() error {
 	if err != nil {
 		return fmt.Errorf("failed to marshal notification history: %w", err)
 	}
-	if err := os.WriteFile(filepath.Join(s.dataDir, "notification_history.json"), notifHistoryData, 0644); err != nil {
+	if err := writeFileAtomic(filepath.Join(s.dataDir, "notification_history.json"), notifHistoryData, 0644); err != nil {
 		return fmt.Errorf("failed to write notification history: %w", err)
 	}
 
+	// Save product events
+	eventsData, err := json.MarshalIndent(s.productEvents, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal product events: %w", err)
+	}
+	if err := writeFileAtomic(filepath.Join(s.dataDir, "product_events.json"), eventsData, 0644); err != nil {
+		return fmt.Errorf("failed to write product events: %w", err)
+	}
+
+	// Save preferences
+	prefs := make([]storedPreferences, 0, len(s.preferences))
+	for key, p := range s.preferences {
+		prefs = append(prefs, storedPreferences{BarkKey: key, UserPreferences: *p})
+	}
+	prefsData, err := json.MarshalIndent(prefs, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal preferences: %w", err)
+	}
+	if err := writeFileAtomic(filepath.Join(s.dataDir, "preferences.json"), prefsData, 0644); err != nil {
+		return fmt.Errorf("failed to write preferences: %w", err)
+	}
+
+	// Save delivery health
+	health := make([]storedDeliveryHealth, 0, len(s.deliveryHealth))
+	for key, h := range s.deliveryHealth {
+		health = append(health, storedDeliveryHealth{BarkKey: key, DeliveryHealth: *h})
+	}
+	healthData, err := json.MarshalIndent(health, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal delivery health: %w", err)
+	}
+	if err := writeFileAtomic(filepath.Join(s.dataDir, "delivery_health.json"), healthData, 0644); err != nil {
+		return fmt.Errorf("failed to write delivery health: %w", err)
+	}
+
+	// Save pending region deletions
+	deletionsData, err := json.MarshalIndent(s.regionDeletions, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal region deletions: %w", err)
+	}
+	if err := writeFileAtomic(filepath.Join(s.dataDir, "region_deletions.json"), deletionsData, 0644); err != nil {
+		return fmt.Errorf("failed to write region deletions: %w", err)
+	}
+
+	// Save daily stats timeline
+	points := make([]model.DailyCategoryStats, 0, len(s.dailyStats))
+	for _, p := range s.dailyStats {
+		points = append(points, p)
+	}
+	sortDailyStats(points)
+	dailyStatsData, err := json.MarshalIndent(points, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal daily stats: %w", err)
+	}
+	if err := writeFileAtomic(filepath.Join(s.dataDir, "daily_stats.json"), dailyStatsData, 0644); err != nil {
+		return fmt.Errorf("failed to write daily stats: %w", err)
+	}
+
+	// Save spec tier price index
+	indexPoints := make([]model.DailyPriceIndex, 0, len(s.priceIndex))
+	for _, p := range s.priceIndex {
+		indexPoints = append(indexPoints, p)
+	}
+	sortPriceIndex(indexPoints)
+	priceIndexData, err := json.MarshalIndent(indexPoints, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal price index: %w", err)
+	}
+	if err := writeFileAtomic(filepath.Join(s.dataDir, "price_index.json"), priceIndexData, 0644); err != nil {
+		return fmt.Errorf("failed to write price index: %w", err)
+	}
+
+	// Save daily catalog snapshots
+	snapshots := make([]model.StatsSnapshot, 0, len(s.statsHistory))
+	for _, snap := range s.statsHistory {
+		snapshots = append(snapshots, snap)
+	}
+	sortStatsSnapshots(snapshots)
+	statsHistoryData, err := json.MarshalIndent(snapshots, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal stats history: %w", err)
+	}
+	if err := writeFileAtomic(filepath.Join(s.dataDir, "stats_history.json"), statsHistoryData, 0644); err != nil {
+		return fmt.Errorf("failed to write stats history: %w", err)
+	}
+
+	// Save price annotations
+	annotations := make([]model.PriceAnnotation, 0, len(s.annotations))
+	for _, a := range s.annotations {
+		annotations = append(annotations, a)
+	}
+	sortAnnotations(annotations)
+	annotationsData, err := json.MarshalIndent(annotations, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal annotations: %w", err)
+	}
+	if err := writeFileAtomic(filepath.Join(s.dataDir, "annotations.json"), annotationsData, 0644); err != nil {
+		return fmt.Errorf("failed to write annotations: %w", err)
+	}
+
+	// Save retailer prices
+	retailerPricesData, err := json.MarshalIndent(s.retailerPrices, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal retailer prices: %w", err)
+	}
+	if err := writeFileAtomic(filepath.Join(s.dataDir, "retailer_prices.json"), retailerPricesData, 0644); err != nil {
+		return fmt.Errorf("failed to write retailer prices: %w", err)
+	}
+
+	// Save region registry
+	regionsData, err := json.MarshalIndent(s.sortedRegionsLocked(), "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal regions: %w", err)
+	}
+	if err := writeFileAtomic(filepath.Join(s.dataDir, "regions.json"), regionsData, 0644); err != nil {
+		return fmt.Errorf("failed to write regions: %w", err)
+	}
+
+	// Save per-category default sorts
+	categorySortsData, err := json.MarshalIndent(s.categorySorts, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal category sorts: %w", err)
+	}
+	if err := writeFileAtomic(filepath.Join(s.dataDir, "category_sorts.json"), categorySortsData, 0644); err != nil {
+		return fmt.Errorf("failed to write category sorts: %w", err)
+	}
+
+	// Save scrape run records
+	scrapeRunsData, err := json.MarshalIndent(s.scrapeRuns, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal scrape runs: %w", err)
+	}
+	if err := writeFileAtomic(filepath.Join(s.dataDir, "scrape_runs.json"), scrapeRunsData, 0644); err != nil {
+		return fmt.Errorf("failed to write scrape runs: %w", err)
+	}
+
+	// Save watchlists
+	watchlists := make([]storedWatchlist, 0, len(s.watchlists))
+	for _, w := range s.watchlists {
+		watchlists = append(watchlists, storedWatchlist{ClientToken: w.ClientToken, Watchlist: *w})
+	}
+	sort.Slice(watchlists, func(i, j int) bool { return watchlists[i].CreatedAt.Before(watchlists[j].CreatedAt) })
+	watchlistsData, err := json.MarshalIndent(watchlists, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal watchlists: %w", err)
+	}
+	if err := writeFileAtomic(filepath.Join(s.dataDir, "watchlists.json"), watchlistsData, 0644); err != nil {
+		return fmt.Errorf("failed to write watchlists: %w", err)
+	}
+
+	// Save notification retry queue
+	retries := make([]*model.NotificationRetry, 0, len(s.notificationRetries))
+	for _, r := range s.notificationRetries {
+		retries = append(retries, r)
+	}
+	sort.Slice(retries, func(i, j int) bool { return retries[i].CreatedAt.Before(retries[j].CreatedAt) })
+	retriesData, err := json.MarshalIndent(retries, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal notification retries: %w", err)
+	}
+	if err := writeFileAtomic(filepath.Join(s.dataDir, "notification_retries.json"), retriesData, 0644); err != nil {
+		return fmt.Errorf("failed to write notification retries: %w", err)
+	}
+
+	// Save pushes held for quiet hours
+	held := make([]*model.HeldNotification, 0, len(s.heldNotifications))
+	for _, n := range s.heldNotifications {
+		held = append(held, n)
+	}
+	sort.Slice(held, func(i, j int) bool { return held[i].CreatedAt.Before(held[j].CreatedAt) })
+	heldData, err := json.MarshalIndent(held, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal held notifications: %w", err)
+	}
+	if err := writeFileAtomic(filepath.Join(s.dataDir, "held_notifications.json"), heldData, 0644); err != nil {
+		return fmt.Errorf("failed to write held notifications: %w", err)
+	}
+
+	// Save detail fetch queue
+	pending := make([]*model.PendingDetail, 0, len(s.pendingDetails))
+	for _, d := range s.pendingDetails {
+		pending = append(pending, d)
+	}
+	sort.Slice(pending, func(i, j int) bool { return pending[i].Before(pending[j]) })
+	pendingData, err := json.MarshalIndent(pending, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal pending details: %w", err)
+	}
+	if err := writeFileAtomic(filepath.Join(s.dataDir, "pending_details.json"), pendingData, 0644); err != nil {
+		return fmt.Errorf("failed to write pending details: %w", err)
+	}
+
+	// Save saved searches
+	searches := make([]*model.SavedSearch, 0, len(s.savedSearches))
+	for _, search := range s.savedSearches {
+		searches = append(searches, search)
+	}
+	sort.Slice(searches, func(i, j int) bool { return searches[i].CreatedAt.Before(searches[j].CreatedAt) })
+	searchesData, err := json.MarshalIndent(searches, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal saved searches: %w", err)
+	}
+	if err := writeFileAtomic(filepath.Join(s.dataDir, "saved_searches.json"), searchesData, 0644); err != nil {
+		return fmt.Errorf("failed to write saved searches: %w", err)
+	}
+
+	// Save product overrides
+	overrides := make([]*model.ProductOverride, 0, len(s.productOverrides))
+	for _, o := range s.productOverrides {
+		overrides = append(overrides, o)
+	}
+	sort.Slice(overrides, func(i, j int) bool { return overrides[i].ProductID < overrides[j].ProductID })
+	overridesData, err := json.MarshalIndent(overrides, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal product overrides: %w", err)
+	}
+	if err := writeFileAtomic(filepath.Join(s.dataDir, "product_overrides.json"), overridesData, 0644); err != nil {
+		return fmt.Errorf("failed to write product overrides: %w", err)
+	}
+
+	// Save users
+	users := make([]storedUser, 0, len(s.users))
+	for _, u := range s.users {
+		users = append(users, storedUser{APIKeyHash: u.APIKeyHash, User: *u})
+	}
+	sort.Slice(users, func(i, j int) bool { return users[i].CreatedAt.Before(users[j].CreatedAt) })
+	usersData, err := json.MarshalIndent(users, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal users: %w", err)
+	}
+	if err := writeFileAtomic(filepath.Join(s.dataDir, "users.json"), usersData, 0644); err != nil {
+		return fmt.Errorf("failed to write users: %w", err)
+	}
+
 	return nil
 }
 
-// GetAllProducts returns all products
+// GetAllProducts returns all products except archived ones
 func (s *Store) GetAllProducts() []*model.Product {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
 	products := make([]*model.Product, 0, len(s.products))
 	for _, p := range s.products {
-		products = append(products, p)
+		if !p.Archived {
+			products = append(products, p)
+		}
 	}
 	return products
 }
@@ -191,7 +771,7 @@ func (s *Store) GetProductsByCategory(category string) []*model.Product {
 
 	var products []*model.Product
 	for _, p := range s.products {
-		if p.Category == category {
+		if p.Category == category && !p.Archived {
 			products = append(products, p)
 		}
 	}
@@ -205,7 +785,7 @@ func (s *Store) GetProductsByRegion(region string) []*model.Product {
 
 	var products []*model.Product
 	for _, p := range s.products {
-		if p.Region == region {
+		if p.Region == region && !p.Archived {
 			products = append(products, p)
 		}
 	}
@@ -216,15 +796,41 @@ func (s *Store) GetProductsByRegion(region string) []*model.Product {
 func (s *Store) UpsertProduct(product *model.Product) (priceChanged bool, oldPrice float64) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
+	defer s.productsVersion.Add(1)
+
+	return s.upsertProductLocked(product, time.Now())
+}
+
+// UpsertProducts upserts the products of a scrape cycle under a single lock,
+// returning what changed for each in order
+func (s *Store) UpsertProducts(products []*model.Product) ([]model.UpsertResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	defer s.productsVersion.Add(1)
 
 	now := time.Now()
+	results := make([]model.UpsertResult, len(products))
+	for i, product := range products {
+		results[i].PriceChanged, results[i].OldPrice = s.upsertProductLocked(product, now)
+	}
+	return results, nil
+}
+
+// upsertProductLocked adds or updates a product; the caller holds s.mu
+func (s *Store) upsertProductLocked(product *model.Product, now time.Time) (priceChanged bool, oldPrice float64) {
+	// Corrections pinned by an admin win over scraped values
+	if override, ok := s.productOverrides[product.ID]; ok {
+		override.Apply(product)
+	}
 
 	existing, exists := s.products[product.ID]
 	if exists {
+		// Existing product - always set oldPrice to distinguish from new products
+		oldPrice = existing.Price
+
 		// Check for price change
 		if existing.Price != product.Price {
 			priceChanged = true
-			oldPrice = existing.Price
 
 			// Add to history
 			s.history[product.ID] = append(s.history[product.ID], model.PriceHistory{
@@ -240,10 +846,19 @@ func (s *Store) UpsertProduct(product *model.Product) (priceChanged bool, oldPri
 			}
 		}
 
+		// Record stock transitions for inventory velocity
+		if existing.StockStatus != product.StockStatus {
+			s.addProductEventLocked(product, product.StockStatus, now)
+		}
+
 		// Update created_at to preserve original creation time
 		product.CreatedAt = existing.CreatedAt
+		product.KeepRefurbTerms(existing)
+		product.KeepDominantColor(existing)
+		product.Archived = existing.Archived
 	} else {
 		product.CreatedAt = now
+		s.addProductEventLocked(product, model.EventListed, now)
 
 		// Initialize history with current price for new products
 		s.history[product.ID] = []model.PriceHistory{
@@ -267,24 +882,100 @@ func (s *Store) UpsertProduct(product *model.Product) (priceChanged bool, oldPri
 	return priceChanged, oldPrice
 }
 
+// ProductsVersion changes whenever a product is added, updated or removed, so
+// readers can tell whether data derived from the catalog is still current
+func (s *Store) ProductsVersion() uint64 {
+	return s.productsVersion.Load()
+}
+
+// UpdateStockStatus changes a product's stock status and records the transition
+func (s *Store) UpdateStockStatus(id, status string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	defer s.productsVersion.Add(1)
+
+	p, ok := s.products[id]
+	if !ok {
+		return fmt.Errorf("product not found")
+	}
+	if p.StockStatus == status {
+		return nil
+	}
+
+	now := time.Now()
+	p.StockStatus = status
+	p.UpdatedAt = now
+	s.addProductEventLocked(p, status, now)
+	return nil
+}
+
+// addProductEventLocked appends a product lifecycle event (must be called with lock held)
+func (s *Store) addProductEventLocked(product *model.Product, eventType string, now time.Time) {
+	s.productEvents = append(s.productEvents, model.ProductEvent{
+		ProductID: product.ID,
+		EventType: eventType,
+		Price:     product.Price,
+		CreatedAt: now,
+	})
+}
+
+// GetProductEvents returns lifecycle events for a product in chronological order
+func (s *Store) GetProductEvents(productID string) []model.ProductEvent {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	events := []model.ProductEvent{}
+	for _, e := range s.productEvents {
+		if e.ProductID == productID {
+			events = append(events, e)
+		}
+	}
+	return events
+}
+
+// GetInventoryVelocity returns sell-out velocity per model/config computed from
+// product events, rebuilt only after products changed
+func (s *Store) GetInventoryVelocity() model.InventoryVelocityIndex {
+	return s.velocity.get(s.productsVersion.Load(), s.buildInventoryVelocity)
+}
+
+// buildInventoryVelocity aggregates all product events into the velocity index
+func (s *Store) buildInventoryVelocity() model.InventoryVelocityIndex {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	events := make([]model.ProductEvent, len(s.productEvents))
+	copy(events, s.productEvents)
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].ProductID < events[j].ProductID
+	})
+
+	return model.BuildInventoryVelocity(events, s.products)
+}
+
 // calculateValueScore computes a 0-100 value score based on discount and price history
 func (s *Store) calculateValueScore(product *model.Product, history []model.PriceHistory, now time.Time) float64 {
-	score := 50.0 // Base score
+	return s.scoreBreakdown(product, history, now).Score
+}
+
+// scoreBreakdown computes the value score of product component by component
+func (s *Store) scoreBreakdown(product *model.Product, history []model.PriceHistory, now time.Time) *model.ScoreBreakdown {
+	b := model.NewScoreBreakdown(product.ID, 50)
 
 	// Discount score: 0-30 points
+	discount := product.Discount * 2 // Less than 5% gets proportional score
 	if product.Discount >= 15 {
-		score += 30
+		discount = 30
 	} else if product.Discount >= 12 {
-		score += 25
+		discount = 25
 	} else if product.Discount >= 10 {
-		score += 20
+		discount = 20
 	} else if product.Discount >= 8 {
-		score += 15
+		discount = 15
 	} else if product.Discount >= 5 {
-		score += 10
-	} else {
-		score += product.Discount * 2 // Less than 5% gets proportional score
+		discount = 10
 	}
+	b.Add(model.ScoreDiscount, discount, 30, fmt.Sprintf("比新机便宜 %.1f%%", product.Discount))
 
 	// Price trend score: 0-25 points
 	if len(history) >= 2 {
@@ -292,26 +983,30 @@ func (s *Store) calculateValueScore(product *model.Product, history []model.Pric
 		lastPrice := history[len(history)-1].Price
 		change := (lastPrice - firstPrice) / firstPrice
 
+		trend, reason := 10.0, "价格稳定" // Stable
 		if change < -0.02 { // Price dropped >2%
-			score += 25
+			trend, reason = 25, fmt.Sprintf("价格下降 %.1f%%", -change*100)
 		} else if change < -0.01 { // Price dropped >1%
-			score += 20
+			trend, reason = 20, fmt.Sprintf("价格下降 %.1f%%", -change*100)
 		} else if change < 0 { // Price dropped
-			score += 15
+			trend, reason = 15, fmt.Sprintf("价格小幅下降 %.1f%%", -change*100)
 		} else if change > 0.02 { // Price rose >2%
-			score += 0
-		} else {
-			score += 10 // Stable
+			trend, reason = 0, fmt.Sprintf("价格上涨 %.1f%%", change*100)
 		}
+		b.Add(model.ScoreTrend, trend, 25, reason)
+	} else {
+		b.Add(model.ScoreTrend, 0, 25, "价格记录不足")
 	}
 
-	// Stock status score: 0-15 points
-	if product.StockStatus == "available" {
-		score += 15
-	} else if product.StockStatus == "limited" {
-		score += 10
+	// Stock status score: 0-15 points, sold_out gets 0 points
+	switch product.StockStatus {
+	case "available":
+		b.Add(model.ScoreStock, 15, 15, "有货")
+	case "limited":
+		b.Add(model.ScoreStock, 10, 15, "库存紧张")
+	default:
+		b.Add(model.ScoreStock, 0, 15, "已售罄")
 	}
-	// sold_out gets 0 points
 
 	// Price position score: 0-20 points (current price vs historical range)
 	if len(history) >= 2 {
@@ -328,42 +1023,79 @@ func (s *Store) calculateValueScore(product *model.Product, history []model.Pric
 
 		if maxPrice > minPrice {
 			position := (product.Price - minPrice) / (maxPrice - minPrice)
-			if position <= 0.1 {
-				score += 20 // Near historical low
-			} else if position <= 0.3 {
-				score += 15
-			} else if position <= 0.5 {
-				score += 10
-			} else if position <= 0.7 {
-				score += 5
-			}
-			// Near historical high gets 0 points
+			b.Add(model.ScorePricePosition, positionPoints(position), 20, positionReason(position, minPrice, maxPrice))
 		} else {
-			score += 10 // No price variation
+			b.Add(model.ScorePricePosition, 10, 20, "价格没有变化过") // No price variation
 		}
+	} else {
+		b.Add(model.ScorePricePosition, 0, 20, "价格记录不足")
 	}
 
 	// Age score: 0-10 points (newer listings get higher score)
-	daysSinceCreation := now.Sub(product.CreatedAt).Hours() / 24
-	if daysSinceCreation <= 7 {
-		score += 10
-	} else if daysSinceCreation <= 30 {
-		score += 7
-	} else if daysSinceCreation <= 90 {
-		score += 3
-	}
+	days := now.Sub(product.CreatedAt).Hours() / 24
+	b.Add(model.ScoreAge, agePoints(days), 10, fmt.Sprintf("上架 %d 天", int(days)))
+
+	// Retailer score: -10 to 10 points (refurbished vs new at JD, Amazon...)
+	retailer, reason := retailerScore(product.Price, s.retailerPrices[product.ID])
+	b.Add(model.ScoreRetailer, retailer, 10, reason)
+
+	b.Finish()
+	return b
+}
 
-	// Clamp score to 0-100
-	if score > 100 {
-		return 100
+// positionPoints scores where a price sits in its historical range, 0 at the
+// low and 1 at the high: 0-20 points
+func positionPoints(position float64) float64 {
+	switch {
+	case position <= 0.1:
+		return 20 // Near historical low
+	case position <= 0.3:
+		return 15
+	case position <= 0.5:
+		return 10
+	case position <= 0.7:
+		return 5
+	}
+	return 0 // Near historical high
+}
+
+// positionReason describes a price's position in its historical range
+func positionReason(position, min, max float64) string {
+	switch {
+	case position <= 0.1:
+		return fmt.Sprintf("接近历史最低价 ¥%.0f", min)
+	case position > 0.7:
+		return fmt.Sprintf("接近历史最高价 ¥%.0f", max)
 	}
-	if score < 0 {
-		return 0
+	return fmt.Sprintf("位于历史价格区间 ¥%.0f-¥%.0f 的 %.0f%%", min, max, position*100)
+}
+
+// agePoints scores a listing by its age in days, newer is better: 0-10 points
+func agePoints(days float64) float64 {
+	switch {
+	case days <= 7:
+		return 10
+	case days <= 30:
+		return 7
+	case days <= 90:
+		return 3
+	}
+	return 0
+}
+
+// GetScoreBreakdown explains a product's value score component by component
+func (s *Store) GetScoreBreakdown(productID string) (*model.ScoreBreakdown, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	p, ok := s.products[productID]
+	if !ok {
+		return nil, false
 	}
-	return score
+	return s.scoreBreakdown(p, s.history[productID], time.Now()), true
 }
 
-// updatePriceStats updates lowest_price, highest_price, and price_trend
+// updatePriceStats updates lowest_price, highest_price, price_trend and the volatility indicators
 func (s *Store) updatePriceStats(product *model.Product, now time.Time) {
 	history := s.history[product.ID]
 	if len(history) == 0 {
@@ -399,9 +1131,163 @@ func (s *Store) updatePriceStats(product *model.Product, now time.Time) {
 			product.PriceTrend = "stable"
 		}
 	}
+
+	product.Volatility, product.DropStreak, product.Stability = model.PriceIndicators(history, product.Price)
 }
 
-// GetPriceHistory returns price history for a product
+// GetRegions returns the region registry ordered by code
+func (s *Store) GetRegions() []*model.Region {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	regions := s.sortedRegionsLocked()
+	for i, r := range regions {
+		c := *r
+		regions[i] = &c
+	}
+	return regions
+}
+
+// sortedRegionsLocked returns the registry entries ordered by code; caller holds the lock
+func (s *Store) sortedRegionsLocked() []*model.Region {
+	regions := make([]*model.Region, 0, len(s.regions))
+	for _, r := range s.regions {
+		regions = append(regions, r)
+	}
+	sort.Slice(regions, func(i, j int) bool { return regions[i].Code < regions[j].Code })
+	return regions
+}
+
+// GetRegion returns a region by code
+func (s *Store) GetRegion(code string) (*model.Region, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	r, ok := s.regions[code]
+	if !ok {
+		return nil, false
+	}
+	c := *r
+	return &c, true
+}
+
+// UpsertRegion adds or replaces a region in the registry
+func (s *Store) UpsertRegion(region *model.Region) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c := *region
+	s.regions[region.Code] = &c
+	return nil
+}
+
+// DeleteRegion removes a region from the registry; its products are left untouched
+func (s *Store) DeleteRegion(code string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.regions[code]; !ok {
+		return fmt.Errorf("region not found")
+	}
+	delete(s.regions, code)
+	return nil
+}
+
+// AddWatchlist stores a new watchlist
+func (s *Store) AddWatchlist(watchlist *model.Watchlist) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.watchlists[watchlist.ID]; exists {
+		return fmt.Errorf("watchlist already exists")
+	}
+	w := *watchlist
+	w.ProductIDs = append([]string(nil), watchlist.ProductIDs...)
+	s.watchlists[w.ID] = &w
+	return nil
+}
+
+// GetWatchlists returns the watchlists of a client token, oldest first
+func (s *Store) GetWatchlists(clientToken string) []*model.Watchlist {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	watchlists := []*model.Watchlist{}
+	for _, w := range s.watchlists {
+		if w.ClientToken == clientToken {
+			copied := *w
+			watchlists = append(watchlists, &copied)
+		}
+	}
+	sort.Slice(watchlists, func(i, j int) bool { return watchlists[i].CreatedAt.Before(watchlists[j].CreatedAt) })
+	return watchlists
+}
+
+// GetWatchlist returns a watchlist by ID
+func (s *Store) GetWatchlist(id string) (*model.Watchlist, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	w, ok := s.watchlists[id]
+	if !ok {
+		return nil, false
+	}
+	copied := *w
+	return &copied, true
+}
+
+// DeleteWatchlist removes a watchlist
+func (s *Store) DeleteWatchlist(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.watchlists[id]; !ok {
+		return fmt.Errorf("watchlist not found")
+	}
+	delete(s.watchlists, id)
+	return nil
+}
+
+// AddAnnotation adds a price chart annotation
+func (s *Store) AddAnnotation(annotation *model.PriceAnnotation) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.annotations[annotation.ID] = *annotation
+	return nil
+}
+
+// DeleteAnnotation removes a price chart annotation
+func (s *Store) DeleteAnnotation(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.annotations[id]; !ok {
+		return fmt.Errorf("annotation not found")
+	}
+	delete(s.annotations, id)
+	return nil
+}
+
+// GetAnnotations returns annotations dated between from and to (YYYY-MM-DD, inclusive;
+// empty = unbounded), ordered by date
+func (s *Store) GetAnnotations(from, to string) []model.PriceAnnotation {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	annotations := []model.PriceAnnotation{}
+	for _, a := range s.annotations {
+		if (from != "" && a.Date < from) || (to != "" && a.Date > to) {
+			continue
+		}
+		annotations = append(annotations, a)
+	}
+
+	sortAnnotations(annotations)
+	return annotations
+}
+
+// GetPriceHistory returns price history for a product
 func (s *Store) GetPriceHistory(productID string) []model.PriceHistory {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
@@ -409,6 +1295,18 @@ func (s *Store) GetPriceHistory(productID string) []model.PriceHistory {
 	return s.history[productID]
 }
 
+// GetPriceStats computes price statistics for a product from its price history
+func (s *Store) GetPriceStats(productID string) (*model.PriceStats, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	p, ok := s.products[productID]
+	if !ok {
+		return nil, false
+	}
+	return model.ComputePriceStats(productID, s.history[productID], p.Price, time.Now()), true
+}
+
 // GetCategories returns all unique categories
 func (s *Store) GetCategories() []string {
 	s.mu.RLock()
@@ -416,7 +1314,9 @@ func (s *Store) GetCategories() []string {
 
 	categoryMap := make(map[string]bool)
 	for _, p := range s.products {
-		categoryMap[p.Category] = true
+		if !p.Archived {
+			categoryMap[p.Category] = true
+		}
 	}
 
 	categories := make([]string, 0, len(categoryMap))
@@ -426,6 +1326,31 @@ func (s *Store) GetCategories() []string {
 	return categories
 }
 
+// GetCategoryDefaultSorts returns the default product sort configured per category
+func (s *Store) GetCategoryDefaultSorts() map[string]string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	sorts := make(map[string]string, len(s.categorySorts))
+	for category, sortBy := range s.categorySorts {
+		sorts[category] = sortBy
+	}
+	return sorts
+}
+
+// SetCategoryDefaultSort sets a category's default product sort; an empty sortBy removes it
+func (s *Store) SetCategoryDefaultSort(category, sortBy string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if sortBy == "" {
+		delete(s.categorySorts, category)
+	} else {
+		s.categorySorts[category] = sortBy
+	}
+	return nil
+}
+
 // AddSubscription adds a new subscription
 func (s *Store) AddSubscription(sub *model.Subscription) error {
 	s.mu.Lock()
@@ -440,6 +1365,44 @@ func (s *Store) AddSubscription(sub *model.Subscription) error {
 	return nil
 }
 
+// UpdateSubscription replaces the settings of a price subscription. Its product,
+// Bark Key and notification counters are kept.
+func (s *Store) UpdateSubscription(sub *model.Subscription) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	existing, exists := s.subscriptions[sub.ID]
+	if !exists {
+		return fmt.Errorf("subscription not found")
+	}
+
+	existing.TargetPrice = sub.TargetPrice
+	existing.QuietHoursStart = sub.QuietHoursStart
+	existing.QuietHoursEnd = sub.QuietHoursEnd
+	existing.Timezone = sub.Timezone
+	existing.LowStockAlert = sub.LowStockAlert
+	existing.BarkServer = sub.BarkServer
+	existing.Language = sub.Language
+	existing.Paused = sub.Paused
+	existing.UpdatedAt = sub.UpdatedAt
+	return nil
+}
+
+// SetSubscriptionPaused pauses or resumes a price subscription
+func (s *Store) SetSubscriptionPaused(id string, paused bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sub, exists := s.subscriptions[id]
+	if !exists {
+		return fmt.Errorf("subscription not found")
+	}
+
+	sub.Paused = paused
+	sub.UpdatedAt = time.Now()
+	return nil
+}
+
 // RemoveSubscription removes a subscription
 func (s *Store) RemoveSubscription(id string) error {
 	s.mu.Lock()
@@ -466,85 +1429,625 @@ func (s *Store) RemoveSubscription(id string) error {
 	return nil
 }
 
-// DeleteProductsByRegion deletes all products from a specific region
-func (s *Store) DeleteProductsByRegion(region string) (int, error) {
+// PreviewRegionDeletion returns what DeleteProductsByRegion would archive, without archiving
+func (s *Store) PreviewRegionDeletion(region string) *model.RegionDeletion {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	deletion, _ := s.summarizeListedLocked(func(p *model.Product) bool { return p.Region == region })
+	deletion.Region = region
+	return deletion
+}
+
+// DeleteProductsByRegion archives all listed products of a region, which hides their
+// price history, subscriptions and events with them. The deletion can be undone for
+// RegionDeletionGracePeriod; later on the products can still be restored one by one.
+func (s *Store) DeleteProductsByRegion(region string) (*model.RegionDeletion, error) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
+	defer s.productsVersion.Add(1)
+
+	deletion, ids := s.summarizeListedLocked(func(p *model.Product) bool { return p.Region == region })
+	deletion.Region = region
+	s.archiveForDeletionLocked(deletion, ids, time.Now())
+
+	return deletion, nil
+}
 
+// ArchiveProductsByRegion hides all products of a region from listings, keeping
+// their price history, events and subscriptions. Returns the number archived.
+func (s *Store) ArchiveProductsByRegion(region string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	defer s.productsVersion.Add(1)
+
+	now := time.Now()
 	count := 0
-	for id, p := range s.products {
-		if p.Region == region {
-			delete(s.products, id)
+	for _, p := range s.products {
+		if p.Region == region && !p.Archived {
+			// Bumping updated_at carries the change to replicas
+			p.Archived = true
+			p.UpdatedAt = now
+			count++
+		}
+	}
+	return count, nil
+}
+
+// RestoreProducts brings archived products back into listings. Returns the number restored.
+func (s *Store) RestoreProducts(ids []string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	defer s.productsVersion.Add(1)
+
+	return s.restoreProductsLocked(ids, time.Now()), nil
+}
+
+// restoreProductsLocked unarchives the listed products and returns how many were archived
+func (s *Store) restoreProductsLocked(ids []string, now time.Time) int {
+	count := 0
+	for _, id := range ids {
+		if p, ok := s.products[id]; ok && p.Archived {
+			p.Archived = false
+			p.UpdatedAt = now
 			count++
 		}
 	}
-	return count, nil
+	return count
+}
+
+// GetArchivedProducts returns the archived products of a region (all regions if empty)
+func (s *Store) GetArchivedProducts(region string) []*model.Product {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	products := []*model.Product{}
+	for _, p := range s.products {
+		if p.Archived && (region == "" || p.Region == region) {
+			products = append(products, p)
+		}
+	}
+	sort.Slice(products, func(i, j int) bool { return products[i].UpdatedAt.After(products[j].UpdatedAt) })
+	return products
+}
+
+// PreviewStaleDeletion returns what DeleteStaleProducts would archive, without archiving
+func (s *Store) PreviewStaleDeletion(before time.Time) *model.RegionDeletion {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	deletion, _ := s.summarizeListedLocked(func(p *model.Product) bool { return p.UpdatedAt.Before(before) })
+	deletion.StaleBefore = &before
+	return deletion
+}
+
+// DeleteStaleProducts archives listed products not updated by a scrape since before.
+// Like a region deletion, it can be undone for RegionDeletionGracePeriod.
+func (s *Store) DeleteStaleProducts(before time.Time) (*model.RegionDeletion, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	defer s.productsVersion.Add(1)
+
+	deletion, ids := s.summarizeListedLocked(func(p *model.Product) bool { return p.UpdatedAt.Before(before) })
+	deletion.StaleBefore = &before
+	s.archiveForDeletionLocked(deletion, ids, time.Now())
+
+	return deletion, nil
+}
+
+// summarizeListedLocked counts the listed products matching keep and the records
+// archiving them would hide, and returns their IDs
+func (s *Store) summarizeListedLocked(keep func(*model.Product) bool) (*model.RegionDeletion, []string) {
+	deletion := &model.RegionDeletion{}
+	matched := make(map[string]bool)
+	var ids []string
+	for id, p := range s.products {
+		if p.Archived || !keep(p) {
+			continue
+		}
+		matched[id] = true
+		ids = append(ids, id)
+		deletion.PriceHistory += len(s.history[id])
+		deletion.Subscriptions += len(s.subscriptionsByProduct[id])
+	}
+	deletion.Products = len(ids)
+
+	for _, e := range s.productEvents {
+		if matched[e.ProductID] {
+			deletion.Events++
+		}
+	}
+	return deletion, ids
+}
+
+// archiveForDeletionLocked archives the products of a deletion and keeps their IDs for undo
+func (s *Store) archiveForDeletionLocked(deletion *model.RegionDeletion, ids []string, now time.Time) {
+	s.purgeExpiredDeletionsLocked(now)
+	newRegionDeletion(deletion, now)
+
+	for _, id := range ids {
+		p := s.products[id]
+		p.Archived = true
+		p.UpdatedAt = now
+	}
+
+	if len(ids) > 0 {
+		s.regionDeletions[deletion.ID] = &pendingDeletion{Deletion: deletion, ProductIDs: ids}
+	}
+}
+
+// UndoRegionDeletion restores the products archived by a deletion within its grace period
+func (s *Store) UndoRegionDeletion(id string) (*model.RegionDeletion, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	defer s.productsVersion.Add(1)
+
+	now := time.Now()
+	s.purgeExpiredDeletionsLocked(now)
+
+	pending, ok := s.regionDeletions[id]
+	if !ok {
+		return nil, fmt.Errorf("region deletion not found or expired")
+	}
+	s.restoreProductsLocked(pending.ProductIDs, now)
+
+	delete(s.regionDeletions, id)
+	return pending.Deletion, nil
+}
+
+// GetRegionDeletions returns region deletions that can still be undone, newest first
+func (s *Store) GetRegionDeletions() []*model.RegionDeletion {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.purgeExpiredDeletionsLocked(time.Now())
+
+	deletions := make([]*model.RegionDeletion, 0, len(s.regionDeletions))
+	for _, pending := range s.regionDeletions {
+		deletions = append(deletions, pending.Deletion)
+	}
+	sort.Slice(deletions, func(i, j int) bool {
+		return deletions[i].DeletedAt.After(deletions[j].DeletedAt)
+	})
+	return deletions
+}
+
+// purgeExpiredDeletionsLocked drops undo data whose grace period has passed
+func (s *Store) purgeExpiredDeletionsLocked(now time.Time) {
+	for id, pending := range s.regionDeletions {
+		if now.After(pending.Deletion.ExpiresAt) {
+			delete(s.regionDeletions, id)
+		}
+	}
+}
+
+// GetSubscriptionsByProduct returns all subscriptions for a product
+func (s *Store) GetSubscriptionsByProduct(productID string) []*model.Subscription {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	subIDs := s.subscriptionsByProduct[productID]
+	subs := make([]*model.Subscription, 0, len(subIDs))
+	for _, id := range subIDs {
+		if sub, ok := s.subscriptions[id]; ok {
+			subs = append(subs, sub)
+		}
+	}
+	return subs
+}
+
+// GetSubscriptionsByBarkKey returns the price subscriptions of a Bark Key, newest first
+func (s *Store) GetSubscriptionsByBarkKey(barkKey string) []*model.Subscription {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	subs := make([]*model.Subscription, 0)
+	for _, sub := range s.subscriptions {
+		if sub.BarkKey == barkKey {
+			subs = append(subs, sub)
+		}
+	}
+	sort.Slice(subs, func(i, j int) bool { return subs[i].CreatedAt.After(subs[j].CreatedAt) })
+	return subs
+}
+
+// GetSubscription returns a price subscription by ID
+func (s *Store) GetSubscription(id string) (*model.Subscription, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	sub, ok := s.subscriptions[id]
+	return sub, ok
+}
+
+// GetAllSubscriptions returns all subscriptions
+func (s *Store) GetAllSubscriptions() []*model.Subscription {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	subs := make([]*model.Subscription, 0, len(s.subscriptions))
+	for _, sub := range s.subscriptions {
+		subs = append(subs, sub)
+	}
+	return subs
+}
+
+// UpdateLastScrapeTime updates the last scrape timestamp
+func (s *Store) UpdateLastScrapeTime(t time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.lastScrapeTime = t
+}
+
+// GetLastScrapeTime returns the last scrape timestamp
+func (s *Store) GetLastScrapeTime() time.Time {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.lastScrapeTime
+}
+
+// GetStats returns system statistics
+func (s *Store) GetStats() *model.Stats {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	stats := &model.Stats{
+		Categories:          make(map[string]int),
+		TotalSubscriptions:  len(s.subscriptions),
+		LastScrapeTime:      s.lastScrapeTime,
+		AvailableProducts:   0,
+	}
+
+	for _, p := range s.products {
+		if p.Archived {
+			continue
+		}
+		stats.TotalProducts++
+		stats.Categories[p.Category]++
+		if p.StockStatus == "available" {
+			stats.AvailableProducts++
+		}
+	}
+
+	historyRows := 0
+	for _, h := range s.history {
+		historyRows += len(h)
+	}
+	stats.Retention = s.retention.stats(historyRows, 0, len(s.notificationHistory))
+
+	// Delivery latency per channel; entries recorded before channels were tracked are Bark pushes
+	since := time.Now().Add(-model.DeliveryLatencyWindow)
+	samples := make(map[string][]int64)
+	for _, h := range s.notificationHistory {
+		if h.Status != "sent" || h.DeliveryLatencyMs == nil || h.CreatedAt.Before(since) {
+			continue
+		}
+		channel := h.Channel
+		if channel == "" {
+			channel = model.ChannelBark
+		}
+		samples[channel] = append(samples[channel], *h.DeliveryLatencyMs)
+	}
+	stats.DeliveryLatency = model.SummarizeLatencies(samples)
+
+	return stats
+}
+
+// SetRetentionPolicy sets the row limits applied by EnforceRetention
+func (s *Store) SetRetentionPolicy(policy model.RetentionPolicy) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.retention.policy = policy
+}
+
+// EnforceRetention drops the oldest price history per product and notification
+// history per Bark Key beyond the configured limits
+func (s *Store) EnforceRetention() (int, int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	evictedHistory := 0
+	if max := s.retention.policy.MaxHistoryPerProduct; max > 0 {
+		for id, h := range s.history {
+			if len(h) > max {
+				evictedHistory += len(h) - max
+				s.history[id] = append([]model.PriceHistory(nil), h[len(h)-max:]...)
+			}
+		}
+	}
+
+	evictedNotifications := 0
+	if max := s.retention.policy.MaxNotificationsPerKey; max > 0 {
+		// History is appended in time order, so walk backwards keeping the newest per key
+		counts := make(map[string]int)
+		kept := make([]*model.NotificationHistory, 0, len(s.notificationHistory))
+		for i := len(s.notificationHistory) - 1; i >= 0; i-- {
+			h := s.notificationHistory[i]
+			counts[h.BarkKey]++
+			if counts[h.BarkKey] > max {
+				evictedNotifications++
+				continue
+			}
+			kept = append(kept, h)
+		}
+		for i, j := 0, len(kept)-1; i < j; i, j = i+1, j-1 {
+			kept[i], kept[j] = kept[j], kept[i]
+		}
+		s.notificationHistory = kept
+	}
+
+	s.retention.record(evictedHistory, evictedNotifications, time.Now())
+	return evictedHistory, evictedNotifications, nil
+}
+
+// CompactPriceHistory downsamples price history recorded before the policy's
+// RawHistoryDays to the last point of each day and returns how many points were
+// dropped. Unlike the SQLite store, no daily min/max is kept.
+func (s *Store) CompactPriceHistory(now time.Time) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	before := s.retention.compactBefore(now)
+	if before.IsZero() {
+		return 0, nil
+	}
+
+	compacted := 0
+	for id, h := range s.history {
+		kept := make([]model.PriceHistory, 0, len(h))
+		for i, point := range h {
+			// History is appended in time order: a point followed by one of the same day isn't the close
+			if point.Timestamp.Before(before) && i+1 < len(h) &&
+				point.Timestamp.Format("2006-01-02") == h[i+1].Timestamp.Format("2006-01-02") {
+				compacted++
+				continue
+			}
+			kept = append(kept, point)
+		}
+		if len(kept) < len(h) {
+			s.history[id] = kept
+		}
+	}
+
+	s.retention.recordCompaction(compacted, now)
+	return compacted, nil
+}
+
+// RecordDailyStats stores today's per category/region aggregates, catalog
+// snapshots and spec tier price index, replacing earlier values recorded the same day
+func (s *Store) RecordDailyStats(now time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	products := make([]*model.Product, 0, len(s.products))
+	for _, p := range s.products {
+		if !p.Archived {
+			products = append(products, p)
+		}
+	}
+
+	for _, point := range aggregateDailyStats(products, now) {
+		s.dailyStats[point.Date+"|"+point.Category+"|"+point.Region] = point
+	}
+	for _, point := range aggregatePriceIndex(products, now) {
+		s.priceIndex[point.Date+"|"+point.Tier+"|"+point.Region] = point
+	}
+
+	subscriptions := make(map[string]int)
+	for _, sub := range s.subscriptions {
+		subscriptions[sub.ProductID]++
+	}
+	for _, snap := range aggregateStatsSnapshots(products, subscriptions, now) {
+		s.statsHistory[snap.Date+"|"+snap.Region] = snap
+	}
+	return nil
+}
+
+// GetStatsHistory returns the daily catalog snapshots since the given day of one
+// region, or of the whole catalog when region is empty
+func (s *Store) GetStatsHistory(region string, since time.Time) []model.StatsSnapshot {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	from := since.Format(dailyStatsDateFormat)
+	snapshots := []model.StatsSnapshot{}
+	for _, snap := range s.statsHistory {
+		if snap.Date >= from && snap.Region == region {
+			snapshots = append(snapshots, snap)
+		}
+	}
+
+	sortStatsSnapshots(snapshots)
+	return snapshots
+}
+
+// GetPriceIndexTimeline returns the daily spec tier price index since the given
+// day, optionally of one region (empty = all)
+func (s *Store) GetPriceIndexTimeline(region string, since time.Time) []model.DailyPriceIndex {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	from := since.Format(dailyStatsDateFormat)
+	points := []model.DailyPriceIndex{}
+	for _, p := range s.priceIndex {
+		if p.Date < from || (region != "" && p.Region != region) {
+			continue
+		}
+		points = append(points, p)
+	}
+
+	sortPriceIndex(points)
+	return points
+}
+
+// GetStatsTimeline returns daily aggregates since the given day, optionally filtered
+// by category and region (empty = all)
+func (s *Store) GetStatsTimeline(category, region string, since time.Time) []model.DailyCategoryStats {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	from := since.Format(dailyStatsDateFormat)
+	points := []model.DailyCategoryStats{}
+	for _, p := range s.dailyStats {
+		if p.Date < from {
+			continue
+		}
+		if category != "" && p.Category != category {
+			continue
+		}
+		if region != "" && p.Region != region {
+			continue
+		}
+		points = append(points, p)
+	}
+
+	sortDailyStats(points)
+	return points
+}
+
+// RecordScrapeRun stores a scrape run and its per category counts, dropping the
+// oldest records beyond maxScrapeRuns
+func (s *Store) RecordScrapeRun(run *model.ScrapeRun) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.scrapeRuns = append(s.scrapeRuns, run)
+	if len(s.scrapeRuns) > maxScrapeRuns {
+		s.scrapeRuns = s.scrapeRuns[len(s.scrapeRuns)-maxScrapeRuns:]
+	}
+	return nil
 }
 
-// GetSubscriptionsByProduct returns all subscriptions for a product
-func (s *Store) GetSubscriptionsByProduct(productID string) []*model.Subscription {
+// GetScrapeRuns returns up to limit scrape run records, newest first
+func (s *Store) GetScrapeRuns(limit int) []*model.ScrapeRun {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
-	subIDs := s.subscriptionsByProduct[productID]
-	subs := make([]*model.Subscription, 0, len(subIDs))
-	for _, id := range subIDs {
-		if sub, ok := s.subscriptions[id]; ok {
-			subs = append(subs, sub)
-		}
+	runs := []*model.ScrapeRun{}
+	for i := len(s.scrapeRuns) - 1; i >= 0 && len(runs) < limit; i-- {
+		runs = append(runs, s.scrapeRuns[i])
 	}
-	return subs
+	return runs
 }
 
-// GetAllSubscriptions returns all subscriptions
-func (s *Store) GetAllSubscriptions() []*model.Subscription {
+// GetScrapeRun returns a scrape run record by run ID
+func (s *Store) GetScrapeRun(id string) (*model.ScrapeRun, bool) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
-	subs := make([]*model.Subscription, 0, len(s.subscriptions))
-	for _, sub := range s.subscriptions {
-		subs = append(subs, sub)
+	for i := len(s.scrapeRuns) - 1; i >= 0; i-- {
+		if s.scrapeRuns[i].ID == id {
+			return s.scrapeRuns[i], true
+		}
 	}
-	return subs
+	return nil, false
 }
 
-// UpdateLastScrapeTime updates the last scrape timestamp
-func (s *Store) UpdateLastScrapeTime(t time.Time) {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-
-	s.lastScrapeTime = t
+// inWindow reports whether t falls in (since, until], compared in whole seconds
+// like the replication batch window
+func inWindow(t, since, until time.Time) bool {
+	return t.Unix() > since.Unix() && t.Unix() <= until.Unix()
 }
 
-// GetLastScrapeTime returns the last scrape timestamp
-func (s *Store) GetLastScrapeTime() time.Time {
+// GetPriceHistoryBetween returns the history of every product recorded in (since, until]
+func (s *Store) GetPriceHistoryBetween(since, until time.Time) []model.PriceHistory {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
-	return s.lastScrapeTime
+	history := []model.PriceHistory{}
+	for _, entries := range s.history {
+		for _, h := range entries {
+			if inWindow(h.Timestamp, since, until) {
+				history = append(history, h)
+			}
+		}
+	}
+	return history
 }
 
-// GetStats returns system statistics
-func (s *Store) GetStats() *model.Stats {
+// GetProductEventsBetween returns the events of every product created in (since, until]
+func (s *Store) GetProductEventsBetween(since, until time.Time) []model.ProductEvent {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
-	stats := &model.Stats{
-		TotalProducts:       len(s.products),
-		Categories:          make(map[string]int),
-		TotalSubscriptions:  len(s.subscriptions),
-		LastScrapeTime:      s.lastScrapeTime,
-		AvailableProducts:   0,
+	events := []model.ProductEvent{}
+	for _, e := range s.productEvents {
+		if inWindow(e.CreatedAt, since, until) {
+			events = append(events, e)
+		}
 	}
+	return events
+}
 
-	for _, p := range s.products {
-		stats.Categories[p.Category]++
-		if p.StockStatus == "available" {
-			stats.AvailableProducts++
+// ApplyReplication writes a batch pulled from an upstream instance. Products are
+// stored as sent, their history and events inside the batch window replace the
+// local ones (so re-applying a window is harmless), and products no longer listed
+// upstream are removed.
+func (s *Store) ApplyReplication(batch *model.ReplicationBatch) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	defer s.productsVersion.Add(1)
+
+	listed := make(map[string]bool, len(batch.ProductIDs))
+	for _, id := range batch.ProductIDs {
+		listed[id] = true
+	}
+	for id := range s.products {
+		if !listed[id] {
+			delete(s.products, id)
+			delete(s.history, id)
+			delete(s.prevPrices, id)
+			delete(s.subscriptionsByProduct, id)
 		}
 	}
 
-	return stats
+	changed := make(map[string]bool, len(batch.Products))
+	for _, p := range batch.Products {
+		changed[p.ID] = true
+		s.products[p.ID] = p
+		s.prevPrices[p.ID] = p.Price
+
+		history := s.history[p.ID][:0]
+		for _, h := range s.history[p.ID] {
+			if !batch.InReplicationWindow(h.Timestamp) {
+				history = append(history, h)
+			}
+		}
+		s.history[p.ID] = history
+	}
+	for _, h := range batch.PriceHistory {
+		if changed[h.ProductID] {
+			s.history[h.ProductID] = append(s.history[h.ProductID], h)
+		}
+	}
+	for id := range changed {
+		if n := len(s.history[id]); n > maxHistoryPerProduct {
+			s.history[id] = s.history[id][n-maxHistoryPerProduct:]
+		}
+	}
+
+	events := s.productEvents[:0]
+	for _, e := range s.productEvents {
+		if listed[e.ProductID] && !(changed[e.ProductID] && batch.InReplicationWindow(e.CreatedAt)) {
+			events = append(events, e)
+		}
+	}
+	for _, e := range batch.Events {
+		if changed[e.ProductID] {
+			events = append(events, e)
+		}
+	}
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].CreatedAt.Before(events[j].CreatedAt)
+	})
+	s.productEvents = events
+
+	if !batch.LastScrapeTime.IsZero() {
+		s.lastScrapeTime = batch.LastScrapeTime
+	}
+	return nil
 }
 
 // AddNewArrivalSubscription adds a new arrival subscription
@@ -560,7 +2063,8 @@ func (s *Store) AddNewArrivalSubscription(sub *model.NewArrivalSubscription) err
 	return nil
 }
 
-// RemoveNewArrivalSubscription removes a new arrival subscription
+// RemoveNewArrivalSubscription soft-deletes a new arrival subscription: it stops
+// matching but keeps its filters and notified products until it is restored or purged
 func (s *Store) RemoveNewArrivalSubscription(id string) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -569,11 +2073,15 @@ func (s *Store) RemoveNewArrivalSubscription(id string) error {
 		return fmt.Errorf("new arrival subscription not found")
 	}
 
-	if _, exists := s.newArrivalSubscriptions[id]; !exists {
+	sub, exists := s.newArrivalSubscriptions[id]
+	if !exists {
 		return fmt.Errorf("new arrival subscription not found")
 	}
 
+	sub.DeletedAt = time.Now()
+	s.deletedNewArrivalSubscriptions[id] = sub
 	delete(s.newArrivalSubscriptions, id)
+	delete(s.pendingNotifications, id)
 	return nil
 }
 
@@ -638,22 +2146,9 @@ func (s *Store) UpdateNotifiedProductIDs(subscriptionID, productID string) error
 		return fmt.Errorf("new arrival subscription not found")
 	}
 
-	// Parse existing IDs
 	var ids []string
-	if sub.NotifiedProductIDs != "" && sub.NotifiedProductIDs != "[]" {
-		// Simple JSON parsing
-		trimmed := sub.NotifiedProductIDs[1 : len(sub.NotifiedProductIDs)-1]
-		if trimmed != "" {
-			// Split by comma and clean quotes
-			parts := strings.Split(trimmed, ",")
-			for _, part := range parts {
-				cleaned := strings.TrimSpace(part)
-				cleaned = strings.Trim(cleaned, `"`)
-				if cleaned != "" {
-					ids = append(ids, cleaned)
-				}
-			}
-		}
+	if sub.NotifiedProductIDs != "" {
+		_ = json.Unmarshal([]byte(sub.NotifiedProductIDs), &ids)
 	}
 
 	// Check if already notified
@@ -663,19 +2158,11 @@ func (s *Store) UpdateNotifiedProductIDs(subscriptionID, productID string) error
 		}
 	}
 
-	// Add new ID
-	ids = append(ids, productID)
-
-	// Build JSON array
-	if len(ids) == 0 {
-		sub.NotifiedProductIDs = "[]"
-	} else {
-		quotedIDs := make([]string, len(ids))
-		for i, id := range ids {
-			quotedIDs[i] = "\"" + id + "\""
-		}
-		sub.NotifiedProductIDs = "[" + strings.Join(quotedIDs, ",") + "]"
+	data, err := json.Marshal(append(ids, productID))
+	if err != nil {
+		return err
 	}
+	sub.NotifiedProductIDs = string(data)
 
 	return nil
 }
@@ -749,6 +2236,77 @@ func (s *Store) GetUnreadNotificationCount() int {
 	return count
 }
 
+// UpdateNotificationStatus changes the delivery status of a notification history entry.
+// latencyMs is the delivery latency of a sent notification, nil otherwise.
+func (s *Store) UpdateNotificationStatus(id, status, errorMessage string, latencyMs *int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, h := range s.notificationHistory {
+		if h.ID == id {
+			h.Status = status
+			h.ErrorMessage = errorMessage
+			h.DeliveryLatencyMs = latencyMs
+			return nil
+		}
+	}
+
+	return fmt.Errorf("notification not found")
+}
+
+// AddNotificationRetry queues a failed push for another attempt
+func (s *Store) AddNotificationRetry(retry *model.NotificationRetry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	r := *retry
+	s.notificationRetries[r.ID] = &r
+	return nil
+}
+
+// GetDueNotificationRetries returns up to limit queued retries due at now, earliest first
+func (s *Store) GetDueNotificationRetries(now time.Time, limit int) []*model.NotificationRetry {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	due := []*model.NotificationRetry{}
+	for _, r := range s.notificationRetries {
+		if !r.NextAttemptAt.After(now) {
+			retry := *r
+			due = append(due, &retry)
+		}
+	}
+	sort.Slice(due, func(i, j int) bool { return due[i].NextAttemptAt.Before(due[j].NextAttemptAt) })
+	if len(due) > limit {
+		due = due[:limit]
+	}
+	return due
+}
+
+// UpdateNotificationRetry stores the outcome of a failed retry attempt
+func (s *Store) UpdateNotificationRetry(retry *model.NotificationRetry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	r, ok := s.notificationRetries[retry.ID]
+	if !ok {
+		return fmt.Errorf("notification retry not found")
+	}
+	r.Attempts = retry.Attempts
+	r.NextAttemptAt = retry.NextAttemptAt
+	r.LastError = retry.LastError
+	return nil
+}
+
+// DeleteNotificationRetry removes a retry once it was delivered or given up
+func (s *Store) DeleteNotificationRetry(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.notificationRetries, id)
+	return nil
+}
+
 // UpdateNewArrivalSubscription updates an existing subscription
 func (s *Store) UpdateNewArrivalSubscription(sub *model.NewArrivalSubscription) error {
 	s.mu.Lock()
@@ -802,18 +2360,97 @@ func (s *Store) ResumeSubscription(id string) error {
 	return nil
 }
 
-// IncrementNotificationCount increments the notification count for a subscription
+// GetPreferences returns the preferences for a Bark Key (defaults if none are stored)
+func (s *Store) GetPreferences(barkKey string) *model.UserPreferences {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if p, ok := s.preferences[barkKey]; ok {
+		copy := *p
+		return &copy
+	}
+	return &model.UserPreferences{BarkKey: barkKey}
+}
+
+// GetDeliveryHealth returns the push failure tracking of a Bark Key (a healthy
+// record if none is stored)
+func (s *Store) GetDeliveryHealth(barkKey string) *model.DeliveryHealth {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if h, ok := s.deliveryHealth[barkKey]; ok {
+		copy := *h
+		return &copy
+	}
+	return &model.DeliveryHealth{BarkKey: barkKey}
+}
+
+// SetDeliveryHealth saves the push failure tracking of h.BarkKey; a healthy
+// record removes it
+func (s *Store) SetDeliveryHealth(h *model.DeliveryHealth) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if h.ConsecutiveFailures == 0 && !h.Broken() {
+		delete(s.deliveryHealth, h.BarkKey)
+		return nil
+	}
+	copy := *h
+	s.deliveryHealth[h.BarkKey] = &copy
+	return nil
+}
+
+// SetAllPaused turns the kill switch of a Bark Key on or off. It is kept in the
+// key's preferences apart from each subscription's own pause, so resuming leaves
+// individually paused subscriptions paused. Returns the number of new arrival
+// subscriptions the switch applies to, those not paused on their own.
+func (s *Store) SetAllPaused(barkKey string, paused bool) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	count := 0
+	for _, sub := range s.newArrivalSubscriptions {
+		if sub.BarkKey == barkKey && !sub.Paused {
+			count++
+		}
+	}
+
+	prefs, ok := s.preferences[barkKey]
+	if !ok {
+		prefs = &model.UserPreferences{BarkKey: barkKey}
+		s.preferences[barkKey] = prefs
+	}
+	prefs.PausedAll = paused
+	prefs.UpdatedAt = time.Now()
+
+	return count, nil
+}
+
+// SetPreferences saves the preferences of prefs.BarkKey
+func (s *Store) SetPreferences(prefs *model.UserPreferences) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p := *prefs
+	s.preferences[p.BarkKey] = &p
+	return nil
+}
+
+// IncrementNotificationCount increments the notification count for a subscription,
+// price or new arrival
 func (s *Store) IncrementNotificationCount(id string) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	if s.newArrivalSubscriptions == nil {
-		return fmt.Errorf("new arrival subscription not found")
+	if sub, exists := s.subscriptions[id]; exists {
+		sub.NotificationCount++
+		sub.LastNotifiedAt = time.Now()
+		return nil
 	}
 
 	sub, exists := s.newArrivalSubscriptions[id]
 	if !exists {
-		return fmt.Errorf("new arrival subscription not found")
+		return fmt.Errorf("subscription not found")
 	}
 
 	sub.NotificationCount++
@@ -821,6 +2458,172 @@ func (s *Store) IncrementNotificationCount(id string) error {
 	return nil
 }
 
+// AddPendingNotification buffers a digest item; a product already pending for the subscription is ignored
+func (s *Store) AddPendingNotification(item *model.PendingNotification) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, p := range s.pendingNotifications[item.SubscriptionID] {
+		if p.ProductID == item.ProductID {
+			return nil
+		}
+	}
+	s.pendingNotifications[item.SubscriptionID] = append(s.pendingNotifications[item.SubscriptionID], *item)
+	return nil
+}
+
+// GetPendingNotifications returns the buffered digest items of a subscription, oldest first
+func (s *Store) GetPendingNotifications(subscriptionID string) []model.PendingNotification {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return append([]model.PendingNotification{}, s.pendingNotifications[subscriptionID]...)
+}
+
+// ClearPendingNotifications drops the buffered digest items of a subscription
+func (s *Store) ClearPendingNotifications(subscriptionID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.pendingNotifications, subscriptionID)
+	return nil
+}
+
+// MigrateBarkKey re-associates all subscriptions and notification history from oldKey to newKey
+func (s *Store) MigrateBarkKey(oldKey, newKey string) (*model.KeyMigrationResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	result := &model.KeyMigrationResult{}
+
+	for _, sub := range s.subscriptions {
+		if sub.BarkKey == oldKey {
+			sub.BarkKey = newKey
+			result.Subscriptions++
+		}
+	}
+
+	for _, sub := range s.newArrivalSubscriptions {
+		if sub.BarkKey == oldKey {
+			sub.BarkKey = newKey
+			sub.UpdatedAt = time.Now()
+			result.NewArrivalSubscriptions++
+		}
+	}
+
+	maskedKey := maskBarkKey(newKey)
+	for _, h := range s.notificationHistory {
+		if h.BarkKey == oldKey {
+			h.BarkKey = newKey
+			h.BarkKeyMasked = maskedKey
+			result.NotificationHistory++
+		}
+	}
+
+	// Preferences follow the key; the old key's settings win
+	if prefs, ok := s.preferences[oldKey]; ok {
+		prefs.BarkKey = newKey
+		s.preferences[newKey] = prefs
+		delete(s.preferences, oldKey)
+	}
+
+	// So does the user; an unregistered user of the new key gives way
+	if user := s.userByBarkKeyLocked(oldKey); user != nil {
+		if existing := s.userByBarkKeyLocked(newKey); existing != nil && !existing.Registered() {
+			delete(s.users, existing.ID)
+		}
+		user.BarkKey = newKey
+		user.UpdatedAt = time.Now()
+	}
+
+	return result, nil
+}
+
+// AddUser stores a new user; each Bark Key belongs to at most one user
+func (s *Store) AddUser(user *model.User) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.users[user.ID]; exists {
+		return fmt.Errorf("user %s already exists", user.ID)
+	}
+	if s.userByBarkKeyLocked(user.BarkKey) != nil {
+		return fmt.Errorf("bark key already belongs to a user")
+	}
+	u := *user
+	s.users[u.ID] = &u
+	return nil
+}
+
+// UpdateUser saves a user's name, Bark Key and API key hash
+func (s *Store) UpdateUser(user *model.User) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.users[user.ID]; !exists {
+		return fmt.Errorf("user not found")
+	}
+	if owner := s.userByBarkKeyLocked(user.BarkKey); owner != nil && owner.ID != user.ID {
+		return fmt.Errorf("bark key already belongs to a user")
+	}
+	u := *user
+	s.users[u.ID] = &u
+	return nil
+}
+
+// GetUserByBarkKey returns the user owning a Bark Key
+func (s *Store) GetUserByBarkKey(barkKey string) (*model.User, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if barkKey == "" {
+		return nil, false
+	}
+	if u := s.userByBarkKeyLocked(barkKey); u != nil {
+		copied := *u
+		return &copied, true
+	}
+	return nil, false
+}
+
+// GetUserByAPIKeyHash returns the registered user with an API key hash
+func (s *Store) GetUserByAPIKeyHash(hash string) (*model.User, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if hash == "" {
+		return nil, false
+	}
+	for _, u := range s.users {
+		if u.APIKeyHash == hash {
+			copied := *u
+			return &copied, true
+		}
+	}
+	return nil, false
+}
+
+// userByBarkKeyLocked finds the user owning a Bark Key. Caller must hold s.mu.
+func (s *Store) userByBarkKeyLocked(barkKey string) *model.User {
+	for _, u := range s.users {
+		if u.BarkKey == barkKey {
+			return u
+		}
+	}
+	return nil
+}
+
+// maskBarkKey masks a Bark Key for storage in history records (shows first 4 and last 4 chars)
+func maskBarkKey(key string) string {
+	if key == "" {
+		return ""
+	}
+	if len(key) <= 8 {
+		return "****"
+	}
+	return key[:4] + "****" + key[len(key)-4:]
+}
+
 // GetScraperStatus returns the current scraper status (in-memory for JSON store)
 func (s *Store) GetScraperStatus() *model.ScraperStatus {
 	s.mu.RLock()
@@ -842,3 +2645,25 @@ func (s *Store) UpdateScraperStatus(status *model.ScraperStatus) error {
 	s.scraperStatus = status
 	return nil
 }
+
+// GetRegionScraperStatuses returns the last scrape status of every region, by region code (in-memory for JSON store)
+func (s *Store) GetRegionScraperStatuses() []*model.ScraperStatus {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	statuses := make([]*model.ScraperStatus, 0, len(s.regionScraperStatus))
+	for _, status := range s.regionScraperStatus {
+		statuses = append(statuses, status)
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Region < statuses[j].Region })
+	return statuses
+}
+
+// UpdateRegionScraperStatus updates the scrape status of status.Region (in-memory for JSON store)
+func (s *Store) UpdateRegionScraperStatus(status *model.ScraperStatus) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.regionScraperStatus[status.Region] = status
+	return nil
+}
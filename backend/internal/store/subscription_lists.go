@@ -0,0 +1,278 @@
+package store
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"apple-price/internal/model"
+)
+
+// Filter fields stored in subscription_filters
+const (
+	filterCategory    = "category"
+	filterModel       = "model"
+	filterChip        = "chip"
+	filterStorage     = "storage"
+	filterMemory      = "memory"
+	filterStockStatus = "stock_status"
+	filterKeyword     = "keyword"
+)
+
+// subscriptionFilterLists maps each filter field to its list on a subscription
+func subscriptionFilterLists(sub *model.NewArrivalSubscription) []struct {
+	field  string
+	values *[]string
+} {
+	return []struct {
+		field  string
+		values *[]string
+	}{
+		{filterCategory, &sub.Categories},
+		{filterModel, &sub.Models},
+		{filterChip, &sub.Chips},
+		{filterStorage, &sub.Storages},
+		{filterMemory, &sub.Memories},
+		{filterStockStatus, &sub.StockStatuses},
+		{filterKeyword, &sub.Keywords},
+	}
+}
+
+// legacyFilterColumns are the JSON-array columns of new_arrival_subscriptions that
+// subscription_filters replaced
+var legacyFilterColumns = map[string]string{
+	"categories":     filterCategory,
+	"models":         filterModel,
+	"chips":          filterChip,
+	"storages":       filterStorage,
+	"memories":       filterMemory,
+	"stock_statuses": filterStockStatus,
+	"keywords":       filterKeyword,
+}
+
+// execer is satisfied by both *sql.DB and *sql.Tx
+type execer interface {
+	Exec(query string, args ...any) (sql.Result, error)
+}
+
+// writeSubscriptionFilters replaces the filter rows of a subscription
+func writeSubscriptionFilters(db execer, sub *model.NewArrivalSubscription) error {
+	if _, err := db.Exec("DELETE FROM subscription_filters WHERE subscription_id = ?", sub.ID); err != nil {
+		return err
+	}
+	for _, list := range subscriptionFilterLists(sub) {
+		if err := insertSubscriptionFilters(db, sub.ID, list.field, *list.values); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// insertSubscriptionFilters adds the values of one filter field, keeping their order
+func insertSubscriptionFilters(db execer, subscriptionID, field string, values []string) error {
+	for i, v := range values {
+		if _, err := db.Exec(`
+			INSERT OR IGNORE INTO subscription_filters (subscription_id, field, value, position)
+			VALUES (?, ?, ?, ?)
+		`, subscriptionID, field, v, i); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// loadSubscriptionLists fills filter lists and notified product IDs of subs from the child tables
+func (s *SQLiteStore) loadSubscriptionLists(subs []*model.NewArrivalSubscription) {
+	if len(subs) == 0 {
+		return
+	}
+
+	byID := make(map[string]*model.NewArrivalSubscription, len(subs))
+	placeholders := make([]string, 0, len(subs))
+	args := make([]any, 0, len(subs))
+	for _, sub := range subs {
+		// Empty lists serialize as [] rather than null
+		for _, list := range subscriptionFilterLists(sub) {
+			*list.values = []string{}
+		}
+		byID[sub.ID] = sub
+		placeholders = append(placeholders, "?")
+		args = append(args, sub.ID)
+	}
+	in := strings.Join(placeholders, ", ")
+
+	rows, err := s.db.Query(`
+		SELECT subscription_id, field, value FROM subscription_filters
+		WHERE subscription_id IN (`+in+`)
+		ORDER BY subscription_id, field, position
+	`, args...)
+	if err == nil {
+		for rows.Next() {
+			var id, field, value string
+			if rows.Scan(&id, &field, &value) != nil {
+				continue
+			}
+			sub := byID[id]
+			for _, list := range subscriptionFilterLists(sub) {
+				if list.field == field {
+					*list.values = append(*list.values, value)
+					break
+				}
+			}
+		}
+		rows.Close()
+	}
+
+	notified := make(map[string][]string, len(subs))
+	rows, err = s.db.Query(`
+		SELECT subscription_id, product_id FROM subscription_notified_products
+		WHERE subscription_id IN (`+in+`)
+		ORDER BY subscription_id, notified_at, product_id
+	`, args...)
+	if err == nil {
+		for rows.Next() {
+			var id, productID string
+			if rows.Scan(&id, &productID) == nil {
+				notified[id] = append(notified[id], productID)
+			}
+		}
+		rows.Close()
+	}
+
+	for _, sub := range subs {
+		sub.NotifiedProductIDs = "[]"
+		if ids := notified[sub.ID]; len(ids) > 0 {
+			data, _ := json.Marshal(ids)
+			sub.NotifiedProductIDs = string(data)
+		}
+	}
+}
+
+// migrateSubscriptionLists moves the JSON-array columns of new_arrival_subscriptions
+// into subscription_filters and subscription_notified_products, then drops them.
+// Safe to run on every start: it is a no-op once the columns are gone.
+func (s *SQLiteStore) migrateSubscriptionLists() error {
+	columns, err := s.tableColumns("new_arrival_subscriptions")
+	if err != nil {
+		return err
+	}
+
+	var legacy []string
+	for column := range legacyFilterColumns {
+		if columns[column] {
+			legacy = append(legacy, column)
+		}
+	}
+	hasNotified := columns["notified_product_ids"]
+	if len(legacy) == 0 && !hasNotified {
+		return nil
+	}
+
+	selected := append([]string{"id", "created_at"}, legacy...)
+	if hasNotified {
+		selected = append(selected, "notified_product_ids")
+	}
+
+	rows, err := s.db.Query("SELECT " + strings.Join(selected, ", ") + " FROM new_arrival_subscriptions")
+	if err != nil {
+		return err
+	}
+
+	type legacyRow struct {
+		id      string
+		created int64
+		values  []sql.NullString
+	}
+	var legacyRows []legacyRow
+	for rows.Next() {
+		r := legacyRow{values: make([]sql.NullString, len(selected)-2)}
+		dest := []any{&r.id, &r.created}
+		for i := range r.values {
+			dest = append(dest, &r.values[i])
+		}
+		if err := rows.Scan(dest...); err != nil {
+			rows.Close()
+			return err
+		}
+		legacyRows = append(legacyRows, r)
+	}
+	rows.Close()
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, r := range legacyRows {
+		for i, column := range selected[2:] {
+			var values []string
+			if r.values[i].String != "" {
+				if err := json.Unmarshal([]byte(r.values[i].String), &values); err != nil {
+					slog.Warn("Skipping unparseable subscription list", "subscription_id", r.id, "column", column, "error", err)
+					continue
+				}
+			}
+
+			if column == "notified_product_ids" {
+				for _, productID := range values {
+					if _, err := tx.Exec(`
+						INSERT OR IGNORE INTO subscription_notified_products (subscription_id, product_id, notified_at)
+						VALUES (?, ?, ?)
+					`, r.id, productID, r.created); err != nil {
+						return fmt.Errorf("failed to migrate notified products: %w", err)
+					}
+				}
+				continue
+			}
+
+			if err := insertSubscriptionFilters(tx, r.id, legacyFilterColumns[column], values); err != nil {
+				return fmt.Errorf("failed to migrate subscription filters: %w", err)
+			}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	for _, column := range selected[2:] {
+		if _, err := s.db.Exec("ALTER TABLE new_arrival_subscriptions DROP COLUMN " + column); err != nil {
+			return fmt.Errorf("failed to drop column %s: %w", column, err)
+		}
+	}
+
+	slog.Info("Migrated subscription lists to relational tables", "subscriptions", len(legacyRows), "columns", len(selected)-2)
+	return nil
+}
+
+// tableColumns returns the set of column names of a table
+func (s *SQLiteStore) tableColumns(table string) (map[string]bool, error) {
+	rows, err := s.db.Query("SELECT name FROM pragma_table_info(?)", table)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	columns := make(map[string]bool)
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		columns[name] = true
+	}
+	return columns, rows.Err()
+}
+
+// markNotified records that a subscription was notified about a product (idempotent)
+func markNotified(db execer, subscriptionID, productID string, now time.Time) error {
+	_, err := db.Exec(`
+		INSERT OR IGNORE INTO subscription_notified_products (subscription_id, product_id, notified_at)
+		VALUES (?, ?, ?)
+	`, subscriptionID, productID, now.Unix())
+	return err
+}
@@ -0,0 +1,94 @@
+package store
+
+import (
+	"fmt"
+	"time"
+
+	"apple-price/internal/model"
+)
+
+// GetDeletedNewArrivalSubscription returns a soft-deleted new arrival subscription by ID
+func (s *Store) GetDeletedNewArrivalSubscription(id string) (*model.NewArrivalSubscription, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	sub, ok := s.deletedNewArrivalSubscriptions[id]
+	return sub, ok
+}
+
+// RestoreNewArrivalSubscription brings back a soft-deleted new arrival subscription
+// with its filters and notified products
+func (s *Store) RestoreNewArrivalSubscription(id string) (*model.NewArrivalSubscription, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sub, ok := s.deletedNewArrivalSubscriptions[id]
+	if !ok {
+		return nil, fmt.Errorf("deleted new arrival subscription not found")
+	}
+
+	sub.DeletedAt = time.Time{}
+	sub.UpdatedAt = time.Now()
+	s.newArrivalSubscriptions[id] = sub
+	delete(s.deletedNewArrivalSubscriptions, id)
+	return sub, nil
+}
+
+// PurgeDeletedSubscriptions permanently removes new arrival subscriptions
+// soft-deleted before the given time
+func (s *Store) PurgeDeletedSubscriptions(before time.Time) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	purged := 0
+	for id, sub := range s.deletedNewArrivalSubscriptions {
+		if sub.DeletedAt.Before(before) {
+			delete(s.deletedNewArrivalSubscriptions, id)
+			purged++
+		}
+	}
+	return purged, nil
+}
+
+// GetDeletedNewArrivalSubscription returns a soft-deleted new arrival subscription by ID
+func (s *SQLiteStore) GetDeletedNewArrivalSubscription(id string) (*model.NewArrivalSubscription, bool) {
+	return s.getNewArrivalSubscription(id, true)
+}
+
+// RestoreNewArrivalSubscription brings back a soft-deleted new arrival subscription
+// with its filters and notified products
+func (s *SQLiteStore) RestoreNewArrivalSubscription(id string) (*model.NewArrivalSubscription, error) {
+	s.mu.Lock()
+	res, err := s.db.Exec(`
+		UPDATE new_arrival_subscriptions SET deleted_at = NULL, updated_at = ?
+		WHERE id = ? AND deleted_at IS NOT NULL
+	`, time.Now().Unix(), id)
+	s.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return nil, fmt.Errorf("deleted new arrival subscription not found")
+	}
+
+	sub, ok := s.GetNewArrivalSubscription(id)
+	if !ok {
+		return nil, fmt.Errorf("new arrival subscription not found")
+	}
+	return sub, nil
+}
+
+// PurgeDeletedSubscriptions permanently removes new arrival subscriptions
+// soft-deleted before the given time; their filters, notified products and
+// pending notifications go with them
+func (s *SQLiteStore) PurgeDeletedSubscriptions(before time.Time) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	res, err := s.db.Exec("DELETE FROM new_arrival_subscriptions WHERE deleted_at IS NOT NULL AND deleted_at < ?", before.Unix())
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge deleted subscriptions: %w", err)
+	}
+	purged, _ := res.RowsAffected()
+	return int(purged), nil
+}
@@ -0,0 +1,203 @@
+package store
+
+import (
+	"sort"
+	"time"
+
+	"apple-price/internal/model"
+)
+
+// dailyStatsDateFormat is the layout of DailyCategoryStats.Date
+const dailyStatsDateFormat = "2006-01-02"
+
+// aggregateDailyStats computes per category/region aggregates for the day containing now
+func aggregateDailyStats(products []*model.Product, now time.Time) []model.DailyCategoryStats {
+	date := now.Format(dailyStatsDateFormat)
+
+	type bucket struct {
+		stats       model.DailyCategoryStats
+		priceSum    float64
+		discountSum float64
+	}
+	buckets := make(map[string]*bucket)
+
+	for _, p := range products {
+		key := p.Category + "|" + p.Region
+		b, ok := buckets[key]
+		if !ok {
+			b = &bucket{stats: model.DailyCategoryStats{Date: date, Category: p.Category, Region: p.Region}}
+			buckets[key] = b
+		}
+
+		if p.CreatedAt.Format(dailyStatsDateFormat) == date {
+			b.stats.NewListings++
+		}
+		if p.StockStatus == "sold_out" {
+			continue
+		}
+		b.stats.ProductCount++
+		b.priceSum += p.Price
+		b.discountSum += p.Discount
+	}
+
+	result := make([]model.DailyCategoryStats, 0, len(buckets))
+	for _, b := range buckets {
+		if b.stats.ProductCount > 0 {
+			b.stats.AvgPrice = b.priceSum / float64(b.stats.ProductCount)
+			b.stats.AvgDiscount = b.discountSum / float64(b.stats.ProductCount)
+		}
+		result = append(result, b.stats)
+	}
+
+	sortDailyStats(result)
+	return result
+}
+
+// aggregateStatsSnapshots computes today's snapshot of each region and of the
+// whole catalog (empty region). subscriptions counts price subscriptions per
+// product ID; the whole catalog snapshot counts all of them, including those of
+// products no longer listed.
+func aggregateStatsSnapshots(products []*model.Product, subscriptions map[string]int, now time.Time) []model.StatsSnapshot {
+	date := now.Format(dailyStatsDateFormat)
+
+	type bucket struct {
+		snapshot    model.StatsSnapshot
+		discountSum float64
+	}
+	buckets := map[string]*bucket{"": {snapshot: model.StatsSnapshot{Date: date, Categories: map[string]model.CategorySnapshot{}}}}
+	for _, n := range subscriptions {
+		buckets[""].snapshot.Subscriptions += n
+	}
+
+	for _, p := range products {
+		b, ok := buckets[p.Region]
+		if !ok {
+			b = &bucket{snapshot: model.StatsSnapshot{Date: date, Region: p.Region, Categories: map[string]model.CategorySnapshot{}}}
+			buckets[p.Region] = b
+		}
+		b.snapshot.Subscriptions += subscriptions[p.ID]
+
+		for _, b := range []*bucket{b, buckets[""]} {
+			s := &b.snapshot
+			category := s.Categories[p.Category]
+			s.TotalProducts++
+			if p.CreatedAt.Format(dailyStatsDateFormat) == date {
+				s.NewListings++
+				category.NewListings++
+			}
+			switch p.StockStatus {
+			case "sold_out":
+				s.SoldOutProducts++
+			case "available":
+				s.AvailableProducts++
+			}
+			if p.StockStatus != "sold_out" {
+				b.discountSum += p.Discount
+				category.Products++
+			}
+			s.Categories[p.Category] = category
+		}
+	}
+
+	result := make([]model.StatsSnapshot, 0, len(buckets))
+	for _, b := range buckets {
+		if listed := b.snapshot.TotalProducts - b.snapshot.SoldOutProducts; listed > 0 {
+			b.snapshot.AvgDiscount = b.discountSum / float64(listed)
+		}
+		result = append(result, b.snapshot)
+	}
+
+	sortStatsSnapshots(result)
+	return result
+}
+
+// sortStatsSnapshots orders snapshots by date, then region (all regions first)
+func sortStatsSnapshots(snapshots []model.StatsSnapshot) {
+	sort.Slice(snapshots, func(i, j int) bool {
+		if snapshots[i].Date != snapshots[j].Date {
+			return snapshots[i].Date < snapshots[j].Date
+		}
+		return snapshots[i].Region < snapshots[j].Region
+	})
+}
+
+// sortAnnotations orders annotations by date, then creation time
+func sortAnnotations(annotations []model.PriceAnnotation) {
+	sort.Slice(annotations, func(i, j int) bool {
+		if annotations[i].Date != annotations[j].Date {
+			return annotations[i].Date < annotations[j].Date
+		}
+		return annotations[i].CreatedAt.Before(annotations[j].CreatedAt)
+	})
+}
+
+// sortDailyStats orders points by date, then category and region
+func sortDailyStats(stats []model.DailyCategoryStats) {
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].Date != stats[j].Date {
+			return stats[i].Date < stats[j].Date
+		}
+		if stats[i].Category != stats[j].Category {
+			return stats[i].Category < stats[j].Category
+		}
+		return stats[i].Region < stats[j].Region
+	})
+}
+
+// aggregatePriceIndex computes today's average price and price per GB of storage
+// for each spec tier and region, over listings that are not sold out. Products
+// whose line, chip or storage can't be parsed are left out.
+func aggregatePriceIndex(products []*model.Product, now time.Time) []model.DailyPriceIndex {
+	date := now.Format(dailyStatsDateFormat)
+
+	type bucket struct {
+		point    model.DailyPriceIndex
+		priceSum float64
+		perGBSum float64
+	}
+	buckets := make(map[string]*bucket)
+
+	for _, p := range products {
+		if p.StockStatus == "sold_out" || p.Price <= 0 {
+			continue
+		}
+		combo := model.ParseSpecCombo(p.Name + " " + p.Specs)
+		tier, storage := combo.Tier(), combo.StorageGB()
+		if tier == "" || storage == 0 {
+			continue
+		}
+
+		key := tier + "|" + p.Region
+		b, ok := buckets[key]
+		if !ok {
+			b = &bucket{point: model.DailyPriceIndex{Date: date, Tier: tier, Region: p.Region}}
+			buckets[key] = b
+		}
+		b.point.Products++
+		b.priceSum += p.Price
+		b.perGBSum += p.Price / float64(storage)
+	}
+
+	result := make([]model.DailyPriceIndex, 0, len(buckets))
+	for _, b := range buckets {
+		b.point.AvgPrice = b.priceSum / float64(b.point.Products)
+		b.point.PricePerGB = b.perGBSum / float64(b.point.Products)
+		result = append(result, b.point)
+	}
+
+	sortPriceIndex(result)
+	return result
+}
+
+// sortPriceIndex orders points by date, then tier and region
+func sortPriceIndex(points []model.DailyPriceIndex) {
+	sort.Slice(points, func(i, j int) bool {
+		if points[i].Date != points[j].Date {
+			return points[i].Date < points[j].Date
+		}
+		if points[i].Tier != points[j].Tier {
+			return points[i].Tier < points[j].Tier
+		}
+		return points[i].Region < points[j].Region
+	})
+}
@@ -0,0 +1,225 @@
+package store
+
+import (
+	"encoding/json"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"apple-price/internal/model"
+)
+
+// usageFile holds the daily usage reports in the data directory
+const usageFile = "usage.json"
+
+// UsageSource provides the subscription counts sampled into the usage report
+type UsageSource interface {
+	GetAllSubscriptions() []*model.Subscription
+	GetAllNewArrivalSubscriptions() []*model.NewArrivalSubscription
+}
+
+// UsageRecorder aggregates anonymous usage counters (API requests, notifications,
+// subscription counts) into one report per day, kept in the data directory for
+// the operator. Nothing leaves the server.
+type UsageRecorder struct {
+	dataDir  string
+	source   UsageSource
+	days     map[string]*model.UsageDay // date -> counters
+	dirty    bool
+	mu       sync.Mutex
+	stopCh   chan struct{}
+	stopOnce sync.Once
+}
+
+// NewUsageRecorder creates a recorder for dataDir, picking up the reports of
+// earlier runs. source may be nil, in which case subscriptions are not counted.
+func NewUsageRecorder(dataDir string, source UsageSource) *UsageRecorder {
+	r := &UsageRecorder{
+		dataDir: dataDir,
+		source:  source,
+		days:    make(map[string]*model.UsageDay),
+		stopCh:  make(chan struct{}),
+	}
+
+	if data, err := os.ReadFile(filepath.Join(dataDir, usageFile)); err == nil {
+		var days []model.UsageDay
+		if err := json.Unmarshal(data, &days); err != nil {
+			slog.Warn("Failed to load usage report, starting over", "error", err)
+		}
+		for i := range days {
+			r.days[days[i].Date] = &days[i]
+		}
+	}
+
+	return r
+}
+
+// Start samples subscription counts and writes the report every interval until Stop is called
+func (r *UsageRecorder) Start(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				r.Flush()
+			case <-r.stopCh:
+				r.Flush()
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops periodic flushing after a final write
+func (r *UsageRecorder) Stop() {
+	r.stopOnce.Do(func() { close(r.stopCh) })
+}
+
+// RecordRequest counts an API request by route template (e.g. "GET /api/products/:id")
+func (r *UsageRecorder) RecordRequest(route string, status int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	day := r.todayLocked(time.Now())
+	day.APIRequests++
+	if status >= 500 {
+		day.APIErrors++
+	}
+	day.Routes[route]++
+	r.dirty = true
+}
+
+// RecordNotification counts a notification delivery attempt
+func (r *UsageRecorder) RecordNotification(notificationType, status string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	day := r.todayLocked(time.Now())
+	day.Notifications[status]++
+	day.NotificationTypes[notificationType]++
+	r.dirty = true
+}
+
+// Report returns the last days of usage (today included), newest first, with
+// today's subscription counts sampled now
+func (r *UsageRecorder) Report(days int) *model.UsageReport {
+	r.sampleSubscriptions()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	report := &model.UsageReport{GeneratedAt: now, Days: []model.UsageDay{}}
+	for i := 0; i < days; i++ {
+		date := now.AddDate(0, 0, -i).Format("2006-01-02")
+		if day, ok := r.days[date]; ok {
+			report.Days = append(report.Days, copyUsageDay(day))
+		}
+	}
+	return report
+}
+
+// Flush samples subscription counts, drops days past model.UsageRetentionDays
+// and writes the report if anything changed
+func (r *UsageRecorder) Flush() {
+	r.sampleSubscriptions()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	cutoff := time.Now().AddDate(0, 0, -model.UsageRetentionDays).Format("2006-01-02")
+	for date := range r.days {
+		if date <= cutoff {
+			delete(r.days, date)
+			r.dirty = true
+		}
+	}
+	if !r.dirty {
+		return
+	}
+
+	days := make([]*model.UsageDay, 0, len(r.days))
+	for _, day := range r.days {
+		days = append(days, day)
+	}
+	sort.Slice(days, func(i, j int) bool { return days[i].Date < days[j].Date })
+
+	data, err := json.MarshalIndent(days, "", "  ")
+	if err != nil {
+		slog.Error("Failed to marshal usage report", "error", err)
+		return
+	}
+	if err := writeFileAtomic(filepath.Join(r.dataDir, usageFile), data, 0644); err != nil {
+		slog.Error("Failed to write usage report", "error", err)
+		return
+	}
+	r.dirty = false
+}
+
+// sampleSubscriptions records the current subscription counts for today
+func (r *UsageRecorder) sampleSubscriptions() {
+	if r.source == nil {
+		return
+	}
+
+	subs := r.source.GetAllSubscriptions()
+	newArrival := r.source.GetAllNewArrivalSubscriptions()
+	keys := make(map[string]bool)
+	for _, sub := range subs {
+		keys[sub.BarkKey] = true
+	}
+	for _, sub := range newArrival {
+		keys[sub.BarkKey] = true
+	}
+	delete(keys, "")
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	day := r.todayLocked(time.Now())
+	if day.Subscriptions != len(subs) || day.NewArrivalSubscriptions != len(newArrival) || day.BarkKeys != len(keys) {
+		day.Subscriptions = len(subs)
+		day.NewArrivalSubscriptions = len(newArrival)
+		day.BarkKeys = len(keys)
+		r.dirty = true
+	}
+}
+
+// todayLocked returns the counters of now's day, creating them on first use.
+// Caller must hold r.mu.
+func (r *UsageRecorder) todayLocked(now time.Time) *model.UsageDay {
+	date := now.Format("2006-01-02")
+	day, ok := r.days[date]
+	if !ok {
+		day = &model.UsageDay{
+			Date:              date,
+			Routes:            make(map[string]int64),
+			Notifications:     make(map[string]int64),
+			NotificationTypes: make(map[string]int64),
+		}
+		r.days[date] = day
+	}
+	return day
+}
+
+// copyUsageDay copies a day's counters so callers can't race with recording
+func copyUsageDay(day *model.UsageDay) model.UsageDay {
+	copied := *day
+	copied.Routes = copyCounts(day.Routes)
+	copied.Notifications = copyCounts(day.Notifications)
+	copied.NotificationTypes = copyCounts(day.NotificationTypes)
+	return copied
+}
+
+func copyCounts(counts map[string]int64) map[string]int64 {
+	copied := make(map[string]int64, len(counts))
+	for k, v := range counts {
+		copied[k] = v
+	}
+	return copied
+}
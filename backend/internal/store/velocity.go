@@ -0,0 +1,33 @@
+package store
+
+import (
+	"sync"
+
+	"apple-price/internal/model"
+)
+
+// velocityCache keeps the inventory velocity index, which aggregates every
+// product event, until the next product change (ProductsVersion moved).
+// Product lists, details and notifications all read it.
+type velocityCache struct {
+	mu      sync.Mutex
+	loaded  bool
+	version uint64
+	index   model.InventoryVelocityIndex
+}
+
+// get returns the cached index, rebuilding it when version differs from the
+// one it was built at. Callers must not modify the index.
+func (c *velocityCache) get(version uint64, build func() model.InventoryVelocityIndex) model.InventoryVelocityIndex {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// version is read before building: a change landing mid-build bumps it
+	// again, so the next read rebuilds
+	if !c.loaded || version != c.version {
+		c.index = build()
+		c.version = version
+		c.loaded = true
+	}
+	return c.index
+}
@@ -2,6 +2,7 @@ import { useState, useEffect } from 'react'
 import Header, { SystemStatus } from './components/Header'
 import Home from './pages/Home'
 import NotificationModal from './components/NotificationModal'
+import { useBrowserNotifications } from './hooks/useBrowserNotifications'
 
 interface StatsResponse {
   total_products: number
@@ -17,6 +18,7 @@ function App() {
   const [isNotificationOpen, setIsNotificationOpen] = useState(false)
   const [categories, setCategories] = useState<string[]>([])
   const [systemStatus, setSystemStatus] = useState<SystemStatus | undefined>()
+  const browserNotifications = useBrowserNotifications()
 
   useEffect(() => {
     const fetchStatus = async () => {
@@ -42,6 +44,7 @@ function App() {
         productCount={filteredCount}
         onOpenNotifications={() => setIsNotificationOpen(true)}
         systemStatus={systemStatus}
+        browserNotifications={browserNotifications}
       />
       <Home
         onFilteredCountChange={setFilteredCount}
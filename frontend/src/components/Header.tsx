@@ -12,6 +12,7 @@ interface HeaderProps {
   productCount?: number;
   onOpenNotifications?: () => void;
   systemStatus?: SystemStatus;
+  browserNotifications?: { supported: boolean; enabled: boolean; toggle: () => void };
 }
 
 function formatLastUpdate(time: string): string {
@@ -41,6 +42,7 @@ export default function Header({
   productCount,
   onOpenNotifications,
   systemStatus,
+  browserNotifications,
 }: HeaderProps) {
   const getStatusColor = () => {
     if (!systemStatus) return "bg-gray-400";
@@ -106,6 +108,19 @@ export default function Header({
                 <span className="text-xs text-gray-400 ml-1">款产品</span>
               </div>
             )}
+            {browserNotifications?.supported && (
+              <button
+                onClick={browserNotifications.toggle}
+                title="页面打开期间，在浏览器中提醒上新、到货和降价"
+                className={`px-3 py-1.5 rounded-lg text-sm font-medium transition-colors ${
+                  browserNotifications.enabled
+                    ? "bg-[#0071E3]/10 text-[#0071E3]"
+                    : "text-gray-500 hover:bg-gray-100"
+                }`}
+              >
+                {browserNotifications.enabled ? "浏览器提醒已开启" : "浏览器提醒"}
+              </button>
+            )}
             <button
               onClick={onOpenNotifications}
               className="flex items-center gap-1.5 px-3 py-1.5 bg-[#0071E3] text-white rounded-lg text-sm font-medium hover:bg-[#0077ED] transition-colors"
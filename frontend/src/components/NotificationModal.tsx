@@ -27,6 +27,9 @@ interface NotificationHistoryItem {
   product_specs: string;
   status: "sent" | "failed";
   error_message?: string;
+  title?: string;
+  body?: string;
+  channel_params?: string;
   created_at: string;
 }
 
@@ -666,6 +669,14 @@ export default function NotificationModal({
                           <div className="text-xs text-gray-400 mt-1">
                             {new Date(h.created_at).toLocaleString("zh-CN")}
                           </div>
+                          {h.body && (
+                            <div className="text-xs text-gray-600 mt-1 bg-white p-2 rounded whitespace-pre-line">
+                              {h.title && (
+                                <div className="font-medium">{h.title}</div>
+                              )}
+                              {h.body}
+                            </div>
+                          )}
                           {h.error_message && (
                             <div className="text-xs text-red-500 mt-1 bg-red-50 p-2 rounded">
                               {h.error_message}
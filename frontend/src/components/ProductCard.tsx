@@ -3,6 +3,7 @@ import { parseSpecs } from "@/utils/product";
 
 interface ProductCardProps {
   product: Product;
+  highlighted?: boolean; // 通知链接指向的产品
 }
 
 // 从 description 中提取规格信息
@@ -83,7 +84,10 @@ function extractSpecsFromDescription(
   return specs;
 }
 
-export default function ProductCard({ product }: ProductCardProps) {
+export default function ProductCard({
+  product,
+  highlighted = false,
+}: ProductCardProps) {
   const specs = parseSpecs(product.specs_detail);
 
   // 如果 specs_detail 为空或信息不全，尝试从 description 中提取
@@ -187,10 +191,13 @@ export default function ProductCard({ product }: ProductCardProps) {
 
   return (
     <a
+      id={`product-${product.id}`}
       href={product.product_url}
       target="_blank"
       rel="noopener noreferrer"
-      className="block bg-white rounded-xl overflow-hidden hover:shadow-md transition-all duration-200 border border-gray-100 group"
+      className={`block bg-white rounded-xl overflow-hidden hover:shadow-md transition-all duration-200 border group ${
+        highlighted ? "border-[#0071E3] ring-2 ring-[#0071E3]/30" : "border-gray-100"
+      }`}
     >
       <div className="flex items-center gap-3 p-2.5">
         {/* Image */}
@@ -0,0 +1,101 @@
+import { useState, useEffect, useCallback } from 'react'
+
+const ENABLED_KEY = 'apple-price-browser-notifications'
+
+// Events worth a desktop notification; sold out / price rises stay silent
+const POLL_TYPES = 'listed,available,price_drop'
+
+interface PollEvent {
+  type: string
+  product_id: string
+  product_name: string
+  image_url?: string
+  price: number
+  old_price?: number
+}
+
+interface PollBatch {
+  until: number
+  events: PollEvent[]
+}
+
+function eventTitle(event: PollEvent): string {
+  switch (event.type) {
+    case 'listed':
+      return '新品上架'
+    case 'available':
+      return '到货提醒'
+    default:
+      return `降价 ¥${event.old_price} → ¥${event.price}`
+  }
+}
+
+function showNotification(event: PollEvent) {
+  const notification = new Notification(eventTitle(event), {
+    body: `${event.product_name} ¥${event.price}`,
+    icon: event.image_url,
+    tag: `${event.type}-${event.product_id}`,
+  })
+  notification.onclick = () => {
+    window.focus()
+    window.location.href = `/?product=${encodeURIComponent(event.product_id)}`
+  }
+}
+
+/**
+ * Desktop notifications straight from the browser, without Bark: long-polls
+ * /api/poll (works where WebSocket/SSE are blocked) while the page is open.
+ */
+export function useBrowserNotifications() {
+  const supported = typeof window !== 'undefined' && 'Notification' in window
+  const [enabled, setEnabled] = useState(() => {
+    try {
+      return supported && Notification.permission === 'granted' && localStorage.getItem(ENABLED_KEY) === 'true'
+    } catch {
+      return false
+    }
+  })
+
+  const toggle = useCallback(async () => {
+    if (!supported) return
+    let next = !enabled
+    if (next && Notification.permission !== 'granted') {
+      next = (await Notification.requestPermission()) === 'granted'
+    }
+    try {
+      localStorage.setItem(ENABLED_KEY, String(next))
+    } catch {
+      console.error('Failed to save notification preference')
+    }
+    setEnabled(next)
+  }, [enabled, supported])
+
+  useEffect(() => {
+    if (!enabled) return
+
+    const controller = new AbortController()
+    const poll = async () => {
+      let since: number | null = null
+      while (!controller.signal.aborted) {
+        try {
+          const params = new URLSearchParams({ types: POLL_TYPES })
+          if (since !== null) params.set('since', String(since))
+          const res = await fetch(`/api/poll?${params}`, { signal: controller.signal })
+          if (!res.ok) throw new Error(`poll failed: ${res.status}`)
+          const batch: PollBatch = await res.json()
+          batch.events.forEach(showNotification)
+          since = batch.until
+        } catch (err) {
+          if (controller.signal.aborted) return
+          console.error('Browser notification poll failed:', err)
+          await new Promise((resolve) => setTimeout(resolve, 10000))
+        }
+      }
+    }
+
+    poll()
+    return () => controller.abort()
+  }, [enabled])
+
+  return { supported, enabled, toggle }
+}
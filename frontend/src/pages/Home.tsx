@@ -187,6 +187,10 @@ export default function Home({
   const [colorFilter, setColorFilter] = useState<string>("全部");
   const [searchQuery, setSearchQuery] = useState<string>("");
   const [sortBy, setSortBy] = useState<string>("default");
+  // 通知跳转：?product=<id> 定位并高亮该产品
+  const [linkedProductId] = useState<string | null>(() =>
+    new URLSearchParams(window.location.search).get("product"),
+  );
   const [filterOptions, setFilterOptions] = useState<FilterOptions>({
     chips: [],
     storages: [],
@@ -551,6 +555,14 @@ export default function Home({
     filterConfig,
   ]);
 
+  // 产品加载后滚动到通知链接的产品
+  useEffect(() => {
+    if (!linkedProductId || !products) return;
+    document
+      .getElementById(`product-${linkedProductId}`)
+      ?.scrollIntoView({ behavior: "smooth", block: "center" });
+  }, [linkedProductId, products]);
+
   // 通知父组件筛选结果数量
   useEffect(() => {
     onFilteredCountChange?.(filteredProducts.length);
@@ -942,7 +954,11 @@ export default function Home({
         {filteredProducts.length > 0 ? (
           <div className="space-y-3">
             {filteredProducts.map((product) => (
-              <ProductCard key={product.id} product={product} />
+              <ProductCard
+                key={product.id}
+                product={product}
+                highlighted={product.id === linkedProductId}
+              />
             ))}
           </div>
         ) : (
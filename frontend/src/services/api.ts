@@ -20,8 +20,11 @@ export const api = {
     category?: string;
     sort?: string;
     order?: string;
-  }): Promise<{ count: number; products: Product[] }> {
-    const response = await axios.get(`${API_BASE}/products`, { params });
+  }): Promise<{ count: number; total: number; products: Product[] }> {
+    // The grid shows the whole catalog, so opt out of the default list limit
+    const response = await axios.get(`${API_BASE}/products`, {
+      params: { all: true, ...params },
+    });
     return response.data;
   },
 };